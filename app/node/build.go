@@ -37,10 +37,12 @@ import (
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/funcsvc"
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/usersvc"
 	"github.com/kwilteam/kwil-db/node/snapshotter"
+	"github.com/kwilteam/kwil-db/node/statetree"
 	"github.com/kwilteam/kwil-db/node/store"
 	"github.com/kwilteam/kwil-db/node/txapp"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 	"github.com/kwilteam/kwil-db/node/voting"
+	"github.com/kwilteam/kwil-db/node/wasm"
 )
 
 func buildServer(ctx context.Context, d *coreDependencies) *server {
@@ -75,6 +77,9 @@ func buildServer(ctx context.Context, d *coreDependencies) *server {
 	// accounts
 	accounts := buildAccountStore(ctx, d, db)
 
+	// wasm precompiles must be registered before the engine is built
+	buildWasmPrecompiles(ctx, d, db)
+
 	// eventstore, votestore
 	es, vs := buildVoteStore(ctx, d, closers) // ev, vs
 
@@ -95,6 +100,9 @@ func buildServer(ctx context.Context, d *coreDependencies) *server {
 	// BlockProcessor
 	bp := buildBlockProcessor(ctx, d, db, txApp, accounts, vs, snapshotStore, es, migrator, bs, mp)
 
+	// State tree, if the network has state proofs
+	stateTree := buildStateTree(ctx, d, bp, closers)
+
 	// Consensus
 	ce := buildConsensusEngine(ctx, d, db, mp, bs, bp)
 
@@ -106,13 +114,17 @@ func buildServer(ctx context.Context, d *coreDependencies) *server {
 
 	// RPC Services
 	rpcSvcLogger := d.logger.New("USER")
-	jsonRPCTxSvc := usersvc.NewService(db, e, node, bp, vs, migrator, rpcSvcLogger,
+	userSvcOpts := []usersvc.Opt{
 		usersvc.WithReadTxTimeout(time.Duration(d.cfg.DB.ReadTxTimeout)),
 		usersvc.WithPrivateMode(d.cfg.RPC.Private),
 		usersvc.WithChallengeExpiry(time.Duration(d.cfg.RPC.ChallengeExpiry)),
 		usersvc.WithChallengeRateLimit(d.cfg.RPC.ChallengeRateLimit),
-		usersvc.WithBlockAgeHealth(6*time.Duration(max(d.cfg.Consensus.ProposeTimeout, d.cfg.Consensus.EmptyBlockTimeout))),
-	)
+		usersvc.WithBlockAgeHealth(6 * time.Duration(max(d.cfg.Consensus.ProposeTimeout, d.cfg.Consensus.EmptyBlockTimeout))),
+	}
+	if stateTree != nil {
+		userSvcOpts = append(userSvcOpts, usersvc.WithStateProver(stateTree))
+	}
+	jsonRPCTxSvc := usersvc.NewService(db, e, node, bp, vs, migrator, rpcSvcLogger, userSvcOpts...)
 
 	rpcServerLogger := d.logger.New("RPC")
 	jsonRPCServer, err := rpcserver.NewServer(d.cfg.RPC.ListenAddress,
@@ -188,7 +200,13 @@ func initializeP2PService(ctx context.Context, d *coreDependencies, closers *clo
 		failBuild(err, "failed to create p2p service")
 	}
 
-	if err := p2pSvc.Start(ctx, d.cfg.P2P.BootNodes...); err != nil {
+	// In sentry mode, the sentries are the only peers we may connect to.
+	bootNodes := d.cfg.P2P.BootNodes
+	if len(d.cfg.P2P.SentryNodes) > 0 {
+		bootNodes = d.cfg.P2P.SentryNodes
+	}
+
+	if err := p2pSvc.Start(ctx, bootNodes...); err != nil {
 		p2pSvc.Close() // the stuff started in NewP2PService...
 		failBuild(err, "failed to start p2p service")
 	}
@@ -418,6 +436,29 @@ func buildMetaStore(ctx context.Context, db *pg.DB) {
 	}
 }
 
+func buildWasmPrecompiles(ctx context.Context, d *coreDependencies, db *pg.DB) {
+	err := wasm.InitializeStore(ctx, db)
+	if err != nil {
+		failBuild(err, "failed to initialize wasm precompile store")
+	}
+
+	for _, w := range d.genesisCfg.WasmPrecompiles {
+		if err = wasm.Register(ctx, w.Name, w.Module); err != nil {
+			failBuild(err, "failed to register genesis wasm precompile "+w.Name)
+		}
+	}
+
+	tx, err := db.BeginReadTx(ctx)
+	if err != nil {
+		failBuild(err, "failed to start transaction")
+	}
+	defer tx.Rollback(ctx)
+
+	if err = wasm.LoadModules(ctx, tx); err != nil {
+		failBuild(err, "failed to load wasm precompiles")
+	}
+}
+
 // service returns a common.Service with the given logger name
 func (c *coreDependencies) service(loggerName string) *common.Service {
 	signer := auth.GetNodeSigner(c.privKey)
@@ -453,6 +494,25 @@ func buildBlockProcessor(ctx context.Context, d *coreDependencies, db *pg.DB, tx
 	return bp
 }
 
+func buildStateTree(ctx context.Context, d *coreDependencies, bp *blockprocessor.BlockProcessor, closers *closeFuncs) *statetree.Tree {
+	if !d.genesisCfg.StateProofs {
+		return nil
+	}
+	tree, err := statetree.Open(config.StateTreeDir(d.rootDir), d.logger.New("STATETREE"))
+	if err != nil {
+		failBuild(err, "failed to open state tree")
+	}
+	closers.addCloser(tree.Close, "Closing state tree")
+
+	// A snapshot does not include the state tree, so it is rebuilt from the
+	// user tables if it is behind the restored database.
+	if err = bp.SetStateTree(ctx, tree); err != nil {
+		failBuild(err, "failed to set the state tree")
+	}
+
+	return tree
+}
+
 func buildMigrator(d *coreDependencies, ctx context.Context, db *pg.DB, accounts *accounts.Accounts, vs *voting.VoteStore) *migrations.Migrator {
 	migrationsDir := config.MigrationDir(d.rootDir)
 
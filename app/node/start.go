@@ -80,7 +80,11 @@ func StartCmd() *cobra.Command {
 			defer stopProfiler()
 
 			if cfg.Telemetry.Enable {
-				stopMetrics, err := metrics.StartOTEL(cmd.Context(), metrics.WithOTELEndpoint(cfg.Telemetry.OTLPEndpoint))
+				otelOpts := []metrics.OTELOption{metrics.WithOTELEndpoint(cfg.Telemetry.OTLPEndpoint)}
+				if cfg.Telemetry.TraceFile != "" {
+					otelOpts = append(otelOpts, metrics.WithOTELTraceFile(rootedPath(cfg.Telemetry.TraceFile, rootDir)))
+				}
+				stopMetrics, err := metrics.StartOTEL(cmd.Context(), otelOpts...)
 				if err != nil {
 					cmd.Usage()
 					return err
@@ -22,6 +22,7 @@ import (
 	"github.com/kwilteam/kwil-db/app/snapshot"
 	"github.com/kwilteam/kwil-db/app/utils"
 	"github.com/kwilteam/kwil-db/app/validator"
+	"github.com/kwilteam/kwil-db/app/wasm"
 	"github.com/kwilteam/kwil-db/app/whitelist"
 	"github.com/kwilteam/kwil-db/config"
 	_ "github.com/kwilteam/kwil-db/extensions" // a base location where all extensions can be registered
@@ -87,6 +88,7 @@ func RootCmd() *cobra.Command {
 	cmd.AddCommand(whitelist.WhitelistCmd())
 	cmd.AddCommand(block.NewBlockExecCmd())
 	cmd.AddCommand(migration.NewMigrationCmd())
+	cmd.AddCommand(wasm.NewWasmCmd())
 
 	cmd.AddCommand(setup.SetupCmd()) // only kinda needs merged config for `setup reset`
 
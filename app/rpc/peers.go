@@ -3,6 +3,8 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"strconv"
+	"time"
 
 	"github.com/kwilteam/kwil-db/app/shared/display"
 	types "github.com/kwilteam/kwil-db/core/types/admin"
@@ -10,13 +12,21 @@ import (
 )
 
 var (
-	peersLong = "The `peers` command retrieves and print a list of the node's peers, with their public information."
+	peersLong = "The `peers` command retrieves and print a list of the node's peers, with their public information." + `
+
+With ` + "`--scores`" + `, it instead prints the scores of peers that have misbehaved (e.g. served
+invalid blocks or timed out on requests) or provided useful responses, lowest score first.
+Peers whose score falls too low are temporarily banned.`
 
 	peersExample = `# Print a list of the node's peers
-kwild admin peers --rpcserver /tmp/kwild.socket`
+kwild admin peers --rpcserver /tmp/kwild.socket
+
+# Print the peer scores and bans
+kwild admin peers --scores`
 )
 
 func peersCmd() *cobra.Command {
+	var scores bool
 	var cmd = &cobra.Command{
 		Use:     "peers",
 		Short:   "Print a list of the node's peers, with their public information.",
@@ -30,6 +40,14 @@ func peersCmd() *cobra.Command {
 				return display.PrintErr(cmd, err)
 			}
 
+			if scores {
+				peerScores, err := client.PeerScores(ctx)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				return display.PrintCmd(cmd, &peerScoresMsg{scores: peerScores, cmd: cmd})
+			}
+
 			peers, err := client.Peers(ctx)
 			if err != nil {
 				return display.PrintErr(cmd, err)
@@ -39,7 +57,9 @@ func peersCmd() *cobra.Command {
 		},
 	}
 
+	cmd.Flags().BoolVar(&scores, "scores", false, "print the peer scores and bans instead of the connected peers")
 	BindRPCFlags(cmd)
+	display.BindTableFlags(cmd)
 
 	return cmd
 }
@@ -59,3 +79,38 @@ func (p *peersMsg) MarshalJSON() ([]byte, error) {
 func (p *peersMsg) MarshalText() ([]byte, error) {
 	return json.MarshalIndent(p.peers, "", "  ")
 }
+
+// peerScoresMsg is a wrapper around the []*types.PeerScore type that
+// implements the MsgFormatter interface.
+type peerScoresMsg struct {
+	scores []*types.PeerScore
+	cmd    *cobra.Command
+}
+
+var _ display.MsgFormatter = (*peerScoresMsg)(nil)
+
+func (p *peerScoresMsg) MarshalJSON() ([]byte, error) {
+	if p.scores == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(p.scores)
+}
+
+func (p *peerScoresMsg) MarshalText() ([]byte, error) {
+	var rows [][]string
+	for _, s := range p.scores {
+		var bannedUntil string
+		if s.BannedUntil > 0 {
+			bannedUntil = time.UnixMilli(s.BannedUntil).Format(time.RFC3339)
+		}
+		rows = append(rows, []string{
+			s.NodeID,
+			strconv.FormatFloat(s.Score, 'f', 1, 64),
+			strconv.Itoa(s.Bans),
+			bannedUntil,
+			strconv.FormatBool(s.Connected),
+		})
+	}
+
+	return display.FormatTable(p.cmd, []string{"Node ID", "Score", "Bans", "Banned Until", "Connected"}, rows)
+}
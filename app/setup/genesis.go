@@ -29,13 +29,18 @@ Validators and balance allocations should have the format "pubkey:power", "addre
 kwild setup genesis --out /path/to/directory --chain-id mychainid --validator 890fe7ae9cb1fa6177555d5651e1b8451b4a9c64021c876236c700bc2690ff1d:1
 
 # Create a new genesis.json with the specified allocation
-kwild setup genesis --alloc 0x7f5f4552091a69125d5dfcb7b8c2659029395bdf:100`
+kwild setup genesis --alloc 0x7f5f4552091a69125d5dfcb7b8c2659029395bdf:100
+
+# Create a new genesis.json with a WebAssembly precompile extension
+kwild setup genesis --wasm-precompile mymath=./mymath.wasm`
 )
 
 type genesisFlagConfig struct {
-	chainID    string
-	validators []string
-	allocs     []string
+	chainID     string
+	validators  []string
+	allocs      []string
+	wasm        []string
+	stateProofs bool
 	networkParams
 }
 
@@ -46,6 +51,19 @@ type networkParams struct {
 	maxBlockSize  int64
 	joinExpiry    time.Duration
 	maxVotesPerTx int64
+	nonceWindow   int64
+
+	equivocationJailBlocks   int64
+	equivocationSlashPercent int64
+	equivocationBurnPercent  int64
+	maxEvidenceAge           int64
+	stakePowerUnit           string
+	unbondingBlocks          int64
+	stakeBlockReward         string
+	feeLeaderPercent         int64
+	feeValidatorPercent      int64
+	feeTreasuryPercent       int64
+	treasury                 string
 }
 
 func GenesisCmd() *cobra.Command {
@@ -132,6 +150,8 @@ func bindGenesisFlags(cmd *cobra.Command, cfg *genesisFlagConfig) {
 	cmd.Flags().StringVar(&cfg.chainID, chainIDFlag, "", "chainID for the genesis.json file")
 	cmd.Flags().StringSliceVar(&cfg.validators, validatorsFlag, nil, "public key, keyType and power of initial validator(s), may be specified multiple times") // accept: [hexpubkey1#keyType1:power1]
 	cmd.Flags().StringSliceVar(&cfg.allocs, allocsFlag, nil, "address and initial balance allocation(s) in the format id#keyType:amount")
+	cmd.Flags().StringSliceVar(&cfg.wasm, wasmFlag, nil, "WebAssembly precompile extension(s) in the format name=path/to/module.wasm")
+	cmd.Flags().BoolVar(&cfg.stateProofs, stateProofsFlag, false, "maintain a Merkle tree of all table rows in the app hash, so that query results can be proven")
 	bindNetworkParamsFlags(cmd, &cfg.networkParams)
 }
 
@@ -142,18 +162,45 @@ func bindNetworkParamsFlags(cmd *cobra.Command, cfg *networkParams) {
 	cmd.Flags().Int64Var(&cfg.maxBlockSize, maxBlockSizeFlag, 0, "maximum block size")
 	cmd.Flags().DurationVar(&cfg.joinExpiry, joinExpiryFlag, 0, "Number of blocks before a join proposal expires")
 	cmd.Flags().Int64Var(&cfg.maxVotesPerTx, maxVotesPerTxFlag, 0, "Maximum votes per transaction")
+	cmd.Flags().Int64Var(&cfg.nonceWindow, nonceWindowFlag, 0, "Number of nonces a transaction may skip ahead of an account's next nonce (0 for strictly sequential nonces)")
+	cmd.Flags().Int64Var(&cfg.equivocationJailBlocks, equivocationJailBlocksFlag, 0, "Number of blocks an equivocating validator is removed from the validator set (0 disables jailing)")
+	cmd.Flags().Int64Var(&cfg.equivocationSlashPercent, equivocationSlashPercentFlag, 0, "Percentage of an equivocating validator's power that is removed")
+	cmd.Flags().Int64Var(&cfg.equivocationBurnPercent, equivocationBurnPercentFlag, 0, "Percentage of an equivocating validator's account balance that is burned")
+	cmd.Flags().Int64Var(&cfg.maxEvidenceAge, maxEvidenceAgeFlag, 0, "Number of blocks after an equivocation during which evidence of it may be submitted (0 allows any age)")
+	cmd.Flags().StringVar(&cfg.stakePowerUnit, stakePowerUnitFlag, "", "Bonded stake per unit of validator power (unset disables staking)")
+	cmd.Flags().Int64Var(&cfg.unbondingBlocks, unbondingBlocksFlag, 0, "Number of blocks before undelegated stake is returned")
+	cmd.Flags().StringVar(&cfg.stakeBlockReward, stakeBlockRewardFlag, "", "Amount distributed to stakers each block")
+	cmd.Flags().Int64Var(&cfg.feeLeaderPercent, feeLeaderPercentFlag, 0, "Percentage of a block's fees credited to the block proposer")
+	cmd.Flags().Int64Var(&cfg.feeValidatorPercent, feeValidatorPercentFlag, 0, "Percentage of a block's fees distributed to the validators, or their stakers if staking is enabled")
+	cmd.Flags().Int64Var(&cfg.feeTreasuryPercent, feeTreasuryPercentFlag, 0, "Percentage of a block's fees credited to the treasury account")
+	cmd.Flags().StringVar(&cfg.treasury, treasuryFlag, "", "Treasury account, as 0x<address> or <key#keyType> (fees not distributed are burned)")
 }
 
 const (
 	chainIDFlag       = "chain-id"
 	validatorsFlag    = "validator"
 	allocsFlag        = "alloc"
+	wasmFlag          = "wasm-precompile"
+	stateProofsFlag   = "state-proofs"
 	withGasFlag       = "with-gas"
 	leaderFlag        = "leader"
 	dbOwnerFlag       = "db-owner"
 	maxBlockSizeFlag  = "max-block-size"
 	joinExpiryFlag    = "join-expiry"
 	maxVotesPerTxFlag = "max-votes-per-tx"
+	nonceWindowFlag   = "nonce-window"
+
+	equivocationJailBlocksFlag   = "equivocation-jail-blocks"
+	equivocationSlashPercentFlag = "equivocation-slash-percent"
+	equivocationBurnPercentFlag  = "equivocation-burn-percent"
+	maxEvidenceAgeFlag           = "max-evidence-age"
+	stakePowerUnitFlag           = "stake-power-unit"
+	unbondingBlocksFlag          = "unbonding-blocks"
+	stakeBlockRewardFlag         = "stake-block-reward"
+	feeLeaderPercentFlag         = "fee-leader-percent"
+	feeValidatorPercentFlag      = "fee-validator-percent"
+	feeTreasuryPercentFlag       = "fee-treasury-percent"
+	treasuryFlag                 = "treasury"
 )
 
 // mergeGenesisFlags merges the genesis configuration flags with the given configuration.
@@ -216,6 +263,30 @@ func mergeGenesisFlags(conf *config.GenesisConfig, cmd *cobra.Command, flagCfg *
 		conf.Allocs = append(conf.Allocs, allocs...)
 	}
 
+	if cmd.Flags().Changed(wasmFlag) {
+		conf.WasmPrecompiles = nil
+		for _, w := range flagCfg.wasm {
+			name, path, ok := strings.Cut(w, "=")
+			if !ok || name == "" || path == "" {
+				return nil, fmt.Errorf("invalid format for wasm precompile, expected name=path, received: %s", w)
+			}
+
+			module, err := os.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read wasm precompile %s: %w", name, err)
+			}
+
+			conf.WasmPrecompiles = append(conf.WasmPrecompiles, config.WasmPrecompile{
+				Name:   name,
+				Module: module,
+			})
+		}
+	}
+
+	if cmd.Flags().Changed(stateProofsFlag) {
+		conf.StateProofs = flagCfg.stateProofs
+	}
+
 	return mergeNetworkParamFlags(conf, cmd, &flagCfg.networkParams)
 }
 
@@ -305,5 +376,86 @@ func mergeNetworkParamFlags(conf *config.GenesisConfig, cmd *cobra.Command, flag
 		conf.MaxVotesPerTx = flagCfg.maxVotesPerTx
 	}
 
+	if cmd.Flags().Changed(nonceWindowFlag) {
+		conf.NonceWindow = flagCfg.nonceWindow
+	}
+
+	if cmd.Flags().Changed(equivocationJailBlocksFlag) {
+		conf.EquivocationJailBlocks = flagCfg.equivocationJailBlocks
+	}
+
+	if cmd.Flags().Changed(equivocationSlashPercentFlag) {
+		conf.EquivocationSlashPercent = flagCfg.equivocationSlashPercent
+	}
+
+	if cmd.Flags().Changed(equivocationBurnPercentFlag) {
+		conf.EquivocationBurnPercent = flagCfg.equivocationBurnPercent
+	}
+
+	if cmd.Flags().Changed(maxEvidenceAgeFlag) {
+		conf.MaxEvidenceAge = flagCfg.maxEvidenceAge
+	}
+
+	if cmd.Flags().Changed(stakePowerUnitFlag) {
+		unit, ok := new(big.Int).SetString(flagCfg.stakePowerUnit, 10)
+		if !ok || unit.Sign() < 0 {
+			return nil, fmt.Errorf("invalid stake power unit: %s", flagCfg.stakePowerUnit)
+		}
+		conf.StakePowerUnit = unit
+	}
+
+	if cmd.Flags().Changed(unbondingBlocksFlag) {
+		conf.UnbondingBlocks = flagCfg.unbondingBlocks
+	}
+
+	if cmd.Flags().Changed(stakeBlockRewardFlag) {
+		reward, ok := new(big.Int).SetString(flagCfg.stakeBlockReward, 10)
+		if !ok || reward.Sign() < 0 {
+			return nil, fmt.Errorf("invalid stake block reward: %s", flagCfg.stakeBlockReward)
+		}
+		conf.StakeBlockReward = reward
+	}
+
+	if cmd.Flags().Changed(feeLeaderPercentFlag) {
+		conf.FeeLeaderPercent = flagCfg.feeLeaderPercent
+	}
+
+	if cmd.Flags().Changed(feeValidatorPercentFlag) {
+		conf.FeeValidatorPercent = flagCfg.feeValidatorPercent
+	}
+
+	if cmd.Flags().Changed(feeTreasuryPercentFlag) {
+		conf.FeeTreasuryPercent = flagCfg.feeTreasuryPercent
+	}
+
+	if cmd.Flags().Changed(treasuryFlag) {
+		treasury, err := parseAccountID(flagCfg.treasury)
+		if err != nil {
+			return nil, fmt.Errorf("invalid treasury: %w", err)
+		}
+		conf.Treasury = treasury
+	}
+
 	return conf, nil
 }
+
+// parseAccountID parses an account as 0x<address> for an ethereum address, or
+// as <key#keyType>.
+func parseAccountID(s string) (*types.AccountID, error) {
+	if addr, ok := strings.CutPrefix(s, "0x"); ok {
+		id, err := hex.DecodeString(addr)
+		if err != nil || len(id) == 0 {
+			return nil, fmt.Errorf("invalid hex address: %s", s)
+		}
+		return &types.AccountID{Identifier: id, KeyType: crypto.KeyTypeSecp256k1}, nil
+	}
+
+	id, keyType, err := config.DecodePubKeyAndType(s)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = crypto.ParseKeyType(string(keyType)); err != nil {
+		return nil, fmt.Errorf("invalid key type: %s", keyType)
+	}
+	return &types.AccountID{Identifier: id, KeyType: keyType}, nil
+}
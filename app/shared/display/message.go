@@ -187,5 +187,20 @@ Height: %d`,
 		msg += "\n  " + strings.ReplaceAll(r.Result.Log, "\n", "\n  ")
 	}
 
+	// batch transactions have a result for each payload that was executed
+	if r.Result != nil && len(r.Result.SubResults) > 0 {
+		msg += "\nBatch Results:"
+		for i, sr := range r.Result.SubResults {
+			status := "success"
+			if sr.Code != uint32(types.CodeOk) {
+				status = fmt.Sprintf("failed (code %d)", sr.Code)
+			}
+			msg += fmt.Sprintf("\n  %d: %s", i, status)
+			if sr.Log != "" {
+				msg += "\n    " + strings.ReplaceAll(sr.Log, "\n", "\n    ")
+			}
+		}
+	}
+
 	return msg
 }
@@ -279,6 +279,23 @@ func TestRespTxQuery_MarshalText(t *testing.T) {
 			},
 			expected: "Transaction ID: 0300000000000000000000000000000000000000000000000000000000000000\nStatus: pending\nHeight: -1\nLogs:\n  transaction pending",
 		},
+		{
+			name: "batch results",
+			input: &RespTxQuery{
+				Msg: &types.TxQueryResponse{
+					Hash:   types.Hash{0x4},
+					Height: 60,
+					Result: &types.TxResult{
+						Code: uint32(types.CodeInsufficientBalance),
+						SubResults: []types.SubResult{
+							{Code: uint32(types.CodeOk), Log: "post created"},
+							{Code: uint32(types.CodeInsufficientBalance)},
+						},
+					},
+				},
+			},
+			expected: "Transaction ID: 0400000000000000000000000000000000000000000000000000000000000000\nStatus: failed\nHeight: 60\nBatch Results:\n  0: success\n    post created\n  1: failed (code 6)",
+		},
 		{
 			name: "pending status",
 			input: &RespTxQuery{
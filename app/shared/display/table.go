@@ -2,6 +2,7 @@ package display
 
 import (
 	"bytes"
+	"encoding/csv"
 	"fmt"
 
 	"github.com/olekukonko/tablewriter"
@@ -29,6 +30,20 @@ func FormatTable(cmd *cobra.Command, columns []string, rows [][]string) ([]byte,
 	return recordsToTable(columns, rows, c), nil
 }
 
+// FormatCSV formats the table with the given columns and rows as CSV, with the
+// columns in the first record.
+func FormatCSV(columns []string, rows [][]string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write(columns); err != nil {
+		return nil, err
+	}
+	if err := w.WriteAll(rows); err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
 // BindTableFlags binds the flags to the table config
 func BindTableFlags(cmd *cobra.Command) {
 	cmd.Flags().IntP("width", "w", 0, "Set the width of the table columns. Text beyond this width will be wrapped.")
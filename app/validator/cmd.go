@@ -25,6 +25,10 @@ func NewValidatorsCmd() *cobra.Command {
 		leaveCmd(),
 		listJoinRequestsCmd(),
 		promoteCmd(),
+		stakeCmd(),
+		delegateCmd(),
+		undelegateCmd(),
+		stakesCmd(),
 	)
 
 	rpc.BindRPCFlags(validatorsCmd)
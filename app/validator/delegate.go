@@ -0,0 +1,55 @@
+package validator
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/rpc"
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/config"
+)
+
+var (
+	delegateLong = "The `delegate` command bonds an amount from this node's account to a validator, adding to the validator's power. The validator must have bonded stake of its own. Delegated stake earns a share of the block rewards in proportion to its amount."
+
+	delegateExample = `# Delegate 500 to a validator, given in format <hexPubkey#pubkeytype>
+kwild validators delegate e16141e4def3a7f2dfc5bbf40d50619b4d7bc9c9f670fcad98327b0d3d7b97b6#0 500`
+)
+
+func delegateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "delegate <validator> <amount>",
+		Short:   "Delegate stake from this node's account to a validator.",
+		Long:    delegateLong,
+		Example: delegateExample,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			validatorBts, valKeyType, err := config.DecodePubKeyAndType(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			amount, err := parseStakeAmount(args[1])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			clt, err := rpc.AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			txHash, err := clt.Delegate(ctx, validatorBts, valKeyType, amount)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespTxHash(txHash))
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,60 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"math/big"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/rpc"
+	"github.com/kwilteam/kwil-db/app/shared/display"
+)
+
+var (
+	stakeLong = "The `stake` command bonds an amount from this node's account to its own validator. When staking is enabled by the `stake_power_unit` network parameter, a validator's power is its total bonded stake divided by the power unit. The node must already be a validator, and its first stake must be at least one power unit."
+
+	stakeExample = `# Bond 1000 from this node's account to its validator
+kwild validators stake 1000`
+)
+
+func stakeCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "stake <amount>",
+		Short:   "Bond stake from this node's account to its own validator.",
+		Long:    stakeLong,
+		Example: stakeExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			amount, err := parseStakeAmount(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			clt, err := rpc.AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			txHash, err := clt.Stake(ctx, amount)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespTxHash(txHash))
+		},
+	}
+
+	return cmd
+}
+
+// parseStakeAmount parses a positive decimal amount of stake.
+func parseStakeAmount(s string) (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(s, 10)
+	if !ok || amount.Sign() <= 0 {
+		return nil, errors.New("amount must be a positive integer")
+	}
+	return amount, nil
+}
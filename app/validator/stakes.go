@@ -0,0 +1,91 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/rpc"
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	adminTypes "github.com/kwilteam/kwil-db/core/types/admin"
+)
+
+var (
+	stakesLong = `List the stake bonded to validators, and the undelegated stake that is unbonding with the height at which it is returned.`
+
+	stakesExample = `# List the bonded and unbonding stake
+kwild validators stakes`
+)
+
+func stakesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "stakes",
+		Short:   "List the stake bonded to validators and the stake that is unbonding.",
+		Long:    stakesLong,
+		Example: stakesExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			clt, err := rpc.AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			stakes, unbonding, err := clt.ListStakes(ctx)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, &respStakes{Stakes: stakes, Unbonding: unbonding, cmd: cmd})
+		},
+	}
+
+	display.BindTableFlags(cmd)
+	return cmd
+}
+
+// respStakes represents the bonded and unbonding stake in cli
+type respStakes struct {
+	Stakes    []*adminTypes.Stake
+	Unbonding []*adminTypes.Unbonding
+	cmd       *cobra.Command
+}
+
+func (r *respStakes) MarshalJSON() ([]byte, error) {
+	stakes, unbonding := r.Stakes, r.Unbonding
+	if stakes == nil {
+		stakes = []*adminTypes.Stake{}
+	}
+	if unbonding == nil {
+		unbonding = []*adminTypes.Unbonding{}
+	}
+	return json.Marshal(struct {
+		Stakes    []*adminTypes.Stake     `json:"stakes"`
+		Unbonding []*adminTypes.Unbonding `json:"unbonding"`
+	}{stakes, unbonding})
+}
+
+func (r *respStakes) MarshalText() ([]byte, error) {
+	var rows [][]string
+	for _, s := range r.Stakes {
+		rows = append(rows, []string{
+			s.Validator.PrettyString(),
+			s.Delegator.PrettyString(),
+			s.Amount,
+			"",
+		})
+	}
+	for _, u := range r.Unbonding {
+		rows = append(rows, []string{
+			"(unbonding)",
+			u.Delegator.PrettyString(),
+			u.Amount,
+			strconv.FormatInt(u.Height, 10),
+		})
+	}
+
+	return display.FormatTable(r.cmd, []string{"Validator", "Delegator", "Amount", "Release Height"}, rows)
+}
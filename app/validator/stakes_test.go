@@ -0,0 +1,37 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/types"
+	adminTypes "github.com/kwilteam/kwil-db/core/types/admin"
+)
+
+func Test_respStakes_MarshalJSON(t *testing.T) {
+	val := types.AccountID{Identifier: []byte{0x12, 0x34}, KeyType: crypto.KeyTypeEd25519}
+	del := types.AccountID{Identifier: []byte{0xAB, 0xCD}, KeyType: crypto.KeyTypeSecp256k1}
+
+	resp := &respStakes{
+		Stakes: []*adminTypes.Stake{
+			{Validator: val, Delegator: val, Amount: "300"},
+			{Validator: val, Delegator: del, Amount: "100"},
+		},
+		Unbonding: []*adminTypes.Unbonding{
+			{Delegator: del, Height: 42, Amount: "50"},
+		},
+	}
+
+	got, err := resp.MarshalJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"stakes":[`+
+		`{"validator":{"identifier":"1234","key_type":"ed25519"},"delegator":{"identifier":"1234","key_type":"ed25519"},"amount":"300"},`+
+		`{"validator":{"identifier":"1234","key_type":"ed25519"},"delegator":{"identifier":"abcd","key_type":"secp256k1"},"amount":"100"}],`+
+		`"unbonding":[{"delegator":{"identifier":"abcd","key_type":"secp256k1"},"height":42,"amount":"50"}]}`, string(got))
+
+	got, err = (&respStakes{}).MarshalJSON()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"stakes":[],"unbonding":[]}`, string(got))
+}
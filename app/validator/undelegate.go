@@ -0,0 +1,55 @@
+package validator
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/rpc"
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/config"
+)
+
+var (
+	undelegateLong = "The `undelegate` command unbonds an amount of this node's stake with a validator. A validator unbonds its own stake by giving its own public key. The validator's power is reduced immediately, and the amount is returned to this node's account after the `unbonding_blocks` network parameter."
+
+	undelegateExample = `# Unbond 500 of the stake delegated to a validator, given in format <hexPubkey#pubkeytype>
+kwild validators undelegate e16141e4def3a7f2dfc5bbf40d50619b4d7bc9c9f670fcad98327b0d3d7b97b6#0 500`
+)
+
+func undelegateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "undelegate <validator> <amount>",
+		Short:   "Unbond this node's stake with a validator.",
+		Long:    undelegateLong,
+		Example: undelegateExample,
+		Args:    cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			validatorBts, valKeyType, err := config.DecodePubKeyAndType(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			amount, err := parseStakeAmount(args[1])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			clt, err := rpc.AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			txHash, err := clt.Undelegate(ctx, validatorBts, valKeyType, amount)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespTxHash(txHash))
+		},
+	}
+
+	return cmd
+}
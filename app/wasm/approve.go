@@ -0,0 +1,50 @@
+package wasm
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/rpc"
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/wasm"
+)
+
+func approveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "approve <proposal_id>",
+		Short:   "Approve a WebAssembly precompile proposal.",
+		Example: "wasm approve <proposal_id>",
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			clt, err := rpc.AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			proposalID, err := types.ParseUUID(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			resStat, err := clt.ResolutionStatus(ctx, proposalID)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			if resStat.Type != wasm.PrecompileResolutionType {
+				return display.PrintErr(cmd, fmt.Errorf("proposal is not a wasm precompile proposal, is %v", resStat.Type))
+			}
+
+			txHash, err := clt.ApproveResolution(ctx, proposalID)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, display.RespTxHash(txHash))
+		},
+	}
+
+	return cmd
+}
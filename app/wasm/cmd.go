@@ -0,0 +1,23 @@
+package wasm
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/rpc"
+)
+
+var wasmCmd = &cobra.Command{
+	Use:   "wasm",
+	Short: "Functions for dealing with WebAssembly precompile proposals",
+	Long:  "The wasm command provides functions for proposing and approving WebAssembly precompile extensions.",
+}
+
+func NewWasmCmd() *cobra.Command {
+	wasmCmd.AddCommand(
+		proposeCmd(),
+		approveCmd(),
+	)
+
+	rpc.BindRPCFlags(wasmCmd)
+	return wasmCmd
+}
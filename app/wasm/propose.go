@@ -0,0 +1,83 @@
+package wasm
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/rpc"
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/wasm"
+)
+
+var (
+	proposeLong = `Submit a proposal to add a WebAssembly precompile extension to the network.
+
+The module is compiled locally before it is proposed, so that an invalid module
+is reported without creating a resolution. Once approved by the validators, the
+precompile can be used with ` + "`USE <name> AS <alias>`" + `.`
+
+	proposeExample = `# Propose the module in mymath.wasm as the "mymath" precompile
+kwild wasm propose --name mymath --file ./mymath.wasm`
+)
+
+func proposeCmd() *cobra.Command {
+	var name, file string
+
+	cmd := &cobra.Command{
+		Use:     "propose",
+		Short:   "Submit a WebAssembly precompile proposal.",
+		Long:    proposeLong,
+		Example: proposeExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := cmd.Context()
+			if name == "" {
+				return display.PrintErr(cmd, errors.New("name must not be empty"))
+			}
+
+			code, err := os.ReadFile(file)
+			if err != nil {
+				return display.PrintErr(cmd, fmt.Errorf("failed to read module: %w", err))
+			}
+
+			mod, err := wasm.Compile(ctx, name, code, wasm.DefaultLimits)
+			if err != nil {
+				return display.PrintErr(cmd, fmt.Errorf("invalid module: %w", err))
+			}
+			mod.Close(ctx)
+
+			clt, err := rpc.AdminSvcClient(ctx, cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			proposalBts, err := wasm.PrecompileDeclaration{
+				Name:   name,
+				Module: code,
+			}.MarshalBinary()
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			txHash, err := clt.CreateResolution(ctx, proposalBts, wasm.PrecompileResolutionType)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, &display.RespResolutionBroadcast{
+				TxHash: txHash,
+				ID:     types.VotableEventID(wasm.PrecompileResolutionType, proposalBts),
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&name, "name", "n", "", "Name of the precompile.")
+	cmd.Flags().StringVarP(&file, "file", "f", "", "Path to the WebAssembly module.")
+	cmd.MarkFlagRequired("file")
+
+	return cmd
+}
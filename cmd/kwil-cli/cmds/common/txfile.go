@@ -0,0 +1,50 @@
+package common
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// ReadTxFile reads a JSON transaction file, as written by WriteJSONFile. Such
+// files are used to pass a transaction between parties before it is broadcast,
+// such as the members of a multisig account or a fee sponsor. The file may
+// instead contain the hexadecimal serialized transaction, as decoded by the
+// utils decode-tx command.
+func ReadTxFile(path string) (*types.Transaction, error) {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	bts = bytes.TrimSpace(bts)
+
+	var tx types.Transaction
+	if len(bts) > 0 && bts[0] != '{' {
+		raw, err := hex.DecodeString(string(bytes.TrimPrefix(bts, []byte("0x"))))
+		if err != nil {
+			return nil, fmt.Errorf("invalid transaction file: not JSON or hexadecimal: %w", err)
+		}
+		if err = tx.UnmarshalBinary(raw); err != nil {
+			return nil, fmt.Errorf("invalid serialized transaction: %w", err)
+		}
+	} else if err = json.Unmarshal(bts, &tx); err != nil {
+		return nil, fmt.Errorf("invalid transaction file: %w", err)
+	}
+	if tx.Body == nil {
+		return nil, fmt.Errorf("transaction file %s has no body", path)
+	}
+	return &tx, nil
+}
+
+// WriteJSONFile writes v to the file as indented JSON.
+func WriteJSONFile(path string, v any) error {
+	bts, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(bts, '\n'), 0644)
+}
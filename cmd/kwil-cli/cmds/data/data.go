@@ -0,0 +1,101 @@
+// Package data contains the kwil-cli commands that import and export the rows
+// of tables in bulk.
+package data
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var dataLong = `Import and export the rows of tables in bulk.
+
+Rows are imported from CSV, JSONL, and Parquet files into a table in chunks,
+with one INSERT of many rows per transaction, and exported from a table or
+query to CSV or JSONL files.`
+
+func NewCmdData() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "data",
+		Short: "Import and export the rows of tables in bulk.",
+		Long:  dataLong,
+	}
+
+	cmd.AddCommand(
+		importCmd(),
+		exportCmd(),
+	)
+
+	return cmd
+}
+
+// The formats of the files that rows are imported from and exported to.
+const (
+	formatCSV     = "csv"
+	formatJSONL   = "jsonl"
+	formatParquet = "parquet"
+)
+
+// fileFormat returns the format of a file, which is the given format, or
+// inferred from the extension of the file if none is given.
+func fileFormat(file, format string, supported ...string) (string, error) {
+	if format == "" {
+		format = strings.TrimPrefix(strings.ToLower(filepath.Ext(file)), ".")
+		if format == "ndjson" {
+			format = formatJSONL
+		}
+		if format == "" {
+			return "", fmt.Errorf("cannot infer the format of %s, use --format", file)
+		}
+	}
+
+	format = strings.ToLower(format)
+	for _, f := range supported {
+		if format == f {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf(`unsupported format "%s", must be one of %s`, format, strings.Join(supported, ", "))
+}
+
+// column is a column of a table.
+type column struct {
+	Name       string
+	Type       *types.DataType
+	PrimaryKey bool
+}
+
+// loadColumns reads the columns of a table from the info namespace of a node.
+func loadColumns(ctx context.Context, cl clientType.Client, namespace, table string) ([]*column, error) {
+	res, err := cl.Query(ctx, `{info}SELECT name, data_type, is_primary_key FROM columns
+	WHERE namespace = $namespace AND table_name = $table`, map[string]any{"namespace": namespace, "table": table}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var cols []*column
+	var name, dataType string
+	var pk bool
+	err = res.Scan(func() error {
+		dt, err := types.ParseDataType(dataType)
+		if err != nil {
+			return fmt.Errorf(`column "%s": %w`, name, err)
+		}
+		cols = append(cols, &column{Name: name, Type: dt, PrimaryKey: pk})
+		return nil
+	}, &name, &dataType, &pk)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(cols) == 0 {
+		return nil, fmt.Errorf(`table "%s" not found in namespace "%s"`, table, namespace)
+	}
+	return cols, nil
+}
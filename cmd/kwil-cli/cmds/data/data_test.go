@@ -0,0 +1,352 @@
+package data
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+// writeFile writes a file in a temporary directory and opens it.
+func writeFile(t *testing.T, name, content string) *os.File {
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { f.Close() })
+	return f
+}
+
+// readAll reads all rows of a reader.
+func readAll(t *testing.T, r rowReader) [][]any {
+	var rows [][]any
+	for {
+		row, err := r.Read()
+		if errors.Is(err, io.EOF) {
+			return rows
+		}
+		require.NoError(t, err)
+		rows = append(rows, row)
+	}
+}
+
+func Test_rowReaders(t *testing.T) {
+	t.Run("csv", func(t *testing.T) {
+		f := writeFile(t, "users.csv", "id, name,tags\n1,Alice,\"[\"\"a\"\"]\"\n2,\\N,[]\n")
+		r, err := newRowReader(f, formatCSV, `\N`)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id", "name", "tags"}, r.Columns())
+		assert.Equal(t, [][]any{{"1", "Alice", `["a"]`}, {"2", nil, "[]"}}, readAll(t, r))
+	})
+
+	t.Run("empty csv", func(t *testing.T) {
+		_, err := newRowReader(writeFile(t, "empty.csv", ""), formatCSV, "")
+		require.Error(t, err)
+	})
+
+	t.Run("jsonl", func(t *testing.T) {
+		f := writeFile(t, "users.jsonl", `{"name": "Alice", "id": 1}
+{"id": 2, "name": null}
+`)
+		r, err := newRowReader(f, formatJSONL, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id", "name"}, r.Columns())
+		rows := readAll(t, r)
+		assert.Len(t, rows, 2)
+		assert.Equal(t, json.Number("1"), rows[0][0])
+		assert.Equal(t, "Alice", rows[0][1])
+		assert.Nil(t, rows[1][1])
+	})
+
+	t.Run("jsonl with new key", func(t *testing.T) {
+		f := writeFile(t, "users.jsonl", "{\"id\": 1}\n{\"id\": 2, \"name\": \"Bob\"}\n")
+		r, err := newRowReader(f, formatJSONL, "")
+		require.NoError(t, err)
+		_, err = r.Read()
+		require.NoError(t, err)
+		_, err = r.Read()
+		require.ErrorContains(t, err, "name")
+	})
+
+	t.Run("parquet", func(t *testing.T) {
+		type user struct {
+			ID   int64    `parquet:"id"`
+			Name string   `parquet:"name"`
+			Data []byte   `parquet:"data"`
+			Tags []string `parquet:"tags,list"`
+		}
+		path := filepath.Join(t.TempDir(), "users.parquet")
+		require.NoError(t, parquet.WriteFile(path, []user{
+			{ID: 1, Name: "Alice", Data: []byte{1, 2}, Tags: []string{"a", "b"}},
+		}))
+		f, err := os.Open(path)
+		require.NoError(t, err)
+		defer f.Close()
+
+		r, err := newRowReader(f, formatParquet, "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"id", "name", "data", "tags"}, r.Columns())
+		rows := readAll(t, r)
+		require.Len(t, rows, 1)
+		assert.Equal(t, []any{int64(1), "Alice", []byte{1, 2}, []any{"a", "b"}}, rows[0])
+	})
+
+	t.Run("invalid parquet", func(t *testing.T) {
+		_, err := newRowReader(writeFile(t, "users.parquet", "id,name\n"), formatParquet, "")
+		require.Error(t, err)
+	})
+}
+
+func Test_rowWriters(t *testing.T) {
+	rows := [][]any{
+		{int64(1), "Alice, A.", []any{"a", nil}},
+		{int64(2), nil, []any{}},
+	}
+
+	for format, want := range map[string]string{
+		formatCSV: `id,name,tags
+1,"Alice, A.","[""a"",null]"
+2,NULL,[]
+`,
+		formatJSONL: `{"id":1,"name":"Alice, A.","tags":["a",null]}
+{"id":2,"name":null,"tags":[]}
+`,
+	} {
+		var buf bytes.Buffer
+		w, err := newRowWriter(&buf, format, []string{"id", "name", "tags"}, "NULL")
+		require.NoError(t, err)
+		for _, row := range rows {
+			require.NoError(t, w.Write(row))
+		}
+		require.NoError(t, w.Flush())
+		assert.Equal(t, want, buf.String(), format)
+	}
+}
+
+// fakeClient is a client with a "users" table, which records the statements
+// that are executed.
+type fakeClient struct {
+	clientType.Client
+
+	// failBroadcasts is the number of broadcasts that fail, and failTx is the
+	// number of the transaction that fails, if not 0.
+	failBroadcasts int
+	failTx         int
+
+	sql     []string
+	params  []map[string]any
+	queries []string
+	// rows are the rows returned by queries of the table, and heights are
+	// the heights returned by ChainInfo.
+	rows    [][]any
+	heights []uint64
+}
+
+func (f *fakeClient) Query(_ context.Context, query string, params map[string]any, _ bool) (*types.QueryResult, error) {
+	if strings.HasPrefix(query, "{info}") {
+		res := &types.QueryResult{ColumnNames: []string{"name", "data_type", "is_primary_key"}}
+		if params["table"] == "users" {
+			res.Values = [][]any{
+				{"id", "int8", true},
+				{"name", "text", false},
+				{"tags", "text[]", false},
+			}
+		}
+		return res, nil
+	}
+
+	f.queries = append(f.queries, query)
+	i := strings.LastIndex(query, "\nLIMIT ")
+	if i < 0 {
+		return nil, errors.New("query is not paged")
+	}
+	var limit, offset int
+	if _, err := fmt.Sscanf(query[i+1:], "LIMIT %d OFFSET %d", &limit, &offset); err != nil {
+		return nil, err
+	}
+	res := &types.QueryResult{
+		ColumnNames: []string{"id", "name", "tags"},
+		ColumnTypes: []*types.DataType{types.TextType, types.TextType, types.TextArrayType},
+	}
+	for i := offset; i < offset+limit && i < len(f.rows); i++ {
+		res.Values = append(res.Values, append([]any(nil), f.rows[i]...))
+	}
+	return res, nil
+}
+
+func (f *fakeClient) ChainInfo(context.Context) (*types.ChainInfo, error) {
+	h := f.heights[0]
+	if len(f.heights) > 1 {
+		f.heights = f.heights[1:]
+	}
+	return &types.ChainInfo{BlockHeight: h}, nil
+}
+
+func (f *fakeClient) ExecuteSQL(_ context.Context, sql string, params map[string]any, _ ...clientType.TxOpt) (types.Hash, error) {
+	if f.failBroadcasts > 0 {
+		f.failBroadcasts--
+		return types.Hash{}, errors.New("connection refused")
+	}
+	f.sql = append(f.sql, sql)
+	f.params = append(f.params, params)
+	return types.Hash{byte(len(f.sql))}, nil
+}
+
+func (f *fakeClient) WaitTx(_ context.Context, txHash types.Hash, _ time.Duration) (*types.TxQueryResponse, error) {
+	res := &types.TxQueryResponse{Hash: txHash, Result: &types.TxResult{}}
+	if int(txHash[0]) == f.failTx {
+		res.Result.Code = 1
+		res.Result.Log = "duplicate key"
+	}
+	return res, nil
+}
+
+func Test_importer(t *testing.T) {
+	const file = "users.csv"
+	csvData := "name,id,tags\nAlice,1,\"[\"\"a\"\"]\"\n,2,[]\nCarol,3,\nDan,4,\"[null]\"\nEve,5,\n"
+	newImporter := func(cl *fakeClient, cp string) *importer {
+		return &importer{
+			cl:           cl,
+			namespace:    "main",
+			table:        "users",
+			batchSize:    2,
+			retries:      1,
+			checkpoint:   cp,
+			progress:     io.Discard,
+			retryWait:    time.Millisecond,
+			pollInterval: time.Millisecond,
+		}
+	}
+	newReader := func() rowReader {
+		r, err := newRowReader(writeFile(t, file, csvData), formatCSV, "")
+		require.NoError(t, err)
+		return r
+	}
+
+	t.Run("chunks", func(t *testing.T) {
+		cl := &fakeClient{failBroadcasts: 1}
+		cp := filepath.Join(t.TempDir(), "cp")
+		res, err := newImporter(cl, cp).run(context.Background(), newReader(), file, false)
+		require.NoError(t, err)
+		assert.Equal(t, &respImport{Namespace: "main", Table: "users", Rows: 5, Transactions: 3}, res)
+		assert.NoFileExists(t, cp)
+
+		require.Len(t, cl.sql, 3)
+		assert.Equal(t, "{main}INSERT INTO users (name, id, tags) VALUES ($r0_0, $r0_1, $r0_2), (NULL, $r1_1, ARRAY[]::text[]);", cl.sql[0])
+		assert.Equal(t, map[string]any{"$r0_0": ptr("Alice"), "$r0_1": ptr(int64(1)), "$r0_2": []*string{ptr("a")}, "$r1_1": ptr(int64(2))}, cl.params[0])
+		assert.Equal(t, "{main}INSERT INTO users (name, id, tags) VALUES ($r0_0, $r0_1, NULL), ($r1_0, $r1_1, $r1_2);", cl.sql[1])
+		assert.Equal(t, []*string{nil}, cl.params[1]["$r1_2"])
+
+		for _, sql := range cl.sql {
+			_, err := parse.Parse(sql)
+			require.NoError(t, err, sql)
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		cl := &fakeClient{failTx: 2}
+		cp := filepath.Join(t.TempDir(), "cp")
+		_, err := newImporter(cl, cp).run(context.Background(), newReader(), file, false)
+		require.ErrorContains(t, err, "rows 3 to 4")
+		require.ErrorContains(t, err, "duplicate key")
+
+		// the checkpoint is not used without --resume
+		_, err = newImporter(cl, cp).run(context.Background(), newReader(), file, false)
+		require.ErrorContains(t, err, "--resume")
+
+		// nor by another import
+		other := newImporter(cl, cp)
+		other.table = "accounts"
+		_, err = other.run(context.Background(), newReader(), file, true)
+		require.Error(t, err)
+
+		cl.failTx = 0
+		cl.sql = nil
+		res, err := newImporter(cl, cp).run(context.Background(), newReader(), file, true)
+		require.NoError(t, err)
+		assert.Equal(t, &respImport{Namespace: "main", Table: "users", Rows: 3, Skipped: 2, Transactions: 2}, res)
+		require.Len(t, cl.sql, 2)
+		assert.NoFileExists(t, cp)
+	})
+
+	t.Run("unknown column", func(t *testing.T) {
+		r, err := newRowReader(writeFile(t, file, "id,email\n1,a@b.c\n"), formatCSV, "")
+		require.NoError(t, err)
+		_, err = newImporter(&fakeClient{}, filepath.Join(t.TempDir(), "cp")).run(context.Background(), r, file, false)
+		require.ErrorContains(t, err, `no column "email"`)
+	})
+}
+
+func Test_userQuery(t *testing.T) {
+	q, err := userQuery("main", "SELECT id FROM users ORDER BY id -- by id\n;")
+	require.NoError(t, err)
+	assert.Equal(t, "{main}SELECT id FROM users ORDER BY id -- by id", q.sql)
+
+	q, err = userQuery("main", "{app}SELECT id FROM users ORDER BY id")
+	require.NoError(t, err)
+	assert.Equal(t, "{app}SELECT id FROM users ORDER BY id", q.sql)
+
+	for _, query := range []string{
+		"SELECT id FROM users",
+		"SELECT id FROM users ORDER BY id LIMIT 10",
+		"DELETE FROM users",
+		"SELECT 1 ORDER BY 1; SELECT 2 ORDER BY 1",
+	} {
+		_, err := userQuery("main", query)
+		require.Error(t, err, query)
+	}
+}
+
+func Test_exporter(t *testing.T) {
+	rows := [][]any{
+		{"1", "Alice", []any{"a"}},
+		{"2", nil, []any{}},
+		{"9007199254740993", "Carol", nil},
+	}
+
+	q, err := tableQuery(context.Background(), &fakeClient{}, "main", "users")
+	require.NoError(t, err)
+	assert.Equal(t, "{main}SELECT id::text AS id, name, tags FROM users ORDER BY id", q.sql)
+	_, err = parse.Parse(q.sql + "\nLIMIT 1 OFFSET 0")
+	require.NoError(t, err)
+
+	newExporter := func(cl *fakeClient) *exporter {
+		return &exporter{cl: cl, pageSize: 2, retries: 1, format: formatJSONL, progress: io.Discard}
+	}
+	f, err := os.CreateTemp(t.TempDir(), "export")
+	require.NoError(t, err)
+	defer f.Close()
+
+	// a block is committed during the first attempt
+	cl := &fakeClient{rows: rows, heights: []uint64{5, 6, 6, 6}}
+	res, err := newExporter(cl).run(context.Background(), q, f)
+	require.NoError(t, err)
+	assert.Equal(t, &respExport{Rows: 3, Height: 6}, res)
+	assert.Len(t, cl.queries, 4)
+
+	bts, err := os.ReadFile(f.Name())
+	require.NoError(t, err)
+	assert.Equal(t, `{"id":1,"name":"Alice","tags":["a"]}
+{"id":2,"name":null,"tags":[]}
+{"id":9007199254740993,"name":"Carol","tags":null}
+`, string(bts))
+
+	cl = &fakeClient{rows: rows, heights: []uint64{5, 6, 7, 8}}
+	_, err = newExporter(cl).run(context.Background(), q, f)
+	require.ErrorContains(t, err, "from height 7 to 8")
+}
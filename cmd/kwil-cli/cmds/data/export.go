@@ -0,0 +1,334 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+var (
+	exportLong = `Export the rows of a table or query to a file.
+
+The rows are read in pages of ` + "`--page-size`" + ` rows, and written to a CSV or
+JSONL file, which is given by ` + "`--format`" + ` or inferred from the extension of
+the ` + "`--out`" + ` file. Without ` + "`--out`" + `, they are written to stdout once
+all of them are read.
+
+The rows of a table are ordered by its primary key. A query given by
+` + "`--query`" + ` must be a SELECT statement with an ORDER BY clause and without
+LIMIT or OFFSET, so that it can be paged.
+
+All pages are read at a single block height. The height is checked before the
+first page and after the last one, and if a block was committed in between,
+the export is started again, up to ` + "`--retries`" + ` times.
+
+The values are written in the same formats that ` + "`data import`" + ` reads, so
+that the file can be imported into another table. In CSV files, null values
+are written as ` + "`--null`" + `, and arrays as JSON arrays. Bytea values are
+base64 encoded.`
+
+	exportExample = `# Export the "users" table of the "main" namespace to a CSV file
+kwil-cli data export --table users --out ./users.csv
+
+# Export the rows of a query in the "app" namespace as JSONL to stdout
+kwil-cli data export --namespace app --query "SELECT id, name FROM users WHERE id > 100 ORDER BY id" --format jsonl`
+)
+
+func exportCmd() *cobra.Command {
+	var table, query, namespace, format, out, null string
+	var pageSize, retries int
+
+	cmd := &cobra.Command{
+		Use:     "export",
+		Short:   "Export the rows of a table or query to a file.",
+		Long:    exportLong,
+		Example: exportExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (table == "") == (query == "") {
+				return display.PrintErr(cmd, errors.New("exactly one of --table and --query must be given"))
+			}
+			if pageSize < 1 {
+				return display.PrintErr(cmd, errors.New("--page-size must be positive"))
+			}
+			if format == "" && out == "" {
+				format = formatCSV
+			}
+			format, err := fileFormat(out, format, formatCSV, formatJSONL)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return client.DialClient(cmd.Context(), cmd, client.WithoutPrivateKey, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				var q *exportQuery
+				if table != "" {
+					q, err = tableQuery(ctx, cl, namespace, table)
+				} else {
+					q, err = userQuery(namespace, query)
+				}
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				// the rows are written to a temporary file, since the export
+				// may be started again
+				dir := os.TempDir()
+				if out != "" {
+					out, err = helpers.ExpandPath(out)
+					if err != nil {
+						return display.PrintErr(cmd, err)
+					}
+					dir = filepath.Dir(out)
+				}
+				tmp, err := os.CreateTemp(dir, ".kwil-export-*")
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				defer os.Remove(tmp.Name())
+				defer tmp.Close()
+
+				ex := &exporter{
+					cl:       cl,
+					pageSize: pageSize,
+					retries:  retries,
+					format:   format,
+					null:     null,
+					progress: progressWriter(cmd),
+				}
+				res, err := ex.run(ctx, q, tmp)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				if out == "" {
+					if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+						return display.PrintErr(cmd, err)
+					}
+					_, err = io.Copy(cmd.OutOrStdout(), tmp)
+					return err
+				}
+
+				if err := tmp.Close(); err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				if err := os.Rename(tmp.Name(), out); err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				res.File = out
+				return display.PrintCmd(cmd, res)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&table, "table", "t", "", "the table to export")
+	cmd.Flags().StringVarP(&query, "query", "q", "", "the SELECT statement of the rows to export, instead of a table")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", engine.DefaultNamespace, "the namespace of the table or query")
+	cmd.Flags().StringVar(&format, "format", "", "the format of the file: csv or jsonl (default is the extension of the --out file, or csv)")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "the file to write the rows to, instead of stdout")
+	cmd.Flags().IntVar(&pageSize, "page-size", 1000, "the number of rows read in each query")
+	cmd.Flags().IntVar(&retries, "retries", 3, "the number of times the export is started again if a block is committed during it")
+	cmd.Flags().StringVar(&null, "null", "", "the CSV field that null values are written as")
+	return cmd
+}
+
+// exportQuery is a query whose rows are exported.
+type exportQuery struct {
+	// sql is the query, without a LIMIT or OFFSET.
+	sql string
+	// types are the types of the columns that the values are converted from,
+	// or nil to use the types in the results.
+	types []*types.DataType
+}
+
+// tableQuery returns the query of the rows of a table, ordered by its primary
+// key. Integers are read as text, since they could lose precision as JSON
+// numbers.
+func tableQuery(ctx context.Context, cl clientType.Client, namespace, table string) (*exportQuery, error) {
+	cols, err := loadColumns(ctx, cl, namespace, table)
+	if err != nil {
+		return nil, err
+	}
+
+	q := &exportQuery{}
+	var selects, pk []string
+	for _, c := range cols {
+		sel := c.Name
+		if strings.EqualFold(c.Type.Name, types.IntType.Name) {
+			textType := types.TextType.Copy()
+			textType.IsArray = c.Type.IsArray
+			sel = fmt.Sprintf("%s::%s AS %s", c.Name, textType, c.Name)
+		}
+		selects = append(selects, sel)
+		q.types = append(q.types, c.Type)
+		if c.PrimaryKey {
+			pk = append(pk, c.Name)
+		}
+	}
+	if len(pk) == 0 {
+		return nil, fmt.Errorf(`table "%s" has no primary key to order its rows by`, table)
+	}
+
+	q.sql = fmt.Sprintf("{%s}SELECT %s FROM %s ORDER BY %s", namespace, strings.Join(selects, ", "), table, strings.Join(pk, ", "))
+	return q, nil
+}
+
+// userQuery returns a query given by the user, which must be an ordered
+// SELECT statement without a LIMIT or OFFSET.
+func userQuery(namespace, query string) (*exportQuery, error) {
+	stmts, err := parse.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	if len(stmts) != 1 {
+		return nil, fmt.Errorf("expected 1 statement, got %d", len(stmts))
+	}
+	stmt, ok := stmts[0].(*parse.SQLStatement)
+	if !ok {
+		return nil, errors.New("the query must be a SELECT statement")
+	}
+	sel, ok := stmt.SQL.(*parse.SelectStatement)
+	if !ok {
+		return nil, errors.New("the query must be a SELECT statement")
+	}
+	if sel.Limit != nil || sel.Offset != nil {
+		return nil, errors.New("the query cannot have a LIMIT or OFFSET, since it is paged")
+	}
+	if len(sel.Ordering) == 0 {
+		return nil, errors.New("the query must have an ORDER BY clause, so that it can be paged")
+	}
+
+	sql := strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(query), ";"))
+	if stmt.GetNamespacePrefix() == "" {
+		sql = "{" + namespace + "}" + sql
+	}
+	return &exportQuery{sql: sql}, nil
+}
+
+// exporter writes the rows of a query to a file.
+type exporter struct {
+	cl       clientType.Client
+	pageSize int
+	retries  int
+	format   string
+	null     string
+	progress io.Writer
+}
+
+// run writes the rows of the query to the file. Rows are read from the
+// committed state of the node, which only changes when a block is committed,
+// so the rows are of a single height if the height is the same before and
+// after they are read. Otherwise, the file is truncated and the rows are
+// read again.
+func (ex *exporter) run(ctx context.Context, q *exportQuery, f *os.File) (*respExport, error) {
+	for attempt := 0; ; attempt++ {
+		before, err := ex.cl.ChainInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := f.Truncate(0); err != nil {
+			return nil, err
+		}
+		if _, err := f.Seek(0, io.SeekStart); err != nil {
+			return nil, err
+		}
+		rows, err := ex.write(ctx, q, f)
+		if err != nil {
+			return nil, err
+		}
+
+		after, err := ex.cl.ChainInfo(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if before.BlockHeight == after.BlockHeight {
+			return &respExport{Rows: rows, Height: after.BlockHeight}, nil
+		}
+
+		if attempt == ex.retries {
+			return nil, fmt.Errorf("blocks were committed during the export, from height %d to %d; try again, or with a larger --page-size",
+				before.BlockHeight, after.BlockHeight)
+		}
+		fmt.Fprintf(ex.progress, "blocks were committed during the export, from height %d to %d, starting again\n",
+			before.BlockHeight, after.BlockHeight)
+	}
+}
+
+// write reads the pages of the query and writes their rows.
+func (ex *exporter) write(ctx context.Context, q *exportQuery, w io.Writer) (int64, error) {
+	var rw rowWriter
+	var rows int64
+	for {
+		// the LIMIT is on a new line in case the query ends with a comment
+		sql := fmt.Sprintf("%s\nLIMIT %d OFFSET %d", q.sql, ex.pageSize, rows)
+		res, err := ex.cl.Query(ctx, sql, nil, true)
+		if err != nil {
+			return 0, err
+		}
+
+		if rw == nil {
+			rw, err = newRowWriter(w, ex.format, res.ColumnNames, ex.null)
+			if err != nil {
+				return 0, err
+			}
+		}
+		colTypes := q.types
+		if colTypes == nil {
+			colTypes = res.ColumnTypes
+		}
+
+		for _, row := range res.Values {
+			for i, v := range row {
+				var dt *types.DataType
+				if i < len(colTypes) {
+					dt = colTypes[i]
+				}
+				row[i], err = exportValue(v, dt)
+				if err != nil {
+					return 0, fmt.Errorf(`row %d, column "%s": %w`, rows+1, res.ColumnNames[i], err)
+				}
+			}
+			if err := rw.Write(row); err != nil {
+				return 0, err
+			}
+			rows++
+		}
+		fmt.Fprintf(ex.progress, "exported %d rows\n", rows)
+
+		if len(res.Values) < ex.pageSize {
+			return rows, rw.Flush()
+		}
+	}
+}
+
+// respExport is the result of an export to a file.
+type respExport struct {
+	File   string `json:"file"`
+	Rows   int64  `json:"rows"`
+	Height uint64 `json:"height"`
+}
+
+func (r *respExport) MarshalJSON() ([]byte, error) {
+	type alias respExport
+	return json.Marshal((*alias)(r))
+}
+
+func (r *respExport) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("exported %d rows at height %d to %s", r.Rows, r.Height, r.File)), nil
+}
@@ -0,0 +1,272 @@
+package data
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/parquet-go/parquet-go"
+)
+
+// rowReader reads the rows of a file to import.
+type rowReader interface {
+	// Columns returns the names of the columns of the rows.
+	Columns() []string
+	// Read returns the values of the next row, in the order of the columns.
+	// It returns io.EOF after the last row.
+	Read() ([]any, error)
+}
+
+// newRowReader returns a reader of the rows of a file in a format. CSV
+// fields that are equal to null are null.
+func newRowReader(f *os.File, format, null string) (rowReader, error) {
+	switch format {
+	case formatCSV:
+		return newCSVReader(f, null)
+	case formatJSONL:
+		return newJSONLReader(f)
+	case formatParquet:
+		return newParquetReader(f)
+	default:
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+}
+
+// csvReader reads a CSV file with a header.
+type csvReader struct {
+	r       *csv.Reader
+	columns []string
+	null    string
+}
+
+func newCSVReader(r io.Reader, null string) (*csvReader, error) {
+	cr := csv.NewReader(r)
+	header, err := cr.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, errors.New("CSV file has no header")
+		}
+		return nil, err
+	}
+	for i, h := range header {
+		header[i] = strings.TrimSpace(h)
+	}
+	return &csvReader{r: cr, columns: header, null: null}, nil
+}
+
+func (c *csvReader) Columns() []string {
+	return c.columns
+}
+
+func (c *csvReader) Read() ([]any, error) {
+	rec, err := c.r.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	row := make([]any, len(rec))
+	for i, field := range rec {
+		if field != c.null {
+			row[i] = field
+		}
+	}
+	return row, nil
+}
+
+// jsonlReader reads a file with a JSON object on each line. The columns are
+// the keys of the first object, and later objects cannot have other keys.
+type jsonlReader struct {
+	dec     *json.Decoder
+	columns []string
+	// first is the first object, which is read to get the columns.
+	first map[string]any
+	n     int
+}
+
+func newJSONLReader(r io.Reader) (*jsonlReader, error) {
+	dec := json.NewDecoder(bufio.NewReader(r))
+	dec.UseNumber()
+
+	jr := &jsonlReader{dec: dec}
+	if err := dec.Decode(&jr.first); err != nil {
+		if errors.Is(err, io.EOF) {
+			return jr, nil
+		}
+		return nil, fmt.Errorf("row 1: %w", err)
+	}
+	for col := range jr.first {
+		jr.columns = append(jr.columns, col)
+	}
+	slices.Sort(jr.columns)
+	return jr, nil
+}
+
+func (j *jsonlReader) Columns() []string {
+	return j.columns
+}
+
+func (j *jsonlReader) Read() ([]any, error) {
+	obj := j.first
+	j.first = nil
+	if obj == nil {
+		if err := j.dec.Decode(&obj); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return nil, fmt.Errorf("row %d: %w", j.n+1, err)
+		}
+	}
+	j.n++
+
+	row := make([]any, len(j.columns))
+	for col, v := range obj {
+		i := slices.Index(j.columns, col)
+		if i < 0 {
+			return nil, fmt.Errorf(`row %d has key "%s", which the first row does not have`, j.n, col)
+		}
+		row[i] = v
+	}
+	return row, nil
+}
+
+// parquetReader reads the rows of a Parquet file. The columns are the
+// top-level fields of its schema.
+type parquetReader struct {
+	r       *parquet.Reader
+	columns []string
+	// binary is true for the columns that have byte arrays which are not
+	// strings, which are read as strings.
+	binary []bool
+}
+
+func newParquetReader(f *os.File) (*parquetReader, error) {
+	// the file is checked first, since the reader panics if it is invalid
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parquet.OpenFile(f, stat.Size()); err != nil {
+		return nil, fmt.Errorf("invalid Parquet file: %w", err)
+	}
+
+	pr := &parquetReader{r: parquet.NewReader(f)}
+	for _, field := range pr.r.Schema().Fields() {
+		pr.columns = append(pr.columns, field.Name())
+		typ := field.Type()
+		pr.binary = append(pr.binary, field.Leaf() && typ.Kind() == parquet.ByteArray &&
+			(typ.LogicalType() == nil || typ.LogicalType().UTF8 == nil))
+	}
+	return pr, nil
+}
+
+func (p *parquetReader) Columns() []string {
+	return p.columns
+}
+
+func (p *parquetReader) Read() ([]any, error) {
+	obj := make(map[string]any)
+	if err := p.r.Read(&obj); err != nil {
+		return nil, err
+	}
+
+	row := make([]any, len(p.columns))
+	for i, col := range p.columns {
+		row[i] = obj[col]
+		if s, ok := row[i].(string); ok && p.binary[i] {
+			row[i] = []byte(s)
+		}
+	}
+	return row, nil
+}
+
+// rowWriter writes exported rows to a file.
+type rowWriter interface {
+	// Write writes a row of values converted by exportValue.
+	Write(row []any) error
+	Flush() error
+}
+
+// newRowWriter returns a writer of rows with the columns in a format. CSV
+// files have a header, and null values are written as null.
+func newRowWriter(w io.Writer, format string, columns []string, null string) (rowWriter, error) {
+	switch format {
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(columns); err != nil {
+			return nil, err
+		}
+		return &csvWriter{w: cw, null: null}, nil
+	case formatJSONL:
+		keys := make([][]byte, len(columns))
+		for i, col := range columns {
+			var err error
+			keys[i], err = json.Marshal(col)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return &jsonlWriter{w: bufio.NewWriter(w), keys: keys}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+}
+
+type csvWriter struct {
+	w    *csv.Writer
+	null string
+}
+
+func (c *csvWriter) Write(row []any) error {
+	rec := make([]string, len(row))
+	for i, v := range row {
+		var err error
+		rec[i], err = csvField(v, c.null)
+		if err != nil {
+			return err
+		}
+	}
+	return c.w.Write(rec)
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+// jsonlWriter writes each row as a JSON object on a line, with the keys in
+// the order of the columns.
+type jsonlWriter struct {
+	w    *bufio.Writer
+	keys [][]byte
+}
+
+func (j *jsonlWriter) Write(row []any) error {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, v := range row {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		val, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(j.keys[i])
+		buf.WriteByte(':')
+		buf.Write(val)
+	}
+	buf.WriteString("}\n")
+	_, err := j.w.Write(buf.Bytes())
+	return err
+}
+
+func (j *jsonlWriter) Flush() error {
+	return j.w.Flush()
+}
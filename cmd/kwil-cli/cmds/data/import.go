@@ -0,0 +1,426 @@
+package data
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine"
+)
+
+var (
+	importLong = `Import the rows of a file into a table.
+
+The rows are read from a CSV, JSONL, or Parquet file, which is given by
+` + "`--format`" + ` or inferred from the extension of the file, and inserted in
+chunks of ` + "`--batch-size`" + ` rows, with one INSERT statement per
+transaction. Each transaction is waited for before the next one is sent.
+
+The columns of the file are matched to the columns of the table by name:
+
+- CSV files have a header with the names of the columns. Fields that equal
+  ` + "`--null`" + ` are null, and arrays are JSON arrays.
+- JSONL files have a JSON object on each line, and the columns are the keys of
+  the first object.
+- Parquet files have a column for each top-level field of their schema.
+
+Values are parsed as the type of their column. Bytea values are base64 encoded,
+except for the byte arrays of Parquet files.
+
+Broadcasting a transaction is retried up to ` + "`--retries`" + ` times. After
+each committed transaction, the number of imported rows is saved in a checkpoint
+file, which is ` + "`<file>.checkpoint`" + ` unless ` + "`--checkpoint`" + ` is given.
+If the import is interrupted or a transaction fails, it continues after the
+committed rows with ` + "`--resume`" + `. The checkpoint is deleted once all rows
+are imported.
+
+This command requires a private key, and the privileges to insert into the
+table.`
+
+	importExample = `# Import a CSV file into the "users" table of the "main" namespace
+kwil-cli data import --file ./users.csv --table users
+
+# Import a JSONL file into the "app" namespace in chunks of 1000 rows
+kwil-cli data import --file ./users.jsonl --table users --namespace app --batch-size 1000
+
+# Continue an import that was interrupted
+kwil-cli data import --file ./users.parquet --table users --resume`
+)
+
+func importCmd() *cobra.Command {
+	var file, format, table, namespace, checkpointFile, null string
+	var batchSize, retries int
+	var resume bool
+
+	cmd := &cobra.Command{
+		Use:     "import",
+		Short:   "Import the rows of a file into a table.",
+		Long:    importLong,
+		Example: importExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if batchSize < 1 {
+				return display.PrintErr(cmd, errors.New("--batch-size must be positive"))
+			}
+			format, err := fileFormat(file, format, formatCSV, formatJSONL, formatParquet)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			file, err = helpers.ExpandPath(file)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			if checkpointFile == "" {
+				checkpointFile = file + ".checkpoint"
+			}
+
+			return client.DialClient(cmd.Context(), cmd, 0, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				f, err := os.Open(file)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				defer f.Close()
+
+				rows, err := newRowReader(f, format, null)
+				if err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("failed to read %s: %w", file, err))
+				}
+
+				im := &importer{
+					cl:         cl,
+					namespace:  namespace,
+					table:      table,
+					batchSize:  batchSize,
+					retries:    retries,
+					checkpoint: checkpointFile,
+					progress:   progressWriter(cmd),
+				}
+				res, err := im.run(ctx, rows, file, resume)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				return display.PrintCmd(cmd, res)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "the file to import")
+	cmd.Flags().StringVar(&format, "format", "", "the format of the file: csv, jsonl, or parquet (default is the extension of the file)")
+	cmd.Flags().StringVarP(&table, "table", "t", "", "the table to import the rows into")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", engine.DefaultNamespace, "the namespace of the table")
+	cmd.Flags().IntVar(&batchSize, "batch-size", 100, "the number of rows inserted in each transaction")
+	cmd.Flags().IntVar(&retries, "retries", 3, "the number of times broadcasting a transaction is retried")
+	cmd.Flags().StringVar(&checkpointFile, "checkpoint", "", "the file that the number of imported rows is saved in (default is the file with a .checkpoint extension)")
+	cmd.Flags().BoolVar(&resume, "resume", false, "continue the import after the rows in the checkpoint")
+	cmd.Flags().StringVar(&null, "null", "", "the CSV field that is a null value")
+	cmd.MarkFlagRequired("file")
+	cmd.MarkFlagRequired("table")
+	return cmd
+}
+
+// progressWriter returns the writer of the progress of a command, which is
+// stderr unless the output is silenced or JSON.
+func progressWriter(cmd *cobra.Command) io.Writer {
+	if out, _ := cmd.Flags().GetString("output"); out == "json" || display.ShouldSilence(cmd) {
+		return io.Discard
+	}
+	return cmd.ErrOrStderr()
+}
+
+// importer inserts rows into a table in chunks.
+type importer struct {
+	cl        clientType.Client
+	namespace string
+	table     string
+	batchSize int
+	retries   int
+	// checkpoint is the file that the number of imported rows is saved in.
+	checkpoint string
+	progress   io.Writer
+	// retryWait is the minimum wait before a broadcast is retried.
+	retryWait time.Duration
+	// pollInterval is the interval that transactions are polled at.
+	pollInterval time.Duration
+}
+
+// checkpoint is the state of an import that is saved after each committed
+// transaction, so that it can be resumed.
+type checkpoint struct {
+	File      string `json:"file"`
+	Namespace string `json:"namespace"`
+	Table     string `json:"table"`
+	// Rows is the number of rows of the file that have been imported.
+	Rows int64 `json:"rows"`
+}
+
+// run imports the rows into the table. If resume is true, the rows in the
+// checkpoint are skipped.
+func (im *importer) run(ctx context.Context, rows rowReader, file string, resume bool) (*respImport, error) {
+	cols, err := loadColumns(ctx, im.cl, im.namespace, im.table)
+	if err != nil {
+		return nil, err
+	}
+
+	// the columns of the file, in its order
+	fileCols := make([]*column, len(rows.Columns()))
+	for i, name := range rows.Columns() {
+		idx := slices.IndexFunc(cols, func(c *column) bool { return strings.EqualFold(c.Name, name) })
+		if idx < 0 {
+			return nil, fmt.Errorf(`table "%s" has no column "%s"`, im.table, name)
+		}
+		fileCols[i] = cols[idx]
+	}
+
+	cp, err := im.loadCheckpoint(file, resume)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &respImport{Namespace: im.namespace, Table: im.table, Skipped: cp.Rows}
+	for i := int64(0); i < cp.Rows; i++ {
+		if _, err := rows.Read(); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("the checkpoint has %d rows, but the file has %d", cp.Rows, i)
+			}
+			return nil, fmt.Errorf("failed to read row %d: %w", i+1, err)
+		}
+	}
+
+	for eof := false; !eof; {
+		chunk := make([][]any, 0, im.batchSize)
+		for len(chunk) < im.batchSize {
+			row, err := rows.Read()
+			if errors.Is(err, io.EOF) {
+				eof = true
+				break
+			}
+			n := cp.Rows + int64(len(chunk)) + 1
+			if err != nil {
+				return nil, fmt.Errorf("failed to read row %d: %w", n, err)
+			}
+
+			for i, v := range row {
+				row[i], err = importValue(v, fileCols[i].Type)
+				if err != nil {
+					return nil, fmt.Errorf(`row %d, column "%s": %w`, n, fileCols[i].Name, err)
+				}
+			}
+			chunk = append(chunk, row)
+		}
+		if len(chunk) == 0 {
+			break
+		}
+
+		if err := im.insert(ctx, fileCols, chunk); err != nil {
+			return nil, fmt.Errorf("failed to import rows %d to %d: %w", cp.Rows+1, cp.Rows+int64(len(chunk)), err)
+		}
+
+		cp.Rows += int64(len(chunk))
+		res.Rows += int64(len(chunk))
+		res.Transactions++
+		if err := saveCheckpoint(im.checkpoint, cp); err != nil {
+			return nil, err
+		}
+		fmt.Fprintf(im.progress, "imported %d rows\n", cp.Rows)
+	}
+
+	if err := os.Remove(im.checkpoint); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	return res, nil
+}
+
+// loadCheckpoint returns the checkpoint to start the import from. A
+// checkpoint of another import is an error, and so is an existing checkpoint
+// if the import is not resumed, since its rows would be imported again.
+func (im *importer) loadCheckpoint(file string, resume bool) (*checkpoint, error) {
+	abs, err := filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	cp := &checkpoint{File: abs, Namespace: im.namespace, Table: im.table}
+
+	bts, err := os.ReadFile(im.checkpoint)
+	if errors.Is(err, os.ErrNotExist) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var saved checkpoint
+	if err := json.Unmarshal(bts, &saved); err != nil {
+		return nil, fmt.Errorf("invalid checkpoint %s: %w", im.checkpoint, err)
+	}
+	if !resume {
+		return nil, fmt.Errorf("checkpoint %s has %d rows of a previous import, use --resume to continue it, or delete it to import all rows again",
+			im.checkpoint, saved.Rows)
+	}
+	if saved.File != cp.File || saved.Namespace != cp.Namespace || saved.Table != cp.Table {
+		return nil, fmt.Errorf(`checkpoint %s is of the import of %s into "%s.%s"`, im.checkpoint, saved.File, saved.Namespace, saved.Table)
+	}
+	return &saved, nil
+}
+
+// saveCheckpoint writes the checkpoint to a temporary file that replaces the
+// file, so that it is not corrupted if the import is interrupted.
+func saveCheckpoint(file string, cp *checkpoint) error {
+	tmp := file + ".tmp"
+	if err := common.WriteJSONFile(tmp, cp); err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return os.Rename(tmp, file)
+}
+
+// insert inserts a chunk of rows in a transaction, and waits for it to be
+// committed. Broadcasting the transaction is retried, but a failed
+// transaction is not.
+func (im *importer) insert(ctx context.Context, cols []*column, rows [][]any) error {
+	stmt, params := insertStatement(im.namespace, im.table, cols, rows)
+
+	minWait := im.retryWait
+	if minWait == 0 {
+		minWait = time.Second
+	}
+	retrier := &backoff.Backoff{
+		Min:    minWait,
+		Max:    30 * time.Second,
+		Factor: 2,
+		Jitter: true,
+	}
+
+	var txHash types.Hash
+	for attempt := 0; ; attempt++ {
+		var err error
+		txHash, err = im.cl.ExecuteSQL(ctx, stmt, params)
+		if err == nil {
+			break
+		}
+		if attempt == im.retries || ctx.Err() != nil {
+			return err
+		}
+
+		wait := retrier.Duration()
+		fmt.Fprintf(im.progress, "failed to broadcast transaction, retrying in %v: %v\n", wait.Round(time.Millisecond), err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+
+	interval := im.pollInterval
+	if interval == 0 {
+		interval = time.Second
+	}
+	res, err := im.cl.WaitTx(ctx, txHash, interval)
+	if err != nil {
+		return fmt.Errorf("failed to wait for transaction %s: %w", txHash, err)
+	}
+	if res.Result == nil || res.Result.Code != uint32(types.CodeOk) {
+		var log string
+		if res.Result != nil {
+			log = res.Result.Log
+		}
+		return fmt.Errorf("transaction %s failed: %s", txHash, log)
+	}
+	return nil
+}
+
+// insertStatement returns an INSERT statement of the rows, with a parameter
+// for each value. Nulls and empty arrays are written in the statement, since
+// their parameters would not have a type.
+func insertStatement(namespace, table string, cols []*column, rows [][]any) (string, map[string]any) {
+	var stmt strings.Builder
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name
+	}
+	fmt.Fprintf(&stmt, "{%s}INSERT INTO %s (%s) VALUES", namespace, table, strings.Join(names, ", "))
+
+	params := make(map[string]any)
+	for i, row := range rows {
+		if i > 0 {
+			stmt.WriteString(",")
+		}
+		stmt.WriteString(" (")
+		for j, v := range row {
+			if j > 0 {
+				stmt.WriteString(", ")
+			}
+			switch {
+			case v == nil:
+				stmt.WriteString("NULL")
+			case cols[j].Type.IsArray && emptySlice(v):
+				fmt.Fprintf(&stmt, "ARRAY[]::%s", cols[j].Type)
+			default:
+				name := fmt.Sprintf("$r%d_%d", i, j)
+				params[name] = v
+				stmt.WriteString(name)
+			}
+		}
+		stmt.WriteString(")")
+	}
+	stmt.WriteString(";")
+	return stmt.String(), params
+}
+
+func emptySlice(v any) bool {
+	switch s := v.(type) {
+	case []*string:
+		return len(s) == 0
+	case []*int64:
+		return len(s) == 0
+	case []*bool:
+		return len(s) == 0
+	case []*[]byte:
+		return len(s) == 0
+	case []*types.UUID:
+		return len(s) == 0
+	case []*types.Decimal:
+		return len(s) == 0
+	default:
+		return false
+	}
+}
+
+// respImport is the result of an import.
+type respImport struct {
+	Namespace string `json:"namespace"`
+	Table     string `json:"table"`
+	// Rows is the number of rows that were imported, and Skipped the number
+	// of rows in the checkpoint of a resumed import.
+	Rows         int64 `json:"rows"`
+	Skipped      int64 `json:"skipped"`
+	Transactions int   `json:"transactions"`
+}
+
+func (r *respImport) MarshalJSON() ([]byte, error) {
+	type alias respImport
+	return json.Marshal((*alias)(r))
+}
+
+func (r *respImport) MarshalText() ([]byte, error) {
+	msg := fmt.Sprintf(`imported %d rows into "%s.%s" in %d transactions`, r.Rows, r.Namespace, r.Table, r.Transactions)
+	if r.Skipped > 0 {
+		msg += fmt.Sprintf(", after %d rows that were imported before", r.Skipped)
+	}
+	return []byte(msg), nil
+}
@@ -0,0 +1,213 @@
+package data
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// importValue converts a value read from a file to the Go type of a column,
+// which is encoded as a parameter. Scalars can be strings, JSON numbers,
+// booleans, byte slices, and the numbers of Parquet files, and are parsed as
+// the type of the column. Bytea strings are base64 encoded. Arrays are slices
+// of scalars, or strings with a JSON array.
+func importValue(v any, dt *types.DataType) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+	if !dt.IsArray {
+		return importScalar(v, dt)
+	}
+
+	elems, ok := v.([]any)
+	if !ok {
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected an array, got %T", v)
+		}
+		dec := json.NewDecoder(strings.NewReader(str))
+		dec.UseNumber()
+		if err := dec.Decode(&elems); err != nil {
+			return nil, fmt.Errorf("invalid array %s: %w", str, err)
+		}
+	}
+
+	elemType := dt.Copy()
+	elemType.IsArray = false
+	vals := make([]any, len(elems))
+	for i, elem := range elems {
+		var err error
+		vals[i], err = importScalar(elem, elemType)
+		if err != nil {
+			return nil, fmt.Errorf("element %d: %w", i+1, err)
+		}
+	}
+
+	switch strings.ToLower(elemType.Name) {
+	case types.TextType.Name:
+		return ptrSlice[string](vals), nil
+	case types.IntType.Name:
+		return ptrSlice[int64](vals), nil
+	case types.BoolType.Name:
+		return ptrSlice[bool](vals), nil
+	case types.ByteaType.Name:
+		return ptrSlice[[]byte](vals), nil
+	case types.UUIDType.Name:
+		return ptrSlice[types.UUID](vals), nil
+	default:
+		return ptrSlice[types.Decimal](vals), nil
+	}
+}
+
+// ptrSlice converts values that are pointers to T or nil to a slice.
+func ptrSlice[T any](vals []any) []*T {
+	s := make([]*T, len(vals))
+	for i, v := range vals {
+		if v != nil {
+			s[i] = v.(*T)
+		}
+	}
+	return s
+}
+
+// importScalar converts a scalar value to a pointer to the Go type of a
+// column, or nil.
+func importScalar(v any, dt *types.DataType) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	name := strings.ToLower(dt.Name)
+	if name == types.ByteaType.Name {
+		switch b := v.(type) {
+		case []byte:
+			return &b, nil
+		case string:
+			bts, err := base64.StdEncoding.DecodeString(b)
+			if err != nil {
+				return nil, fmt.Errorf("invalid base64 bytea: %w", err)
+			}
+			return &bts, nil
+		default:
+			return nil, fmt.Errorf("cannot convert %T to %s", v, dt)
+		}
+	}
+
+	var str string
+	switch val := v.(type) {
+	case string:
+		str = val
+	case json.Number:
+		str = val.String()
+	case []byte:
+		str = string(val)
+	case bool:
+		str = strconv.FormatBool(val)
+	case int, int8, int16, int32, int64, uint8, uint16, uint32, uint64:
+		str = fmt.Sprint(val)
+	case float32:
+		str = strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case float64:
+		str = strconv.FormatFloat(val, 'f', -1, 64)
+	default:
+		return nil, fmt.Errorf("cannot convert %T to %s", v, dt)
+	}
+
+	switch name {
+	case types.TextType.Name:
+		return &str, nil
+	case types.IntType.Name:
+		i, err := strconv.ParseInt(str, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s", dt, str)
+		}
+		return &i, nil
+	case types.BoolType.Name:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s", dt, str)
+		}
+		return &b, nil
+	case types.UUIDType.Name:
+		return types.ParseUUID(str)
+	case types.NumericStr:
+		if len(dt.Metadata) != 2 {
+			return nil, fmt.Errorf("numeric type %s has no precision and scale", dt)
+		}
+		return types.ParseDecimalExplicit(str, dt.Metadata[0], dt.Metadata[1])
+	default:
+		return nil, fmt.Errorf("unsupported type %s", dt)
+	}
+}
+
+// exportValue converts a value returned by a query to the value that is
+// written to a file, given the type of its column. Integers are int64,
+// booleans are bool, and other scalars are strings, with bytea base64
+// encoded. Arrays are []any of those.
+func exportValue(v any, dt *types.DataType) (any, error) {
+	if v == nil || dt == nil {
+		return v, nil
+	}
+	if !dt.IsArray {
+		return exportScalar(v, dt)
+	}
+
+	elems, ok := v.([]any)
+	if !ok {
+		return nil, fmt.Errorf("expected an array, got %T", v)
+	}
+	vals := make([]any, len(elems))
+	for i, elem := range elems {
+		var err error
+		vals[i], err = exportScalar(elem, dt)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return vals, nil
+}
+
+func exportScalar(v any, dt *types.DataType) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch strings.ToLower(dt.Name) {
+	case types.IntType.Name:
+		var i int64
+		err := types.ScanTo([]any{v}, &i)
+		return i, err
+	case types.BoolType.Name:
+		var b bool
+		err := types.ScanTo([]any{v}, &b)
+		return b, err
+	default:
+		var s string
+		err := types.ScanTo([]any{v}, &s)
+		return s, err
+	}
+}
+
+// csvField formats an exported value as a CSV field. Arrays are JSON arrays,
+// and null is the given string.
+func csvField(v any, null string) (string, error) {
+	switch val := v.(type) {
+	case nil:
+		return null, nil
+	case string:
+		return val, nil
+	case int64:
+		return strconv.FormatInt(val, 10), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	case []any:
+		bts, err := json.Marshal(val)
+		return string(bts), err
+	default:
+		return fmt.Sprint(val), nil
+	}
+}
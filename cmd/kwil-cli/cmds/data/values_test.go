@@ -0,0 +1,111 @@
+package data
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func Test_importValue(t *testing.T) {
+	numeric, err := types.NewNumericType(10, 2)
+	require.NoError(t, err)
+	numericArr := numeric.Copy()
+	numericArr.IsArray = true
+
+	tests := []struct {
+		name    string
+		v       any
+		dt      *types.DataType
+		want    any
+		wantErr bool
+	}{
+		{"null", nil, types.IntType, nil, false},
+		{"text", "hello", types.TextType, ptr("hello"), false},
+		{"int from string", "42", types.IntType, ptr(int64(42)), false},
+		{"int from json number", json.Number("-7"), types.IntType, ptr(int64(-7)), false},
+		{"int from parquet", int32(3), types.IntType, ptr(int64(3)), false},
+		{"invalid int", "4.5", types.IntType, nil, true},
+		{"bool", "true", types.BoolType, ptr(true), false},
+		{"bool from json", true, types.BoolType, ptr(true), false},
+		{"bytea from base64", "AQID", types.ByteaType, ptr([]byte{1, 2, 3}), false},
+		{"bytea from bytes", []byte{4}, types.ByteaType, ptr([]byte{4}), false},
+		{"invalid base64", "!!", types.ByteaType, nil, true},
+		{"uuid", "c1b7a2b2-8f7b-4ad1-8f9a-2d1f1f0e7b6a", types.UUIDType,
+			types.MustParseUUID("c1b7a2b2-8f7b-4ad1-8f9a-2d1f1f0e7b6a"), false},
+		{"numeric", "12.5", numeric, types.MustParseDecimalExplicit("12.50", 10, 2), false},
+		{"numeric from float", 1.25, numeric, types.MustParseDecimalExplicit("1.25", 10, 2), false},
+		{"text array from json", `["a", null]`, types.TextArrayType, []*string{ptr("a"), nil}, false},
+		{"int array from slice", []any{int64(1), "2"}, types.IntArrayType, []*int64{ptr(int64(1)), ptr(int64(2))}, false},
+		{"empty array", `[]`, types.BoolArrayType, []*bool{}, false},
+		{"numeric array", `[1.5]`, numericArr, []*types.Decimal{types.MustParseDecimalExplicit("1.50", 10, 2)}, false},
+		{"invalid array", "a,b", types.TextArrayType, nil, true},
+		{"invalid element", `["x"]`, types.IntArrayType, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := importValue(tt.v, tt.dt)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.EqualValues(t, tt.want, got)
+		})
+	}
+}
+
+func Test_exportValue(t *testing.T) {
+	numeric, err := types.NewNumericType(10, 2)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		v    any
+		dt   *types.DataType
+		want any
+	}{
+		{"null", nil, types.IntType, nil},
+		{"int from text", "9007199254740993", types.IntType, int64(9007199254740993)},
+		{"int from json", float64(5), types.IntType, int64(5)},
+		{"bool", true, types.BoolType, true},
+		{"text", "hi", types.TextType, "hi"},
+		{"numeric", "1.50", numeric, "1.50"},
+		{"int array from text", []any{"1", nil}, types.IntArrayType, []any{int64(1), nil}},
+		{"unknown type", float64(1), nil, float64(1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := exportValue(tt.v, tt.dt)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_csvField(t *testing.T) {
+	for v, want := range map[any]string{
+		nil:        "NULL",
+		"a":        "a",
+		int64(-1):  "-1",
+		false:      "false",
+		float64(2): "2",
+	} {
+		got, err := csvField(v, "NULL")
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	got, err := csvField([]any{"a", nil, int64(1)}, "NULL")
+	require.NoError(t, err)
+	assert.Equal(t, `["a",null,1]`, got)
+}
@@ -31,7 +31,11 @@ This command requires a private key, and it will author a transaction from the p
 Actions can be given any mix of positional and named parameters in order to execute an action one. If you wish
 to batch execute an action, you can provide a CSV file, and map the CSV column names to either the action parameter
 name or the action parameter position. If any named parameters are specified while using a CSV file, then all batch
-action calls will set the named parameter to the same value.`
+action calls will set the named parameter to the same value.
+
+To execute several payloads atomically in one transaction, such as actions in different namespaces, SQL
+statements, and transfers, provide a JSON batch file with the ` + "`--batch`" + ` flag instead of an action. If any
+payload in the batch fails, the changes of all of them are reverted.`
 
 	execActionExample = `# Execute the action 'register' with no parameters
 kwil-cli exec-action register
@@ -53,11 +57,19 @@ kwil-cli exec-action register --csv /path/to/file.csv --csv-mapping name:0 --csv
 
 # Execute the action 'register' with a CSV file, but override all ages to be 10
 # Assume the same action signature and CSV file as above
-kwil-cli exec-action register --csv /path/to/file.csv --csv-mapping name:0 --param age:int=10`
+kwil-cli exec-action register --csv /path/to/file.csv --csv-mapping name:0 --param age:int=10
+
+# Execute a batch of payloads from a JSON file with the following contents:
+# [
+#   {"type": "execute", "namespace": "users", "action": "register", "args": [["text:satoshi", "int:30"]]},
+#   {"type": "raw_statement", "statement": "INSERT INTO logs.entries (msg) VALUES ($msg)", "params": ["msg:text=registered"]},
+#   {"type": "transfer", "to": "0xc89D42189f0450C2b2c3c61f58Ec5d628176A1E7", "amount": "1000"}
+# ]
+kwil-cli exec-action --batch /path/to/batch.json`
 )
 
 func execActionCmd() *cobra.Command {
-	var namespace, csvFile string
+	var namespace, csvFile, batchFile string
 	var namedParams, csvParams []string
 
 	cmd := &cobra.Command{
@@ -66,15 +78,22 @@ func execActionCmd() *cobra.Command {
 		Long:    execActionLong,
 		Example: execActionExample,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) < 1 {
-				return display.PrintErr(cmd, fmt.Errorf("no action provided"))
-			}
-
 			txFlags, err := common.GetTxFlags(cmd)
 			if err != nil {
 				return display.PrintErr(cmd, err)
 			}
 
+			if batchFile != "" {
+				if len(args) > 0 || csvFile != "" || len(namedParams) > 0 || namespace != "" {
+					return display.PrintErr(cmd, errors.New("cannot specify an action or parameters with a batch file"))
+				}
+				return execBatchFile(cmd, batchFile, txFlags)
+			}
+
+			if len(args) < 1 {
+				return display.PrintErr(cmd, fmt.Errorf("no action provided"))
+			}
+
 			if len(args) > 1 && csvFile != "" {
 				return display.PrintErr(cmd, fmt.Errorf("cannot specify both CSV file and positional parameters"))
 			}
@@ -176,6 +195,7 @@ func execActionCmd() *cobra.Command {
 	// If we use StringSliceVar, it will split the array into multiple parameters.
 	cmd.Flags().StringArrayVarP(&namedParams, "param", "p", nil, `named parameters that will override any positional or CSV parameters. format: "name:type=value"`)
 	cmd.Flags().StringVar(&csvFile, "csv", "", "CSV file containing the parameters to pass to the action")
+	cmd.Flags().StringVar(&batchFile, "batch", "", "JSON file with payloads to execute atomically in one transaction")
 	cmd.Flags().StringSliceVarP(&csvParams, "csv-mapping", "m", nil, `mapping of CSV columns to action parameters. format: "csv_column:action_param_name" OR "csv_column:action_param_position"`)
 	common.BindTxFlags(cmd)
 	return cmd
@@ -2,6 +2,7 @@ package cmds
 
 import (
 	"fmt"
+	"os"
 	"strings"
 	"testing"
 
@@ -236,3 +237,40 @@ func mustNewNumericArr(prec, scale uint16) *types.DataType {
 func ptr[T any](a T) *T {
 	return &a
 }
+
+func Test_ReadBatchFile(t *testing.T) {
+	path := t.TempDir() + "/batch.json"
+	err := os.WriteFile(path, []byte(`[
+		{"type": "execute", "namespace": "users", "action": "register", "args": [["text:satoshi", "int:30"], ["text:hal", "null"]]},
+		{"type": "raw_statement", "statement": "DELETE FROM logs.entries WHERE id = $id", "params": ["id:int=1"]},
+		{"type": "transfer", "to": "0x01ab", "amount": "1000"}
+	]`), 0644)
+	require.NoError(t, err)
+
+	batch, err := readBatchFile(path)
+	require.NoError(t, err)
+	require.Len(t, batch.Payloads, 3)
+
+	var exec types.ActionExecution
+	require.Equal(t, types.PayloadTypeExecute, batch.Payloads[0].Type)
+	require.NoError(t, exec.UnmarshalBinary(batch.Payloads[0].Payload))
+	assert.Equal(t, "users", exec.Namespace)
+	assert.Len(t, exec.Arguments, 2)
+
+	var raw types.RawStatement
+	require.Equal(t, types.PayloadTypeRawStatement, batch.Payloads[1].Type)
+	require.NoError(t, raw.UnmarshalBinary(batch.Payloads[1].Payload))
+	require.Len(t, raw.Parameters, 1)
+	assert.Equal(t, "id", raw.Parameters[0].Name)
+
+	var transfer types.Transfer
+	require.Equal(t, types.PayloadTypeTransfer, batch.Payloads[2].Type)
+	require.NoError(t, transfer.UnmarshalBinary(batch.Payloads[2].Payload))
+	assert.Equal(t, "1000", transfer.Amount.String())
+	assert.Equal(t, types.HexBytes{0x01, 0xab}, transfer.To.Identifier)
+
+	err = os.WriteFile(path, []byte(`[{"type": "validator_leave"}]`), 0644)
+	require.NoError(t, err)
+	_, err = readBatchFile(path)
+	require.Error(t, err)
+}
@@ -0,0 +1,184 @@
+package cmds
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	coreClient "github.com/kwilteam/kwil-db/core/client"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// batchExecutor is the client method used to execute a batch.
+type batchExecutor interface {
+	ExecuteBatch(ctx context.Context, batch *types.Batch, opts ...clientType.TxOpt) (types.Hash, error)
+}
+
+// batchFileEntry is one payload in a batch file. The fields that apply depend
+// on the type, which is one of "execute", "raw_statement", or "transfer".
+// Action arguments and SQL parameters use the same format as the exec-action
+// and exec-sql commands, e.g. "text:satoshi" and "name:text=satoshi".
+type batchFileEntry struct {
+	Type types.PayloadType `json:"type"`
+
+	// execute
+	Namespace string     `json:"namespace,omitempty"`
+	Action    string     `json:"action,omitempty"`
+	Args      [][]string `json:"args,omitempty"`
+
+	// raw_statement
+	Statement string   `json:"statement,omitempty"`
+	Params    []string `json:"params,omitempty"`
+
+	// transfer
+	To      string `json:"to,omitempty"`
+	KeyType string `json:"key_type,omitempty"`
+	Amount  string `json:"amount,omitempty"`
+}
+
+// payload creates the transaction payload for the entry.
+func (e *batchFileEntry) payload() (types.Payload, error) {
+	switch e.Type {
+	case types.PayloadTypeExecute:
+		if e.Action == "" {
+			return nil, errors.New("no action provided")
+		}
+		exec := &types.ActionExecution{
+			Namespace: e.Namespace,
+			Action:    e.Action,
+		}
+		for _, tuple := range e.Args {
+			var params []any
+			for _, arg := range tuple {
+				_, param, err := parseTypedParam(arg)
+				if err != nil {
+					return nil, err
+				}
+				params = append(params, param)
+			}
+			encoded, err := coreClient.EncodeInputs(params)
+			if err != nil {
+				return nil, err
+			}
+			exec.Arguments = append(exec.Arguments, encoded)
+		}
+		return exec, nil
+
+	case types.PayloadTypeRawStatement:
+		if e.Statement == "" {
+			return nil, errors.New("no SQL statement provided")
+		}
+		params, err := parseParams(e.Params)
+		if err != nil {
+			return nil, err
+		}
+		raw := &types.RawStatement{Statement: e.Statement}
+		for name, val := range params {
+			encoded, err := types.EncodeValue(val)
+			if err != nil {
+				return nil, err
+			}
+			raw.Parameters = append(raw.Parameters, &types.NamedValue{Name: name, Value: encoded})
+		}
+		return raw, nil
+
+	case types.PayloadTypeTransfer:
+		amount, ok := new(big.Int).SetString(e.Amount, 10)
+		if !ok || amount.Sign() < 0 {
+			return nil, fmt.Errorf("invalid decimal amount %q", e.Amount)
+		}
+		// as with the transfer command, a 0x prefix permits an ethereum address
+		id, err := hex.DecodeString(strings.TrimPrefix(e.To, "0x"))
+		if err != nil || len(id) == 0 {
+			return nil, fmt.Errorf("invalid recipient %q", e.To)
+		}
+		keyType := crypto.KeyTypeSecp256k1
+		if e.KeyType != "" {
+			keyType = crypto.KeyType(e.KeyType)
+		}
+		return &types.Transfer{
+			To:     &types.AccountID{Identifier: id, KeyType: keyType},
+			Amount: amount,
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported batch payload type %q", e.Type)
+	}
+}
+
+// readBatchFile reads a JSON file with a list of batch payloads.
+func readBatchFile(path string) (*types.Batch, error) {
+	path, err := helpers.ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []*batchFileEntry
+	if err = json.Unmarshal(bts, &entries); err != nil {
+		return nil, fmt.Errorf("invalid batch file: %w", err)
+	}
+	return newBatch(entries)
+}
+
+// newBatch creates a batch payload with the payloads of the entries.
+func newBatch(entries []*batchFileEntry) (*types.Batch, error) {
+	if len(entries) == 0 {
+		return nil, errors.New("batch file has no payloads")
+	}
+
+	batch := &types.Batch{}
+	for i, entry := range entries {
+		payload, err := entry.payload()
+		if err != nil {
+			return nil, fmt.Errorf("batch payload %d: %w", i, err)
+		}
+		bp, err := types.NewBatchPayload(payload)
+		if err != nil {
+			return nil, fmt.Errorf("batch payload %d: %w", i, err)
+		}
+		batch.Payloads = append(batch.Payloads, bp)
+	}
+
+	return batch, nil
+}
+
+// execBatchFile broadcasts a batch transaction with the payloads in the file.
+func execBatchFile(cmd *cobra.Command, path string, txFlags *common.TxFlags) error {
+	batch, err := readBatchFile(path)
+	if err != nil {
+		return display.PrintErr(cmd, err)
+	}
+
+	return client.DialClient(cmd.Context(), cmd, 0, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+		be, ok := cl.(batchExecutor)
+		if !ok {
+			return display.PrintErr(cmd, errors.New("client cannot execute batches"))
+		}
+
+		txHash, err := be.ExecuteBatch(ctx, batch, clientType.WithNonce(txFlags.NonceOverride),
+			clientType.WithSyncBroadcast(txFlags.SyncBroadcast))
+		if err != nil {
+			return display.PrintErr(cmd, err)
+		}
+
+		return common.DisplayTxResult(ctx, cl, txHash, cmd)
+	})
+}
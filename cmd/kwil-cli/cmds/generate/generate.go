@@ -0,0 +1,27 @@
+// Package generate contains the kwil-cli commands that generate client code
+// from the actions of a namespace.
+package generate
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var generateLong = `Generate client code from the actions of a namespace.
+
+The action signatures are read from a Kuneiform file, or from a node if no
+file is given, and typed wrappers are generated for them, so that changes to
+the schema are caught when the client is compiled.`
+
+func NewCmdGenerate() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "generate",
+		Short: "Generate client code from the actions of a namespace.",
+		Long:  generateLong,
+	}
+
+	cmd.AddCommand(
+		goCmd(),
+	)
+
+	return cmd
+}
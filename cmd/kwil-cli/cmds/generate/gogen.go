@@ -0,0 +1,334 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"go/token"
+	gotypes "go/types"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+// action is the signature of an action that can be called by a client.
+type action struct {
+	Name       string
+	Parameters []*engine.NamedType
+	// View is true if the action is called, instead of executed in a
+	// transaction.
+	View bool
+	// Returns is nil if the action does not return anything.
+	Returns *parse.ActionReturn
+}
+
+// goFile is the data of the generated Go file.
+type goFile struct {
+	Package   string
+	Namespace string
+	// StdImports are the imports of the standard library, and Imports the
+	// others.
+	StdImports []string
+	Imports    []string
+	// Call is true if any action is called, which uses the call helper.
+	Call    bool
+	Actions []*goAction
+}
+
+// goAction is the data of the wrapper of an action.
+type goAction struct {
+	// Name is the name of the action, and Func the name of its wrapper.
+	Name   string
+	Func   string
+	Params []*goVar
+	View   bool
+	// Row is the name of the struct of the rows the action returns, and
+	// Fields are its fields. Table is true if the action returns any number
+	// of rows, instead of one.
+	Row    string
+	Fields []*goVar
+	Table  bool
+}
+
+// goVar is a Go parameter or struct field.
+type goVar struct {
+	Name string
+	Type string
+}
+
+const (
+	importContext    = `"context"`
+	importErrors     = `"errors"`
+	importClientType = `clientType "github.com/kwilteam/kwil-db/core/client/types"`
+	importTypes      = `"github.com/kwilteam/kwil-db/core/types"`
+)
+
+// generateGo generates a Go file with a client for the actions of a
+// namespace. Each action has a method with typed parameters, and the rows
+// that view actions return are scanned into structs.
+func generateGo(pkg, namespace string, actions []*action) ([]byte, error) {
+	if !token.IsIdentifier(pkg) {
+		return nil, fmt.Errorf(`invalid package name "%s"`, pkg)
+	}
+
+	file := &goFile{Package: pkg, Namespace: namespace}
+	imports := []string{importContext, importClientType}
+	funcs := make(map[string]string)
+	for _, act := range actions {
+		a := &goAction{
+			Name: act.Name,
+			Func: goName(act.Name, true),
+			View: act.View,
+		}
+		if other, ok := funcs[a.Func]; ok {
+			return nil, fmt.Errorf(`actions "%s" and "%s" have the same Go name %s`, other, act.Name, a.Func)
+		}
+		funcs[a.Func] = act.Name
+
+		for _, p := range act.Parameters {
+			typ, err := goType(p.Type)
+			if err != nil {
+				return nil, fmt.Errorf(`parameter %s of action "%s": %w`, p.Name, act.Name, err)
+			}
+			a.Params = append(a.Params, &goVar{Name: paramName(p.Name), Type: typ})
+		}
+
+		if act.View {
+			file.Call = true
+			imports = append(imports, importErrors)
+			if act.Returns != nil && len(act.Returns.Fields) > 0 {
+				a.Row = a.Func + "Row"
+				a.Table = act.Returns.IsTable
+				for i, f := range act.Returns.Fields {
+					typ, err := goType(f.Type)
+					if err != nil {
+						return nil, fmt.Errorf(`return field %s of action "%s": %w`, f.Name, act.Name, err)
+					}
+					name := goName(f.Name, true)
+					if name == "" {
+						name = fmt.Sprintf("Column%d", i+1)
+					}
+					a.Fields = append(a.Fields, &goVar{Name: name, Type: typ})
+				}
+				imports = append(imports, importTypes)
+			}
+		} else {
+			imports = append(imports, importTypes)
+		}
+
+		for _, v := range append(slices.Clone(a.Params), a.Fields...) {
+			if strings.Contains(v.Type, "types.") {
+				imports = append(imports, importTypes)
+			}
+		}
+
+		file.Actions = append(file.Actions, a)
+	}
+
+	slices.Sort(imports)
+	for _, imp := range slices.Compact(imports) {
+		if strings.Contains(imp, ".") {
+			file.Imports = append(file.Imports, imp)
+		} else {
+			file.StdImports = append(file.StdImports, imp)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := goTemplate.Execute(&buf, file); err != nil {
+		return nil, err
+	}
+
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated invalid Go code: %w", err)
+	}
+	return src, nil
+}
+
+// goType returns the Go type of a Kwil type. The types are the ones that
+// values are encoded from and scanned into by the client.
+func goType(dt *types.DataType) (string, error) {
+	var typ string
+	switch strings.ToLower(dt.Name) {
+	case types.IntType.Name:
+		typ = "int64"
+	case types.TextType.Name:
+		typ = "string"
+	case types.BoolType.Name:
+		typ = "bool"
+	case types.ByteaType.Name:
+		typ = "[]byte"
+	case types.UUIDType.Name:
+		typ = "types.UUID"
+	case types.NumericStr:
+		typ = "types.Decimal"
+	default:
+		return "", fmt.Errorf("unsupported type %s", dt.String())
+	}
+
+	if dt.IsArray {
+		typ = "[]" + typ
+	}
+	return typ, nil
+}
+
+// initialisms are the words that are written in upper case in Go names.
+var initialisms = map[string]string{
+	"api":  "API",
+	"http": "HTTP",
+	"id":   "ID",
+	"ip":   "IP",
+	"json": "JSON",
+	"sql":  "SQL",
+	"url":  "URL",
+	"uuid": "UUID",
+}
+
+// goName converts a snake case name to camel case. If exported is true, the
+// first letter is upper case.
+func goName(name string, exported bool) string {
+	var str strings.Builder
+	for _, word := range strings.Split(strings.TrimPrefix(name, "$"), "_") {
+		if word == "" {
+			continue
+		}
+		word = strings.ToLower(word)
+		switch {
+		case str.Len() == 0 && !exported:
+			str.WriteString(word)
+		case initialisms[word] != "":
+			str.WriteString(initialisms[word])
+		default:
+			str.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return str.String()
+}
+
+// reservedNames are the names that the generated methods use, so parameters
+// are renamed if they have them.
+var reservedNames = []string{"c", "ctx", "opts", "vals", "err", "rows", "row", "i", "v",
+	"context", "errors", "clientType", "types"}
+
+// paramName returns the Go name of an action parameter. Names that are Go
+// keywords, predeclared identifiers, or used by the generated code get an
+// "Arg" suffix.
+func paramName(name string) string {
+	n := goName(name, false)
+	if token.IsKeyword(n) || gotypes.Universe.Lookup(n) != nil || slices.Contains(reservedNames, n) {
+		n += "Arg"
+	}
+	return n
+}
+
+var goTemplate = template.Must(template.New("go").Funcs(template.FuncMap{
+	"args": func(params []*goVar) string {
+		names := make([]string, len(params))
+		for i, p := range params {
+			names[i] = p.Name
+		}
+		return strings.Join(names, ", ")
+	},
+}).Parse(`// Code generated by kwil-cli generate go. DO NOT EDIT.
+
+// Package {{.Package}} calls the actions of the "{{.Namespace}}" namespace.
+package {{.Package}}
+
+import (
+{{- range .StdImports}}
+	{{.}}
+{{- end}}
+{{range .Imports}}
+	{{.}}
+{{- end}}
+)
+
+// Namespace is the namespace of the actions that Client calls.
+const Namespace = "{{.Namespace}}"
+
+// Client calls the actions of the "{{.Namespace}}" namespace.
+type Client struct {
+	cl clientType.Client
+}
+
+// NewClient returns a Client that calls actions with cl.
+func NewClient(cl clientType.Client) *Client {
+	return &Client{cl: cl}
+}
+{{- if .Call}}
+
+// call calls an action, and returns the values of the rows it returns.
+func (c *Client) call(ctx context.Context, action string, args ...any) ([][]any, error) {
+	res, err := c.cl.Call(ctx, Namespace, action, args)
+	if err != nil {
+		return nil, err
+	}
+	if res.Error != nil {
+		return nil, errors.New(*res.Error)
+	}
+	if res.QueryResult == nil {
+		return nil, nil
+	}
+	return res.QueryResult.Values, nil
+}
+{{- end}}
+{{- range .Actions}}
+{{- if .Fields}}
+
+// {{.Row}} is a row returned by the {{.Name}} action.
+type {{.Row}} struct {
+{{- range .Fields}}
+	{{.Name}} {{.Type}}
+{{- end}}
+}
+{{- end}}
+{{- if .View}}
+
+// {{.Func}} calls the {{.Name}} action.
+func (c *Client) {{.Func}}(ctx context.Context{{range .Params}}, {{.Name}} {{.Type}}{{end}}) {{if .Table}}([]*{{.Row}}, error){{else if .Fields}}(*{{.Row}}, error){{else}}error{{end}} {
+{{- if not .Fields}}
+	_, err := c.call(ctx, "{{.Name}}"{{range .Params}}, {{.Name}}{{end}})
+	return err
+{{- else}}
+	vals, err := c.call(ctx, "{{.Name}}"{{range .Params}}, {{.Name}}{{end}})
+	if err != nil {
+		return nil, err
+	}
+{{- if .Table}}
+
+	rows := make([]*{{.Row}}, len(vals))
+	for i, v := range vals {
+		rows[i] = &{{.Row}}{}
+		if err := types.ScanTo(v{{range .Fields}}, &rows[i].{{.Name}}{{end}}); err != nil {
+			return nil, err
+		}
+	}
+	return rows, nil
+{{- else}}
+	if len(vals) == 0 {
+		return nil, nil
+	}
+
+	row := &{{.Row}}{}
+	if err := types.ScanTo(vals[0]{{range .Fields}}, &row.{{.Name}}{{end}}); err != nil {
+		return nil, err
+	}
+	return row, nil
+{{- end}}
+{{- end}}
+}
+{{- else}}
+
+// {{.Func}} executes the {{.Name}} action in a transaction, and returns the
+// transaction hash.
+func (c *Client) {{.Func}}(ctx context.Context{{range .Params}}, {{.Name}} {{.Type}}{{end}}, opts ...clientType.TxOpt) (types.Hash, error) {
+	return c.cl.Execute(ctx, Namespace, "{{.Name}}", [][]any{ { {{- args .Params -}} } }, opts...)
+}
+{{- end}}
+{{- end}}
+`))
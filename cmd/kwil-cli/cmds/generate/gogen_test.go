@@ -0,0 +1,63 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const schema = `
+CREATE TABLE users (id INT8 PRIMARY KEY, name TEXT, balance NUMERIC(10,2));
+
+CREATE ACTION create_user($id INT8, $name TEXT, $type TEXT[]) public {
+    INSERT INTO users (id, name) VALUES ($id, $name);
+};
+
+CREATE ACTION get_balance($user_id INT8) public view returns (balance NUMERIC(10,2)) {
+    for $row in SELECT balance FROM users WHERE id = $user_id {
+        return $row.balance;
+    }
+};
+
+CREATE ACTION list_users() public view returns table(id INT8, name TEXT) {
+    return SELECT id, name FROM users;
+};
+
+CREATE ACTION cleanup() private {
+    DELETE FROM users;
+};
+`
+
+func Test_generateGo(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "schema.sql")
+	require.NoError(t, os.WriteFile(file, []byte(schema), 0644))
+
+	actions, err := readActions(file, "app")
+	require.NoError(t, err)
+	// private actions are skipped
+	require.Len(t, actions, 3)
+
+	src, err := generateGo("app", "app", actions)
+	require.NoError(t, err)
+
+	for _, want := range []string{
+		"package app\n",
+		`const Namespace = "app"`,
+		// keywords are renamed
+		"func (c *Client) CreateUser(ctx context.Context, id int64, name string, typeArg []string, opts ...clientType.TxOpt) (types.Hash, error) {",
+		`return c.cl.Execute(ctx, Namespace, "create_user", [][]any{{id, name, typeArg}}, opts...)`,
+		// actions that do not return a table return one row
+		"func (c *Client) GetBalance(ctx context.Context, userID int64) (*GetBalanceRow, error) {",
+		"\tBalance types.Decimal\n",
+		"func (c *Client) ListUsers(ctx context.Context) ([]*ListUsersRow, error) {",
+		"types.ScanTo(v, &rows[i].ID, &rows[i].Name)",
+	} {
+		require.Contains(t, string(src), want)
+	}
+	require.NotContains(t, string(src), "Cleanup")
+
+	_, err = generateGo("app-client", "app", actions)
+	require.Error(t, err)
+}
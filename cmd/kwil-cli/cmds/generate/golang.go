@@ -0,0 +1,231 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/ddl"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+var (
+	goLong = `Generate a typed Go client for the actions of a namespace.
+
+The generated file has a ` + "`Client`" + ` that wraps a Kwil client, with a method
+for each public action of the namespace. The methods take the parameters of
+the action with their Go types. View actions are called, and the rows they
+return are scanned into a struct for each action; other actions are executed
+in a transaction, and return its hash. Private and system actions, which
+cannot be called by clients, are skipped.
+
+The actions are read from the Kuneiform file given by ` + "`--file`" + `, or from
+the node if no file is given. The Go types of the Kwil types are:
+
+    INT8     int64
+    TEXT     string
+    BOOL     bool
+    BYTEA    []byte
+    UUID     types.UUID
+    NUMERIC  types.Decimal
+
+where ` + "`types`" + ` is github.com/kwilteam/kwil-db/core/types, and arrays are
+slices. Regenerate the file when the schema changes, so that calls with the
+wrong parameters fail to compile.`
+
+	goExample = `# Generate a client for the "main" namespace on the node
+kwil-cli generate go --out ./kwilclient/main.go
+
+# Generate a client in package "app" from a Kuneiform file
+kwil-cli generate go --file ./schema.sql --namespace app --package app --out ./app/actions.go`
+)
+
+func goCmd() *cobra.Command {
+	var file, namespace, pkg, out string
+
+	cmd := &cobra.Command{
+		Use:     "go",
+		Short:   "Generate a typed Go client for the actions of a namespace.",
+		Long:    goLong,
+		Example: goExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if pkg == "" {
+				pkg = packageName(namespace)
+			}
+
+			generate := func(actions []*action) error {
+				src, err := generateGo(pkg, namespace, actions)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				if out == "" {
+					_, err = cmd.OutOrStdout().Write(src)
+					return err
+				}
+
+				expanded, err := helpers.ExpandPath(out)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				if err := os.WriteFile(expanded, src, 0644); err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				return display.PrintCmd(cmd, display.RespString(fmt.Sprintf("generated %d actions in %s", len(actions), out)))
+			}
+
+			if file != "" {
+				actions, err := readActions(file, namespace)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				return generate(actions)
+			}
+
+			return client.DialClient(cmd.Context(), cmd, client.WithoutPrivateKey, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				actions, err := loadActions(ctx, cl, namespace)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				return generate(actions)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&file, "file", "f", "", "the Kuneiform file that defines the actions, instead of reading them from the node")
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", engine.DefaultNamespace, "the namespace of the actions")
+	cmd.Flags().StringVar(&pkg, "package", "", "the name of the generated Go package (default is the namespace)")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "the file to write the generated code to, instead of stdout")
+	return cmd
+}
+
+// packageName returns a Go package name for a namespace.
+func packageName(namespace string) string {
+	return strings.ToLower(strings.ReplaceAll(namespace, "_", ""))
+}
+
+// readActions reads the actions of a namespace from a Kuneiform file.
+func readActions(file, namespace string) ([]*action, error) {
+	expanded, err := helpers.ExpandPath(file)
+	if err != nil {
+		return nil, err
+	}
+	bts, err := os.ReadFile(expanded)
+	if err != nil {
+		return nil, err
+	}
+
+	stmts, err := parse.Parse(string(bts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	def, err := ddl.FromStatements(namespace, stmts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+
+	var actions []*action
+	for _, act := range def.Actions {
+		if !callable(act.Modifiers) {
+			continue
+		}
+		actions = append(actions, &action{
+			Name:       act.Name,
+			Parameters: act.Parameters,
+			View:       hasModifier(act.Modifiers, "view"),
+			Returns:    act.Returns,
+		})
+	}
+	return actions, nil
+}
+
+// loadActions reads the actions of a namespace from the info namespace of a
+// node.
+func loadActions(ctx context.Context, cl clientType.Client, namespace string) ([]*action, error) {
+	res, err := cl.Query(ctx, `SELECT name, access_modifiers, parameter_names, parameter_types, return_names, return_types, returns_table
+	FROM info.actions WHERE namespace = $namespace`, map[string]any{"namespace": namespace}, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var actions []*action
+	var name string
+	var modifiers, paramNames, paramTypes, returnNames, returnTypes []string
+	var returnsTable bool
+	err = res.Scan(func() error {
+		if !callable(modifiers) {
+			return nil
+		}
+
+		act := &action{Name: name, View: hasModifier(modifiers, "view")}
+		var err error
+		act.Parameters, err = namedTypes(paramNames, paramTypes)
+		if err != nil {
+			return fmt.Errorf(`action "%s": %w`, name, err)
+		}
+		if len(returnNames) > 0 {
+			act.Returns = &parse.ActionReturn{IsTable: returnsTable}
+			act.Returns.Fields, err = namedTypes(returnNames, returnTypes)
+			if err != nil {
+				return fmt.Errorf(`action "%s": %w`, name, err)
+			}
+		}
+
+		actions = append(actions, act)
+		return nil
+	}, &name, &modifiers, &paramNames, &paramTypes, &returnNames, &returnTypes, &returnsTable)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(actions) == 0 {
+		ns, err := cl.Query(ctx, "SELECT name FROM info.namespaces WHERE name = $namespace", map[string]any{"namespace": namespace}, true)
+		if err != nil {
+			return nil, err
+		}
+		if len(ns.Values) == 0 {
+			return nil, fmt.Errorf(`%w: "%s"`, engine.ErrNamespaceNotFound, namespace)
+		}
+	}
+	return actions, nil
+}
+
+// namedTypes returns the named types with the given names and types.
+func namedTypes(names, typeNames []string) ([]*engine.NamedType, error) {
+	if len(names) != len(typeNames) {
+		return nil, errors.New("names and types have different lengths")
+	}
+
+	named := make([]*engine.NamedType, len(names))
+	for i, name := range names {
+		dt, err := types.ParseDataType(typeNames[i])
+		if err != nil {
+			return nil, err
+		}
+		named[i] = &engine.NamedType{Name: name, Type: dt}
+	}
+	return named, nil
+}
+
+// callable returns true if an action with the modifiers can be called by
+// clients.
+func callable(modifiers []string) bool {
+	return !hasModifier(modifiers, "private") && !hasModifier(modifiers, "system")
+}
+
+func hasModifier(modifiers []string, mod string) bool {
+	return slices.ContainsFunc(modifiers, func(m string) bool { return strings.EqualFold(m, mod) })
+}
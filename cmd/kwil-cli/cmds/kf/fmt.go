@@ -0,0 +1,152 @@
+package kf
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	"github.com/kwilteam/kwil-db/node/engine/format"
+)
+
+var (
+	fmtLong = `Format Kuneiform files in the canonical style.
+
+The files are parsed and printed from the syntax tree, so formatting is
+idempotent, and any file that does not parse is reported as an error. SQL
+keywords are upper case, action keywords and modifiers are lower case, blocks
+are indented with four spaces, and SQL statements that do not fit on a line
+are split into one clause per line. Comments are kept.
+
+By default, the formatted source is printed. With ` + "`--write`" + `, the files
+are rewritten in place instead. With ` + "`--check`" + `, the files that are not
+formatted are listed and the command exits with a non-zero code if there are
+any, which is useful in CI. If no files are given, the source is read from
+stdin.`
+
+	fmtExample = `# Print a formatted file
+kwil-cli kf fmt ./schema.sql
+
+# Format files in place
+kwil-cli kf fmt --write ./schema.sql ./actions.sql
+
+# Fail if any file is not formatted
+kwil-cli kf fmt --check ./*.sql`
+)
+
+func fmtCmd() *cobra.Command {
+	var write, check bool
+
+	cmd := &cobra.Command{
+		Use:     "fmt [<file>...]",
+		Short:   "Format Kuneiform files in the canonical style.",
+		Long:    fmtLong,
+		Example: fmtExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if write && check {
+				return display.PrintErr(cmd, errors.New("cannot specify both --write and --check"))
+			}
+			if write && len(args) == 0 {
+				return display.PrintErr(cmd, errors.New("--write requires files"))
+			}
+
+			files, err := readSources(cmd, args)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			res := &respFormat{check: check}
+			for _, file := range files {
+				formatted, err := format.Format(file.Src)
+				if err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("%s: %w", file.Name, err))
+				}
+
+				changed := formatted != file.Src
+				switch {
+				case check:
+					if changed {
+						res.Unformatted = append(res.Unformatted, file.Name)
+					}
+				case write:
+					if !changed {
+						continue
+					}
+					path, err := helpers.ExpandPath(file.Name)
+					if err != nil {
+						return display.PrintErr(cmd, err)
+					}
+					if err = os.WriteFile(path, []byte(formatted), 0644); err != nil {
+						return display.PrintErr(cmd, err)
+					}
+					res.Written = append(res.Written, file.Name)
+				default:
+					res.Files = append(res.Files, &formattedFile{Name: file.Name, Source: formatted})
+				}
+			}
+
+			if len(res.Unformatted) > 0 {
+				return display.PrintErr(cmd, fmt.Errorf("files are not formatted:\n%s", strings.Join(res.Unformatted, "\n")))
+			}
+
+			return display.PrintCmd(cmd, res)
+		},
+	}
+
+	cmd.Flags().BoolVarP(&write, "write", "w", false, "write the formatted source back to the files")
+	cmd.Flags().BoolVar(&check, "check", false, "list the files that are not formatted, and fail if there are any")
+
+	return cmd
+}
+
+type formattedFile struct {
+	Name   string `json:"name"`
+	Source string `json:"source"`
+}
+
+// respFormat is the result of formatting files.
+type respFormat struct {
+	check bool
+	// Files are the formatted files, if they were not written or checked.
+	Files []*formattedFile `json:"files,omitempty"`
+	// Written are the files that were rewritten.
+	Written []string `json:"written,omitempty"`
+	// Unformatted are the files that are not formatted, if checking.
+	Unformatted []string `json:"unformatted,omitempty"`
+}
+
+func (r *respFormat) MarshalJSON() ([]byte, error) {
+	type alias respFormat
+	return json.Marshal((*alias)(r))
+}
+
+func (r *respFormat) MarshalText() ([]byte, error) {
+	if r.check {
+		return []byte("All files are formatted."), nil
+	}
+
+	if r.Files == nil {
+		if len(r.Written) == 0 {
+			return []byte("No files changed."), nil
+		}
+		return []byte("Formatted:\n" + strings.Join(r.Written, "\n")), nil
+	}
+
+	var sb strings.Builder
+	for i, f := range r.Files {
+		if len(r.Files) > 1 {
+			if i > 0 {
+				sb.WriteString("\n")
+			}
+			fmt.Fprintf(&sb, "-- %s\n", f.Name)
+		}
+		sb.WriteString(f.Source)
+	}
+	// the output is printed with a trailing newline
+	return []byte(strings.TrimSuffix(sb.String(), "\n")), nil
+}
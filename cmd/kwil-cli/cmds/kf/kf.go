@@ -0,0 +1,68 @@
+// Package kf contains the kwil-cli commands for working with Kuneiform source
+// files, such as formatting and linting them.
+package kf
+
+import (
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+)
+
+var kfLong = `Kuneiform source file commands.
+
+These commands work on local Kuneiform files, and do not connect to a node.
+They are meant to be used while editing and in CI, and exit with a non-zero
+code when a check fails.`
+
+func NewCmdKf() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "kf",
+		Short: "Kuneiform source file commands.",
+		Long:  kfLong,
+	}
+
+	cmd.AddCommand(
+		fmtCmd(),
+		lintCmd(),
+	)
+
+	return cmd
+}
+
+// stdinName is the name used for source read from stdin.
+const stdinName = "<stdin>"
+
+// sourceFile is a Kuneiform source file.
+type sourceFile struct {
+	Name string
+	Src  string
+}
+
+// readSources reads the files at the given paths, or stdin if there are none.
+func readSources(cmd *cobra.Command, paths []string) ([]*sourceFile, error) {
+	if len(paths) == 0 {
+		bts, err := io.ReadAll(cmd.InOrStdin())
+		if err != nil {
+			return nil, err
+		}
+		return []*sourceFile{{Name: stdinName, Src: string(bts)}}, nil
+	}
+
+	files := make([]*sourceFile, len(paths))
+	for i, path := range paths {
+		expanded, err := helpers.ExpandPath(path)
+		if err != nil {
+			return nil, err
+		}
+
+		bts, err := os.ReadFile(expanded)
+		if err != nil {
+			return nil, err
+		}
+		files[i] = &sourceFile{Name: path, Src: string(bts)}
+	}
+	return files, nil
+}
@@ -0,0 +1,117 @@
+package kf
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared"
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/node/engine/lint"
+)
+
+var (
+	lintLong = `Check Kuneiform files for likely mistakes.
+
+The files are parsed, and the actions they create are checked with the
+following rules:
+
+  - ` + "`unused-variable`" + `: a local variable is assigned but never read.
+  - ` + "`unreachable-code`" + `: a statement follows a ` + "`return`, `break`, or `continue`" + `.
+  - ` + "`missing-access-modifier`" + `: an action has none of ` + "`public`, `private`, or `system`" + `.
+  - ` + "`unordered-loop`" + `: a loop iterates over a SELECT without an ORDER BY.
+  - ` + "`shadowed-variable`" + `: a variable in a nested block has the name of an outer
+    variable or parameter.
+
+Each problem is printed as ` + "`file:line:col: rule: message`" + `, and the command
+exits with a non-zero code if there are any, which is useful in CI. Rules can
+be turned off with ` + "`--disable`" + `. If no files are given, the source is read
+from stdin.`
+
+	lintExample = `# Lint files
+kwil-cli kf lint ./schema.sql ./actions.sql
+
+# Lint without checking loop ordering
+kwil-cli kf lint --disable unordered-loop ./schema.sql`
+)
+
+func lintCmd() *cobra.Command {
+	var disabled []string
+
+	cmd := &cobra.Command{
+		Use:     "lint [<file>...]",
+		Short:   "Check Kuneiform files for likely mistakes.",
+		Long:    lintLong,
+		Example: lintExample,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			for _, rule := range disabled {
+				if !slices.Contains(lint.Rules, rule) {
+					return display.PrintErr(cmd, fmt.Errorf("unknown rule %q, must be one of: %s", rule, strings.Join(lint.Rules, ", ")))
+				}
+			}
+
+			files, err := readSources(cmd, args)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			res := &respLint{}
+			for _, file := range files {
+				diags, err := lint.Lint(file.Src)
+				if err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("%s: %w", file.Name, err))
+				}
+
+				for _, d := range diags {
+					if slices.Contains(disabled, d.Rule) {
+						continue
+					}
+					res.Problems = append(res.Problems, &problem{File: file.Name, Diagnostic: d})
+				}
+			}
+
+			if err := display.PrintCmd(cmd, res); err != nil {
+				return err
+			}
+			if len(res.Problems) > 0 {
+				// exit with a non-zero code, but the problems were already printed
+				shared.SetCmdCtxErr(cmd, fmt.Errorf("found %d problems", len(res.Problems)))
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().StringSliceVar(&disabled, "disable", nil, "rules to turn off")
+
+	return cmd
+}
+
+type problem struct {
+	File string `json:"file"`
+	*lint.Diagnostic
+}
+
+// respLint is the result of linting files.
+type respLint struct {
+	Problems []*problem `json:"problems"`
+}
+
+func (r *respLint) MarshalJSON() ([]byte, error) {
+	type alias respLint
+	return json.Marshal((*alias)(r))
+}
+
+func (r *respLint) MarshalText() ([]byte, error) {
+	if len(r.Problems) == 0 {
+		return []byte("No problems found."), nil
+	}
+
+	lines := make([]string, len(r.Problems))
+	for i, p := range r.Problems {
+		lines[i] = p.File + ":" + p.Diagnostic.String()
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
@@ -0,0 +1,89 @@
+package multisig
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	rpcclient "github.com/kwilteam/kwil-db/core/rpc/client"
+	"github.com/kwilteam/kwil-db/core/rpc/client/user"
+)
+
+var (
+	broadcastLong = `Broadcast a multisig transaction file.
+
+The signatures are verified before broadcasting, so a transaction without
+enough valid member signatures is reported without being sent.`
+
+	broadcastExample = `# Broadcast tx.json and wait for it to be included in a block
+kwil-cli multisig broadcast tx.json --sync`
+)
+
+func broadcastCmd() *cobra.Command {
+	var syncBcast bool
+
+	cmd := &cobra.Command{
+		Use:     "broadcast <tx-file>",
+		Short:   "Broadcast a signed multisig transaction file.",
+		Long:    broadcastLong,
+		Example: broadcastExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tx, err := common.ReadTxFile(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			if _, err = tx.MultiSignature(); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			msg, err := tx.SerializeMsg()
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			err = auth.MultisigAuthenticator{}.Verify(tx.Sender, msg, tx.Signature.Data)
+			if err != nil {
+				return display.PrintErr(cmd, fmt.Errorf("invalid multisig transaction: %w", err))
+			}
+
+			return client.DialClient(cmd.Context(), cmd, client.WithoutPrivateKey, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				svc, ok := cl.(interface{ SvcClient() user.TxSvcClient })
+				if !ok {
+					return display.PrintErr(cmd, errors.New("client cannot broadcast transactions"))
+				}
+
+				wait := rpcclient.BroadcastWaitAccept
+				if syncBcast {
+					wait = rpcclient.BroadcastWaitCommit
+				}
+				txHash, err := svc.SvcClient().Broadcast(ctx, tx, wait)
+				if err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("broadcast failed: %w", err))
+				}
+
+				if len(txHash) != 0 && syncBcast {
+					time.Sleep(500 * time.Millisecond) // otherwise it says not found at first
+					resp, err := cl.TxQuery(ctx, txHash)
+					if err != nil {
+						return display.PrintErr(cmd, fmt.Errorf("tx query failed: %w", err))
+					}
+					return display.PrintCmd(cmd, display.NewTxHashAndExecResponse(resp))
+				}
+				return display.PrintCmd(cmd, display.RespTxHash(txHash))
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&syncBcast, "sync", false, "synchronous broadcast (wait for it to be included in a block)")
+
+	return cmd
+}
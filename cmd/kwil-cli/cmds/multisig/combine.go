@@ -0,0 +1,74 @@
+package multisig
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+)
+
+var (
+	combineLong = `Merge the member signatures from several signed copies of a multisig transaction.
+
+All of the files must be copies of the same transaction. The combined
+transaction is written to the file given with ` + "`--out`" + `.`
+
+	combineExample = `# Combine the signatures of two members
+kwil-cli multisig combine tx-alice.json tx-bob.json --out tx.json`
+)
+
+func combineCmd() *cobra.Command {
+	var out string
+
+	cmd := &cobra.Command{
+		Use:     "combine <tx-file>...",
+		Short:   "Combine signatures from multisig transaction files.",
+		Long:    combineLong,
+		Example: combineExample,
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tx, err := common.ReadTxFile(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			msig, err := tx.MultiSignature()
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			body := tx.Body.Bytes()
+
+			for _, file := range args[1:] {
+				other, err := common.ReadTxFile(file)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				if !bytes.Equal(other.Body.Bytes(), body) || !bytes.Equal(other.Sender, tx.Sender) ||
+					other.Serialization != tx.Serialization {
+					return display.PrintErr(cmd, fmt.Errorf("%s is not the same transaction as %s", file, args[0]))
+				}
+				otherSig, err := other.MultiSignature()
+				if err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("%s: %w", file, err))
+				}
+				if err = msig.Merge(otherSig); err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("%s: %w", file, err))
+				}
+			}
+
+			tx.Signature = msig.Signature()
+			if err = common.WriteJSONFile(out, tx); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, newRespTxFile(out, tx, msig))
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write the combined transaction to")
+	cmd.MarkFlagRequired("out")
+
+	return cmd
+}
@@ -0,0 +1,92 @@
+package multisig
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+)
+
+var (
+	createLong = `Create a multisig account from a threshold and the member account IDs.
+
+Each member is given as ` + "`<type>:<id>`" + `, where type is the signature type of
+the member's signer and id is its hexadecimal compact ID. An Ethereum address
+may be given without a type, in which case the type is ` + "`" + auth.EthPersonalSignAuth + "`" + `,
+as used by ` + "`kwil-cli`" + ` keys.
+
+The members are sorted, so the account ID does not depend on their order. The
+multisig file written with ` + "`--out`" + ` is used to create transactions.`
+
+	createExample = `# Create a 2-of-3 multisig of Ethereum accounts
+kwil-cli multisig create 2 0xc89D42189f0450C2b2c3c61f58Ec5d628176A1E7 \
+  0x6B0C5dC1B7C4D2c8b6B1aF3b5a1E0d3C9d1E2F3a \
+  ed25519:0aa611bf555596912bc6f9a9f169f8785918e7bab9924001895798ff13f05842 \
+  --out treasury.json`
+)
+
+func createCmd() *cobra.Command {
+	var out string
+
+	cmd := &cobra.Command{
+		Use:     "create <threshold> <member>...",
+		Short:   "Create a multisig account.",
+		Long:    createLong,
+		Example: createExample,
+		Args:    cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			threshold, err := strconv.ParseUint(args[0], 10, 16)
+			if err != nil {
+				return display.PrintErr(cmd, fmt.Errorf("invalid threshold: %w", err))
+			}
+
+			members := make([]auth.MultisigMember, len(args)-1)
+			for i, arg := range args[1:] {
+				if members[i], err = parseMember(arg); err != nil {
+					return display.PrintErr(cmd, err)
+				}
+			}
+
+			ms, err := auth.NewMultisig(uint16(threshold), members)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			mf := newMultisigFile(ms)
+			if out != "" {
+				if err = common.WriteJSONFile(out, mf); err != nil {
+					return display.PrintErr(cmd, err)
+				}
+			}
+
+			return display.PrintCmd(cmd, &respMultisig{multisigFile: mf, File: out})
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write the multisig to")
+
+	return cmd
+}
+
+// parseMember parses a member in the form <type>:<hex id>, or an Ethereum
+// address.
+func parseMember(s string) (auth.MultisigMember, error) {
+	typ, id, found := strings.Cut(s, ":")
+	if !found {
+		typ, id = auth.EthPersonalSignAuth, s
+	}
+	bts, err := hex.DecodeString(strings.TrimPrefix(id, "0x"))
+	if err != nil {
+		return auth.MultisigMember{}, fmt.Errorf("invalid member ID %q: %w", id, err)
+	}
+	if typ == auth.EthPersonalSignAuth && len(bts) != auth.EthAddressIdentLength {
+		return auth.MultisigMember{}, fmt.Errorf("member %q is not an Ethereum address", s)
+	}
+	return auth.MultisigMember{Type: typ, ID: bts}, nil
+}
@@ -0,0 +1,145 @@
+// Package multisig contains the kwil-cli commands for multi-signature
+// accounts. A transaction from a multisig account is created as an unsigned
+// transaction file, which is passed between the members to sign, and then
+// broadcast once enough members have signed.
+package multisig
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var multisigLong = `Multi-signature (M-of-N) account commands.
+
+A multisig account is controlled by a set of member keys, of which at least a
+threshold number must sign each transaction. Its account ID is derived from the
+members and the threshold.
+
+Transactions from a multisig account are prepared offline in a transaction file:
+
+  1. ` + "`create`" + ` writes a multisig file describing the account.
+  2. ` + "`transfer`" + ` creates an unsigned transaction file for the account.
+  3. Each member runs ` + "`sign`" + ` on the transaction file with their own key.
+  4. ` + "`combine`" + ` merges the signatures from several signed copies.
+  5. ` + "`broadcast`" + ` sends the transaction once enough members have signed.`
+
+func NewCmdMultisig() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "multisig",
+		Short: "Multi-signature account commands.",
+		Long:  multisigLong,
+	}
+
+	cmd.AddCommand(
+		createCmd(),
+		transferCmd(),
+		signCmd(),
+		combineCmd(),
+		broadcastCmd(),
+	)
+
+	return cmd
+}
+
+// multisigFile is the JSON file describing a multisig account.
+type multisigFile struct {
+	AccountID types.HexBytes `json:"account_id"`
+	Threshold uint16         `json:"threshold"`
+	Members   []memberFile   `json:"members"`
+}
+
+type memberFile struct {
+	Type string         `json:"type"`
+	ID   types.HexBytes `json:"id"`
+}
+
+func newMultisigFile(ms *auth.Multisig) *multisigFile {
+	mf := &multisigFile{
+		AccountID: ms.ID(),
+		Threshold: ms.Threshold,
+	}
+	for _, m := range ms.Members {
+		mf.Members = append(mf.Members, memberFile{Type: m.Type, ID: m.ID})
+	}
+	return mf
+}
+
+func readMultisig(path string) (*auth.Multisig, error) {
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var mf multisigFile
+	if err = json.Unmarshal(bts, &mf); err != nil {
+		return nil, fmt.Errorf("invalid multisig file: %w", err)
+	}
+	members := make([]auth.MultisigMember, len(mf.Members))
+	for i, m := range mf.Members {
+		members[i] = auth.MultisigMember{Type: m.Type, ID: m.ID}
+	}
+	return auth.NewMultisig(mf.Threshold, members)
+}
+
+// respMultisig is the output of the create command.
+type respMultisig struct {
+	*multisigFile
+	File string `json:"file,omitempty"`
+}
+
+func (r *respMultisig) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		*multisigFile
+		File string `json:"file,omitempty"`
+	}{r.multisigFile, r.File})
+}
+
+func (r *respMultisig) MarshalText() ([]byte, error) {
+	msg := fmt.Sprintf("Account ID: %s (%s)\nThreshold: %d of %d\n",
+		r.AccountID, auth.MultisigAuth, r.Threshold, len(r.Members))
+	for i, m := range r.Members {
+		msg += fmt.Sprintf("Member %d: %s (%s)\n", i, m.ID, m.Type)
+	}
+	if r.File != "" {
+		msg += fmt.Sprintf("Written to %s\n", r.File)
+	}
+	return []byte(msg), nil
+}
+
+// respTxFile describes a multisig transaction file and its signatures.
+type respTxFile struct {
+	File      string         `json:"file"`
+	AccountID types.HexBytes `json:"account_id"`
+	Nonce     uint64         `json:"nonce"`
+	Signed    []int          `json:"signed"`
+	Threshold uint16         `json:"threshold"`
+}
+
+func newRespTxFile(path string, tx *types.Transaction, msig *auth.MultiSignature) *respTxFile {
+	signed := make([]int, len(msig.Signatures))
+	for i, s := range msig.Signatures {
+		signed[i] = int(s.Index)
+	}
+	return &respTxFile{
+		File:      path,
+		AccountID: tx.Sender,
+		Nonce:     tx.Body.Nonce,
+		Signed:    signed,
+		Threshold: msig.Multisig.Threshold,
+	}
+}
+
+func (r *respTxFile) MarshalJSON() ([]byte, error) {
+	type alias respTxFile
+	return json.Marshal((*alias)(r))
+}
+
+func (r *respTxFile) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("Transaction file: %s\nAccount ID: %s\nNonce: %d\nSignatures: %d of %d required (members %v)\n",
+		r.File, r.AccountID, r.Nonce, len(r.Signed), r.Threshold, r.Signed)), nil
+}
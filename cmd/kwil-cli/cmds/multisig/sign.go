@@ -0,0 +1,76 @@
+package multisig
+
+import (
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+)
+
+var (
+	signLong = `Add a signature to a multisig transaction file with the configured private key.
+
+The key must belong to a member of the multisig. The file is updated in place
+unless ` + "`--out`" + ` is given, so that members may either sign the same file in
+turn, or sign separate copies to be merged with ` + "`kwil-cli multisig combine`" + `.
+Signing does not require a connection to a node.`
+
+	signExample = `# Sign tx.json with the configured key
+kwil-cli multisig sign tx.json
+
+# Sign with another key, writing a separate copy
+kwil-cli multisig sign tx.json --private-key <hex> --out tx-bob.json`
+)
+
+func signCmd() *cobra.Command {
+	var out string
+
+	cmd := &cobra.Command{
+		Use:     "sign <tx-file>",
+		Short:   "Sign a multisig transaction file.",
+		Long:    signLong,
+		Example: signExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := config.ActiveConfig()
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			if conf.PrivateKey == nil {
+				return display.PrintErr(cmd, errors.New("no private key configured"))
+			}
+			signer := &auth.EthPersonalSigner{Key: *conf.PrivateKey}
+
+			tx, err := common.ReadTxFile(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			if err = tx.SignMultisig(signer); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			msig, err := tx.MultiSignature()
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			if out == "" {
+				out = args[0]
+			}
+			if err = common.WriteJSONFile(out, tx); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, newRespTxFile(out, tx, msig))
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write the signed transaction to (default is to update the input file)")
+
+	return cmd
+}
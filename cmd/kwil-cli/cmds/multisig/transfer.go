@@ -0,0 +1,135 @@
+package multisig
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/rpc/client/user"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var (
+	transferLong = `Create an unsigned transfer from a multisig account.
+
+The transaction is written to the file given with ` + "`--out`" + `, to be signed by the
+members with ` + "`kwil-cli multisig sign`" + `. The nonce and fee are requested from
+the node unless they are given with ` + "`--nonce`" + ` and ` + "`--fee`" + `.`
+
+	transferExample = `# Transfer 100 from the multisig in treasury.json
+kwil-cli multisig transfer treasury.json 0xc89D42189f0450C2b2c3c61f58Ec5d628176A1E7 100 --out tx.json`
+)
+
+func transferCmd() *cobra.Command {
+	var out, keyTypeStr, feeStr string
+	var nonce int64
+
+	cmd := &cobra.Command{
+		Use:     "transfer <multisig-file> <recipientID> <amount>",
+		Short:   "Create an unsigned transfer from a multisig account.",
+		Long:    transferLong,
+		Example: transferExample,
+		Args:    cobra.ExactArgs(3),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ms, err := readMultisig(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			id, err := hex.DecodeString(strings.TrimPrefix(args[1], "0x"))
+			if err != nil {
+				return display.PrintErr(cmd, fmt.Errorf("failed to decode account ID: %w", err))
+			}
+
+			amount, ok := new(big.Int).SetString(args[2], 10)
+			if !ok {
+				return display.PrintErr(cmd, errors.New("invalid decimal amount"))
+			}
+
+			var fee *big.Int
+			if feeStr != "" {
+				if fee, ok = new(big.Int).SetString(feeStr, 10); !ok {
+					return display.PrintErr(cmd, errors.New("invalid decimal fee"))
+				}
+			}
+
+			payload := &types.Transfer{
+				To: &types.AccountID{
+					Identifier: id,
+					KeyType:    crypto.KeyType(keyTypeStr),
+				},
+				Amount: amount,
+			}
+
+			return client.DialClient(cmd.Context(), cmd, client.WithoutPrivateKey, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				tx, err := newMultisigTx(ctx, cl, ms, payload, nonce, fee)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				if err = common.WriteJSONFile(out, tx); err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				return display.PrintCmd(cmd, newRespTxFile(out, tx, &auth.MultiSignature{Multisig: *ms}))
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write the unsigned transaction to")
+	cmd.Flags().StringVarP(&keyTypeStr, "keytype", "t", crypto.KeyTypeSecp256k1.String(), "key type of the recipient account ID (default secp256k1 for Ethereum)")
+	cmd.Flags().Int64VarP(&nonce, "nonce", "N", -1, "nonce override (-1 means request from server)")
+	cmd.Flags().StringVar(&feeStr, "fee", "", "fee override (default is the node's estimate)")
+	cmd.MarkFlagRequired("out")
+
+	return cmd
+}
+
+// newMultisigTx creates an unsigned transaction from the multisig account. If
+// the nonce is not positive, the account's next nonce is requested from the
+// node, and if the fee is nil, it is estimated by the node.
+func newMultisigTx(ctx context.Context, cl clientType.Client, ms *auth.Multisig, payload types.Payload, nonce int64, fee *big.Int) (*types.Transaction, error) {
+	if nonce <= 0 {
+		acct, err := cl.GetAccount(ctx, &types.AccountID{
+			Identifier: ms.ID(),
+			KeyType:    crypto.KeyTypeMultisig,
+		}, types.AccountStatusPending)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get multisig account: %w", err)
+		}
+		nonce = acct.Nonce + 1
+	}
+
+	tx, err := types.CreateTransaction(payload, cl.ChainID(), uint64(nonce))
+	if err != nil {
+		return nil, err
+	}
+	if err = tx.SetMultisig(ms); err != nil {
+		return nil, err
+	}
+
+	if fee == nil {
+		svc, ok := cl.(interface{ SvcClient() user.TxSvcClient })
+		if !ok {
+			return nil, errors.New("client cannot estimate fees, use --fee")
+		}
+		if fee, err = svc.SvcClient().EstimateCost(ctx, tx); err != nil {
+			return nil, fmt.Errorf("failed to estimate fee: %w", err)
+		}
+	}
+	tx.Body.Fee = fee
+
+	return tx, nil
+}
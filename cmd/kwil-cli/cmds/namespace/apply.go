@@ -0,0 +1,135 @@
+package namespace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/manifoldco/promptui"
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+var (
+	applyLong = `Apply a migration to a live namespace as a single transaction.
+
+The migration is either generated from a Kuneiform file, in the same way as
+` + "`namespace diff`" + `, or read from a script that was generated and reviewed
+before, given with ` + "`--migration`" + `. All statements are submitted in one
+raw statement transaction, so either all of them are applied or none are.
+
+The migration is printed, and must be confirmed before it is submitted, unless
+` + "`--assume-yes`" + ` is set.`
+
+	applyExample = `# Migrate the "app" namespace to the definition in a file
+kwil-cli namespace apply --file ./schema.sql --namespace app
+
+# Apply a reviewed migration, and wait for it to be included in a block
+kwil-cli namespace diff --file ./schema.sql --namespace app > migration.sql
+kwil-cli namespace apply --migration ./migration.sql --sync`
+)
+
+func applyCmd() *cobra.Command {
+	var file, namespace, migrationFile string
+
+	cmd := &cobra.Command{
+		Use:     "apply",
+		Short:   "Apply a migration to a live namespace as a single transaction.",
+		Long:    applyLong,
+		Example: applyExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if (file == "") == (migrationFile == "") {
+				return display.PrintErr(cmd, errors.New("exactly one of --file or --migration must be provided"))
+			}
+
+			txFlags, err := common.GetTxFlags(cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			assumeYes, err := helpers.GetAssumeYesFlag(cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return client.DialClient(cmd.Context(), cmd, 0, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				var script string
+				var destructive bool
+				if migrationFile != "" {
+					script, err = readMigration(migrationFile)
+					if err != nil {
+						return display.PrintErr(cmd, err)
+					}
+				} else {
+					m, err := migration(ctx, cl, file, namespace)
+					if err != nil {
+						return display.PrintErr(cmd, err)
+					}
+					if m.Empty() {
+						return display.PrintCmd(cmd, display.RespString(fmt.Sprintf(`namespace "%s" is up to date`, namespace)))
+					}
+					script = m.String()
+					destructive = m.Destructive()
+				}
+
+				if !assumeYes {
+					fmt.Fprintln(cmd.OutOrStdout(), script)
+
+					label := "Apply the migration? (y/n)"
+					if destructive {
+						label = "The migration deletes data. Apply it? (y/n)"
+					}
+					res, err := (&promptui.Prompt{Label: label, Default: "N"}).Run()
+					if err != nil {
+						return display.PrintErr(cmd, err)
+					}
+					if res != "Y" && res != "y" {
+						return display.PrintErr(cmd, errors.New("migration cancelled"))
+					}
+				}
+
+				txHash, err := cl.ExecuteSQL(ctx, script, nil, clientType.WithNonce(txFlags.NonceOverride), clientType.WithSyncBroadcast(txFlags.SyncBroadcast))
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				return common.DisplayTxResult(ctx, cl, txHash, cmd)
+			})
+		},
+	}
+
+	bindSchemaFlags(cmd, &file, &namespace)
+	cmd.Flags().StringVarP(&migrationFile, "migration", "m", "", "a migration script to apply, instead of generating it from --file")
+	common.BindTxFlags(cmd)
+	return cmd
+}
+
+// readMigration reads a migration script, checking that it parses.
+func readMigration(file string) (string, error) {
+	expanded, err := helpers.ExpandPath(file)
+	if err != nil {
+		return "", err
+	}
+	bts, err := os.ReadFile(expanded)
+	if err != nil {
+		return "", err
+	}
+
+	stmts, err := parse.Parse(string(bts))
+	if err != nil {
+		return "", fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	if len(stmts) == 0 {
+		return "", fmt.Errorf("%s has no statements", file)
+	}
+	return string(bts), nil
+}
@@ -0,0 +1,89 @@
+package namespace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/node/engine/ddl"
+)
+
+var (
+	diffLong = `Generate the migration from a live namespace to its definition in a Kuneiform file.
+
+The tables, columns, constraints, indexes, actions, and privileges of the
+namespace are read from the node, and compared with the CREATE TABLE, CREATE
+INDEX, CREATE ACTION, CREATE ROLE, USE, and GRANT statements in the file.
+Statements without a namespace prefix define the namespace given by
+` + "`--namespace`" + `. The migration is printed as a Kuneiform script, ordered so
+that it can be applied as a single transaction with ` + "`namespace apply`" + `.
+
+Statements that delete data, such as dropping a table or changing the type of
+a column, and statements that fail on existing data, are preceded by a
+` + "`-- WARNING`" + ` comment. Roles and extensions are created, but never
+dropped. If the namespace does not exist, the migration creates it.`
+
+	diffExample = `# Print the migration of the "main" namespace
+kwil-cli namespace diff --file ./schema.sql
+
+# Save the migration of the "app" namespace to review it
+kwil-cli namespace diff --file ./schema.sql --namespace app > migration.sql`
+)
+
+func diffCmd() *cobra.Command {
+	var file, namespace string
+
+	cmd := &cobra.Command{
+		Use:     "diff",
+		Short:   "Generate the migration from a live namespace to a Kuneiform file.",
+		Long:    diffLong,
+		Example: diffExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return client.DialClient(cmd.Context(), cmd, client.WithoutPrivateKey, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				m, err := migration(ctx, cl, file, namespace)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				return display.PrintCmd(cmd, &respMigration{Namespace: namespace, Migration: m})
+			})
+		},
+	}
+
+	bindSchemaFlags(cmd, &file, &namespace)
+	return cmd
+}
+
+// respMigration is the migration of a namespace.
+type respMigration struct {
+	Namespace string
+	Migration *ddl.Migration
+}
+
+func (r *respMigration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Namespace   string           `json:"namespace"`
+		Statements  []*ddl.Statement `json:"statements"`
+		Destructive bool             `json:"destructive"`
+	}{
+		Namespace:   r.Namespace,
+		Statements:  r.Migration.Statements,
+		Destructive: r.Migration.Destructive(),
+	})
+}
+
+func (r *respMigration) MarshalText() ([]byte, error) {
+	if r.Migration.Empty() {
+		return []byte(fmt.Sprintf(`-- namespace "%s" is up to date`, r.Namespace)), nil
+	}
+	// the output is printed with a trailing newline
+	return []byte(strings.TrimSuffix(r.Migration.String(), "\n")), nil
+}
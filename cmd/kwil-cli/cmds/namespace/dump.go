@@ -0,0 +1,81 @@
+package namespace
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/rpc/client/user"
+	"github.com/kwilteam/kwil-db/node/engine"
+)
+
+var (
+	dumpLong = `Print the DDL that defines a live namespace.
+
+The node reconstructs the Kuneiform statements that define the namespace from
+its tables, indexes, foreign keys, actions, and privileges, and the roles and
+extensions they use. The output can be saved to version the schema, compared
+with ` + "`namespace diff`" + `, or deployed to another network with
+` + "`exec-sql`" + ` or ` + "`namespace apply`" + `. The namespace, roles, and
+extensions are created only if they do not exist, so the output can be
+deployed to networks that already have them.`
+
+	dumpExample = `# Print the DDL of the "main" namespace
+kwil-cli namespace dump
+
+# Save the DDL of the "app" namespace
+kwil-cli namespace dump --namespace app > schema.sql`
+)
+
+func dumpCmd() *cobra.Command {
+	var namespace string
+
+	cmd := &cobra.Command{
+		Use:     "dump",
+		Short:   "Print the DDL that defines a live namespace.",
+		Long:    dumpLong,
+		Example: dumpExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return client.DialClient(cmd.Context(), cmd, client.WithoutPrivateKey, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				svc, ok := cl.(interface{ SvcClient() user.TxSvcClient })
+				if !ok {
+					return display.PrintErr(cmd, errors.New("client cannot get the schema of a namespace"))
+				}
+
+				ddl, err := svc.SvcClient().Schema(ctx, namespace)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+
+				return display.PrintCmd(cmd, &respDDL{Namespace: namespace, DDL: ddl})
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", engine.DefaultNamespace, "the namespace to dump")
+	return cmd
+}
+
+// respDDL is the DDL of a namespace.
+type respDDL struct {
+	Namespace string `json:"namespace"`
+	DDL       string `json:"ddl"`
+}
+
+func (r *respDDL) MarshalJSON() ([]byte, error) {
+	type alias respDDL
+	return json.Marshal((*alias)(r))
+}
+
+func (r *respDDL) MarshalText() ([]byte, error) {
+	// the output is printed with a trailing newline
+	return []byte(strings.TrimSuffix(r.DDL, "\n")), nil
+}
@@ -0,0 +1,94 @@
+// Package namespace contains the kwil-cli commands for managing the schema of
+// a namespace on a live node, such as dumping its DDL, comparing it to a
+// Kuneiform file, and migrating it.
+package namespace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/ddl"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+var namespaceLong = `Namespace schema commands.
+
+These commands print the DDL of a namespace on a node, compare its schema with
+a Kuneiform file, and generate and apply the migration between them.`
+
+func NewCmdNamespace() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "namespace",
+		Short: "Namespace schema commands.",
+		Long:  namespaceLong,
+	}
+
+	cmd.AddCommand(
+		dumpCmd(),
+		diffCmd(),
+		applyCmd(),
+	)
+
+	return cmd
+}
+
+// bindSchemaFlags binds the flags of the file and namespace to compare.
+func bindSchemaFlags(cmd *cobra.Command, file, namespace *string) {
+	cmd.Flags().StringVarP(file, "file", "f", "", "the Kuneiform file that defines the namespace")
+	cmd.Flags().StringVarP(namespace, "namespace", "n", engine.DefaultNamespace, "the namespace to compare the file with")
+}
+
+// readDefinition reads the definition of a namespace from a Kuneiform file.
+func readDefinition(file, namespace string) (*ddl.Namespace, error) {
+	if file == "" {
+		return nil, errors.New("no file provided")
+	}
+
+	expanded, err := helpers.ExpandPath(file)
+	if err != nil {
+		return nil, err
+	}
+	bts, err := os.ReadFile(expanded)
+	if err != nil {
+		return nil, err
+	}
+
+	stmts, err := parse.Parse(string(bts))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+
+	def, err := ddl.FromStatements(namespace, stmts)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", file, err)
+	}
+	return def, nil
+}
+
+// migration returns the migration from the live namespace to its definition
+// in a file.
+func migration(ctx context.Context, cl clientType.Client, file, namespace string) (*ddl.Migration, error) {
+	def, err := readDefinition(file, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	live, err := ddl.Load(ctx, func(ctx context.Context, query string, params map[string]any) (*types.QueryResult, error) {
+		return cl.Query(ctx, query, params, true)
+	}, namespace)
+	if errors.Is(err, engine.ErrNamespaceNotFound) {
+		live = nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	return ddl.Diff(live, def)
+}
@@ -10,7 +10,13 @@ import (
 	"github.com/kwilteam/kwil-db/app/shared/version"
 	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/account"
 	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/configure"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/data"
 	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/database"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/generate"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/kf"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/multisig"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/namespace"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/sponsor"
 	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/utils"
 	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
 	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
@@ -73,13 +79,21 @@ func NewRootCmd() *cobra.Command {
 	rootCmd.AddCommand(
 		account.NewCmdAccount(),
 		configure.NewCmdConfigure(),
+		data.NewCmdData(),
 		database.NewCmdDatabase(),
+		generate.NewCmdGenerate(),
+		kf.NewCmdKf(),
+		multisig.NewCmdMultisig(),
+		namespace.NewCmdNamespace(),
+		sponsor.NewCmdSponsor(),
 		utils.NewCmdUtils(),
 		version.NewVersionCmd(),
 		execSQLCmd(),
 		execActionCmd(),
 		callActionCmd(),
 		queryCmd(),
+		shellCmd(),
+		txCmd(),
 	)
 
 	shared.ApplySanitizedHelpFuncRecursively(rootCmd)
@@ -0,0 +1,342 @@
+package cmds
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// shellCommand is a backslash command of the shell.
+type shellCommand struct {
+	name string
+	args string
+	help string
+	// complete returns the names that the first argument is completed from.
+	complete func(c *completions) []string
+}
+
+var shellCommands = []*shellCommand{
+	{name: `\?`, help: "show the commands"},
+	{name: `\q`, help: "quit the shell"},
+	{name: `\c`, args: "[namespace]", help: "switch to a namespace, or show the current one",
+		complete: func(c *completions) []string { return c.namespaces }},
+	{name: `\dn`, help: "list the namespaces"},
+	{name: `\dt`, help: "list the tables of the current namespace"},
+	{name: `\d`, args: "[table]", help: "describe the columns of a table, or list the tables",
+		complete: func(c *completions) []string { return c.tables }},
+	{name: `\da`, help: "list the actions of the current namespace"},
+	{name: `\call`, args: "action [type:value ...]", help: "call a view action",
+		complete: func(c *completions) []string { return c.actions }},
+	{name: `\exec`, args: "action [type:value ...]", help: "execute an action in a transaction",
+		complete: func(c *completions) []string { return c.actions }},
+	{name: `\o`, args: "[table|json|csv]", help: "set the output format, or show the current one"},
+}
+
+// runCommand runs a backslash command. It returns true if the shell should
+// quit.
+func (s *shell) runCommand(ctx context.Context, line string) (quit bool, err error) {
+	args, err := splitArgs(line)
+	if err != nil {
+		return false, err
+	}
+	name, args := args[0], args[1:]
+
+	maxArgs := map[string]int{`\c`: 1, `\d`: 1, `\o`: 1, `\call`: -1, `\exec`: -1}[name]
+	if maxArgs >= 0 && len(args) > maxArgs {
+		return false, fmt.Errorf(`too many arguments for %s`, name)
+	}
+
+	switch name {
+	case `\q`, `\quit`:
+		return true, nil
+	case `\?`, `\h`, `\help`:
+		return false, display.PrintCmd(s.cmd, display.RespString(shellHelp()))
+	case `\c`:
+		if len(args) == 0 {
+			return false, display.PrintCmd(s.cmd, display.RespString(fmt.Sprintf(`using namespace "%s"`, s.namespace)))
+		}
+		return false, s.setNamespace(ctx, args[0])
+	case `\dn`:
+		return false, s.printQuery(ctx, "{info}SELECT name, type FROM namespaces", nil)
+	case `\dt`:
+		return false, s.listTables(ctx)
+	case `\d`:
+		if len(args) == 0 {
+			return false, s.listTables(ctx)
+		}
+		return false, s.describeTable(ctx, args[0])
+	case `\da`:
+		return false, s.listActions(ctx)
+	case `\call`, `\exec`:
+		if len(args) == 0 {
+			return false, fmt.Errorf("%s requires an action", name)
+		}
+		var params []any
+		for _, p := range args[1:] {
+			_, param, err := parseTypedParam(p)
+			if err != nil {
+				return false, err
+			}
+			params = append(params, param)
+		}
+
+		if name == `\exec` {
+			return false, s.execute(ctx, func(opts ...clientType.TxOpt) (types.Hash, error) {
+				return s.cl.Execute(ctx, s.namespace, args[0], [][]any{params}, opts...)
+			})
+		}
+
+		res, err := s.cl.Call(ctx, s.namespace, args[0], params)
+		if err != nil {
+			return false, err
+		}
+		if res.Error != nil {
+			return false, fmt.Errorf("%s", *res.Error)
+		}
+		return false, s.printRows(res.QueryResult)
+	case `\o`:
+		if len(args) == 0 {
+			return false, display.PrintCmd(s.cmd, display.RespString("output format is "+s.format))
+		}
+		return false, s.setFormat(args[0])
+	default:
+		return false, fmt.Errorf(`unknown command %s, type \? for the list of commands`, name)
+	}
+}
+
+// shellHelp returns the help of the backslash commands.
+func shellHelp() string {
+	var b strings.Builder
+	b.WriteString("Statements end with a semicolon, and can span multiple lines.\n\nCommands:\n")
+	for _, c := range shellCommands {
+		fmt.Fprintf(&b, "  %-32s %s\n", strings.TrimSpace(c.name+" "+c.args), c.help)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// splitArgs splits a command line into its arguments, which are separated by
+// whitespace. Whitespace in quotes does not separate arguments, and the quotes
+// are kept so that parameter values are parsed like those of call-action.
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var arg strings.Builder
+	var quote rune
+	for _, c := range line {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case unicode.IsSpace(c):
+			if arg.Len() > 0 {
+				args = append(args, arg.String())
+				arg.Reset()
+			}
+			continue
+		}
+		arg.WriteRune(c)
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote %c", quote)
+	}
+	if arg.Len() > 0 {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
+
+// printQuery runs a query and prints its rows.
+func (s *shell) printQuery(ctx context.Context, sql string, params map[string]any) error {
+	res, err := s.query(ctx, sql, params)
+	if err != nil {
+		return err
+	}
+	return s.printRows(res)
+}
+
+func (s *shell) listTables(ctx context.Context) error {
+	return s.printQuery(ctx, "{info}SELECT name FROM tables WHERE namespace = $namespace",
+		map[string]any{"namespace": s.namespace})
+}
+
+func (s *shell) describeTable(ctx context.Context, table string) error {
+	res, err := s.query(ctx, `{info}SELECT name, data_type, is_nullable, default_value, is_primary_key
+	FROM columns WHERE namespace = $namespace AND table_name = $table`,
+		map[string]any{"namespace": s.namespace, "table": table})
+	if err != nil {
+		return err
+	}
+	if len(res.Values) == 0 {
+		return fmt.Errorf(`table "%s" not found in namespace "%s"`, table, s.namespace)
+	}
+	return s.printRows(res)
+}
+
+// listActions prints the actions of the current namespace, with their
+// parameters and return types formatted like in their definitions.
+func (s *shell) listActions(ctx context.Context) error {
+	res, err := s.query(ctx, `{info}SELECT name, access_modifiers, parameter_names, parameter_types, return_names, return_types, returns_table
+	FROM actions WHERE namespace = $namespace AND built_in = false`, map[string]any{"namespace": s.namespace})
+	if err != nil {
+		return err
+	}
+
+	actions := &types.QueryResult{
+		ColumnNames: []string{"name", "modifiers", "parameters", "returns"},
+		ColumnTypes: []*types.DataType{types.TextType, types.TextType, types.TextType, types.TextType},
+	}
+	var name string
+	var modifiers, paramNames, paramTypes, returnNames, returnTypes []string
+	var returnsTable bool
+	err = res.Scan(func() error {
+		returns := fieldList(returnNames, returnTypes)
+		if returnsTable {
+			returns = "table" + returns
+		} else if len(returnTypes) == 0 {
+			returns = ""
+		}
+		actions.Values = append(actions.Values, []any{name, strings.ToLower(strings.Join(modifiers, " ")),
+			fieldList(paramNames, paramTypes), returns})
+		return nil
+	}, &name, &modifiers, &paramNames, &paramTypes, &returnNames, &returnTypes, &returnsTable)
+	if err != nil {
+		return err
+	}
+	return s.printRows(actions)
+}
+
+// fieldList formats names and types as a parenthesized list.
+func fieldList(names, typeNames []string) string {
+	fields := make([]string, len(typeNames))
+	for i, typ := range typeNames {
+		fields[i] = typ
+		if i < len(names) && names[i] != "" {
+			fields[i] = names[i] + " " + typ
+		}
+	}
+	return "(" + strings.Join(fields, ", ") + ")"
+}
+
+// completions are the names that the shell completes.
+type completions struct {
+	namespaces []string
+	// tables and actions are those of the current namespace.
+	tables  []string
+	actions []string
+}
+
+// loadCompletions loads the names that are completed from the node.
+func (s *shell) loadCompletions(ctx context.Context) (*completions, error) {
+	c := &completions{}
+	params := map[string]any{"namespace": s.namespace}
+	for _, q := range []struct {
+		sql   string
+		names *[]string
+	}{
+		{"{info}SELECT name FROM namespaces", &c.namespaces},
+		{"{info}SELECT name FROM tables WHERE namespace = $namespace", &c.tables},
+		{"{info}SELECT name FROM actions WHERE namespace = $namespace AND built_in = false", &c.actions},
+	} {
+		res, err := s.query(ctx, q.sql, params)
+		if err != nil {
+			return nil, err
+		}
+		var name string
+		err = res.Scan(func() error {
+			*q.names = append(*q.names, name)
+			return nil
+		}, &name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Do implements readline.AutoCompleter. The completions are loaded when they
+// are first needed, and again after they may have changed.
+func (s *shell) Do(line []rune, pos int) ([][]rune, int) {
+	if s.completions == nil {
+		c, err := s.loadCompletions(s.cmd.Context())
+		if err != nil {
+			// complete the keywords and commands anyway
+			c = &completions{}
+		}
+		s.completions = c
+	}
+
+	candidates, n := complete(string(line[:pos]), s.completions)
+	suffixes := make([][]rune, len(candidates))
+	for i, c := range candidates {
+		suffixes[i] = []rune(c)
+	}
+	return suffixes, n
+}
+
+// sqlKeywords are the keywords that are completed in statements.
+var sqlKeywords = []string{"ACTION", "ALTER", "AND", "AS", "ASC", "BY", "CREATE", "CURRENT",
+	"DELETE", "DESC", "DISTINCT", "DROP", "FROM", "GRANT", "GROUP", "HAVING", "INDEX", "INNER",
+	"INSERT", "INTO", "JOIN", "KEY", "LEFT", "LIMIT", "NAMESPACE", "NOT", "NULL", "OFFSET", "ON",
+	"OR", "ORDER", "PRIMARY", "REVOKE", "ROLE", "SELECT", "SET", "TABLE", "TO", "UPDATE",
+	"VALUES", "WHERE"}
+
+// complete returns the completions of the word before the cursor, which are
+// the rest of the names that start with it, and the length of the word.
+// Commands complete their first argument, a word after a brace completes a
+// namespace, and other words complete SQL keywords and tables.
+func complete(line string, c *completions) ([]string, int) {
+	start := strings.LastIndexFunc(line, func(r rune) bool {
+		return !(r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r))
+	}) + 1
+	word := line[start:]
+
+	var names []string
+	switch {
+	case strings.HasPrefix(strings.TrimLeftFunc(line, unicode.IsSpace), `\`):
+		fields := strings.Fields(line)
+		if len(fields) == 1 && !unicode.IsSpace(rune(line[len(line)-1])) {
+			// the command itself, with its backslash
+			word = fields[0]
+			for _, cmd := range shellCommands {
+				names = append(names, cmd.name)
+			}
+			break
+		}
+		if len(fields) > 2 || (len(fields) == 2 && word != fields[1]) {
+			break
+		}
+		for _, cmd := range shellCommands {
+			if cmd.name == fields[0] && cmd.complete != nil {
+				names = cmd.complete(c)
+			}
+		}
+	case start > 0 && line[start-1] == '{':
+		names = c.namespaces
+	default:
+		lower := word != "" && word == strings.ToLower(word)
+		for _, kw := range sqlKeywords {
+			if lower {
+				kw = strings.ToLower(kw)
+			}
+			names = append(names, kw)
+		}
+		names = append(names, c.tables...)
+	}
+
+	var candidates []string
+	for _, name := range names {
+		if len(name) > len(word) && strings.HasPrefix(strings.ToLower(name), strings.ToLower(word)) {
+			candidates = append(candidates, name[len(word):])
+		}
+	}
+	slices.Sort(candidates)
+	return slices.Compact(candidates), len([]rune(word))
+}
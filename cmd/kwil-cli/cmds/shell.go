@@ -0,0 +1,461 @@
+package cmds
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chzyer/readline"
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+var (
+	shellLong = `Start an interactive shell for running SQL and actions.
+
+The shell reads statements until they are terminated by a semicolon, so they
+can span multiple lines. SELECT statements are run as queries, and all other
+statements are executed in a transaction. Actions are called and executed with
+the ` + "`\\call`" + ` and ` + "`\\exec`" + ` commands, which take positional
+parameters in the same type:value format as the ` + "`call-action`" + ` command.
+
+Statements are run in the current namespace, which is "main" unless the
+` + "`--namespace`" + ` flag is given, and is switched with ` + "`\\c`" + ` or a
+` + "`SET CURRENT NAMESPACE`" + ` statement. Statements with a ` + "`{namespace}`" + `
+prefix are run in that namespace.
+
+Successive transactions are given consecutive nonces, so that writes do not
+have to wait for the previous one to be included in a block. If a transaction
+is rejected, the next nonce is requested from the node again.
+
+Type ` + "`\\?`" + ` in the shell for the list of commands. The TAB key completes
+commands, namespaces, tables, and actions, and the history is saved in the
+configuration directory.
+
+A private key is only required to execute transactions, unless the RPC is in
+private mode, or you are talking to Kwil Gateway.`
+
+	shellExample = `# Start a shell in the "main" namespace
+kwil-cli shell
+
+# Start a shell in the "app" namespace, and wait for each transaction to be included in a block
+kwil-cli shell --namespace app --sync
+
+# Run the statements in a file
+kwil-cli shell < statements.sql`
+)
+
+func shellCmd() *cobra.Command {
+	var namespace string
+	var gwAuth, rpcAuth bool
+
+	cmd := &cobra.Command{
+		Use:     "shell",
+		Short:   "Start an interactive shell for running SQL and actions.",
+		Long:    shellLong,
+		Example: shellExample,
+		Args:    cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			txFlags, err := common.GetTxFlags(cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			var dialFlags uint8
+			if gwAuth {
+				dialFlags = client.UsingGateway
+			}
+			if rpcAuth {
+				dialFlags = dialFlags | client.AuthenticatedCalls
+			}
+			// writes check for the private key when they are executed
+			dialFlags = dialFlags | client.WithoutPrivateKey
+
+			return client.DialClient(cmd.Context(), cmd, dialFlags, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				s := newShell(cmd, cl, namespace, rpcAuth)
+				s.interactive = readline.DefaultIsTerminal()
+				if txFlags.NonceOverride > 0 {
+					s.nextNonce = txFlags.NonceOverride
+				}
+
+				rl, err := readline.NewEx(&readline.Config{
+					Prompt:                 s.prompt(false),
+					HistoryFile:            shellHistoryFile(),
+					DisableAutoSaveHistory: true,
+					AutoComplete:           s,
+					InterruptPrompt:        "^C",
+					EOFPrompt:              `\q`,
+				})
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				defer rl.Close()
+
+				return s.run(ctx, rl)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&namespace, "namespace", "n", engine.DefaultNamespace, "the namespace to start the shell in")
+	cmd.Flags().BoolVar(&rpcAuth, "rpc-auth", false, "signals that queries and calls are made to a kwil node and should be authenticated with the private key")
+	cmd.Flags().BoolVar(&gwAuth, "gateway-auth", false, "signals that queries and calls are made to a gateway and should be authenticated with the private key")
+	common.BindTxFlags(cmd)
+	display.BindTableFlags(cmd)
+	return cmd
+}
+
+// shellHistoryFile returns the file that the shell history is saved in. It is
+// empty, which disables saving the history, if the configuration directory
+// cannot be created.
+func shellHistoryFile() string {
+	dir := config.ConfigDir()
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "shell_history")
+}
+
+// shell is the state of an interactive shell.
+type shell struct {
+	cmd     *cobra.Command
+	cl      clientType.Client
+	rpcAuth bool
+	// interactive is true if the input is a terminal. Prompts are only
+	// shown if it is, so that they are not mixed with the output of a file.
+	interactive bool
+
+	// namespace is the namespace that statements and actions are run in.
+	namespace string
+	// format is the output format of rows, which is one of shellFormats.
+	format string
+	// nextNonce is the nonce of the next transaction. It is zero if it must
+	// be requested from the node.
+	nextNonce int64
+	// completions are the names that are completed, which are loaded from the
+	// node when they are first needed.
+	completions *completions
+}
+
+// shellFormats are the output formats of the shell.
+var shellFormats = []string{"table", "json", "csv"}
+
+func newShell(cmd *cobra.Command, cl clientType.Client, namespace string, rpcAuth bool) *shell {
+	s := &shell{
+		cmd:       cmd,
+		cl:        cl,
+		rpcAuth:   rpcAuth,
+		namespace: namespace,
+		format:    "table",
+	}
+	if out, _ := cmd.Flags().GetString("output"); out == "json" {
+		s.format = "json"
+	}
+	return s
+}
+
+// prompt returns the prompt of the shell, which shows the current namespace.
+// The prompt of the lines that continue a statement is different.
+func (s *shell) prompt(continued bool) string {
+	if !s.interactive {
+		return ""
+	}
+	if continued {
+		return s.namespace + "-> "
+	}
+	return s.namespace + "=> "
+}
+
+// run reads and runs statements and commands until the input ends or the
+// shell is quit. Errors are printed, and do not stop the shell.
+func (s *shell) run(ctx context.Context, rl *readline.Instance) error {
+	var buf strings.Builder
+	for {
+		rl.SetPrompt(s.prompt(buf.Len() > 0))
+		line, err := rl.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			// like psql, an interrupt discards the statement being typed
+			buf.Reset()
+			continue
+		}
+		if errors.Is(err, io.EOF) {
+			// the last statement of a file may not have a semicolon
+			if rest := strings.TrimSpace(buf.String()); rest != "" {
+				if err := s.runStatement(ctx, rest); err != nil {
+					_ = display.PrintErr(s.cmd, err)
+				}
+			}
+			return nil
+		}
+		if err != nil {
+			return display.PrintErr(s.cmd, err)
+		}
+
+		if buf.Len() == 0 {
+			trimmed := strings.TrimSpace(line)
+			switch {
+			case trimmed == "", strings.HasPrefix(trimmed, "--"):
+				continue
+			case strings.HasPrefix(trimmed, `\`):
+				_ = rl.SaveHistory(trimmed)
+				quit, err := s.runCommand(ctx, trimmed)
+				if err != nil {
+					_ = display.PrintErr(s.cmd, err)
+				}
+				if quit {
+					return nil
+				}
+				continue
+			}
+		}
+
+		buf.WriteString(line)
+		buf.WriteString("\n")
+		stmts, rest := splitStatements(buf.String())
+		buf.Reset()
+		if rest != "" {
+			buf.WriteString(rest)
+			buf.WriteString("\n")
+		}
+
+		for _, stmt := range stmts {
+			_ = rl.SaveHistory(stmt)
+			if err := s.runStatement(ctx, stmt); err != nil {
+				_ = display.PrintErr(s.cmd, err)
+			}
+		}
+	}
+}
+
+// splitStatements splits the input into the statements that are terminated by
+// a semicolon, and returns the rest of the input, which is an incomplete
+// statement. Semicolons in strings, comments, and blocks, such as the body of
+// an action, do not terminate statements.
+func splitStatements(input string) (stmts []string, rest string) {
+	runes := []rune(input)
+	var quote rune // the quote of the string or identifier that we are in
+	var depth int  // the depth of braces
+	var lineComment, blockComment bool
+	start := 0
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+		var next rune
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+
+		switch {
+		case lineComment:
+			lineComment = c != '\n'
+		case blockComment:
+			if c == '*' && next == '/' {
+				blockComment = false
+				i++
+			}
+		case quote != 0:
+			// a doubled quote is an escaped quote, and toggles back
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '-' && next == '-':
+			lineComment = true
+			i++
+		case c == '/' && next == '*':
+			blockComment = true
+			i++
+		case c == '{':
+			depth++
+		case c == '}':
+			if depth > 0 {
+				depth--
+			}
+		case c == ';' && depth == 0:
+			stmt := strings.TrimSpace(string(runes[start : i+1]))
+			if stmt != ";" {
+				stmts = append(stmts, stmt)
+			}
+			start = i + 1
+		}
+	}
+
+	return stmts, strings.TrimSpace(string(runes[start:]))
+}
+
+// runStatement runs a statement. SELECT statements are run as queries, and
+// other statements in a transaction, except SET CURRENT NAMESPACE, which
+// switches the namespace of the shell.
+func (s *shell) runStatement(ctx context.Context, stmt string) error {
+	parsed, err := parse.Parse(stmt)
+	if err != nil {
+		return fmt.Errorf("failed to parse SQL statement: %s", err)
+	}
+	if len(parsed) != 1 {
+		return fmt.Errorf("expected 1 statement, got %d", len(parsed))
+	}
+
+	switch st := parsed[0].(type) {
+	case *parse.SetCurrentNamespaceStatement:
+		return s.setNamespace(ctx, st.Namespace)
+	case *parse.SQLStatement:
+		if _, ok := st.SQL.(*parse.SelectStatement); ok {
+			res, err := s.query(ctx, s.namespaced(stmt, st), nil)
+			if err != nil {
+				return err
+			}
+			return s.printRows(res)
+		}
+	}
+
+	err = s.execute(ctx, func(opts ...clientType.TxOpt) (types.Hash, error) {
+		return s.cl.ExecuteSQL(ctx, s.namespaced(stmt, parsed[0]), nil, opts...)
+	})
+	// the statement may have changed the tables or actions
+	s.completions = nil
+	return err
+}
+
+// namespaced prefixes the statement with the current namespace, unless it has
+// a namespace prefix or cannot have one.
+func (s *shell) namespaced(stmt string, parsed parse.TopLevelStatement) string {
+	if s.namespace == engine.DefaultNamespace {
+		return stmt
+	}
+	ns, ok := parsed.(parse.Namespaceable)
+	if !ok || ns.GetNamespacePrefix() != "" {
+		return stmt
+	}
+	return "{" + s.namespace + "}" + stmt
+}
+
+// query runs a read-only query.
+func (s *shell) query(ctx context.Context, sql string, params map[string]any) (*types.QueryResult, error) {
+	return s.cl.Query(ctx, sql, params, !s.rpcAuth)
+}
+
+// setNamespace switches the current namespace, if it exists.
+func (s *shell) setNamespace(ctx context.Context, namespace string) error {
+	res, err := s.query(ctx, "{info}SELECT name FROM namespaces WHERE name = $namespace", map[string]any{"namespace": namespace})
+	if err != nil {
+		return err
+	}
+	if len(res.Values) == 0 {
+		return fmt.Errorf(`%w: "%s"`, engine.ErrNamespaceNotFound, namespace)
+	}
+
+	s.namespace = namespace
+	s.completions = nil
+	return display.PrintCmd(s.cmd, display.RespString(fmt.Sprintf(`using namespace "%s"`, namespace)))
+}
+
+// execute executes a transaction with the next nonce, and prints its hash or
+// result.
+func (s *shell) execute(ctx context.Context, fn func(opts ...clientType.TxOpt) (types.Hash, error)) error {
+	if s.cl.Signer() == nil {
+		return errors.New("a private key is required to execute transactions")
+	}
+	txFlags, err := common.GetTxFlags(s.cmd)
+	if err != nil {
+		return err
+	}
+
+	if s.nextNonce == 0 {
+		s.nextNonce, err = s.pendingNonce(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	txHash, err := fn(clientType.WithNonce(s.nextNonce), clientType.WithSyncBroadcast(txFlags.SyncBroadcast))
+	if err != nil {
+		// the nonce may be wrong, e.g. if another transaction was sent by
+		// the same account, so it is requested again for the next one
+		s.nextNonce = 0
+		return err
+	}
+	s.nextNonce++
+
+	return common.DisplayTxResult(ctx, s.cl, txHash, s.cmd)
+}
+
+// pendingNonce gets the next nonce of the signer's account from the node,
+// including the transactions in its mempool.
+func (s *shell) pendingNonce(ctx context.Context) (int64, error) {
+	ident, err := types.GetSignerAccount(s.cl.Signer())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get signer account: %w", err)
+	}
+
+	acc, err := s.cl.GetAccount(ctx, ident, types.AccountStatusPending)
+	if err != nil {
+		return 0, err
+	}
+	if acc.ID != nil && len(acc.ID.Identifier) > 0 {
+		return acc.Nonce + 1, nil
+	}
+	return 1, nil
+}
+
+// printRows prints rows in the output format of the shell.
+func (s *shell) printRows(res *types.QueryResult) error {
+	if res == nil {
+		res = &types.QueryResult{}
+	}
+	return display.PrintCmd(s.cmd, &respShellRows{Data: res, csv: s.format == "csv", cmd: s.cmd})
+}
+
+// setFormat sets the output format of the shell. The table and CSV formats
+// are the text output of the display package.
+func (s *shell) setFormat(format string) error {
+	format = strings.ToLower(format)
+	if format == "text" {
+		format = "table"
+	}
+
+	output := "text"
+	switch format {
+	case "table", "csv":
+	case "json":
+		output = "json"
+	default:
+		return fmt.Errorf(`invalid output format "%s", must be one of %s`, format, strings.Join(shellFormats, ", "))
+	}
+	if err := s.cmd.Flags().Set("output", output); err != nil {
+		return err
+	}
+
+	s.format = format
+	return display.PrintCmd(s.cmd, display.RespString("output format is "+format))
+}
+
+// respShellRows is the rows returned by a query or action in the shell. As
+// text, they are a table or CSV.
+type respShellRows struct {
+	Data *types.QueryResult
+	csv  bool
+	cmd  *cobra.Command
+}
+
+func (r *respShellRows) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.Data)
+}
+
+func (r *respShellRows) MarshalText() ([]byte, error) {
+	if r.csv {
+		return display.FormatCSV(r.Data.ColumnNames, getStringRows(r.Data.Values))
+	}
+	return display.FormatTable(r.cmd, r.Data.ColumnNames, getStringRows(r.Data.Values))
+}
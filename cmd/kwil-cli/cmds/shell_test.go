@@ -0,0 +1,213 @@
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/chzyer/readline"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+func Test_splitStatements(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		stmts []string
+		rest  string
+	}{
+		{
+			name:  "one statement",
+			input: "SELECT 1;\n",
+			stmts: []string{"SELECT 1;"},
+		},
+		{
+			name:  "multiple statements and an incomplete one",
+			input: "SELECT 1; SELECT 2;\nSELECT\n3",
+			stmts: []string{"SELECT 1;", "SELECT 2;"},
+			rest:  "SELECT\n3",
+		},
+		{
+			name:  "semicolons in strings and comments",
+			input: "SELECT 'a;''b', \"c;\" -- d;\n/* e; */ FROM t;",
+			stmts: []string{"SELECT 'a;''b', \"c;\" -- d;\n/* e; */ FROM t;"},
+		},
+		{
+			name:  "action body",
+			input: "CREATE ACTION a() public {\n  INSERT INTO t VALUES (1);\n};",
+			stmts: []string{"CREATE ACTION a() public {\n  INSERT INTO t VALUES (1);\n};"},
+		},
+		{
+			name:  "incomplete action body",
+			input: "{app}CREATE ACTION a() public {\n  INSERT INTO t VALUES (1);",
+			rest:  "{app}CREATE ACTION a() public {\n  INSERT INTO t VALUES (1);",
+		},
+		{
+			name:  "empty statements",
+			input: ";; ;",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmts, rest := splitStatements(tt.input)
+			assert.Equal(t, tt.stmts, stmts)
+			assert.Equal(t, tt.rest, rest)
+		})
+	}
+}
+
+func Test_splitArgs(t *testing.T) {
+	args, err := splitArgs(`\call  get_user int:1 text:"John Doe" text:'a b'`)
+	require.NoError(t, err)
+	assert.Equal(t, []string{`\call`, "get_user", "int:1", `text:"John Doe"`, "text:'a b'"}, args)
+
+	_, err = splitArgs(`\call get_user text:"John`)
+	require.Error(t, err)
+}
+
+func Test_complete(t *testing.T) {
+	c := &completions{
+		namespaces: []string{"main", "info", "app"},
+		tables:     []string{"users", "user_posts"},
+		actions:    []string{"get_user", "create_user"},
+	}
+
+	tests := []struct {
+		line       string
+		candidates []string
+		length     int
+	}{
+		{`\d`, []string{"a", "n", "t"}, 2},
+		{`\c `, []string{"app", "info", "main"}, 0},
+		{`\c ma`, []string{"in"}, 2},
+		{`\d user`, []string{"_posts", "s"}, 4},
+		{`\call get`, []string{"_user"}, 3},
+		{`\call get_user us`, nil, 2},
+		{`\o js`, nil, 2},
+		{"sel", []string{"ect"}, 3},
+		{"SELECT * FR", []string{"OM"}, 2},
+		{"SELECT * FROM users_", nil, 6},
+		{"SELECT * FROM use", []string{"r_posts", "rs"}, 3},
+		{"{ap", []string{"p"}, 2},
+	}
+	for _, tt := range tests {
+		candidates, length := complete(tt.line, c)
+		assert.Equal(t, tt.candidates, candidates, tt.line)
+		assert.Equal(t, tt.length, length, tt.line)
+	}
+}
+
+// fakeShellClient is a client that records the statements and actions that a
+// shell runs.
+type fakeShellClient struct {
+	clientType.Client
+
+	signer  auth.Signer
+	queries []string
+	sql     []string
+	actions []string
+	nonces  []int64
+}
+
+func (f *fakeShellClient) Signer() auth.Signer {
+	return f.signer
+}
+
+func (f *fakeShellClient) Query(_ context.Context, query string, params map[string]any, _ bool) (*types.QueryResult, error) {
+	f.queries = append(f.queries, query)
+	res := &types.QueryResult{ColumnNames: []string{"id"}, ColumnTypes: []*types.DataType{types.IntType}}
+	if params["namespace"] != "app" && strings.Contains(query, "namespaces WHERE") {
+		return res, nil
+	}
+	res.Values = [][]any{{int64(1)}}
+	return res, nil
+}
+
+func (f *fakeShellClient) GetAccount(_ context.Context, account *types.AccountID, _ types.AccountStatus) (*types.Account, error) {
+	return &types.Account{ID: account, Nonce: 4}, nil
+}
+
+func (f *fakeShellClient) ExecuteSQL(_ context.Context, sql string, _ map[string]any, opts ...clientType.TxOpt) (types.Hash, error) {
+	f.sql = append(f.sql, sql)
+	f.nonces = append(f.nonces, clientType.GetTxOpts(opts).Nonce)
+	if strings.Contains(sql, "fail") {
+		return types.Hash{}, errors.New("invalid nonce")
+	}
+	return types.Hash{1}, nil
+}
+
+func (f *fakeShellClient) Execute(_ context.Context, namespace string, action string, tuples [][]any, opts ...clientType.TxOpt) (types.Hash, error) {
+	f.actions = append(f.actions, namespace+"."+action)
+	f.nonces = append(f.nonces, clientType.GetTxOpts(opts).Nonce)
+	return types.Hash{2}, nil
+}
+
+func (f *fakeShellClient) Call(_ context.Context, namespace string, action string, inputs []any) (*types.CallResult, error) {
+	f.actions = append(f.actions, namespace+"."+action)
+	res, err := f.Query(context.Background(), action, nil, true)
+	return &types.CallResult{QueryResult: res}, err
+}
+
+func Test_shell(t *testing.T) {
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	cl := &fakeShellClient{signer: &auth.EthPersonalSigner{Key: *privKey.(*crypto.Secp256k1PrivateKey)}}
+
+	input := `SELECT *
+FROM users;
+\c app
+INSERT INTO users VALUES (1);
+\exec add_user int:1 text:"John Doe"
+INSERT INTO fail VALUES (1); INSERT INTO users VALUES (2);
+\o csv
+\call get_user
+-- a comment
+SELECT id FROM users;
+\q
+SELECT 2;
+`
+
+	cmd := shellCmd()
+	cmd.Flags().String("output", "text", "")
+	cmd.SetContext(context.Background())
+	var out bytes.Buffer
+	cmd.SetOut(&out)
+
+	rl, err := readline.NewEx(&readline.Config{
+		Stdin:          io.NopCloser(strings.NewReader(input)),
+		Stdout:         io.Discard,
+		FuncIsTerminal: func() bool { return false },
+	})
+	require.NoError(t, err)
+	defer rl.Close()
+
+	s := newShell(cmd, cl, "main", false)
+	require.NoError(t, s.run(context.Background(), rl))
+
+	assert.Equal(t, []string{
+		"SELECT *\nFROM users;",
+		"{info}SELECT name FROM namespaces WHERE name = $namespace",
+		"get_user",
+		"{app}SELECT id FROM users;",
+	}, cl.queries)
+	assert.Equal(t, []string{
+		"{app}INSERT INTO users VALUES (1);",
+		"{app}INSERT INTO fail VALUES (1);",
+		"{app}INSERT INTO users VALUES (2);",
+	}, cl.sql)
+	assert.Equal(t, []string{"app.add_user", "app.get_user"}, cl.actions)
+	// the nonce is requested again after a transaction is rejected
+	assert.Equal(t, []int64{5, 6, 7, 5}, cl.nonces)
+
+	assert.Contains(t, out.String(), `using namespace "app"`)
+	assert.Contains(t, out.String(), "output format is csv\nid\n1\n")
+}
@@ -0,0 +1,87 @@
+package sponsor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var (
+	limitLong = `Limit the total fees that the configured account will sponsor.
+
+Each fee paid for a sponsored transaction is deducted from the remaining limit,
+and transactions are no longer sponsored once it is used up. Setting the limit
+again replaces the remaining amount. With ` + "`--remove`" + `, the account pays fees
+until its balance is exhausted.`
+
+	limitExample = `# Sponsor at most 1000000 in fees
+kwil-cli sponsor limit 1000000
+
+# Remove the limit
+kwil-cli sponsor limit --remove`
+)
+
+// limitSetter is the client method used to set a sponsor limit.
+type limitSetter interface {
+	SetSponsorLimit(ctx context.Context, limit *big.Int, opts ...clientType.TxOpt) (types.Hash, error)
+}
+
+func limitCmd() *cobra.Command {
+	var remove bool
+
+	cmd := &cobra.Command{
+		Use:     "limit [<amount>]",
+		Short:   "Limit the total fees the account will sponsor.",
+		Long:    limitLong,
+		Example: limitExample,
+		Args:    cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			var limit *big.Int
+			switch {
+			case remove && len(args) == 0:
+			case !remove && len(args) == 1:
+				var ok bool
+				limit, ok = new(big.Int).SetString(args[0], 10)
+				if !ok || limit.Sign() < 0 {
+					return display.PrintErr(cmd, errors.New("invalid decimal amount"))
+				}
+			default:
+				return display.PrintErr(cmd, errors.New("either an amount or --remove is required"))
+			}
+
+			txFlags, err := common.GetTxFlags(cmd)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return client.DialClient(cmd.Context(), cmd, 0, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				ls, ok := cl.(limitSetter)
+				if !ok {
+					return display.PrintErr(cmd, errors.New("client cannot set sponsor limits"))
+				}
+
+				txHash, err := ls.SetSponsorLimit(ctx, limit, clientType.WithNonce(txFlags.NonceOverride),
+					clientType.WithSyncBroadcast(txFlags.SyncBroadcast))
+				if err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("set sponsor limit failed: %w", err))
+				}
+				return common.DisplayTxResult(ctx, cl, txHash, cmd)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&remove, "remove", false, "remove the limit")
+	common.BindTxFlags(cmd)
+
+	return cmd
+}
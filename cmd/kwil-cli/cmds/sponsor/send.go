@@ -0,0 +1,85 @@
+package sponsor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var (
+	sendLong = `Sponsor a transaction file and broadcast it.
+
+The transaction must be signed by its sender. It is co-signed with the
+configured private key, which pays the fee up to ` + "`--max-fee`" + ` (by default,
+the transaction's fee), and then broadcast.`
+
+	sendExample = `# Pay for the transaction in tx.json and wait for it to be included in a block
+kwil-cli sponsor send tx.json --sync`
+)
+
+// txSponsor is the client method used to sponsor and broadcast a transaction.
+type txSponsor interface {
+	SponsorTx(ctx context.Context, tx *types.Transaction, maxFee *big.Int, opts ...clientType.TxOpt) (types.Hash, error)
+}
+
+func sendCmd() *cobra.Command {
+	var maxFeeStr string
+	var syncBcast bool
+
+	cmd := &cobra.Command{
+		Use:     "send <tx-file>",
+		Short:   "Sponsor and broadcast a transaction file.",
+		Long:    sendLong,
+		Example: sendExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tx, err := common.ReadTxFile(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			maxFee, err := parseMaxFee(maxFeeStr, tx)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return client.DialClient(cmd.Context(), cmd, 0, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				sp, ok := cl.(txSponsor)
+				if !ok {
+					return display.PrintErr(cmd, errors.New("client cannot sponsor transactions"))
+				}
+
+				txHash, err := sp.SponsorTx(ctx, tx, maxFee, clientType.WithSyncBroadcast(syncBcast))
+				if err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("sponsored broadcast failed: %w", err))
+				}
+
+				if len(txHash) != 0 && syncBcast {
+					time.Sleep(500 * time.Millisecond) // otherwise it says not found at first
+					resp, err := cl.TxQuery(ctx, txHash)
+					if err != nil {
+						return display.PrintErr(cmd, fmt.Errorf("tx query failed: %w", err))
+					}
+					return display.PrintCmd(cmd, display.NewTxHashAndExecResponse(resp))
+				}
+				return display.PrintCmd(cmd, display.RespTxHash(txHash))
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&maxFeeStr, "max-fee", "", "largest fee to pay (default is the transaction fee)")
+	cmd.Flags().BoolVar(&syncBcast, "sync", false, "synchronous broadcast (wait for it to be included in a block)")
+
+	return cmd
+}
@@ -0,0 +1,67 @@
+package sponsor
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+)
+
+var (
+	signLong = `Sign a transaction file as its sponsor with the configured private key.
+
+The transaction must already be signed by its sender. The sponsor agrees to pay
+up to the fee given with ` + "`--max-fee`" + `, which defaults to the transaction's fee.
+The file is updated in place unless ` + "`--out`" + ` is given. Signing does not require
+a connection to a node, and the co-signed transaction may be broadcast by
+anyone, such as the sender.`
+
+	signExample = `# Sponsor the transaction in tx.json, paying at most 1000
+kwil-cli sponsor sign tx.json --max-fee 1000 --out sponsored.json`
+)
+
+func signCmd() *cobra.Command {
+	var out, maxFeeStr string
+
+	cmd := &cobra.Command{
+		Use:     "sign <tx-file>",
+		Short:   "Sign a transaction file as its sponsor.",
+		Long:    signLong,
+		Example: signExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			signer, err := configSigner()
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			tx, err := common.ReadTxFile(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			maxFee, err := parseMaxFee(maxFeeStr, tx)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			if err = tx.SignSponsorship(signer, maxFee); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			if out == "" {
+				out = args[0]
+			}
+			if err = common.WriteJSONFile(out, tx); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, newRespSponsoredTx(out, tx))
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write the sponsored transaction to (default is to update the input file)")
+	cmd.Flags().StringVar(&maxFeeStr, "max-fee", "", "largest fee to pay (default is the transaction fee)")
+
+	return cmd
+}
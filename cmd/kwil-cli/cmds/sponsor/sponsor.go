@@ -0,0 +1,108 @@
+// Package sponsor contains the kwil-cli commands for paying the fees of other
+// accounts' transactions. A sponsored transaction is signed by its sender and
+// passed to the sponsor as a transaction file, which the sponsor co-signs and
+// broadcasts.
+package sponsor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var sponsorLong = `Fee sponsorship commands.
+
+A sponsor pays the fee of a transaction on behalf of its sender, such as a new
+user without a balance. The transaction is executed as the original sender, so
+the sender is still the ` + "`@caller`" + ` of any action, but the fee is taken from the
+sponsor's account.
+
+The sender signs the transaction as usual, without broadcasting it, and gives
+the signed transaction file to the sponsor. The sponsor then either:
+
+  - runs ` + "`send`" + ` to co-sign and broadcast the transaction, or
+  - runs ` + "`sign`" + ` to co-sign it offline, to be broadcast later.
+
+The sponsor signs the largest fee that it will pay. The total fees that a
+sponsor will pay may be capped with ` + "`limit`" + `.`
+
+func NewCmdSponsor() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sponsor",
+		Short: "Fee sponsorship commands.",
+		Long:  sponsorLong,
+	}
+
+	cmd.AddCommand(
+		signCmd(),
+		sendCmd(),
+		limitCmd(),
+	)
+
+	return cmd
+}
+
+// configSigner returns a signer for the configured private key.
+func configSigner() (auth.Signer, error) {
+	conf, err := config.ActiveConfig()
+	if err != nil {
+		return nil, err
+	}
+	if conf.PrivateKey == nil {
+		return nil, errors.New("no private key configured")
+	}
+	return &auth.EthPersonalSigner{Key: *conf.PrivateKey}, nil
+}
+
+// parseMaxFee parses the max fee flag, which defaults to the transaction fee.
+func parseMaxFee(s string, tx *types.Transaction) (*big.Int, error) {
+	if s == "" {
+		return tx.Body.Fee, nil
+	}
+	maxFee, ok := new(big.Int).SetString(s, 10)
+	if !ok || maxFee.Sign() < 0 {
+		return nil, errors.New("invalid decimal max fee")
+	}
+	if tx.Body.Fee.Cmp(maxFee) > 0 {
+		return nil, fmt.Errorf("transaction fee %s exceeds the max fee %s", tx.Body.Fee, maxFee)
+	}
+	return maxFee, nil
+}
+
+// respSponsoredTx describes a sponsored transaction file.
+type respSponsoredTx struct {
+	File    string         `json:"file"`
+	Sender  types.HexBytes `json:"sender"`
+	Nonce   uint64         `json:"nonce"`
+	Fee     string         `json:"fee"`
+	Sponsor types.HexBytes `json:"sponsor"`
+	MaxFee  string         `json:"max_fee"`
+}
+
+func newRespSponsoredTx(path string, tx *types.Transaction) *respSponsoredTx {
+	return &respSponsoredTx{
+		File:    path,
+		Sender:  tx.Sender,
+		Nonce:   tx.Body.Nonce,
+		Fee:     tx.Body.Fee.String(),
+		Sponsor: tx.Sponsor.Sender,
+		MaxFee:  tx.Sponsor.MaxFee.String(),
+	}
+}
+
+func (r *respSponsoredTx) MarshalJSON() ([]byte, error) {
+	type alias respSponsoredTx
+	return json.Marshal((*alias)(r))
+}
+
+func (r *respSponsoredTx) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("Transaction file: %s\nSender: %s\nNonce: %d\nFee: %s\nSponsor: %s\nMax fee: %s\n",
+		r.File, r.Sender, r.Nonce, r.Fee, r.Sponsor, r.MaxFee)), nil
+}
@@ -0,0 +1,81 @@
+package cmds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	rpcclient "github.com/kwilteam/kwil-db/core/rpc/client"
+	"github.com/kwilteam/kwil-db/core/rpc/client/user"
+)
+
+var (
+	txBroadcastLong = `Broadcast a signed transaction file.
+
+The signatures are verified before broadcasting, so an unsigned transaction or
+one with an invalid signature is reported without being sent. Signatures of
+types that ` + "`kwil-cli`" + ` does not support, such as those of authenticator
+extensions, are left to the node to verify.`
+
+	txBroadcastExample = `# Broadcast tx.json and wait for it to be included in a block
+kwil-cli tx broadcast tx.json --sync`
+)
+
+func txBroadcastCmd() *cobra.Command {
+	var syncBcast bool
+
+	cmd := &cobra.Command{
+		Use:     "broadcast <tx-file>",
+		Short:   "Broadcast a signed transaction file.",
+		Long:    txBroadcastLong,
+		Example: txBroadcastExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tx, err := common.ReadTxFile(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			if err = verifyTx(tx); err != nil && !errors.Is(err, errUnknownAuth) {
+				return display.PrintErr(cmd, fmt.Errorf("invalid transaction: %w", err))
+			}
+
+			return client.DialClient(cmd.Context(), cmd, client.WithoutPrivateKey, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				svc, ok := cl.(interface{ SvcClient() user.TxSvcClient })
+				if !ok {
+					return display.PrintErr(cmd, errors.New("client cannot broadcast transactions"))
+				}
+
+				wait := rpcclient.BroadcastWaitAccept
+				if syncBcast {
+					wait = rpcclient.BroadcastWaitCommit
+				}
+				txHash, err := svc.SvcClient().Broadcast(ctx, tx, wait)
+				if err != nil {
+					return display.PrintErr(cmd, fmt.Errorf("broadcast failed: %w", err))
+				}
+
+				if len(txHash) != 0 && syncBcast {
+					time.Sleep(500 * time.Millisecond) // otherwise it says not found at first
+					resp, err := cl.TxQuery(ctx, txHash)
+					if err != nil {
+						return display.PrintErr(cmd, fmt.Errorf("tx query failed: %w", err))
+					}
+					return display.PrintCmd(cmd, display.NewTxHashAndExecResponse(resp))
+				}
+				return display.PrintCmd(cmd, display.RespTxHash(txHash))
+			})
+		},
+	}
+
+	cmd.Flags().BoolVar(&syncBcast, "sync", false, "synchronous broadcast (wait for it to be included in a block)")
+
+	return cmd
+}
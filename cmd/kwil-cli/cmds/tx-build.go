@@ -0,0 +1,212 @@
+package cmds
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/client"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/helpers"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/rpc/client/user"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var (
+	txBuildLong = `Build an unsigned transaction file from a payload file.
+
+The payload file is JSON in the format of the entries of the batch files of
+` + "`exec-action --batch`" + `: an object with a "type" of "execute", "raw_statement", or
+"transfer", and the fields of that type. A list of such objects is a batch
+payload.
+
+The nonce and fee are given with ` + "`--nonce`" + ` and ` + "`--fee`" + `, and the chain ID is the
+configured one, e.g. from ` + "`--chain-id`" + `. Any of them that are not given are
+requested from the node: the next nonce of the sender, the node's estimate of
+the fee, and the node's chain ID. When all of them are given, no connection to
+a node is made.
+
+The sender is given with ` + "`--sender`" + `, or is the account of the configured private
+key if there is one. It is written to the file, so that ` + "`tx sign`" + ` can check
+that the transaction is signed by the account it was built for.`
+
+	txBuildExample = `# Build a transfer with the following payload.json, requesting the nonce, fee,
+# and chain ID of the configured account from the node:
+# {"type": "transfer", "to": "0xc89D42189f0450C2b2c3c61f58Ec5d628176A1E7", "amount": "1000"}
+kwil-cli tx build payload.json --out tx.json
+
+# Build the transaction without a connection to a node
+kwil-cli tx build payload.json --sender 0x6B0C5dC1B7C4D2c8b6B1aF3b5a1E0d3C9d1E2F3a \
+  --nonce 12 --fee 0 --chain-id kwil-testnet --out tx.json`
+)
+
+func txBuildCmd() *cobra.Command {
+	var out, senderStr, keyTypeStr, feeStr, description string
+	var nonce int64
+
+	cmd := &cobra.Command{
+		Use:     "build <payload-file>",
+		Short:   "Build an unsigned transaction file.",
+		Long:    txBuildLong,
+		Example: txBuildExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			payload, err := readPayloadFile(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			var fee *big.Int
+			if feeStr != "" {
+				var ok bool
+				if fee, ok = new(big.Int).SetString(feeStr, 10); !ok || fee.Sign() < 0 {
+					return display.PrintErr(cmd, errors.New("invalid decimal fee"))
+				}
+			}
+
+			conf, err := config.ActiveConfig()
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			sender, err := txSender(senderStr, keyTypeStr, conf)
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			build := func(ctx context.Context, cl clientType.Client) error {
+				tx, err := buildTx(ctx, cl, payload, sender, conf.ChainID, nonce, fee)
+				if err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				tx.Body.Description = description
+
+				if err = common.WriteJSONFile(out, tx); err != nil {
+					return display.PrintErr(cmd, err)
+				}
+				return display.PrintCmd(cmd, newRespTx(out, tx))
+			}
+
+			if nonce > 0 && fee != nil && conf.ChainID != "" {
+				return build(cmd.Context(), nil)
+			}
+			return client.DialClient(cmd.Context(), cmd, client.WithoutPrivateKey, func(ctx context.Context, cl clientType.Client, conf *config.KwilCliConfig) error {
+				return build(ctx, cl)
+			})
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write the unsigned transaction to")
+	cmd.Flags().StringVar(&senderStr, "sender", "", "account ID of the sender (default is the account of the configured private key)")
+	cmd.Flags().StringVarP(&keyTypeStr, "keytype", "t", crypto.KeyTypeSecp256k1.String(), "key type of the sender account ID (default secp256k1 for Ethereum)")
+	cmd.Flags().Int64VarP(&nonce, "nonce", "N", -1, "nonce of the transaction (-1 means request from server)")
+	cmd.Flags().StringVar(&feeStr, "fee", "", "fee of the transaction (default is the node's estimate)")
+	cmd.Flags().StringVar(&description, "description", "", "description of the transaction")
+	cmd.MarkFlagRequired("out")
+
+	return cmd
+}
+
+// readPayloadFile reads a JSON file with a payload in the format of a batch
+// file entry, or a list of them, which is a batch payload.
+func readPayloadFile(path string) (types.Payload, error) {
+	path, err := helpers.ExpandPath(path)
+	if err != nil {
+		return nil, err
+	}
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.HasPrefix(bytes.TrimSpace(bts), []byte("[")) {
+		var entries []*batchFileEntry
+		if err = json.Unmarshal(bts, &entries); err != nil {
+			return nil, fmt.Errorf("invalid payload file: %w", err)
+		}
+		return newBatch(entries)
+	}
+
+	var entry batchFileEntry
+	if err = json.Unmarshal(bts, &entry); err != nil {
+		return nil, fmt.Errorf("invalid payload file: %w", err)
+	}
+	return entry.payload()
+}
+
+// txSender returns the account of the sender of a transaction, which is the
+// given hexadecimal ID, or the account of the configured private key. It is
+// nil if neither is set.
+func txSender(id, keyType string, conf *config.KwilCliConfig) (*types.AccountID, error) {
+	if id == "" {
+		if conf.PrivateKey == nil {
+			return nil, nil
+		}
+		signer := &auth.EthPersonalSigner{Key: *conf.PrivateKey}
+		return &types.AccountID{Identifier: signer.CompactID(), KeyType: crypto.KeyTypeSecp256k1}, nil
+	}
+
+	bts, err := hex.DecodeString(strings.TrimPrefix(id, "0x"))
+	if err != nil || len(bts) == 0 {
+		return nil, fmt.Errorf("invalid sender %q", id)
+	}
+	kt, err := crypto.ParseKeyType(keyType)
+	if err != nil {
+		return nil, err
+	}
+	return &types.AccountID{Identifier: bts, KeyType: kt}, nil
+}
+
+// buildTx creates an unsigned transaction from the sender. If the nonce is not
+// positive, the sender's next nonce is requested from the node, if the chain
+// ID is empty, it is the node's, and if the fee is nil, it is estimated by the
+// node. The client is only used to request them, and may be nil otherwise.
+func buildTx(ctx context.Context, cl clientType.Client, payload types.Payload, sender *types.AccountID,
+	chainID string, nonce int64, fee *big.Int) (*types.Transaction, error) {
+	if nonce <= 0 {
+		if sender == nil {
+			return nil, errors.New("no sender to request the nonce of, use --sender or --nonce")
+		}
+		acct, err := cl.GetAccount(ctx, sender, types.AccountStatusPending)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get sender account: %w", err)
+		}
+		nonce = acct.Nonce + 1
+	}
+	if chainID == "" {
+		chainID = cl.ChainID()
+	}
+
+	tx, err := types.CreateTransaction(payload, chainID, uint64(nonce))
+	if err != nil {
+		return nil, err
+	}
+	if sender != nil {
+		tx.Sender = sender.Identifier
+	}
+
+	if fee == nil {
+		svc, ok := cl.(interface{ SvcClient() user.TxSvcClient })
+		if !ok {
+			return nil, errors.New("client cannot estimate fees, use --fee")
+		}
+		if fee, err = svc.SvcClient().EstimateCost(ctx, tx); err != nil {
+			return nil, fmt.Errorf("failed to estimate fee: %w", err)
+		}
+	}
+	tx.Body.Fee = fee
+
+	return tx, nil
+}
@@ -0,0 +1,95 @@
+package cmds
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/config"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var (
+	txSignLong = `Sign a transaction file with the configured private key.
+
+If the transaction was built for a sender, the key must be the sender's. The
+file is updated in place unless ` + "`--out`" + ` is given. Signing does not require a
+connection to a node, so it may be done on a machine without one. Review the
+transaction with ` + "`kwil-cli tx inspect`" + ` before signing it.
+
+Transactions from multisig accounts are signed with ` + "`kwil-cli multisig sign`" + `.`
+
+	txSignExample = `# Sign tx.json with the configured key
+kwil-cli tx sign tx.json
+
+# Sign with another key, writing a separate file
+kwil-cli tx sign tx.json --private-key <hex> --out signed.json`
+)
+
+func txSignCmd() *cobra.Command {
+	var out string
+
+	cmd := &cobra.Command{
+		Use:     "sign <tx-file>",
+		Short:   "Sign a transaction file.",
+		Long:    txSignLong,
+		Example: txSignExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := config.ActiveConfig()
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			if conf.PrivateKey == nil {
+				return display.PrintErr(cmd, errors.New("no private key configured"))
+			}
+			signer := &auth.EthPersonalSigner{Key: *conf.PrivateKey}
+
+			tx, err := common.ReadTxFile(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			if err = signTx(tx, signer); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			if out == "" {
+				out = args[0]
+			}
+			if err = common.WriteJSONFile(out, tx); err != nil {
+				return display.PrintErr(cmd, err)
+			}
+
+			return display.PrintCmd(cmd, newRespTx(out, tx))
+		},
+	}
+
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write the signed transaction to (default is to update the input file)")
+
+	return cmd
+}
+
+// signTx signs an unsigned transaction. If it has a sender, it must be the
+// signer.
+func signTx(tx *types.Transaction, signer auth.Signer) error {
+	if tx.Signature != nil {
+		if tx.Signature.Type == auth.MultisigAuth {
+			return errors.New("transaction is from a multisig account, use kwil-cli multisig sign")
+		}
+		return errors.New("transaction is already signed")
+	}
+	if tx.Body.ChainID == "" {
+		return errors.New("transaction has no chain ID")
+	}
+	if len(tx.Sender) > 0 && !bytes.Equal(tx.Sender, signer.CompactID()) {
+		return fmt.Errorf("transaction was built for sender %s, not the signer %s",
+			tx.Sender, types.HexBytes(signer.CompactID()))
+	}
+	return tx.Sign(signer)
+}
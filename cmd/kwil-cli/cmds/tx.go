@@ -0,0 +1,233 @@
+package cmds
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/app/shared/display"
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var txLong = `Build, sign, inspect, and broadcast transactions offline.
+
+Executing an action or statement with ` + "`kwil-cli`" + ` requires a connection to a node,
+which gives the nonce and chain ID of the transaction before it is signed. With
+these commands, a transaction is instead prepared in a transaction file, so
+that it can be signed on a machine without a network connection:
+
+  1. ` + "`build`" + ` writes an unsigned transaction file with its nonce, fee, and
+     chain ID. These are requested from a node unless they are given.
+  2. ` + "`inspect`" + ` shows a transaction file, to review it before it is signed.
+  3. ` + "`sign`" + ` signs the transaction file with the configured private key,
+     without a connection to a node.
+  4. ` + "`broadcast`" + ` sends the signed transaction file to a node.
+
+Transaction files are JSON, and may be co-signed by a fee sponsor with
+` + "`kwil-cli sponsor sign`" + ` before they are broadcast. A transaction file may also
+have the hexadecimal serialized transaction, as decoded by
+` + "`kwil-cli utils decode-tx`" + `.`
+
+func txCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "tx",
+		Short: "Build, sign, inspect, and broadcast transactions offline.",
+		Long:  txLong,
+	}
+
+	cmd.AddCommand(
+		txBuildCmd(),
+		txInspectCmd(),
+		txSignCmd(),
+		txBroadcastCmd(),
+	)
+
+	return cmd
+}
+
+var (
+	txInspectLong = `Show a transaction file and verify its signatures.
+
+The payload is decoded, so that the transaction can be reviewed before it is
+signed or broadcast. Signatures of the types that ` + "`kwil-cli`" + ` supports are
+verified, including those of multisig members and fee sponsors. Inspecting a
+transaction does not require a connection to a node.`
+
+	txInspectExample = `# Review an unsigned transaction
+kwil-cli tx inspect tx.json`
+)
+
+func txInspectCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:     "inspect <tx-file>",
+		Short:   "Show a transaction file and verify its signatures.",
+		Long:    txInspectLong,
+		Example: txInspectExample,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tx, err := common.ReadTxFile(args[0])
+			if err != nil {
+				return display.PrintErr(cmd, err)
+			}
+			return display.PrintCmd(cmd, newRespTx(args[0], tx))
+		},
+	}
+}
+
+var (
+	// errUnsigned is returned by verifyTx for a transaction without a
+	// signature.
+	errUnsigned = errors.New("transaction is not signed")
+	// errUnknownAuth is returned by verifyTx for a signature of a type that
+	// kwil-cli cannot verify, such as those of authenticator extensions.
+	errUnknownAuth = errors.New("unknown signature type")
+)
+
+// authenticator returns the authenticator of a signature type.
+func authenticator(authType string) (auth.Authenticator, error) {
+	switch authType {
+	case auth.EthPersonalSignAuth:
+		return auth.EthSecp256k1Authenticator{}, nil
+	case auth.Secp256k1Auth:
+		return auth.Secp25k1Authenticator{}, nil
+	case auth.Ed25519Auth:
+		return auth.Ed25519Authenticator{}, nil
+	case auth.MultisigAuth:
+		return auth.MultisigAuthenticator{}, nil
+	default:
+		return nil, fmt.Errorf("%w %s", errUnknownAuth, authType)
+	}
+}
+
+// verifyTx verifies the signature of a transaction, and of its sponsor if it
+// is sponsored.
+func verifyTx(tx *types.Transaction) error {
+	if tx.Signature == nil {
+		return errUnsigned
+	}
+	authn, err := authenticator(tx.Signature.Type)
+	if err != nil {
+		return err
+	}
+	msg, err := tx.SerializeMsg()
+	if err != nil {
+		return err
+	}
+	if err = authn.Verify(tx.Sender, msg, tx.Signature.Data); err != nil {
+		return err
+	}
+
+	if tx.Sponsor == nil {
+		return nil
+	}
+	if tx.Sponsor.Signature == nil {
+		return errors.New("sponsorship is not signed")
+	}
+	authn, err = authenticator(tx.Sponsor.Signature.Type)
+	if err != nil {
+		return err
+	}
+	msg, err = tx.SponsorshipMsg(tx.Sponsor.MaxFee)
+	if err != nil {
+		return err
+	}
+	if err = authn.Verify(tx.Sponsor.Sender, msg, tx.Sponsor.Signature.Data); err != nil {
+		return fmt.Errorf("invalid sponsor signature: %w", err)
+	}
+	return nil
+}
+
+// respTx describes a transaction file.
+type respTx struct {
+	File string `json:"file"`
+	// Hash is the ID of a signed transaction, which changes when it is signed.
+	Hash          *types.Hash       `json:"hash,omitempty"`
+	Sender        types.HexBytes    `json:"sender,omitempty"`
+	Sponsor       *types.HexBytes   `json:"sponsor,omitempty"`
+	ChainID       string            `json:"chain_id"`
+	Nonce         uint64            `json:"nonce"`
+	Fee           string            `json:"fee"`
+	Description   string            `json:"description,omitempty"`
+	PayloadType   types.PayloadType `json:"payload_type"`
+	Payload       json.RawMessage   `json:"payload"`
+	SignatureType string            `json:"signature_type,omitempty"`
+	// Signature is "valid", "unsigned", or why the signatures are not valid.
+	Signature string `json:"signature"`
+}
+
+func newRespTx(file string, tx *types.Transaction) *respTx {
+	r := &respTx{
+		File:        file,
+		Sender:      tx.Sender,
+		ChainID:     tx.Body.ChainID,
+		Nonce:       tx.Body.Nonce,
+		Fee:         "0",
+		Description: tx.Body.Description,
+		PayloadType: tx.Body.PayloadType,
+		Signature:   "valid",
+	}
+	if tx.Body.Fee != nil {
+		r.Fee = tx.Body.Fee.String()
+	}
+	if tx.Sponsor != nil {
+		r.Sponsor = &tx.Sponsor.Sender
+	}
+	if tx.Signature != nil {
+		hash := tx.Hash()
+		r.Hash = &hash
+		r.SignatureType = tx.Signature.Type
+	}
+
+	// as with decode-tx, a payload that cannot be decoded is shown as base64
+	r.Payload, _ = json.Marshal(base64.StdEncoding.EncodeToString(tx.Body.Payload))
+	if payload, err := types.UnmarshalPayload(tx.Body.PayloadType, tx.Body.Payload); err == nil {
+		if bts, err := json.Marshal(payload); err == nil {
+			r.Payload = bts
+		}
+	}
+
+	if err := verifyTx(tx); err != nil {
+		switch {
+		case errors.Is(err, errUnsigned):
+			r.Signature = "unsigned"
+		case errors.Is(err, errUnknownAuth):
+			r.Signature = "not verified: " + err.Error()
+		default:
+			r.Signature = "invalid: " + err.Error()
+		}
+	}
+	return r
+}
+
+func (r *respTx) MarshalJSON() ([]byte, error) {
+	type alias respTx
+	return json.Marshal((*alias)(r))
+}
+
+func (r *respTx) MarshalText() ([]byte, error) {
+	msg := fmt.Sprintf("Transaction file: %s\n", r.File)
+	if r.Hash != nil {
+		msg += fmt.Sprintf("Transaction ID: %s\n", r.Hash)
+	}
+	msg += fmt.Sprintf("Sender: %s\n", r.Sender)
+	if r.Sponsor != nil {
+		msg += fmt.Sprintf("Sponsor: %s\n", r.Sponsor)
+	}
+	msg += fmt.Sprintf(`ChainID: %s
+Nonce: %d
+Fee: %s
+Description: %s
+Payload type: %s
+Payload: %s
+`, r.ChainID, r.Nonce, r.Fee, r.Description, r.PayloadType, r.Payload)
+	if r.SignatureType != "" {
+		msg += fmt.Sprintf("Signature type: %s\n", r.SignatureType)
+	}
+	msg += fmt.Sprintf("Signature: %s\n", r.Signature)
+	return []byte(msg), nil
+}
@@ -0,0 +1,112 @@
+package cmds
+
+import (
+	"context"
+	"encoding/hex"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/cmd/kwil-cli/cmds/common"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+func newTestSigner(t *testing.T) auth.Signer {
+	privKey, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	return &auth.EthPersonalSigner{Key: *privKey.(*crypto.Secp256k1PrivateKey)}
+}
+
+func Test_readPayloadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "payload.json")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"type": "transfer", "to": "0x01ab", "amount": "1000"}`), 0644))
+	payload, err := readPayloadFile(path)
+	require.NoError(t, err)
+	transfer, ok := payload.(*types.Transfer)
+	require.True(t, ok)
+	assert.Equal(t, "1000", transfer.Amount.String())
+
+	require.NoError(t, os.WriteFile(path, []byte(` [{"type": "raw_statement", "statement": "DELETE FROM t"}]`), 0644))
+	payload, err = readPayloadFile(path)
+	require.NoError(t, err)
+	batch, ok := payload.(*types.Batch)
+	require.True(t, ok)
+	assert.Len(t, batch.Payloads, 1)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"type": "transfer", "to": "0x01ab"}`), 0644))
+	_, err = readPayloadFile(path)
+	require.Error(t, err)
+}
+
+func Test_txWorkflow(t *testing.T) {
+	signer := newTestSigner(t)
+	sender := &types.AccountID{Identifier: signer.CompactID(), KeyType: crypto.KeyTypeSecp256k1}
+	payload := &types.Transfer{
+		To:     &types.AccountID{Identifier: []byte{1}, KeyType: crypto.KeyTypeSecp256k1},
+		Amount: big.NewInt(10),
+	}
+
+	// the nonce is requested from the node unless it is given
+	tx, err := buildTx(context.Background(), &fakeShellClient{}, payload, sender, "kwil-test", -1, big.NewInt(0))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(5), tx.Body.Nonce)
+
+	// nothing is requested if all are given
+	tx, err = buildTx(context.Background(), nil, payload, sender, "kwil-test", 7, big.NewInt(2))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), tx.Body.Nonce)
+	assert.Equal(t, "2", tx.Body.Fee.String())
+	assert.Equal(t, types.HexBytes(signer.CompactID()), tx.Sender)
+
+	_, err = buildTx(context.Background(), nil, payload, nil, "kwil-test", -1, big.NewInt(2))
+	require.Error(t, err)
+
+	// the sender's key type must be known
+	id, err := txSender("0x0102", "ed25519", nil)
+	require.NoError(t, err)
+	assert.Equal(t, crypto.KeyTypeEd25519, id.KeyType)
+	_, err = txSender("0x0102", "ed2551", nil)
+	require.ErrorContains(t, err, "unknown key type")
+
+	// the file is written and read as it is passed to the signing machine
+	path := filepath.Join(t.TempDir(), "tx.json")
+	require.NoError(t, common.WriteJSONFile(path, tx))
+	tx, err = common.ReadTxFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "unsigned", newRespTx(path, tx).Signature)
+
+	require.ErrorContains(t, signTx(tx, newTestSigner(t)), "built for sender")
+	require.NoError(t, signTx(tx, signer))
+	require.ErrorContains(t, signTx(tx, signer), "already signed")
+	require.NoError(t, verifyTx(tx))
+
+	resp := newRespTx(path, tx)
+	assert.Equal(t, "valid", resp.Signature)
+	assert.Equal(t, tx.Hash(), *resp.Hash)
+
+	// a hexadecimal serialized transaction is also a transaction file
+	require.NoError(t, os.WriteFile(path, []byte(hex.EncodeToString(tx.Bytes())+"\n"), 0644))
+	decoded, err := common.ReadTxFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, tx.Hash(), decoded.Hash())
+
+	// a sponsor's signature is verified too
+	require.NoError(t, decoded.SignSponsorship(newTestSigner(t), big.NewInt(5)))
+	require.NoError(t, verifyTx(decoded))
+	decoded.Sponsor.MaxFee = big.NewInt(6)
+	require.ErrorContains(t, verifyTx(decoded), "sponsor")
+
+	tx.Body.Nonce++
+	require.Error(t, verifyTx(tx))
+	assert.Contains(t, newRespTx(path, tx).Signature, "invalid")
+
+	tx.Signature.Type = "custom"
+	require.ErrorIs(t, verifyTx(tx), errUnknownAuth)
+}
@@ -36,12 +36,28 @@ using the flag many times.
 The tests need an active PostgreSQL instance to run against. Users can
 use the ` + "`--test-container`" + ` flag to have ` + "`kwil-cli`" + ` setup
 and teardown a Docker test container, if they have Docker installed locally.
+Users without Docker can use the ` + "`--embedded-postgres`" + ` flag to have
+` + "`kwil-cli`" + ` start a temporary PostgreSQL server from the locally
+installed ` + "`initdb`" + ` and ` + "`pg_ctl`" + ` binaries, which are found
+in the PATH or in the directory given by ` + "`--pg-bin-dir`" + `.
 Alternatively, users can specify a PostgreSQL connection using the
- ` + "`--host`, `--port`, `--user`, `--password`, and `--database` " + `flags.`
+ ` + "`--host`, `--port`, `--user`, `--password`, and `--database` " + `flags.
+
+The ` + "`--coverage`" + ` flag reports which statements and ` + "`if`/`for`" + ` branches
+of the actions created by the seed scripts were executed by the tests. A
+summary is printed for each action, and the ` + "`--coverage-lcov`" + ` and
+` + "`--coverage-html`" + ` flags write LCOV and HTML reports keyed to the lines
+of the seed scripts. Either report flag implies ` + "`--coverage`" + `.`
 
 	testExample = `# Run tests with a test container
 kwil-cli utils test --file ./test1.json --file ./test2.json --test-container
 
+# Run tests with a temporary Postgres server, without Docker
+kwil-cli utils test --file ./test1.json --embedded-postgres --pg-bin-dir /usr/lib/postgresql/16/bin
+
+# Run tests and write an LCOV coverage report
+kwil-cli utils test --file ./test1.json --test-container --coverage-lcov ./lcov.info
+
 # Run tests against a manually set up local Postgres instance
 kwil-cli utils test --file ./test1.json --host localhost --port 5432 \
 --user postgres --password password --database postgres`
@@ -50,7 +66,10 @@ kwil-cli utils test --file ./test1.json --host localhost --port 5432 \
 func testCmd() *cobra.Command {
 	var testCases []string
 	var host, port, user, pass, dbName string
-	var useTestContainer bool
+	var useTestContainer, useEmbedded bool
+	var pgBinDir, pgDataDir string
+	var coverage bool
+	var lcovPath, htmlPath string
 	cmd := &cobra.Command{
 		Use:     "test",
 		Short:   "Runs Kuneiform JSON tests.",
@@ -77,8 +96,21 @@ func testCmd() *cobra.Command {
 				}
 			}
 
-			// either useContainer or db flags can be set
-			if useTestContainer {
+			// either useContainer, useEmbedded, or db flags can be set
+			if useTestContainer && useEmbedded {
+				return display.PrintErr(cmd, errors.New("cannot specify both --test-container and --embedded-postgres"))
+			}
+
+			if useEmbedded {
+				if userHasSetPgConn {
+					return display.PrintErr(cmd, fmt.Errorf("cannot specify both --embedded-postgres and --%s", setPgConnFlag))
+				}
+
+				opts.EmbeddedPostgres = &testing.EmbeddedPostgres{
+					BinDir:  pgBinDir,
+					DataDir: pgDataDir,
+				}
+			} else if useTestContainer {
 				// if useTestContainer, ensure no other flags are set
 				if userHasSetPgConn {
 					return display.PrintErr(cmd, fmt.Errorf("cannot specify both --test-container and --%s", setPgConnFlag))
@@ -87,7 +119,7 @@ func testCmd() *cobra.Command {
 				opts.UseTestContainer = true
 			} else {
 				if !userHasSetPgConn {
-					return display.PrintErr(cmd, errors.New("must specify either postgres connection flags or --test-container"))
+					return display.PrintErr(cmd, errors.New("must specify either postgres connection flags, --test-container, or --embedded-postgres"))
 				}
 
 				opts.Conn = &testing.ConnConfig{
@@ -127,6 +159,35 @@ func testCmd() *cobra.Command {
 				return false, nil
 			}
 
+			if coverage || lcovPath != "" || htmlPath != "" {
+				opts.Coverage = testing.NewCoverage()
+			}
+
+			// finish writes the coverage reports, if any, and prints the result
+			finish := func(res *testsPassed) error {
+				if opts.Coverage == nil {
+					return display.PrintCmd(cmd, res)
+				}
+
+				res.Coverage = opts.Coverage.Summary()
+				if lcovPath != "" {
+					if err := writeReport(lcovPath, func(f *os.File) error {
+						return opts.Coverage.WriteLCOV(f, "kwil-cli")
+					}); err != nil {
+						return display.PrintErr(cmd, err)
+					}
+				}
+				if htmlPath != "" {
+					if err := writeReport(htmlPath, func(f *os.File) error {
+						return opts.Coverage.WriteHTML(f)
+					}); err != nil {
+						return display.PrintErr(cmd, err)
+					}
+				}
+
+				return display.PrintCmd(cmd, res)
+			}
+
 			// run the tests
 			for _, path := range testCases {
 				_, err := expandHome(&path)
@@ -149,14 +210,14 @@ func testCmd() *cobra.Command {
 				}
 
 				if err = schemaTest.Run(cmd.Context(), &opts); err != nil {
-					return display.PrintCmd(cmd, &testsPassed{
+					return finish(&testsPassed{
 						Passing: false,
 						Reason:  err.Error(),
 					})
 				}
 			}
 
-			return display.PrintCmd(cmd, &testsPassed{
+			return finish(&testsPassed{
 				Passing: true,
 			})
 		},
@@ -164,19 +225,46 @@ func testCmd() *cobra.Command {
 
 	cmd.Flags().StringSliceVarP(&testCases, "file", "f", nil, "filepaths of tests to run")
 	cmd.Flags().BoolVar(&useTestContainer, "test-container", false, "runs the tests with a Docker testcontainer")
+	cmd.Flags().BoolVar(&useEmbedded, "embedded-postgres", false, "runs the tests with a temporary Postgres server started from local binaries")
+	cmd.Flags().StringVar(&pgBinDir, "pg-bin-dir", "", "directory containing the initdb and pg_ctl binaries (default: PATH)")
+	cmd.Flags().StringVar(&pgDataDir, "pg-data-dir", "", "data directory for the embedded Postgres server, reused if it exists (default: temporary directory)")
 	cmd.Flags().StringVar(&dbName, "database", "kwild", "name of the Postgres database to manually connect to")
 	cmd.Flags().StringVar(&user, "user", "postgres", "user with administrative privileges on the database")
 	cmd.Flags().StringVar(&pass, "password", "", "password for the database user")
 	cmd.Flags().StringVar(&host, "host", "localhost", "host of the database")
 	cmd.Flags().StringVar(&port, "port", "5432", "port of the database")
+	cmd.Flags().BoolVar(&coverage, "coverage", false, "report the statement and branch coverage of the tested actions")
+	cmd.Flags().StringVar(&lcovPath, "coverage-lcov", "", "path to write an LCOV coverage report to")
+	cmd.Flags().StringVar(&htmlPath, "coverage-html", "", "path to write an HTML coverage report to")
 	helpers.BindAssumeYesFlag(cmd)
 
 	return cmd
 }
 
+// writeReport creates the file at path, expanding a leading ~, and writes a
+// report to it.
+func writeReport(path string, write func(*os.File) error) error {
+	if _, err := expandHome(&path); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	if err = write(f); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
 type testsPassed struct {
-	Passing bool   `json:"passing"`
-	Reason  string `json:"reason,omitempty"`
+	Passing  bool                     `json:"passing"`
+	Reason   string                   `json:"reason,omitempty"`
+	Coverage *testing.CoverageSummary `json:"coverage,omitempty"`
 }
 
 func (t *testsPassed) MarshalJSON() ([]byte, error) {
@@ -189,11 +277,16 @@ func (t *testsPassed) MarshalJSON() ([]byte, error) {
 }
 
 func (t *testsPassed) MarshalText() (text []byte, err error) {
+	var coverage string
+	if t.Coverage != nil {
+		coverage = "\n\nCoverage:\n" + t.Coverage.String()
+	}
+
 	if !t.Passing {
-		return []byte("\nTests failed:\n" + t.Reason), nil
+		return []byte("\nTests failed:\n" + t.Reason + coverage), nil
 	}
 
-	return []byte("\nAll tests passed successfully."), nil
+	return []byte("\nAll tests passed successfully." + coverage), nil
 }
 
 // adjustPath expands a path relative to another path.
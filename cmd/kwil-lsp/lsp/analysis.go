@@ -0,0 +1,767 @@
+package lsp
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/lint"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+	"github.com/kwilteam/kwil-db/node/engine/planner/logical"
+)
+
+// diagnosticSource is the source of all diagnostics published by the server.
+const diagnosticSource = "kuneiform"
+
+var (
+	// errUntyped is returned to the planner for variables whose type could
+	// not be inferred. Errors caused by it are not reported.
+	errUntyped = errors.New("variable type is unknown")
+	// errUnknownNamespace is returned to the planner for tables in a namespace
+	// that the schema knows nothing about, which may exist on a node that is
+	// not configured. Errors caused by it are not reported.
+	errUnknownNamespace = errors.New("unknown namespace")
+)
+
+// contextualVariables are the types of the @ variables.
+var contextualVariables = map[string]*types.DataType{
+	"@caller":          types.TextType,
+	"@txid":            types.TextType,
+	"@signer":          types.ByteaType,
+	"@height":          types.IntType,
+	"@foreign_caller":  types.TextType,
+	"@block_timestamp": types.IntType,
+	"@authenticator":   types.TextType,
+}
+
+// analysis is the result of checking a document against a schema.
+type analysis struct {
+	diagnostics []*Diagnostic
+	// symbols are the names in the document that have hover information or a
+	// definition.
+	symbols []*symbol
+	// statements are the top-level statements and the namespaces they apply
+	// to, and actions the variables of each action.
+	statements []*statementInfo
+	actions    []*actionInfo
+	// relations are the tables that each SQL statement reads or writes, by
+	// alias.
+	relations []*relationInfo
+}
+
+// symbol is a name in a document.
+type symbol struct {
+	span span
+	// hover is markdown describing the symbol.
+	hover string
+	// definition is where the symbol is defined, if known.
+	definition *Location
+}
+
+type statementInfo struct {
+	span      span
+	namespace string
+}
+
+type actionInfo struct {
+	span span
+	// variables are all variables of the action, in the order they are
+	// declared.
+	variables []*variable
+}
+
+type relationInfo struct {
+	span   span
+	tables map[string]*table
+}
+
+// symbolAt returns the innermost symbol at a position.
+func (a *analysis) symbolAt(p kpos) *symbol {
+	var found *symbol
+	for _, sym := range a.symbols {
+		if sym.span.contains(p) && (found == nil || sym.span.inside(found.span)) {
+			found = sym
+		}
+	}
+	return found
+}
+
+// namespaceAt returns the namespace of the statement at a position.
+func (a *analysis) namespaceAt(p kpos) string {
+	ns := defaultNamespace
+	for _, stmt := range a.statements {
+		if p.before(stmt.span.start) {
+			break
+		}
+		ns = stmt.namespace
+	}
+	return ns
+}
+
+// actionAt returns the action at a position, or nil if there is none.
+func (a *analysis) actionAt(p kpos) *actionInfo {
+	for _, act := range a.actions {
+		if act.span.contains(p) {
+			return act
+		}
+	}
+	return nil
+}
+
+// relationsAt returns the innermost tables of the SQL statement at a position.
+func (a *analysis) relationsAt(p kpos) map[string]*table {
+	var found *relationInfo
+	for _, rel := range a.relations {
+		if rel.span.contains(p) && (found == nil || rel.span.inside(found.span)) {
+			found = rel
+		}
+	}
+	if found == nil {
+		return nil
+	}
+	return found.tables
+}
+
+// variable is a variable in an action.
+type variable struct {
+	name string
+	// kind is "parameter", "loop variable", or "variable".
+	kind string
+	// typ is the type of the variable, or nil if it is a record or the type
+	// is unknown. record is the type of the fields of a record.
+	typ    *types.DataType
+	record map[string]*types.DataType
+	// action is the name of the action the variable is in.
+	action     string
+	definition *Location
+}
+
+// scope is a block of an action, which variables are declared in.
+type scope struct {
+	parent *scope
+	vars   map[string]*variable
+}
+
+func (s *scope) child() *scope {
+	return &scope{parent: s, vars: make(map[string]*variable)}
+}
+
+// lookup finds a variable in the scope or its parents.
+func (s *scope) lookup(name string) *variable {
+	for ; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// analyzer checks a single document.
+type analyzer struct {
+	doc    *document
+	schema *schema
+	res    *analysis
+}
+
+// analyze checks a document against a schema. If the document does not
+// parse, only the parse errors are reported.
+func analyze(doc *document, s *schema) (res *analysis) {
+	a := &analyzer{doc: doc, schema: s, res: &analysis{}}
+
+	for _, pe := range doc.parseErrs {
+		msg := pe.Message
+		if pe.Err != nil {
+			msg = pe.Err.Error() + ": " + msg
+		}
+		a.report(pe.Position, SeverityError, "", msg)
+	}
+	if len(doc.parseErrs) > 0 {
+		return a.res
+	}
+
+	defer func() {
+		// a bug in the analysis should not take down the server
+		if r := recover(); r != nil {
+			a.res.diagnostics = append(a.res.diagnostics, &Diagnostic{
+				Severity: SeverityError,
+				Source:   diagnosticSource,
+				Message:  fmt.Sprintf("internal error while checking the document: %v", r),
+			})
+			res = a.res
+		}
+	}()
+
+	for _, d := range lint.Statements(doc.stmts) {
+		start := kpos{line: d.Line, col: d.Col - 1}
+		a.res.diagnostics = append(a.res.diagnostics, &Diagnostic{
+			Range:    doc.toRange(span{start: start, end: doc.tokenEnd(start)}),
+			Severity: SeverityWarning,
+			Code:     d.Rule,
+			Source:   diagnosticSource,
+			Message:  d.Message,
+		})
+	}
+
+	current := defaultNamespace
+	for _, stmt := range doc.stmts {
+		ns := current
+		if n, ok := stmt.(parse.Namespaceable); ok && n.GetNamespacePrefix() != "" {
+			ns = n.GetNamespacePrefix()
+		}
+		if sp, ok := doc.nodeSpan(stmt.GetPosition()); ok {
+			a.res.statements = append(a.res.statements, &statementInfo{span: sp, namespace: ns})
+		}
+
+		switch stmt := stmt.(type) {
+		case *parse.SetCurrentNamespaceStatement:
+			current = stmt.Namespace
+		case *parse.SQLStatement:
+			a.sql(stmt, ns, nil)
+		case *parse.CreateTableStatement:
+			a.createTable(stmt, ns)
+		case *parse.CreateActionStatement:
+			a.action(stmt, ns)
+		}
+	}
+
+	return a.res
+}
+
+// report adds a diagnostic for a node.
+func (a *analyzer) report(pos *parse.Position, severity DiagnosticSeverity, code, msg string) {
+	d := &Diagnostic{
+		Severity: severity,
+		Code:     code,
+		Source:   diagnosticSource,
+		Message:  msg,
+	}
+	if sp, ok := a.doc.nodeSpan(pos); ok {
+		d.Range = a.doc.toRange(sp)
+	}
+	a.res.diagnostics = append(a.res.diagnostics, d)
+}
+
+// addSymbol adds a symbol if the span is known.
+func (a *analyzer) addSymbol(sp span, ok bool, hover string, def *Location) {
+	if ok {
+		a.res.symbols = append(a.res.symbols, &symbol{span: sp, hover: hover, definition: def})
+	}
+}
+
+func (a *analyzer) createTable(stmt *parse.CreateTableStatement, ns string) {
+	tbl := a.schema.table(ns, stmt.Name)
+	if tbl == nil {
+		return
+	}
+
+	for _, col := range stmt.Columns {
+		if c, ok := tbl.Column(col.Name); ok {
+			sp, ok := a.doc.nameSpan(col.GetPosition(), col.Name)
+			a.addSymbol(sp, ok, columnHover(tbl, c), tbl.columns[col.Name])
+		}
+	}
+}
+
+func (a *analyzer) action(stmt *parse.CreateActionStatement, ns string) {
+	sp, ok := a.doc.nodeSpan(stmt.GetPosition())
+	if !ok {
+		return
+	}
+	info := &actionInfo{span: sp}
+	a.res.actions = append(a.res.actions, info)
+
+	def := a.doc.location(sp)
+	top := (&scope{}).child()
+	for _, param := range stmt.Parameters {
+		v := &variable{name: param.Name, kind: "parameter", typ: param.Type, action: stmt.Name, definition: def}
+		top.vars[param.Name] = v
+		info.variables = append(info.variables, v)
+	}
+
+	w := &actionWalker{analyzer: a, info: info, namespace: ns, action: stmt.Name}
+	w.block(stmt.Statements, top)
+}
+
+// actionWalker walks the statements of an action, tracking the variables in
+// scope and their types.
+type actionWalker struct {
+	*analyzer
+	info      *actionInfo
+	namespace string
+	action    string
+}
+
+// declare declares a variable in a scope.
+func (w *actionWalker) declare(s *scope, v *parse.ExpressionVariable, kind string, typ *types.DataType, record map[string]*types.DataType) *variable {
+	decl := &variable{name: v.Name, kind: kind, typ: typ, record: record, action: w.action}
+	if sp, ok := w.doc.nodeSpan(v.GetPosition()); ok {
+		decl.definition = w.doc.location(sp)
+	}
+	s.vars[v.Name] = decl
+	w.info.variables = append(w.info.variables, decl)
+	return decl
+}
+
+// assign assigns to a variable, declaring it if it does not exist.
+func (w *actionWalker) assign(s *scope, v *parse.ExpressionVariable, typ *types.DataType) {
+	decl := s.lookup(v.Name)
+	if decl == nil {
+		w.declare(s, v, "variable", typ, nil)
+	} else if decl.typ == nil && decl.record == nil {
+		decl.typ = typ
+	}
+	w.symbols(v, w.namespace, s, nil)
+}
+
+func (w *actionWalker) block(stmts []parse.ActionStmt, s *scope) {
+	for _, stmt := range stmts {
+		switch stmt := stmt.(type) {
+		case *parse.ActionStmtDeclaration:
+			w.declare(s, stmt.Variable, "variable", stmt.Type, nil)
+			w.symbols(stmt.Variable, w.namespace, s, nil)
+		case *parse.ActionStmtAssign:
+			w.symbols(stmt.Value, w.namespace, s, nil)
+			typ := stmt.Type
+			if typ == nil {
+				typ = w.exprType(stmt.Value, s)
+			}
+
+			if v, ok := stmt.Variable.(*parse.ExpressionVariable); ok {
+				w.assign(s, v, typ)
+			} else {
+				w.symbols(stmt.Variable, w.namespace, s, nil)
+			}
+		case *parse.ActionStmtCall:
+			w.symbols(stmt.Call, w.namespace, s, nil)
+			returns := w.returnTypes(stmt.Call, s)
+			for i, r := range stmt.Receivers {
+				if r == nil {
+					continue
+				}
+				var typ *types.DataType
+				if i < len(returns) {
+					typ = returns[i]
+				}
+				w.assign(s, r, typ)
+			}
+		case *parse.ActionStmtForLoop:
+			body := s.child()
+			var typ *types.DataType
+			var record map[string]*types.DataType
+
+			switch term := stmt.LoopTerm.(type) {
+			case *parse.LoopTermRange:
+				w.symbols(term.Start, w.namespace, s, nil)
+				w.symbols(term.End, w.namespace, s, nil)
+				typ = types.IntType
+			case *parse.LoopTermSQL:
+				if plan := w.sql(term.Statement, w.namespace, s); plan != nil {
+					record = recordType(plan.Plan.Relation())
+				}
+			case *parse.LoopTermExpression:
+				w.symbols(term.Expression, w.namespace, s, nil)
+				if call, ok := term.Expression.(*parse.ExpressionFunctionCall); ok && !term.Array {
+					// looping over an action that returns a table
+					if act := w.callee(call); act != nil && act.returns != nil {
+						record = make(map[string]*types.DataType)
+						for _, f := range act.returns.Fields {
+							record[f.Name] = f.Type
+						}
+					}
+				} else if arr := w.exprType(term.Expression, s); arr != nil && arr.IsArray {
+					typ = arr.Copy()
+					typ.IsArray = false
+				}
+			}
+
+			w.declare(body, stmt.Receiver, "loop variable", typ, record)
+			w.symbols(stmt.Receiver, w.namespace, body, nil)
+			w.block(stmt.Body, body)
+		case *parse.ActionStmtIf:
+			for _, ifThen := range stmt.IfThens {
+				w.symbols(ifThen.If, w.namespace, s, nil)
+				w.block(ifThen.Then, s.child())
+			}
+			w.block(stmt.Else, s.child())
+		case *parse.ActionStmtSQL:
+			w.sql(stmt.SQL, w.namespace, s)
+		case *parse.ActionStmtReturn:
+			for _, v := range stmt.Values {
+				w.symbols(v, w.namespace, s, nil)
+			}
+			if stmt.SQL != nil {
+				w.sql(stmt.SQL, w.namespace, s)
+			}
+		case *parse.ActionStmtReturnNext:
+			for _, v := range stmt.Values {
+				w.symbols(v, w.namespace, s, nil)
+			}
+		}
+	}
+}
+
+// callee returns the action called by a function call, or nil if it is not an
+// action.
+func (w *actionWalker) callee(call *parse.ExpressionFunctionCall) *action {
+	ns := call.Namespace
+	if ns == "" {
+		ns = w.namespace
+	}
+	return w.schema.action(ns, call.Name)
+}
+
+// returnTypes returns the types returned by a call, or nil if they are not
+// known.
+func (w *actionWalker) returnTypes(call *parse.ExpressionFunctionCall, s *scope) []*types.DataType {
+	if act := w.callee(call); act != nil {
+		if act.returns == nil {
+			return nil
+		}
+		typs := make([]*types.DataType, len(act.returns.Fields))
+		for i, f := range act.returns.Fields {
+			typs[i] = f.Type
+		}
+		return typs
+	}
+
+	if typ := w.exprType(call, s); typ != nil {
+		return []*types.DataType{typ}
+	}
+	return nil
+}
+
+// exprType infers the type of an expression by planning it as a SELECT
+// without a FROM clause. It returns nil if the type cannot be inferred.
+func (w *actionWalker) exprType(expr parse.Expression, s *scope) *types.DataType {
+	stmt := &parse.SQLStatement{
+		SQL: &parse.SelectStatement{
+			SelectCores: []*parse.SelectCore{{
+				Columns: []parse.ResultColumn{&parse.ResultColumnExpression{Expression: expr}},
+			}},
+		},
+	}
+
+	plan, err := w.plan(stmt, w.namespace, s)
+	if err != nil {
+		return nil
+	}
+
+	fields := plan.Plan.Relation().Fields
+	if len(fields) != 1 {
+		return nil
+	}
+	typ, err := fields[0].Scalar()
+	if err != nil {
+		return nil
+	}
+	return typ
+}
+
+// sql checks a SQL statement, adding the symbols in it. The scope is nil for
+// statements outside of actions. It returns nil if the statement could not be
+// planned.
+func (a *analyzer) sql(stmt *parse.SQLStatement, ns string, s *scope) *logical.AnalyzedPlan {
+	if stmt.GetNamespacePrefix() != "" {
+		ns = stmt.GetNamespacePrefix()
+	}
+
+	// the tables by alias, for resolving columns. This must be done before
+	// planning, since the planner qualifies the tables.
+	tables := make(map[string]*table)
+	addTable := func(tblNs, name, alias string) {
+		if tblNs == "" {
+			tblNs = ns
+		}
+		if tbl := a.schema.table(tblNs, name); tbl != nil {
+			if alias == "" {
+				alias = name
+			}
+			tables[alias] = tbl
+		}
+	}
+	switch core := stmt.SQL.(type) {
+	case *parse.InsertStatement:
+		addTable("", core.Table, core.Alias)
+	case *parse.UpdateStatement:
+		addTable("", core.Table, core.Alias)
+	case *parse.DeleteStatement:
+		addTable("", core.Table, core.Alias)
+	}
+	parse.RecursivelyVisitPositions(stmt, func(gp parse.GetPositioner) {
+		if rt, ok := gp.(*parse.RelationTable); ok {
+			addTable(rt.Namespace, rt.Table, rt.Alias)
+		}
+	})
+
+	if sp, ok := a.doc.nodeSpan(stmt.GetPosition()); ok {
+		a.res.relations = append(a.res.relations, &relationInfo{span: sp, tables: tables})
+	}
+	a.symbols(stmt, ns, s, tables)
+
+	plan, err := a.plan(stmt, ns, s)
+	if err != nil {
+		if !errors.Is(err, errUntyped) && !errors.Is(err, errUnknownNamespace) {
+			a.report(stmt.GetPosition(), SeverityError, "", err.Error())
+		}
+		return nil
+	}
+	return plan
+}
+
+// plan plans a SQL statement with the tables of the schema and the variables
+// in scope.
+func (a *analyzer) plan(stmt *parse.SQLStatement, ns string, s *scope) (*logical.AnalyzedPlan, error) {
+	return logical.CreateLogicalPlan(stmt,
+		func(tblNs, name string) (*engine.Table, error) {
+			if tblNs == "" {
+				tblNs = ns
+			}
+			if _, ok := a.schema.namespaces[tblNs]; !ok {
+				return nil, fmt.Errorf(`%w: "%s"`, errUnknownNamespace, tblNs)
+			}
+			tbl := a.schema.table(tblNs, name)
+			if tbl == nil {
+				return nil, fmt.Errorf(`%w: "%s"`, engine.ErrUnknownTable, name)
+			}
+			return tbl.Table, nil
+		},
+		func(name string) (*types.DataType, error) {
+			if typ, ok := contextualVariables[name]; ok {
+				return typ, nil
+			}
+			v := s.lookup(name)
+			switch {
+			case v == nil && s == nil:
+				// variables outside of actions are passed as parameters
+				return nil, errUntyped
+			case v == nil || v.record != nil:
+				return nil, fmt.Errorf("%w: %s", engine.ErrUnknownVariable, name)
+			case v.typ == nil:
+				return nil, errUntyped
+			}
+			return v.typ, nil
+		},
+		func(name string) (map[string]*types.DataType, error) {
+			if v := s.lookup(name); v != nil && v.record != nil {
+				return v.record, nil
+			}
+			return nil, fmt.Errorf("%w: %s", engine.ErrUnknownVariable, name)
+		},
+		func(name string) bool {
+			return a.schema.action(ns, name) != nil
+		},
+		false, ns)
+}
+
+// symbols adds the symbols for the variables, columns, tables, and functions
+// in a node. Columns are resolved with the tables of the enclosing SQL
+// statement, if any.
+func (a *analyzer) symbols(node any, ns string, s *scope, tables map[string]*table) {
+	parse.RecursivelyVisitPositions(node, func(gp parse.GetPositioner) {
+		switch n := gp.(type) {
+		case *parse.ExpressionVariable:
+			sp, ok := a.doc.nodeSpan(n.GetPosition())
+			if typ, isCtx := contextualVariables[n.Name]; isCtx {
+				a.addSymbol(sp, ok, codeBlock(n.Name+" "+typeString(typ))+"\nContextual variable.", nil)
+			} else if v := s.lookup(n.Name); v != nil {
+				a.addSymbol(sp, ok, variableHover(v), v.definition)
+			}
+		case *parse.ExpressionColumn:
+			tbl, col := resolveColumn(tables, n.Table, n.Column)
+			if col != nil {
+				sp, ok := a.doc.nodeSpan(n.GetPosition())
+				a.addSymbol(sp, ok, columnHover(tbl, col), tbl.columns[col.Name])
+			}
+		case *parse.RelationTable:
+			tblNs, name := n.Namespace, n.Table
+			if tblNs != "" {
+				name = tblNs + "." + name
+			} else {
+				tblNs = ns
+			}
+			if tbl := a.schema.table(tblNs, n.Table); tbl != nil {
+				sp, ok := a.doc.nameSpan(n.GetPosition(), name)
+				a.addSymbol(sp, ok, tableHover(tbl), tbl.location)
+			}
+		case *parse.ExpressionFunctionCall:
+			name := n.Name
+			if n.Namespace != "" {
+				name = n.Namespace + "." + name
+			}
+			sp, ok := a.doc.nameSpan(n.GetPosition(), name)
+
+			if def, builtin := engine.Functions[n.Name]; builtin && n.Namespace == "" {
+				a.addSymbol(sp, ok, functionHover(n.Name, def), nil)
+				return
+			}
+
+			actNs := n.Namespace
+			if actNs == "" {
+				actNs = ns
+			}
+			if act := a.schema.action(actNs, n.Name); act != nil {
+				a.addSymbol(sp, ok, a.actionHover(act), act.location)
+			}
+		}
+	})
+}
+
+// resolveColumn finds a column in the tables of a statement. If the column is
+// not qualified, it must be in exactly one of the tables.
+func resolveColumn(tables map[string]*table, qualifier, name string) (*table, *engine.Column) {
+	if qualifier != "" {
+		tbl, ok := tables[qualifier]
+		if !ok {
+			return nil, nil
+		}
+		col, ok := tbl.Column(name)
+		if !ok {
+			return nil, nil
+		}
+		return tbl, col
+	}
+
+	var foundTbl *table
+	var foundCol *engine.Column
+	for _, tbl := range tables {
+		if col, ok := tbl.Column(name); ok {
+			if foundCol != nil && foundTbl != tbl {
+				return nil, nil
+			}
+			foundTbl, foundCol = tbl, col
+		}
+	}
+	return foundTbl, foundCol
+}
+
+// recordType returns the types of the fields of a relation.
+func recordType(rel *logical.Relation) map[string]*types.DataType {
+	record := make(map[string]*types.DataType)
+	for _, f := range rel.Fields {
+		if typ, err := f.Scalar(); err == nil {
+			record[f.Name] = typ
+		}
+	}
+	return record
+}
+
+func typeString(typ *types.DataType) string {
+	if typ == nil {
+		return "UNKNOWN"
+	}
+	return strings.ToUpper(typ.String())
+}
+
+func codeBlock(code string) string {
+	return "```kuneiform\n" + code + "\n```"
+}
+
+func variableHover(v *variable) string {
+	var decl string
+	switch {
+	case v.record != nil:
+		fields := make([]string, 0, len(v.record))
+		for name, typ := range v.record {
+			fields = append(fields, name+" "+typeString(typ))
+		}
+		slices.Sort(fields)
+		decl = v.name + " RECORD (" + strings.Join(fields, ", ") + ")"
+	default:
+		decl = v.name + " " + typeString(v.typ)
+	}
+
+	kind := strings.ToUpper(v.kind[:1]) + v.kind[1:]
+	return codeBlock(decl) + fmt.Sprintf("\n%s of action `%s`.", kind, v.action)
+}
+
+func columnDecl(col *engine.Column) string {
+	decl := col.Name + " " + typeString(col.DataType)
+	if col.IsPrimaryKey {
+		decl += " PRIMARY KEY"
+	} else if !col.Nullable {
+		decl += " NOT NULL"
+	}
+	return decl
+}
+
+func columnHover(tbl *table, col *engine.Column) string {
+	return codeBlock(columnDecl(col)) + fmt.Sprintf("\nColumn of table `%s.%s`.", tbl.namespace, tbl.Name)
+}
+
+func tableHover(tbl *table) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "TABLE %s.%s (", tbl.namespace, tbl.Name)
+	for i, col := range tbl.Columns {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString("\n    " + columnDecl(col))
+	}
+	sb.WriteString("\n)")
+	return codeBlock(sb.String())
+}
+
+// actionSignature formats the parameters, modifiers, and return type of an
+// action.
+func actionSignature(act *action) string {
+	var sb strings.Builder
+	sb.WriteString("(")
+	for i, p := range act.parameters {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(p.Name + " " + typeString(p.Type))
+	}
+	sb.WriteString(")")
+
+	if len(act.modifiers) > 0 {
+		sb.WriteString(" " + strings.Join(act.modifiers, " "))
+	}
+
+	if act.returns != nil {
+		sb.WriteString(" RETURNS ")
+		if act.returns.IsTable {
+			sb.WriteString("TABLE ")
+		}
+		sb.WriteString("(")
+		for i, f := range act.returns.Fields {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(f.Name + " " + typeString(f.Type))
+		}
+		sb.WriteString(")")
+	}
+	return sb.String()
+}
+
+func (a *analyzer) actionHover(act *action) string {
+	hover := codeBlock(fmt.Sprintf("ACTION %s.%s%s", act.namespace, act.name, actionSignature(act)))
+	if act.builtIn {
+		ext := act.namespace
+		if ns, ok := a.schema.namespaces[act.namespace]; ok && ns.extension != "" {
+			ext = ns.extension
+		}
+		hover += fmt.Sprintf("\nMethod of the `%s` extension.", ext)
+	}
+	return hover
+}
+
+// functionKind describes a built-in function.
+func functionKind(def engine.FunctionDefinition) string {
+	switch def.(type) {
+	case *engine.AggregateFunctionDefinition:
+		return "aggregate function"
+	case *engine.WindowFunctionDefinition:
+		return "window function"
+	default:
+		return "scalar function"
+	}
+}
+
+func functionHover(name string, def engine.FunctionDefinition) string {
+	return codeBlock(name+"(...)") + "\nBuilt-in " + functionKind(def) + "."
+}
@@ -0,0 +1,146 @@
+package lsp
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/node/engine"
+)
+
+var (
+	// qualifiedPrefix matches a qualifier followed by a dot and a partial
+	// name at the end of a line, such as "users.na" or "$row.".
+	qualifiedPrefix = regexp.MustCompile(`([$]?[a-zA-Z_][a-zA-Z0-9_]*)\.[a-zA-Z0-9_]*$`)
+	// namePrefix matches a partial name or variable at the end of a line.
+	namePrefix = regexp.MustCompile(`[$@]?[a-zA-Z0-9_]*$`)
+)
+
+// complete returns the completions at a position. The client filters them by
+// what has been typed, so all candidates for the context are returned.
+func complete(doc *document, a *analysis, s *schema, pos Position) []*CompletionItem {
+	p := doc.fromLSP(pos)
+	prefix := doc.prefix(pos)
+
+	if m := qualifiedPrefix.FindStringSubmatch(prefix); m != nil {
+		return completeQualified(a, s, p, m[1])
+	}
+
+	word := namePrefix.FindString(prefix)
+	switch {
+	case strings.HasPrefix(word, "$"):
+		return completeVariables(a, p)
+	case strings.HasPrefix(word, "@"):
+		var items []*CompletionItem
+		for _, name := range slices.Sorted(maps.Keys(contextualVariables)) {
+			items = append(items, &CompletionItem{
+				Label:  name,
+				Kind:   CompletionKindVariable,
+				Detail: typeString(contextualVariables[name]),
+			})
+		}
+		return items
+	}
+
+	ns := a.namespaceAt(p)
+	var items []*CompletionItem
+	for _, name := range slices.Sorted(maps.Keys(engine.Functions)) {
+		items = append(items, &CompletionItem{
+			Label:  name,
+			Kind:   CompletionKindFunction,
+			Detail: "built-in " + functionKind(engine.Functions[name]),
+		})
+	}
+	if n, ok := s.namespaces[ns]; ok {
+		items = append(items, namespaceItems(n)...)
+	}
+	for _, name := range slices.Sorted(maps.Keys(s.namespaces)) {
+		items = append(items, &CompletionItem{Label: name, Kind: CompletionKindModule, Detail: "namespace"})
+	}
+	return items
+}
+
+// completeQualified completes a name after a qualifier, which can be a
+// namespace, a table or its alias, or a record variable.
+func completeQualified(a *analysis, s *schema, p kpos, qualifier string) []*CompletionItem {
+	if strings.HasPrefix(qualifier, "$") {
+		act := a.actionAt(p)
+		if act == nil {
+			return nil
+		}
+		var items []*CompletionItem
+		for _, v := range act.variables {
+			if v.name != qualifier || v.record == nil {
+				continue
+			}
+			for _, field := range slices.Sorted(maps.Keys(v.record)) {
+				items = append(items, &CompletionItem{
+					Label:  field,
+					Kind:   CompletionKindField,
+					Detail: typeString(v.record[field]),
+				})
+			}
+		}
+		return items
+	}
+
+	if n, ok := s.namespaces[qualifier]; ok {
+		return namespaceItems(n)
+	}
+
+	tbl, ok := a.relationsAt(p)[qualifier]
+	if !ok {
+		tbl = s.table(a.namespaceAt(p), qualifier)
+	}
+	if tbl == nil {
+		return nil
+	}
+
+	items := make([]*CompletionItem, len(tbl.Columns))
+	for i, col := range tbl.Columns {
+		items[i] = &CompletionItem{Label: col.Name, Kind: CompletionKindField, Detail: columnDecl(col)}
+	}
+	return items
+}
+
+// completeVariables completes the variables of the action at a position.
+func completeVariables(a *analysis, p kpos) []*CompletionItem {
+	act := a.actionAt(p)
+	if act == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var items []*CompletionItem
+	for _, v := range act.variables {
+		if seen[v.name] {
+			continue
+		}
+		seen[v.name] = true
+
+		detail := typeString(v.typ)
+		if v.record != nil {
+			detail = "RECORD"
+		}
+		items = append(items, &CompletionItem{Label: v.name, Kind: CompletionKindVariable, Detail: detail})
+	}
+	return items
+}
+
+// namespaceItems returns the tables and actions of a namespace.
+func namespaceItems(n *namespace) []*CompletionItem {
+	var items []*CompletionItem
+	for _, name := range slices.Sorted(maps.Keys(n.tables)) {
+		items = append(items, &CompletionItem{Label: name, Kind: CompletionKindClass, Detail: "table"})
+	}
+	for _, name := range slices.Sorted(maps.Keys(n.actions)) {
+		act := n.actions[name]
+		kind := CompletionKindFunction
+		if act.builtIn {
+			kind = CompletionKindMethod
+		}
+		items = append(items, &CompletionItem{Label: name, Kind: kind, Detail: actionSignature(act)})
+	}
+	return items
+}
@@ -0,0 +1,242 @@
+package lsp
+
+import (
+	"net/url"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+	"github.com/kwilteam/kwil-db/node/engine/parse/gen"
+)
+
+// document is the source of a Kuneiform file, either opened by the client or
+// read from the workspace.
+//
+// The parser trims the source before parsing it, so the positions of parsed
+// nodes are lines (1-based) and rune columns (0-based) in the trimmed source.
+// A document converts them to and from LSP positions in the original source.
+type document struct {
+	uri     string
+	version int
+	text    string
+	lines   []string
+	// lineOffset is the number of lines trimmed from the start of the source,
+	// and colOffset the number of runes trimmed from the first parsed line.
+	lineOffset int
+	colOffset  int
+	// tokenEnds maps the start of each token to its end, since parsed
+	// positions end at the start of their last token.
+	tokenEnds map[kpos]kpos
+
+	// stmts are the parsed statements, and parseErrs the errors encountered
+	// while parsing them.
+	stmts     []parse.TopLevelStatement
+	parseErrs []*parse.ParseError
+}
+
+// kpos is a position in the trimmed source, as used by the parser.
+type kpos struct {
+	line, col int
+}
+
+func (p kpos) before(o kpos) bool {
+	return p.line < o.line || (p.line == o.line && p.col < o.col)
+}
+
+// span is a range in the trimmed source. The end is exclusive.
+type span struct {
+	start, end kpos
+}
+
+func (s span) contains(p kpos) bool {
+	// the end is included so that the cursor directly after an identifier
+	// still refers to it
+	return !p.before(s.start) && !s.end.before(p)
+}
+
+// inside returns true if s is within o.
+func (s span) inside(o span) bool {
+	return !s.start.before(o.start) && !o.end.before(s.end)
+}
+
+func newDocument(uri string, version int, text string) *document {
+	trimmed := strings.TrimSpace(text)
+	prefix := text[:len(text)-len(strings.TrimLeftFunc(text, unicode.IsSpace))]
+
+	d := &document{
+		uri:        uri,
+		version:    version,
+		text:       text,
+		lines:      strings.Split(text, "\n"),
+		lineOffset: strings.Count(prefix, "\n"),
+		colOffset:  utf8.RuneCountInString(prefix[strings.LastIndex(prefix, "\n")+1:]),
+		tokenEnds:  make(map[kpos]kpos),
+	}
+
+	lexer := gen.NewKuneiformLexer(antlr.NewInputStream(trimmed))
+	lexer.RemoveErrorListeners()
+	for {
+		tok := lexer.NextToken()
+		if tok.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+
+		txt := tok.GetText()
+		end := kpos{line: tok.GetLine() + strings.Count(txt, "\n")}
+		if i := strings.LastIndex(txt, "\n"); i >= 0 {
+			end.col = utf8.RuneCountInString(txt[i+1:])
+		} else {
+			end.col = tok.GetColumn() + utf8.RuneCountInString(txt)
+		}
+		d.tokenEnds[kpos{line: tok.GetLine(), col: tok.GetColumn()}] = end
+	}
+
+	res, err := parse.ParseWithErrListener(text)
+	if err != nil {
+		d.parseErrs = []*parse.ParseError{{Err: err, Message: "failed to parse"}}
+		return d
+	}
+	d.stmts = res.Statements
+	d.parseErrs = res.ParseErrs.Errors()
+
+	return d
+}
+
+// nodeSpan returns the span of a parsed node. It returns false if the node
+// does not have a position.
+func (d *document) nodeSpan(pos *parse.Position) (span, bool) {
+	if pos == nil || pos.StartLine == nil || pos.StartCol == nil {
+		return span{}, false
+	}
+
+	s := span{start: kpos{line: *pos.StartLine, col: *pos.StartCol}}
+	if pos.EndLine == nil || pos.EndCol == nil {
+		s.end = d.tokenEnd(s.start)
+		return s, true
+	}
+
+	s.end = d.tokenEnd(kpos{line: *pos.EndLine, col: *pos.EndCol})
+	return s, true
+}
+
+// nameSpan returns the span of a name of the given length at the start of a
+// node, such as the name of a table or function.
+func (d *document) nameSpan(pos *parse.Position, name string) (span, bool) {
+	s, ok := d.nodeSpan(pos)
+	if !ok {
+		return s, false
+	}
+	s.end = kpos{line: s.start.line, col: s.start.col + utf8.RuneCountInString(name)}
+	return s, true
+}
+
+// tokenEnd returns the end of the token that starts at a position.
+func (d *document) tokenEnd(start kpos) kpos {
+	if end, ok := d.tokenEnds[start]; ok {
+		return end
+	}
+	return kpos{line: start.line, col: start.col + 1}
+}
+
+// toLSP converts a position in the trimmed source to an LSP position.
+func (d *document) toLSP(p kpos) Position {
+	col := p.col
+	if p.line == 1 {
+		col += d.colOffset
+	}
+	line := p.line - 1 + d.lineOffset
+
+	return Position{Line: line, Character: utf16Len(d.line(line), col)}
+}
+
+// fromLSP converts an LSP position to a position in the trimmed source.
+func (d *document) fromLSP(p Position) kpos {
+	col := runeLen(d.line(p.Line), p.Character)
+	line := p.Line + 1 - d.lineOffset
+	if line == 1 {
+		col -= d.colOffset
+	}
+	return kpos{line: line, col: col}
+}
+
+func (d *document) toRange(s span) Range {
+	return Range{Start: d.toLSP(s.start), End: d.toLSP(s.end)}
+}
+
+func (d *document) location(s span) *Location {
+	return &Location{URI: d.uri, Range: d.toRange(s)}
+}
+
+// line returns a line of the source, or "" if it is out of range.
+func (d *document) line(i int) string {
+	if i < 0 || i >= len(d.lines) {
+		return ""
+	}
+	return d.lines[i]
+}
+
+// prefix returns the text of the line before an LSP position.
+func (d *document) prefix(p Position) string {
+	line := d.line(p.Line)
+	runes := runeLen(line, p.Character)
+	for i := range line {
+		if runes == 0 {
+			return line[:i]
+		}
+		runes--
+	}
+	return line
+}
+
+// utf16Len returns the number of UTF-16 code units in the first n runes of s.
+func utf16Len(s string, n int) int {
+	units := 0
+	for _, r := range s {
+		if n == 0 {
+			break
+		}
+		units += utf16.RuneLen(r)
+		n--
+	}
+	// positions past the end of the line are kept as is
+	return units + n
+}
+
+// runeLen returns the number of runes in the first n UTF-16 code units of s.
+func runeLen(s string, n int) int {
+	runes := 0
+	for _, r := range s {
+		if n <= 0 {
+			break
+		}
+		n -= utf16.RuneLen(r)
+		runes++
+	}
+	if n > 0 {
+		runes += n
+	}
+	return runes
+}
+
+// uriToPath converts a file URI to a path.
+func uriToPath(uri string) (string, bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	return filepath.FromSlash(u.Path), true
+}
+
+// pathToURI converts a path to a file URI.
+func pathToURI(path string) string {
+	abs, err := filepath.Abs(path)
+	if err == nil {
+		path = abs
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(path)}).String()
+}
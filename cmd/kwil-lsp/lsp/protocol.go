@@ -0,0 +1,201 @@
+package lsp
+
+// This file contains the subset of the Language Server Protocol types used by
+// the server. See the specification for the full definitions:
+// https://microsoft.github.io/language-server-protocol/specifications/lsp/3.17/specification/
+
+// Position is a zero-based line and UTF-16 character offset in a document.
+type Position struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+// Range is a range in a document. The end is exclusive.
+type Range struct {
+	Start Position `json:"start"`
+	End   Position `json:"end"`
+}
+
+// Location is a range in a document.
+type Location struct {
+	URI   string `json:"uri"`
+	Range Range  `json:"range"`
+}
+
+// DiagnosticSeverity is the severity of a diagnostic.
+type DiagnosticSeverity int
+
+const (
+	SeverityError       DiagnosticSeverity = 1
+	SeverityWarning     DiagnosticSeverity = 2
+	SeverityInformation DiagnosticSeverity = 3
+	SeverityHint        DiagnosticSeverity = 4
+)
+
+// Diagnostic is a problem in a document.
+type Diagnostic struct {
+	Range    Range              `json:"range"`
+	Severity DiagnosticSeverity `json:"severity"`
+	Code     string             `json:"code,omitempty"`
+	Source   string             `json:"source"`
+	Message  string             `json:"message"`
+}
+
+// PublishDiagnosticsParams are the params of textDocument/publishDiagnostics.
+type PublishDiagnosticsParams struct {
+	URI         string        `json:"uri"`
+	Version     *int          `json:"version,omitempty"`
+	Diagnostics []*Diagnostic `json:"diagnostics"`
+}
+
+// WorkspaceFolder is a root folder of the workspace.
+type WorkspaceFolder struct {
+	URI  string `json:"uri"`
+	Name string `json:"name"`
+}
+
+// InitializeParams are the params of the initialize request.
+type InitializeParams struct {
+	RootURI               string             `json:"rootUri"`
+	WorkspaceFolders      []*WorkspaceFolder `json:"workspaceFolders"`
+	InitializationOptions *InitOptions       `json:"initializationOptions"`
+}
+
+// InitOptions are the Kuneiform specific initialization options, which take
+// precedence over the flags the server was started with.
+type InitOptions struct {
+	// Provider is the URL of a node to read the schema from.
+	Provider string `json:"provider"`
+	// ChainID is the chain ID of the node. It is not verified if empty.
+	ChainID string `json:"chainId"`
+}
+
+// TextDocumentSyncKind is how documents are synced with the server.
+type TextDocumentSyncKind int
+
+// SyncFull syncs the full content of documents on every change.
+const SyncFull TextDocumentSyncKind = 1
+
+// TextDocumentSyncOptions are the document sync capabilities of the server.
+type TextDocumentSyncOptions struct {
+	OpenClose bool                 `json:"openClose"`
+	Change    TextDocumentSyncKind `json:"change"`
+}
+
+// CompletionOptions are the completion capabilities of the server.
+type CompletionOptions struct {
+	TriggerCharacters []string `json:"triggerCharacters"`
+}
+
+// ServerCapabilities are the features the server supports.
+type ServerCapabilities struct {
+	TextDocumentSync   *TextDocumentSyncOptions `json:"textDocumentSync"`
+	HoverProvider      bool                     `json:"hoverProvider"`
+	DefinitionProvider bool                     `json:"definitionProvider"`
+	CompletionProvider *CompletionOptions       `json:"completionProvider"`
+}
+
+// ServerInfo identifies the server.
+type ServerInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// InitializeResult is the result of the initialize request.
+type InitializeResult struct {
+	Capabilities ServerCapabilities `json:"capabilities"`
+	ServerInfo   ServerInfo         `json:"serverInfo"`
+}
+
+// TextDocumentItem is a document opened by the client.
+type TextDocumentItem struct {
+	URI        string `json:"uri"`
+	LanguageID string `json:"languageId"`
+	Version    int    `json:"version"`
+	Text       string `json:"text"`
+}
+
+// TextDocumentIdentifier identifies a document.
+type TextDocumentIdentifier struct {
+	URI string `json:"uri"`
+}
+
+// VersionedTextDocumentIdentifier identifies a version of a document.
+type VersionedTextDocumentIdentifier struct {
+	URI     string `json:"uri"`
+	Version int    `json:"version"`
+}
+
+// TextDocumentContentChangeEvent is a change to a document. Since the server
+// only supports full syncing, the text is the whole document.
+type TextDocumentContentChangeEvent struct {
+	Text string `json:"text"`
+}
+
+// DidOpenTextDocumentParams are the params of textDocument/didOpen.
+type DidOpenTextDocumentParams struct {
+	TextDocument TextDocumentItem `json:"textDocument"`
+}
+
+// DidChangeTextDocumentParams are the params of textDocument/didChange.
+type DidChangeTextDocumentParams struct {
+	TextDocument   VersionedTextDocumentIdentifier   `json:"textDocument"`
+	ContentChanges []*TextDocumentContentChangeEvent `json:"contentChanges"`
+}
+
+// DidCloseTextDocumentParams are the params of textDocument/didClose.
+type DidCloseTextDocumentParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+}
+
+// TextDocumentPositionParams are the params of requests at a position in a
+// document, such as hover, definition, and completion.
+type TextDocumentPositionParams struct {
+	TextDocument TextDocumentIdentifier `json:"textDocument"`
+	Position     Position               `json:"position"`
+}
+
+// MarkupContent is formatted text.
+type MarkupContent struct {
+	// Kind is "plaintext" or "markdown".
+	Kind  string `json:"kind"`
+	Value string `json:"value"`
+}
+
+// Hover is the result of textDocument/hover.
+type Hover struct {
+	Contents MarkupContent `json:"contents"`
+	Range    *Range        `json:"range,omitempty"`
+}
+
+// CompletionItemKind is the kind of a completion item.
+type CompletionItemKind int
+
+const (
+	CompletionKindMethod   CompletionItemKind = 2
+	CompletionKindFunction CompletionItemKind = 3
+	CompletionKindField    CompletionItemKind = 5
+	CompletionKindVariable CompletionItemKind = 6
+	CompletionKindClass    CompletionItemKind = 7
+	CompletionKindModule   CompletionItemKind = 9
+)
+
+// CompletionItem is a single completion.
+type CompletionItem struct {
+	Label  string             `json:"label"`
+	Kind   CompletionItemKind `json:"kind"`
+	Detail string             `json:"detail,omitempty"`
+}
+
+// CompletionList is the result of textDocument/completion.
+type CompletionList struct {
+	IsIncomplete bool              `json:"isIncomplete"`
+	Items        []*CompletionItem `json:"items"`
+}
+
+// LogMessageParams are the params of window/logMessage.
+type LogMessageParams struct {
+	// Type is 1 for errors, 2 for warnings, 3 for info, and 4 for logs.
+	Type    int    `json:"type"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,442 @@
+package lsp
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/precompiles"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+// defaultNamespace is the namespace statements apply to unless they specify
+// another.
+const defaultNamespace = "main"
+
+// schema is the set of namespaces that Kuneiform source is checked against.
+// It is built from the schema of a node, if one is configured, and the
+// statements in the workspace files.
+type schema struct {
+	namespaces map[string]*namespace
+}
+
+// namespace is a namespace, or an extension used with USE ... AS.
+type namespace struct {
+	name string
+	// extension is the name of the extension the namespace is an alias for,
+	// if any.
+	extension string
+	tables    map[string]*table
+	actions   map[string]*action
+	// location is where the namespace is created, or nil if it comes from a
+	// node or is a default namespace.
+	location *Location
+}
+
+// table is a table in a namespace.
+type table struct {
+	*engine.Table
+	namespace string
+	// location is where the table is created, and columns are where each of
+	// its columns are defined. They are nil if the table comes from a node.
+	location *Location
+	columns  map[string]*Location
+}
+
+// action is an action or precompile method in a namespace.
+type action struct {
+	namespace  string
+	name       string
+	parameters []*engine.NamedType
+	modifiers  []string
+	// returns is nil if the action does not return anything.
+	returns *parse.ActionReturn
+	// builtIn is true for the methods of extensions.
+	builtIn bool
+	// location is where the action is created, or nil if it comes from a
+	// node or an extension.
+	location *Location
+}
+
+func newSchema() *schema {
+	s := &schema{namespaces: make(map[string]*namespace)}
+	s.namespace(defaultNamespace)
+	return s
+}
+
+// namespace gets or creates a namespace.
+func (s *schema) namespace(name string) *namespace {
+	ns, ok := s.namespaces[name]
+	if !ok {
+		ns = &namespace{
+			name:    name,
+			tables:  make(map[string]*table),
+			actions: make(map[string]*action),
+		}
+		s.namespaces[name] = ns
+	}
+	return ns
+}
+
+// table returns a table, or nil if it does not exist.
+func (s *schema) table(ns, name string) *table {
+	n, ok := s.namespaces[ns]
+	if !ok {
+		return nil
+	}
+	return n.tables[name]
+}
+
+// action returns an action, or nil if it does not exist.
+func (s *schema) action(ns, name string) *action {
+	n, ok := s.namespaces[ns]
+	if !ok {
+		return nil
+	}
+	return n.actions[name]
+}
+
+// clone copies the schema, so that statements can be applied to the copy.
+// Tables and actions are replaced rather than modified, so they are shared.
+func (s *schema) clone() *schema {
+	s2 := &schema{namespaces: make(map[string]*namespace, len(s.namespaces))}
+	for name, ns := range s.namespaces {
+		ns2 := *ns
+		ns2.tables = maps.Clone(ns.tables)
+		ns2.actions = maps.Clone(ns.actions)
+		s2.namespaces[name] = &ns2
+	}
+	return s2
+}
+
+// apply applies the statements of a document to the schema, in order.
+func (s *schema) apply(doc *document) {
+	current := defaultNamespace
+	for _, stmt := range doc.stmts {
+		if stmt == nil {
+			continue
+		}
+
+		ns := current
+		if n, ok := stmt.(parse.Namespaceable); ok && n.GetNamespacePrefix() != "" {
+			ns = n.GetNamespacePrefix()
+		}
+
+		switch stmt := stmt.(type) {
+		case *parse.SetCurrentNamespaceStatement:
+			current = stmt.Namespace
+		case *parse.CreateNamespaceStatement:
+			n := s.namespace(stmt.Namespace)
+			if sp, ok := doc.nodeSpan(stmt.GetPosition()); ok {
+				n.location = doc.location(sp)
+			}
+		case *parse.DropNamespaceStatement:
+			delete(s.namespaces, stmt.Namespace)
+		case *parse.UseExtensionStatement:
+			s.useExtension(stmt.ExtName, stmt.Alias)
+		case *parse.UnuseExtensionStatement:
+			delete(s.namespaces, stmt.Alias)
+		case *parse.CreateTableStatement:
+			s.namespace(ns).tables[stmt.Name] = tableFromStatement(doc, ns, stmt)
+		case *parse.DropTableStatement:
+			for _, name := range stmt.Tables {
+				delete(s.namespace(ns).tables, name)
+			}
+		case *parse.AlterTableStatement:
+			s.alterTable(ns, stmt)
+		case *parse.CreateActionStatement:
+			act := &action{
+				namespace:  ns,
+				name:       stmt.Name,
+				parameters: stmt.Parameters,
+				modifiers:  stmt.Modifiers,
+				returns:    stmt.Returns,
+			}
+			if sp, ok := doc.nodeSpan(stmt.GetPosition()); ok {
+				act.location = doc.location(sp)
+			}
+			s.namespace(ns).actions[stmt.Name] = act
+		case *parse.DropActionStatement:
+			delete(s.namespace(ns).actions, stmt.Name)
+		}
+	}
+}
+
+// tableFromStatement converts a CREATE TABLE statement to a table.
+func tableFromStatement(doc *document, ns string, stmt *parse.CreateTableStatement) *table {
+	tbl := &table{
+		Table:     &engine.Table{Name: stmt.Name},
+		namespace: ns,
+		columns:   make(map[string]*Location),
+	}
+	if sp, ok := doc.nodeSpan(stmt.GetPosition()); ok {
+		tbl.location = doc.location(sp)
+	}
+
+	var pk []string
+	for _, c := range stmt.Constraints {
+		if c, ok := c.Constraint.(*parse.PrimaryKeyOutOfLineConstraint); ok {
+			pk = append(pk, c.Columns...)
+		}
+	}
+
+	for _, col := range stmt.Columns {
+		c := &engine.Column{
+			Name:         col.Name,
+			DataType:     col.Type,
+			Nullable:     true,
+			IsPrimaryKey: slices.Contains(pk, col.Name),
+		}
+		for _, con := range col.Constraints {
+			switch con.(type) {
+			case *parse.PrimaryKeyInlineConstraint:
+				c.IsPrimaryKey = true
+			case *parse.NotNullConstraint:
+				c.Nullable = false
+			}
+		}
+		if c.IsPrimaryKey {
+			c.Nullable = false
+		}
+
+		tbl.Columns = append(tbl.Columns, c)
+		if sp, ok := doc.nodeSpan(col.GetPosition()); ok {
+			tbl.columns[col.Name] = doc.location(sp)
+		}
+	}
+
+	return tbl
+}
+
+// alterTable applies the column and name changes of an ALTER TABLE statement.
+// Other changes do not affect how the table is checked.
+func (s *schema) alterTable(ns string, stmt *parse.AlterTableStatement) {
+	n := s.namespace(ns)
+	old, ok := n.tables[stmt.Table]
+	if !ok {
+		return
+	}
+
+	tbl := &table{
+		Table:     old.Table.Copy(),
+		namespace: ns,
+		location:  old.location,
+		columns:   maps.Clone(old.columns),
+	}
+
+	for _, act := range stmt.Actions {
+		switch act := act.(type) {
+		case *parse.AddColumn:
+			tbl.Columns = append(tbl.Columns, &engine.Column{Name: act.Name, DataType: act.Type, Nullable: true})
+		case *parse.DropColumn:
+			tbl.Columns = slices.DeleteFunc(tbl.Columns, func(c *engine.Column) bool { return c.Name == act.Name })
+			delete(tbl.columns, act.Name)
+		case *parse.RenameColumn:
+			if c, ok := tbl.Column(act.OldName); ok {
+				c.Name = act.NewName
+				tbl.columns[act.NewName] = tbl.columns[act.OldName]
+				delete(tbl.columns, act.OldName)
+			}
+		case *parse.AlterColumnSet:
+			if c, ok := tbl.Column(act.Column); ok && act.Type == parse.ConstraintTypeNotNull {
+				c.Nullable = false
+			}
+		case *parse.AlterColumnDrop:
+			if c, ok := tbl.Column(act.Column); ok && act.Type == parse.ConstraintTypeNotNull {
+				c.Nullable = true
+			}
+		case *parse.RenameTable:
+			delete(n.tables, tbl.Name)
+			tbl.Name = act.Name
+		}
+	}
+
+	n.tables[tbl.Name] = tbl
+}
+
+// useExtension adds a namespace for an extension alias. If the extension is
+// registered in this binary, its methods are added to the namespace.
+func (s *schema) useExtension(ext, alias string) {
+	ns := s.namespace(alias)
+	ns.extension = ext
+
+	for _, method := range precompileMethods(ext, alias) {
+		params := make([]*engine.NamedType, len(method.Parameters))
+		for i, p := range method.Parameters {
+			params[i] = &engine.NamedType{Name: "$" + p.Name, Type: p.Type}
+		}
+
+		mods := make([]string, len(method.AccessModifiers))
+		for i, m := range method.AccessModifiers {
+			mods[i] = strings.ToLower(string(m))
+		}
+
+		var returns *parse.ActionReturn
+		if method.Returns != nil {
+			returns = &parse.ActionReturn{IsTable: method.Returns.IsTable}
+			for _, f := range method.Returns.Fields {
+				returns.Fields = append(returns.Fields, &engine.NamedType{Name: f.Name, Type: f.Type})
+			}
+		}
+
+		ns.actions[method.Name] = &action{
+			namespace:  alias,
+			name:       method.Name,
+			parameters: params,
+			modifiers:  mods,
+			returns:    returns,
+			builtIn:    true,
+		}
+	}
+}
+
+// precompileMethods returns the methods of a precompile registered in this
+// binary. Precompiles are initialized without a service or database, so
+// initializers that need them are skipped.
+func precompileMethods(ext, alias string) (methods []precompiles.Method) {
+	init, ok := precompiles.RegisteredPrecompiles()[strings.ToLower(ext)]
+	if !ok {
+		return nil
+	}
+
+	defer func() {
+		if recover() != nil {
+			methods = nil
+		}
+	}()
+
+	p, err := init(context.Background(), nil, nil, alias, nil)
+	if err != nil {
+		return nil
+	}
+	return p.Methods
+}
+
+// querier queries a node. It is satisfied by *client.Client.
+type querier interface {
+	Query(ctx context.Context, query string, params map[string]any, skipAuth bool) (*types.QueryResult, error)
+}
+
+// loadSchema reads the namespaces, tables, and actions of a node from its
+// info namespace.
+func loadSchema(ctx context.Context, q querier) (*schema, error) {
+	s := newSchema()
+
+	res, err := q.Query(ctx, "SELECT name FROM info.namespaces", nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list namespaces: %w", err)
+	}
+	var nsName string
+	err = res.Scan(func() error {
+		s.namespace(nsName)
+		return nil
+	}, &nsName)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err = q.Query(ctx, "SELECT namespace, extension FROM info.extensions", nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list extensions: %w", err)
+	}
+	var extName string
+	err = res.Scan(func() error {
+		s.namespace(nsName).extension = extName
+		return nil
+	}, &nsName, &extName)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err = q.Query(ctx, "SELECT namespace, table_name, name, data_type, is_nullable, is_primary_key FROM info.columns", nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list columns: %w", err)
+	}
+	var tblName, colName, dataType string
+	var nullable, pk bool
+	err = res.Scan(func() error {
+		dt, err := types.ParseDataType(dataType)
+		if err != nil {
+			return fmt.Errorf(`column "%s.%s": %w`, tblName, colName, err)
+		}
+
+		ns := s.namespace(nsName)
+		tbl, ok := ns.tables[tblName]
+		if !ok {
+			tbl = &table{Table: &engine.Table{Name: tblName}, namespace: nsName}
+			ns.tables[tblName] = tbl
+		}
+		tbl.Columns = append(tbl.Columns, &engine.Column{
+			Name:         colName,
+			DataType:     dt,
+			Nullable:     nullable,
+			IsPrimaryKey: pk,
+		})
+		return nil
+	}, &nsName, &tblName, &colName, &dataType, &nullable, &pk)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err = q.Query(ctx, `SELECT namespace, name, access_modifiers, parameter_names, parameter_types,
+	return_names, return_types, returns_table, built_in FROM info.actions`, nil, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list actions: %w", err)
+	}
+	var actName string
+	var mods, paramNames, paramTypes, returnNames, returnTypes []string
+	var returnsTable, builtIn bool
+	err = res.Scan(func() error {
+		act := &action{
+			namespace: nsName,
+			name:      actName,
+			modifiers: make([]string, len(mods)),
+			builtIn:   builtIn,
+		}
+		for i, m := range mods {
+			act.modifiers[i] = strings.ToLower(m)
+		}
+
+		act.parameters, err = namedTypes(paramNames, paramTypes)
+		if err != nil {
+			return fmt.Errorf(`action "%s": %w`, actName, err)
+		}
+
+		if len(returnTypes) > 0 || returnsTable {
+			act.returns = &parse.ActionReturn{IsTable: returnsTable}
+			act.returns.Fields, err = namedTypes(returnNames, returnTypes)
+			if err != nil {
+				return fmt.Errorf(`action "%s": %w`, actName, err)
+			}
+		}
+
+		s.namespace(nsName).actions[actName] = act
+		return nil
+	}, &nsName, &actName, &mods, &paramNames, &paramTypes, &returnNames, &returnTypes, &returnsTable, &builtIn)
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// namedTypes pairs names with types.
+func namedTypes(names, typs []string) ([]*engine.NamedType, error) {
+	if len(names) != len(typs) {
+		return nil, fmt.Errorf("got %d names and %d types", len(names), len(typs))
+	}
+
+	res := make([]*engine.NamedType, len(names))
+	for i, name := range names {
+		dt, err := types.ParseDataType(typs[i])
+		if err != nil {
+			return nil, err
+		}
+		res[i] = &engine.NamedType{Name: name, Type: dt}
+	}
+	return res, nil
+}
@@ -0,0 +1,490 @@
+// Package lsp implements a Language Server Protocol server for Kuneiform.
+//
+// The server checks Kuneiform files against the tables and actions defined in
+// the workspace, and optionally those of a live node. It publishes parse
+// errors, type errors found by the logical planner, and lint warnings, and
+// provides hover information, go-to-definition, and completion.
+package lsp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/kwilteam/kwil-db/core/client"
+	clientType "github.com/kwilteam/kwil-db/core/client/types"
+	"github.com/kwilteam/kwil-db/core/log"
+)
+
+// Config configures the server.
+type Config struct {
+	// Provider is the URL of a node to read the schema from. If empty, only
+	// the workspace files are used.
+	Provider string
+	// ChainID is the chain ID of the provider. It is not verified if empty.
+	ChainID string
+	// Version is the version reported to the client.
+	Version string
+	// Logger logs the server's activity. It must not write to the output
+	// stream of the server.
+	Logger log.Logger
+}
+
+// sourceExtensions are the extensions of the workspace files that are read.
+var sourceExtensions = []string{".sql", ".kf"}
+
+// maxSourceSize is the size of the largest workspace file that is read.
+const maxSourceSize = 1 << 20
+
+// schemaTimeout is how long to wait for the schema of the provider.
+const schemaTimeout = 10 * time.Second
+
+// Server is a Kuneiform language server. It handles one client over a
+// stream, processing messages in the order they are received.
+type Server struct {
+	cfg    Config
+	logger log.Logger
+	conn   *conn
+	// dial connects to the provider. It is replaced in tests.
+	dial func(ctx context.Context, provider, chainID string) (querier, error)
+
+	roots []string
+	// files are the workspace files read from disk, and open the documents
+	// opened by the client, by URI. Open documents take precedence.
+	files map[string]*document
+	open  map[string]*openDocument
+	// remote is the schema of the provider, and schema the schema with the
+	// workspace files applied to it.
+	remote *schema
+	schema *schema
+
+	shutdown bool
+}
+
+// openDocument is a document opened by the client.
+type openDocument struct {
+	doc      *document
+	analysis *analysis
+}
+
+// NewServer creates a server.
+func NewServer(cfg *Config) *Server {
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.DiscardLogger
+	}
+
+	return &Server{
+		cfg:    *cfg,
+		logger: logger,
+		dial: func(ctx context.Context, provider, chainID string) (querier, error) {
+			return client.NewClient(ctx, provider, &clientType.Options{
+				ChainID: chainID,
+				Silence: true,
+			})
+		},
+		files:  make(map[string]*document),
+		open:   make(map[string]*openDocument),
+		schema: newSchema(),
+	}
+}
+
+// Serve serves a client, reading requests from r and writing responses to w.
+// It returns when the client sends the exit notification, the input is
+// closed, or the context is canceled.
+func (s *Server) Serve(ctx context.Context, r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+
+	type readResult struct {
+		req *request
+		err error
+	}
+	reqs := make(chan readResult)
+	go func() {
+		for {
+			req, err := s.conn.read()
+			select {
+			case reqs <- readResult{req, err}:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				var rpcErr *rpcError
+				if !errors.As(err, &rpcErr) {
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		var res readResult
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case res = <-reqs:
+		}
+
+		if res.err != nil {
+			var rpcErr *rpcError
+			if errors.As(res.err, &rpcErr) {
+				if err := s.conn.replyErr(nil, rpcErr); err != nil {
+					return err
+				}
+				continue
+			}
+			if errors.Is(res.err, io.EOF) {
+				return nil
+			}
+			return res.err
+		}
+
+		if res.req.Method == "exit" {
+			return nil
+		}
+
+		if err := s.handle(ctx, res.req); err != nil {
+			return err
+		}
+	}
+}
+
+// handle handles a request or notification. It only returns an error if the
+// response cannot be written.
+func (s *Server) handle(ctx context.Context, req *request) error {
+	result, err := s.dispatch(ctx, req)
+	if req.isNotification() {
+		if err != nil {
+			s.logger.Warnf("failed to handle %s: %v", req.Method, err)
+		}
+		return nil
+	}
+
+	if err != nil {
+		var rpcErr *rpcError
+		if !errors.As(err, &rpcErr) {
+			rpcErr = &rpcError{Code: codeInternalError, Message: err.Error()}
+		}
+		return s.conn.replyErr(req.ID, rpcErr)
+	}
+	return s.conn.reply(req.ID, result)
+}
+
+func (s *Server) dispatch(ctx context.Context, req *request) (any, error) {
+	if s.shutdown && req.Method != "exit" {
+		return nil, &rpcError{Code: codeInvalidRequest, Message: "server is shut down"}
+	}
+
+	switch req.Method {
+	case "initialize":
+		var params InitializeParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.initialize(ctx, &params), nil
+	case "initialized":
+		return nil, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		td := params.TextDocument
+		s.open[td.URI] = &openDocument{doc: newDocument(td.URI, td.Version, td.Text)}
+		return nil, s.update()
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		od, ok := s.open[params.TextDocument.URI]
+		if !ok || len(params.ContentChanges) == 0 {
+			return nil, nil
+		}
+		// with full syncing, the last change is the whole document
+		text := params.ContentChanges[len(params.ContentChanges)-1].Text
+		od.doc = newDocument(params.TextDocument.URI, params.TextDocument.Version, text)
+		return nil, s.update()
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		uri := params.TextDocument.URI
+		delete(s.open, uri)
+		if _, ok := s.files[uri]; ok {
+			// the file may have changed on disk while it was open
+			s.readFile(uri)
+		}
+		if err := s.publish(uri, nil, nil); err != nil {
+			return nil, err
+		}
+		return nil, s.update()
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.hover(&params), nil
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.definition(&params), nil
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := unmarshalParams(req.Params, &params); err != nil {
+			return nil, err
+		}
+		return s.completion(&params), nil
+	}
+
+	if req.isNotification() {
+		// notifications that are not supported, such as $/cancelRequest,
+		// can be ignored
+		return nil, nil
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: "method not supported: " + req.Method}
+}
+
+func unmarshalParams(params json.RawMessage, v any) error {
+	if len(params) == 0 {
+		return &rpcError{Code: codeInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &rpcError{Code: codeInvalidParams, Message: err.Error()}
+	}
+	return nil
+}
+
+func (s *Server) initialize(ctx context.Context, params *InitializeParams) *InitializeResult {
+	if opts := params.InitializationOptions; opts != nil {
+		if opts.Provider != "" {
+			s.cfg.Provider = opts.Provider
+		}
+		if opts.ChainID != "" {
+			s.cfg.ChainID = opts.ChainID
+		}
+	}
+
+	for _, folder := range params.WorkspaceFolders {
+		if path, ok := uriToPath(folder.URI); ok {
+			s.roots = append(s.roots, path)
+		}
+	}
+	if len(s.roots) == 0 {
+		if path, ok := uriToPath(params.RootURI); ok {
+			s.roots = append(s.roots, path)
+		}
+	}
+
+	for _, root := range s.roots {
+		s.scan(root)
+	}
+
+	if s.cfg.Provider != "" {
+		if err := s.loadRemote(ctx); err != nil {
+			s.logMessage(1, fmt.Sprintf("failed to read the schema from %s: %v", s.cfg.Provider, err))
+		}
+	}
+
+	// there are no open documents yet, so nothing is published
+	_ = s.update()
+
+	return &InitializeResult{
+		Capabilities: ServerCapabilities{
+			TextDocumentSync: &TextDocumentSyncOptions{
+				OpenClose: true,
+				Change:    SyncFull,
+			},
+			HoverProvider:      true,
+			DefinitionProvider: true,
+			CompletionProvider: &CompletionOptions{
+				TriggerCharacters: []string{".", "$", "@"},
+			},
+		},
+		ServerInfo: ServerInfo{Name: "kwil-lsp", Version: s.cfg.Version},
+	}
+}
+
+// loadRemote reads the schema of the provider.
+func (s *Server) loadRemote(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, schemaTimeout)
+	defer cancel()
+
+	q, err := s.dial(ctx, s.cfg.Provider, s.cfg.ChainID)
+	if err != nil {
+		return err
+	}
+
+	remote, err := loadSchema(ctx, q)
+	if err != nil {
+		return err
+	}
+	s.remote = remote
+
+	s.logger.Infof("read %d namespaces from %s", len(remote.namespaces), s.cfg.Provider)
+	return nil
+}
+
+// scan reads the Kuneiform files in a workspace root. Hidden directories and
+// dependency directories are skipped.
+func (s *Server) scan(root string) {
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// unreadable files and directories are skipped
+			return nil
+		}
+
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (strings.HasPrefix(name, ".") || name == "node_modules" || name == "vendor") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !slices.Contains(sourceExtensions, filepath.Ext(path)) {
+			return nil
+		}
+		if info, err := d.Info(); err != nil || info.Size() > maxSourceSize {
+			return nil
+		}
+
+		s.readFile(pathToURI(path))
+		return nil
+	})
+	if err != nil {
+		s.logger.Warnf("failed to scan %s: %v", root, err)
+	}
+}
+
+// readFile reads a workspace file from disk. Files that cannot be read are
+// removed from the workspace.
+func (s *Server) readFile(uri string) {
+	path, ok := uriToPath(uri)
+	if !ok {
+		return
+	}
+
+	bts, err := os.ReadFile(path)
+	if err != nil {
+		delete(s.files, uri)
+		return
+	}
+	s.files[uri] = newDocument(uri, 0, string(bts))
+}
+
+// update rebuilds the schema from the workspace, then checks and publishes
+// the diagnostics of every open document, since a change to one document can
+// affect the others.
+func (s *Server) update() error {
+	docs := maps.Clone(s.files)
+	for uri, od := range s.open {
+		docs[uri] = od.doc
+	}
+
+	if s.remote != nil {
+		s.schema = s.remote.clone()
+	} else {
+		s.schema = newSchema()
+	}
+	for _, uri := range slices.Sorted(maps.Keys(docs)) {
+		s.schema.apply(docs[uri])
+	}
+
+	for _, uri := range slices.Sorted(maps.Keys(s.open)) {
+		od := s.open[uri]
+		res := analyze(od.doc, s.schema)
+		if len(od.doc.parseErrs) > 0 && od.analysis != nil {
+			// keep the symbols of the last version that parsed, so hover and
+			// completion keep working while the document is being edited
+			res.symbols = od.analysis.symbols
+			res.statements = od.analysis.statements
+			res.actions = od.analysis.actions
+			res.relations = od.analysis.relations
+		}
+		od.analysis = res
+
+		if err := s.publish(uri, &od.doc.version, res.diagnostics); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Server) publish(uri string, version *int, diags []*Diagnostic) error {
+	if diags == nil {
+		diags = []*Diagnostic{}
+	}
+	return s.conn.notify("textDocument/publishDiagnostics", &PublishDiagnosticsParams{
+		URI:         uri,
+		Version:     version,
+		Diagnostics: diags,
+	})
+}
+
+// logMessage shows a message in the client's log.
+func (s *Server) logMessage(typ int, msg string) {
+	s.logger.Info(msg)
+	if err := s.conn.notify("window/logMessage", &LogMessageParams{Type: typ, Message: msg}); err != nil {
+		s.logger.Warnf("failed to send log message: %v", err)
+	}
+}
+
+func (s *Server) hover(params *TextDocumentPositionParams) *Hover {
+	od, ok := s.open[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	sym := od.analysis.symbolAt(od.doc.fromLSP(params.Position))
+	if sym == nil {
+		return nil
+	}
+
+	rng := od.doc.toRange(sym.span)
+	return &Hover{
+		Contents: MarkupContent{Kind: "markdown", Value: sym.hover},
+		Range:    &rng,
+	}
+}
+
+func (s *Server) definition(params *TextDocumentPositionParams) []*Location {
+	od, ok := s.open[params.TextDocument.URI]
+	if !ok {
+		return nil
+	}
+
+	sym := od.analysis.symbolAt(od.doc.fromLSP(params.Position))
+	if sym == nil || sym.definition == nil {
+		return nil
+	}
+	return []*Location{sym.definition}
+}
+
+func (s *Server) completion(params *TextDocumentPositionParams) *CompletionList {
+	od, ok := s.open[params.TextDocument.URI]
+	if !ok {
+		return &CompletionList{Items: []*CompletionItem{}}
+	}
+
+	items := complete(od.doc, od.analysis, s.schema, params.Position)
+	if items == nil {
+		items = []*CompletionItem{}
+	}
+	return &CompletionList{Items: items}
+}
@@ -0,0 +1,345 @@
+package lsp
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// testClient is an LSP client talking to a server over pipes.
+type testClient struct {
+	t      *testing.T
+	w      io.Writer
+	msgs   chan map[string]json.RawMessage
+	nextID int
+	// notifications are the notifications received while waiting for
+	// responses, by method.
+	notifications map[string][]json.RawMessage
+}
+
+func newTestClient(t *testing.T, s *Server) *testClient {
+	clientR, serverW := io.Pipe()
+	serverR, clientW := io.Pipe()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- s.Serve(context.Background(), serverR, serverW)
+		serverW.Close()
+	}()
+
+	c := &testClient{
+		t:             t,
+		w:             clientW,
+		msgs:          make(chan map[string]json.RawMessage, 100),
+		notifications: make(map[string][]json.RawMessage),
+	}
+
+	go func() {
+		r := textproto.NewReader(bufio.NewReader(clientR))
+		for {
+			header, err := r.ReadMIMEHeader()
+			if err != nil {
+				close(c.msgs)
+				return
+			}
+			length, _ := strconv.Atoi(header.Get("Content-Length"))
+			body := make([]byte, length)
+			if _, err = io.ReadFull(r.R, body); err != nil {
+				close(c.msgs)
+				return
+			}
+			var msg map[string]json.RawMessage
+			if err = json.Unmarshal(body, &msg); err != nil {
+				close(c.msgs)
+				return
+			}
+			c.msgs <- msg
+		}
+	}()
+
+	t.Cleanup(func() {
+		c.send(map[string]any{"jsonrpc": "2.0", "method": "exit"})
+		require.NoError(t, <-done)
+	})
+
+	return c
+}
+
+func (c *testClient) send(msg any) {
+	bts, err := json.Marshal(msg)
+	require.NoError(c.t, err)
+	_, err = io.WriteString(c.w, "Content-Length: "+strconv.Itoa(len(bts))+"\r\n\r\n"+string(bts))
+	require.NoError(c.t, err)
+}
+
+// notify sends a notification.
+func (c *testClient) notify(method string, params any) {
+	c.send(map[string]any{"jsonrpc": "2.0", "method": method, "params": params})
+}
+
+// call sends a request and unmarshals the result into res.
+func (c *testClient) call(method string, params any, res any) {
+	c.nextID++
+	id := strconv.Itoa(c.nextID)
+	c.send(map[string]any{"jsonrpc": "2.0", "id": c.nextID, "method": method, "params": params})
+
+	for msg := range c.msgs {
+		if m, ok := msg["method"]; ok {
+			var name string
+			require.NoError(c.t, json.Unmarshal(m, &name))
+			c.notifications[name] = append(c.notifications[name], msg["params"])
+			continue
+		}
+
+		require.Equal(c.t, id, string(msg["id"]))
+		require.Nil(c.t, msg["error"], "error: %s", msg["error"])
+		require.NoError(c.t, json.Unmarshal(msg["result"], res))
+		return
+	}
+	c.t.Fatal("server closed the connection")
+}
+
+// diagnostics returns the last diagnostics published for a document.
+func (c *testClient) diagnostics(uri string) []*Diagnostic {
+	var diags []*Diagnostic
+	for _, raw := range c.notifications["textDocument/publishDiagnostics"] {
+		var params PublishDiagnosticsParams
+		require.NoError(c.t, json.Unmarshal(raw, &params))
+		if params.URI == uri {
+			diags = params.Diagnostics
+		}
+	}
+	return diags
+}
+
+// fakeNode is a node with a namespace that has a table and an extension
+// with a method.
+type fakeNode struct{}
+
+func (fakeNode) Query(_ context.Context, query string, _ map[string]any, _ bool) (*types.QueryResult, error) {
+	switch {
+	case strings.Contains(query, "info.namespaces"):
+		return &types.QueryResult{Values: [][]any{{"main"}, {"remote"}, {"tokens"}}}, nil
+	case strings.Contains(query, "info.extensions"):
+		return &types.QueryResult{Values: [][]any{{"tokens", "erc20"}}}, nil
+	case strings.Contains(query, "info.columns"):
+		return &types.QueryResult{Values: [][]any{
+			{"remote", "balances", "owner", "text", false, true},
+			{"remote", "balances", "amount", "numeric(78,0)", true, false},
+		}}, nil
+	case strings.Contains(query, "info.actions"):
+		return &types.QueryResult{Values: [][]any{
+			{"tokens", "balance", []any{"PUBLIC", "VIEW"}, []any{"$param_1"}, []any{"text"}, []any{"balance"}, []any{"numeric(78,0)"}, false, true},
+		}}, nil
+	}
+	return &types.QueryResult{}, nil
+}
+
+// position returns the LSP position of the first occurrence of a substring,
+// plus an offset.
+func position(t *testing.T, text, substr string, offset int) Position {
+	i := strings.Index(text, substr)
+	require.GreaterOrEqual(t, i, 0, substr)
+	i += offset
+	line := strings.Count(text[:i], "\n")
+	return Position{Line: line, Character: i - (strings.LastIndex(text[:i], "\n") + 1)}
+}
+
+const workspaceSchema = `CREATE TABLE users (
+    id INT8 PRIMARY KEY,
+    name TEXT NOT NULL
+);
+
+CREATE NAMESPACE other;
+
+{other}CREATE ACTION greet($name TEXT) public view returns (greeting TEXT) {
+    return 'hello ' || $name;
+};
+`
+
+const openDoc = `
+CREATE ACTION get_user($id INT8) public view returns (name TEXT) {
+    for $row in SELECT id, name FROM users WHERE id = $id ORDER BY id {
+        $greeting := other.greet($row.name);
+        return $greeting;
+    }
+    $total := $id + 1;
+    $b := tokens.balance(@caller);
+    SELECT missing FROM users;
+};
+
+SELECT u.name, amount FROM users AS u JOIN remote.balances ON owner = u.name;
+`
+
+func Test_Server(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "schema.sql"), []byte(workspaceSchema), 0644))
+	// hidden directories are not read
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".hidden"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".hidden", "bad.sql"), []byte("CREATE TABLE users (x int);"), 0644))
+
+	s := NewServer(&Config{Provider: "http://localhost:8484"})
+	s.dial = func(ctx context.Context, provider, chainID string) (querier, error) {
+		require.Equal(t, "http://localhost:8484", provider)
+		return fakeNode{}, nil
+	}
+	c := newTestClient(t, s)
+
+	var initRes InitializeResult
+	c.call("initialize", &InitializeParams{RootURI: pathToURI(dir)}, &initRes)
+	require.True(t, initRes.Capabilities.HoverProvider)
+	c.notify("initialized", map[string]any{})
+
+	uri := pathToURI(filepath.Join(dir, "actions.sql"))
+	c.notify("textDocument/didOpen", &DidOpenTextDocumentParams{
+		TextDocument: TextDocumentItem{URI: uri, LanguageID: "kuneiform", Version: 1, Text: openDoc},
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			pos    Position
+			want   string
+			hasDef bool
+		}{
+			{"parameter", position(t, openDoc, "id = $id", 5), "$id INT8", true},
+			{"record", position(t, openDoc, "$row.name", 1), "$row RECORD (id INT8, name TEXT)", true},
+			{"inferred", position(t, openDoc, "$total", 1), "$total INT8", true},
+			{"call receiver", position(t, openDoc, "return $greeting", 8), "$greeting TEXT", true},
+			{"precompile receiver", position(t, openDoc, "$b :=", 1), "$b NUMERIC(78,0)", true},
+			{"contextual", position(t, openDoc, "@caller", 1), "@caller TEXT", false},
+			{"column", position(t, openDoc, "WHERE id", 6), "id INT8 PRIMARY KEY", true},
+			{"aliased column", position(t, openDoc, "u.name", 2), "name TEXT NOT NULL", true},
+			{"remote column", position(t, openDoc, "amount FROM", 1), "amount NUMERIC(78,0)", false},
+			{"table", position(t, openDoc, "FROM users", 6), "TABLE main.users (", true},
+			{"action", position(t, openDoc, "other.greet", 7), "ACTION other.greet($name TEXT) public view RETURNS (greeting TEXT)", true},
+			{"precompile", position(t, openDoc, "tokens.balance", 8), "Method of the `erc20` extension.", false},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				params := &TextDocumentPositionParams{TextDocument: TextDocumentIdentifier{URI: uri}, Position: tt.pos}
+
+				var hover *Hover
+				c.call("textDocument/hover", params, &hover)
+				require.NotNil(t, hover)
+				require.Contains(t, hover.Contents.Value, tt.want)
+
+				var locs []*Location
+				c.call("textDocument/definition", params, &locs)
+				if tt.hasDef {
+					require.Len(t, locs, 1)
+				} else {
+					require.Empty(t, locs)
+				}
+			})
+		}
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		var locs []*Location
+		c.call("textDocument/definition", &TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     position(t, openDoc, "other.greet", 7),
+		}, &locs)
+		require.Len(t, locs, 1)
+		require.Equal(t, pathToURI(filepath.Join(dir, "schema.sql")), locs[0].URI)
+		require.Equal(t, 7, locs[0].Range.Start.Line)
+
+		c.call("textDocument/definition", &TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     position(t, openDoc, "u.name", 2),
+		}, &locs)
+		require.Len(t, locs, 1)
+		require.Equal(t, Range{Start: Position{Line: 2, Character: 4}, End: Position{Line: 2, Character: 22}}, locs[0].Range)
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		diags := c.diagnostics(uri)
+		require.Len(t, diags, 3)
+
+		// unused variables are reported by the linter
+		for i, name := range []string{"$total", "$b"} {
+			require.Equal(t, "unused-variable", diags[i].Code)
+			require.Equal(t, SeverityWarning, diags[i].Severity)
+			require.Equal(t, position(t, openDoc, name+" :=", 0), diags[i].Range.Start)
+		}
+
+		// the unknown column is reported by the planner, on its statement
+		require.Equal(t, SeverityError, diags[2].Severity)
+		require.Contains(t, diags[2].Message, "missing")
+		require.Equal(t, position(t, openDoc, "SELECT missing", 0), diags[2].Range.Start)
+	})
+
+	t.Run("completion", func(t *testing.T) {
+		complete := func(text string, pos Position) []string {
+			c.notify("textDocument/didChange", &DidChangeTextDocumentParams{
+				TextDocument:   VersionedTextDocumentIdentifier{URI: uri, Version: 2},
+				ContentChanges: []*TextDocumentContentChangeEvent{{Text: text}},
+			})
+
+			var list CompletionList
+			c.call("textDocument/completion", &TextDocumentPositionParams{
+				TextDocument: TextDocumentIdentifier{URI: uri},
+				Position:     pos,
+			}, &list)
+
+			labels := make([]string, len(list.Items))
+			for i, item := range list.Items {
+				labels[i] = item.Label
+			}
+			return labels
+		}
+
+		labels := complete(openDoc, position(t, openDoc, "$total :=", 1))
+		require.ElementsMatch(t, []string{"$id", "$row", "$greeting", "$total", "$b"}, labels)
+
+		labels = complete(openDoc, position(t, openDoc, "row.name", 4))
+		require.ElementsMatch(t, []string{"id", "name"}, labels)
+
+		labels = complete(openDoc, position(t, openDoc, "balance(", 0))
+		require.Equal(t, []string{"balance"}, labels)
+
+		labels = complete(openDoc, position(t, openDoc, "u.name", 2))
+		require.Equal(t, []string{"id", "name"}, labels)
+
+		labels = complete(openDoc, position(t, openDoc, "@caller", 1))
+		require.Contains(t, labels, "@block_timestamp")
+
+		// built-in functions are completed while the document does not parse
+		broken := strings.Replace(openDoc, "SELECT missing FROM users;", "return ab", 1)
+		labels = complete(broken, position(t, broken, "return ab", 9))
+		require.Contains(t, labels, "abs")
+		require.Contains(t, labels, "users")
+
+		diags := c.diagnostics(uri)
+		require.Len(t, diags, 1)
+		require.Equal(t, SeverityError, diags[0].Severity)
+	})
+
+	t.Run("close", func(t *testing.T) {
+		c.notify("textDocument/didClose", &DidCloseTextDocumentParams{TextDocument: TextDocumentIdentifier{URI: uri}})
+
+		var hover *Hover
+		c.call("textDocument/hover", &TextDocumentPositionParams{
+			TextDocument: TextDocumentIdentifier{URI: uri},
+			Position:     position(t, openDoc, "$total", 1),
+		}, &hover)
+		require.Nil(t, hover)
+		require.Empty(t, c.diagnostics(uri))
+	})
+
+	var res any
+	c.call("shutdown", nil, &res)
+}
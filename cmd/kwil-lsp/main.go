@@ -0,0 +1,97 @@
+// Command kwil-lsp is a Language Server Protocol server for Kuneiform. Editors
+// start it and communicate with it over stdin and stdout.
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kwilteam/kwil-db/cmd/kwil-lsp/lsp"
+	"github.com/kwilteam/kwil-db/core/log"
+	"github.com/kwilteam/kwil-db/version"
+)
+
+var longDesc = `kwil-lsp is a language server for Kuneiform.
+
+It is started by an editor, and communicates with it using the Language Server
+Protocol over stdin and stdout. It offers diagnostics for parse errors, type
+errors, and lint warnings, hover information for variables, columns, tables,
+actions, and functions, go-to-definition for tables, columns, actions, and
+variables, and completion of built-in functions, precompile methods, tables,
+columns, actions, and variables.
+
+Files are checked against the tables and actions created by the ` + "`.sql`" + ` and
+` + "`.kf`" + ` files in the workspace. If a provider is set, they are also checked
+against the schema of that node. The provider can also be set by the editor
+with the ` + "`provider`" + ` initialization option.`
+
+func main() {
+	ctx, cancel := context.WithCancel(context.Background())
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sig
+		cancel()
+	}()
+
+	if err := rootCmd().ExecuteContext(ctx); err != nil {
+		os.Exit(-1)
+	}
+
+	os.Exit(0)
+}
+
+func rootCmd() *cobra.Command {
+	var provider, chainID, logFile, logLevel string
+
+	cmd := &cobra.Command{
+		Use:               "kwil-lsp",
+		Short:             "Kuneiform language server",
+		Long:              longDesc,
+		Version:           version.KwilVersion,
+		Args:              cobra.NoArgs,
+		SilenceUsage:      true,
+		CompletionOptions: cobra.CompletionOptions{DisableDefaultCmd: true},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			level, err := log.ParseLevel(logLevel)
+			if err != nil {
+				return err
+			}
+
+			// stdout is used by the protocol, so logs are discarded unless
+			// written to a file
+			var w io.Writer = io.Discard
+			if logFile != "" {
+				f, err := log.NewFileWriter(logFile)
+				if err != nil {
+					return fmt.Errorf("failed to open log file: %w", err)
+				}
+				defer f.Close()
+				w = f
+			}
+
+			server := lsp.NewServer(&lsp.Config{
+				Provider: provider,
+				ChainID:  chainID,
+				Version:  version.KwilVersion,
+				Logger:   log.New(log.WithWriter(w), log.WithLevel(level), log.WithName("lsp")),
+			})
+			return server.Serve(cmd.Context(), os.Stdin, os.Stdout)
+		},
+	}
+
+	cmd.Flags().StringVarP(&provider, "provider", "P", "", "URL of a node to read the schema from")
+	cmd.Flags().StringVar(&chainID, "chain-id", "", "chain ID of the provider, which is verified if set")
+	cmd.Flags().StringVar(&logFile, "log-file", "", "file to write logs to")
+	cmd.Flags().StringVar(&logLevel, "log-level", "info", "log level (debug, info, warn, error)")
+	// editors commonly pass --stdio, which is the only supported transport
+	cmd.Flags().Bool("stdio", true, "communicate over stdin and stdout")
+
+	return cmd
+}
@@ -61,6 +61,14 @@ type GenesisAlloc struct {
 	Amount  *big.Int    `json:"amount"`
 }
 
+// WasmPrecompile is a WebAssembly precompile extension declared in genesis.
+type WasmPrecompile struct {
+	// Name is the name of the precompile, as used in `USE <name> AS <alias>`.
+	Name string `json:"name"`
+	// Module is the WebAssembly binary.
+	Module types.HexBytes `json:"module"`
+}
+
 // KeyHexBytes wraps hex bytes, and allows it to receive Ethereum 0x addresses
 type KeyHexBytes struct{ types.HexBytes }
 
@@ -129,6 +137,15 @@ type GenesisConfig struct {
 	// Migration specifies the migration configuration required for zero downtime migration.
 	Migration MigrationParams `json:"migration"`
 
+	// WasmPrecompiles are WebAssembly precompile extensions that are
+	// available from genesis.
+	WasmPrecompiles []WasmPrecompile `json:"wasm_precompiles,omitempty"`
+
+	// StateProofs enables the state tree, a Merkle tree of all user table rows
+	// whose root is included in the app hash, so that query results can be
+	// proven to clients. It can only be set at genesis.
+	StateProofs bool `json:"state_proofs,omitempty"`
+
 	// NetworkParameters are network level configurations that can be
 	// evolved over the lifetime of a network.
 	types.NetworkParameters
@@ -141,6 +158,10 @@ func (gc *GenesisConfig) SanityChecks() error {
 		return errors.New("invalid state hash, must be empty or 32 bytes")
 	}
 
+	if gc.StateProofs && len(gc.StateHash) != 0 {
+		return errors.New("state proofs cannot be enabled for a network that starts from a genesis state")
+	}
+
 	if len(gc.Validators) == 0 {
 		return errors.New("no validators provided")
 	}
@@ -275,6 +296,7 @@ func DefaultGenesisConfig() *GenesisConfig {
 			JoinExpiry:       types.Duration(7 * 24 * time.Hour), // 1 week
 			DisabledGasCosts: true,
 			MaxVotesPerTx:    200,
+			MaxEvidenceAge:   100_000, // blocks
 			MigrationStatus:  types.NoActiveMigration,
 		},
 	}
@@ -404,6 +426,7 @@ type Logging struct {
 type Telemetry struct {
 	Enable       bool   `toml:"enable" comment:"enable telemetry"`
 	OTLPEndpoint string `toml:"otlp_endpoint" comment:"open telemetry protocol collector endpoint"` // "127.0.0.1:4318"
+	TraceFile    string `toml:"trace_file" comment:"optional file to which traces are also written as JSON, relative to the root directory"`
 }
 
 type MempoolConfig struct {
@@ -423,6 +446,8 @@ type PeerConfig struct {
 	Whitelist         []string `toml:"whitelist" comment:"allowed node IDs when in private mode"`
 	TargetConnections int      `toml:"target_connections" comment:"target number of connections to maintain"`
 	ExternalAddress   string   `toml:"external_address" comment:"external address in host:port format to advertise to the network"`
+	SentryNodes       []string `toml:"sentry_nodes" comment:"sentry mode: the only peers to connect to, in the same format as bootnodes; disables PEX and hides this node from discovery"`
+	PrivatePeers      []string `toml:"private_peers" comment:"node IDs of peers to never advertise to other peers, such as a validator behind this sentry node"`
 }
 
 // StoreConfig contains options related to the block store. This is the embedded
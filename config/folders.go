@@ -14,6 +14,7 @@ const (
 	configFileName    = "config.toml"
 	migrationsDirName = "migrations"
 	blockstoreDirName = "blockstore"
+	stateTreeDirName  = "statetree"
 
 	// receivedSnapshotsDirName is the directory where snapshots are received
 	receivedSnapshotsDirName = "received_snapshots"
@@ -31,6 +32,11 @@ func BlockstoreDir(rootDir string) string {
 	return filepath.Join(rootDir, blockstoreDirName)
 }
 
+// StateTreeDir returns the state tree directory in the root directory.
+func StateTreeDir(rootDir string) string {
+	return filepath.Join(rootDir, stateTreeDirName)
+}
+
 // GenesisStateFileName returns the genesis state file in the root directory.
 func GenesisStateFileName(rootDir string) string {
 	return filepath.Join(rootDir, genesisStateFileName)
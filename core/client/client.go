@@ -46,6 +46,12 @@ type Client struct {
 
 	// nodeRequiresAuth is true if the remote node requires authenticated call RPCs.
 	nodeRequiresAuth bool
+
+	// localNonces enables local nonce assignment from the nonces counter,
+	// which is shared by copies of the Client, such as the one embedded in the
+	// gateway client, so that they do not assign the same nonce.
+	localNonces bool
+	nonces      *nonceCounter
 }
 
 // SvcClient is a trapdoor to access the underlying
@@ -115,6 +121,8 @@ func WrapClient(ctx context.Context, client RPCClient, options *clientType.Optio
 		noWarnings:        clientOptions.Silence,
 		skipVerifyChainID: clientOptions.SkipVerifyChainID,
 		skipHealthcheck:   clientOptions.SkipHealthcheck,
+		localNonces:       clientOptions.LocalNonces,
+		nonces:            &nonceCounter{},
 	}
 
 	var remoteChainID string
@@ -210,8 +218,10 @@ func (c *Client) Transfer(ctx context.Context, to *types.AccountID, amount *big.
 	if err != nil {
 		return types.Hash{}, err
 	}
-	nonceOpt := clientType.WithNonce(acct.Nonce + 1)
-	opts = append([]clientType.TxOpt{nonceOpt}, opts...) // prepend in case caller specified a nonce
+	if !c.localNonces {
+		nonceOpt := clientType.WithNonce(acct.Nonce + 1)
+		opts = append([]clientType.TxOpt{nonceOpt}, opts...) // prepend in case caller specified a nonce
+	}
 	txOpts := clientType.GetTxOpts(opts)
 
 	trans := &types.Transfer{
@@ -307,6 +317,23 @@ func (c *Client) ExecuteSQL(ctx context.Context, stmt string, params map[string]
 	return c.txClient.Broadcast(ctx, tx, syncBcastFlag(txOpts.SyncBcast))
 }
 
+// ExecuteBatch executes the payloads of a batch in one transaction. They are
+// executed in order, and if any of them fails, the changes of all of them are
+// reverted. The transaction result has a sub-result for each payload that was
+// executed. The fee is the sum of the fees of the payloads.
+func (c *Client) ExecuteBatch(ctx context.Context, batch *types.Batch, opts ...clientType.TxOpt) (types.Hash, error) {
+	txOpts := clientType.GetTxOpts(opts)
+	tx, err := c.newTx(ctx, batch, txOpts)
+	if err != nil {
+		return types.Hash{}, err
+	}
+
+	c.logger.Debug("execute batch", "payloads", len(batch.Payloads),
+		"fee", tx.Body.Fee.String(), "nonce", tx.Body.Nonce)
+
+	return c.txClient.Broadcast(ctx, tx, syncBcastFlag(txOpts.SyncBcast))
+}
+
 // Call calls an action. It returns the result records.
 func (c *Client) Call(ctx context.Context, namespace string, action string, inputs []any) (*types.CallResult, error) {
 	encoded, err := EncodeInputs(inputs)
@@ -395,6 +422,18 @@ func (c *Client) GetAccount(ctx context.Context, acctID *types.AccountID, status
 	return c.txClient.GetAccount(ctx, acctID, status)
 }
 
+// BlockFees gets the accounting of the transaction fees collected in the block
+// at the given height, and how they were distributed.
+func (c *Client) BlockFees(ctx context.Context, height int64) (*types.FeeDistribution, error) {
+	return c.txClient.BlockFees(ctx, height)
+}
+
+// Schema gets the Kuneiform DDL that defines a namespace, reconstructed by the
+// node from its tables, indexes, actions, roles, privileges, and extensions.
+func (c *Client) Schema(ctx context.Context, namespace string) (string, error) {
+	return c.txClient.Schema(ctx, namespace)
+}
+
 func (c *Client) GetNumAccounts(ctx context.Context) (count, height int64, err error) {
 	return c.txClient.GetNumAccounts(ctx)
 }
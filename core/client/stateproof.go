@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// StateProof gets a row of a table by its primary key, with a proof of its
+// inclusion or absence in the state after the block at the given height, or
+// the latest height if zero. The primary key values must be in the order of
+// the table's primary key columns. The proof is not verified; use
+// VerifyStateProof with the app hash of a block verified by a light client.
+func (c *Client) StateProof(ctx context.Context, namespace, table string, primaryKey []any, height int64) (*types.StateProof, error) {
+	pk, err := encodeValues(primaryKey)
+	if err != nil {
+		return nil, err
+	}
+	return c.txClient.StateProof(ctx, namespace, table, pk, height)
+}
+
+// VerifyStateProof verifies a state proof for the row with the given primary
+// key against the trusted app hash of the block at the proof's height. It
+// returns the row's columns and values, or nil if the proof shows that there
+// is no row with the primary key.
+func VerifyStateProof(proof *types.StateProof, appHash types.Hash, namespace, table string, primaryKey ...any) (map[string]any, error) {
+	if !strings.EqualFold(proof.Namespace, namespace) || !strings.EqualFold(proof.Table, table) {
+		return nil, fmt.Errorf("proof is for %s.%s, not %s.%s", proof.Namespace, proof.Table, namespace, table)
+	}
+
+	pk, err := encodeValues(primaryKey)
+	if err != nil {
+		return nil, err
+	}
+	if key := types.StateKey(namespace, table, pk); proof.Key != key {
+		return nil, errors.New("proof is not for the primary key")
+	}
+
+	if err = proof.Verify(appHash); err != nil {
+		return nil, err
+	}
+
+	if proof.Row == nil {
+		return nil, nil
+	}
+	return proof.Row.Decode()
+}
+
+func encodeValues(values []any) ([]*types.EncodedValue, error) {
+	encoded := make([]*types.EncodedValue, len(values))
+	for i, v := range values {
+		enc, err := types.EncodeValue(v)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode value %d: %w", i, err)
+		}
+		encoded[i] = enc
+	}
+	return encoded, nil
+}
@@ -3,6 +3,8 @@ package client
 import (
 	"context"
 	"fmt"
+	"math/big"
+	"sync"
 
 	clientType "github.com/kwilteam/kwil-db/core/client/types"
 	"github.com/kwilteam/kwil-db/core/types"
@@ -20,25 +22,18 @@ func (c *Client) newTx(ctx context.Context, data types.Payload, txOpts *clientTy
 	var nonce uint64
 	if txOpts.Nonce > 0 {
 		nonce = uint64(txOpts.Nonce)
-	} else {
-		ident, err := types.GetSignerAccount(c.Signer())
+	} else if c.localNonces {
+		n, err := c.takeNonce(ctx)
 		if err != nil {
-			return nil, fmt.Errorf("failed to get signer account: %w", err)
+			return nil, err
 		}
-
-		// Get the latest nonce for the account, if it exists.
-		acc, err := c.txClient.GetAccount(ctx, ident, types.AccountStatusPending)
+		nonce = uint64(n)
+	} else {
+		n, err := c.pendingNonce(ctx)
 		if err != nil {
 			return nil, err
 		}
-
-		// NOTE: an error type would be more robust signalling of a non-existent
-		// account, but presently a nil ID is set by internal/accounts.
-		if acc.ID != nil && len(acc.ID.Identifier) > 0 {
-			nonce = uint64(acc.Nonce + 1)
-		} else {
-			nonce = 1
-		}
+		nonce = uint64(n)
 	}
 
 	// build transaction
@@ -67,3 +62,111 @@ func (c *Client) newTx(ctx context.Context, data types.Payload, txOpts *clientTy
 
 	return tx, nil
 }
+
+// pendingNonce gets the next nonce for the signer's account from the node,
+// including transactions in its mempool.
+func (c *Client) pendingNonce(ctx context.Context) (int64, error) {
+	ident, err := types.GetSignerAccount(c.Signer())
+	if err != nil {
+		return 0, fmt.Errorf("failed to get signer account: %w", err)
+	}
+
+	// Get the latest nonce for the account, if it exists.
+	acc, err := c.txClient.GetAccount(ctx, ident, types.AccountStatusPending)
+	if err != nil {
+		return 0, err
+	}
+
+	// NOTE: an error type would be more robust signalling of a non-existent
+	// account, but presently a nil ID is set by internal/accounts.
+	if acc.ID != nil && len(acc.ID.Identifier) > 0 {
+		return acc.Nonce + 1, nil
+	}
+	return 1, nil
+}
+
+// nonceCounter is the next locally assigned nonce.
+type nonceCounter struct {
+	mtx sync.Mutex
+	// next is the next nonce to use, or zero if it must be retrieved from the
+	// node.
+	next int64
+}
+
+// takeNonce returns the next locally assigned nonce, initializing the counter
+// from the node if needed. It is safe for concurrent use.
+func (c *Client) takeNonce(ctx context.Context) (int64, error) {
+	c.nonces.mtx.Lock()
+	defer c.nonces.mtx.Unlock()
+
+	if c.nonces.next == 0 {
+		n, err := c.pendingNonce(ctx)
+		if err != nil {
+			return 0, err
+		}
+		c.nonces.next = n
+	}
+
+	nonce := c.nonces.next
+	c.nonces.next++
+	return nonce, nil
+}
+
+// ResetNonce discards the locally tracked nonce so that the next transaction
+// gets its nonce from the node. It only has an effect when the client was
+// created with the LocalNonces option. It should be used if a transaction with
+// a locally assigned nonce is not accepted by the node, since later nonces
+// would otherwise leave a gap.
+func (c *Client) ResetNonce() {
+	c.nonces.mtx.Lock()
+	defer c.nonces.mtx.Unlock()
+	c.nonces.next = 0
+}
+
+// SignTx creates a transaction with the payload, signed by the Client's Signer,
+// without broadcasting it. This is used to create a transaction that is
+// broadcast by another party, such as a sponsor that pays its fee with
+// SponsorTx. The nonce and fee are requested from the node unless they are
+// given in the options.
+func (c *Client) SignTx(ctx context.Context, payload types.Payload, opts ...clientType.TxOpt) (*types.Transaction, error) {
+	return c.newTx(ctx, payload, clientType.GetTxOpts(opts))
+}
+
+// SponsorTx signs a transaction that was signed by another account as its
+// sponsor, and broadcasts it. The Client's Signer pays the transaction fee, up
+// to maxFee, while the transaction is executed as its original sender. If
+// maxFee is nil, it is the fee set in the transaction. The nonce and fee
+// options do not apply, since the transaction is already signed by its sender.
+func (c *Client) SponsorTx(ctx context.Context, tx *types.Transaction, maxFee *big.Int, opts ...clientType.TxOpt) (types.Hash, error) {
+	if c.Signer() == nil {
+		return types.Hash{}, fmt.Errorf("signer must be set to sponsor a transaction")
+	}
+	if tx.Body == nil || tx.Signature == nil {
+		return types.Hash{}, fmt.Errorf("transaction must be signed by its sender")
+	}
+	if maxFee == nil {
+		maxFee = tx.Body.Fee
+	}
+	if tx.Body.Fee.Cmp(maxFee) > 0 {
+		return types.Hash{}, fmt.Errorf("transaction fee %s exceeds the max fee %s", tx.Body.Fee, maxFee)
+	}
+
+	if err := tx.SignSponsorship(c.Signer(), maxFee); err != nil {
+		return types.Hash{}, fmt.Errorf("failed to sign sponsorship: %w", err)
+	}
+
+	txOpts := clientType.GetTxOpts(opts)
+	return c.txClient.Broadcast(ctx, tx, syncBcastFlag(txOpts.SyncBcast))
+}
+
+// SetSponsorLimit limits the total fees that the Client's Signer will pay for
+// the transactions that it sponsors. A nil limit removes the limit.
+func (c *Client) SetSponsorLimit(ctx context.Context, limit *big.Int, opts ...clientType.TxOpt) (types.Hash, error) {
+	txOpts := clientType.GetTxOpts(opts)
+	tx, err := c.newTx(ctx, &types.SponsorLimit{Limit: limit}, txOpts)
+	if err != nil {
+		return types.Hash{}, err
+	}
+
+	return c.txClient.Broadcast(ctx, tx, syncBcastFlag(txOpts.SyncBcast))
+}
@@ -36,6 +36,15 @@ type Options struct {
 
 	// Conn is the http client to use.
 	Conn *http.Client
+
+	// LocalNonces makes the client assign nonces from a local counter rather
+	// than querying the node's pending nonce for every transaction. This lets
+	// a single sender build and broadcast many transactions concurrently. The
+	// counter is initialized from the node on first use, and may be reset
+	// with the client's ResetNonce method, e.g. after a rejected transaction.
+	// When the network has a nonce window, transactions using these nonces may
+	// be accepted out of order.
+	LocalNonces bool
 }
 
 // Apply applies the passed options to the receiver.
@@ -65,6 +74,8 @@ func (c *Options) Apply(opts *Options) {
 	c.SkipHealthcheck = opts.SkipHealthcheck
 
 	c.Silence = opts.Silence
+
+	c.LocalNonces = opts.LocalNonces
 }
 
 // DefaultOptions returns the default options for the client.
@@ -18,6 +18,10 @@ these two Authenticator implementations out of the SDK and into the main module
 where they are only available to the application that needs them, but it may be
 awkward to have complementary verification defined in the same place as the
 signing.
+
+The MultisigAuthenticator verifies M-of-N multi-signatures, which are made up of
+the signatures of the members of a Multisig, created with their own Signers.
+The multisig account ID is derived from the members and threshold.
 */
 package auth
 
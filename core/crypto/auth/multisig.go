@@ -0,0 +1,393 @@
+package auth
+
+// multisig is an M-of-N signature scheme in which the members sign with their
+// own signers, and the multisig account is identified by a hash of the members
+// and the threshold.
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/utils"
+)
+
+const (
+	// MultisigAuth is the multi-signature authenticator type. The signature
+	// data is a serialized MultiSignature, and the sender is the multisig
+	// account ID, which is derived from the members and threshold.
+	MultisigAuth = "multisig"
+
+	// MaxMultisigMembers is the maximum number of members of a multisig.
+	MaxMultisigMembers = 32
+
+	// MultisigIDLength is the length of a multisig account ID.
+	MultisigIDLength = sha256.Size
+
+	multisigVersion = 0
+)
+
+// MultisigMember is a member of a multisig, identified by the AuthType and
+// CompactID of its Signer.
+type MultisigMember struct {
+	Type string `json:"type"`
+	ID   []byte `json:"id"`
+}
+
+// Multisig describes an M-of-N multi-signature account.
+type Multisig struct {
+	// Threshold is the number of member signatures required.
+	Threshold uint16 `json:"threshold"`
+	// Members are sorted by type and then ID, so the account ID does not
+	// depend on the order in which they were given.
+	Members []MultisigMember `json:"members"`
+}
+
+// NewMultisig creates a Multisig from the threshold and members, which are
+// sorted into canonical order.
+func NewMultisig(threshold uint16, members []MultisigMember) (*Multisig, error) {
+	ms := &Multisig{
+		Threshold: threshold,
+		Members:   slices.Clone(members),
+	}
+	slices.SortFunc(ms.Members, compareMembers)
+	if err := ms.Validate(); err != nil {
+		return nil, err
+	}
+	return ms, nil
+}
+
+func compareMembers(a, b MultisigMember) int {
+	if c := cmp.Compare(a.Type, b.Type); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.ID, b.ID)
+}
+
+// Validate checks that the threshold is attainable, and that the members are
+// unique, in canonical order, and not themselves multisigs.
+func (ms *Multisig) Validate() error {
+	n := len(ms.Members)
+	if n == 0 {
+		return errors.New("multisig has no members")
+	}
+	if n > MaxMultisigMembers {
+		return fmt.Errorf("multisig has %d members, the maximum is %d", n, MaxMultisigMembers)
+	}
+	if ms.Threshold == 0 || int(ms.Threshold) > n {
+		return fmt.Errorf("invalid threshold %d for %d members", ms.Threshold, n)
+	}
+	for i, m := range ms.Members {
+		if m.Type == "" || len(m.ID) == 0 {
+			return fmt.Errorf("member %d has no type or ID", i)
+		}
+		if m.Type == MultisigAuth {
+			return errors.New("multisig members may not be multisigs")
+		}
+		if i > 0 && compareMembers(ms.Members[i-1], m) >= 0 {
+			return errors.New("multisig members are not unique and sorted")
+		}
+	}
+	return nil
+}
+
+// MemberIndex returns the index of the member with the given type and ID, or
+// -1 if there is no such member.
+func (ms *Multisig) MemberIndex(authType string, id []byte) int {
+	return slices.IndexFunc(ms.Members, func(m MultisigMember) bool {
+		return m.Type == authType && bytes.Equal(m.ID, id)
+	})
+}
+
+// ID returns the multisig account ID, which is the SHA-256 hash of the
+// serialized multisig.
+func (ms *Multisig) ID() []byte {
+	h := sha256.Sum256(ms.Bytes())
+	return h[:]
+}
+
+func (ms *Multisig) Bytes() []byte {
+	buf := new(bytes.Buffer)
+	ms.WriteTo(buf) // does not error with a bytes.Buffer as the Writer
+	return buf.Bytes()
+}
+
+func (ms *Multisig) WriteTo(w io.Writer) (int64, error) {
+	cw := utils.NewCountingWriter(w)
+	if err := binary.Write(cw, binary.LittleEndian, uint16(multisigVersion)); err != nil {
+		return cw.Written(), err
+	}
+	if err := binary.Write(cw, binary.LittleEndian, ms.Threshold); err != nil {
+		return cw.Written(), err
+	}
+	if err := binary.Write(cw, binary.LittleEndian, uint16(len(ms.Members))); err != nil {
+		return cw.Written(), err
+	}
+	for _, m := range ms.Members {
+		if err := writeBytes(cw, []byte(m.Type)); err != nil {
+			return cw.Written(), err
+		}
+		if err := writeBytes(cw, m.ID); err != nil {
+			return cw.Written(), err
+		}
+	}
+	return cw.Written(), nil
+}
+
+func (ms *Multisig) ReadFrom(r io.Reader) (int64, error) {
+	cr := utils.NewCountingReader(r)
+	var ver, n uint16
+	if err := binary.Read(cr, binary.LittleEndian, &ver); err != nil {
+		return cr.ReadCount(), err
+	}
+	if ver != multisigVersion {
+		return cr.ReadCount(), fmt.Errorf("unsupported multisig version %d", ver)
+	}
+	if err := binary.Read(cr, binary.LittleEndian, &ms.Threshold); err != nil {
+		return cr.ReadCount(), err
+	}
+	if err := binary.Read(cr, binary.LittleEndian, &n); err != nil {
+		return cr.ReadCount(), err
+	}
+	if n > MaxMultisigMembers {
+		return cr.ReadCount(), fmt.Errorf("too many multisig members: %d", n)
+	}
+	ms.Members = make([]MultisigMember, n)
+	for i := range ms.Members {
+		typ, err := readBytes(cr)
+		if err != nil {
+			return cr.ReadCount(), err
+		}
+		id, err := readBytes(cr)
+		if err != nil {
+			return cr.ReadCount(), err
+		}
+		ms.Members[i] = MultisigMember{Type: string(typ), ID: id}
+	}
+	return cr.ReadCount(), nil
+}
+
+// MemberSignature is the signature of one member of a multisig.
+type MemberSignature struct {
+	// Index is the index of the member in Multisig.Members.
+	Index uint16 `json:"index"`
+	// Data is the signature data, of the member's signature type.
+	Data []byte `json:"sig"`
+}
+
+// MultiSignature is the data of a multisig Signature. It includes the
+// multisig itself, so that the authenticator can check it against the
+// account ID, and the member signatures sorted by member index.
+type MultiSignature struct {
+	Multisig   Multisig          `json:"multisig"`
+	Signatures []MemberSignature `json:"signatures"`
+}
+
+// AddSignature adds or replaces a member's signature. The signature type must
+// be the member's type.
+func (msig *MultiSignature) AddSignature(memberID []byte, sig *Signature) error {
+	idx := msig.Multisig.MemberIndex(sig.Type, memberID)
+	if idx == -1 {
+		return fmt.Errorf("signer %x (%s) is not a member of the multisig", memberID, sig.Type)
+	}
+	msig.Signatures = slices.DeleteFunc(msig.Signatures, func(s MemberSignature) bool {
+		return int(s.Index) == idx
+	})
+	msig.Signatures = append(msig.Signatures, MemberSignature{
+		Index: uint16(idx),
+		Data:  slices.Clone(sig.Data),
+	})
+	slices.SortFunc(msig.Signatures, func(a, b MemberSignature) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+	return nil
+}
+
+// Merge adds the signatures from another MultiSignature of the same multisig.
+func (msig *MultiSignature) Merge(other *MultiSignature) error {
+	if !bytes.Equal(msig.Multisig.ID(), other.Multisig.ID()) {
+		return errors.New("signatures are for different multisigs")
+	}
+	for _, s := range other.Signatures {
+		if int(s.Index) >= len(msig.Multisig.Members) {
+			return fmt.Errorf("invalid member index %d", s.Index)
+		}
+		m := msig.Multisig.Members[s.Index]
+		if err := msig.AddSignature(m.ID, &Signature{Type: m.Type, Data: s.Data}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Signature returns the MultiSignature as a Signature of type MultisigAuth.
+func (msig *MultiSignature) Signature() *Signature {
+	return &Signature{
+		Data: msig.Bytes(),
+		Type: MultisigAuth,
+	}
+}
+
+func (msig *MultiSignature) Bytes() []byte {
+	buf := new(bytes.Buffer)
+	msig.WriteTo(buf) // does not error with a bytes.Buffer as the Writer
+	return buf.Bytes()
+}
+
+func (msig *MultiSignature) WriteTo(w io.Writer) (int64, error) {
+	cw := utils.NewCountingWriter(w)
+	if _, err := msig.Multisig.WriteTo(cw); err != nil {
+		return cw.Written(), err
+	}
+	if err := binary.Write(cw, binary.LittleEndian, uint16(len(msig.Signatures))); err != nil {
+		return cw.Written(), err
+	}
+	for _, s := range msig.Signatures {
+		if err := binary.Write(cw, binary.LittleEndian, s.Index); err != nil {
+			return cw.Written(), err
+		}
+		if err := writeBytes(cw, s.Data); err != nil {
+			return cw.Written(), err
+		}
+	}
+	return cw.Written(), nil
+}
+
+func (msig *MultiSignature) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+	if _, err := msig.Multisig.ReadFrom(r); err != nil {
+		return fmt.Errorf("failed to read multisig: %w", err)
+	}
+	var n uint16
+	if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+		return err
+	}
+	if int(n) > len(msig.Multisig.Members) {
+		return fmt.Errorf("too many signatures: %d", n)
+	}
+	msig.Signatures = make([]MemberSignature, n)
+	for i := range msig.Signatures {
+		if err := binary.Read(r, binary.LittleEndian, &msig.Signatures[i].Index); err != nil {
+			return err
+		}
+		data, err := readBytes(r)
+		if err != nil {
+			return err
+		}
+		msig.Signatures[i].Data = data
+	}
+	if r.Len() != 0 {
+		return errors.New("extra multisignature data")
+	}
+	return nil
+}
+
+func writeBytes(w io.Writer, b []byte) error {
+	if _, err := w.Write(binary.AppendUvarint(nil, uint64(len(b)))); err != nil {
+		return err
+	}
+	_, err := w.Write(b)
+	return err
+}
+
+func readBytes(r io.Reader) ([]byte, error) {
+	cr := utils.NewCountingReader(r)
+	n, err := binary.ReadUvarint(cr)
+	if err != nil {
+		return nil, err
+	}
+	if rl, ok := r.(interface{ Len() int }); ok && int(n) > rl.Len() {
+		return nil, fmt.Errorf("impossibly long length: %d", n)
+	}
+	b := make([]byte, n)
+	if _, err := io.ReadFull(cr, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// MultisigAuthenticator verifies multi-signatures by verifying each member
+// signature with the Authenticator for the member's type.
+type MultisigAuthenticator struct {
+	// Authenticators returns the Authenticator for a member signature type.
+	// If nil, only the SDK's Ed25519, Secp256k1 and EthPersonalSign types are
+	// supported.
+	Authenticators func(authType string) (Authenticator, error)
+}
+
+var _ Authenticator = MultisigAuthenticator{}
+
+func (a MultisigAuthenticator) memberAuthenticator(authType string) (Authenticator, error) {
+	if a.Authenticators != nil {
+		return a.Authenticators(authType)
+	}
+	switch authType {
+	case Ed25519Auth:
+		return Ed25519Authenticator{}, nil
+	case Secp256k1Auth:
+		return Secp25k1Authenticator{}, nil
+	case EthPersonalSignAuth:
+		return EthSecp256k1Authenticator{}, nil
+	}
+	return nil, fmt.Errorf("unsupported member signature type %s", authType)
+}
+
+// Identifier returns the hexadecimal encoded multisig account ID.
+func (MultisigAuthenticator) Identifier(compactID []byte) (string, error) {
+	if len(compactID) != MultisigIDLength {
+		return "", fmt.Errorf("invalid multisig ID length: %d", len(compactID))
+	}
+	return hex.EncodeToString(compactID), nil
+}
+
+// Verify checks that the multisig in the signature has the given ID, and that
+// at least the threshold number of members signed the message. Every included
+// member signature must be valid.
+func (a MultisigAuthenticator) Verify(compactID, msg, signature []byte) error {
+	var msig MultiSignature
+	if err := msig.UnmarshalBinary(signature); err != nil {
+		return fmt.Errorf("invalid multisignature: %w", err)
+	}
+	ms := &msig.Multisig
+	if err := ms.Validate(); err != nil {
+		return err
+	}
+	if !bytes.Equal(ms.ID(), compactID) {
+		return errors.New("multisig does not match the account ID")
+	}
+
+	if len(msig.Signatures) < int(ms.Threshold) {
+		return fmt.Errorf("%w: %d of %d required signatures", crypto.ErrInvalidSignature,
+			len(msig.Signatures), ms.Threshold)
+	}
+
+	for i, s := range msig.Signatures {
+		if int(s.Index) >= len(ms.Members) {
+			return fmt.Errorf("invalid member index %d", s.Index)
+		}
+		if i > 0 && s.Index <= msig.Signatures[i-1].Index {
+			return errors.New("member signatures are not unique and sorted")
+		}
+		m := ms.Members[s.Index]
+		authn, err := a.memberAuthenticator(m.Type)
+		if err != nil {
+			return err
+		}
+		if err = authn.Verify(m.ID, msg, s.Data); err != nil {
+			return fmt.Errorf("member %d signature: %w", s.Index, err)
+		}
+	}
+
+	return nil
+}
+
+func (MultisigAuthenticator) KeyType() crypto.KeyType {
+	return crypto.KeyTypeMultisig
+}
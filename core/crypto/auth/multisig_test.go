@@ -0,0 +1,100 @@
+package auth_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+)
+
+func Test_Multisig(t *testing.T) {
+	signers := []auth.Signer{
+		secp256k1Signer(t, [32]byte{1}),
+		ed25519Signer(t, [32]byte{2}),
+		secp256k1PlainSigner(t, [32]byte{3}),
+	}
+	members := make([]auth.MultisigMember, len(signers))
+	for i, s := range signers {
+		members[i] = auth.MultisigMember{Type: s.AuthType(), ID: s.CompactID()}
+	}
+
+	ms, err := auth.NewMultisig(2, members)
+	require.NoError(t, err)
+
+	// member order does not change the account ID
+	reversed := []auth.MultisigMember{members[2], members[1], members[0]}
+	ms2, err := auth.NewMultisig(2, reversed)
+	require.NoError(t, err)
+	assert.Equal(t, ms.ID(), ms2.ID())
+
+	// nor does a different threshold give the same account
+	ms3, err := auth.NewMultisig(3, members)
+	require.NoError(t, err)
+	assert.NotEqual(t, ms.ID(), ms3.ID())
+
+	msg := []byte("foo")
+	sign := func(msig *auth.MultiSignature, s auth.Signer) {
+		sig, err := s.Sign(msg)
+		require.NoError(t, err)
+		require.NoError(t, msig.AddSignature(s.CompactID(), sig))
+	}
+
+	authn := auth.MultisigAuthenticator{}
+
+	msig := &auth.MultiSignature{Multisig: *ms}
+	sign(msig, signers[0])
+	err = authn.Verify(ms.ID(), msg, msig.Bytes())
+	require.Error(t, err, "below threshold")
+
+	// combine with a signature collected separately
+	other := &auth.MultiSignature{Multisig: *ms}
+	sign(other, signers[2])
+	require.NoError(t, msig.Merge(other))
+	require.Len(t, msig.Signatures, 2)
+
+	sigData := msig.Signature().Data
+	require.NoError(t, authn.Verify(ms.ID(), msg, sigData))
+
+	var decoded auth.MultiSignature
+	require.NoError(t, decoded.UnmarshalBinary(sigData))
+	assert.Equal(t, *msig, decoded)
+
+	ident, err := authn.Identifier(ms.ID())
+	require.NoError(t, err)
+	assert.Len(t, ident, 2*auth.MultisigIDLength)
+
+	t.Run("wrong account", func(t *testing.T) {
+		require.Error(t, authn.Verify(ms3.ID(), msg, sigData))
+	})
+
+	t.Run("wrong message", func(t *testing.T) {
+		require.Error(t, authn.Verify(ms.ID(), []byte("bar"), sigData))
+	})
+
+	t.Run("non-member", func(t *testing.T) {
+		s := ed25519Signer(t, [32]byte{4})
+		sig, err := s.Sign(msg)
+		require.NoError(t, err)
+		require.Error(t, msig.AddSignature(s.CompactID(), sig))
+	})
+
+	t.Run("duplicate signature", func(t *testing.T) {
+		dup := &auth.MultiSignature{Multisig: *ms}
+		sign(dup, signers[0])
+		dup.Signatures = append(dup.Signatures, dup.Signatures[0])
+		require.Error(t, authn.Verify(ms.ID(), msg, dup.Bytes()))
+	})
+
+	t.Run("invalid multisigs", func(t *testing.T) {
+		_, err := auth.NewMultisig(0, members)
+		require.Error(t, err)
+		_, err = auth.NewMultisig(4, members)
+		require.Error(t, err)
+		_, err = auth.NewMultisig(1, []auth.MultisigMember{members[0], members[0]})
+		require.Error(t, err)
+		_, err = auth.NewMultisig(1, []auth.MultisigMember{{Type: auth.MultisigAuth, ID: ms.ID()}})
+		require.Error(t, err)
+	})
+}
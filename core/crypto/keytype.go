@@ -21,6 +21,7 @@ const (
 const (
 	keyIDSecp256k1 = iota
 	keyIDEd25519
+	keyIDMultisig
 )
 
 func (kt KeyType) String() string {
@@ -0,0 +1,42 @@
+package crypto
+
+import "errors"
+
+// KeyTypeMultisig is the key type of multi-signature accounts. A multisig
+// account has no key pair of its own. Its identifier is derived from the
+// member keys and the signature threshold, and its transactions are authorized
+// by the signatures of its members. See the MultisigAuthenticator in the auth
+// package.
+const KeyTypeMultisig KeyType = "multisig"
+
+// ErrMultisigNoKey is returned when attempting to use a multisig account as a
+// key pair.
+var ErrMultisigNoKey = errors.New("multisig accounts have no keys")
+
+// MultisigDefinition is the KeyDefinition for multisig accounts. It exists so
+// that multisig accounts have a registered key type, but it cannot unmarshal
+// or generate keys.
+type MultisigDefinition struct{}
+
+var _ KeyDefinition = MultisigDefinition{}
+
+func (MultisigDefinition) Type() KeyType {
+	return KeyTypeMultisig
+}
+
+func (MultisigDefinition) EncodeFlag() uint32 {
+	return keyIDMultisig
+}
+
+func (MultisigDefinition) UnmarshalPrivateKey([]byte) (PrivateKey, error) {
+	return nil, ErrMultisigNoKey
+}
+
+func (MultisigDefinition) UnmarshalPublicKey([]byte) (PublicKey, error) {
+	return nil, ErrMultisigNoKey
+}
+
+// Generate returns nil, since there is no multisig key pair.
+func (MultisigDefinition) Generate() PrivateKey {
+	return nil
+}
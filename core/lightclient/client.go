@@ -0,0 +1,211 @@
+// Package lightclient implements a client that verifies the blocks of a Kwil
+// chain without trusting the RPC provider that serves them. Starting from a
+// trusted genesis or checkpoint state, it fetches each block and its commit
+// info with the chain.block RPC, and checks the validator signatures and the
+// validator set transitions. Verified block headers may then be used to prove
+// the inclusion of transactions with the headers' merkle roots.
+//
+// Blocks are verified sequentially, so a client that falls far behind the
+// chain should be started from a recent checkpoint, which is the TrustedState
+// returned by a previous session.
+package lightclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	rpcclient "github.com/kwilteam/kwil-db/core/rpc/client"
+	"github.com/kwilteam/kwil-db/core/types"
+	chaintypes "github.com/kwilteam/kwil-db/core/types/chain"
+)
+
+// Chain is the untrusted source of blocks. It is satisfied by the chain RPC
+// client in core/rpc/client/chain/jsonrpc.
+type Chain interface {
+	BlockByHeight(ctx context.Context, height int64) (*chaintypes.Block, *chaintypes.CommitInfo, error)
+	Tx(ctx context.Context, hash types.Hash) (*chaintypes.Tx, error)
+}
+
+// VerifiedHeader is a block header verified by the light client.
+type VerifiedHeader struct {
+	Header *types.BlockHeader
+	Hash   types.Hash
+	// AppHash is the app hash resulting from the execution of the block.
+	AppHash types.Hash
+}
+
+// Client verifies the blocks of a chain.
+type Client struct {
+	chain      Chain
+	trustLevel TrustLevel
+
+	mtx     sync.RWMutex
+	state   *TrustedState
+	headers map[int64]*VerifiedHeader // verified in this session
+}
+
+// Option is a functional option for the light client.
+type Option func(*Client)
+
+// WithTrustLevel sets the fraction of the validator power that must agree with
+// a block. The default is DefaultTrustLevel.
+func WithTrustLevel(tl TrustLevel) Option {
+	return func(c *Client) {
+		c.trustLevel = tl
+	}
+}
+
+// New creates a light client that verifies the blocks following the trusted
+// state.
+func New(chain Chain, trusted *TrustedState, opts ...Option) (*Client, error) {
+	if trusted == nil {
+		return nil, errors.New("no trusted state")
+	}
+	if err := trusted.validate(); err != nil {
+		return nil, fmt.Errorf("invalid trusted state: %w", err)
+	}
+
+	c := &Client{
+		chain:      chain,
+		trustLevel: DefaultTrustLevel,
+		state:      trusted.clone(),
+		headers:    make(map[int64]*VerifiedHeader),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	if err := c.trustLevel.validate(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// TrustedState returns the current trusted state, which may be persisted and
+// used as a checkpoint to start a later session.
+func (c *Client) TrustedState() *TrustedState {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	return c.state.clone()
+}
+
+// Header returns the verified header at the given height, if it was verified
+// in this session.
+func (c *Client) Header(height int64) (*VerifiedHeader, bool) {
+	c.mtx.RLock()
+	defer c.mtx.RUnlock()
+	vh, ok := c.headers[height]
+	return vh, ok
+}
+
+// VerifyTo verifies all blocks up to the given height, and returns the
+// verified header at that height.
+func (c *Client) VerifyTo(ctx context.Context, height int64) (*VerifiedHeader, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	if vh, ok := c.headers[height]; ok {
+		return vh, nil
+	}
+	if height <= c.state.Height {
+		return nil, fmt.Errorf("height %d is not after the trusted height %d", height, c.state.Height)
+	}
+
+	var vh *VerifiedHeader
+	for c.state.Height < height {
+		var err error
+		if vh, err = c.verifyNext(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return vh, nil
+}
+
+// Sync verifies blocks until the latest block served by the chain, and returns
+// the latest verified header. If there are no new blocks, it returns nil.
+func (c *Client) Sync(ctx context.Context) (*VerifiedHeader, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var latest *VerifiedHeader
+	for {
+		vh, err := c.verifyNext(ctx)
+		if errors.Is(err, rpcclient.ErrNotFound) {
+			return latest, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		latest = vh
+	}
+}
+
+// verifyNext verifies the block following the trusted state, and advances
+// the trusted state. c.mtx must be locked.
+func (c *Client) verifyNext(ctx context.Context) (*VerifiedHeader, error) {
+	height := c.state.Height + 1
+	blk, ci, err := c.chain.BlockByHeight(ctx, height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block %d: %w", height, err)
+	}
+
+	next, err := VerifyBlock(c.state, blk, ci, c.trustLevel)
+	if err != nil {
+		return nil, fmt.Errorf("block %d failed verification: %w", height, err)
+	}
+
+	vh := &VerifiedHeader{
+		Header:  blk.Header,
+		Hash:    next.BlockHash,
+		AppHash: next.AppHash,
+	}
+	c.headers[height] = vh
+	c.state = next
+
+	return vh, nil
+}
+
+// VerifyTx gets a transaction from the chain and proves its inclusion in a
+// verified block, verifying blocks up to the transaction's height if needed.
+func (c *Client) VerifyTx(ctx context.Context, txHash types.Hash) (*chaintypes.Tx, *TxProof, error) {
+	tx, err := c.chain.Tx(ctx, txHash)
+	if err != nil {
+		return nil, nil, err
+	}
+	if tx.Height <= 0 {
+		return nil, nil, errors.New("transaction is not in a block")
+	}
+	if tx.Tx != nil && tx.Tx.Hash() != txHash {
+		return nil, nil, errors.New("transaction does not match its hash")
+	}
+
+	vh, ok := c.Header(tx.Height)
+	if !ok {
+		if vh, err = c.VerifyTo(ctx, tx.Height); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	blk, _, err := c.chain.BlockByHeight(ctx, tx.Height)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to get block %d: %w", tx.Height, err)
+	}
+	if blk.Header == nil || blk.Hash() != vh.Hash {
+		return nil, nil, fmt.Errorf("block %d does not match the verified header", tx.Height)
+	}
+
+	proof, err := NewTxProof(blk, tx.Index)
+	if err != nil {
+		return nil, nil, err
+	}
+	if proof.TxHash != txHash {
+		return nil, nil, fmt.Errorf("transaction %d of block %d is not %s", tx.Index, tx.Height, txHash)
+	}
+	if err = proof.Verify(vh.Header); err != nil {
+		return nil, nil, err
+	}
+
+	return tx, proof, nil
+}
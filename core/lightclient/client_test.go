@@ -0,0 +1,333 @@
+package lightclient
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	rpcclient "github.com/kwilteam/kwil-db/core/rpc/client"
+	"github.com/kwilteam/kwil-db/core/types"
+	chaintypes "github.com/kwilteam/kwil-db/core/types/chain"
+)
+
+// testChain builds a chain of blocks signed by a set of validators.
+type testChain struct {
+	t       *testing.T
+	keys    []crypto.PrivateKey
+	vals    []*types.Validator
+	leader  crypto.PrivateKey
+	blocks  []*types.Block
+	commits []*types.CommitInfo
+	txs     map[types.Hash]*chaintypes.Tx
+}
+
+func newTestChain(t *testing.T, numVals int) *testChain {
+	tc := &testChain{t: t, txs: make(map[types.Hash]*chaintypes.Tx)}
+	for range numVals {
+		tc.vals = append(tc.vals, tc.addKey())
+	}
+	tc.leader = tc.keys[0]
+	return tc
+}
+
+// addKey creates a key for a validator, which is not yet in the validator set.
+func (tc *testChain) addKey() *types.Validator {
+	priv, pub, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(tc.t, err)
+	val := &types.Validator{
+		AccountID: types.AccountID{Identifier: pub.Bytes(), KeyType: pub.Type()},
+		Power:     1,
+	}
+	tc.keys = append(tc.keys, priv)
+	return val
+}
+
+func (tc *testChain) keyFor(val *types.Validator) crypto.PrivateKey {
+	for _, key := range tc.keys {
+		if bytes.Equal(key.Public().Bytes(), val.Identifier) {
+			return key
+		}
+	}
+	tc.t.Fatalf("no key for validator %s", val.PrettyString())
+	return nil
+}
+
+func (tc *testChain) genesis() *TrustedState {
+	ts, err := GenesisState(&chaintypes.Genesis{
+		InitialHeight: 1,
+		Leader:        types.PublicKey{PublicKey: tc.leader.Public()},
+		Validators:    cloneVals(tc.vals),
+	})
+	require.NoError(tc.t, err)
+	return ts
+}
+
+func cloneVals(vals []*types.Validator) []*types.Validator {
+	out := make([]*types.Validator, len(vals))
+	for i, v := range vals {
+		val := *v
+		out[i] = &val
+	}
+	return out
+}
+
+// addBlock adds a block with the given number of transactions, with ACK votes
+// from the first numVotes current validators, and the given commit info updates.
+func (tc *testChain) addBlock(numTxs, numVotes int, valUpdates []*types.Validator, paramUpdates types.ParamUpdates) *types.Block {
+	height := int64(len(tc.blocks) + 1)
+	var prevHash, prevAppHash types.Hash
+	if len(tc.blocks) > 0 {
+		prevHash = tc.blocks[len(tc.blocks)-1].Hash()
+		prevAppHash = tc.commits[len(tc.commits)-1].AppHash
+	}
+
+	txns := make([]*types.Transaction, numTxs)
+	for i := range txns {
+		txns[i] = &types.Transaction{
+			Signature: &auth.Signature{},
+			Body: &types.TransactionBody{
+				Payload: []byte{byte(height), byte(i)},
+				Fee:     big.NewInt(0),
+				Nonce:   uint64(i),
+			},
+			Sender: []byte("alice"),
+		}
+	}
+
+	blk := types.NewBlock(height, prevHash, prevAppHash, types.ValidatorSetHash(tc.vals), types.Hash{},
+		time.UnixMilli(1729890593000+height), txns)
+	require.NoError(tc.t, blk.Sign(tc.leader))
+
+	for i, tx := range txns {
+		tc.txs[tx.Hash()] = &chaintypes.Tx{Hash: tx.Hash(), Height: height, Index: uint32(i), Tx: tx}
+	}
+
+	appHash := types.HashBytes([]byte{byte(height)})
+	ci := &types.CommitInfo{
+		AppHash:          appHash,
+		ParamUpdates:     paramUpdates,
+		ValidatorUpdates: valUpdates,
+	}
+	for _, val := range tc.vals[:numVotes] {
+		key := tc.keyFor(val)
+		sig, err := types.SignVote(blk.Hash(), true, &appHash, key)
+		require.NoError(tc.t, err)
+		ci.Votes = append(ci.Votes, &types.VoteInfo{AckStatus: types.AckAgree, Signature: *sig})
+	}
+
+	tc.blocks = append(tc.blocks, blk)
+	tc.commits = append(tc.commits, ci)
+
+	tc.vals = applyValidatorUpdates(tc.vals, cloneVals(valUpdates))
+	if leader, ok := paramUpdates[types.ParamNameLeader]; ok {
+		for _, key := range tc.keys {
+			if key.Public().Equals(leader.(types.PublicKey).PublicKey) {
+				tc.leader = key
+			}
+		}
+	}
+
+	return blk
+}
+
+func (tc *testChain) BlockByHeight(_ context.Context, height int64) (*types.Block, *types.CommitInfo, error) {
+	if height < 1 || height > int64(len(tc.blocks)) {
+		return nil, nil, rpcclient.ErrNotFound
+	}
+	return tc.blocks[height-1], tc.commits[height-1], nil
+}
+
+func (tc *testChain) Tx(_ context.Context, hash types.Hash) (*chaintypes.Tx, error) {
+	tx, ok := tc.txs[hash]
+	if !ok {
+		return nil, rpcclient.ErrNotFound
+	}
+	return tx, nil
+}
+
+func TestClient_Sync(t *testing.T) {
+	ctx := context.Background()
+	tc := newTestChain(t, 4)
+	genesis := tc.genesis()
+
+	tc.addBlock(3, 4, nil, nil)
+	// a new validator joins and an existing one leaves
+	newVal := tc.addKey()
+	tc.addBlock(1, 3, []*types.Validator{newVal, {AccountID: tc.vals[3].AccountID, Power: 0}}, nil)
+	// the validators vote to change the leader
+	tc.addBlock(0, 4, nil, types.ParamUpdates{
+		types.ParamNameLeader: types.PublicKey{PublicKey: tc.keys[1].Public()},
+	})
+	tc.addBlock(5, 4, nil, nil)
+
+	lc, err := New(tc, genesis)
+	require.NoError(t, err)
+
+	vh, err := lc.Sync(ctx)
+	require.NoError(t, err)
+	require.Equal(t, int64(4), vh.Header.Height)
+	require.Equal(t, tc.blocks[3].Hash(), vh.Hash)
+	require.Equal(t, tc.commits[3].AppHash, vh.AppHash)
+
+	ts := lc.TrustedState()
+	require.Equal(t, int64(4), ts.Height)
+	require.True(t, ts.Leader.Equals(tc.keys[1].Public()))
+	require.Equal(t, types.ValidatorSetHash(tc.vals), types.ValidatorSetHash(ts.Validators))
+
+	// nothing new
+	vh, err = lc.Sync(ctx)
+	require.NoError(t, err)
+	require.Nil(t, vh)
+
+	// resume from the checkpoint
+	tc.addBlock(1, 4, nil, nil)
+	lc, err = New(tc, ts)
+	require.NoError(t, err)
+	vh, err = lc.VerifyTo(ctx, 5)
+	require.NoError(t, err)
+	require.Equal(t, tc.blocks[4].Hash(), vh.Hash)
+}
+
+func TestClient_VerifyTx(t *testing.T) {
+	ctx := context.Background()
+	tc := newTestChain(t, 3)
+	genesis := tc.genesis()
+
+	tc.addBlock(2, 3, nil, nil)
+	blk := tc.addBlock(5, 3, nil, nil)
+
+	lc, err := New(tc, genesis)
+	require.NoError(t, err)
+
+	txHash := blk.Txns[4].Hash()
+	tx, proof, err := lc.VerifyTx(ctx, txHash)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), tx.Height)
+	require.Equal(t, txHash, proof.TxHash)
+
+	vh, ok := lc.Header(2)
+	require.True(t, ok)
+	require.NoError(t, proof.Verify(vh.Header))
+
+	// a proof for a different transaction fails
+	proof.TxHash = blk.Txns[3].Hash()
+	require.Error(t, proof.Verify(vh.Header))
+
+	// an RPC provider lying about the index is detected
+	tc.txs[txHash].Index = 3
+	_, _, err = lc.VerifyTx(ctx, txHash)
+	require.Error(t, err)
+}
+
+func TestVerifyBlock(t *testing.T) {
+	tests := []struct {
+		name    string
+		tamper  func(tc *testChain, blk *types.Block, ci *types.CommitInfo) (*types.Block, *types.CommitInfo)
+		tl      TrustLevel
+		wantErr error
+	}{
+		{
+			name: "valid",
+		},
+		{
+			name: "majority is enough with a trust level of one half",
+			tamper: func(tc *testChain, blk *types.Block, ci *types.CommitInfo) (*types.Block, *types.CommitInfo) {
+				ci.Votes = ci.Votes[:2]
+				return blk, ci
+			},
+			tl: TrustLevel{1, 2},
+		},
+		{
+			name: "insufficient votes",
+			tamper: func(tc *testChain, blk *types.Block, ci *types.CommitInfo) (*types.Block, *types.CommitInfo) {
+				ci.Votes = ci.Votes[:2]
+				return blk, ci
+			},
+			wantErr: ErrInsufficientVotes,
+		},
+		{
+			name: "duplicate votes",
+			tamper: func(tc *testChain, blk *types.Block, ci *types.CommitInfo) (*types.Block, *types.CommitInfo) {
+				ci.Votes = append(ci.Votes[:2], ci.Votes[0])
+				return blk, ci
+			},
+			wantErr: errAny,
+		},
+		{
+			name: "vote for a different app hash",
+			tamper: func(tc *testChain, blk *types.Block, ci *types.CommitInfo) (*types.Block, *types.CommitInfo) {
+				ci.AppHash = types.Hash{1}
+				return blk, ci
+			},
+			wantErr: errAny,
+		},
+		{
+			name: "not signed by the leader",
+			tamper: func(tc *testChain, blk *types.Block, ci *types.CommitInfo) (*types.Block, *types.CommitInfo) {
+				require.NoError(t, blk.Sign(tc.keys[1]))
+				return blk, ci
+			},
+			wantErr: errAny,
+		},
+		{
+			name: "transactions do not match the merkle root",
+			tamper: func(tc *testChain, blk *types.Block, ci *types.CommitInfo) (*types.Block, *types.CommitInfo) {
+				blk.Txns[0], blk.Txns[1] = blk.Txns[1], blk.Txns[0]
+				return blk, ci
+			},
+			wantErr: errAny,
+		},
+		{
+			name: "unexpected validator set",
+			tamper: func(tc *testChain, blk *types.Block, ci *types.CommitInfo) (*types.Block, *types.CommitInfo) {
+				// re-sign a block that commits to a different validator set
+				blk = types.NewBlock(blk.Header.Height, blk.Header.PrevHash, blk.Header.PrevAppHash,
+					types.Hash{1}, blk.Header.NetworkParamsHash, blk.Header.Timestamp, blk.Txns)
+				require.NoError(t, blk.Sign(tc.leader))
+				return blk, ci
+			},
+			wantErr: ErrValidatorSetMismatch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := newTestChain(t, 3)
+			genesis := tc.genesis()
+			blk := tc.addBlock(2, 3, nil, nil)
+			ci := tc.commits[0]
+			if tt.tamper != nil {
+				blk, ci = tt.tamper(tc, blk, ci)
+			}
+			tl := tt.tl
+			if tl.Denominator == 0 {
+				tl = DefaultTrustLevel
+			}
+
+			next, err := VerifyBlock(genesis, blk, ci, tl)
+			switch {
+			case tt.wantErr == nil:
+				require.NoError(t, err)
+				require.Equal(t, int64(1), next.Height)
+				require.Equal(t, blk.Hash(), next.BlockHash)
+			case tt.wantErr == errAny:
+				require.Error(t, err)
+			default:
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// errAny is a sentinel for test cases that expect any error.
+var errAny = &anyError{}
+
+type anyError struct{}
+
+func (*anyError) Error() string { return "any error" }
@@ -0,0 +1,55 @@
+package lightclient
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+// TxProof proves that a transaction is included in a block, using the merkle
+// root of the block's transactions.
+type TxProof struct {
+	TxHash types.Hash `json:"tx_hash"`
+	Height int64      `json:"height"`
+	// Index is the position of the transaction in the block.
+	Index uint32 `json:"index"`
+	// Proof is the list of sibling hashes from the transaction up to the
+	// merkle root.
+	Proof []types.Hash `json:"proof"`
+}
+
+// NewTxProof creates the inclusion proof for the transaction at the given
+// index of a block.
+func NewTxProof(blk *types.Block, idx uint32) (*TxProof, error) {
+	if int(idx) >= len(blk.Txns) {
+		return nil, fmt.Errorf("transaction index %d out of range for %d transactions", idx, len(blk.Txns))
+	}
+
+	txHashes := make([]types.Hash, len(blk.Txns))
+	for i, tx := range blk.Txns {
+		txHashes[i] = tx.HashCache()
+	}
+	proof, err := types.CalcMerkleProof(txHashes, int(idx))
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxProof{
+		TxHash: txHashes[idx],
+		Height: blk.Header.Height,
+		Index:  idx,
+		Proof:  proof,
+	}, nil
+}
+
+// Verify checks the proof against a verified block header.
+func (p *TxProof) Verify(hdr *types.BlockHeader) error {
+	if hdr.Height != p.Height {
+		return fmt.Errorf("proof is for height %d, not %d", p.Height, hdr.Height)
+	}
+	if !types.VerifyMerkleProof(hdr.MerkleRoot, p.TxHash, p.Index, hdr.NumTxns, p.Proof) {
+		return errors.New("transaction is not included in the block")
+	}
+	return nil
+}
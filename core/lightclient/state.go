@@ -0,0 +1,92 @@
+package lightclient
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	chaintypes "github.com/kwilteam/kwil-db/core/types/chain"
+)
+
+// TrustedState is the state of the chain that the light client trusts, from
+// which it verifies the following blocks. It must be obtained from a trusted
+// source, such as the network's genesis file or a checkpoint recorded by a
+// previous session. It is JSON-serializable so that it may be persisted.
+type TrustedState struct {
+	// Height is the height of the last trusted block. For a genesis state,
+	// this is one less than the initial height of the chain.
+	Height int64 `json:"height"`
+	// BlockHash is the hash of the last trusted block. It is zero for a
+	// genesis state.
+	BlockHash types.Hash `json:"block_hash"`
+	// AppHash is the app hash resulting from the execution of the last trusted
+	// block. It is not known for a genesis state.
+	AppHash types.Hash `json:"app_hash"`
+	// Validators is the validator set that votes on the block following the
+	// last trusted block.
+	Validators []*types.Validator `json:"validators"`
+	// Leader is the leader that proposes the block following the last trusted
+	// block.
+	Leader types.PublicKey `json:"leader"`
+}
+
+// GenesisState creates the trusted state for a chain from its genesis
+// configuration. The genesis configuration must come from a trusted source,
+// not from the RPC provider that the light client is verifying.
+func GenesisState(genesis *chaintypes.Genesis) (*TrustedState, error) {
+	if genesis.InitialHeight < 1 {
+		return nil, fmt.Errorf("invalid initial height %d", genesis.InitialHeight)
+	}
+	ts := &TrustedState{
+		Height:     genesis.InitialHeight - 1,
+		Validators: genesis.Validators,
+		Leader:     genesis.Leader,
+	}
+	if err := ts.validate(); err != nil {
+		return nil, err
+	}
+	return ts, nil
+}
+
+// IsGenesis indicates if the state precedes the first block of the chain.
+func (ts *TrustedState) IsGenesis() bool {
+	return ts.BlockHash.IsZero()
+}
+
+func (ts *TrustedState) validate() error {
+	if ts.Height < 0 {
+		return fmt.Errorf("invalid height %d", ts.Height)
+	}
+	if len(ts.Validators) == 0 {
+		return errors.New("no validators in trusted state")
+	}
+	for _, v := range ts.Validators {
+		if v.Power <= 0 {
+			return fmt.Errorf("validator %s has no power", v.PrettyString())
+		}
+	}
+	if ts.Leader.PublicKey == nil {
+		return errors.New("no leader in trusted state")
+	}
+	if !ts.isValidator(ts.Leader) {
+		return errors.New("leader is not a validator")
+	}
+	return nil
+}
+
+func (ts *TrustedState) isValidator(key types.PublicKey) bool {
+	return slices.ContainsFunc(ts.Validators, func(v *types.Validator) bool {
+		return v.KeyType == key.Type() && slices.Equal(v.Identifier, key.Bytes())
+	})
+}
+
+func (ts *TrustedState) clone() *TrustedState {
+	c := *ts
+	c.Validators = make([]*types.Validator, len(ts.Validators))
+	for i, v := range ts.Validators {
+		val := *v
+		c.Validators[i] = &val
+	}
+	return &c
+}
@@ -0,0 +1,191 @@
+package lightclient
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+
+	"github.com/kwilteam/kwil-db/core/types"
+)
+
+var (
+	// ErrInsufficientVotes is returned when the commit info for a block does
+	// not have enough agreeing votes to meet the trust level.
+	ErrInsufficientVotes = errors.New("insufficient validator votes")
+	// ErrValidatorSetMismatch is returned when the validator set committed to
+	// by a block header is not the one expected from the trusted state.
+	ErrValidatorSetMismatch = errors.New("validator set hash mismatch")
+)
+
+// TrustLevel is the fraction of the validator set's total power that must
+// agree with a block for the light client to accept it.
+type TrustLevel struct {
+	Numerator   int64
+	Denominator int64
+}
+
+// DefaultTrustLevel requires that more than two thirds of the validator power
+// agreed with a block. Note that the network commits a block once a simple
+// majority of the validators agree with it, so a client that must follow every
+// block of a small validator set may need to use a trust level of one half.
+var DefaultTrustLevel = TrustLevel{2, 3}
+
+func (tl TrustLevel) validate() error {
+	if tl.Denominator <= 0 || tl.Numerator < 0 || tl.Numerator >= tl.Denominator {
+		return fmt.Errorf("invalid trust level %d/%d", tl.Numerator, tl.Denominator)
+	}
+	if tl.Numerator*2 < tl.Denominator {
+		return fmt.Errorf("trust level %d/%d is less than one half", tl.Numerator, tl.Denominator)
+	}
+	return nil
+}
+
+// VerifyCommit checks that more than the trust level fraction of the
+// validators' total power signed ACK votes that agree with the block hash and
+// the commit info's app hash. Votes that do not agree are verified but not
+// counted.
+func VerifyCommit(validators []*types.Validator, blkHash types.Hash, ci *types.CommitInfo, tl TrustLevel) error {
+	if ci == nil {
+		return errors.New("missing commit info")
+	}
+
+	var total, agreed int64
+	for _, v := range validators {
+		total += v.Power
+	}
+
+	seen := make(map[string]bool, len(ci.Votes))
+	for _, vote := range ci.Votes {
+		idx := slices.IndexFunc(validators, func(v *types.Validator) bool {
+			return v.KeyType == vote.Signature.PubKeyType && slices.Equal(v.Identifier, vote.Signature.PubKey)
+		})
+		if idx == -1 {
+			return fmt.Errorf("vote is from a non-validator: %x", vote.Signature.PubKey)
+		}
+		val := validators[idx]
+		if seen[val.PrettyString()] {
+			return fmt.Errorf("duplicate vote from validator %s", val.PrettyString())
+		}
+		seen[val.PrettyString()] = true
+
+		if err := vote.Verify(blkHash, ci.AppHash); err != nil {
+			return fmt.Errorf("invalid vote from validator %s: %w", val.PrettyString(), err)
+		}
+
+		if vote.AckStatus == types.AckAgree {
+			agreed += val.Power
+		}
+	}
+
+	// agreed / total > numerator / denominator
+	lhs := new(big.Int).Mul(big.NewInt(agreed), big.NewInt(tl.Denominator))
+	rhs := new(big.Int).Mul(big.NewInt(total), big.NewInt(tl.Numerator))
+	if lhs.Cmp(rhs) <= 0 {
+		return fmt.Errorf("%w: %d of %d power agreed, need more than %d/%d",
+			ErrInsufficientVotes, agreed, total, tl.Numerator, tl.Denominator)
+	}
+	return nil
+}
+
+// VerifyBlock verifies that a block and its commit info follow the trusted
+// state, and returns the trusted state after the block. It checks that:
+//   - the block links to the last trusted block and app hash
+//   - the header commits to the trusted validator set
+//   - the block is signed by the leader
+//   - the transactions match the header's merkle root
+//   - enough validators agreed with the block
+//
+// The validator set and leader of the returned state include the updates from
+// the commit info.
+func VerifyBlock(ts *TrustedState, blk *types.Block, ci *types.CommitInfo, tl TrustLevel) (*TrustedState, error) {
+	if blk == nil || blk.Header == nil {
+		return nil, errors.New("missing block header")
+	}
+	hdr := blk.Header
+
+	if hdr.Height != ts.Height+1 {
+		return nil, fmt.Errorf("block height %d does not follow trusted height %d", hdr.Height, ts.Height)
+	}
+	if hdr.PrevHash != ts.BlockHash {
+		return nil, fmt.Errorf("previous block hash mismatch, expected %s, got %s", ts.BlockHash, hdr.PrevHash)
+	}
+	// The app hash of the genesis state is not known to the client.
+	if !ts.IsGenesis() && hdr.PrevAppHash != ts.AppHash {
+		return nil, fmt.Errorf("previous app hash mismatch, expected %s, got %s", ts.AppHash, hdr.PrevAppHash)
+	}
+
+	if valSetHash := types.ValidatorSetHash(ts.Validators); hdr.ValidatorSetHash != valSetHash {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrValidatorSetMismatch, valSetHash, hdr.ValidatorSetHash)
+	}
+
+	// A leader that was replaced offline proposes the block announcing its
+	// promotion, which must be signed by the new leader.
+	leader := ts.Leader
+	if hdr.NewLeader != nil {
+		leader = types.PublicKey{PublicKey: hdr.NewLeader}
+		if !ts.isValidator(leader) {
+			return nil, errors.New("new leader is not a validator")
+		}
+	}
+	valid, err := blk.VerifySignature(leader.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify block signature: %w", err)
+	}
+	if !valid {
+		return nil, errors.New("invalid block signature")
+	}
+
+	if hdr.NumTxns != uint32(len(blk.Txns)) {
+		return nil, fmt.Errorf("transaction count mismatch, expected %d, got %d", hdr.NumTxns, len(blk.Txns))
+	}
+	if merkleRoot := blk.CalcMerkleRoot(); merkleRoot != hdr.MerkleRoot {
+		return nil, fmt.Errorf("merkle root mismatch, expected %s, got %s", hdr.MerkleRoot, merkleRoot)
+	}
+
+	blkHash := blk.Hash()
+	if err = VerifyCommit(ts.Validators, blkHash, ci, tl); err != nil {
+		return nil, err
+	}
+
+	next := ts.clone()
+	next.Height = hdr.Height
+	next.BlockHash = blkHash
+	next.AppHash = ci.AppHash
+	next.Leader = leader
+	next.Validators = applyValidatorUpdates(next.Validators, ci.ValidatorUpdates)
+
+	if len(ci.ParamUpdates) > 0 {
+		params := &types.NetworkParameters{Leader: next.Leader}
+		if err = types.MergeUpdates(params, ci.ParamUpdates); err != nil {
+			return nil, fmt.Errorf("invalid param updates: %w", err)
+		}
+		next.Leader = params.Leader
+	}
+
+	if err = next.validate(); err != nil {
+		return nil, fmt.Errorf("invalid state after block %d: %w", hdr.Height, err)
+	}
+
+	return next, nil
+}
+
+// applyValidatorUpdates applies the validator updates of a block to a
+// validator set. An update with zero power removes the validator.
+func applyValidatorUpdates(validators, updates []*types.Validator) []*types.Validator {
+	for _, update := range updates {
+		idx := slices.IndexFunc(validators, func(v *types.Validator) bool {
+			return v.KeyType == update.KeyType && slices.Equal(v.Identifier, update.Identifier)
+		})
+		switch {
+		case idx == -1 && update.Power > 0:
+			val := *update
+			validators = append(validators, &val)
+		case idx != -1 && update.Power > 0:
+			validators[idx].Power = update.Power
+		case idx != -1:
+			validators = slices.Delete(validators, idx, idx+1)
+		}
+	}
+	return validators
+}
@@ -3,6 +3,7 @@ package admin
 
 import (
 	"context"
+	"math/big"
 
 	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/types"
@@ -17,7 +18,12 @@ type AdminClient interface {
 	Promote(ctx context.Context, publicKey []byte, pubKeyType crypto.KeyType, height int64) error
 	ListValidators(ctx context.Context) ([]*types.Validator, error)
 	Peers(ctx context.Context) ([]*adminTypes.PeerInfo, error)
+	PeerScores(ctx context.Context) ([]*adminTypes.PeerScore, error)
 	Remove(ctx context.Context, publicKey []byte, pubKeyType crypto.KeyType) (types.Hash, error)
+	Stake(ctx context.Context, amount *big.Int) (types.Hash, error)
+	Delegate(ctx context.Context, publicKey []byte, pubKeyType crypto.KeyType, amount *big.Int) (types.Hash, error)
+	Undelegate(ctx context.Context, publicKey []byte, pubKeyType crypto.KeyType, amount *big.Int) (types.Hash, error)
+	ListStakes(ctx context.Context) ([]*adminTypes.Stake, []*adminTypes.Unbonding, error)
 	Status(ctx context.Context) (*adminTypes.Status, error)
 	Version(ctx context.Context) (string, error)
 	ListPendingJoins(ctx context.Context) ([]*types.JoinRequest, error)
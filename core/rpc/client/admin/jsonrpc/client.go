@@ -2,6 +2,7 @@ package adminclient
 
 import (
 	"context"
+	"math/big"
 	"net/url"
 	"time"
 
@@ -117,6 +118,18 @@ func (cl *Client) Peers(ctx context.Context) ([]*adminTypes.PeerInfo, error) {
 	return res.Peers, err
 }
 
+// PeerScores lists the scores of the node's peers that have misbehaved or
+// provided useful responses, including banned peers.
+func (cl *Client) PeerScores(ctx context.Context) ([]*adminTypes.PeerScore, error) {
+	cmd := &adminjson.PeerScoresRequest{}
+	res := &adminjson.PeerScoresResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodPeerScores), cmd, res)
+	if err != nil {
+		return nil, err
+	}
+	return res.Scores, err
+}
+
 // Remove votes to remove the validator specified by the given public key.
 func (cl *Client) Remove(ctx context.Context, publicKey []byte, pubKeyType crypto.KeyType) (types.Hash, error) {
 	cmd := &adminjson.RemoveRequest{
@@ -131,6 +144,63 @@ func (cl *Client) Remove(ctx context.Context, publicKey []byte, pubKeyType crypt
 	return res.TxHash, err
 }
 
+// Stake bonds an amount from the node's account to its own validator.
+func (cl *Client) Stake(ctx context.Context, amount *big.Int) (types.Hash, error) {
+	cmd := &adminjson.StakeRequest{
+		Amount: amount.String(),
+	}
+	res := &userjson.BroadcastResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodValStake), cmd, res)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return res.TxHash, err
+}
+
+// Delegate bonds an amount from the node's account to the validator specified
+// by the given public key.
+func (cl *Client) Delegate(ctx context.Context, publicKey []byte, pubKeyType crypto.KeyType, amount *big.Int) (types.Hash, error) {
+	cmd := &adminjson.DelegateRequest{
+		PubKey:     publicKey,
+		PubKeyType: pubKeyType,
+		Amount:     amount.String(),
+	}
+	res := &userjson.BroadcastResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodValDelegate), cmd, res)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return res.TxHash, err
+}
+
+// Undelegate unbonds an amount of the node's stake with the validator
+// specified by the given public key.
+func (cl *Client) Undelegate(ctx context.Context, publicKey []byte, pubKeyType crypto.KeyType, amount *big.Int) (types.Hash, error) {
+	cmd := &adminjson.UndelegateRequest{
+		PubKey:     publicKey,
+		PubKeyType: pubKeyType,
+		Amount:     amount.String(),
+	}
+	res := &userjson.BroadcastResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodValUndelegate), cmd, res)
+	if err != nil {
+		return types.Hash{}, err
+	}
+	return res.TxHash, err
+}
+
+// ListStakes lists the stake bonded to validators and the stake that is
+// unbonding.
+func (cl *Client) ListStakes(ctx context.Context) ([]*adminTypes.Stake, []*adminTypes.Unbonding, error) {
+	cmd := &adminjson.ListStakesRequest{}
+	res := &adminjson.ListStakesResponse{}
+	err := cl.CallMethod(ctx, string(adminjson.MethodValStakes), cmd, res)
+	if err != nil {
+		return nil, nil, err
+	}
+	return res.Stakes, res.Unbonding, nil
+}
+
 // Promote promotes a validator to a leader at the specified height.
 func (cl *Client) Promote(ctx context.Context, publicKey []byte, pubKeyType crypto.KeyType, height int64) error {
 	cmd := &adminjson.PromoteRequest{
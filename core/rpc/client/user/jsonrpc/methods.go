@@ -169,6 +169,20 @@ func (cl *Client) AuthenticatedQuery(ctx context.Context, msg *types.Authenticat
 	return (*types.QueryResult)(res), nil
 }
 
+// Schema gets the Kuneiform DDL that defines a namespace.
+func (cl *Client) Schema(ctx context.Context, namespace string) (string, error) {
+	cmd := &userjson.SchemaRequest{
+		Namespace: namespace,
+	}
+	res := &userjson.SchemaResponse{}
+	err := cl.CallMethod(ctx, string(userjson.MethodSchema), cmd, res)
+	if err != nil {
+		return "", err
+	}
+
+	return res.DDL, nil
+}
+
 func (cl *Client) TxQuery(ctx context.Context, txHash types.Hash) (*types.TxQueryResponse, error) {
 	cmd := &userjson.TxQueryRequest{
 		TxHash: txHash,
@@ -182,6 +196,58 @@ func (cl *Client) TxQuery(ctx context.Context, txHash types.Hash) (*types.TxQuer
 	return res, nil
 }
 
+// StateProof gets a row of a table by its primary key, with a proof of its
+// inclusion or absence in the state at the given height, or the latest height
+// if zero.
+func (cl *Client) StateProof(ctx context.Context, namespace, table string, primaryKey []*types.EncodedValue, height int64) (*types.StateProof, error) {
+	cmd := &userjson.StateProofRequest{
+		Namespace:  namespace,
+		Table:      table,
+		PrimaryKey: primaryKey,
+		Height:     height,
+	}
+	res := &userjson.StateProofResponse{}
+	err := cl.CallMethod(ctx, string(userjson.MethodStateProof), cmd, res)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// BlockFees gets the accounting of the transaction fees collected in the block
+// at the given height.
+func (cl *Client) BlockFees(ctx context.Context, height int64) (*types.FeeDistribution, error) {
+	cmd := &userjson.BlockFeesRequest{
+		Height: height,
+	}
+	res := &userjson.BlockFeesResponse{}
+	err := cl.CallMethod(ctx, string(userjson.MethodBlockFees), cmd, res)
+	if err != nil {
+		return nil, err
+	}
+
+	fees := &types.FeeDistribution{Height: res.Height}
+	for _, amt := range []struct {
+		dst **big.Int
+		str string
+	}{
+		{&fees.Collected, res.Collected},
+		{&fees.Leader, res.Leader},
+		{&fees.Validators, res.Validators},
+		{&fees.Treasury, res.Treasury},
+		{&fees.Burned, res.Burned},
+	} {
+		val, ok := new(big.Int).SetString(amt.str, 10)
+		if !ok {
+			return nil, fmt.Errorf("failed to parse fee amount to big.Int. received: %s", amt.str)
+		}
+		*amt.dst = val
+	}
+
+	return fees, nil
+}
+
 // ListUpdateProposals lists all consensus parameter update proposals that have been proposed that are still in the pending state.
 func (cl *Client) ListUpdateProposals(ctx context.Context) ([]*types.ConsensusParamUpdateProposal, error) {
 	cmd := &userjson.ListPendingConsensusUpdatesRequest{}
@@ -22,6 +22,9 @@ type TxSvcClient interface {
 	Query(ctx context.Context, query string, params map[string]*types.EncodedValue) (*types.QueryResult, error)
 	AuthenticatedQuery(ctx context.Context, msg *types.AuthenticatedQuery) (*types.QueryResult, error)
 	TxQuery(ctx context.Context, txHash types.Hash) (*types.TxQueryResponse, error)
+	Schema(ctx context.Context, namespace string) (string, error)
+	StateProof(ctx context.Context, namespace, table string, primaryKey []*types.EncodedValue, height int64) (*types.StateProof, error)
+	BlockFees(ctx context.Context, height int64) (*types.FeeDistribution, error)
 
 	// Migration methods
 	ListMigrations(ctx context.Context) ([]*types.Migration, error)
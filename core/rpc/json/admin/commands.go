@@ -9,6 +9,8 @@ import (
 
 type StatusRequest struct{}
 type PeersRequest struct{}
+
+type PeerScoresRequest struct{}
 type GetConfigRequest struct{}
 type ApproveRequest struct {
 	PubKey     []byte         `json:"pubkey"`
@@ -25,6 +27,30 @@ type JoinStatusRequest struct {
 	PubKeyType crypto.KeyType `json:"pubkey_type"`
 }
 type ListValidatorsRequest struct{}
+
+// StakeRequest bonds an amount, as a decimal string, from the node's account
+// to its own validator.
+type StakeRequest struct {
+	Amount string `json:"amount"`
+}
+
+// DelegateRequest bonds an amount, as a decimal string, from the node's
+// account to a validator.
+type DelegateRequest struct {
+	PubKey     []byte         `json:"pubkey"`
+	PubKeyType crypto.KeyType `json:"pubkey_type"`
+	Amount     string         `json:"amount"`
+}
+
+// UndelegateRequest unbonds an amount, as a decimal string, of the node's
+// stake with a validator.
+type UndelegateRequest struct {
+	PubKey     []byte         `json:"pubkey"`
+	PubKeyType crypto.KeyType `json:"pubkey_type"`
+	Amount     string         `json:"amount"`
+}
+
+type ListStakesRequest struct{}
 type ListJoinRequestsRequest struct{}
 
 type PeerRequest struct {
@@ -9,6 +9,7 @@ const (
 	MethodVersion           jsonrpc.Method = "admin.version"
 	MethodStatus            jsonrpc.Method = "admin.status"
 	MethodPeers             jsonrpc.Method = "admin.peers"
+	MethodPeerScores        jsonrpc.Method = "admin.peer_scores"
 	MethodConfig            jsonrpc.Method = "admin.config"
 	MethodValApprove        jsonrpc.Method = "admin.val_approve"
 	MethodValJoin           jsonrpc.Method = "admin.val_join"
@@ -18,6 +19,10 @@ const (
 	MethodValList           jsonrpc.Method = "admin.val_list"
 	MethodValListJoins      jsonrpc.Method = "admin.val_list_joins"
 	MethodValPromote        jsonrpc.Method = "admin.val_promote"
+	MethodValStake          jsonrpc.Method = "admin.val_stake"
+	MethodValDelegate       jsonrpc.Method = "admin.val_delegate"
+	MethodValUndelegate     jsonrpc.Method = "admin.val_undelegate"
+	MethodValStakes         jsonrpc.Method = "admin.val_stakes"
 	MethodAddPeer           jsonrpc.Method = "admin.add_peer"
 	MethodRemovePeer        jsonrpc.Method = "admin.remove_peer"
 	MethodListPeers         jsonrpc.Method = "admin.list_peers"
@@ -58,6 +58,10 @@ type PeersResponse struct {
 	Peers []*adminTypes.PeerInfo `json:"peers"`
 }
 
+type PeerScoresResponse struct {
+	Scores []*adminTypes.PeerScore `json:"scores"`
+}
+
 // type Peer = adminTypes.PeerInfo
 
 // type Peer struct {
@@ -84,6 +88,11 @@ type ListValidatorsResponse struct {
 	Validators []*Validator `json:"validators,omitempty"`
 }
 
+type ListStakesResponse struct {
+	Stakes    []*adminTypes.Stake     `json:"stakes,omitempty"`
+	Unbonding []*adminTypes.Unbonding `json:"unbonding,omitempty"`
+}
+
 type ListJoinRequestsResponse struct {
 	JoinRequests []*PendingJoin `json:"join_requests,omitempty"`
 }
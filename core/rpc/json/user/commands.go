@@ -99,6 +99,19 @@ type TxQueryRequest struct {
 	TxHash types.Hash `json:"tx_hash"`
 }
 
+// StateProofRequest contains the request parameters for MethodStateProof.
+type StateProofRequest struct {
+	Namespace  string                `json:"namespace"`
+	Table      string                `json:"table"`
+	PrimaryKey []*types.EncodedValue `json:"primary_key" desc:"primary key values, in the order of the table's primary key columns"`
+	Height     int64                 `json:"height,omitempty" desc:"block height of the state to prove, or the latest if zero"`
+}
+
+// BlockFeesRequest contains the request parameters for MethodBlockFees.
+type BlockFeesRequest struct {
+	Height int64 `json:"height" desc:"height of the block"`
+}
+
 // LoadChangesetsRequest contains the request parameters for MethodLoadChangesets.
 type ChangesetMetadataRequest struct {
 	Height int64 `json:"height"`
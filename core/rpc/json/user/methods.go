@@ -26,4 +26,6 @@ const (
 	MethodMigrationMetadata     jsonrpc.Method = "user.migration_metadata"
 	MethodMigrationGenesisChunk jsonrpc.Method = "user.migration_genesis_chunk"
 	MethodChallenge             jsonrpc.Method = "user.challenge"
+	MethodStateProof            jsonrpc.Method = "user.state_proof"
+	MethodBlockFees             jsonrpc.Method = "user.block_fees"
 )
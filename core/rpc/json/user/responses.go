@@ -58,9 +58,28 @@ type EstimatePriceResponse struct {
 	Price string `json:"price,omitempty"`
 }
 
+// SchemaResponse contains the response object for MethodSchema.
+type SchemaResponse struct {
+	DDL string `json:"ddl" desc:"the Kuneiform statements that define the namespace"`
+}
+
 // TxQueryResponse contains the response object for MethodTxQuery.
 type TxQueryResponse = types.TxQueryResponse
 
+// StateProofResponse contains the response object for MethodStateProof.
+type StateProofResponse = types.StateProof
+
+// BlockFeesResponse contains the response object for MethodBlockFees. The
+// amounts are decimal strings.
+type BlockFeesResponse struct {
+	Height     int64  `json:"height"`
+	Collected  string `json:"collected"`
+	Leader     string `json:"leader"`
+	Validators string `json:"validators"`
+	Treasury   string `json:"treasury"`
+	Burned     string `json:"burned"`
+}
+
 type ChangesetsResponse struct {
 	Changesets []byte `json:"changesets"`
 }
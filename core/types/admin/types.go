@@ -48,6 +48,17 @@ type PeerInfo struct {
 	Inbound    bool   `json:"inbound"`
 }
 
+// PeerScore describes the reputation of a peer node, which is lowered when it
+// misbehaves, such as by serving invalid blocks or timing out on requests. A
+// peer with a low score is temporarily banned.
+type PeerScore struct {
+	NodeID      string  `json:"node_id"`
+	Score       float64 `json:"score"`
+	Bans        int     `json:"bans"`                   // number of times the peer has been banned
+	BannedUntil int64   `json:"banned_until,omitempty"` // unix milliseconds, if currently banned
+	Connected   bool    `json:"connected"`
+}
+
 type MigrationInfo struct {
 	Status        string `json:"status"`
 	StartHeight   int64  `json:"start_height"`
@@ -66,3 +77,19 @@ type TxInfo struct {
 	ID     types.Hash `json:"id"`
 	Status bool       `json:"status"`
 }
+
+// Stake is an amount, as a decimal string, bonded by a delegator to a
+// validator. A validator's own stake has the validator as the delegator.
+type Stake struct {
+	Validator types.AccountID `json:"validator"`
+	Delegator types.AccountID `json:"delegator"`
+	Amount    string          `json:"amount"`
+}
+
+// Unbonding is an amount, as a decimal string, of undelegated stake that is
+// returned to the delegator at a block height.
+type Unbonding struct {
+	Delegator types.AccountID `json:"delegator"`
+	Height    int64           `json:"height"`
+	Amount    string          `json:"amount"`
+}
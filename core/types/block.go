@@ -456,6 +456,69 @@ func CalcMerkleRoot(leaves []Hash) Hash {
 	return leaves[0]
 }
 
+// CalcMerkleProof computes the proof that the leaf at the given index is
+// included in the merkle root computed by CalcMerkleRoot. The proof is the list
+// of sibling hashes from the leaf level up to, but not including, the root.
+func CalcMerkleProof(leaves []Hash, idx int) ([]Hash, error) {
+	if idx < 0 || idx >= len(leaves) {
+		return nil, fmt.Errorf("leaf index %d out of range for %d leaves", idx, len(leaves))
+	}
+
+	leaves = slices.Clone(leaves)
+
+	var proof []Hash
+	var buf [2 * HashLen]byte
+	for len(leaves) > 1 {
+		if len(leaves)&1 != 0 {
+			leaves = append(leaves, leaves[len(leaves)-1])
+		}
+		proof = append(proof, leaves[idx^1])
+
+		for i := range len(leaves) / 2 {
+			copy(buf[:HashLen], leaves[i*2][:])
+			copy(buf[HashLen:], leaves[i*2+1][:])
+			leaves[i] = HashBytes(buf[:])
+		}
+		leaves = leaves[:len(leaves)/2]
+		idx /= 2
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof checks that the leaf at the given index of a tree with
+// numLeaves leaves is included in the merkle root, using a proof created by
+// CalcMerkleProof.
+func VerifyMerkleProof(root, leaf Hash, idx, numLeaves uint32, proof []Hash) bool {
+	if idx >= numLeaves {
+		return false
+	}
+
+	// The tree height is fixed by the number of leaves, which prevents a proof
+	// for an interior node being passed off as one for a leaf.
+	var depth int
+	for n := numLeaves; n > 1; n = (n + 1) / 2 {
+		depth++
+	}
+	if len(proof) != depth {
+		return false
+	}
+
+	var buf [2 * HashLen]byte
+	node := leaf
+	for _, sibling := range proof {
+		if idx&1 == 0 {
+			copy(buf[:HashLen], node[:])
+			copy(buf[HashLen:], sibling[:])
+		} else {
+			copy(buf[:HashLen], sibling[:])
+			copy(buf[HashLen:], node[:])
+		}
+		node = HashBytes(buf[:])
+		idx /= 2
+	}
+	return node == root
+}
+
 func DecodeBlock(rawBlk []byte) (*Block, error) {
 	r := bytes.NewReader(rawBlk)
 
@@ -421,6 +421,32 @@ func TestCalcMerkleRoot_Extended(t *testing.T) {
 	})
 }
 
+func TestMerkleProof(t *testing.T) {
+	for _, n := range []int{1, 2, 3, 4, 5, 7, 8, 13} {
+		leaves := make([]Hash, n)
+		for i := range leaves {
+			leaves[i] = HashBytes([]byte{byte(i)})
+		}
+		root := CalcMerkleRoot(leaves)
+
+		for i, leaf := range leaves {
+			proof, err := CalcMerkleProof(leaves, i)
+			require.NoError(t, err)
+			require.True(t, VerifyMerkleProof(root, leaf, uint32(i), uint32(n), proof), "n=%d i=%d", n, i)
+
+			// wrong leaf, index, or leaf count
+			require.False(t, VerifyMerkleProof(root, Hash{0xff}, uint32(i), uint32(n), proof))
+			if n > 1 {
+				require.False(t, VerifyMerkleProof(root, leaf, uint32((i+1)%n), uint32(n), proof), "n=%d i=%d", n, i)
+			}
+			require.False(t, VerifyMerkleProof(root, leaf, uint32(i), uint32(2*n), proof))
+		}
+
+		_, err := CalcMerkleProof(leaves, n)
+		require.Error(t, err)
+	}
+}
+
 func TestBlock_Size(t *testing.T) {
 	t.Run("empty block", func(t *testing.T) {
 		blk := &Block{
@@ -58,6 +58,42 @@ type Genesis struct {
 	// MaxVotesPerTx is the maximum number of votes that can be included in a
 	// single transaction.
 	MaxVotesPerTx int64 `json:"max_votes_per_tx"`
+	// NonceWindow is the number of nonces that a transaction may skip past an
+	// account's next sequential nonce.
+	NonceWindow int64 `json:"nonce_window"`
+	// EquivocationJailBlocks is the number of blocks for which an
+	// equivocating validator is removed from the validator set.
+	EquivocationJailBlocks int64 `json:"equivocation_jail_blocks"`
+	// EquivocationSlashPercent is the percentage of an equivocating
+	// validator's power that is removed.
+	EquivocationSlashPercent int64 `json:"equivocation_slash_percent"`
+	// EquivocationBurnPercent is the percentage of an equivocating
+	// validator's account balance that is burned.
+	EquivocationBurnPercent int64 `json:"equivocation_burn_percent"`
+	// MaxEvidenceAge is the number of blocks after an equivocation during
+	// which evidence of it may be submitted.
+	MaxEvidenceAge int64 `json:"max_evidence_age"`
+	// StakePowerUnit is the amount of bonded stake per unit of validator
+	// power, as a decimal string. It is empty if staking is disabled.
+	StakePowerUnit string `json:"stake_power_unit,omitempty"`
+	// UnbondingBlocks is the number of blocks before undelegated stake is
+	// returned.
+	UnbondingBlocks int64 `json:"unbonding_blocks"`
+	// StakeBlockReward is the amount distributed to stakers each block, as a
+	// decimal string. It is empty if there are no rewards.
+	StakeBlockReward string `json:"stake_block_reward,omitempty"`
+	// FeeLeaderPercent is the percentage of a block's fees credited to the
+	// block proposer.
+	FeeLeaderPercent int64 `json:"fee_leader_percent"`
+	// FeeValidatorPercent is the percentage of a block's fees distributed to
+	// the validators.
+	FeeValidatorPercent int64 `json:"fee_validator_percent"`
+	// FeeTreasuryPercent is the percentage of a block's fees credited to the
+	// treasury account.
+	FeeTreasuryPercent int64 `json:"fee_treasury_percent"`
+	// Treasury is the treasury account as id#keyType. It is empty if there is
+	// no treasury.
+	Treasury string `json:"treasury,omitempty"`
 }
 
 // NamedTx pairs a transaction hash with the transaction itself. This is done
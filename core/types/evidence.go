@@ -0,0 +1,274 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+)
+
+// EvidenceType is the kind of misbehavior proven by an Evidence.
+type EvidenceType string
+
+const (
+	// EvidenceDuplicateProposal proves that a leader signed two different
+	// block proposals with the same height and timestamp. A leader that
+	// re-proposes a block for the same height, such as after a reset, always
+	// uses a newer timestamp, so this never happens with an honest leader.
+	EvidenceDuplicateProposal EvidenceType = "duplicate_proposal"
+	// EvidenceDuplicateVote proves that a validator accepted two different
+	// blocks with the same height and timestamp. Validators never accept a
+	// conflicting proposal unless it has a newer timestamp.
+	EvidenceDuplicateVote EvidenceType = "duplicate_vote"
+)
+
+func (et EvidenceType) String() string {
+	return string(et)
+}
+
+// Valid says if the evidence kind is known.
+func (et EvidenceType) Valid() bool {
+	switch et {
+	case EvidenceDuplicateProposal, EvidenceDuplicateVote:
+		return true
+	}
+	return false
+}
+
+// Evidence is a payload that proves a validator equivocated, i.e. signed two
+// conflicting blocks or votes for the same height. Any account may submit
+// evidence, and the offender is penalized according to the network's
+// equivocation parameters.
+type Evidence struct {
+	Kind EvidenceType `json:"kind"`
+	// Offender is the public key of the validator that equivocated.
+	Offender HexBytes       `json:"offender"`
+	KeyType  crypto.KeyType `json:"key_type"`
+
+	// HeaderA and HeaderB are the conflicting block headers. They must have
+	// the same height and timestamp, but different hashes.
+	HeaderA *BlockHeader `json:"header_a"`
+	HeaderB *BlockHeader `json:"header_b"`
+	// SignatureA and SignatureB are the offender's signatures for the
+	// respective headers. For a duplicate proposal, these are the leader's
+	// signatures of the block hashes. For a duplicate vote, these are the
+	// validator's signatures of its ACK votes for the blocks.
+	SignatureA HexBytes `json:"signature_a"`
+	SignatureB HexBytes `json:"signature_b"`
+	// AppHashA and AppHashB are the app hashes that the validator reported in
+	// its ACK votes. They are only used with duplicate vote evidence.
+	AppHashA *Hash `json:"app_hash_a,omitempty"`
+	AppHashB *Hash `json:"app_hash_b,omitempty"`
+}
+
+var _ Payload = (*Evidence)(nil)
+
+func (e Evidence) Type() PayloadType {
+	return PayloadTypeSubmitEvidence
+}
+
+// Height returns the height at which the offender equivocated.
+func (e *Evidence) Height() int64 {
+	if e.HeaderA == nil {
+		return 0
+	}
+	return e.HeaderA.Height
+}
+
+// ID uniquely identifies the offense, regardless of the order of the two
+// conflicting headers, so that the same offense cannot be penalized twice.
+func (e *Evidence) ID() Hash {
+	hashA, hashB := e.HeaderA.Hash(), e.HeaderB.Hash()
+	if bytes.Compare(hashA[:], hashB[:]) > 0 {
+		hashA, hashB = hashB, hashA
+	}
+
+	hasher := NewHasher()
+	hasher.Write([]byte(e.Kind))
+	hasher.Write(e.KeyType.Bytes())
+	hasher.Write(e.Offender)
+	hasher.Write(hashA[:])
+	hasher.Write(hashB[:])
+	return hasher.Sum(nil)
+}
+
+// Verify checks that the evidence proves an equivocation by the offender. It
+// does not check that the offender is a validator.
+func (e *Evidence) Verify() error {
+	if !e.Kind.Valid() {
+		return fmt.Errorf("unknown evidence kind %q", e.Kind)
+	}
+	if e.HeaderA == nil || e.HeaderB == nil {
+		return errors.New("evidence requires two block headers")
+	}
+	if e.HeaderA.Height != e.HeaderB.Height {
+		return errors.New("conflicting headers are for different heights")
+	}
+	if e.HeaderA.Timestamp.UnixMilli() != e.HeaderB.Timestamp.UnixMilli() {
+		return errors.New("conflicting headers have different timestamps")
+	}
+
+	hashA, hashB := e.HeaderA.Hash(), e.HeaderB.Hash()
+	if hashA == hashB {
+		return errors.New("headers do not conflict")
+	}
+
+	pubKey, err := crypto.UnmarshalPublicKey(e.Offender, e.KeyType)
+	if err != nil {
+		return fmt.Errorf("invalid offender public key: %w", err)
+	}
+
+	switch e.Kind {
+	case EvidenceDuplicateProposal:
+		if err = verifyProposalSig(pubKey, hashA, e.SignatureA); err != nil {
+			return err
+		}
+		return verifyProposalSig(pubKey, hashB, e.SignatureB)
+	default: // EvidenceDuplicateVote
+		if e.AppHashA == nil || e.AppHashB == nil {
+			return errors.New("duplicate vote evidence requires the app hashes")
+		}
+		if err = e.verifyVoteSig(hashA, *e.AppHashA, e.SignatureA); err != nil {
+			return err
+		}
+		return e.verifyVoteSig(hashB, *e.AppHashB, e.SignatureB)
+	}
+}
+
+func verifyProposalSig(pubKey crypto.PublicKey, blkHash Hash, sig []byte) error {
+	valid, err := pubKey.Verify(blkHash[:], sig)
+	if err != nil {
+		return fmt.Errorf("failed to verify proposal signature: %w", err)
+	}
+	if !valid {
+		return errors.New("invalid proposal signature")
+	}
+	return nil
+}
+
+func (e *Evidence) verifyVoteSig(blkHash, appHash Hash, sig []byte) error {
+	vote := &VoteInfo{
+		AckStatus: AckAgree,
+		Signature: Signature{
+			PubKeyType: e.KeyType,
+			PubKey:     e.Offender,
+			Data:       sig,
+		},
+	}
+	return vote.Verify(blkHash, appHash)
+}
+
+const evidenceVersion = 0
+
+func (e Evidence) MarshalBinary() ([]byte, error) {
+	if e.HeaderA == nil || e.HeaderB == nil {
+		return nil, errors.New("evidence requires two block headers")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, SerializationByteOrder, uint16(evidenceVersion)); err != nil {
+		return nil, err
+	}
+	if err := WriteString(buf, string(e.Kind)); err != nil {
+		return nil, err
+	}
+	if err := WriteCompactBytes(buf, EmptyIfNil(e.Offender)); err != nil {
+		return nil, err
+	}
+	if _, err := e.KeyType.WriteTo(buf); err != nil {
+		return nil, err
+	}
+
+	for _, side := range []struct {
+		hdr     *BlockHeader
+		sig     []byte
+		appHash *Hash
+	}{
+		{e.HeaderA, e.SignatureA, e.AppHashA},
+		{e.HeaderB, e.SignatureB, e.AppHashB},
+	} {
+		if err := WriteCompactBytes(buf, EncodeBlockHeader(side.hdr)); err != nil {
+			return nil, err
+		}
+		if err := WriteCompactBytes(buf, EmptyIfNil(side.sig)); err != nil {
+			return nil, err
+		}
+		var appHash []byte
+		if side.appHash != nil {
+			appHash = side.appHash[:]
+		}
+		if err := WriteCompactBytes(buf, EmptyIfNil(appHash)); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (e *Evidence) UnmarshalBinary(b []byte) error {
+	rd := bytes.NewReader(b)
+
+	var version uint16
+	if err := binary.Read(rd, SerializationByteOrder, &version); err != nil {
+		return err
+	}
+	if version != evidenceVersion {
+		return fmt.Errorf("unsupported evidence payload version %d", version)
+	}
+
+	evType, err := ReadString(rd)
+	if err != nil {
+		return fmt.Errorf("failed to read evidence kind: %w", err)
+	}
+	e.Kind = EvidenceType(evType)
+
+	if e.Offender, err = ReadCompactBytes(rd); err != nil {
+		return fmt.Errorf("failed to read offender: %w", err)
+	}
+	if _, err = e.KeyType.ReadFrom(rd); err != nil {
+		return fmt.Errorf("failed to read offender key type: %w", err)
+	}
+
+	readSide := func() (*BlockHeader, []byte, *Hash, error) {
+		hdrBts, err := ReadCompactBytes(rd)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to read header: %w", err)
+		}
+		hdr, err := DecodeBlockHeader(bytes.NewReader(hdrBts))
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		sig, err := ReadCompactBytes(rd)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to read signature: %w", err)
+		}
+		appHashBts, err := ReadCompactBytes(rd)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("failed to read app hash: %w", err)
+		}
+		if len(appHashBts) == 0 {
+			return hdr, sig, nil, nil
+		}
+		if len(appHashBts) != HashLen {
+			return nil, nil, nil, fmt.Errorf("invalid app hash length %d", len(appHashBts))
+		}
+		var appHash Hash
+		copy(appHash[:], appHashBts)
+		return hdr, sig, &appHash, nil
+	}
+
+	if e.HeaderA, e.SignatureA, e.AppHashA, err = readSide(); err != nil {
+		return err
+	}
+	if e.HeaderB, e.SignatureB, e.AppHashB, err = readSide(); err != nil {
+		return err
+	}
+
+	if rd.Len() != 0 {
+		return errors.New("extra evidence data")
+	}
+
+	return nil
+}
@@ -0,0 +1,166 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+)
+
+func TestEvidence_Verify(t *testing.T) {
+	priv, pub, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	_, otherPub, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+
+	stamp := time.Now().Truncate(time.Millisecond).UTC()
+	blkA := NewBlock(10, Hash{1}, Hash{2}, Hash{3}, Hash{4}, stamp, nil)
+	blkB := NewBlock(10, Hash{5}, Hash{2}, Hash{3}, Hash{4}, stamp, nil)
+	require.NoError(t, blkA.Sign(priv))
+	require.NoError(t, blkB.Sign(priv))
+
+	proposal := func() *Evidence {
+		return &Evidence{
+			Kind:       EvidenceDuplicateProposal,
+			Offender:   pub.Bytes(),
+			KeyType:    pub.Type(),
+			HeaderA:    blkA.Header,
+			HeaderB:    blkB.Header,
+			SignatureA: blkA.Signature,
+			SignatureB: blkB.Signature,
+		}
+	}
+
+	appHash := Hash{9}
+	sigA, err := SignVote(blkA.Hash(), true, &appHash, priv)
+	require.NoError(t, err)
+	sigB, err := SignVote(blkB.Hash(), true, &appHash, priv)
+	require.NoError(t, err)
+
+	vote := func() *Evidence {
+		return &Evidence{
+			Kind:       EvidenceDuplicateVote,
+			Offender:   pub.Bytes(),
+			KeyType:    pub.Type(),
+			HeaderA:    blkA.Header,
+			HeaderB:    blkB.Header,
+			SignatureA: sigA.Data,
+			SignatureB: sigB.Data,
+			AppHashA:   &appHash,
+			AppHashB:   &appHash,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		ev      func() *Evidence
+		wantErr bool
+	}{
+		{"duplicate proposal", proposal, false},
+		{"duplicate vote", vote, false},
+		{"unknown kind", func() *Evidence {
+			ev := proposal()
+			ev.Kind = "bogus"
+			return ev
+		}, true},
+		{"same header", func() *Evidence {
+			ev := proposal()
+			ev.HeaderB, ev.SignatureB = ev.HeaderA, ev.SignatureA
+			return ev
+		}, true},
+		{"different heights", func() *Evidence {
+			ev := proposal()
+			hdr := *blkB.Header
+			hdr.Height++
+			ev.HeaderB = &hdr
+			return ev
+		}, true},
+		{"different timestamps", func() *Evidence {
+			ev := proposal()
+			hdr := *blkB.Header
+			hdr.Timestamp = hdr.Timestamp.Add(time.Millisecond)
+			ev.HeaderB = &hdr
+			return ev
+		}, true},
+		{"wrong offender", func() *Evidence {
+			ev := proposal()
+			ev.Offender = otherPub.Bytes()
+			return ev
+		}, true},
+		{"proposal signature used as vote", func() *Evidence {
+			ev := vote()
+			ev.SignatureA = blkA.Signature
+			return ev
+		}, true},
+		{"vote missing app hash", func() *Evidence {
+			ev := vote()
+			ev.AppHashB = nil
+			return ev
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.ev().Verify()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestEvidence_MarshalUnmarshal(t *testing.T) {
+	priv, pub, err := crypto.GenerateEd25519Key(nil)
+	require.NoError(t, err)
+
+	stamp := time.UnixMilli(1700000000000).UTC()
+	blkA := NewBlock(3, Hash{1}, Hash{2}, Hash{3}, Hash{4}, stamp, nil)
+	blkB := NewBlock(3, Hash{1}, Hash{2}, Hash{3}, Hash{5}, stamp, nil)
+	appHashA, appHashB := Hash{6}, Hash{7}
+	sigA, err := SignVote(blkA.Hash(), true, &appHashA, priv)
+	require.NoError(t, err)
+	sigB, err := SignVote(blkB.Hash(), true, &appHashB, priv)
+	require.NoError(t, err)
+
+	ev := &Evidence{
+		Kind:       EvidenceDuplicateVote,
+		Offender:   pub.Bytes(),
+		KeyType:    pub.Type(),
+		HeaderA:    blkA.Header,
+		HeaderB:    blkB.Header,
+		SignatureA: sigA.Data,
+		SignatureB: sigB.Data,
+		AppHashA:   &appHashA,
+		AppHashB:   &appHashB,
+	}
+
+	bts, err := ev.MarshalBinary()
+	require.NoError(t, err)
+
+	var ev2 Evidence
+	require.NoError(t, ev2.UnmarshalBinary(bts))
+	require.NoError(t, ev2.Verify())
+	assert.Equal(t, ev.ID(), ev2.ID())
+	assert.Equal(t, *ev.AppHashB, *ev2.AppHashB)
+
+	// the ID does not depend on the order of the headers
+	swapped := ev2
+	swapped.HeaderA, swapped.HeaderB = ev2.HeaderB, ev2.HeaderA
+	assert.Equal(t, ev.ID(), swapped.ID())
+
+	// proposal evidence has no app hashes
+	ev.Kind, ev.AppHashA, ev.AppHashB = EvidenceDuplicateProposal, nil, nil
+	bts, err = ev.MarshalBinary()
+	require.NoError(t, err)
+	require.NoError(t, ev2.UnmarshalBinary(bts))
+	assert.Nil(t, ev2.AppHashA)
+	assert.Nil(t, ev2.AppHashB)
+
+	err = ev2.UnmarshalBinary(append(bts, 0))
+	assert.Error(t, err)
+}
@@ -0,0 +1,27 @@
+package types
+
+import "math/big"
+
+// FeeDistribution is the accounting of the transaction fees collected in a
+// block, and how they were distributed according to the fee network
+// parameters. The amounts that were not credited to an account are burned.
+type FeeDistribution struct {
+	Height     int64    `json:"height"`
+	Collected  *big.Int `json:"collected"`
+	Leader     *big.Int `json:"leader"`
+	Validators *big.Int `json:"validators"`
+	Treasury   *big.Int `json:"treasury"`
+	Burned     *big.Int `json:"burned"`
+}
+
+// NewFeeDistribution creates a FeeDistribution for a block with zero amounts.
+func NewFeeDistribution(height int64) *FeeDistribution {
+	return &FeeDistribution{
+		Height:     height,
+		Collected:  new(big.Int),
+		Leader:     new(big.Int),
+		Validators: new(big.Int),
+		Treasury:   new(big.Int),
+		Burned:     new(big.Int),
+	}
+}
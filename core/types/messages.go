@@ -20,6 +20,7 @@ type BlockExecResult struct {
 	AppHash          Hash
 	ValidatorUpdates []*Validator
 	ParamUpdates     ParamUpdates
+	Fees             *FeeDistribution // nil if no fees were collected
 }
 
 type CommitRequest struct {
@@ -7,6 +7,8 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
+	"math/big"
 	"reflect"
 	"sort"
 
@@ -83,6 +85,10 @@ func (pk *PublicKey) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// MaxNonceWindow is the largest allowed NonceWindow. It bounds the number of
+// skipped nonces that are recorded for an account.
+const MaxNonceWindow = 1024
+
 // NetworkParameters are network level configurations that can be evolved over
 // the lifetime of a network. Fields that should not (un)marshal as part of the
 // genesis.json file should contain the `json:"-"` tag.
@@ -109,6 +115,67 @@ type NetworkParameters struct {
 	// MaxVotesPerTx is the maximum number of votes allowed in a single transaction.
 	MaxVotesPerTx int64 `json:"max_votes_per_tx"`
 
+	// NonceWindow is the number of nonces that an account's transaction may
+	// skip past the next sequential nonce. The skipped nonces remain usable, in
+	// any order, until they fall more than NonceWindow behind the account's
+	// highest used nonce. This allows a sender to have many transactions in
+	// flight without one stuck transaction blocking the rest. Zero requires
+	// strictly sequential nonces. It may be at most MaxNonceWindow.
+	NonceWindow int64 `json:"nonce_window"`
+
+	// EquivocationJailBlocks is the number of blocks for which a validator is
+	// removed from the validator set after evidence that it signed conflicting
+	// blocks or votes is committed. Zero disables jailing.
+	EquivocationJailBlocks int64 `json:"equivocation_jail_blocks"`
+
+	// EquivocationSlashPercent is the percentage of an equivocating validator's
+	// power that is permanently removed.
+	EquivocationSlashPercent int64 `json:"equivocation_slash_percent"`
+
+	// EquivocationBurnPercent is the percentage of an equivocating validator's
+	// account balance that is burned. Zero disables burning.
+	EquivocationBurnPercent int64 `json:"equivocation_burn_percent"`
+
+	// MaxEvidenceAge is the number of blocks after an equivocation during
+	// which evidence of it may be submitted. Older evidence is rejected, so
+	// that a validator is not penalized long after the offense, and the power
+	// at the offense height need not be kept forever. Zero allows evidence of
+	// any age.
+	MaxEvidenceAge int64 `json:"max_evidence_age"`
+
+	// StakePowerUnit is the amount of bonded stake that gives a validator one
+	// unit of power. When set, a validator's power is its total bonded stake,
+	// from itself and its delegators, divided by this amount. Nil or zero
+	// disables staking.
+	StakePowerUnit *big.Int `json:"stake_power_unit"`
+
+	// UnbondingBlocks is the number of blocks after an undelegation before the
+	// unbonded stake is returned to the delegator's account.
+	UnbondingBlocks int64 `json:"unbonding_blocks"`
+
+	// StakeBlockReward is the amount minted each block and distributed to the
+	// accounts that have bonded stake to active validators, in proportion to
+	// their stake. Nil or zero disables rewards.
+	StakeBlockReward *big.Int `json:"stake_block_reward"`
+
+	// FeeLeaderPercent is the percentage of the transaction fees collected in
+	// a block that is credited to the block's proposer.
+	FeeLeaderPercent int64 `json:"fee_leader_percent"`
+
+	// FeeValidatorPercent is the percentage of the transaction fees collected
+	// in a block that is distributed to the active validators in proportion to
+	// their power, or to their stakers when staking is enabled.
+	FeeValidatorPercent int64 `json:"fee_validator_percent"`
+
+	// FeeTreasuryPercent is the percentage of the transaction fees collected
+	// in a block that is credited to the Treasury account. Fees that are not
+	// distributed by the fee percentages are burned.
+	FeeTreasuryPercent int64 `json:"fee_treasury_percent"`
+
+	// Treasury is the account that receives the treasury share of the fees.
+	// If it is nil, the treasury share is burned.
+	Treasury *AccountID `json:"treasury"`
+
 	// MigrationStatus is the status of the migration to the new network. This
 	// is not configurable, but is mutable and used to track the status of the
 	// migration on nodes of the old network. The "param" tag is used since json
@@ -133,15 +200,27 @@ type ParamName = string
 
 // The ParamName values correspond to the fields of the NetworkParameters struct.
 var (
-	ParamNameLeader           ParamName
-	ParamNameMaxBlockSize     ParamName
-	ParamNameJoinExpiry       ParamName
-	ParamNameDisabledGasCosts ParamName
-	ParamNameMaxVotesPerTx    ParamName
-	ParamNameMigrationStatus  ParamName
+	ParamNameLeader                   ParamName
+	ParamNameMaxBlockSize             ParamName
+	ParamNameJoinExpiry               ParamName
+	ParamNameDisabledGasCosts         ParamName
+	ParamNameMaxVotesPerTx            ParamName
+	ParamNameNonceWindow              ParamName
+	ParamNameEquivocationJailBlocks   ParamName
+	ParamNameEquivocationSlashPercent ParamName
+	ParamNameEquivocationBurnPercent  ParamName
+	ParamNameMaxEvidenceAge           ParamName
+	ParamNameStakePowerUnit           ParamName
+	ParamNameUnbondingBlocks          ParamName
+	ParamNameStakeBlockReward         ParamName
+	ParamNameFeeLeaderPercent         ParamName
+	ParamNameFeeValidatorPercent      ParamName
+	ParamNameFeeTreasuryPercent       ParamName
+	ParamNameTreasury                 ParamName
+	ParamNameMigrationStatus          ParamName
 )
 
-const numParams = 6
+const numParams = 18
 
 // setParamNames sets the ParamName constants based on the json tags of a struct
 // (intended for NetworkParameters, but any for unit testing). This looks crazy,
@@ -173,6 +252,30 @@ func setParamNames(np any) {
 			ParamNameDisabledGasCosts = fieldTag
 		case "MaxVotesPerTx":
 			ParamNameMaxVotesPerTx = fieldTag
+		case "NonceWindow":
+			ParamNameNonceWindow = fieldTag
+		case "EquivocationJailBlocks":
+			ParamNameEquivocationJailBlocks = fieldTag
+		case "EquivocationSlashPercent":
+			ParamNameEquivocationSlashPercent = fieldTag
+		case "EquivocationBurnPercent":
+			ParamNameEquivocationBurnPercent = fieldTag
+		case "MaxEvidenceAge":
+			ParamNameMaxEvidenceAge = fieldTag
+		case "StakePowerUnit":
+			ParamNameStakePowerUnit = fieldTag
+		case "UnbondingBlocks":
+			ParamNameUnbondingBlocks = fieldTag
+		case "StakeBlockReward":
+			ParamNameStakeBlockReward = fieldTag
+		case "FeeLeaderPercent":
+			ParamNameFeeLeaderPercent = fieldTag
+		case "FeeValidatorPercent":
+			ParamNameFeeValidatorPercent = fieldTag
+		case "FeeTreasuryPercent":
+			ParamNameFeeTreasuryPercent = fieldTag
+		case "Treasury":
+			ParamNameTreasury = fieldTag
 		case "MigrationStatus":
 			ParamNameMigrationStatus = fieldTag
 		default:
@@ -214,6 +317,34 @@ func MergeUpdates(np *NetworkParameters, updates ParamUpdates) (err error) {
 			np.DisabledGasCosts = update.(bool)
 		case ParamNameMaxVotesPerTx:
 			np.MaxVotesPerTx = update.(int64)
+		case ParamNameNonceWindow:
+			window := update.(int64)
+			if window < 0 || window > MaxNonceWindow {
+				return fmt.Errorf("nonce window should be between 0 and %d", MaxNonceWindow)
+			}
+			np.NonceWindow = window
+		case ParamNameEquivocationJailBlocks:
+			np.EquivocationJailBlocks = update.(int64)
+		case ParamNameEquivocationSlashPercent:
+			np.EquivocationSlashPercent = update.(int64)
+		case ParamNameEquivocationBurnPercent:
+			np.EquivocationBurnPercent = update.(int64)
+		case ParamNameMaxEvidenceAge:
+			np.MaxEvidenceAge = update.(int64)
+		case ParamNameStakePowerUnit:
+			np.StakePowerUnit = update.(*big.Int)
+		case ParamNameUnbondingBlocks:
+			np.UnbondingBlocks = update.(int64)
+		case ParamNameStakeBlockReward:
+			np.StakeBlockReward = update.(*big.Int)
+		case ParamNameFeeLeaderPercent:
+			np.FeeLeaderPercent = update.(int64)
+		case ParamNameFeeValidatorPercent:
+			np.FeeValidatorPercent = update.(int64)
+		case ParamNameFeeTreasuryPercent:
+			np.FeeTreasuryPercent = update.(int64)
+		case ParamNameTreasury:
+			np.Treasury = update.(*AccountID)
 		case ParamNameMigrationStatus:
 			np.MigrationStatus = update.(MigrationStatus)
 		default:
@@ -339,7 +470,9 @@ func (pu ParamUpdates) MarshalBinary() ([]byte, error) {
 			} else {
 				return nil, fmt.Errorf("invalid type for %s", key)
 			}
-		case ParamNameMaxBlockSize, ParamNameMaxVotesPerTx:
+		case ParamNameMaxBlockSize, ParamNameMaxVotesPerTx, ParamNameNonceWindow,
+			ParamNameEquivocationJailBlocks, ParamNameEquivocationSlashPercent, ParamNameEquivocationBurnPercent, ParamNameMaxEvidenceAge,
+			ParamNameUnbondingBlocks, ParamNameFeeLeaderPercent, ParamNameFeeValidatorPercent, ParamNameFeeTreasuryPercent:
 			if val, ok := value.(int64); ok {
 				if err := binary.Write(buf, binary.LittleEndian, val); err != nil {
 					return nil, err
@@ -347,6 +480,39 @@ func (pu ParamUpdates) MarshalBinary() ([]byte, error) {
 			} else {
 				return nil, fmt.Errorf("invalid type for %s", key)
 			}
+		case ParamNameStakePowerUnit, ParamNameStakeBlockReward:
+			val, ok := value.(*big.Int)
+			if !ok {
+				return nil, fmt.Errorf("invalid type for %s", key)
+			}
+			var amtBts []byte // nil is encoded as an empty string
+			if val != nil {
+				amtBts = []byte(val.String())
+			}
+			if err := binary.Write(buf, binary.LittleEndian, uint16(len(amtBts))); err != nil {
+				return nil, err
+			}
+			if _, err := buf.Write(amtBts); err != nil {
+				return nil, err
+			}
+		case ParamNameTreasury:
+			val, ok := value.(*AccountID)
+			if !ok {
+				return nil, fmt.Errorf("invalid type for %s", key)
+			}
+			var acctBts []byte // nil is encoded as an empty string
+			if val != nil {
+				var err error
+				if acctBts, err = val.MarshalBinary(); err != nil {
+					return nil, err
+				}
+			}
+			if err := binary.Write(buf, binary.LittleEndian, uint16(len(acctBts))); err != nil {
+				return nil, err
+			}
+			if _, err := buf.Write(acctBts); err != nil {
+				return nil, err
+			}
 		case ParamNameMigrationStatus:
 			if val, ok := value.(MigrationStatus); ok {
 				statusBts := []byte(val)
@@ -423,7 +589,9 @@ func (pu *ParamUpdates) UnmarshalBinary(data []byte) error {
 				return err
 			}
 			updates[paramName] = expiry
-		case ParamNameMaxBlockSize, ParamNameMaxVotesPerTx:
+		case ParamNameMaxBlockSize, ParamNameMaxVotesPerTx, ParamNameNonceWindow,
+			ParamNameEquivocationJailBlocks, ParamNameEquivocationSlashPercent, ParamNameEquivocationBurnPercent, ParamNameMaxEvidenceAge,
+			ParamNameUnbondingBlocks, ParamNameFeeLeaderPercent, ParamNameFeeValidatorPercent, ParamNameFeeTreasuryPercent:
 			var val int64
 			if err := binary.Read(buf, binary.LittleEndian, &val); err != nil {
 				return err
@@ -435,6 +603,41 @@ func (pu *ParamUpdates) UnmarshalBinary(data []byte) error {
 				return err
 			}
 			updates[paramName] = val == 1
+		case ParamNameStakePowerUnit, ParamNameStakeBlockReward:
+			var length uint16
+			if err := binary.Read(buf, binary.LittleEndian, &length); err != nil {
+				return err
+			}
+			val := make([]byte, length)
+			if _, err := io.ReadFull(buf, val); err != nil {
+				return err
+			}
+			var amt *big.Int
+			if length > 0 {
+				var ok bool
+				amt, ok = new(big.Int).SetString(string(val), 10)
+				if !ok {
+					return fmt.Errorf("invalid amount for %s", paramName)
+				}
+			}
+			updates[paramName] = amt
+		case ParamNameTreasury:
+			var length uint16
+			if err := binary.Read(buf, binary.LittleEndian, &length); err != nil {
+				return err
+			}
+			val := make([]byte, length)
+			if _, err := io.ReadFull(buf, val); err != nil {
+				return err
+			}
+			var acct *AccountID
+			if length > 0 {
+				acct = &AccountID{}
+				if err := acct.UnmarshalBinary(val); err != nil {
+					return fmt.Errorf("invalid account for %s: %w", paramName, err)
+				}
+			}
+			updates[paramName] = acct
 		case ParamNameMigrationStatus:
 			var length uint16
 			if err := binary.Read(buf, binary.LittleEndian, &length); err != nil {
@@ -479,13 +682,30 @@ func (pu *ParamUpdates) UnmarshalJSON(b []byte) error {
 			pu0[pn] = pk
 
 		// the int64 params
-		case ParamNameMaxBlockSize, ParamNameJoinExpiry, ParamNameMaxVotesPerTx:
+		case ParamNameMaxBlockSize, ParamNameJoinExpiry, ParamNameMaxVotesPerTx, ParamNameNonceWindow,
+			ParamNameEquivocationJailBlocks, ParamNameEquivocationSlashPercent, ParamNameEquivocationBurnPercent, ParamNameMaxEvidenceAge,
+			ParamNameUnbondingBlocks, ParamNameFeeLeaderPercent, ParamNameFeeValidatorPercent, ParamNameFeeTreasuryPercent:
 			var i int64
 			if err := json.Unmarshal(v, &i); err != nil {
 				return err
 			}
 			pu0[pn] = i
 
+		// the amount params
+		case ParamNameStakePowerUnit, ParamNameStakeBlockReward:
+			amt := new(big.Int)
+			if err := json.Unmarshal(v, amt); err != nil {
+				return err
+			}
+			pu0[pn] = amt
+
+		case ParamNameTreasury:
+			acct := &AccountID{}
+			if err := json.Unmarshal(v, acct); err != nil {
+				return err
+			}
+			pu0[pn] = acct
+
 		case ParamNameMigrationStatus:
 			var ms MigrationStatus
 			if err := json.Unmarshal(v, &ms); err != nil {
@@ -512,12 +732,24 @@ func (pu *ParamUpdates) UnmarshalJSON(b []byte) error {
 func (np NetworkParameters) ToMap() map[ParamName]any {
 	// Create a map using ParamNames as keys.
 	return map[ParamName]any{
-		ParamNameLeader:           np.Leader,
-		ParamNameMaxBlockSize:     np.MaxBlockSize,
-		ParamNameJoinExpiry:       np.JoinExpiry,
-		ParamNameDisabledGasCosts: np.DisabledGasCosts,
-		ParamNameMaxVotesPerTx:    np.MaxVotesPerTx,
-		ParamNameMigrationStatus:  np.MigrationStatus,
+		ParamNameLeader:                   np.Leader,
+		ParamNameMaxBlockSize:             np.MaxBlockSize,
+		ParamNameJoinExpiry:               np.JoinExpiry,
+		ParamNameDisabledGasCosts:         np.DisabledGasCosts,
+		ParamNameMaxVotesPerTx:            np.MaxVotesPerTx,
+		ParamNameNonceWindow:              np.NonceWindow,
+		ParamNameEquivocationJailBlocks:   np.EquivocationJailBlocks,
+		ParamNameEquivocationSlashPercent: np.EquivocationSlashPercent,
+		ParamNameEquivocationBurnPercent:  np.EquivocationBurnPercent,
+		ParamNameMaxEvidenceAge:           np.MaxEvidenceAge,
+		ParamNameStakePowerUnit:           np.StakePowerUnit,
+		ParamNameUnbondingBlocks:          np.UnbondingBlocks,
+		ParamNameStakeBlockReward:         np.StakeBlockReward,
+		ParamNameFeeLeaderPercent:         np.FeeLeaderPercent,
+		ParamNameFeeValidatorPercent:      np.FeeValidatorPercent,
+		ParamNameFeeTreasuryPercent:       np.FeeTreasuryPercent,
+		ParamNameTreasury:                 np.Treasury,
+		ParamNameMigrationStatus:          np.MigrationStatus,
 	}
 }
 
@@ -572,9 +804,45 @@ func (np *NetworkParameters) Equals(other *NetworkParameters) bool {
 		np.JoinExpiry == other.JoinExpiry &&
 		np.DisabledGasCosts == other.DisabledGasCosts &&
 		np.MaxVotesPerTx == other.MaxVotesPerTx &&
+		np.NonceWindow == other.NonceWindow &&
+		np.EquivocationJailBlocks == other.EquivocationJailBlocks &&
+		np.EquivocationSlashPercent == other.EquivocationSlashPercent &&
+		np.EquivocationBurnPercent == other.EquivocationBurnPercent &&
+		np.MaxEvidenceAge == other.MaxEvidenceAge &&
+		amountsEqual(np.StakePowerUnit, other.StakePowerUnit) &&
+		np.UnbondingBlocks == other.UnbondingBlocks &&
+		amountsEqual(np.StakeBlockReward, other.StakeBlockReward) &&
+		np.FeeLeaderPercent == other.FeeLeaderPercent &&
+		np.FeeValidatorPercent == other.FeeValidatorPercent &&
+		np.FeeTreasuryPercent == other.FeeTreasuryPercent &&
+		accountsEqual(np.Treasury, other.Treasury) &&
 		np.MigrationStatus == other.MigrationStatus
 }
 
+// amountsEqual compares two amount parameters, treating nil as zero.
+func amountsEqual(a, b *big.Int) bool {
+	if a == nil {
+		a = new(big.Int)
+	}
+	if b == nil {
+		b = new(big.Int)
+	}
+	return a.Cmp(b) == 0
+}
+
+// accountsEqual compares two account parameters, which may be nil.
+func accountsEqual(a, b *AccountID) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.Equals(b)
+}
+
+// StakingEnabled indicates if validator power is derived from bonded stake.
+func (np *NetworkParameters) StakingEnabled() bool {
+	return np.StakePowerUnit != nil && np.StakePowerUnit.Sign() > 0
+}
+
 func (np *NetworkParameters) SanityChecks() error {
 	// Leader shouldn't be empty
 	if np.Leader.PublicKey == nil || len(np.Leader.Bytes()) == 0 {
@@ -586,6 +854,47 @@ func (np *NetworkParameters) SanityChecks() error {
 		return errors.New("max votes per tx should be greater than 0")
 	}
 
+	if np.NonceWindow < 0 || np.NonceWindow > MaxNonceWindow {
+		return fmt.Errorf("nonce window should be between 0 and %d", MaxNonceWindow)
+	}
+
+	if np.EquivocationJailBlocks < 0 {
+		return errors.New("equivocation jail blocks should not be negative")
+	}
+
+	if np.EquivocationSlashPercent < 0 || np.EquivocationSlashPercent > 100 {
+		return errors.New("equivocation slash percent should be between 0 and 100")
+	}
+
+	if np.EquivocationBurnPercent < 0 || np.EquivocationBurnPercent > 100 {
+		return errors.New("equivocation burn percent should be between 0 and 100")
+	}
+
+	if np.MaxEvidenceAge < 0 {
+		return errors.New("max evidence age should not be negative")
+	}
+
+	if np.StakePowerUnit != nil && np.StakePowerUnit.Sign() < 0 {
+		return errors.New("stake power unit should not be negative")
+	}
+
+	if np.UnbondingBlocks < 0 {
+		return errors.New("unbonding blocks should not be negative")
+	}
+
+	if np.StakeBlockReward != nil && np.StakeBlockReward.Sign() < 0 {
+		return errors.New("stake block reward should not be negative")
+	}
+
+	for _, pct := range []int64{np.FeeLeaderPercent, np.FeeValidatorPercent, np.FeeTreasuryPercent} {
+		if pct < 0 || pct > 100 {
+			return errors.New("fee percents should be between 0 and 100")
+		}
+	}
+	if np.FeeLeaderPercent+np.FeeValidatorPercent+np.FeeTreasuryPercent > 100 {
+		return errors.New("fee percents should not total more than 100")
+	}
+
 	// join expiry shouldn't be 0
 	if np.JoinExpiry == 0 {
 		return errors.New("join expiry should be greater than 0")
@@ -606,9 +915,25 @@ func (np NetworkParameters) String() string {
 	Join Expiry: %d
 	Disabled Gas Costs: %t
 	Max Votes Per Tx: %d
+	Nonce Window: %d
+	Equivocation Jail Blocks: %d
+	Equivocation Slash Percent: %d
+	Equivocation Burn Percent: %d
+	Max Evidence Age: %d
+	Stake Power Unit: %v
+	Unbonding Blocks: %d
+	Stake Block Reward: %v
+	Fee Leader Percent: %d
+	Fee Validator Percent: %d
+	Fee Treasury Percent: %d
+	Treasury: %v
 	Migration Status: %s`,
 		&np.Leader, np.MaxBlockSize, np.JoinExpiry,
-		np.DisabledGasCosts, np.MaxVotesPerTx, np.MigrationStatus)
+		np.DisabledGasCosts, np.MaxVotesPerTx, np.NonceWindow,
+		np.EquivocationJailBlocks, np.EquivocationSlashPercent, np.EquivocationBurnPercent, np.MaxEvidenceAge,
+		np.StakePowerUnit, np.UnbondingBlocks, np.StakeBlockReward,
+		np.FeeLeaderPercent, np.FeeValidatorPercent, np.FeeTreasuryPercent, treasuryString(np.Treasury),
+		np.MigrationStatus)
 }
 
 func (np *NetworkParameters) Hash() Hash {
@@ -622,7 +947,50 @@ func (np *NetworkParameters) Hash() Hash {
 	binary.Write(hasher, SerializationByteOrder, np.JoinExpiry)
 	binary.Write(hasher, SerializationByteOrder, np.DisabledGasCosts)
 	binary.Write(hasher, SerializationByteOrder, np.MaxVotesPerTx)
+	if np.NonceWindow != 0 { // preserve the hash of networks that predate it
+		binary.Write(hasher, SerializationByteOrder, np.NonceWindow)
+	}
+	if np.EquivocationJailBlocks != 0 || np.EquivocationSlashPercent != 0 || np.EquivocationBurnPercent != 0 {
+		binary.Write(hasher, SerializationByteOrder, np.EquivocationJailBlocks)
+		binary.Write(hasher, SerializationByteOrder, np.EquivocationSlashPercent)
+		binary.Write(hasher, SerializationByteOrder, np.EquivocationBurnPercent)
+	}
+	if np.MaxEvidenceAge != 0 {
+		binary.Write(hasher, SerializationByteOrder, np.MaxEvidenceAge)
+	}
+	if !amountsEqual(np.StakePowerUnit, nil) || np.UnbondingBlocks != 0 || !amountsEqual(np.StakeBlockReward, nil) {
+		hasher.Write([]byte(amountString(np.StakePowerUnit)))
+		binary.Write(hasher, SerializationByteOrder, np.UnbondingBlocks)
+		hasher.Write([]byte(amountString(np.StakeBlockReward)))
+	}
+	if np.FeeLeaderPercent != 0 || np.FeeValidatorPercent != 0 || np.FeeTreasuryPercent != 0 || np.Treasury != nil {
+		binary.Write(hasher, SerializationByteOrder, np.FeeLeaderPercent)
+		binary.Write(hasher, SerializationByteOrder, np.FeeValidatorPercent)
+		binary.Write(hasher, SerializationByteOrder, np.FeeTreasuryPercent)
+		if np.Treasury == nil {
+			hasher.Write([]byte{0})
+		} else {
+			hasher.Write(np.Treasury.Bytes())
+		}
+	}
 	hasher.Write([]byte(np.MigrationStatus))
 
 	return hasher.Sum(nil)
 }
+
+// amountString returns the decimal string of an amount parameter, with nil as
+// zero.
+func amountString(amt *big.Int) string {
+	if amt == nil {
+		return "0"
+	}
+	return amt.String()
+}
+
+// treasuryString returns the printable treasury account, which may be nil.
+func treasuryString(acct *AccountID) string {
+	if acct == nil {
+		return "<nil>"
+	}
+	return acct.PrettyString()
+}
@@ -3,6 +3,7 @@ package types
 import (
 	"bytes"
 	"encoding/hex"
+	"math/big"
 	"reflect"
 	"testing"
 	"time"
@@ -78,6 +79,12 @@ func TestSetParamNames(t *testing.T) {
 				if ParamNameMaxVotesPerTx != "max_votes_per_tx" {
 					t.Errorf("ParamNameMaxVotesPerTx = %v, want %v", ParamNameMaxVotesPerTx, "max_votes_per_tx")
 				}
+				if ParamNameNonceWindow != "nonce_window" {
+					t.Errorf("ParamNameNonceWindow = %v, want %v", ParamNameNonceWindow, "nonce_window")
+				}
+				if ParamNameEquivocationJailBlocks != "equivocation_jail_blocks" {
+					t.Errorf("ParamNameEquivocationJailBlocks = %v, want %v", ParamNameEquivocationJailBlocks, "equivocation_jail_blocks")
+				}
 				if ParamNameMigrationStatus != "migration_status" {
 					t.Errorf("ParamNameMigrationStatus = %v, want %v", ParamNameMigrationStatus, "migration_status")
 				}
@@ -105,12 +112,24 @@ func TestParamUpdatesMarshalBinary(t *testing.T) {
 		{
 			name: "all parameter types",
 			updates: ParamUpdates{
-				ParamNameLeader:           PublicKey{pub},
-				ParamNameMaxBlockSize:     int64(1000),
-				ParamNameJoinExpiry:       Duration(10 * time.Second),
-				ParamNameDisabledGasCosts: true,
-				ParamNameMaxVotesPerTx:    int64(10),
-				ParamNameMigrationStatus:  MigrationStatus("pending"),
+				ParamNameLeader:                   PublicKey{pub},
+				ParamNameMaxBlockSize:             int64(1000),
+				ParamNameJoinExpiry:               Duration(10 * time.Second),
+				ParamNameDisabledGasCosts:         true,
+				ParamNameMaxVotesPerTx:            int64(10),
+				ParamNameNonceWindow:              int64(64),
+				ParamNameEquivocationJailBlocks:   int64(100),
+				ParamNameEquivocationSlashPercent: int64(10),
+				ParamNameEquivocationBurnPercent:  int64(5),
+				ParamNameMaxEvidenceAge:           int64(1000),
+				ParamNameStakePowerUnit:           big.NewInt(1000),
+				ParamNameUnbondingBlocks:          int64(20),
+				ParamNameStakeBlockReward:         (*big.Int)(nil),
+				ParamNameFeeLeaderPercent:         int64(20),
+				ParamNameFeeValidatorPercent:      int64(50),
+				ParamNameFeeTreasuryPercent:       int64(10),
+				ParamNameTreasury:                 &AccountID{Identifier: []byte{1, 2, 3}, KeyType: crypto.KeyTypeSecp256k1},
+				ParamNameMigrationStatus:          MigrationStatus("pending"),
 			},
 			wantErr: false,
 		},
@@ -316,6 +335,14 @@ func TestMergeUpdates(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "nonce window too large",
+			np:   &NetworkParameters{},
+			updates: ParamUpdates{
+				ParamNameNonceWindow: int64(MaxNonceWindow + 1),
+			},
+			wantErr: true,
+		},
 		{
 			name: "update with empty updates map",
 			np: &NetworkParameters{
@@ -706,6 +733,17 @@ func TestNetworkParametersEquals(t *testing.T) {
 			},
 			expected: false,
 		},
+		{
+			name: "nil and set treasury",
+			np1: &NetworkParameters{
+				Leader: PublicKey{pub0},
+			},
+			np2: &NetworkParameters{
+				Leader:   PublicKey{pub0},
+				Treasury: &AccountID{Identifier: []byte{1}, KeyType: crypto.KeyTypeEd25519},
+			},
+			expected: false,
+		},
 		{
 			name: "different migration status",
 			np1: &NetworkParameters{
@@ -797,6 +835,42 @@ func TestNetworkParametersHash(t *testing.T) {
 				np.MaxVotesPerTx = 20
 			},
 		},
+		{
+			name: "different stake power unit",
+			mutator: func(np *NetworkParameters) {
+				np.StakePowerUnit = big.NewInt(100)
+			},
+		},
+		{
+			name: "different stake block reward",
+			mutator: func(np *NetworkParameters) {
+				np.StakeBlockReward = big.NewInt(5)
+			},
+		},
+		{
+			name: "different fee leader percent",
+			mutator: func(np *NetworkParameters) {
+				np.FeeLeaderPercent = 10
+			},
+		},
+		{
+			name: "different treasury",
+			mutator: func(np *NetworkParameters) {
+				np.Treasury = &AccountID{Identifier: []byte{1}, KeyType: crypto.KeyTypeEd25519}
+			},
+		},
+		{
+			name: "different fee leader percent",
+			mutator: func(np *NetworkParameters) {
+				np.FeeLeaderPercent = 10
+			},
+		},
+		{
+			name: "different treasury",
+			mutator: func(np *NetworkParameters) {
+				np.Treasury = &AccountID{Identifier: []byte{1}, KeyType: crypto.KeyTypeEd25519}
+			},
+		},
 		{
 			name: "different migration status",
 			mutator: func(np *NetworkParameters) {
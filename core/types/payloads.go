@@ -51,6 +51,12 @@ const (
 	PayloadTypeCreateResolution    PayloadType = "create_resolution"
 	PayloadTypeApproveResolution   PayloadType = "approve_resolution"
 	PayloadTypeDeleteResolution    PayloadType = "delete_resolution"
+	PayloadTypeSponsorLimit        PayloadType = "sponsor_limit"
+	PayloadTypeSubmitEvidence      PayloadType = "submit_evidence"
+	PayloadTypeBatch               PayloadType = "batch"
+	PayloadTypeStake               PayloadType = "stake"
+	PayloadTypeDelegate            PayloadType = "delegate"
+	PayloadTypeUndelegate          PayloadType = "undelegate"
 )
 
 // payloadConcreteTypes associates a payload type with the concrete type of
@@ -70,6 +76,12 @@ var payloadConcreteTypes = map[PayloadType]Payload{
 	PayloadTypeCreateResolution:    &CreateResolution{},
 	PayloadTypeApproveResolution:   &ApproveResolution{},
 	// PayloadTypeDeleteResolution:    &DeleteResolution{},
+	PayloadTypeSponsorLimit:   &SponsorLimit{},
+	PayloadTypeSubmitEvidence: &Evidence{},
+	PayloadTypeBatch:          &Batch{},
+	PayloadTypeStake:          &Stake{},
+	PayloadTypeDelegate:       &Delegate{},
+	PayloadTypeUndelegate:     &Undelegate{},
 }
 
 // UnmarshalPayload unmarshals a serialized transaction payload into an instance
@@ -111,6 +123,12 @@ var payloadTypes = map[PayloadType]bool{
 	PayloadTypeCreateResolution:    true,
 	PayloadTypeApproveResolution:   true,
 	PayloadTypeDeleteResolution:    true,
+	PayloadTypeSponsorLimit:        true,
+	PayloadTypeSubmitEvidence:      true,
+	PayloadTypeBatch:               true,
+	PayloadTypeStake:               true,
+	PayloadTypeDelegate:            true,
+	PayloadTypeUndelegate:          true,
 }
 
 // Valid says if the payload type is known. This does not mean that the node
@@ -126,6 +144,12 @@ func (p PayloadType) Valid() bool {
 		PayloadTypeCreateResolution,
 		PayloadTypeApproveResolution,
 		PayloadTypeDeleteResolution,
+		PayloadTypeSponsorLimit,
+		PayloadTypeSubmitEvidence,
+		PayloadTypeBatch,
+		PayloadTypeStake,
+		PayloadTypeDelegate,
+		PayloadTypeUndelegate,
 		PayloadTypeRawStatement,
 		PayloadTypeExecute,
 		// These should not come in user transactions, but they are not invalid
@@ -966,6 +990,157 @@ func (v *Transfer) UnmarshalBinary(b []byte) error {
 	return nil
 }
 
+// SponsorLimit sets the total amount of fees that the sender will pay for
+// transactions that it sponsors. Each sponsored fee is deducted from the
+// remaining limit. A nil Limit removes the limit, so the sponsor pays fees
+// until its balance is exhausted.
+type SponsorLimit struct {
+	Limit *big.Int `json:"limit"`
+}
+
+var _ Payload = (*SponsorLimit)(nil)
+
+func (v SponsorLimit) Type() PayloadType {
+	return PayloadTypeSponsorLimit
+}
+
+// sponsor limit payload version
+const slVersion = 0
+
+func (v SponsorLimit) MarshalBinary() ([]byte, error) {
+	if v.Limit != nil && v.Limit.Sign() < 0 {
+		return nil, errors.New("negative sponsor limit")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, SerializationByteOrder, uint16(slVersion)); err != nil {
+		return nil, err
+	}
+	if err := WriteBigInt(buf, v.Limit); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (v *SponsorLimit) UnmarshalBinary(b []byte) error {
+	rd := bytes.NewReader(b)
+
+	var version uint16
+	if err := binary.Read(rd, SerializationByteOrder, &version); err != nil {
+		return err
+	}
+	if version != slVersion {
+		return fmt.Errorf("unsupported sponsor limit payload version %d", version)
+	}
+
+	limit, err := ReadBigInt(rd)
+	if err != nil {
+		return err
+	}
+	if limit != nil && limit.Sign() < 0 {
+		return errors.New("negative sponsor limit")
+	}
+	v.Limit = limit
+	return nil
+}
+
+// Batch is a list of payloads that are executed in order by one transaction,
+// all or nothing. The transaction's fee is the sum of the fees of the
+// payloads. Only user payloads may be batched: raw statements, action
+// executions, and transfers.
+type Batch struct {
+	Payloads []*BatchPayload `json:"payloads"`
+}
+
+// BatchPayload is one serialized payload of a Batch.
+type BatchPayload struct {
+	Type    PayloadType `json:"type"`
+	Payload []byte      `json:"payload"`
+}
+
+// NewBatchPayload serializes a payload for a Batch.
+func NewBatchPayload(p Payload) (*BatchPayload, error) {
+	bts, err := p.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &BatchPayload{Type: p.Type(), Payload: bts}, nil
+}
+
+var _ Payload = (*Batch)(nil)
+
+func (b Batch) Type() PayloadType {
+	return PayloadTypeBatch
+}
+
+// batch payload version
+const batchVersion = 0
+
+// maxBatchPayloads is the maximum number of payloads in a Batch.
+const maxBatchPayloads = 1000
+
+func (b Batch) MarshalBinary() ([]byte, error) {
+	if len(b.Payloads) > maxBatchPayloads {
+		return nil, fmt.Errorf("too many batch payloads: %d > %d", len(b.Payloads), maxBatchPayloads)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, SerializationByteOrder, uint16(batchVersion)); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(buf, SerializationByteOrder, uint16(len(b.Payloads))); err != nil {
+		return nil, err
+	}
+	for _, p := range b.Payloads {
+		if err := WriteString(buf, p.Type.String()); err != nil {
+			return nil, err
+		}
+		if err := WriteBytes(buf, p.Payload); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+func (b *Batch) UnmarshalBinary(bts []byte) error {
+	rd := bytes.NewReader(bts)
+
+	var version uint16
+	if err := binary.Read(rd, SerializationByteOrder, &version); err != nil {
+		return err
+	}
+	if version != batchVersion {
+		return fmt.Errorf("unsupported batch payload version %d", version)
+	}
+
+	var numPayloads uint16
+	if err := binary.Read(rd, SerializationByteOrder, &numPayloads); err != nil {
+		return err
+	}
+	if numPayloads > maxBatchPayloads {
+		return fmt.Errorf("too many batch payloads: %d > %d", numPayloads, maxBatchPayloads)
+	}
+
+	payloads := make([]*BatchPayload, numPayloads)
+	for i := range payloads {
+		pt, err := ReadString(rd)
+		if err != nil {
+			return err
+		}
+		payload, err := ReadBytes(rd)
+		if err != nil {
+			return err
+		}
+		payloads[i] = &BatchPayload{Type: PayloadType(pt), Payload: payload}
+	}
+	if rd.Len() != 0 {
+		return errors.New("extra data after batch payloads")
+	}
+
+	b.Payloads = payloads
+	return nil
+}
+
 // ValidatorJoin requests to join the network with
 // a certain amount of power
 type ValidatorJoin struct {
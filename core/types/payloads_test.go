@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"math/big"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -1044,3 +1045,45 @@ func TestActionCall_MarshalUnmarshal(t *testing.T) {
 		require.Error(t, err)
 	})
 }
+
+func TestSponsorLimit_MarshalUnmarshal(t *testing.T) {
+	for _, limit := range []*big.Int{nil, big.NewInt(0), big.NewInt(12345)} {
+		bts, err := SponsorLimit{Limit: limit}.MarshalBinary()
+		require.NoError(t, err)
+
+		var sl SponsorLimit
+		require.NoError(t, sl.UnmarshalBinary(bts))
+		assert.Equal(t, limit, sl.Limit)
+	}
+
+	_, err := SponsorLimit{Limit: big.NewInt(-1)}.MarshalBinary()
+	require.Error(t, err)
+
+	assert.True(t, PayloadTypeSponsorLimit.Valid())
+}
+
+func TestBatch_MarshalUnmarshal(t *testing.T) {
+	transfer, err := NewBatchPayload(&Transfer{To: &AccountID{Identifier: []byte{1, 2}, KeyType: crypto.KeyTypeSecp256k1}, Amount: big.NewInt(10)})
+	require.NoError(t, err)
+	raw, err := NewBatchPayload(&RawStatement{Statement: "DELETE FROM t;"})
+	require.NoError(t, err)
+
+	batch := Batch{Payloads: []*BatchPayload{transfer, raw}}
+	bts, err := batch.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded Batch
+	require.NoError(t, decoded.UnmarshalBinary(bts))
+	assert.Equal(t, batch, decoded)
+
+	var tr Transfer
+	require.NoError(t, tr.UnmarshalBinary(decoded.Payloads[0].Payload))
+	assert.Equal(t, big.NewInt(10), tr.Amount)
+
+	require.Error(t, decoded.UnmarshalBinary(append(bts, 0)))
+
+	_, err = Batch{Payloads: make([]*BatchPayload, maxBatchPayloads+1)}.MarshalBinary()
+	require.Error(t, err)
+
+	assert.True(t, PayloadTypeBatch.Valid())
+}
@@ -127,16 +127,35 @@ type TxResult struct {
 	Gas    int64   `json:"gas"`
 	Log    string  `json:"log,omitempty"`
 	Events []Event `json:"events,omitempty"`
+	// SubResults are the results of the payloads of a batch transaction, in
+	// order, up to and including the first that failed.
+	SubResults []SubResult `json:"sub_results,omitempty"`
 }
 
-// txResultsVer is the results structure or serialization version known presently
-const txResultsVer uint16 = 0 // v0 has events with no data a future v1 will change how events are decoded
+// SubResult is the result of one payload of a batch transaction. The changes
+// of a successful payload are reverted if a later payload in the batch fails.
+type SubResult struct {
+	Code uint32 `json:"code"`
+	Log  string `json:"log,omitempty"`
+}
+
+// txResultsVer is the results structure or serialization version known
+// presently. v0 has events with no data. v1 adds sub-results, and is only used
+// if there are sub-results, so the encoding of other results is unchanged.
+const (
+	txResultsVer        uint16 = 0
+	txResultsVerSubRslt uint16 = 1
+)
 
 func (tr TxResult) MarshalBinary() ([]byte, error) {
 	data := make([]byte, 2+4+4, 2+4+4+2+2) // put 10 bytes, append the rest
 
 	// version
-	binary.BigEndian.PutUint16(data, txResultsVer)
+	version := txResultsVer
+	if len(tr.SubResults) > 0 {
+		version = txResultsVerSubRslt
+	}
+	binary.BigEndian.PutUint16(data, version)
 
 	// Encode code as 4 bytes
 	binary.BigEndian.PutUint32(data[2:], tr.Code)
@@ -160,6 +179,18 @@ func (tr TxResult) MarshalBinary() ([]byte, error) {
 		data = append(data, evt...)
 	}
 
+	if version == txResultsVerSubRslt {
+		if len(tr.SubResults) > math.MaxUint16 {
+			return nil, errors.New("too many sub-results")
+		}
+		data = binary.BigEndian.AppendUint16(data, uint16(len(tr.SubResults)))
+		for _, sr := range tr.SubResults {
+			data = binary.BigEndian.AppendUint32(data, sr.Code)
+			data = binary.BigEndian.AppendUint32(data, uint32(len(sr.Log)))
+			data = append(data, sr.Log...)
+		}
+	}
+
 	return data, nil
 }
 
@@ -171,7 +202,7 @@ func (tr *TxResult) UnmarshalBinary(data []byte) error {
 	var offset int
 
 	version := binary.BigEndian.Uint16(data)
-	if version != txResultsVer {
+	if version != txResultsVer && version != txResultsVerSubRslt {
 		return fmt.Errorf("unsupported version %d", version)
 	}
 	offset += 2
@@ -214,6 +245,32 @@ func (tr *TxResult) UnmarshalBinary(data []byte) error {
 		offset += int(eventLen)
 	}
 
+	tr.SubResults = nil
+	if version != txResultsVerSubRslt {
+		return nil
+	}
+
+	if len(data) < offset+2 {
+		return errors.New("insufficient data for sub-results length")
+	}
+	numSubResults := binary.BigEndian.Uint16(data[offset:])
+	offset += 2
+
+	tr.SubResults = make([]SubResult, numSubResults)
+	for i := range tr.SubResults {
+		if len(data) < offset+8 {
+			return errors.New("insufficient data for sub-result")
+		}
+		tr.SubResults[i].Code = binary.BigEndian.Uint32(data[offset:])
+		logLen := int(binary.BigEndian.Uint32(data[offset+4:]))
+		offset += 8
+		if len(data) < offset+logLen {
+			return errors.New("insufficient data for sub-result log")
+		}
+		tr.SubResults[i].Log = string(data[offset : offset+logLen])
+		offset += logLen
+	}
+
 	return nil
 }
 
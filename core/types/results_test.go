@@ -67,6 +67,45 @@ func TestTxResultMarshalUnmarshal(t *testing.T) {
 		}
 	})
 
+	t.Run("with sub-results", func(t *testing.T) {
+		tr := TxResult{
+			Code: 1,
+			Log:  "batch payload 1 failed",
+			SubResults: []SubResult{
+				{Code: 0},
+				{Code: 1, Log: "insufficient balance"},
+			},
+		}
+
+		data, err := tr.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ver := binary.BigEndian.Uint16(data); ver != txResultsVerSubRslt {
+			t.Errorf("got version %d, want %d", ver, txResultsVerSubRslt)
+		}
+
+		var decoded TxResult
+		err = decoded.UnmarshalBinary(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !reflect.DeepEqual(decoded.SubResults, tr.SubResults) {
+			t.Errorf("got sub-results %v, want %v", decoded.SubResults, tr.SubResults)
+		}
+
+		// without sub-results the v0 encoding is used
+		tr.SubResults = nil
+		data, err = tr.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ver := binary.BigEndian.Uint16(data); ver != txResultsVer {
+			t.Errorf("got version %d, want %d", ver, txResultsVer)
+		}
+	})
+
 	t.Run("invalid data length", func(t *testing.T) {
 		data := make([]byte, 3)
 		var tr TxResult
@@ -0,0 +1,201 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+)
+
+// Sponsorship is a sponsor's agreement to pay the fee of another account's
+// transaction. The transaction is still executed as the original sender, but
+// its fee is debited from the sponsor. The sponsor signs a message that commits
+// to the sender's signed message, the sender, and the most it will pay, so the
+// sponsorship cannot be moved to another transaction.
+type Sponsorship struct {
+	// Sender is the sponsor's identifier, in the same form as the
+	// transaction's Sender.
+	Sender HexBytes `json:"sender"`
+	// MaxFee is the largest fee that the sponsor will pay for the transaction.
+	MaxFee *big.Int `json:"max_fee"`
+	// Signature is the sponsor's signature of the sponsorship message.
+	Signature *auth.Signature `json:"signature"`
+}
+
+const sponsorshipMsgTmplV0 = `Sponsor Kwil transaction
+
+Transaction Digest: %x
+Sender: %x
+Max Fee: %s
+`
+
+// SponsorshipMsg produces the message that a sponsor signs to pay the fee of
+// the transaction, up to maxFee. The transaction must already be signed by its
+// sender, since the message commits to the sender.
+func (t *Transaction) SponsorshipMsg(maxFee *big.Int) ([]byte, error) {
+	if t.Body == nil {
+		return nil, errors.New("transaction has no body")
+	}
+	if len(t.Sender) == 0 {
+		return nil, errors.New("transaction has no sender")
+	}
+	if maxFee == nil || maxFee.Sign() < 0 {
+		return nil, errors.New("invalid max fee")
+	}
+	msg, err := t.SerializeMsg()
+	if err != nil {
+		return nil, err
+	}
+	digest := HashBytes(msg)
+	return []byte(fmt.Sprintf(sponsorshipMsgTmplV0, digest[:20], []byte(t.Sender), maxFee.String())), nil
+}
+
+// SignSponsorship signs the transaction as a sponsor that pays its fee, up to
+// maxFee. The sender's signature is not changed.
+func (t *Transaction) SignSponsorship(signer auth.Signer, maxFee *big.Int) error {
+	msg, err := t.SponsorshipMsg(maxFee)
+	if err != nil {
+		return err
+	}
+
+	signature, err := signer.Sign(msg)
+	if err != nil {
+		return err
+	}
+
+	t.Sponsor = &Sponsorship{
+		Sender:    signer.CompactID(),
+		MaxFee:    new(big.Int).Set(maxFee),
+		Signature: signature,
+	}
+	t.cachedHash = nil
+	return nil
+}
+
+// IsSponsored indicates if the fee of the transaction is paid by a sponsor.
+func (t *Transaction) IsSponsored() bool {
+	return t.Sponsor != nil
+}
+
+// MarshalJSON marshals to JSON with MaxFee as a string.
+func (s Sponsorship) MarshalJSON() ([]byte, error) {
+	maxFee := "0"
+	if s.MaxFee != nil {
+		maxFee = s.MaxFee.String()
+	}
+	return json.Marshal(&struct {
+		Sender    HexBytes        `json:"sender"`
+		MaxFee    string          `json:"max_fee"`
+		Signature *auth.Signature `json:"signature"`
+	}{
+		Sender:    s.Sender,
+		MaxFee:    maxFee,
+		Signature: s.Signature,
+	})
+}
+
+// UnmarshalJSON unmarshals from JSON, handling a max fee string.
+func (s *Sponsorship) UnmarshalJSON(data []byte) error {
+	type sponsorshipAlias Sponsorship
+	aux := &struct {
+		MaxFee string `json:"max_fee"`
+		*sponsorshipAlias
+	}{
+		sponsorshipAlias: (*sponsorshipAlias)(s),
+	}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	maxFee, ok := new(big.Int).SetString(aux.MaxFee, 10)
+	if !ok {
+		return fmt.Errorf("could not parse max fee: %q", aux.MaxFee)
+	}
+	if maxFee.Sign() < 0 {
+		return errors.New("sponsorship max fee must be non-negative")
+	}
+	s.MaxFee = maxFee
+	return nil
+}
+
+const sponsorshipVersion = 0
+
+// SerializeSize gives the size of the serialized sponsorship.
+func (s *Sponsorship) SerializeSize() int64 {
+	bts, _ := s.MarshalBinary()
+	return int64(len(bts))
+}
+
+// MarshalBinary serializes the sponsorship.
+func (s Sponsorship) MarshalBinary() ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	if err := binary.Write(buf, SerializationByteOrder, uint16(sponsorshipVersion)); err != nil {
+		return nil, err
+	}
+	if err := WriteCompactBytes(buf, EmptyIfNil(s.Sender)); err != nil {
+		return nil, err
+	}
+	if err := WriteBigInt(buf, s.MaxFee); err != nil {
+		return nil, err
+	}
+	var sigBytes []byte
+	if s.Signature != nil {
+		sigBytes = s.Signature.Bytes()
+	}
+	if err := WriteCompactBytes(buf, EmptyIfNil(sigBytes)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary deserializes the sponsorship.
+func (s *Sponsorship) UnmarshalBinary(data []byte) error {
+	rd := bytes.NewReader(data)
+
+	var version uint16
+	if err := binary.Read(rd, SerializationByteOrder, &version); err != nil {
+		return err
+	}
+	if version != sponsorshipVersion {
+		return fmt.Errorf("unsupported sponsorship version %d", version)
+	}
+
+	sender, err := ReadCompactBytes(rd)
+	if err != nil {
+		return fmt.Errorf("failed to read sponsor: %w", err)
+	}
+	s.Sender = sender
+
+	if s.MaxFee, err = ReadBigInt(rd); err != nil {
+		return fmt.Errorf("failed to read max fee: %w", err)
+	}
+	// Block execution compares fees to the max fee without re-validating the
+	// transaction, so it must be set when decoded.
+	if s.MaxFee == nil || s.MaxFee.Sign() < 0 {
+		return errors.New("sponsorship max fee must be non-negative")
+	}
+
+	sigBytes, err := ReadCompactBytes(rd)
+	if err != nil {
+		return fmt.Errorf("failed to read sponsor signature: %w", err)
+	}
+	s.Signature = nil
+	if len(sigBytes) != 0 {
+		var sig auth.Signature
+		if err = sig.UnmarshalBinary(sigBytes); err != nil {
+			return fmt.Errorf("failed to unmarshal sponsor signature: %w", err)
+		}
+		s.Signature = &sig
+	}
+
+	if rd.Len() != 0 {
+		return errors.New("extra sponsorship data")
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+)
+
+// Stake bonds an amount from the sender's account to the sender's own
+// validator. The sender must be a validator node. The validator's power is
+// derived from its total bonded stake when staking is enabled by the
+// StakePowerUnit network parameter.
+type Stake struct {
+	Amount *big.Int `json:"amount"`
+}
+
+var _ Payload = (*Stake)(nil)
+
+func (s Stake) Type() PayloadType {
+	return PayloadTypeStake
+}
+
+// stake payload version
+const stakeVersion = 0
+
+func (s Stake) MarshalBinary() ([]byte, error) {
+	if s.Amount == nil || s.Amount.Sign() <= 0 {
+		return nil, errors.New("stake amount must be positive")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, SerializationByteOrder, uint16(stakeVersion)); err != nil {
+		return nil, err
+	}
+	if err := WriteBigInt(buf, s.Amount); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (s *Stake) UnmarshalBinary(b []byte) error {
+	rd := bytes.NewReader(b)
+
+	var version uint16
+	if err := binary.Read(rd, SerializationByteOrder, &version); err != nil {
+		return err
+	}
+	if version != stakeVersion {
+		return fmt.Errorf("unsupported stake payload version %d", version)
+	}
+
+	amt, err := ReadBigInt(rd)
+	if err != nil {
+		return err
+	}
+	if amt == nil || amt.Sign() <= 0 {
+		return errors.New("stake amount must be positive")
+	}
+	if rd.Len() != 0 {
+		return errors.New("extra data after stake payload")
+	}
+
+	s.Amount = amt
+	return nil
+}
+
+// Delegate bonds an amount from the sender's account to a validator that has
+// bonded stake of its own.
+type Delegate struct {
+	Validator []byte         `json:"validator"`
+	KeyType   crypto.KeyType `json:"key_type"`
+	Amount    *big.Int       `json:"amount"`
+}
+
+var _ Payload = (*Delegate)(nil)
+
+func (d Delegate) Type() PayloadType {
+	return PayloadTypeDelegate
+}
+
+// delegate payload version
+const delegateVersion = 0
+
+func (d Delegate) MarshalBinary() ([]byte, error) {
+	return marshalDelegation(delegateVersion, d.Validator, d.KeyType, d.Amount)
+}
+
+func (d *Delegate) UnmarshalBinary(b []byte) error {
+	var err error
+	d.Validator, d.KeyType, d.Amount, err = unmarshalDelegation(delegateVersion, b)
+	return err
+}
+
+// Undelegate unbonds an amount that the sender bonded to a validator, either
+// with Delegate or, for the validator itself, with Stake. The amount is
+// returned to the sender's account after the UnbondingBlocks network
+// parameter.
+type Undelegate struct {
+	Validator []byte         `json:"validator"`
+	KeyType   crypto.KeyType `json:"key_type"`
+	Amount    *big.Int       `json:"amount"`
+}
+
+var _ Payload = (*Undelegate)(nil)
+
+func (u Undelegate) Type() PayloadType {
+	return PayloadTypeUndelegate
+}
+
+// undelegate payload version
+const undelegateVersion = 0
+
+func (u Undelegate) MarshalBinary() ([]byte, error) {
+	return marshalDelegation(undelegateVersion, u.Validator, u.KeyType, u.Amount)
+}
+
+func (u *Undelegate) UnmarshalBinary(b []byte) error {
+	var err error
+	u.Validator, u.KeyType, u.Amount, err = unmarshalDelegation(undelegateVersion, b)
+	return err
+}
+
+// marshalDelegation serializes the fields shared by Delegate and Undelegate.
+func marshalDelegation(version uint16, validator []byte, keyType crypto.KeyType, amount *big.Int) ([]byte, error) {
+	if len(validator) == 0 {
+		return nil, errors.New("missing validator")
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, errors.New("amount must be positive")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, SerializationByteOrder, version); err != nil {
+		return nil, err
+	}
+	if err := WriteBytes(buf, validator); err != nil {
+		return nil, err
+	}
+	if _, err := keyType.WriteTo(buf); err != nil {
+		return nil, err
+	}
+	if err := WriteBigInt(buf, amount); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// unmarshalDelegation deserializes the fields shared by Delegate and
+// Undelegate.
+func unmarshalDelegation(wantVersion uint16, b []byte) ([]byte, crypto.KeyType, *big.Int, error) {
+	rd := bytes.NewReader(b)
+
+	var version uint16
+	if err := binary.Read(rd, SerializationByteOrder, &version); err != nil {
+		return nil, "", nil, err
+	}
+	if version != wantVersion {
+		return nil, "", nil, fmt.Errorf("unsupported delegation payload version %d", version)
+	}
+
+	validator, err := ReadBytes(rd)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if len(validator) == 0 {
+		return nil, "", nil, errors.New("missing validator")
+	}
+
+	var keyType crypto.KeyType
+	if _, err = keyType.ReadFrom(rd); err != nil {
+		return nil, "", nil, err
+	}
+
+	amount, err := ReadBigInt(rd)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	if amount == nil || amount.Sign() <= 0 {
+		return nil, "", nil, errors.New("amount must be positive")
+	}
+	if rd.Len() != 0 {
+		return nil, "", nil, errors.New("extra data after delegation payload")
+	}
+
+	return validator, keyType, amount, nil
+}
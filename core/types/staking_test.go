@@ -0,0 +1,60 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+)
+
+func TestStake_MarshalUnmarshal(t *testing.T) {
+	stake := Stake{Amount: big.NewInt(1000)}
+	bts, err := stake.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded Stake
+	require.NoError(t, decoded.UnmarshalBinary(bts))
+	assert.Equal(t, stake, decoded)
+
+	require.Error(t, decoded.UnmarshalBinary(append(bts, 0)))
+
+	_, err = Stake{Amount: big.NewInt(0)}.MarshalBinary()
+	require.Error(t, err)
+	_, err = Stake{}.MarshalBinary()
+	require.Error(t, err)
+
+	assert.True(t, PayloadTypeStake.Valid())
+}
+
+func TestDelegation_MarshalUnmarshal(t *testing.T) {
+	del := Delegate{
+		Validator: []byte{1, 2, 3},
+		KeyType:   crypto.KeyTypeEd25519,
+		Amount:    big.NewInt(42),
+	}
+	bts, err := del.MarshalBinary()
+	require.NoError(t, err)
+
+	var decoded Delegate
+	require.NoError(t, decoded.UnmarshalBinary(bts))
+	assert.Equal(t, del, decoded)
+
+	// the payloads are versioned separately, but encoded the same way
+	undel := Undelegate(del)
+	ubts, err := undel.MarshalBinary()
+	require.NoError(t, err)
+
+	p, err := UnmarshalPayload(PayloadTypeUndelegate, ubts)
+	require.NoError(t, err)
+	assert.Equal(t, &undel, p)
+
+	_, err = Delegate{KeyType: crypto.KeyTypeEd25519, Amount: big.NewInt(1)}.MarshalBinary()
+	require.Error(t, err)
+	_, err = Undelegate{Validator: []byte{1}, KeyType: crypto.KeyTypeEd25519, Amount: big.NewInt(-1)}.MarshalBinary()
+	require.Error(t, err)
+
+	require.Error(t, decoded.UnmarshalBinary(append(bts, 0)))
+}
@@ -0,0 +1,275 @@
+package types
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// The state tree is a sparse Merkle tree that commits to the rows of all user
+// tables. It is keyed by the hash of a row's namespace, table, and primary key
+// (see StateKey), and its root is part of a block's app hash. A subtree with
+// only one row is represented by that row's leaf, so the depth of a leaf is the
+// length of the shortest key prefix that is unique among the rows.
+
+const (
+	stateLeafPrefix = byte(0)
+	stateNodePrefix = byte(1)
+
+	// maxStateTreeDepth is the number of bits in a state key.
+	maxStateTreeDepth = 8 * HashLen
+)
+
+// StateKey computes the key of a row in the state tree. The primary key values
+// must be in the order of the table's primary key columns. Only the data of
+// the values is used, so, for example, numeric values need not have the same
+// precision as the column, but must have the same scale.
+func StateKey(namespace, table string, primaryKey []*EncodedValue) Hash {
+	hasher := NewHasher()
+	WriteString(hasher, strings.ToLower(namespace))
+	WriteString(hasher, strings.ToLower(table))
+	for _, v := range primaryKey {
+		binary.Write(hasher, SerializationByteOrder, uint16(len(v.Data)))
+		for _, data := range v.Data {
+			WriteBytes(hasher, data)
+		}
+	}
+	return hasher.Sum(nil)
+}
+
+// StateLeafHash is the hash of a leaf of the state tree.
+func StateLeafHash(key, valueHash Hash) Hash {
+	var buf [1 + 2*HashLen]byte
+	buf[0] = stateLeafPrefix
+	copy(buf[1:], key[:])
+	copy(buf[1+HashLen:], valueHash[:])
+	return HashBytes(buf[:])
+}
+
+// StateNodeHash is the hash of an interior node of the state tree. An empty
+// subtree has the zero hash.
+func StateNodeHash(left, right Hash) Hash {
+	var buf [1 + 2*HashLen]byte
+	buf[0] = stateNodePrefix
+	copy(buf[1:], left[:])
+	copy(buf[1+HashLen:], right[:])
+	return HashBytes(buf[:])
+}
+
+// StateKeyBit returns the bit of the key at the given depth of the state tree,
+// which is 0 for the left subtree and 1 for the right.
+func StateKeyBit(key Hash, depth int) byte {
+	return (key[depth/8] >> (7 - depth%8)) & 1
+}
+
+// StateRow is a row of a table, as committed to by the state tree.
+type StateRow struct {
+	Columns []string        `json:"columns"`
+	Values  []*EncodedValue `json:"values"`
+}
+
+const stateRowVersion = 0
+
+func (r StateRow) MarshalBinary() ([]byte, error) {
+	if len(r.Columns) != len(r.Values) {
+		return nil, errors.New("number of columns and values do not match")
+	}
+
+	buf := new(bytes.Buffer)
+	if err := binary.Write(buf, SerializationByteOrder, uint16(stateRowVersion)); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(buf, SerializationByteOrder, uint16(len(r.Columns))); err != nil {
+		return nil, err
+	}
+	for i, col := range r.Columns {
+		if err := WriteString(buf, col); err != nil {
+			return nil, err
+		}
+		valBts, err := r.Values[i].MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		if err := WriteBytes(buf, valBts); err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (r *StateRow) UnmarshalBinary(b []byte) error {
+	rd := bytes.NewReader(b)
+
+	var version uint16
+	if err := binary.Read(rd, SerializationByteOrder, &version); err != nil {
+		return err
+	}
+	if version != stateRowVersion {
+		return fmt.Errorf("unsupported state row version %d", version)
+	}
+
+	var numCols uint16
+	if err := binary.Read(rd, SerializationByteOrder, &numCols); err != nil {
+		return err
+	}
+	r.Columns = make([]string, numCols)
+	r.Values = make([]*EncodedValue, numCols)
+	for i := range numCols {
+		col, err := ReadString(rd)
+		if err != nil {
+			return err
+		}
+		valBts, err := ReadBytes(rd)
+		if err != nil {
+			return err
+		}
+		var val EncodedValue
+		if err = val.UnmarshalBinary(valBts); err != nil {
+			return err
+		}
+		r.Columns[i] = col
+		r.Values[i] = &val
+	}
+
+	if rd.Len() != 0 {
+		return errors.New("extra state row data")
+	}
+
+	return nil
+}
+
+// Hash is the value hash of the row's leaf in the state tree.
+func (r *StateRow) Hash() (Hash, error) {
+	bts, err := r.MarshalBinary()
+	if err != nil {
+		return Hash{}, err
+	}
+	return HashBytes(bts), nil
+}
+
+// Decode decodes the row's values into a map of column names to native Go
+// values.
+func (r *StateRow) Decode() (map[string]any, error) {
+	row := make(map[string]any, len(r.Columns))
+	for i, col := range r.Columns {
+		val, err := r.Values[i].Decode()
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode column %s: %w", col, err)
+		}
+		row[col] = val
+	}
+	return row, nil
+}
+
+// StateHashes are the parts of a block's app hash.
+type StateHashes struct {
+	PrevApp      Hash `json:"prev_app_hash"`
+	Changeset    Hash `json:"changeset_hash"`
+	ValUpdates   Hash `json:"validator_updates_hash"`
+	Accounts     Hash `json:"accounts_hash"`
+	TxResults    Hash `json:"tx_results_hash"`
+	ParamUpdates Hash `json:"param_updates_hash"`
+	// StateRoot is the root of the state tree after the block, if the network
+	// maintains one.
+	StateRoot Hash `json:"state_root"`
+}
+
+// AppHash computes the app hash:
+//
+//	sha256(prevAppHash || changesetHash || valUpdatesHash || accountsHash || txResultsHash || paramUpdatesHash [|| stateRoot])
+//
+// The state root is only included if it is not zero, so that the app hashes of
+// networks without a state tree are unchanged.
+func (sh *StateHashes) AppHash() Hash {
+	hasher := NewHasher()
+
+	hasher.Write(sh.PrevApp[:])
+	hasher.Write(sh.Changeset[:])
+	hasher.Write(sh.ValUpdates[:])
+	hasher.Write(sh.Accounts[:])
+	hasher.Write(sh.TxResults[:])
+	hasher.Write(sh.ParamUpdates[:])
+	if !sh.StateRoot.IsZero() {
+		hasher.Write(sh.StateRoot[:])
+	}
+
+	return hasher.Sum(nil)
+}
+
+// StateLeaf is a leaf of the state tree.
+type StateLeaf struct {
+	Key       Hash `json:"key"`
+	ValueHash Hash `json:"value_hash"`
+}
+
+// StateProof proves that a row is or is not in the state tree after the block
+// at a height, and that the state tree's root is committed to by the block's
+// app hash.
+type StateProof struct {
+	Height    int64  `json:"height"`
+	Namespace string `json:"namespace"`
+	Table     string `json:"table"`
+	Key       Hash   `json:"key"`
+	// Row is the row with the key, or nil if there is no such row.
+	Row *StateRow `json:"row,omitempty"`
+	// Siblings are the hashes of the siblings on the path from the root to
+	// the row's position in the tree.
+	Siblings []Hash `json:"siblings"`
+	// OtherLeaf is the leaf at the row's position in the tree if there is no
+	// row with the key, but there is a row whose key shares the position's
+	// prefix. It is nil if the position is an empty subtree.
+	OtherLeaf *StateLeaf `json:"other_leaf,omitempty"`
+	// StateHashes are the parts of the app hash of the block at the height.
+	StateHashes StateHashes `json:"state_hashes"`
+}
+
+// Verify checks that the proof is valid for the app hash of the block at the
+// proof's height. The app hash must come from a trusted source, such as a
+// light client. Verify does not check that the proof's key is that of the
+// requested row; see StateKey.
+func (p *StateProof) Verify(appHash Hash) error {
+	if p.StateHashes.AppHash() != appHash {
+		return errors.New("state hashes do not match the app hash")
+	}
+	if len(p.Siblings) > maxStateTreeDepth {
+		return errors.New("proof is too long")
+	}
+
+	var node Hash
+	switch {
+	case p.Row != nil && p.OtherLeaf != nil:
+		return errors.New("proof has both a row and another leaf")
+	case p.Row != nil:
+		valueHash, err := p.Row.Hash()
+		if err != nil {
+			return err
+		}
+		node = StateLeafHash(p.Key, valueHash)
+	case p.OtherLeaf != nil:
+		if p.OtherLeaf.Key == p.Key {
+			return errors.New("other leaf has the proof's key")
+		}
+		for depth := range len(p.Siblings) {
+			if StateKeyBit(p.OtherLeaf.Key, depth) != StateKeyBit(p.Key, depth) {
+				return errors.New("other leaf is not at the key's position")
+			}
+		}
+		node = StateLeafHash(p.OtherLeaf.Key, p.OtherLeaf.ValueHash)
+	}
+
+	for depth := len(p.Siblings) - 1; depth >= 0; depth-- {
+		if StateKeyBit(p.Key, depth) == 0 {
+			node = StateNodeHash(node, p.Siblings[depth])
+		} else {
+			node = StateNodeHash(p.Siblings[depth], node)
+		}
+	}
+
+	if node != p.StateHashes.StateRoot {
+		return errors.New("proof does not match the state root")
+	}
+	return nil
+}
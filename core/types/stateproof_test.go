@@ -0,0 +1,185 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testStateRow(t *testing.T, id int64, name string) *StateRow {
+	idVal, err := EncodeValue(id)
+	require.NoError(t, err)
+	nameVal, err := EncodeValue(name)
+	require.NoError(t, err)
+	return &StateRow{
+		Columns: []string{"id", "name"},
+		Values:  []*EncodedValue{idVal, nameVal},
+	}
+}
+
+func TestStateRow_MarshalBinary(t *testing.T) {
+	row := testStateRow(t, 1, "alice")
+	bts, err := row.MarshalBinary()
+	require.NoError(t, err)
+
+	var row2 StateRow
+	require.NoError(t, row2.UnmarshalBinary(bts))
+	require.Equal(t, *row, row2)
+
+	vals, err := row2.Decode()
+	require.NoError(t, err)
+	require.Equal(t, int64(1), *vals["id"].(*int64))
+	require.Equal(t, "alice", *vals["name"].(*string))
+
+	require.Error(t, row2.UnmarshalBinary(append(bts, 0)))
+}
+
+func TestStateHashes_AppHash(t *testing.T) {
+	sh := &StateHashes{
+		PrevApp:      Hash{1},
+		Changeset:    Hash{2},
+		ValUpdates:   Hash{3},
+		Accounts:     Hash{4},
+		TxResults:    Hash{5},
+		ParamUpdates: Hash{6},
+	}
+
+	// without a state root, the app hash is that of networks without a state tree
+	hasher := NewHasher()
+	for _, h := range []Hash{sh.PrevApp, sh.Changeset, sh.ValUpdates, sh.Accounts, sh.TxResults, sh.ParamUpdates} {
+		hasher.Write(h[:])
+	}
+	require.Equal(t, Hash(hasher.Sum(nil)), sh.AppHash())
+
+	withRoot := *sh
+	withRoot.StateRoot = Hash{7}
+	require.NotEqual(t, sh.AppHash(), withRoot.AppHash())
+}
+
+func TestStateProof_Verify(t *testing.T) {
+	// A tree with two rows whose keys differ in the first bit.
+	row := testStateRow(t, 1, "alice")
+	var key, otherKey Hash
+	key[0], otherKey[0] = 0x00, 0x80
+	rowHash, err := row.Hash()
+	require.NoError(t, err)
+	otherValueHash := HashBytes([]byte("other"))
+
+	leaf := StateLeafHash(key, rowHash)
+	otherLeaf := StateLeafHash(otherKey, otherValueHash)
+	sh := StateHashes{StateRoot: StateNodeHash(leaf, otherLeaf)}
+	appHash := sh.AppHash()
+
+	// A tree with an empty subtree next to the first row, for proofs of
+	// absence that end at an empty subtree.
+	absentSH := StateHashes{StateRoot: StateNodeHash(StateNodeHash(leaf, Hash{}), otherLeaf)}
+
+	inclusion := func() *StateProof {
+		return &StateProof{
+			Key:         key,
+			Row:         row,
+			Siblings:    []Hash{otherLeaf},
+			StateHashes: sh,
+		}
+	}
+
+	tests := []struct {
+		name    string
+		proof   func() *StateProof
+		appHash Hash
+		wantErr bool
+	}{
+		{
+			name:  "inclusion",
+			proof: inclusion,
+		},
+		{
+			name: "absence with an empty subtree",
+			proof: func() *StateProof {
+				var absentKey Hash
+				absentKey[0] = 0x40 // left subtree, then right
+				return &StateProof{
+					Key:         absentKey,
+					Siblings:    []Hash{otherLeaf, leaf},
+					StateHashes: absentSH,
+				}
+			},
+			appHash: absentSH.AppHash(),
+		},
+		{
+			name: "absence with another leaf",
+			proof: func() *StateProof {
+				var absentKey Hash
+				absentKey[0] = 0x81
+				return &StateProof{
+					Key:         absentKey,
+					Siblings:    []Hash{leaf},
+					OtherLeaf:   &StateLeaf{Key: otherKey, ValueHash: otherValueHash},
+					StateHashes: sh,
+				}
+			},
+		},
+		{
+			name:    "wrong app hash",
+			proof:   inclusion,
+			appHash: Hash{1},
+			wantErr: true,
+		},
+		{
+			name: "modified row",
+			proof: func() *StateProof {
+				p := inclusion()
+				p.Row = testStateRow(t, 1, "mallory")
+				return p
+			},
+			wantErr: true,
+		},
+		{
+			name: "row claimed absent",
+			proof: func() *StateProof {
+				p := inclusion()
+				p.Row = nil
+				return p
+			},
+			wantErr: true,
+		},
+		{
+			name: "other leaf with the proof's key",
+			proof: func() *StateProof {
+				p := inclusion()
+				p.Key = otherKey
+				p.Row = nil
+				p.Siblings = []Hash{leaf}
+				p.OtherLeaf = &StateLeaf{Key: otherKey, ValueHash: otherValueHash}
+				return p
+			},
+			wantErr: true,
+		},
+		{
+			name: "other leaf not at the key's position",
+			proof: func() *StateProof {
+				p := inclusion()
+				p.Row = nil
+				p.Siblings = []Hash{leaf}
+				p.OtherLeaf = &StateLeaf{Key: otherKey, ValueHash: otherValueHash}
+				return p
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ah := tt.appHash
+			if ah.IsZero() {
+				ah = appHash
+			}
+			err := tt.proof().Verify(ah)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -44,6 +44,10 @@ type Transaction struct {
 	// a public key of the sender, hence bytes that encode as hexadecimal.
 	Sender HexBytes `json:"sender"`
 
+	// Sponsor, if set, is the account that pays the transaction fee on behalf
+	// of the Sender. See SignSponsorship.
+	Sponsor *Sponsorship `json:"sponsor,omitempty"`
+
 	strictUnmarshal bool
 	cachedHash      *Hash // maybe maybe maybe... this would require a mutex or careful use
 }
@@ -265,6 +269,59 @@ func (t *Transaction) Sign(signer auth.Signer) error {
 	return nil
 }
 
+// SetMultisig prepares the transaction to be signed by the members of a
+// multisig account. It sets the Sender to the multisig account ID, and the
+// Signature to a multisignature with no member signatures. Members then sign
+// with SignMultisig.
+func (t *Transaction) SetMultisig(ms *auth.Multisig) error {
+	if err := ms.Validate(); err != nil {
+		return err
+	}
+	msig := &auth.MultiSignature{Multisig: *ms}
+	t.Signature = msig.Signature()
+	t.Sender = ms.ID()
+	return nil
+}
+
+// SignMultisig adds a member's signature to a transaction from a multisig
+// account. The transaction must have been prepared with SetMultisig.
+func (t *Transaction) SignMultisig(signer auth.Signer) error {
+	msig, err := t.MultiSignature()
+	if err != nil {
+		return err
+	}
+
+	msg, err := t.SerializeMsg()
+	if err != nil {
+		return err
+	}
+
+	sig, err := signer.Sign(msg)
+	if err != nil {
+		return err
+	}
+
+	if err = msig.AddSignature(signer.CompactID(), sig); err != nil {
+		return err
+	}
+
+	t.Signature = msig.Signature()
+	return nil
+}
+
+// MultiSignature decodes the signature of a transaction from a multisig
+// account.
+func (t *Transaction) MultiSignature() (*auth.MultiSignature, error) {
+	if t.Signature == nil || t.Signature.Type != auth.MultisigAuth {
+		return nil, errors.New("transaction is not from a multisig account")
+	}
+	msig := &auth.MultiSignature{}
+	if err := msig.UnmarshalBinary(t.Signature.Data); err != nil {
+		return nil, err
+	}
+	return msig, nil
+}
+
 // SerializeMsg prepares a message for signing or verification using a certain
 // message construction format. This is done since a Kwil transaction is foreign
 // to wallets, and it is signed as a message, not a transaction that is native
@@ -303,7 +360,7 @@ func (t *TransactionBody) SerializeMsg(mst SignedMsgSerializationType) ([]byte,
 // SerializeSize gives the size of the serialized transaction.
 func (t *Transaction) SerializeSize() int64 {
 	totalLen := func(l int) int {
-		return l + uvarintLen(uint64(l))
+		return l + varintLen(int64(l)) // compact bytes use a signed varint length
 	}
 	// NOTE: unit tests must have SerializeSize verified against MarshalBinary
 	// and/or WriteTo to ensure this method does not become stale!
@@ -315,11 +372,15 @@ func (t *Transaction) SerializeSize() int64 {
 	if t.Body != nil {
 		bodySize = t.Body.SerializeSize()
 	}
-	return int64(2 +
+	size := int64(2 +
 		totalLen(int(sigSize)) +
 		totalLen(int(bodySize)) +
 		totalLen(len(t.Serialization)) +
 		totalLen(len(t.Sender)))
+	if t.Sponsor != nil {
+		size += int64(totalLen(int(t.Sponsor.SerializeSize())))
+	}
+	return size
 }
 
 var _ io.WriterTo = (*Transaction)(nil)
@@ -452,7 +513,7 @@ func varintLen(x int64) int {
 // SerializeSize gives the size of the serialized transaction body.
 func (tb TransactionBody) SerializeSize() int64 {
 	totalLen := func(l int) int {
-		return l + uvarintLen(uint64(l))
+		return l + varintLen(int64(l)) // compact bytes use a signed varint length
 	}
 	// NOTE: unit tests must have SerializeSize verified against MarshalBinary!
 	fw := utils.NewCountingWriter(io.Discard)
@@ -539,11 +600,21 @@ func (tb *TransactionBody) UnmarshalBinary(data []byte) error {
 	return nil
 }
 
-const txVersion uint16 = 0
+const (
+	txVersion uint16 = 0
+	// txVersionSponsored is a transaction with a trailing Sponsorship.
+	// Unsponsored transactions are still serialized as txVersion so that
+	// their encoding and hash are unchanged.
+	txVersionSponsored uint16 = 1
+)
 
 func (t *Transaction) serialize(w io.Writer) (err error) {
 	// version
-	if err := binary.Write(w, SerializationByteOrder, txVersion); err != nil {
+	ver := txVersion
+	if t.Sponsor != nil {
+		ver = txVersionSponsored
+	}
+	if err := binary.Write(w, SerializationByteOrder, ver); err != nil {
 		return fmt.Errorf("failed to write transaction version: %w", err)
 	}
 
@@ -575,6 +646,17 @@ func (t *Transaction) serialize(w io.Writer) (err error) {
 		return fmt.Errorf("failed to write transaction sender: %w", err)
 	}
 
+	// Sponsor
+	if t.Sponsor != nil {
+		sponsorBytes, err := t.Sponsor.MarshalBinary()
+		if err != nil {
+			return fmt.Errorf("failed to marshal transaction sponsor: %w", err)
+		}
+		if err := WriteCompactBytes(w, sponsorBytes); err != nil {
+			return fmt.Errorf("failed to write transaction sponsor: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -587,7 +669,7 @@ func (t *Transaction) deserialize(r io.Reader) (int64, error) {
 	if err != nil {
 		return cr.ReadCount(), fmt.Errorf("failed to read transaction version: %w", err)
 	}
-	if ver != txVersion { // in the future we can have different transaction (sub)structs, switch to different handling, etc.
+	if ver != txVersion && ver != txVersionSponsored { // in the future we can have different transaction (sub)structs, switch to different handling, etc.
 		return cr.ReadCount(), fmt.Errorf("unsupported transaction version %d", ver)
 	}
 
@@ -635,6 +717,20 @@ func (t *Transaction) deserialize(r io.Reader) (int64, error) {
 	}
 	t.Sender = senderBytes
 
+	// Sponsor
+	t.Sponsor = nil
+	if ver == txVersionSponsored {
+		sponsorBytes, err := ReadCompactBytes(cr)
+		if err != nil {
+			return cr.ReadCount(), fmt.Errorf("failed to read transaction sponsor: %w", err)
+		}
+		var sponsor Sponsorship
+		if err = sponsor.UnmarshalBinary(sponsorBytes); err != nil {
+			return cr.ReadCount(), fmt.Errorf("failed to unmarshal transaction sponsor: %w", err)
+		}
+		t.Sponsor = &sponsor
+	}
+
 	return cr.ReadCount(), nil
 }
 
@@ -1043,6 +1043,28 @@ func TestTransaction_SerializeSize(t *testing.T) {
 			},
 			expected: 1032, // 2 + 1 + (13) + 2 + (2 + 1000 + 1 + 4) + 1 + 1 + 1 + 4
 		},
+		{
+			// lengths from 64 to 127 are one byte as unsigned varints, but
+			// two as the signed varints that compact bytes are written with
+			name: "sender with two byte length",
+			tx: Transaction{
+				Body:          &TransactionBody{},
+				Signature:     &auth.Signature{},
+				Sender:        make([]byte, 64),
+				Serialization: "",
+			},
+			expected: 86, // 2 + (1 + 13) + (1 + 2) + 1 + (2 + 64)
+		},
+		{
+			name: "body with two byte length",
+			tx: Transaction{
+				Body:          &TransactionBody{Payload: make([]byte, 64)},
+				Signature:     &auth.Signature{},
+				Sender:        []byte{},
+				Serialization: "",
+			},
+			expected: 87, // 2 + (2 + 13 + 1 + 64) + (1 + 2) + 1 + 1
+		},
 	}
 
 	for _, tc := range testCases {
@@ -1207,3 +1229,119 @@ func TestCreateNodeTransaction(t *testing.T) {
 	require.NotNil(t, tx)
 	require.Equal(t, SignedMsgDirect, tx.Serialization)
 }
+
+func TestTransaction_Multisig(t *testing.T) {
+	var signers []auth.Signer
+	var members []auth.MultisigMember
+	for range 3 {
+		priv, _, err := crypto.GenerateSecp256k1Key(nil)
+		require.NoError(t, err)
+		s := auth.GetUserSigner(priv)
+		signers = append(signers, s)
+		members = append(members, auth.MultisigMember{Type: s.AuthType(), ID: s.CompactID()})
+	}
+	ms, err := auth.NewMultisig(2, members)
+	require.NoError(t, err)
+
+	tx, err := CreateTransaction(&Transfer{
+		To:     &AccountID{Identifier: signers[0].CompactID(), KeyType: crypto.KeyTypeSecp256k1},
+		Amount: big.NewInt(1),
+	}, "chain", 1)
+	require.NoError(t, err)
+	require.NoError(t, tx.SetMultisig(ms))
+	assert.Equal(t, HexBytes(ms.ID()), tx.Sender)
+
+	require.NoError(t, tx.SignMultisig(signers[2]))
+
+	// the partially signed transaction survives a JSON round trip, as when it
+	// is passed between members in a file
+	bts, err := json.Marshal(tx)
+	require.NoError(t, err)
+	var tx2 Transaction
+	require.NoError(t, json.Unmarshal(bts, &tx2))
+	require.NoError(t, tx2.SignMultisig(signers[0]))
+
+	msig, err := tx2.MultiSignature()
+	require.NoError(t, err)
+	assert.Len(t, msig.Signatures, 2)
+
+	msg, err := tx2.SerializeMsg()
+	require.NoError(t, err)
+	require.NoError(t, auth.MultisigAuthenticator{}.Verify(tx2.Sender, msg, tx2.Signature.Data))
+
+	// a non-member cannot sign
+	priv, _, err := crypto.GenerateSecp256k1Key(nil)
+	require.NoError(t, err)
+	require.Error(t, tx2.SignMultisig(auth.GetUserSigner(priv)))
+}
+
+func TestTransaction_Sponsored(t *testing.T) {
+	newSigner := func() auth.Signer {
+		priv, _, err := crypto.GenerateSecp256k1Key(nil)
+		require.NoError(t, err)
+		return auth.GetUserSigner(priv)
+	}
+	user, sponsor := newSigner(), newSigner()
+
+	tx, err := CreateTransaction(&Transfer{
+		To:     &AccountID{Identifier: sponsor.CompactID(), KeyType: crypto.KeyTypeSecp256k1},
+		Amount: big.NewInt(0),
+	}, "chain", 1)
+	require.NoError(t, err)
+	tx.Body.Fee = big.NewInt(100)
+
+	// the sponsor must sign after the sender
+	require.Error(t, tx.SignSponsorship(sponsor, big.NewInt(200)))
+
+	require.NoError(t, tx.Sign(user))
+	unsponsored := tx.Bytes()
+	require.NoError(t, tx.SignSponsorship(sponsor, big.NewInt(200)))
+	assert.True(t, tx.IsSponsored())
+	assert.Equal(t, HexBytes(user.CompactID()), tx.Sender)
+	assert.Equal(t, HexBytes(sponsor.CompactID()), tx.Sponsor.Sender)
+
+	// unsponsored transactions keep the original version
+	assert.Equal(t, txVersion, SerializationByteOrder.Uint16(unsponsored))
+
+	bts := tx.Bytes()
+	assert.Equal(t, txVersionSponsored, SerializationByteOrder.Uint16(bts))
+	assert.Equal(t, int64(len(bts)), tx.SerializeSize())
+	assert.NotEqual(t, HashBytes(unsponsored), tx.Hash())
+
+	var tx2 Transaction
+	tx2.StrictUnmarshal()
+	require.NoError(t, tx2.UnmarshalBinary(bts))
+	assert.Equal(t, tx.Sponsor, tx2.Sponsor)
+	assert.Equal(t, tx.Hash(), tx2.Hash())
+
+	// a missing or negative max fee is rejected
+	for _, maxFee := range []*big.Int{nil, big.NewInt(-1)} {
+		sp := *tx.Sponsor
+		sp.MaxFee = maxFee
+		spBts, err := sp.MarshalBinary()
+		require.NoError(t, err)
+		var sp2 Sponsorship
+		require.Error(t, sp2.UnmarshalBinary(spBts))
+	}
+
+	jsonBts, err := json.Marshal(tx)
+	require.NoError(t, err)
+	var tx3 Transaction
+	require.NoError(t, json.Unmarshal(jsonBts, &tx3))
+	assert.Equal(t, tx.Hash(), tx3.Hash())
+
+	// the sponsor signature covers the sender's message, sender, and max fee
+	msg, err := tx.SponsorshipMsg(tx.Sponsor.MaxFee)
+	require.NoError(t, err)
+	authn := auth.EthSecp256k1Authenticator{}
+	require.NoError(t, authn.Verify(tx.Sponsor.Sender, msg, tx.Sponsor.Signature.Data))
+
+	msg, err = tx.SponsorshipMsg(big.NewInt(1000))
+	require.NoError(t, err)
+	require.Error(t, authn.Verify(tx.Sponsor.Sender, msg, tx.Sponsor.Signature.Data))
+
+	tx.Body.Nonce++
+	msg, err = tx.SponsorshipMsg(tx.Sponsor.MaxFee)
+	require.NoError(t, err)
+	require.Error(t, authn.Verify(tx.Sponsor.Sender, msg, tx.Sponsor.Signature.Data))
+}
@@ -8,6 +8,8 @@ import (
 	"errors"
 	"fmt"
 	"math/big"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/kwilteam/kwil-db/core/crypto"
@@ -240,6 +242,23 @@ func (v *Validator) UnmarshalJSON(b []byte) error {
 	return nil
 }
 
+// ValidatorSetHash computes the hash of a validator set that is committed to
+// by the ValidatorSetHash field of a BlockHeader. The order of the validators
+// does not matter.
+func ValidatorSetHash(validators []*Validator) Hash {
+	sorted := slices.Clone(validators)
+	slices.SortFunc(sorted, func(a, b *Validator) int {
+		return strings.Compare(a.PrettyString(), b.PrettyString())
+	})
+
+	hasher := NewHasher()
+	for _, val := range sorted {
+		hasher.Write(val.AccountID.Bytes())
+		binary.Write(hasher, binary.BigEndian, val.Power)
+	}
+	return hasher.Sum(nil)
+}
+
 // DatasetIdentifier contains the information required to identify a dataset.
 type DatasetIdentifier struct {
 	Name      string   `json:"name"`
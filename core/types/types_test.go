@@ -74,3 +74,19 @@ func Test_ValidatorRoundtrip(t *testing.T) {
 
 	require.Equal(t, val, &valBack)
 }
+
+func Test_ValidatorSetHash(t *testing.T) {
+	vals := []*Validator{
+		{AccountID: AccountID{Identifier: []byte{3}, KeyType: crypto.KeyTypeSecp256k1}, Power: 1},
+		{AccountID: AccountID{Identifier: []byte{1}, KeyType: crypto.KeyTypeEd25519}, Power: 2},
+		{AccountID: AccountID{Identifier: []byte{2}, KeyType: crypto.KeyTypeSecp256k1}, Power: 3},
+	}
+	hash := ValidatorSetHash(vals)
+
+	reversed := []*Validator{vals[2], vals[1], vals[0]}
+	require.Equal(t, hash, ValidatorSetHash(reversed))
+	require.Equal(t, &Validator{AccountID: AccountID{Identifier: []byte{3}, KeyType: crypto.KeyTypeSecp256k1}, Power: 1}, vals[0]) // not reordered
+
+	changed := []*Validator{vals[0], vals[1], {AccountID: vals[2].AccountID, Power: 4}}
+	require.NotEqual(t, hash, ValidatorSetHash(changed))
+}
@@ -90,6 +90,13 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+
+	err = RegisterAuthenticator(ModAdd, auth.MultisigAuth, auth.MultisigAuthenticator{
+		Authenticators: GetAuthenticator,
+	})
+	if err != nil {
+		panic(err)
+	}
 }
 
 func IsAuthTypeValid(authType string) bool {
@@ -5,8 +5,10 @@ package precompiles
 import (
 	"context"
 	"fmt"
+	"maps"
 	"slices"
 	"strings"
+	"sync"
 
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/core/types"
@@ -259,17 +261,30 @@ func NewPrecompileValue(name string, t *types.DataType, nullable bool) Precompil
 	}
 }
 
-var registeredPrecompiles = make(map[string]Initializer)
+var (
+	// registeredMtx protects registeredPrecompiles, since precompiles may be
+	// registered while the node is running, such as WebAssembly precompiles
+	// added by a resolution.
+	registeredMtx         sync.RWMutex
+	registeredPrecompiles = make(map[string]Initializer)
+)
 
+// RegisteredPrecompiles returns a copy of the registered precompile
+// initializers, keyed by their lower case names.
 func RegisteredPrecompiles() map[string]Initializer {
-	return registeredPrecompiles
+	registeredMtx.RLock()
+	defer registeredMtx.RUnlock()
+	return maps.Clone(registeredPrecompiles)
 }
 
 // RegisterPrecompile registers a precompile extension with the engine.
 // It is a more user-friendly way to register precompiles than RegisterInitializer.
 func RegisterPrecompile(name string, ext Precompile) error {
 	name = strings.ToLower(name)
-	if _, ok := registeredPrecompiles[name]; ok {
+	registeredMtx.RLock()
+	_, ok := registeredPrecompiles[name]
+	registeredMtx.RUnlock()
+	if ok {
 		return fmt.Errorf("precompile of same name already registered:%s ", name)
 	}
 
@@ -290,6 +305,10 @@ func RegisterPrecompile(name string, ext Precompile) error {
 
 func RegisterInitializer(name string, init Initializer) error {
 	name = strings.ToLower(name)
+
+	registeredMtx.Lock()
+	defer registeredMtx.Unlock()
+
 	if _, ok := registeredPrecompiles[name]; ok {
 		return fmt.Errorf("precompile of same name already registered:%s ", name)
 	}
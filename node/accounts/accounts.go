@@ -6,6 +6,7 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"maps"
 	"math/big"
 	"sync"
 
@@ -109,6 +110,9 @@ func (s *Spend) UnmarshalBinary(data []byte) (err error) {
 func InitializeAccountStore(ctx context.Context, db sql.DB, logger log.Logger) (*Accounts, error) {
 	upgradeFns := map[int64]versioning.UpgradeFunc{
 		0: initTables,
+		1: initNonceGaps,
+		2: initSponsorLimits,
+		3: initFeeDistributions,
 	}
 
 	err := versioning.Upgrade(ctx, db, schemaName, upgradeFns, accountStoreVersion)
@@ -222,23 +226,39 @@ func (a *Accounts) Credit(ctx context.Context, tx sql.Executor, account *types.A
 }
 
 // Spend spends an amount from an account and records nonces. It blocks until the spend is written to the database.
-// The nonce passed must be exactly one greater than the account's nonce. If the nonce is not valid, the spend will fail.
+// The nonce passed must be valid for the account's nonce and the nonce window (see CheckNonce). With a nonce window
+// of zero, it must be exactly one greater than the account's nonce. If the nonce is not valid, the spend will fail.
 // If the account does not have enough funds to spend the amount, an ErrInsufficientFunds error will be returned.
-func (a *Accounts) Spend(ctx context.Context, tx sql.Executor, account *types.AccountID, amount *big.Int, nonce int64) error {
+func (a *Accounts) Spend(ctx context.Context, tx sql.Executor, account *types.AccountID, amount *big.Int, nonce, nonceWindow int64) error {
+	kd, ok := crypto.KeyTypeDefinition(account.KeyType)
+	if !ok {
+		return fmt.Errorf("invalid key type: %s", account.KeyType)
+	}
+
 	acct, err := a.getAccount(ctx, tx, account, true)
 	if err != nil {
-		// If amount is 0 and account does not exist, create the account
-		// Ensure that the nonce is 1, as this is the first tx spend on this account
-		if errors.Is(err, ErrAccountNotFound) && amount.Sign() == 0 && nonce == 1 {
+		// If amount is 0 and account does not exist, create the account.
+		// Ensure that the nonce is valid for the first tx spend on this account.
+		if errors.Is(err, ErrAccountNotFound) && amount.Sign() == 0 {
+			if _, err := CheckNonce(0, nonce, nonceWindow, noNonceGaps); err != nil {
+				return err
+			}
+			if nonce > 1 {
+				if err := addNonceGaps(ctx, tx, account.Identifier, kd.EncodeFlag(), 1, nonce-1); err != nil {
+					return err
+				}
+			}
 			return a.createAccount(ctx, tx, account, amount, nonce)
 		}
 
 		return err
 	}
 
-	// Ensure that the nonce is exactly one greater than the account's nonce
-	if acct.Nonce+1 != nonce {
-		return fmt.Errorf("%w: expected nonce %d, got %d", ErrInvalidNonce, acct.Nonce+1, nonce)
+	ahead, err := CheckNonce(acct.Nonce, nonce, nonceWindow, func(n int64) (bool, error) {
+		return hasNonceGap(ctx, tx, account.Identifier, kd.EncodeFlag(), n)
+	})
+	if err != nil {
+		return err
 	}
 
 	// Ensure that the balance is sufficient
@@ -247,11 +267,146 @@ func (a *Accounts) Spend(ctx context.Context, tx sql.Executor, account *types.Ac
 		return errInsufficientFunds(account, amount, acct.Balance)
 	}
 
+	newNonce := acct.Nonce
+	if ahead {
+		newNonce = nonce
+		if nonce > acct.Nonce+1 {
+			// Record the skipped nonces that are still inside of the window,
+			// and forget those that are now outside of it.
+			first := max(acct.Nonce+1, nonce-nonceWindow+1)
+			err = addNonceGaps(ctx, tx, account.Identifier, kd.EncodeFlag(), first, nonce-1)
+			if err != nil {
+				return err
+			}
+			err = pruneNonceGaps(ctx, tx, account.Identifier, kd.EncodeFlag(), nonce-nonceWindow)
+			if err != nil {
+				return err
+			}
+		}
+	} else {
+		err = deleteNonceGap(ctx, tx, account.Identifier, kd.EncodeFlag(), nonce)
+		if err != nil {
+			return err
+		}
+	}
+
 	// track valid spends for migration
 	// transfers, credits etc need not be tracked as theya re not allowed during migration
 	a.recordSpend(account, amount, nonce)
 
-	return a.updateAccount(ctx, tx, account, newBal, nonce)
+	return a.updateAccount(ctx, tx, account, newBal, newNonce)
+}
+
+// Debit spends an amount from an account without using a nonce. This is used
+// when a sponsor pays the fee of another account's transaction. If the account
+// does not exist or does not have enough funds, an error is returned.
+func (a *Accounts) Debit(ctx context.Context, tx sql.Executor, account *types.AccountID, amount *big.Int) error {
+	if amount.Sign() < 0 {
+		return ErrNegativeBalance
+	}
+
+	acct, err := a.getAccount(ctx, tx, account, true)
+	if err != nil {
+		return err
+	}
+
+	newBal := new(big.Int).Sub(acct.Balance, amount)
+	if newBal.Sign() < 0 {
+		return errInsufficientFunds(account, amount, acct.Balance)
+	}
+
+	// spends are replayed without their nonces, so it is tracked like any other
+	a.recordSpend(account, amount, acct.Nonce)
+
+	return a.updateAccount(ctx, tx, account, newBal, acct.Nonce)
+}
+
+// SponsorLimit returns the remaining amount of fees that the account will pay
+// for transactions that it sponsors. A nil limit means that the account has not
+// set a limit.
+func (a *Accounts) SponsorLimit(ctx context.Context, tx sql.Executor, account *types.AccountID) (*big.Int, error) {
+	kd, ok := crypto.KeyTypeDefinition(account.KeyType)
+	if !ok {
+		return nil, fmt.Errorf("invalid key type: %s", account.KeyType)
+	}
+	return getSponsorLimit(ctx, tx, account.Identifier, kd.EncodeFlag())
+}
+
+// SetSponsorLimit sets the remaining amount of fees that the account will pay
+// for transactions that it sponsors. A nil limit removes the limit.
+func (a *Accounts) SetSponsorLimit(ctx context.Context, tx sql.Executor, account *types.AccountID, limit *big.Int) error {
+	kd, ok := crypto.KeyTypeDefinition(account.KeyType)
+	if !ok {
+		return fmt.Errorf("invalid key type: %s", account.KeyType)
+	}
+	if limit == nil {
+		return deleteSponsorLimit(ctx, tx, account.Identifier, kd.EncodeFlag())
+	}
+	if limit.Sign() < 0 {
+		return ErrNegativeBalance
+	}
+	return setSponsorLimit(ctx, tx, account.Identifier, kd.EncodeFlag(), limit)
+}
+
+// RecordFees stores the accounting of the fees collected in a block.
+func (a *Accounts) RecordFees(ctx context.Context, tx sql.Executor, fees *types.FeeDistribution) error {
+	return insertFeeDistribution(ctx, tx, fees)
+}
+
+// BlockFees returns the accounting of the fees collected in the block at the
+// given height. Blocks that collected no fees have zero amounts.
+func (a *Accounts) BlockFees(ctx context.Context, tx sql.Executor, height int64) (*types.FeeDistribution, error) {
+	fees, err := getFeeDistribution(ctx, tx, height)
+	if err != nil {
+		return nil, err
+	}
+	if fees == nil {
+		return types.NewFeeDistribution(height), nil
+	}
+	return fees, nil
+}
+
+// CheckNonce checks a transaction's nonce against the highest nonce used by the
+// account and the network's nonce window. A nonce is valid if it is the next
+// sequential nonce, if it skips at most nonceWindow nonces past it, or if it is
+// a previously skipped nonce that is still within nonceWindow of the highest
+// nonce. The isGap function reports whether such a nonce was skipped and is
+// still unused. CheckNonce returns true if the nonce is ahead of the highest
+// nonce, and false if it fills a gap.
+func CheckNonce(highest, nonce, nonceWindow int64, isGap func(nonce int64) (bool, error)) (ahead bool, err error) {
+	next := highest + 1
+	switch {
+	case nonce >= next && nonce <= next+nonceWindow:
+		return true, nil
+	case nonce < next && nonce > highest-nonceWindow:
+		ok, err := isGap(nonce)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return false, nil
+		}
+		return false, fmt.Errorf("%w: nonce %d was already used", ErrInvalidNonce, nonce)
+	}
+
+	if nonceWindow == 0 {
+		return false, fmt.Errorf("%w: expected nonce %d, got %d", ErrInvalidNonce, next, nonce)
+	}
+	return false, fmt.Errorf("%w: nonce %d is outside of the window [%d, %d]", ErrInvalidNonce,
+		nonce, max(highest-nonceWindow+1, 1), next+nonceWindow)
+}
+
+func noNonceGaps(int64) (bool, error) { return false, nil }
+
+// NonceGaps returns the nonces that the account skipped over and has not yet
+// used, in ascending order. They may include nonces that are no longer within
+// the nonce window.
+func (a *Accounts) NonceGaps(ctx context.Context, tx sql.Executor, account *types.AccountID) ([]int64, error) {
+	kd, ok := crypto.KeyTypeDefinition(account.KeyType)
+	if !ok {
+		return nil, fmt.Errorf("invalid key type: %s", account.KeyType)
+	}
+	return getNonceGaps(ctx, tx, account.Identifier, kd.EncodeFlag())
 }
 
 func (a *Accounts) recordSpend(account *types.AccountID, amount *big.Int, nonce int64) {
@@ -398,6 +553,28 @@ func (a *Accounts) Rollback() {
 	a.spends = nil
 }
 
+// Savepoint records the current uncommitted account updates and spends. The
+// returned function discards any updates and spends made after the savepoint.
+// It is used when a nested DB transaction that modified accounts, such as one
+// payload in a batch, is rolled back while the block continues.
+func (a *Accounts) Savepoint() (restore func()) {
+	a.mtx.RLock()
+	defer a.mtx.RUnlock()
+
+	// the recorded accounts are replaced rather than modified, so a shallow
+	// copy is enough to restore them
+	updates := maps.Clone(a.updates)
+	numSpends := len(a.spends)
+
+	return func() {
+		a.mtx.Lock()
+		defer a.mtx.Unlock()
+
+		a.updates = updates
+		a.spends = a.spends[:numSpends:numSpends]
+	}
+}
+
 func acctMapKey(account *types.AccountID) string {
 	return string(account.Identifier) + "#" + account.KeyType.String()
 }
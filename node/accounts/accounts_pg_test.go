@@ -97,3 +97,63 @@ func TestGetAccount(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, big.NewInt(100), account.Balance)
 }
+
+func TestSponsorLimit(t *testing.T) {
+	ctx := context.Background()
+	db, err := pg.NewDB(ctx, testConfig)
+	require.NoError(t, err)
+	defer cleanupDB(ctx, db)
+
+	tx, err := db.BeginTx(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	accounts, err := InitializeAccountStore(ctx, tx, log.DiscardLogger)
+	require.NoError(t, err)
+
+	limit, err := accounts.SponsorLimit(ctx, tx, account1)
+	require.NoError(t, err)
+	require.Nil(t, limit)
+
+	require.NoError(t, accounts.SetSponsorLimit(ctx, tx, account1, big.NewInt(500)))
+	require.NoError(t, accounts.SetSponsorLimit(ctx, tx, account1, big.NewInt(300)))
+	limit, err = accounts.SponsorLimit(ctx, tx, account1)
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(300), limit)
+
+	require.NoError(t, accounts.SetSponsorLimit(ctx, tx, account1, nil))
+	limit, err = accounts.SponsorLimit(ctx, tx, account1)
+	require.NoError(t, err)
+	require.Nil(t, limit)
+}
+
+func TestBlockFees(t *testing.T) {
+	ctx := context.Background()
+	db, err := pg.NewDB(ctx, testConfig)
+	require.NoError(t, err)
+	defer cleanupDB(ctx, db)
+
+	tx, err := db.BeginTx(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx)
+
+	accounts, err := InitializeAccountStore(ctx, tx, log.DiscardLogger)
+	require.NoError(t, err)
+
+	fees, err := accounts.BlockFees(ctx, tx, 5)
+	require.NoError(t, err)
+	require.Equal(t, types.NewFeeDistribution(5), fees)
+
+	want := &types.FeeDistribution{
+		Height:     5,
+		Collected:  big.NewInt(100),
+		Leader:     big.NewInt(20),
+		Validators: big.NewInt(50),
+		Treasury:   big.NewInt(10),
+		Burned:     big.NewInt(20),
+	}
+	require.NoError(t, accounts.RecordFees(ctx, tx, want))
+	fees, err = accounts.BlockFees(ctx, tx, 5)
+	require.NoError(t, err)
+	require.Equal(t, want, fees)
+}
@@ -326,7 +326,7 @@ var acctsTestCases = []acctsTestCase{
 		fn: func(t *testing.T, db sql.DB, a *Accounts, c counter, skip bool) {
 			ctx := context.Background()
 
-			err := a.Spend(ctx, db, account1, big.NewInt(100), 1)
+			err := a.Spend(ctx, db, account1, big.NewInt(100), 1, 0)
 			require.ErrorIs(t, err, ErrAccountNotFound)
 			verifyDBAccessCount(t, c, 1, skip)
 		},
@@ -340,7 +340,7 @@ var acctsTestCases = []acctsTestCase{
 			require.NoError(t, err)
 			verifyDBAccessCount(t, c, 1, skip)
 
-			err = a.Spend(ctx, db, account1, big.NewInt(101), 1)
+			err = a.Spend(ctx, db, account1, big.NewInt(101), 1, 0)
 			require.ErrorIs(t, err, ErrInsufficientFunds)
 
 			acc, err := a.GetAccount(ctx, db, account1)
@@ -359,7 +359,7 @@ var acctsTestCases = []acctsTestCase{
 			require.NoError(t, err)
 			verifyDBAccessCount(t, c, 1, skip)
 
-			err = a.Spend(ctx, db, account1, big.NewInt(50), 2)
+			err = a.Spend(ctx, db, account1, big.NewInt(50), 2, 0)
 			require.ErrorIs(t, err, ErrInvalidNonce)
 
 			acc, err := a.GetAccount(ctx, db, account1)
@@ -378,7 +378,7 @@ var acctsTestCases = []acctsTestCase{
 			require.NoError(t, err)
 			verifyDBAccessCount(t, c, 1, skip)
 
-			err = a.Spend(ctx, db, account1, big.NewInt(50), 1)
+			err = a.Spend(ctx, db, account1, big.NewInt(50), 1, 0)
 			require.NoError(t, err)
 
 			acc, err := a.GetAccount(ctx, db, account1)
@@ -392,7 +392,7 @@ var acctsTestCases = []acctsTestCase{
 		fn: func(t *testing.T, db sql.DB, a *Accounts, c counter, skip bool) {
 			ctx := context.Background()
 
-			err := a.Spend(ctx, db, account1, big.NewInt(0), 1)
+			err := a.Spend(ctx, db, account1, big.NewInt(0), 1, 0)
 			require.NoError(t, err)
 			verifyDBAccessCount(t, c, 1, skip)
 
@@ -406,6 +406,59 @@ var acctsTestCases = []acctsTestCase{
 			verifyDBAccessCount(t, c, 1, skip)
 		},
 	},
+	{
+		name: "debit without nonce",
+		fn: func(t *testing.T, db sql.DB, a *Accounts, c counter, skip bool) {
+			ctx := context.Background()
+
+			err := a.Debit(ctx, db, account1, big.NewInt(10))
+			require.ErrorIs(t, err, ErrAccountNotFound)
+
+			err = a.Spend(ctx, db, account1, big.NewInt(0), 1, 0)
+			require.NoError(t, err)
+			err = a.Credit(ctx, db, account1, big.NewInt(100))
+			require.NoError(t, err)
+
+			err = a.Debit(ctx, db, account1, big.NewInt(101))
+			require.ErrorIs(t, err, ErrInsufficientFunds)
+
+			err = a.Debit(ctx, db, account1, big.NewInt(60))
+			require.NoError(t, err)
+
+			acc, err := a.GetAccount(ctx, db, account1)
+			require.NoError(t, err)
+			require.Equal(t, big.NewInt(40), acc.Balance)
+			require.Equal(t, int64(1), acc.Nonce) // unchanged
+		},
+	},
+	{
+		name: "savepoint restores updates",
+		fn: func(t *testing.T, db sql.DB, a *Accounts, c counter, skip bool) {
+			ctx := context.Background()
+
+			err := a.Credit(ctx, db, account1, big.NewInt(100))
+			require.NoError(t, err)
+			err = a.Spend(ctx, db, account1, big.NewInt(10), 1, 0)
+			require.NoError(t, err)
+
+			restore := a.Savepoint()
+
+			err = a.Spend(ctx, db, account1, big.NewInt(20), 2, 0)
+			require.NoError(t, err)
+			err = a.Credit(ctx, db, account2, big.NewInt(5))
+			require.NoError(t, err)
+			require.Len(t, a.Updates(), 2)
+			require.Len(t, a.GetBlockSpends(), 2)
+
+			restore()
+
+			updates := a.Updates()
+			require.Len(t, updates, 1)
+			require.Equal(t, big.NewInt(90), updates[0].Balance)
+			require.Equal(t, int64(1), updates[0].Nonce)
+			require.Len(t, a.GetBlockSpends(), 1)
+		},
+	},
 	{
 		name: "Account Cache test",
 		fn: func(t *testing.T, db sql.DB, a *Accounts, c counter, skip bool) {
@@ -415,7 +468,7 @@ var acctsTestCases = []acctsTestCase{
 			_, ok := a.records.Get(mapKey)
 			require.False(t, ok)
 
-			err := a.Spend(ctx, db, account1, big.NewInt(0), 1)
+			err := a.Spend(ctx, db, account1, big.NewInt(0), 1, 0)
 			require.NoError(t, err)
 			verifyDBAccessCount(t, c, 1, skip)
 
@@ -488,3 +541,39 @@ func Test_Accounts(t *testing.T) {
 		})
 	}
 }
+
+func Test_CheckNonce(t *testing.T) {
+	gaps := map[int64]bool{2: true, 3: true}
+	isGap := func(n int64) (bool, error) { return gaps[n], nil }
+
+	tests := []struct {
+		name    string
+		highest int64
+		nonce   int64
+		window  int64
+		ahead   bool
+		wantErr bool
+	}{
+		{"next nonce", 5, 6, 0, true, false},
+		{"no window, skip", 5, 7, 0, false, true},
+		{"no window, used", 5, 5, 0, false, true},
+		{"no window, unused gap", 5, 3, 0, false, true},
+		{"skip within window", 5, 9, 3, true, false},
+		{"skip beyond window", 5, 10, 3, false, true},
+		{"fill gap", 5, 3, 3, false, false},
+		{"used within window", 5, 4, 3, false, true},
+		{"gap outside window", 5, 2, 3, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ahead, err := CheckNonce(tt.highest, tt.nonce, tt.window, isGap)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrInvalidNonce)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.ahead, ahead)
+		})
+	}
+}
@@ -11,7 +11,7 @@ import (
 var (
 	ErrInsufficientFunds = errors.New("insufficient funds")
 	ErrConvertToBigInt   = errors.New("could not convert to big int")
-	ErrInvalidNonce      = errors.New("invalid nonce")
+	ErrInvalidNonce      = types.ErrInvalidNonce
 	ErrAccountNotFound   = errors.New("account not found")
 	ErrNegativeBalance   = errors.New("negative balance not permitted")
 	ErrNegativeTransfer  = errors.New("negative transfer not permitted")
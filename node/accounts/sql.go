@@ -14,7 +14,7 @@ import (
 const (
 	schemaName = `kwild_accts`
 
-	accountStoreVersion = 0
+	accountStoreVersion = 3
 
 	sqlInitTables = `CREATE TABLE IF NOT EXISTS ` + schemaName + `.accounts (
 		identifier BYTEA NOT NULL,
@@ -32,6 +32,66 @@ const (
 	sqlGetAccount = `SELECT balance, nonce FROM ` + schemaName + `.accounts WHERE identifier = $1 AND id_type = $2`
 
 	sqlNumAccounts = `SELECT COUNT(1) FROM ` + schemaName + `.accounts`
+
+	// nonce_gaps holds the nonces that an account skipped over when a nonce
+	// window is in effect, and which may still be used.
+	sqlInitNonceGaps = `CREATE TABLE IF NOT EXISTS ` + schemaName + `.nonce_gaps (
+		identifier BYTEA NOT NULL,
+		id_type INT4 NOT NULL,
+		nonce INT8 NOT NULL,
+		PRIMARY KEY(identifier, id_type, nonce)
+	);`
+
+	sqlAddNonceGaps = `INSERT INTO ` + schemaName + `.nonce_gaps (identifier, id_type, nonce)
+		SELECT $1, $2, generate_series($3::INT8, $4::INT8)`
+
+	sqlHasNonceGap = `SELECT 1 FROM ` + schemaName + `.nonce_gaps
+		WHERE identifier = $1 AND id_type = $2 AND nonce = $3`
+
+	sqlDeleteNonceGap = `DELETE FROM ` + schemaName + `.nonce_gaps
+		WHERE identifier = $1 AND id_type = $2 AND nonce = $3`
+
+	sqlPruneNonceGaps = `DELETE FROM ` + schemaName + `.nonce_gaps
+		WHERE identifier = $1 AND id_type = $2 AND nonce <= $3`
+
+	sqlGetNonceGaps = `SELECT nonce FROM ` + schemaName + `.nonce_gaps
+		WHERE identifier = $1 AND id_type = $2 ORDER BY nonce`
+
+	// sponsor_limits holds the remaining amount of fees that a sponsor will pay
+	// for the transactions of other accounts. Sponsors without a row have no
+	// limit other than their balance.
+	sqlInitSponsorLimits = `CREATE TABLE IF NOT EXISTS ` + schemaName + `.sponsor_limits (
+		identifier BYTEA NOT NULL,
+		id_type INT4 NOT NULL,
+		remaining TEXT NOT NULL,
+		PRIMARY KEY(identifier, id_type)
+	);`
+
+	sqlSetSponsorLimit = `INSERT INTO ` + schemaName + `.sponsor_limits (identifier, id_type, remaining)
+		VALUES ($1, $2, $3) ON CONFLICT (identifier, id_type) DO UPDATE SET remaining = $3`
+
+	sqlGetSponsorLimit = `SELECT remaining FROM ` + schemaName + `.sponsor_limits
+		WHERE identifier = $1 AND id_type = $2`
+
+	sqlDeleteSponsorLimit = `DELETE FROM ` + schemaName + `.sponsor_limits
+		WHERE identifier = $1 AND id_type = $2`
+
+	// fee_distributions holds the accounting of the transaction fees collected
+	// in each block that collected fees.
+	sqlInitFeeDistributions = `CREATE TABLE IF NOT EXISTS ` + schemaName + `.fee_distributions (
+		height INT8 PRIMARY KEY,
+		collected TEXT NOT NULL,
+		leader TEXT NOT NULL,
+		validators TEXT NOT NULL,
+		treasury TEXT NOT NULL,
+		burned TEXT NOT NULL
+	);`
+
+	sqlInsertFeeDistribution = `INSERT INTO ` + schemaName + `.fee_distributions
+		(height, collected, leader, validators, treasury, burned) VALUES ($1, $2, $3, $4, $5, $6)`
+
+	sqlGetFeeDistribution = `SELECT collected, leader, validators, treasury, burned
+		FROM ` + schemaName + `.fee_distributions WHERE height = $1`
 )
 
 func initTables(ctx context.Context, tx sql.DB) error {
@@ -43,6 +103,163 @@ func initTables(ctx context.Context, tx sql.DB) error {
 	return nil
 }
 
+// initNonceGaps is the upgrade to version 1, which adds the nonce_gaps table.
+func initNonceGaps(ctx context.Context, tx sql.DB) error {
+	_, err := tx.Execute(ctx, sqlInitNonceGaps)
+	if err != nil {
+		return fmt.Errorf("failed to initialize nonce gaps table: %w", err)
+	}
+
+	return nil
+}
+
+// initSponsorLimits is the upgrade to version 2, which adds the sponsor_limits
+// table.
+func initSponsorLimits(ctx context.Context, tx sql.DB) error {
+	_, err := tx.Execute(ctx, sqlInitSponsorLimits)
+	if err != nil {
+		return fmt.Errorf("failed to initialize sponsor limits table: %w", err)
+	}
+
+	return nil
+}
+
+// initFeeDistributions is the upgrade to version 3, which adds the
+// fee_distributions table.
+func initFeeDistributions(ctx context.Context, tx sql.DB) error {
+	_, err := tx.Execute(ctx, sqlInitFeeDistributions)
+	if err != nil {
+		return fmt.Errorf("failed to initialize fee distributions table: %w", err)
+	}
+
+	return nil
+}
+
+// insertFeeDistribution stores the fee accounting of a block.
+func insertFeeDistribution(ctx context.Context, db sql.Executor, fees *types.FeeDistribution) error {
+	_, err := db.Execute(ctx, sqlInsertFeeDistribution, fees.Height, fees.Collected.String(),
+		fees.Leader.String(), fees.Validators.String(), fees.Treasury.String(), fees.Burned.String())
+	return err
+}
+
+// getFeeDistribution returns the fee accounting of a block, or nil if the
+// block collected no fees.
+func getFeeDistribution(ctx context.Context, db sql.Executor, height int64) (*types.FeeDistribution, error) {
+	res, err := db.Execute(ctx, sqlGetFeeDistribution, height)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Rows) == 0 {
+		return nil, nil
+	}
+	if len(res.Rows[0]) != 5 {
+		return nil, fmt.Errorf("expected 5 columns, got %d", len(res.Rows[0]))
+	}
+
+	amts := make([]*big.Int, len(res.Rows[0]))
+	for i, v := range res.Rows[0] {
+		str, ok := v.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid fee amount type %T", v)
+		}
+		if amts[i], ok = new(big.Int).SetString(str, 10); !ok {
+			return nil, ErrConvertToBigInt
+		}
+	}
+
+	return &types.FeeDistribution{
+		Height:     height,
+		Collected:  amts[0],
+		Leader:     amts[1],
+		Validators: amts[2],
+		Treasury:   amts[3],
+		Burned:     amts[4],
+	}, nil
+}
+
+// setSponsorLimit sets the remaining sponsored fees of an account.
+func setSponsorLimit(ctx context.Context, db sql.Executor, acctID []byte, acctType uint32, remaining *big.Int) error {
+	_, err := db.Execute(ctx, sqlSetSponsorLimit, acctID, acctType, remaining.String())
+	return err
+}
+
+// deleteSponsorLimit removes the sponsored fee limit of an account.
+func deleteSponsorLimit(ctx context.Context, db sql.Executor, acctID []byte, acctType uint32) error {
+	_, err := db.Execute(ctx, sqlDeleteSponsorLimit, acctID, acctType)
+	return err
+}
+
+// getSponsorLimit returns the remaining sponsored fees of an account, or nil if
+// it has no limit.
+func getSponsorLimit(ctx context.Context, db sql.Executor, acctID []byte, acctType uint32) (*big.Int, error) {
+	res, err := db.Execute(ctx, sqlGetSponsorLimit, acctID, acctType)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Rows) == 0 {
+		return nil, nil
+	}
+
+	str, ok := res.Rows[0][0].(string)
+	if !ok {
+		return nil, fmt.Errorf("invalid sponsor limit type %T", res.Rows[0][0])
+	}
+	remaining, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return nil, ErrConvertToBigInt
+	}
+	return remaining, nil
+}
+
+// addNonceGaps records the nonces from first to last, inclusive, as skipped.
+// At most types.MaxNonceWindow nonces may be recorded at once.
+func addNonceGaps(ctx context.Context, db sql.Executor, acctID []byte, acctType uint32, first, last int64) error {
+	if last-first >= types.MaxNonceWindow {
+		return fmt.Errorf("too many skipped nonces: %d", last-first+1)
+	}
+	_, err := db.Execute(ctx, sqlAddNonceGaps, acctID, acctType, first, last)
+	return err
+}
+
+// hasNonceGap checks if a nonce was skipped and is still unused.
+func hasNonceGap(ctx context.Context, db sql.Executor, acctID []byte, acctType uint32, nonce int64) (bool, error) {
+	res, err := db.Execute(ctx, sqlHasNonceGap, acctID, acctType, nonce)
+	if err != nil {
+		return false, err
+	}
+	return len(res.Rows) > 0, nil
+}
+
+// deleteNonceGap marks a skipped nonce as used.
+func deleteNonceGap(ctx context.Context, db sql.Executor, acctID []byte, acctType uint32, nonce int64) error {
+	_, err := db.Execute(ctx, sqlDeleteNonceGap, acctID, acctType, nonce)
+	return err
+}
+
+// pruneNonceGaps deletes the skipped nonces that are no longer usable.
+func pruneNonceGaps(ctx context.Context, db sql.Executor, acctID []byte, acctType uint32, upTo int64) error {
+	_, err := db.Execute(ctx, sqlPruneNonceGaps, acctID, acctType, upTo)
+	return err
+}
+
+// getNonceGaps returns the skipped nonces of an account that are unused.
+func getNonceGaps(ctx context.Context, db sql.Executor, acctID []byte, acctType uint32) ([]int64, error) {
+	res, err := db.Execute(ctx, sqlGetNonceGaps, acctID, acctType)
+	if err != nil {
+		return nil, err
+	}
+
+	gaps := make([]int64, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		nonce, ok := sql.Int64(row[0])
+		if !ok {
+			return nil, fmt.Errorf("invalid nonce type %T", row[0])
+		}
+		gaps = append(gaps, nonce)
+	}
+	return gaps, nil
+}
+
 // updateAccount updates the balance and nonce of an account.
 func updateAccount(ctx context.Context, db sql.Executor, acctID []byte, acctType uint32, amount *big.Int, nonce int64) error {
 	_, err := db.Execute(ctx, sqlUpdateAccount, amount.String(), nonce, acctID, acctType)
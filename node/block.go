@@ -7,6 +7,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"os"
 	"slices"
 	"time"
 
@@ -156,6 +157,9 @@ func (n *Node) blkAnnStreamHandler(s network.Stream) {
 	rawBlk, err := request(s, []byte(getMsg), blkReadLimit)
 	if err != nil {
 		n.log.Warnf("announcer failed to provide %v due to error: %v, trying other peers", blkid, err)
+		if isTimeout(err) {
+			n.pm.ReportMisbehavior(peerID, peers.MisbehaviorTimeout)
+		}
 		// Since we are aware, ask other peers. we could also put this in a goroutine
 		s.Close() // close the announcers stream first
 		var gotHeight int64
@@ -186,17 +190,21 @@ func (n *Node) blkAnnStreamHandler(s network.Stream) {
 	blk, err := ktypes.DecodeBlock(rawBlk)
 	if err != nil {
 		n.log.Infof("decodeBlock failed for %v: %v", blkid, err)
+		n.pm.ReportMisbehavior(peerID, peers.MisbehaviorInvalidBlock)
 		return
 	}
 	if blk.Header.Height != height {
 		n.log.Infof("getblk response had unexpected height: wanted %d, got %d", height, blk.Header.Height)
+		n.pm.ReportMisbehavior(peerID, peers.MisbehaviorInvalidBlock)
 		return
 	}
 	gotBlkHash := blk.Header.Hash()
 	if gotBlkHash != blkHash {
 		n.log.Infof("invalid block hash: wanted %v, got %x", blkHash, gotBlkHash)
+		n.pm.ReportMisbehavior(peerID, peers.MisbehaviorInvalidBlock)
 		return
 	}
+	n.pm.ReportGood(peerID)
 
 	// re-announce
 	n.log.Infof("downloaded block %v of height %d from %v, notifying ce of the block", blkid, height, peerID)
@@ -289,11 +297,15 @@ func (n *Node) getBlk(ctx context.Context, blkHash types.Hash) (int64, []byte, *
 		}
 		if err != nil {
 			n.log.Info("block request failed unexpectedly", "peer", peer, "hash", blkHash)
+			if isTimeout(err) {
+				n.pm.ReportMisbehavior(peer, peers.MisbehaviorTimeout)
+			}
 			continue
 		}
 
 		if len(resp) < 8 {
 			n.log.Info("block response too short", "peer", peer, "hash", blkHash)
+			n.pm.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
 			continue
 		}
 		n.log.Debug("Obtained content for block", "block", blkHash, "elapsed", time.Since(t0))
@@ -302,26 +314,37 @@ func (n *Node) getBlk(ctx context.Context, blkHash types.Hash) (int64, []byte, *
 		var height int64
 		if err := binary.Read(rd, binary.LittleEndian, &height); err != nil {
 			n.log.Info("failed to read block height in the block response", "error", err)
+			n.pm.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
 			continue
 		}
 
 		ciBts, err := ktypes.ReadCompactBytes(rd)
 		if err != nil {
 			n.log.Info("failed to read commit info in the block response", "error", err)
+			n.pm.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
 			continue
 		}
 
 		var ci ktypes.CommitInfo
 		if err = ci.UnmarshalBinary(ciBts); err != nil {
 			n.log.Info("failed to unmarshal commit info", "error", err)
+			n.pm.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
 			continue
 		}
 
 		rawBlk, err := ktypes.ReadCompactBytes(rd)
 		if err != nil {
 			n.log.Info("failed to read block in the block response", "error", err)
+			n.pm.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
+			continue
+		}
+
+		if err = checkRawBlock(rawBlk, blkHash, height); err != nil {
+			n.log.Info("invalid block in the block response", "peer", peer, "error", err)
+			n.pm.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
 			continue
 		}
+		n.pm.ReportGood(peer)
 
 		mets.DownloadedBlock(context.Background(), height, int64(len(rawBlk)))
 
@@ -384,6 +407,22 @@ func requestBlockHeight(ctx context.Context, host host.Host, peer peer.ID,
 	}
 }
 
+// checkRawBlock checks that the header of a serialized block has the expected
+// hash and height, without decoding the transactions.
+func checkRawBlock(rawBlk []byte, blkHash types.Hash, height int64) error {
+	hdr, err := ktypes.DecodeBlockHeader(bytes.NewReader(rawBlk))
+	if err != nil {
+		return fmt.Errorf("failed to decode block header: %w", err)
+	}
+	if hdr.Height != height {
+		return fmt.Errorf("unexpected height: wanted %d, got %d", height, hdr.Height)
+	}
+	if gotHash := hdr.Hash(); gotHash != blkHash {
+		return fmt.Errorf("unexpected hash: wanted %v, got %v", blkHash, gotHash)
+	}
+	return nil
+}
+
 // readAll reads from a stream until EOF or:
 // - the stream is closed
 // - the deadline is reached
@@ -398,7 +437,7 @@ func readAll(s network.Stream, limit int64, deadline time.Time, idleTimeout time
 	for {
 		// Check absolute deadline for the entire resource.
 		if time.Now().After(deadline) {
-			return nil, fmt.Errorf("timeout")
+			return nil, fmt.Errorf("timeout: %w", os.ErrDeadlineExceeded)
 		}
 
 		// Set read deadline for this chunk.
@@ -421,10 +460,10 @@ func readAll(s network.Stream, limit int64, deadline time.Time, idleTimeout time
 }
 
 func (n *Node) getBlkHeight(ctx context.Context, height int64) (types.Hash, []byte, *ktypes.CommitInfo, int64, error) {
-	return getBlkHeight(ctx, height, n.host, n.log)
+	return getBlkHeight(ctx, height, n.host, n.pm, n.log)
 }
 
-func getBlkHeight(ctx context.Context, height int64, host host.Host, log log.Logger) (types.Hash, []byte, *ktypes.CommitInfo, int64, error) {
+func getBlkHeight(ctx context.Context, height int64, host host.Host, scorer peerScorer, log log.Logger) (types.Hash, []byte, *ktypes.CommitInfo, int64, error) {
 	availablePeers := peerHosts(host)
 	if len(availablePeers) == 0 {
 		return types.Hash{}, nil, nil, 0, types.ErrPeersNotFound
@@ -475,11 +514,15 @@ func getBlkHeight(ctx context.Context, height int64, host host.Host, log log.Log
 		if err != nil {
 			// e.g. "i/o deadline reached", probably network error
 			log.Warnf("unexpected error from %v: %v", peer, err)
+			if isTimeout(err) {
+				scorer.ReportMisbehavior(peer, peers.MisbehaviorTimeout)
+			}
 			continue
 		}
 
 		if len(resp) < types.HashLen+1 {
 			log.Warnf("block response too short")
+			scorer.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
 			continue
 		}
 
@@ -490,18 +533,21 @@ func getBlkHeight(ctx context.Context, height int64, host host.Host, log log.Log
 
 		if _, err := io.ReadFull(rd, hash[:]); err != nil {
 			log.Warn("failed to read block hash in the block response", "error", err)
+			scorer.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
 			continue
 		}
 
 		ciBts, err := ktypes.ReadCompactBytes(rd)
 		if err != nil {
 			log.Info("failed to read commit info in the block response", "error", err)
+			scorer.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
 			continue
 		}
 
 		var ci ktypes.CommitInfo
 		if err = ci.UnmarshalBinary(ciBts); err != nil {
 			log.Warn("failed to unmarshal commit info", "error", err)
+			scorer.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
 			continue
 		}
 
@@ -510,6 +556,13 @@ func getBlkHeight(ctx context.Context, height int64, host host.Host, log log.Log
 			log.Warn("failed to read block in the block response", "error", err)
 		}
 
+		if err = checkRawBlock(rawBlk, hash, height); err != nil {
+			log.Warn("invalid block in the block response", "peer", peer, "error", err)
+			scorer.ReportMisbehavior(peer, peers.MisbehaviorInvalidBlock)
+			continue
+		}
+		scorer.ReportGood(peer)
+
 		var theirBest int64
 		err = binary.Read(rd, binary.LittleEndian, &theirBest)
 		if err != nil {
@@ -10,6 +10,7 @@ import (
 	"github.com/kwilteam/kwil-db/node/mempool"
 	"github.com/kwilteam/kwil-db/node/migrations"
 	"github.com/kwilteam/kwil-db/node/snapshotter"
+	"github.com/kwilteam/kwil-db/node/statetree"
 	"github.com/kwilteam/kwil-db/node/txapp"
 	"github.com/kwilteam/kwil-db/node/types"
 	"github.com/kwilteam/kwil-db/node/types/sql"
@@ -46,6 +47,8 @@ type TxApp interface {
 	Begin(ctx context.Context, height int64) error
 	Execute(ctx *common.TxContext, db sql.DB, tx *ktypes.Transaction) *txapp.TxResponse
 	Finalize(ctx context.Context, db sql.DB, block *common.BlockContext) (approvedJoins, expiredJoins []*ktypes.AccountID, err error)
+	DistributeFees(ctx context.Context, db sql.DB, block *common.BlockContext, collected *big.Int) (*ktypes.FeeDistribution, error)
+	BlockFees(ctx context.Context, db sql.Executor, height int64) (*ktypes.FeeDistribution, error)
 	Commit() error
 	Rollback()
 	GenesisInit(ctx context.Context, db sql.DB, genesisConfig *config.GenesisConfig, chain *common.ChainContext) error
@@ -56,6 +59,16 @@ type TxApp interface {
 	NumAccounts(ctx context.Context, dbTx sql.Executor) (count, height int64, error error)
 }
 
+// StateTree is the sparse Merkle tree of user table rows whose root is
+// committed to by the app hash of networks with state proofs enabled.
+type StateTree interface {
+	Height() int64
+	Update(height int64, changes []*statetree.Change) (ktypes.Hash, error)
+	Commit(sh *ktypes.StateHashes) error
+	Rollback()
+	Rebuild(height int64, load func(add func(*statetree.Change) error) error) error
+}
+
 // Question:
 // Blockstore: Blocks, Txs, Results, AppHash (for each block)
 // What is replaying a block from the blockstore? -> do we still have the results and apphash?
@@ -16,6 +16,9 @@ import (
 	"sync/atomic"
 	"time"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/config"
 	"github.com/kwilteam/kwil-db/core/crypto"
@@ -24,6 +27,8 @@ import (
 	ktypes "github.com/kwilteam/kwil-db/core/types"
 	authExt "github.com/kwilteam/kwil-db/extensions/auth"
 	"github.com/kwilteam/kwil-db/node/meta"
+	"github.com/kwilteam/kwil-db/node/metrics"
+	"github.com/kwilteam/kwil-db/node/statetree"
 	"github.com/kwilteam/kwil-db/node/types"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 )
@@ -64,6 +69,7 @@ type BlockProcessor struct {
 	events      EventStore
 	migrator    MigratorModule
 	mempool     Mempool // only for rechecks
+	stateTree   StateTree
 	log         log.Logger
 
 	// broadcast function to send transactions to the network
@@ -169,6 +175,41 @@ func (bp *BlockProcessor) SetCallbackFns(broadcastFn BroadcastTxFn, addPeer, rem
 	bp.removePeer = removePeer
 }
 
+// SetStateTree sets the state tree, which is updated with the changes of each
+// block and whose root is included in the app hash. It must be set on all
+// nodes of a network with state proofs enabled, and before any blocks are
+// executed. If the tree is behind the committed height, such as after a crash
+// or a restore from a snapshot, it is rebuilt from the user tables.
+func (bp *BlockProcessor) SetStateTree(ctx context.Context, tree StateTree) error {
+	bp.stateTree = tree
+	height := bp.height.Load()
+	if tree.Height() >= height {
+		return nil
+	}
+
+	bp.log.Info("State tree is behind the chain, rebuilding it", "treeHeight", tree.Height(), "height", height)
+	return bp.rebuildStateTree(ctx, height)
+}
+
+// rebuildStateTree rebuilds the state tree at the given height from the
+// committed user tables.
+func (bp *BlockProcessor) rebuildStateTree(ctx context.Context, height int64) error {
+	readTx, err := bp.db.BeginReadTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin read transaction: %w", err)
+	}
+	defer readTx.Rollback(ctx)
+
+	pks, err := statetree.LoadPrimaryKeys(ctx, readTx)
+	if err != nil {
+		return fmt.Errorf("failed to load the primary keys for the state tree: %w", err)
+	}
+
+	return bp.stateTree.Rebuild(height, func(add func(*statetree.Change) error) error {
+		return statetree.LoadRows(ctx, readTx, pks, add)
+	})
+}
+
 func (bp *BlockProcessor) Close() error {
 	bp.mtx.Lock()
 	defer bp.mtx.Unlock()
@@ -214,6 +255,10 @@ func (bp *BlockProcessor) Rollback(ctx context.Context, height int64, appHash kt
 	// Rollback internal state updates to the validators, accounts and mempool.
 	bp.txapp.Rollback()
 
+	if bp.stateTree != nil {
+		bp.stateTree.Rollback()
+	}
+
 	return nil
 }
 
@@ -336,6 +381,13 @@ func (bp *BlockProcessor) InitChain(ctx context.Context) (int64, []byte, error)
 
 	bp.announceValidators()
 
+	// The genesis state may have user tables restored from a snapshot.
+	if bp.stateTree != nil {
+		if err := bp.rebuildStateTree(ctx, genCfg.InitialHeight); err != nil {
+			return -1, nil, fmt.Errorf("failed to build the genesis state tree: %w", err)
+		}
+	}
+
 	bp.height.Store(genCfg.InitialHeight)
 	if genCfg.StateHash != nil { // TODO: make it a *types.Hash
 		copy(bp.appHash[:], genCfg.StateHash)
@@ -352,6 +404,14 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 	bp.mtx.Lock()
 	defer bp.mtx.Unlock()
 
+	ctx, span := metrics.BlockProcessorTracer.Start(ctx, "block.execute",
+		trace.WithAttributes(
+			attribute.Int64("block.height", req.Height),
+			attribute.Int("block.num_txns", len(req.Block.Txns)),
+			attribute.Bool("syncing", syncing),
+		))
+	defer func() { metrics.EndSpan(span, err) }()
+
 	// TODO: TxApp.Begin is a no-op for now, un-comment when needed
 	// Begin the block execution session
 	// if err = bp.txapp.Begin(ctx, req.Height); err != nil {
@@ -399,6 +459,13 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 
 	txHashes := bp.initBlockExecutionStatus(req.Block)
 
+	// the fees collected from the block's transactions, distributed at the end
+	// of the block
+	collectedFees := new(big.Int)
+
+	// Transactions are executed sequentially, in block order, since they all
+	// run in the block's database transaction, whose changeset is part of the
+	// app hash.
 	for i, tx := range req.Block.Txns {
 		identifier, err := authExt.GetIdentifier(tx.Signature.Type, tx.Sender)
 		if err != nil {
@@ -422,9 +489,10 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 		default:
 			res := bp.txapp.Execute(txCtx, bp.consensusTx, tx)
 			txResult := ktypes.TxResult{
-				Code: uint32(res.ResponseCode),
-				Gas:  res.Spend,
-				Log:  res.Log,
+				Code:       uint32(res.ResponseCode),
+				Gas:        res.Spend,
+				Log:        res.Log,
+				SubResults: res.SubResults,
 			}
 
 			// bookkeeping for the block execution status
@@ -452,6 +520,13 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 
 			txResults[i] = txResult
 
+			// Validator vote fees are returned to the voters when the
+			// resolutions are approved, so they are not distributed.
+			if tx.Body.PayloadType != ktypes.PayloadTypeValidatorVoteIDs &&
+				tx.Body.PayloadType != ktypes.PayloadTypeValidatorVoteBodies {
+				collectedFees.Add(collectedFees, big.NewInt(res.Spend))
+			}
+
 			if isLeader && tx.Body.PayloadType == ktypes.PayloadTypeValidatorVoteBodies {
 				body := &ktypes.ValidatorVoteBodies{}
 				if err := body.UnmarshalBinary(tx.Body.Payload); err != nil {
@@ -474,6 +549,16 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 		}
 	}
 
+	// Distribute the collected fees to the leader, validators, and treasury.
+	fees, err := bp.txapp.DistributeFees(ctx, bp.consensusTx, blockCtx, collectedFees)
+	if err != nil {
+		return nil, fmt.Errorf("failed to distribute the block fees: %w", err)
+	}
+	if fees != nil && !syncing {
+		bp.log.Debug("Distributed block fees", "collected", fees.Collected, "leader", fees.Leader,
+			"validators", fees.Validators, "treasury", fees.Treasury, "burned", fees.Burned)
+	}
+
 	// Process resolutions and end-block hooks.
 	approvedJoins, expiredJoins, err := bp.txapp.Finalize(ctx, bp.consensusTx, blockCtx)
 	if err != nil {
@@ -519,6 +604,28 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 		}()
 	}
 
+	// The state tree collects the row changes from the changesets.
+	type stateChanges struct {
+		changes []*statetree.Change
+		err     error
+	}
+	var stateChangesChan chan stateChanges
+	if bp.stateTree != nil {
+		pks, err := statetree.LoadPrimaryKeys(ctx, bp.consensusTx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load the primary keys for the state tree: %w", err)
+		}
+		csChanStateTree, err := csp.Subscribe(ctx, "statetree")
+		if err != nil {
+			return nil, fmt.Errorf("failed to subscribe to changeset processor: %w", err)
+		}
+		stateChangesChan = make(chan stateChanges, 1)
+		go func() {
+			changes, err := statetree.CollectChanges(csChanStateTree, pks)
+			stateChangesChan <- stateChanges{changes, err}
+		}()
+	}
+
 	go csp.BroadcastChangesets(ctx)
 
 	changesetID, err := bp.consensusTx.Precommit(ctx, csp.csChan)
@@ -526,6 +633,22 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 		return nil, fmt.Errorf("failed to precommit the changeset: %w", err)
 	}
 
+	var stateRoot types.Hash
+	if bp.stateTree != nil {
+		var sc stateChanges
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case sc = <-stateChangesChan:
+		}
+		if sc.err != nil {
+			return nil, fmt.Errorf("failed to collect the state tree changes: %w", sc.err)
+		}
+		if stateRoot, err = bp.stateTree.Update(req.Height, sc.changes); err != nil {
+			return nil, fmt.Errorf("failed to update the state tree: %w", err)
+		}
+	}
+
 	valUpdates := bp.validators.ValidatorUpdates()
 	valUpdatesHash, valUpdatesList := validatorUpdatesHash(valUpdates)
 
@@ -551,9 +674,10 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 		ValUpdates:   valUpdatesHash,
 		TxResults:    txResultsHash,
 		ParamUpdates: paramUpdatesHash,
+		StateRoot:    stateRoot,
 	}
 
-	nextHash := bp.nextAppHash(sh)
+	nextHash := sh.AppHash()
 
 	if !syncing {
 		bp.log.Info("AppState updates: ",
@@ -588,6 +712,7 @@ func (bp *BlockProcessor) ExecuteBlock(ctx context.Context, req *ktypes.BlockExe
 		AppHash:          nextHash,
 		ValidatorUpdates: valUpdatesList,
 		ParamUpdates:     maps.Clone(bp.chainCtx.NetworkUpdates),
+		Fees:             fees,
 	}, nil
 
 }
@@ -646,10 +771,22 @@ func (bp *BlockProcessor) updatePeers(valUpdates []*ktypes.Validator, approvedJo
 
 // Commit method commits the block to the blockstore and postgres database.
 // It also updates the txIndexer and mempool with the transactions in the block.
-func (bp *BlockProcessor) Commit(ctx context.Context, req *ktypes.CommitRequest) error {
+func (bp *BlockProcessor) Commit(ctx context.Context, req *ktypes.CommitRequest) (err error) {
 	bp.mtx.Lock()
 	defer bp.mtx.Unlock()
 
+	ctx, span := metrics.BlockProcessorTracer.Start(ctx, "block.commit",
+		trace.WithAttributes(attribute.Int64("block.height", req.Height)))
+	defer func() { metrics.EndSpan(span, err) }()
+
+	// Commit the state tree first, since an update for an already committed
+	// height is skipped when the block is re-executed after a crash.
+	if bp.stateTree != nil {
+		if err := bp.stateTree.Commit(bp.stateHashes); err != nil {
+			return fmt.Errorf("failed to commit the state tree: %w", err)
+		}
+	}
+
 	// Commit the Postgres Consensus transaction
 	if err := bp.consensusTx.Commit(ctx); err != nil {
 		// maybe attempt rollback and set nil
@@ -771,29 +908,8 @@ func (bp *BlockProcessor) snapshotDB(ctx context.Context, height int64, syncing
 	return nil
 }
 
-type StateHashes struct {
-	PrevApp      types.Hash
-	Changeset    types.Hash
-	ValUpdates   types.Hash
-	Accounts     types.Hash
-	TxResults    types.Hash
-	ParamUpdates types.Hash
-}
-
-// nextAppHash calculates the appHash that encapsulates the state changes occurred during the block execution.
-// sha256(prevAppHash || changesetHash || valUpdatesHash || accountsHash || txResultsHash)
-func (bp *BlockProcessor) nextAppHash(sh *StateHashes) types.Hash {
-	hasher := ktypes.NewHasher()
-
-	hasher.Write(sh.PrevApp[:])
-	hasher.Write(sh.Changeset[:])
-	hasher.Write(sh.ValUpdates[:])
-	hasher.Write(sh.Accounts[:])
-	hasher.Write(sh.TxResults[:])
-	hasher.Write(sh.ParamUpdates[:])
-
-	return hasher.Sum(nil)
-}
+// StateHashes are the parts of the app hash.
+type StateHashes = ktypes.StateHashes
 
 func txResultsHash(results []ktypes.TxResult) types.Hash {
 	hasher := ktypes.NewHasher()
@@ -914,6 +1030,12 @@ func (bp *BlockProcessor) NumAccounts(ctx context.Context, db sql.Executor) (cou
 	return bp.txapp.NumAccounts(ctx, db)
 }
 
+// BlockFees returns the accounting of the fees collected in the block at the
+// given height.
+func (bp *BlockProcessor) BlockFees(ctx context.Context, db sql.Executor, height int64) (*ktypes.FeeDistribution, error) {
+	return bp.txapp.BlockFees(ctx, db, height)
+}
+
 func (bp *BlockProcessor) GetValidators() []*ktypes.Validator {
 	return bp.validators.GetValidators()
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"sort"
 
@@ -120,9 +121,15 @@ func (bp *BlockProcessor) prepareBlockTransactions(ctx context.Context, readTx s
 			}
 		}
 
-		// Drop transactions from unfunded accounts in gasEnabled mode
+		// Drop transactions from unfunded accounts in gasEnabled mode. The
+		// sponsor of a sponsored transaction pays its fee, so it must be funded
+		// instead of the sender.
 		if !bp.chainCtx.NetworkParameters.DisabledGasCosts {
-			ident, err := txapp.TxSenderAcctID(tx.Transaction)
+			acctIDFn := txapp.TxSenderAcctID
+			if tx.Sponsor != nil {
+				acctIDFn = txapp.TxSponsorAcctID
+			}
+			ident, err := acctIDFn(tx.Transaction)
 			if err != nil {
 				bp.log.Error("failed to get sender info", "error", err)
 				continue
@@ -136,7 +143,7 @@ func (bp *BlockProcessor) prepareBlockTransactions(ctx context.Context, readTx s
 
 			if nonce == 0 && balance.Sign() == 0 {
 				invalidTxs = append(invalidTxs, tx.Transaction)
-				bp.log.Warn("Dropping tx from unfunded account while preparing the block", "account", hex.EncodeToString(tx.Sender))
+				bp.log.Warn("Dropping tx from unfunded account while preparing the block", "account", hex.EncodeToString(ident.Identifier))
 				continue
 			}
 		}
@@ -227,7 +234,9 @@ func (bp *BlockProcessor) prepareValidatorVoteBodyTx(ctx context.Context, readTx
 		return nil, err
 	}
 
-	if nonce == 0 {
+	// The proposer's mempool transactions may only fill nonce gaps when the
+	// network has a nonce window, so never go below the account's nonce.
+	if nonce < n {
 		nonce = n
 	}
 
@@ -439,6 +448,50 @@ func (bp *BlockProcessor) PrepareValidatorVoteIDTx(ctx context.Context, db sql.D
 	return tx, ids, nil
 }
 
+// BroadcastEvidenceTx submits evidence that a validator equivocated in a
+// transaction signed by this node. The evidence is not submitted if the node
+// cannot pay the transaction fee.
+func (bp *BlockProcessor) BroadcastEvidenceTx(ctx context.Context, ev *types.Evidence) error {
+	readTx, err := bp.db.BeginReadTx(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin read transaction: %w", err)
+	}
+	defer readTx.Rollback(ctx)
+
+	acctID, err := types.GetSignerAccount(bp.signer)
+	if err != nil {
+		return fmt.Errorf("failed to get signer account: %w", err)
+	}
+
+	bal, nonce, err := bp.AccountInfo(ctx, readTx, acctID, true)
+	if err != nil {
+		return fmt.Errorf("failed to get account info: %w", err)
+	}
+
+	tx, err := types.CreateTransaction(ev, bp.chainCtx.ChainID, uint64(nonce)+1)
+	if err != nil {
+		return err
+	}
+
+	fee, err := bp.Price(ctx, readTx, tx)
+	if err != nil {
+		return fmt.Errorf("failed to estimate fee: %w", err)
+	}
+	tx.Body.Fee = fee
+
+	if bal.Cmp(fee) < 0 {
+		bp.log.Warnf("skipping evidence broadcast: not enough balance to pay for the tx fee, balance: %s, fee: %s", bal.String(), fee.String())
+		return nil
+	}
+
+	if err = tx.Sign(bp.signer); err != nil {
+		return fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	_, _, err = bp.broadcastTxFn(ctx, tx, 0)
+	return err
+}
+
 // verifyTransaction verifies a transaction's signature using the Authenticator
 // registry in this package.
 func verifyTransaction(tx *types.Transaction) error {
@@ -447,5 +500,24 @@ func verifyTransaction(tx *types.Transaction) error {
 		return err
 	}
 
-	return authExt.VerifySignature(tx.Sender, msg, tx.Signature)
+	if err = authExt.VerifySignature(tx.Sender, msg, tx.Signature); err != nil {
+		return err
+	}
+
+	if tx.Sponsor == nil {
+		return nil
+	}
+
+	// The sponsor signs the sender's message, the sender, and its max fee.
+	sponsorMsg, err := tx.SponsorshipMsg(tx.Sponsor.MaxFee)
+	if err != nil {
+		return fmt.Errorf("invalid sponsorship: %w", err)
+	}
+	if tx.Sponsor.Signature == nil {
+		return errors.New("sponsorship is not signed")
+	}
+	if err = authExt.VerifySignature(tx.Sponsor.Sender, sponsorMsg, tx.Sponsor.Signature); err != nil {
+		return fmt.Errorf("invalid sponsor signature: %w", err)
+	}
+	return nil
 }
@@ -689,6 +689,14 @@ func (m *mockTxApp) ApplyMempool(ctx *common.TxContext, db sql.DB, tx *types.Tra
 	return nil
 }
 
+func (m *mockTxApp) DistributeFees(ctx context.Context, db sql.DB, block *common.BlockContext, collected *big.Int) (*types.FeeDistribution, error) {
+	return nil, nil
+}
+
+func (m *mockTxApp) BlockFees(ctx context.Context, db sql.Executor, height int64) (*types.FeeDistribution, error) {
+	return types.NewFeeDistribution(height), nil
+}
+
 func (m *mockTxApp) Begin(ctx context.Context, height int64) error {
 	return nil
 }
@@ -12,6 +12,7 @@ import (
 	"io"
 	"slices"
 	"sync"
+	"time"
 
 	ktypes "github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/node/peers"
@@ -167,8 +168,15 @@ func (n *Node) announceBlkProp(ctx context.Context, blk *ktypes.Block, skipPeers
 	blkHash := blk.Hash()
 	height := blk.Header.Height
 
+	// Transactions that are not in our mempool, such as those added by the
+	// leader when preparing the proposal, are unlikely to be in the peers'
+	// mempools, so they are sent in full with the compact block.
+	compactBlk, _ := newCompactBlock(blk, func(txHash types.Hash) bool {
+		return n.mp.Get(txHash) != nil
+	}).MarshalBinary()
+
 	n.log.Debug("announcing proposed block", "hash", blkHash, "height", height,
-		"txs", len(blk.Txns), "size", len(rawBlk))
+		"txs", len(blk.Txns), "size", len(rawBlk), "compactSize", len(compactBlk))
 
 	peers := n.peers()
 	if len(peers) == 0 {
@@ -177,17 +185,23 @@ func (n *Node) announceBlkProp(ctx context.Context, blk *ktypes.Block, skipPeers
 	}
 
 	skipPeers = append(skipPeers, n.host.ID()) // always skip self
+	peers = slices.DeleteFunc(peers, func(peerID peer.ID) bool {
+		return slices.Contains(skipPeers, peerID)
+	})
+	n.advertiseBlkPropTo(ctx, blk, rawBlk, compactBlk, peers)
+}
+
+// advertiseBlkPropTo advertises a proposed block to each of the given peers.
+func (n *Node) advertiseBlkPropTo(ctx context.Context, blk *ktypes.Block, rawBlk, compactBlk []byte, peers []peer.ID) {
+	blkHash := blk.Hash()
+	height := blk.Header.Height
 	for _, peerID := range peers {
-		if slices.Contains(skipPeers, peerID) {
-			continue
-		}
 		prop := blockProp{Height: height, Hash: blkHash, PrevHash: blk.Header.PrevHash,
 			Stamp: blk.Header.Timestamp.UnixMilli(), LeaderSig: blk.Signature}
 		n.log.Debugf("advertising block proposal %s (height %d / txs %d) to peer %v", blkHash, height, len(blk.Txns), peerID)
 		// resID := annPropMsgPrefix + strconv.Itoa(int(height)) + ":" + prevHash + ":" + blkid
 		propID, _ := prop.MarshalBinary()
-		err := n.advertiseToPeer(ctx, peerID, ProtocolIDBlockPropose, contentAnn{prop.String(), propID, rawBlk},
-			blkSendTimeout)
+		err := n.advertiseBlkProp(ctx, peerID, propID, rawBlk, compactBlk)
 		if err != nil {
 			n.log.Infof(err.Error())
 			continue
@@ -195,17 +209,94 @@ func (n *Node) announceBlkProp(ctx context.Context, blk *ktypes.Block, skipPeers
 	}
 }
 
+// advertiseBlkProp is like advertiseToPeer, but for a block proposal that may
+// be requested as either a full or compact block. Peers that do not support
+// ProtocolIDBlockProposeCompact may only request the full block. A peer that
+// requests the compact block may then request the full block if it is unable
+// to get all of the block's transactions, otherwise it hangs up.
+func (n *Node) advertiseBlkProp(ctx context.Context, peerID peer.ID, propID, rawBlk, compactBlk []byte) error {
+	s, err := n.host.NewStream(ctx, peerID, ProtocolIDBlockProposeCompact, ProtocolIDBlockPropose)
+	if err != nil {
+		return fmt.Errorf("failed to open stream to peer: %w", peers.CompressDialError(err))
+	}
+	proto := s.Protocol()
+
+	s.SetWriteDeadline(time.Now().Add(annWriteTimeout))
+
+	if _, err = s.Write(propID); err != nil {
+		s.Close()
+		return fmt.Errorf("send block proposal failed: %w", err)
+	}
+
+	mets.Advertised(ctx, string(proto))
+
+	go func() {
+		defer s.Close()
+
+		s.SetReadDeadline(time.Now().Add(annRespTimeout))
+
+		var sentCompact bool
+		for {
+			req := make([]byte, len(getMsg))
+			nr, err := io.ReadFull(s, req)
+			if nr == 0 && (err == nil || errors.Is(err, io.EOF)) {
+				if !sentCompact { // they didn't want it
+					mets.AdvertiseRejected(ctx, string(proto))
+				}
+				return
+			}
+			if err != nil {
+				n.log.Warn("bad block proposal response", "error", err)
+				return
+			}
+
+			switch string(req) {
+			case getMsg:
+				s.SetWriteDeadline(time.Now().Add(blkSendTimeout))
+				s.Write(rawBlk)
+				mets.AdvertiseServed(ctx, string(proto), int64(len(rawBlk)))
+				return
+			case compactMsg:
+				if sentCompact || proto != ProtocolIDBlockProposeCompact {
+					n.log.Warn("unexpected compact block proposal request")
+					return
+				}
+				sentCompact = true
+				s.SetWriteDeadline(time.Now().Add(blkSendTimeout))
+				if err := ktypes.WriteBytes(s, compactBlk); err != nil {
+					n.log.Warn("failed to send compact block proposal", "error", err)
+					return
+				}
+				mets.AdvertiseServed(ctx, string(proto), int64(len(compactBlk)))
+				// wait for the peer to get any missing transactions
+				s.SetReadDeadline(time.Now().Add(compactBlkFillTimeout + annRespTimeout))
+			default:
+				n.log.Warn("bad block proposal response", "resp", hex.EncodeToString(req))
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
 // blkPropStreamHandler is the stream handler for the ProtocolIDBlockPropose
-// protocol i.e. proposed block announcements, which originate from the leader,
-// but may be re-announced by other validators.
+// and ProtocolIDBlockProposeCompact protocols i.e. proposed block
+// announcements, which originate from the leader, but may be re-announced by
+// other validators.
 //
 // This stream should:j
 //  1. provide the announcement to the consensus engine (CE)
 //  2. if the CE rejects the ann, close stream
-//  3. if the CE is ready for this proposed block, request the block
+//  3. if the CE is ready for this proposed block, request the block, as a
+//     compact block if the protocol supports it, or the full block
 //  4. provide the block contents to the CE
 //  5. close the stream
 //
+// A node that does not process proposals, such as a sentry node, may instead
+// download a proposal that the CE approves for relay, and advertise it to its
+// private peers, or from a private peer to all others.
+//
 // Note that CE decides what to do. For instance, after we provide the full
 // block contents, the CE will likely begin executing the blocks. When it is
 // done, it will send an ACK/NACK with the
@@ -243,53 +334,387 @@ func (n *Node) blkPropStreamHandler(s network.Stream) {
 		"from_peer", peers.PeerIDStringer(from))
 
 	if !n.ce.AcceptProposal(height, prop.Hash, prop.PrevHash, prop.LeaderSig, prop.Stamp) {
+		// A sentry node does not process proposals, but relays them for its
+		// private peers, which only connect to the network through it.
+		if relayTo := n.propRelayPeers(from); len(relayTo) > 0 && prop.Hash != n.relayedProp &&
+			n.ce.AcceptRelay(height, prop.Hash, prop.LeaderSig) {
+			n.relayBlkProp(s, &prop, from, relayTo)
+			return
+		}
 		// NOTE: if this is ahead of our last commit height, we have to try to catch up
 		n.log.Debug("do not want proposal content", "height", height, "hash", prop.Hash,
 			"prevHash", prop.PrevHash)
 		return
 	}
 
-	_, err = s.Write([]byte(getMsg))
-	if err != nil {
-		n.log.Warnf("failed to request block proposal contents: %w", err)
+	blk := n.getBlkProp(s, &prop, from)
+	if blk == nil {
 		return
 	}
+	hash := prop.Hash
 
-	rd := bufio.NewReader(s)
-	blkProp, err := io.ReadAll(rd)
-	if err != nil {
-		n.log.Warnf("failed to read block proposal contents: %w", err)
-		return
+	n.log.Info("processing block proposal", "height", height, "hash", hash,
+		"from", peers.PeerIDStringer(from))
+
+	ceProcessing = true // ce will call done now, neuter the defer
+
+	n.ce.NotifyBlockProposal(blk, sync.OnceFunc(func() { // make the callback idempotent, and trigger reannounce
+		done()
+		go n.announceBlkProp(context.Background(), blk, s.Conn().RemotePeer())
+	}))
+}
+
+// getBlkProp requests the contents of an accepted block proposal, as a compact
+// block if the protocol supports it, or the full block. It returns nil if the
+// block could not be retrieved or does not match the proposal.
+func (n *Node) getBlkProp(s network.Stream, prop *blockProp, from peer.ID) *ktypes.Block {
+	height := prop.Height
+
+	var blk *ktypes.Block
+	var err error
+	if s.Protocol() == ProtocolIDBlockProposeCompact {
+		blk, err = n.getCompactBlkProp(s, from)
+		if err != nil {
+			n.log.Info("unable to use compact block proposal, requesting full block",
+				"height", height, "hash", prop.Hash, "error", err)
+		}
 	}
 
-	// Q: header first, or full serialized block?
+	if blk == nil {
+		_, err = s.Write([]byte(getMsg))
+		if err != nil {
+			n.log.Warnf("failed to request block proposal contents: %w", err)
+			return nil
+		}
 
-	blk, err := ktypes.DecodeBlock(blkProp)
-	if err != nil {
-		n.log.Warnf("decodeBlock failed for proposal at height %d: %v", height, err)
-		return
+		rd := bufio.NewReader(s)
+		blkProp, err := io.ReadAll(rd)
+		if err != nil {
+			n.log.Warnf("failed to read block proposal contents: %w", err)
+			return nil
+		}
+
+		// Q: header first, or full serialized block?
+
+		blk, err = ktypes.DecodeBlock(blkProp)
+		if err != nil {
+			n.log.Warnf("decodeBlock failed for proposal at height %d: %v", height, err)
+			n.pm.ReportMisbehavior(from, peers.MisbehaviorInvalidBlock)
+			return nil
+		}
 	}
 	if blk.Header.Height != height {
 		n.log.Warnf("unexpected height: wanted %d, got %d", height, blk.Header.Height)
-		return
+		n.pm.ReportMisbehavior(from, peers.MisbehaviorInvalidBlock)
+		return nil
 	}
 
 	annHash := prop.Hash
-	hash := blk.Header.Hash()
-	if hash != annHash {
+	if hash := blk.Header.Hash(); hash != annHash {
 		n.log.Warnf("unexpected hash: wanted %s, got %s", hash, annHash)
+		n.pm.ReportMisbehavior(from, peers.MisbehaviorInvalidBlock)
+		return nil
+	}
+	return blk
+}
+
+// propRelayPeers returns the peers to which a sentry node relays a block
+// proposal from the given peer. Proposals from the network are relayed to the
+// private peers, and those from a private peer are relayed to all others.
+func (n *Node) propRelayPeers(from peer.ID) []peer.ID {
+	fromPrivate := n.pm.IsPrivate(from)
+	var relayTo []peer.ID
+	for _, peerID := range n.peers() {
+		if peerID == from || peerID == n.host.ID() {
+			continue
+		}
+		if fromPrivate || n.pm.IsPrivate(peerID) {
+			relayTo = append(relayTo, peerID)
+		}
+	}
+	return relayTo
+}
+
+// relayBlkProp retrieves the contents of a block proposal that this node will
+// not process, and advertises it to the given peers. The caller must hold
+// blkPropHandling.
+func (n *Node) relayBlkProp(s network.Stream, prop *blockProp, from peer.ID, relayTo []peer.ID) {
+	blk := n.getBlkProp(s, prop, from)
+	if blk == nil {
+		return
+	}
+	if !bytes.Equal(blk.Signature, prop.LeaderSig) {
+		n.log.Warnf("block proposal signature mismatch at height %d", prop.Height)
+		n.pm.ReportMisbehavior(from, peers.MisbehaviorInvalidBlock)
 		return
 	}
+	n.relayedProp = prop.Hash
 
-	n.log.Info("processing block proposal", "height", height, "hash", hash,
-		"from", peers.PeerIDStringer(from))
+	n.log.Info("relaying block proposal", "height", prop.Height, "hash", prop.Hash,
+		"from", peers.PeerIDStringer(from), "peers", len(relayTo))
 
-	ceProcessing = true // ce will call done now, neuter the defer
+	rawBlk := ktypes.EncodeBlock(blk)
+	compactBlk, _ := newCompactBlock(blk, func(txHash types.Hash) bool {
+		return n.mp.Get(txHash) != nil
+	}).MarshalBinary()
+	go n.advertiseBlkPropTo(context.Background(), blk, rawBlk, compactBlk, relayTo)
+}
 
-	n.ce.NotifyBlockProposal(blk, sync.OnceFunc(func() { // make the callback idempotent, and trigger reannounce
-		done()
-		go n.announceBlkProp(context.Background(), blk, s.Conn().RemotePeer())
-	}))
+// compactBlkFillTimeout is the time allowed to get the transactions of a
+// compact block that are not in the mempool.
+const compactBlkFillTimeout = 20 * time.Second
+
+// getCompactBlkProp requests the compact block for an accepted proposal, and
+// assembles the block with transactions from the mempool, and any others from
+// the peer that sent the proposal. If the compact block is received but cannot
+// be assembled, the stream may still be used to request the full block.
+func (n *Node) getCompactBlkProp(s network.Stream, from peer.ID) (*ktypes.Block, error) {
+	if _, err := s.Write([]byte(compactMsg)); err != nil {
+		return nil, fmt.Errorf("failed to request compact block: %w", err)
+	}
+
+	s.SetReadDeadline(time.Now().Add(blkGetTimeout))
+	var length uint32
+	if err := binary.Read(s, ktypes.SerializationByteOrder, &length); err != nil {
+		return nil, fmt.Errorf("failed to read compact block: %w", err)
+	}
+	if length > blkReadLimit {
+		return nil, fmt.Errorf("compact block too large: %d", length)
+	}
+	raw := make([]byte, length)
+	if _, err := io.ReadFull(s, raw); err != nil {
+		return nil, fmt.Errorf("failed to read compact block: %w", err)
+	}
+
+	var cb compactBlock
+	if err := cb.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("invalid compact block: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), compactBlkFillTimeout)
+	defer cancel()
+
+	return cb.assemble(ctx, func(txHash types.Hash) *ktypes.Transaction {
+		if tx := n.mp.Get(txHash); tx != nil {
+			return tx.Transaction
+		}
+		return nil
+	}, func(ctx context.Context, txHash types.Hash) ([]byte, error) {
+		return getTx(ctx, txHash, from, n.host)
+	})
+}
+
+// compactBlock is a block with transactions that are sent as just their
+// hashes, with the expectation that the recipient has them in its mempool.
+type compactBlock struct {
+	Header    *ktypes.BlockHeader
+	Signature []byte
+	Txns      []compactTx
+}
+
+// compactTx is either the hash of a transaction, or the full transaction.
+type compactTx struct {
+	Hash types.Hash
+	Tx   *ktypes.Transaction // nil if only the hash is sent
+}
+
+// newCompactBlock creates a compact block with the hashes of the block's
+// transactions for which have returns true, and the others in full.
+func newCompactBlock(blk *ktypes.Block, have func(types.Hash) bool) *compactBlock {
+	txns := make([]compactTx, len(blk.Txns))
+	for i, tx := range blk.Txns {
+		txHash := tx.HashCache()
+		txns[i].Hash = txHash
+		if !have(txHash) {
+			txns[i].Tx = tx
+		}
+	}
+	return &compactBlock{
+		Header:    blk.Header,
+		Signature: blk.Signature,
+		Txns:      txns,
+	}
+}
+
+const (
+	compactTxHash byte = iota
+	compactTxFull
+)
+
+var _ encoding.BinaryMarshaler = (*compactBlock)(nil)
+
+func (cb *compactBlock) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := ktypes.WriteCompactBytes(&buf, ktypes.EncodeBlockHeader(cb.Header)); err != nil {
+		return nil, err
+	}
+	if err := ktypes.WriteCompactBytes(&buf, cb.Signature); err != nil {
+		return nil, err
+	}
+	if err := binary.Write(&buf, ktypes.SerializationByteOrder, uint32(len(cb.Txns))); err != nil {
+		return nil, err
+	}
+	for _, tx := range cb.Txns {
+		if tx.Tx == nil {
+			buf.WriteByte(compactTxHash)
+			buf.Write(tx.Hash[:])
+			continue
+		}
+		buf.WriteByte(compactTxFull)
+		if err := ktypes.WriteCompactBytes(&buf, tx.Tx.Bytes()); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+var _ encoding.BinaryUnmarshaler = (*compactBlock)(nil)
+
+func (cb *compactBlock) UnmarshalBinary(data []byte) error {
+	r := bytes.NewReader(data)
+
+	hdrBts, err := ktypes.ReadCompactBytes(r)
+	if err != nil {
+		return fmt.Errorf("failed to read block header: %w", err)
+	}
+	hdr, err := ktypes.DecodeBlockHeader(bytes.NewReader(hdrBts))
+	if err != nil {
+		return fmt.Errorf("failed to decode block header: %w", err)
+	}
+
+	sig, err := ktypes.ReadCompactBytes(r)
+	if err != nil {
+		return fmt.Errorf("failed to read signature: %w", err)
+	}
+
+	var numTxns uint32
+	if err := binary.Read(r, ktypes.SerializationByteOrder, &numTxns); err != nil {
+		return fmt.Errorf("failed to read number of transactions: %w", err)
+	}
+	if int64(numTxns) > int64(r.Len()) { // at least one byte each
+		return fmt.Errorf("invalid number of transactions %d", numTxns)
+	}
+
+	txns := make([]compactTx, numTxns)
+	for i := range txns {
+		kind, err := r.ReadByte()
+		if err != nil {
+			return fmt.Errorf("failed to read transaction %d: %w", i, err)
+		}
+		switch kind {
+		case compactTxHash:
+			if _, err := io.ReadFull(r, txns[i].Hash[:]); err != nil {
+				return fmt.Errorf("failed to read transaction hash %d: %w", i, err)
+			}
+		case compactTxFull:
+			rawTx, err := ktypes.ReadCompactBytes(r)
+			if err != nil {
+				return fmt.Errorf("failed to read transaction %d: %w", i, err)
+			}
+			tx := new(ktypes.Transaction)
+			if err := tx.UnmarshalBinary(rawTx); err != nil {
+				return fmt.Errorf("failed to decode transaction %d: %w", i, err)
+			}
+			txns[i] = compactTx{Hash: tx.HashCache(), Tx: tx}
+		default:
+			return fmt.Errorf("invalid transaction kind %d", kind)
+		}
+	}
+
+	if r.Len() != 0 {
+		return errors.New("extra data after compact block")
+	}
+
+	cb.Header, cb.Signature, cb.Txns = hdr, sig, txns
+	return nil
+}
+
+// maxConcurrentTxFetches limits the concurrent requests for transactions of
+// a compact block that are not in the mempool.
+const maxConcurrentTxFetches = 16
+
+// assemble creates the full block, with the transactions that are not in the
+// compact block from get, or if get returns nil, from fetch. The block's
+// transactions are verified against the merkle root in the header, which the
+// caller should verify is the header of the proposed block.
+func (cb *compactBlock) assemble(ctx context.Context, get func(types.Hash) *ktypes.Transaction,
+	fetch func(context.Context, types.Hash) ([]byte, error)) (*ktypes.Block, error) {
+	if cb.Header == nil {
+		return nil, errors.New("missing block header")
+	}
+	if len(cb.Txns) != int(cb.Header.NumTxns) {
+		return nil, fmt.Errorf("block header has %d transactions, compact block has %d",
+			cb.Header.NumTxns, len(cb.Txns))
+	}
+
+	txns := make([]*ktypes.Transaction, len(cb.Txns))
+	var missing []int
+	for i, ctxn := range cb.Txns {
+		if ctxn.Tx != nil {
+			txns[i] = ctxn.Tx
+		} else if tx := get(ctxn.Hash); tx != nil {
+			txns[i] = tx
+		} else {
+			missing = append(missing, i)
+		}
+	}
+
+	if len(missing) > 0 {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		var wg sync.WaitGroup
+		var errOnce sync.Once
+		var fetchErr error
+		sem := make(chan struct{}, maxConcurrentTxFetches)
+		for _, i := range missing {
+			txHash := cb.Txns[i].Hash
+			sem <- struct{}{}
+			wg.Add(1)
+			go func() {
+				defer func() { <-sem; wg.Done() }()
+				tx, err := fetchTx(ctx, txHash, fetch)
+				if err != nil {
+					errOnce.Do(func() {
+						fetchErr = err
+						cancel()
+					})
+					return
+				}
+				txns[i] = tx
+			}()
+		}
+		wg.Wait()
+		if fetchErr != nil {
+			return nil, fetchErr
+		}
+	}
+
+	blk := &ktypes.Block{
+		Header:    cb.Header,
+		Txns:      txns,
+		Signature: cb.Signature,
+	}
+	if root := blk.CalcMerkleRoot(); root != cb.Header.MerkleRoot {
+		return nil, fmt.Errorf("transactions do not match merkle root: wanted %s, got %s",
+			cb.Header.MerkleRoot, root)
+	}
+	return blk, nil
+}
+
+func fetchTx(ctx context.Context, txHash types.Hash, fetch func(context.Context, types.Hash) ([]byte, error)) (*ktypes.Transaction, error) {
+	rawTx, err := fetch(ctx, txHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction %s: %w", txHash, err)
+	}
+	tx := new(ktypes.Transaction)
+	if err := tx.UnmarshalBinary(rawTx); err != nil {
+		return nil, fmt.Errorf("invalid transaction %s: %w", txHash, err)
+	}
+	if tx.HashCache() != txHash {
+		return nil, fmt.Errorf("unexpected transaction: wanted %s, got %s", txHash, tx.HashCache())
+	}
+	return tx, nil
 }
 
 // sendACK is a callback for the result of validator block execution/precommit.
@@ -97,6 +97,14 @@ type ConsensusEngine struct {
 	leaderMtx     sync.RWMutex
 	leaderFile    string // file to persist the leader updates and load from on startup
 
+	// equivocation evidence that this node has already submitted
+	evidenceMtx      sync.Mutex
+	reportedEvidence map[ktypes.Hash]bool
+	// lastProposalStamp is the timestamp of the leader's latest proposal.
+	// Proposals always have increasing timestamps, so that a re-proposal of a
+	// height is not mistaken for equivocation.
+	lastProposalStamp time.Time
+
 	// Channels
 	newBlockProposal chan struct{} // triggers block production in the leader
 	// newRound triggers the start of a new round in the consensus engine.
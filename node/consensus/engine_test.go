@@ -1063,6 +1063,14 @@ func (d *dummyTxApp) Finalize(ctx context.Context, db sql.DB, block *common.Bloc
 	return nil, nil, nil
 }
 
+func (d *dummyTxApp) DistributeFees(ctx context.Context, db sql.DB, block *common.BlockContext, collected *big.Int) (*ktypes.FeeDistribution, error) {
+	return nil, nil
+}
+
+func (d *dummyTxApp) BlockFees(ctx context.Context, db sql.Executor, height int64) (*ktypes.FeeDistribution, error) {
+	return ktypes.NewFeeDistribution(height), nil
+}
+
 func (d *dummyTxApp) Price(ctx context.Context, dbTx sql.DB, tx *ktypes.Transaction, chainContext *common.ChainContext) (*big.Int, error) {
 	return big.NewInt(0), nil
 }
@@ -0,0 +1,123 @@
+package consensus
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+)
+
+// An honest leader that re-proposes a block for a height, such as after a
+// reset, always uses a newer timestamp, and validators only accept a
+// conflicting proposal for the same height if it is newer. Two different
+// blocks with the same height and timestamp that are both signed by the leader
+// or both accepted by a validator are therefore evidence of equivocation,
+// which the node submits to the network in a transaction so that the offender
+// is penalized.
+
+// reportDuplicateProposal reports the leader for signing two different block
+// proposals with the same height and timestamp.
+func (ce *ConsensusEngine) reportDuplicateProposal(ctx context.Context, blkA, blkB *ktypes.Block) {
+	ev := &ktypes.Evidence{
+		Kind:       ktypes.EvidenceDuplicateProposal,
+		Offender:   ce.leader.Bytes(),
+		KeyType:    ce.leader.Type(),
+		HeaderA:    blkA.Header,
+		HeaderB:    blkB.Header,
+		SignatureA: blkA.Signature,
+		SignatureB: blkB.Signature,
+	}
+
+	ce.submitEvidence(ctx, ev)
+}
+
+// checkConflictingCommit compares a block announced for an already committed
+// height with the committed block. If they conflict, the leader and any
+// validators that voted for both blocks are reported.
+func (ce *ConsensusEngine) checkConflictingCommit(ctx context.Context, blkID ktypes.Hash, hdr *ktypes.BlockHeader, ci *ktypes.CommitInfo, leaderSig []byte) {
+	if hdr == nil || ci == nil || hdr.Hash() != blkID {
+		return
+	}
+
+	committedID, committed, committedCI, err := ce.blockStore.GetByHeight(hdr.Height)
+	if err != nil || committedID == blkID || committedCI == nil {
+		return
+	}
+	if committed.Header.Timestamp.UnixMilli() != hdr.Timestamp.UnixMilli() {
+		return // a newer proposal that replaced the committed one, not equivocation
+	}
+
+	ce.log.Warn("Received a conflicting block for a committed height", "height", hdr.Height,
+		"committed", committedID, "conflicting", blkID)
+
+	if len(leaderSig) > 0 && committed.Header.NewLeader == nil && hdr.NewLeader == nil {
+		ce.reportDuplicateProposal(ctx, committed, &ktypes.Block{Header: hdr, Signature: leaderSig})
+	}
+
+	for _, vote := range ci.Votes {
+		if !vote.AckStatus.WasAck() {
+			continue
+		}
+		for _, prev := range committedCI.Votes {
+			if !prev.AckStatus.WasAck() || prev.Signature.PubKeyType != vote.Signature.PubKeyType ||
+				!bytes.Equal(prev.Signature.PubKey, vote.Signature.PubKey) {
+				continue
+			}
+
+			ev := &ktypes.Evidence{
+				Kind:       ktypes.EvidenceDuplicateVote,
+				Offender:   vote.Signature.PubKey,
+				KeyType:    vote.Signature.PubKeyType,
+				HeaderA:    committed.Header,
+				HeaderB:    hdr,
+				SignatureA: prev.Signature.Data,
+				SignatureB: vote.Signature.Data,
+				AppHashA:   voteAppHash(prev, committedCI.AppHash),
+				AppHashB:   voteAppHash(vote, ci.AppHash),
+			}
+			ce.submitEvidence(ctx, ev)
+		}
+	}
+}
+
+// voteAppHash returns the app hash that an accepting vote was signed with.
+func voteAppHash(vote *ktypes.VoteInfo, commitAppHash ktypes.Hash) *ktypes.Hash {
+	if vote.AckStatus == ktypes.AckForked && vote.AppHash != nil {
+		return vote.AppHash
+	}
+	appHash := commitAppHash
+	return &appHash
+}
+
+// submitEvidence verifies the evidence and submits it to the network in a
+// transaction signed by this node, unless the offense was already reported.
+func (ce *ConsensusEngine) submitEvidence(ctx context.Context, ev *ktypes.Evidence) {
+	if err := ev.Verify(); err != nil {
+		ce.log.Warn("Conflicting signatures are not valid evidence", "kind", ev.Kind, "error", err)
+		return
+	}
+
+	id := ev.ID()
+	ce.evidenceMtx.Lock()
+	if ce.reportedEvidence == nil {
+		ce.reportedEvidence = make(map[ktypes.Hash]bool)
+	}
+	reported := ce.reportedEvidence[id]
+	ce.reportedEvidence[id] = true
+	ce.evidenceMtx.Unlock()
+	if reported {
+		return
+	}
+
+	ce.log.Warn("Detected equivocation, submitting evidence", "kind", ev.Kind, "height", ev.Height(),
+		"offender", hex.EncodeToString(ev.Offender))
+
+	// Broadcasting goes through the mempool, which must not be done while
+	// holding the consensus state lock.
+	go func() {
+		if err := ce.blockProcessor.BroadcastEvidenceTx(ctx, ev); err != nil {
+			ce.log.Error("Failed to submit equivocation evidence", "kind", ev.Kind, "error", err)
+		}
+	}()
+}
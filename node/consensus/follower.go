@@ -103,6 +103,11 @@ func (ce *ConsensusEngine) AcceptProposal(height int64, blkID, prevBlockID types
 			// go ce.sendResetMsg(ce.stateInfo.height)
 			return true
 		}
+		if ce.stateInfo.blkProp.blkHash != blkID && ce.stateInfo.blkProp.blk.Header.Timestamp.UnixMilli() == timestamp {
+			// fetch the block, which is evidence that the leader equivocated
+			ce.log.Warn("Conflicting block proposals with the same timestamp", "height", height, "blockID", blkID)
+			return true
+		}
 		ce.log.Debug("Already processing the block proposal", "height", height, "blockID", blkID)
 		return false
 	}
@@ -111,6 +116,31 @@ func (ce *ConsensusEngine) AcceptProposal(height int64, blkID, prevBlockID types
 	return true
 }
 
+// AcceptRelay determines if a node that does not process block proposals, such
+// as a sentry node, should relay the proposal to peers that may, such as a
+// validator that only connects to the network through this node. The proposal
+// must be signed by the leader, and be for the next block to be committed.
+func (ce *ConsensusEngine) AcceptRelay(height int64, blkID types.Hash, leaderSig []byte) bool {
+	if ce.role.Load() != types.RoleSentry {
+		return false
+	}
+
+	valid, err := ce.leader.Verify(blkID[:], leaderSig)
+	if err != nil {
+		ce.log.Error("Error verifying leader signature", "error", err)
+		return false
+	}
+	if !valid {
+		ce.log.Info("Invalid leader signature, not relaying the block proposal msg: ", "height", height)
+		return false
+	}
+
+	ce.stateInfo.mtx.RLock()
+	defer ce.stateInfo.mtx.RUnlock()
+
+	return height == ce.stateInfo.height+1
+}
+
 // AcceptCommit handles the blockAnnounce message from the leader.
 // This should be processed only if this is the next block to be committed by the node.
 // This also checks if the node should request the block from its peers. This can happen
@@ -135,6 +165,15 @@ func (ce *ConsensusEngine) AcceptCommit(height int64, blkID types.Hash, hdr *kty
 		return false
 	}
 
+	if height <= ce.stateInfo.lastCommit.height {
+		if blkID == ce.stateInfo.lastCommit.blkHash {
+			return false // re-announcement of the latest block
+		}
+		// a different block for a committed height may be evidence of equivocation
+		go ce.checkConflictingCommit(context.Background(), blkID, hdr, ci, leaderSig)
+		return false
+	}
+
 	if ce.stateInfo.height+1 != height {
 		return false
 	}
@@ -202,6 +241,14 @@ func (ce *ConsensusEngine) processBlockProposal(ctx context.Context, blkPropMsg
 			return nil
 		}
 
+		// Never accept two different proposals with the same timestamp, which
+		// an honest leader does not create.
+		if ce.state.blkProp.blk.Header.Timestamp.UnixMilli() == blkPropMsg.blk.Header.Timestamp.UnixMilli() {
+			ce.log.Warn("Received conflicting block proposal with the same timestamp, Ignore", "height", blkPropMsg.height, "blockID", blkPropMsg.blkHash)
+			ce.reportDuplicateProposal(ctx, ce.state.blkProp.blk, blkPropMsg.blk)
+			return nil
+		}
+
 		blkHash := ce.state.blkProp.blkHash
 		ce.log.Info("Aborting execution of stale block proposal", "height", blkPropMsg.height, "blockID", blkHash)
 		if err := ce.rollbackState(ctx); err != nil {
@@ -61,4 +61,6 @@ type BlockProcessor interface {
 	BlockExecutionStatus() *ktypes.BlockExecutionStatus
 	HasEvents() bool
 	StateHashes() *blockprocessor.StateHashes
+
+	BroadcastEvidenceTx(ctx context.Context, ev *ktypes.Evidence) error
 }
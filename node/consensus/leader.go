@@ -4,13 +4,11 @@ import (
 	"bytes"
 	"cmp"
 	"context"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"slices"
 	"time"
 
-	"github.com/kwilteam/kwil-db/config"
 	ktypes "github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/node/types"
 )
@@ -238,6 +236,10 @@ func (ce *ConsensusEngine) createBlockProposal(ctx context.Context) (*blockPropo
 	valSetHash := ce.validatorSetHash()
 	paramsHash := ce.blockProcessor.ConsensusParams().Hash()
 	stamp := time.Now().Truncate(time.Millisecond).UTC()
+	if !stamp.After(ce.lastProposalStamp) {
+		stamp = ce.lastProposalStamp.Add(time.Millisecond)
+	}
+	ce.lastProposalStamp = stamp
 	blk := ktypes.NewBlock(ce.state.lc.height+1, ce.state.lc.blkHash, ce.state.lc.appHash, valSetHash, paramsHash, stamp, finalTxs)
 
 	// add the leader updates to the block header if any
@@ -469,23 +471,11 @@ func (ce *ConsensusEngine) processVotes(ctx context.Context) {
 }
 
 func (ce *ConsensusEngine) validatorSetHash() types.Hash {
-	hasher := ktypes.NewHasher()
-
-	keys := make([]string, 0, len(ce.validatorSet))
+	vals := make([]*ktypes.Validator, 0, len(ce.validatorSet))
 	for _, v := range ce.validatorSet {
-		keys = append(keys, config.EncodePubKeyAndType(v.Identifier, v.KeyType))
-	}
-
-	// sort the keys
-	slices.Sort(keys)
-
-	for _, k := range keys {
-		val := ce.validatorSet[k]
-		hasher.Write(val.AccountID.Bytes())
-		binary.Write(hasher, binary.BigEndian, val.Power)
+		vals = append(vals, &v)
 	}
-
-	return hasher.Sum(nil)
+	return ktypes.ValidatorSetHash(vals)
 }
 
 // CancelBlockExecution is used by the leader to manually cancel the block execution
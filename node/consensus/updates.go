@@ -45,6 +45,11 @@ var ParamUpdatesResolution = resolutions.ResolutionConfig{
 		if err != nil {
 			return err
 		}
+		// proposals are only checked by the proposer's client, and invalid
+		// updates would fail to merge when the block is committed
+		if err = types.ValidateUpdates(pud.ParamUpdates); err != nil {
+			return err
+		}
 
 		app.Service.Logger.Info("Applying param updates", "description", pud.Description, "paramUpdates", pud.ParamUpdates)
 
@@ -2,9 +2,15 @@ package node
 
 import (
 	"bytes"
+	"context"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/require"
+
+	ktypes "github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/node/types"
 )
 
@@ -131,3 +137,82 @@ func TestBlockProp_UnmarshalInvalidData(t *testing.T) {
 		})
 	}
 }
+
+func TestCompactBlock(t *testing.T) {
+	blk, _ := createTestBlock(5, 6)
+
+	// The peer has the even transactions in its mempool.
+	mempool := make(map[types.Hash]*ktypes.Transaction)
+	for i, tx := range blk.Txns {
+		if i%2 == 0 {
+			mempool[tx.Hash()] = tx
+		}
+	}
+	get := func(txHash types.Hash) *ktypes.Transaction { return mempool[txHash] }
+
+	// The leader sends the first transaction in full, and the others as hashes.
+	cb := newCompactBlock(blk, func(txHash types.Hash) bool { return txHash != blk.Txns[0].Hash() })
+	raw, err := cb.MarshalBinary()
+	require.NoError(t, err)
+	require.Less(t, len(raw), len(ktypes.EncodeBlock(blk)))
+
+	var cb2 compactBlock
+	require.NoError(t, cb2.UnmarshalBinary(raw))
+	require.NotNil(t, cb2.Txns[0].Tx)
+	for _, tx := range cb2.Txns[1:] {
+		require.Nil(t, tx.Tx)
+	}
+
+	t.Run("fetch missing", func(t *testing.T) {
+		var fetched []types.Hash
+		var mtx sync.Mutex
+		fetch := func(_ context.Context, txHash types.Hash) ([]byte, error) {
+			mtx.Lock()
+			defer mtx.Unlock()
+			fetched = append(fetched, txHash)
+			for _, tx := range blk.Txns {
+				if tx.Hash() == txHash {
+					return tx.Bytes(), nil
+				}
+			}
+			return nil, ErrTxNotFound
+		}
+		blk2, err := cb2.assemble(context.Background(), get, fetch)
+		require.NoError(t, err)
+		require.Equal(t, ktypes.EncodeBlock(blk), ktypes.EncodeBlock(blk2))
+		require.ElementsMatch(t, []types.Hash{blk.Txns[1].Hash(), blk.Txns[3].Hash(), blk.Txns[5].Hash()}, fetched)
+	})
+
+	t.Run("fetch failure", func(t *testing.T) {
+		fetch := func(context.Context, types.Hash) ([]byte, error) { return nil, ErrTxNotFound }
+		_, err := cb2.assemble(context.Background(), get, fetch)
+		require.ErrorIs(t, err, ErrTxNotFound)
+	})
+
+	t.Run("wrong transaction", func(t *testing.T) {
+		fetch := func(context.Context, types.Hash) ([]byte, error) { return blk.Txns[0].Bytes(), nil }
+		_, err := cb2.assemble(context.Background(), get, fetch)
+		require.Error(t, err)
+	})
+
+	t.Run("merkle root mismatch", func(t *testing.T) {
+		bad := cb2
+		bad.Txns = slices.Clone(cb2.Txns)
+		bad.Txns[1], bad.Txns[2] = bad.Txns[2], bad.Txns[1]
+		fetch := func(_ context.Context, txHash types.Hash) ([]byte, error) {
+			for _, tx := range blk.Txns {
+				if tx.Hash() == txHash {
+					return tx.Bytes(), nil
+				}
+			}
+			return nil, ErrTxNotFound
+		}
+		_, err := bad.assemble(context.Background(), get, fetch)
+		require.ErrorContains(t, err, "merkle root")
+	})
+
+	t.Run("invalid data", func(t *testing.T) {
+		require.Error(t, cb2.UnmarshalBinary(raw[:len(raw)-1]))
+		require.Error(t, cb2.UnmarshalBinary(append(raw, 0)))
+	})
+}
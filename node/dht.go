@@ -2,6 +2,7 @@ package node
 
 import (
 	"context"
+	"slices"
 
 	dht "github.com/libp2p/go-libp2p-kad-dht"
 	"github.com/libp2p/go-libp2p/core/discovery"
@@ -23,7 +24,9 @@ import (
 // Higher level logic will be needed for the aggregation, and fallback to
 // next-best shapshots in the event that restore of the current best fails.
 
-func makeDHT(ctx context.Context, h host.Host, peers []peer.AddrInfo, mode dht.ModeOpt, pex bool) (*dht.IpfsDHT, error) {
+// makeDHT creates and bootstraps a DHT. The private peers are never added to
+// the routing table, so they are not given to other peers in DHT queries.
+func makeDHT(ctx context.Context, h host.Host, peers []peer.AddrInfo, mode dht.ModeOpt, pex bool, private ...peer.ID) (*dht.IpfsDHT, error) {
 	// Create a DHT
 	opts := []dht.Option{
 		dht.BootstrapPeers(peers...),
@@ -32,6 +35,11 @@ func makeDHT(ctx context.Context, h host.Host, peers []peer.AddrInfo, mode dht.M
 	if !pex {
 		opts = append(opts, dht.DisableAutoRefresh()) // just use connected peers
 	}
+	if len(private) > 0 {
+		opts = append(opts, dht.RoutingTableFilter(func(_ any, p peer.ID) bool {
+			return !slices.Contains(private, p)
+		}))
+	}
 	kadDHT, err := dht.New(ctx, h, opts...)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,258 @@
+// Package ddl models the definition of a namespace: its tables, indexes,
+// actions, and the roles and privileges that apply to it. Definitions are
+// read from Kuneiform statements or from the info namespace of a live node,
+// and can be compared to produce a migration between them.
+package ddl
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+// Namespace is the definition of a namespace.
+type Namespace struct {
+	// Name is the name of the namespace.
+	Name string
+	// Tables are the tables in the namespace.
+	Tables []*Table
+	// Actions are the actions in the namespace.
+	Actions []*parse.CreateActionStatement
+	// Extensions are the extensions used by the namespace. Each extension is
+	// its own namespace, named by its alias.
+	Extensions []*parse.UseExtensionStatement
+	// Roles are the roles the namespace expects to exist. Roles are global,
+	// so the roles of a live namespace are all roles in the database.
+	Roles []string
+	// Privileges are the privileges granted to roles on the namespace.
+	Privileges []*Privilege
+}
+
+// Table returns a table by name, or nil if it does not exist.
+func (n *Namespace) Table(name string) *Table {
+	for _, t := range n.Tables {
+		if t.Name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// Action returns an action by name, or nil if it does not exist.
+func (n *Namespace) Action(name string) *parse.CreateActionStatement {
+	for _, a := range n.Actions {
+		if a.Name == name {
+			return a
+		}
+	}
+	return nil
+}
+
+// Table is the definition of a table.
+type Table struct {
+	// Name is the name of the table.
+	Name string
+	// Columns are the columns of the table, in order.
+	Columns []*Column
+	// PrimaryKey are the columns of the primary key.
+	PrimaryKey []string
+	// PrimaryKeyName is the name of the primary key constraint. It is only
+	// known for live tables, or if the definition names it.
+	PrimaryKeyName string
+	// Constraints are the unique, check, and foreign key constraints of the
+	// table.
+	Constraints []*Constraint
+	// Indexes are the indexes of the table. Indexes created by the primary
+	// key and unique constraints are not included.
+	Indexes []*Index
+}
+
+// Column returns a column by name, or nil if it does not exist.
+func (t *Table) Column(name string) *Column {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Column is the definition of a column.
+type Column struct {
+	Name string
+	Type *types.DataType
+	// NotNull is true if the column cannot be null. Primary key columns are
+	// always not null.
+	NotNull bool
+	// Default is the default value of the column, or nil if it has none.
+	Default parse.Expression
+}
+
+// Constraint is a unique, check, or foreign key constraint.
+type Constraint struct {
+	// Name is the name of the constraint. It is empty if the definition does
+	// not name it, in which case a name is generated when it is created.
+	Name string
+	// Clause is the constraint. It is a *parse.UniqueOutOfLineConstraint,
+	// *parse.CheckConstraint, or *parse.ForeignKeyOutOfLineConstraint.
+	Clause parse.OutOfLineConstraintClause
+}
+
+// Index is an index on a table.
+type Index struct {
+	// Name is the name of the index. It is empty if the definition does not
+	// name it.
+	Name    string
+	Columns []string
+	Unique  bool
+}
+
+// Privilege is a privilege granted to a role on the namespace.
+type Privilege struct {
+	Role string
+	// Privilege is the upper case name of the privilege, such as "SELECT".
+	Privilege string
+}
+
+// FromStatements reads the definition of a namespace from Kuneiform
+// statements. Statements without a namespace prefix define the namespace,
+// unless a SET CURRENT NAMESPACE statement changes it. Statements for other
+// namespaces are ignored, except for roles and extensions, which are global.
+// Only statements that define a schema are allowed: CREATE TABLE, CREATE
+// INDEX, CREATE ACTION, CREATE ROLE, USE, and GRANT of privileges on the
+// namespace.
+func FromStatements(namespace string, stmts []parse.TopLevelStatement) (*Namespace, error) {
+	def := &Namespace{Name: namespace}
+	current := namespace
+	for _, stmt := range stmts {
+		target := current
+		if n, ok := stmt.(parse.Namespaceable); ok && n.GetNamespacePrefix() != "" {
+			target = n.GetNamespacePrefix()
+		}
+
+		switch s := stmt.(type) {
+		case *parse.SetCurrentNamespaceStatement:
+			current = s.Namespace
+		case *parse.CreateNamespaceStatement:
+			// the namespaces themselves are not part of the definition
+		case *parse.CreateRoleStatement:
+			if !slices.Contains(def.Roles, s.Role) {
+				def.Roles = append(def.Roles, s.Role)
+			}
+		case *parse.UseExtensionStatement:
+			for _, ext := range def.Extensions {
+				if ext.Alias == s.Alias {
+					return nil, errorAt(s, `extension alias "%s" is used more than once`, s.Alias)
+				}
+			}
+			def.Extensions = append(def.Extensions, s)
+		case *parse.GrantOrRevokeStatement:
+			if !s.IsGrant || len(s.Privileges) == 0 || s.ToRole == "" {
+				return nil, errorAt(s, "only privileges granted to roles can define a namespace")
+			}
+			if s.Namespace == nil {
+				return nil, errorAt(s, "global privileges are not part of a namespace definition")
+			}
+			if *s.Namespace != namespace {
+				continue
+			}
+			for _, priv := range s.Privileges {
+				p := &Privilege{Role: s.ToRole, Privilege: strings.ToUpper(priv)}
+				if !slices.ContainsFunc(def.Privileges, func(o *Privilege) bool { return *o == *p }) {
+					def.Privileges = append(def.Privileges, p)
+				}
+			}
+		case *parse.CreateTableStatement:
+			if target != namespace {
+				continue
+			}
+			if def.Table(s.Name) != nil {
+				return nil, errorAt(s, `table "%s" is defined more than once`, s.Name)
+			}
+			def.Tables = append(def.Tables, tableFromStatement(s))
+		case *parse.CreateIndexStatement:
+			if target != namespace {
+				continue
+			}
+			tbl := def.Table(s.On)
+			if tbl == nil {
+				return nil, errorAt(s, `index on unknown table "%s"`, s.On)
+			}
+			tbl.Indexes = append(tbl.Indexes, &Index{
+				Name:    s.Name,
+				Columns: s.Columns,
+				Unique:  s.Type == parse.IndexTypeUnique,
+			})
+		case *parse.CreateActionStatement:
+			if target != namespace {
+				continue
+			}
+			if def.Action(s.Name) != nil {
+				return nil, errorAt(s, `action "%s" is defined more than once`, s.Name)
+			}
+			def.Actions = append(def.Actions, s)
+		default:
+			return nil, errorAt(stmt, "only CREATE TABLE, CREATE INDEX, CREATE ACTION, CREATE ROLE, USE, and GRANT statements can define a namespace")
+		}
+	}
+
+	return def, nil
+}
+
+// tableFromStatement converts a CREATE TABLE statement to a table, moving the
+// unique, check, and foreign key constraints of columns to the table.
+func tableFromStatement(stmt *parse.CreateTableStatement) *Table {
+	tbl := &Table{Name: stmt.Name}
+	for _, col := range stmt.Columns {
+		c := &Column{Name: col.Name, Type: col.Type}
+		for _, con := range col.Constraints {
+			switch con := con.(type) {
+			case *parse.PrimaryKeyInlineConstraint:
+				tbl.PrimaryKey = append(tbl.PrimaryKey, col.Name)
+			case *parse.NotNullConstraint:
+				c.NotNull = true
+			case *parse.DefaultConstraint:
+				c.Default = con.Value
+			case *parse.UniqueInlineConstraint:
+				tbl.Constraints = append(tbl.Constraints, &Constraint{
+					Clause: &parse.UniqueOutOfLineConstraint{Columns: []string{col.Name}},
+				})
+			case *parse.CheckConstraint:
+				tbl.Constraints = append(tbl.Constraints, &Constraint{Clause: con})
+			case *parse.ForeignKeyReferences:
+				tbl.Constraints = append(tbl.Constraints, &Constraint{
+					Clause: &parse.ForeignKeyOutOfLineConstraint{Columns: []string{col.Name}, References: con},
+				})
+			}
+		}
+		tbl.Columns = append(tbl.Columns, c)
+	}
+
+	for _, con := range stmt.Constraints {
+		if pk, ok := con.Constraint.(*parse.PrimaryKeyOutOfLineConstraint); ok {
+			tbl.PrimaryKey = pk.Columns
+			tbl.PrimaryKeyName = con.Name
+			continue
+		}
+		tbl.Constraints = append(tbl.Constraints, &Constraint{Name: con.Name, Clause: con.Constraint})
+	}
+
+	for _, name := range tbl.PrimaryKey {
+		if col := tbl.Column(name); col != nil {
+			col.NotNull = true
+		}
+	}
+
+	return tbl
+}
+
+// errorAt returns an error prefixed with the line of a statement.
+func errorAt(stmt parse.TopLevelStatement, format string, args ...any) error {
+	if pos := stmt.GetPosition(); pos != nil && pos.StartLine != nil {
+		return fmt.Errorf("line %d: %s", *pos.StartLine, fmt.Sprintf(format, args...))
+	}
+	return fmt.Errorf(format, args...)
+}
@@ -0,0 +1,297 @@
+package ddl
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+const schema = `
+CREATE ROLE writer;
+
+CREATE TABLE users (
+    id INT8 PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE CHECK (length(name) > 0),
+    status TEXT DEFAULT 'active'
+);
+
+CREATE TABLE posts (
+    id INT8 PRIMARY KEY,
+    author INT8 NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT
+);
+
+CREATE INDEX posts_author_idx ON posts(author);
+
+CREATE ACTION get_user($id INT8) public view returns (name TEXT) {
+    return SELECT name FROM users WHERE id = $id;
+};
+
+GRANT INSERT, UPDATE ON app TO writer;
+`
+
+func mustDefinition(t *testing.T, src string) *Namespace {
+	stmts, err := parse.Parse(src)
+	require.NoError(t, err)
+	def, err := FromStatements("app", stmts)
+	require.NoError(t, err)
+	return def
+}
+
+func Test_FromStatements(t *testing.T) {
+	def := mustDefinition(t, schema)
+
+	require.Equal(t, []string{"writer"}, def.Roles)
+	require.Len(t, def.Tables, 2)
+	require.Len(t, def.Actions, 1)
+	require.Equal(t, []*Privilege{{"writer", "INSERT"}, {"writer", "UPDATE"}}, def.Privileges)
+
+	users := def.Table("users")
+	require.Equal(t, []string{"id"}, users.PrimaryKey)
+	require.True(t, users.Column("id").NotNull)
+	require.NotNil(t, users.Column("status").Default)
+	require.Len(t, users.Constraints, 2)
+
+	posts := def.Table("posts")
+	require.Len(t, posts.Constraints, 1)
+	require.Equal(t, []*Index{{Name: "posts_author_idx", Columns: []string{"author"}}}, posts.Indexes)
+
+	// statements for other namespaces are ignored
+	def = mustDefinition(t, "{other}CREATE TABLE t (id INT8 PRIMARY KEY);\nSET CURRENT NAMESPACE TO other;\nCREATE TABLE u (id INT8 PRIMARY KEY);")
+	require.Empty(t, def.Tables)
+
+	stmts, err := parse.Parse("CREATE TABLE t (id INT8 PRIMARY KEY);\nINSERT INTO t VALUES (1);")
+	require.NoError(t, err)
+	_, err = FromStatements("app", stmts)
+	require.ErrorContains(t, err, "line 2")
+}
+
+func Test_Diff(t *testing.T) {
+	tests := []struct {
+		name string
+		from string
+		to   string
+		want string
+		// destructive is true if the migration should delete data
+		destructive bool
+	}{
+		{
+			name: "no changes",
+			from: schema,
+			to:   schema,
+		},
+		{
+			name: "add and alter columns",
+			from: schema,
+			to: strings.NewReplacer(
+				"status TEXT DEFAULT 'active'", "status TEXT NOT NULL DEFAULT 'new',\n    age INT8 DEFAULT 0",
+				"body TEXT", "body INT8",
+			).Replace(schema),
+			want: `{app} ALTER TABLE users ALTER COLUMN status SET DEFAULT 'new';
+
+-- WARNING: fails if column "users.status" has null values
+{app} ALTER TABLE users ALTER COLUMN status SET NOT NULL;
+
+{app} ALTER TABLE users ADD COLUMN age INT8;
+
+{app} ALTER TABLE users ALTER COLUMN age SET DEFAULT 0;
+
+-- WARNING: changes the type of column "posts.body" from TEXT to INT8, which deletes its data
+{app} ALTER TABLE posts DROP COLUMN body;
+
+{app} ALTER TABLE posts ADD COLUMN body INT8;
+`,
+			destructive: true,
+		},
+		{
+			name: "drop table and action, change constraints and indexes",
+			from: schema,
+			to: `
+CREATE ROLE writer;
+CREATE ROLE reader;
+
+CREATE TABLE users (
+    id INT8 PRIMARY KEY,
+    name TEXT NOT NULL CHECK (length(name) > 1),
+    status TEXT DEFAULT 'active'
+);
+
+CREATE UNIQUE INDEX users_name_idx ON users(name);
+
+CREATE ACTION count_users() public view returns (n INT8) {
+    return SELECT count(*) FROM users;
+};
+
+GRANT INSERT ON app TO writer;
+GRANT SELECT ON app TO reader;
+`,
+			want: `CREATE ROLE reader;
+
+{app} DROP ACTION get_user;
+
+{app} ALTER TABLE users DROP CONSTRAINT users_name_key;
+
+{app} ALTER TABLE users DROP CONSTRAINT users_name_check;
+
+-- WARNING: drops table "posts" and deletes its data
+{app} DROP TABLE posts;
+
+-- WARNING: fails if existing rows of table "users" violate the constraint
+{app} ALTER TABLE users ADD CHECK (length(name) > 1);
+
+-- WARNING: fails if table "users" has duplicate values in the index
+{app} CREATE UNIQUE INDEX users_name_idx ON users(name);
+
+{app} CREATE ACTION count_users() public view returns (n INT8) {
+    return SELECT count(*) FROM users;
+};
+
+REVOKE UPDATE ON app FROM writer;
+
+GRANT SELECT ON app TO reader;
+`,
+			destructive: true,
+		},
+		{
+			name: "create namespace",
+			to:   "CREATE TABLE t (id INT8 PRIMARY KEY, parent INT8 REFERENCES p(id));\nCREATE TABLE p (id INT8 PRIMARY KEY);",
+			want: `CREATE NAMESPACE app;
+
+{app} CREATE TABLE p (
+    id INT8 PRIMARY KEY
+);
+
+{app} CREATE TABLE t (
+    id INT8 PRIMARY KEY,
+    parent INT8 REFERENCES p(id)
+);
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var from *Namespace
+			if tt.from != "" {
+				from = mustDefinition(t, tt.from)
+			}
+
+			m, err := Diff(from, mustDefinition(t, tt.to))
+			require.NoError(t, err)
+			require.Equal(t, tt.want, m.String())
+			require.Equal(t, tt.want == "", m.Empty())
+			require.Equal(t, tt.destructive, m.Destructive())
+		})
+	}
+}
+
+// liveSchema returns the results of the queries that Load runs for the
+// namespace defined by schema, as Postgres formats them.
+func liveSchema(_ context.Context, query string, params map[string]any) (*types.QueryResult, error) {
+	switch {
+	case strings.Contains(query, "info.namespaces"):
+		if params["namespace"] != "app" {
+			return &types.QueryResult{}, nil
+		}
+		return &types.QueryResult{Values: [][]any{{"app"}}}, nil
+	case strings.Contains(query, "info.columns"):
+		return &types.QueryResult{Values: [][]any{
+			{"posts", "id", "int8", false, "", true},
+			{"posts", "author", "int8", false, "", false},
+			{"posts", "body", "text", true, "", false},
+			{"users", "id", "int8", false, "", true},
+			{"users", "name", "text", false, "", false},
+			{"users", "status", "text", true, "'active'::text", false},
+		}}, nil
+	case strings.Contains(query, "info.constraints"):
+		return &types.QueryResult{Values: [][]any{
+			{"users", "users_name_check", "CHECK ((length(name) > 0))"},
+			{"users", "users_name_key", "UNIQUE (name)"},
+		}}, nil
+	case strings.Contains(query, "info.foreign_keys"):
+		return &types.QueryResult{Values: [][]any{
+			{"posts", "posts_author_fkey", []any{"author"}, "users", []any{"id"}, "NO ACTION", "CASCADE"},
+		}}, nil
+	case strings.Contains(query, "info.indexes"):
+		return &types.QueryResult{Values: [][]any{
+			{"posts", "posts_author_idx", false, false, []any{"author"}},
+			{"posts", "posts_pkey", true, true, []any{"id"}},
+			{"users", "users_name_key", false, true, []any{"name"}},
+			{"users", "users_pkey", true, true, []any{"id"}},
+		}}, nil
+	case strings.Contains(query, "info.actions"):
+		return &types.QueryResult{Values: [][]any{
+			{"get_user", "CREATE ACTION get_user($id int8) public view returns (name text) { return select name from users where id = $id; };"},
+		}}, nil
+	case strings.Contains(query, "info.roles"):
+		return &types.QueryResult{Values: [][]any{{"writer"}}}, nil
+	case strings.Contains(query, "info.role_privileges"):
+		return &types.QueryResult{Values: [][]any{{"writer", "INSERT"}, {"writer", "UPDATE"}}}, nil
+	}
+	return &types.QueryResult{}, nil
+}
+
+func Test_Load(t *testing.T) {
+	live, err := Load(context.Background(), liveSchema, "app")
+	require.NoError(t, err)
+
+	require.Equal(t, "users_pkey", live.Table("users").PrimaryKeyName)
+	require.Len(t, live.Table("users").Indexes, 0)
+	require.Len(t, live.Table("posts").Indexes, 1)
+
+	// the live namespace matches its definition
+	m, err := Diff(live, mustDefinition(t, schema))
+	require.NoError(t, err)
+	require.True(t, m.Empty(), m.String())
+
+	_, err = Load(context.Background(), liveSchema, "missing")
+	require.True(t, errors.Is(err, engine.ErrNamespaceNotFound))
+}
+
+func Test_Dump(t *testing.T) {
+	live, err := Load(context.Background(), liveSchema, "app")
+	require.NoError(t, err)
+
+	// only the extensions that actions use are created
+	live.Extensions = []*parse.UseExtensionStatement{{ExtName: "erc20", Alias: "unused"}}
+
+	dump, err := Dump(live)
+	require.NoError(t, err)
+	require.Equal(t, `CREATE NAMESPACE IF NOT EXISTS app;
+
+CREATE ROLE IF NOT EXISTS writer;
+
+{app} CREATE TABLE users (
+    id INT8 PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    status TEXT DEFAULT 'active'::TEXT,
+    CHECK (length(name) > 0)
+);
+
+{app} CREATE TABLE posts (
+    id INT8 PRIMARY KEY,
+    author INT8 NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    body TEXT
+);
+
+{app} CREATE INDEX posts_author_idx ON posts(author);
+
+{app} CREATE ACTION get_user($id INT8) public view returns (name TEXT) {
+    return SELECT name FROM users WHERE id = $id;
+};
+
+GRANT INSERT, UPDATE ON app TO writer;
+`, dump)
+
+	// the dump defines the live namespace
+	m, err := Diff(live, mustDefinition(t, dump))
+	require.NoError(t, err)
+	require.True(t, m.Empty(), m.String())
+}
@@ -0,0 +1,622 @@
+package ddl
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/node/engine/format"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+// Migration is an ordered list of statements that changes the definition of
+// a namespace into another.
+type Migration struct {
+	Statements []*Statement `json:"statements"`
+}
+
+// Statement is a statement of a migration.
+type Statement struct {
+	// SQL is the formatted Kuneiform statement, including its semicolon.
+	SQL string `json:"sql"`
+	// Warning describes how the statement can lose data or fail. It is empty
+	// if the statement is safe.
+	Warning string `json:"warning,omitempty"`
+	// Destructive is true if the statement deletes data.
+	Destructive bool `json:"destructive,omitempty"`
+}
+
+// Empty returns true if the migration has no statements.
+func (m *Migration) Empty() bool {
+	return len(m.Statements) == 0
+}
+
+// Destructive returns true if any statement of the migration deletes data.
+func (m *Migration) Destructive() bool {
+	return slices.ContainsFunc(m.Statements, func(s *Statement) bool { return s.Destructive })
+}
+
+// String returns the migration as a Kuneiform script. Warnings are written
+// as comments before their statements.
+func (m *Migration) String() string {
+	str := strings.Builder{}
+	for i, stmt := range m.Statements {
+		if i > 0 {
+			str.WriteString("\n")
+		}
+		if stmt.Warning != "" {
+			str.WriteString("-- WARNING: ")
+			str.WriteString(stmt.Warning)
+			str.WriteString("\n")
+		}
+		str.WriteString(stmt.SQL)
+		str.WriteString("\n")
+	}
+	return str.String()
+}
+
+// Diff returns the migration that changes the definition of a namespace from
+// one definition to another. If from is nil, the namespace does not exist and
+// is created.
+//
+// The migration is ordered so that each statement can be applied: roles and
+// extensions are created first, then removed actions, constraints, indexes,
+// and tables are dropped, tables are created and altered, and finally actions
+// are created or replaced and privileges are granted or revoked. Roles and
+// extensions are never dropped, since other namespaces may use them.
+//
+// Changing the type of a column drops and adds it, which deletes its data.
+// Default values and check constraints are compared after removing
+// parentheses and casts, since Postgres adds them to the expressions it
+// stores. Expressions that Postgres rewrites in other ways are dropped and
+// created again.
+func Diff(from, to *Namespace) (*Migration, error) {
+	d := &differ{namespace: to.Name, m: &Migration{}}
+	if from == nil {
+		d.add(&parse.CreateNamespaceStatement{Namespace: to.Name}, "", false)
+		from = &Namespace{Name: to.Name}
+	}
+
+	for _, role := range to.Roles {
+		if !slices.Contains(from.Roles, role) {
+			d.add(&parse.CreateRoleStatement{Role: role}, "", false)
+		}
+	}
+
+	for _, ext := range to.Extensions {
+		if !slices.ContainsFunc(from.Extensions, func(e *parse.UseExtensionStatement) bool { return e.Alias == ext.Alias }) {
+			d.add(ext, "", false)
+		}
+	}
+
+	for _, act := range from.Actions {
+		if to.Action(act.Name) == nil {
+			d.add(&parse.DropActionStatement{Name: act.Name}, "", false)
+		}
+	}
+
+	var tables []*tableDiff
+	for _, t := range to.Tables {
+		if f := from.Table(t.Name); f != nil {
+			tables = append(tables, d.newTableDiff(f, t))
+		}
+	}
+
+	// foreign keys are dropped first, since they can depend on the other
+	// constraints and indexes
+	for _, t := range tables {
+		t.dropConstraints(d, true)
+	}
+	for _, t := range tables {
+		t.dropConstraints(d, false)
+	}
+
+	var removed []*Table
+	for _, t := range from.Tables {
+		if to.Table(t.Name) == nil {
+			removed = append(removed, t)
+		}
+	}
+	removed = sortByReferences(removed)
+	slices.Reverse(removed)
+	for _, t := range removed {
+		d.add(&parse.DropTableStatement{Tables: []string{t.Name}}, fmt.Sprintf(`drops table "%s" and deletes its data`, t.Name), true)
+	}
+
+	for _, t := range tables {
+		t.alterColumns(d)
+	}
+
+	var added []*Table
+	for _, t := range to.Tables {
+		if from.Table(t.Name) == nil {
+			added = append(added, t)
+		}
+	}
+	for _, t := range sortByReferences(added) {
+		d.add(createTable(t), "", false)
+		for _, idx := range t.Indexes {
+			d.add(createIndex(t.Name, idx), "", false)
+		}
+	}
+
+	for _, t := range tables {
+		t.addConstraints(d, false)
+	}
+	for _, t := range tables {
+		t.addConstraints(d, true)
+	}
+
+	for _, act := range to.Actions {
+		old := from.Action(act.Name)
+		if old != nil && d.actionKey(old) == d.actionKey(act) {
+			continue
+		}
+		stmt := *act
+		stmt.IfNotExists = false
+		stmt.OrReplace = old != nil
+		d.add(&stmt, "", false)
+	}
+
+	d.privileges(from.Privileges, to.Privileges, false)
+	d.privileges(to.Privileges, from.Privileges, true)
+
+	if d.err != nil {
+		return nil, d.err
+	}
+	return d.m, nil
+}
+
+// differ accumulates the statements of a migration.
+type differ struct {
+	namespace string
+	m         *Migration
+	// err is the first error formatting a statement.
+	err error
+}
+
+// add formats a statement and adds it to the migration, prefixed with the
+// namespace if it can be.
+func (d *differ) add(stmt parse.TopLevelStatement, warning string, destructive bool) {
+	if n, ok := stmt.(parse.Namespaceable); ok {
+		n.SetNamespacePrefix(d.namespace)
+	}
+
+	sql, err := format.Statement(stmt)
+	if err != nil {
+		if d.err == nil {
+			d.err = err
+		}
+		return
+	}
+	d.m.Statements = append(d.m.Statements, &Statement{SQL: sql, Warning: warning, Destructive: destructive})
+}
+
+// privileges grants or revokes the privileges in a that are not in b,
+// combining the privileges of each role into one statement.
+func (d *differ) privileges(a, b []*Privilege, grant bool) {
+	var roles []string
+	byRole := make(map[string][]string)
+	for _, p := range a {
+		if slices.ContainsFunc(b, func(o *Privilege) bool { return *o == *p }) {
+			continue
+		}
+		if _, ok := byRole[p.Role]; !ok {
+			roles = append(roles, p.Role)
+		}
+		byRole[p.Role] = append(byRole[p.Role], p.Privilege)
+	}
+
+	for _, role := range roles {
+		d.add(&parse.GrantOrRevokeStatement{
+			IsGrant:    grant,
+			Privileges: byRole[role],
+			Namespace:  &d.namespace,
+			ToRole:     role,
+		}, "", false)
+	}
+}
+
+var castPattern = regexp.MustCompile(`(?i)::[a-z0-9_]+(\(\d+,\s*\d+\))?(\[\])?`)
+
+// key formats a statement and removes the parentheses and casts that
+// Postgres adds to expressions, so that equivalent definitions are equal.
+func (d *differ) key(stmt parse.TopLevelStatement) string {
+	sql, err := format.Statement(stmt)
+	if err != nil {
+		if d.err == nil {
+			d.err = err
+		}
+		return ""
+	}
+	sql = castPattern.ReplaceAllString(sql, "")
+	sql = strings.NewReplacer("(", " ", ")", " ").Replace(sql)
+	return strings.Join(strings.Fields(sql), " ")
+}
+
+// constraintKey returns the key of a constraint, ignoring its name.
+func (d *differ) constraintKey(c parse.OutOfLineConstraintClause) string {
+	if fk, ok := c.(*parse.ForeignKeyOutOfLineConstraint); ok {
+		refs := *fk.References
+		if refs.RefTableNamespace == d.namespace {
+			refs.RefTableNamespace = ""
+		}
+		// NO ACTION is the default, and actions are compared in the order
+		// they are loaded
+		refs.Actions = nil
+		for _, on := range []parse.ForeignKeyActionOn{parse.ON_UPDATE, parse.ON_DELETE} {
+			for _, a := range fk.References.Actions {
+				if a.On == on && a.Do != parse.DO_NO_ACTION {
+					refs.Actions = append(refs.Actions, a)
+				}
+			}
+		}
+		c = &parse.ForeignKeyOutOfLineConstraint{Columns: fk.Columns, References: &refs}
+	}
+
+	return d.key(&parse.AlterTableStatement{
+		Table:   "t",
+		Actions: []parse.AlterTableAction{&parse.AddTableConstraint{Constraint: &parse.OutOfLineConstraint{Constraint: c}}},
+	})
+}
+
+// defaultKey returns the key of the default value of a column.
+func (d *differ) defaultKey(e parse.Expression) string {
+	if e == nil {
+		return ""
+	}
+	return d.key(&parse.AlterTableStatement{
+		Table:   "t",
+		Actions: []parse.AlterTableAction{&parse.AlterColumnSet{Column: "c", Type: parse.ConstraintTypeDefault, Value: e}},
+	})
+}
+
+// actionKey returns the formatted CREATE ACTION statement of an action,
+// without its namespace and IF NOT EXISTS or OR REPLACE.
+func (d *differ) actionKey(act *parse.CreateActionStatement) string {
+	stmt := *act
+	stmt.IfNotExists = false
+	stmt.OrReplace = false
+	stmt.NamespacePrefix = ""
+	sql, err := format.Statement(&stmt)
+	if err != nil && d.err == nil {
+		d.err = err
+	}
+	return sql
+}
+
+// tableDiff is the difference between two definitions of a table.
+type tableDiff struct {
+	from, to *Table
+	// replaced are the columns whose type changed, which are dropped and
+	// added again.
+	replaced map[string]bool
+	// dropped and added are the constraints that are dropped and added.
+	dropped, added []*Constraint
+	// pkChanged is true if the primary key is dropped and added again.
+	pkChanged bool
+}
+
+func (d *differ) newTableDiff(from, to *Table) *tableDiff {
+	t := &tableDiff{from: from, to: to, replaced: make(map[string]bool)}
+	for _, col := range to.Columns {
+		if old := from.Column(col.Name); old != nil && !old.Type.EqualsStrict(col.Type) {
+			t.replaced[col.Name] = true
+		}
+	}
+
+	t.pkChanged = !slices.Equal(from.PrimaryKey, to.PrimaryKey) || t.usesReplaced(from.PrimaryKey)
+
+	fromKeys := make([]string, len(from.Constraints))
+	for i, c := range from.Constraints {
+		fromKeys[i] = d.constraintKey(c.Clause)
+	}
+	toKeys := make([]string, len(to.Constraints))
+	for i, c := range to.Constraints {
+		toKeys[i] = d.constraintKey(c.Clause)
+	}
+
+	for i, c := range from.Constraints {
+		if !slices.Contains(toKeys, fromKeys[i]) || t.usesReplaced(constraintColumns(c.Clause)) {
+			t.dropped = append(t.dropped, c)
+		}
+	}
+	for i, c := range to.Constraints {
+		if !slices.Contains(fromKeys, toKeys[i]) || t.usesReplaced(constraintColumns(c.Clause)) {
+			t.added = append(t.added, c)
+		}
+	}
+
+	return t
+}
+
+// usesReplaced returns true if any of the columns is replaced.
+func (t *tableDiff) usesReplaced(columns []string) bool {
+	return slices.ContainsFunc(columns, func(c string) bool { return t.replaced[c] })
+}
+
+// droppedIndexes returns the indexes that are dropped.
+func (t *tableDiff) droppedIndexes() []*Index {
+	var res []*Index
+	for _, idx := range t.from.Indexes {
+		if !slices.ContainsFunc(t.to.Indexes, idx.equals) || t.usesReplaced(idx.Columns) {
+			res = append(res, idx)
+		}
+	}
+	return res
+}
+
+// addedIndexes returns the indexes that are added.
+func (t *tableDiff) addedIndexes() []*Index {
+	var res []*Index
+	for _, idx := range t.to.Indexes {
+		if !slices.ContainsFunc(t.from.Indexes, idx.equals) || t.usesReplaced(idx.Columns) {
+			res = append(res, idx)
+		}
+	}
+	return res
+}
+
+// dropConstraints drops the foreign keys of the table, or its other
+// constraints, indexes, and primary key that changed.
+func (t *tableDiff) dropConstraints(d *differ, foreignKeys bool) {
+	for _, c := range t.dropped {
+		if _, ok := c.Clause.(*parse.ForeignKeyOutOfLineConstraint); ok == foreignKeys {
+			d.add(t.alter(&parse.DropTableConstraint{Name: constraintName(t.from.Name, c)}), "", false)
+		}
+	}
+	if foreignKeys {
+		return
+	}
+
+	for _, idx := range t.droppedIndexes() {
+		name := idx.Name
+		if name == "" {
+			name = generatedName(t.from.Name, idx.Columns, "idx")
+		}
+		d.add(&parse.DropIndexStatement{Name: name}, "", false)
+	}
+
+	if t.pkChanged && len(t.from.PrimaryKey) > 0 {
+		name := t.from.PrimaryKeyName
+		if name == "" {
+			name = generatedName(t.from.Name, nil, "pkey")
+		}
+		d.add(t.alter(&parse.DropTableConstraint{Name: name}), "", false)
+	}
+}
+
+// alterColumns drops, adds, and alters the columns of the table, and adds its
+// primary key if it changed.
+func (t *tableDiff) alterColumns(d *differ) {
+	for _, col := range t.from.Columns {
+		if t.to.Column(col.Name) == nil {
+			d.add(t.alter(&parse.DropColumn{Name: col.Name}), fmt.Sprintf(`drops column "%s.%s" and deletes its data`, t.to.Name, col.Name), true)
+		}
+	}
+
+	for _, col := range t.to.Columns {
+		old := t.from.Column(col.Name)
+		if t.replaced[col.Name] {
+			d.add(t.alter(&parse.DropColumn{Name: col.Name}),
+				fmt.Sprintf(`changes the type of column "%s.%s" from %s to %s, which deletes its data`,
+					t.to.Name, col.Name, strings.ToUpper(old.Type.String()), strings.ToUpper(col.Type.String())), true)
+			old = nil
+		}
+
+		if old == nil {
+			d.add(t.alter(&parse.AddColumn{Name: col.Name, Type: col.Type}), "", false)
+			if col.Default != nil {
+				d.add(t.alter(&parse.AlterColumnSet{Column: col.Name, Type: parse.ConstraintTypeDefault, Value: col.Default}), "", false)
+			}
+			if col.NotNull && !slices.Contains(t.to.PrimaryKey, col.Name) {
+				d.add(t.alter(&parse.AlterColumnSet{Column: col.Name, Type: parse.ConstraintTypeNotNull}),
+					fmt.Sprintf(`fails if table "%s" has rows, since they are null in the new column`, t.to.Name), false)
+			}
+			continue
+		}
+
+		if d.defaultKey(old.Default) != d.defaultKey(col.Default) {
+			if col.Default == nil {
+				d.add(t.alter(&parse.AlterColumnDrop{Column: col.Name, Type: parse.ConstraintTypeDefault}), "", false)
+			} else {
+				d.add(t.alter(&parse.AlterColumnSet{Column: col.Name, Type: parse.ConstraintTypeDefault, Value: col.Default}), "", false)
+			}
+		}
+
+		switch {
+		case col.NotNull && !old.NotNull && !slices.Contains(t.to.PrimaryKey, col.Name):
+			d.add(t.alter(&parse.AlterColumnSet{Column: col.Name, Type: parse.ConstraintTypeNotNull}),
+				fmt.Sprintf(`fails if column "%s.%s" has null values`, t.to.Name, col.Name), false)
+		case !col.NotNull && old.NotNull:
+			d.add(t.alter(&parse.AlterColumnDrop{Column: col.Name, Type: parse.ConstraintTypeNotNull}), "", false)
+		}
+	}
+
+	if t.pkChanged && len(t.to.PrimaryKey) > 0 {
+		d.add(t.alter(&parse.AddTableConstraint{Constraint: &parse.OutOfLineConstraint{
+			Name:       t.to.PrimaryKeyName,
+			Constraint: &parse.PrimaryKeyOutOfLineConstraint{Columns: t.to.PrimaryKey},
+		}}), fmt.Sprintf(`fails if table "%s" has duplicate or null values in the primary key`, t.to.Name), false)
+	}
+}
+
+// addConstraints adds the foreign keys of the table, or its other
+// constraints and indexes that changed.
+func (t *tableDiff) addConstraints(d *differ, foreignKeys bool) {
+	for _, c := range t.added {
+		_, isFK := c.Clause.(*parse.ForeignKeyOutOfLineConstraint)
+		if isFK != foreignKeys {
+			continue
+		}
+
+		warning := fmt.Sprintf(`fails if existing rows of table "%s" violate the constraint`, t.to.Name)
+		d.add(t.alter(&parse.AddTableConstraint{Constraint: &parse.OutOfLineConstraint{Name: c.Name, Constraint: c.Clause}}), warning, false)
+	}
+	if foreignKeys {
+		return
+	}
+
+	for _, idx := range t.addedIndexes() {
+		warning := ""
+		if idx.Unique {
+			warning = fmt.Sprintf(`fails if table "%s" has duplicate values in the index`, t.to.Name)
+		}
+		d.add(createIndex(t.to.Name, idx), warning, false)
+	}
+}
+
+// alter returns an ALTER TABLE statement of the table with one action.
+func (t *tableDiff) alter(action parse.AlterTableAction) *parse.AlterTableStatement {
+	return &parse.AlterTableStatement{Table: t.to.Name, Actions: []parse.AlterTableAction{action}}
+}
+
+// equals returns true if two indexes are on the same columns and have the
+// same type. Their names are not compared.
+func (i *Index) equals(o *Index) bool {
+	return i.Unique == o.Unique && slices.Equal(i.Columns, o.Columns)
+}
+
+// constraintName returns the name of a constraint. If it is not named, it
+// returns the name that Postgres generates for it.
+func constraintName(table string, c *Constraint) string {
+	if c.Name != "" {
+		return c.Name
+	}
+
+	switch clause := c.Clause.(type) {
+	case *parse.UniqueOutOfLineConstraint:
+		return generatedName(table, clause.Columns, "key")
+	case *parse.ForeignKeyOutOfLineConstraint:
+		return generatedName(table, clause.Columns, "fkey")
+	default:
+		// check constraints are named after their first column
+		cols := constraintColumns(clause)
+		return generatedName(table, cols[:min(1, len(cols))], "check")
+	}
+}
+
+// generatedName returns the name that Postgres generates for a constraint or
+// index on columns of a table.
+func generatedName(table string, columns []string, suffix string) string {
+	return strings.Join(append(append([]string{table}, columns...), suffix), "_")
+}
+
+// constraintColumns returns the columns a constraint uses.
+func constraintColumns(c parse.OutOfLineConstraintClause) []string {
+	check, ok := c.(*parse.CheckConstraint)
+	if !ok {
+		return c.LocalColumns()
+	}
+
+	var cols []string
+	parse.RecursivelyVisitPositions(check.Expression, func(gp parse.GetPositioner) {
+		if col, ok := gp.(*parse.ExpressionColumn); ok {
+			cols = append(cols, col.Column)
+		}
+	})
+	return cols
+}
+
+// createTable returns the CREATE TABLE statement of a table. Constraints on a
+// single column without a name are written inline.
+func createTable(t *Table) *parse.CreateTableStatement {
+	stmt := &parse.CreateTableStatement{Name: t.Name}
+	inlinePK := len(t.PrimaryKey) == 1 && t.PrimaryKeyName == ""
+	for _, col := range t.Columns {
+		c := &parse.Column{Name: col.Name, Type: col.Type}
+		isPK := inlinePK && t.PrimaryKey[0] == col.Name
+		if isPK {
+			c.Constraints = append(c.Constraints, &parse.PrimaryKeyInlineConstraint{})
+		}
+		if col.NotNull && !slices.Contains(t.PrimaryKey, col.Name) {
+			c.Constraints = append(c.Constraints, &parse.NotNullConstraint{})
+		}
+		if col.Default != nil {
+			c.Constraints = append(c.Constraints, &parse.DefaultConstraint{Value: col.Default})
+		}
+		stmt.Columns = append(stmt.Columns, c)
+	}
+
+	if len(t.PrimaryKey) > 0 && !inlinePK {
+		stmt.Constraints = append(stmt.Constraints, &parse.OutOfLineConstraint{
+			Name:       t.PrimaryKeyName,
+			Constraint: &parse.PrimaryKeyOutOfLineConstraint{Columns: t.PrimaryKey},
+		})
+	}
+
+	for _, con := range t.Constraints {
+		if con.Name == "" {
+			if inline, col := inlineConstraint(con.Clause); inline != nil {
+				for _, c := range stmt.Columns {
+					if c.Name == col {
+						c.Constraints = append(c.Constraints, inline)
+					}
+				}
+				continue
+			}
+		}
+		stmt.Constraints = append(stmt.Constraints, &parse.OutOfLineConstraint{Name: con.Name, Constraint: con.Clause})
+	}
+
+	return stmt
+}
+
+// inlineConstraint returns the inline form of a unique or foreign key
+// constraint on a single column, and the column. It returns nil if the
+// constraint cannot be written inline.
+func inlineConstraint(c parse.OutOfLineConstraintClause) (parse.InlineConstraint, string) {
+	switch c := c.(type) {
+	case *parse.UniqueOutOfLineConstraint:
+		if len(c.Columns) == 1 {
+			return &parse.UniqueInlineConstraint{}, c.Columns[0]
+		}
+	case *parse.ForeignKeyOutOfLineConstraint:
+		if len(c.Columns) == 1 {
+			return c.References, c.Columns[0]
+		}
+	}
+	return nil, ""
+}
+
+// createIndex returns the CREATE INDEX statement of an index.
+func createIndex(table string, idx *Index) *parse.CreateIndexStatement {
+	stmt := &parse.CreateIndexStatement{Name: idx.Name, On: table, Columns: idx.Columns, Type: parse.IndexTypeBTree}
+	if idx.Unique {
+		stmt.Type = parse.IndexTypeUnique
+	}
+	return stmt
+}
+
+// sortByReferences sorts tables so that each table comes after the tables its
+// foreign keys reference. Tables that reference each other are kept in their
+// original order.
+func sortByReferences(tables []*Table) []*Table {
+	var sorted []*Table
+	visited := make(map[string]bool)
+	var visit func(t *Table)
+	visit = func(t *Table) {
+		if visited[t.Name] {
+			return
+		}
+		visited[t.Name] = true
+		for _, c := range t.Constraints {
+			fk, ok := c.Clause.(*parse.ForeignKeyOutOfLineConstraint)
+			if !ok {
+				continue
+			}
+			for _, ref := range tables {
+				if ref.Name == fk.References.RefTable {
+					visit(ref)
+				}
+			}
+		}
+		sorted = append(sorted, t)
+	}
+
+	for _, t := range tables {
+		visit(t)
+	}
+	return sorted
+}
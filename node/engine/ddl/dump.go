@@ -0,0 +1,104 @@
+package ddl
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+// Dump returns the DDL that defines a namespace, as a Kuneiform script that
+// can deploy it to another network. The namespace, the roles granted
+// privileges on it, and the extensions its actions use are created if they
+// do not exist; then its tables and their indexes, its actions, and its
+// privileges are created.
+//
+// The output is canonical: tables and actions are ordered by name, except
+// that tables come after the tables they reference, and constraints named
+// the way Postgres names them by default are written without their names.
+func Dump(def *Namespace) (string, error) {
+	d := &differ{namespace: def.Name, m: &Migration{}}
+	d.add(&parse.CreateNamespaceStatement{Namespace: def.Name, IfNotExists: true}, "", false)
+
+	var roles []string
+	for _, p := range def.Privileges {
+		if slices.Contains(def.Roles, p.Role) && !slices.Contains(roles, p.Role) {
+			roles = append(roles, p.Role)
+		}
+	}
+	slices.Sort(roles)
+	for _, role := range roles {
+		d.add(&parse.CreateRoleStatement{Role: role, IfNotExists: true}, "", false)
+	}
+
+	used := usedExtensions(def.Actions)
+	for _, ext := range def.Extensions {
+		if used[ext.Alias] {
+			stmt := *ext
+			stmt.IfNotExists = true
+			d.add(&stmt, "", false)
+		}
+	}
+
+	tables := slices.Clone(def.Tables)
+	slices.SortStableFunc(tables, func(a, b *Table) int { return strings.Compare(a.Name, b.Name) })
+	for _, t := range sortByReferences(tables) {
+		t = withDefaultNames(t)
+		d.add(createTable(t), "", false)
+		for _, idx := range t.Indexes {
+			d.add(createIndex(t.Name, idx), "", false)
+		}
+	}
+
+	actions := slices.Clone(def.Actions)
+	slices.SortStableFunc(actions, func(a, b *parse.CreateActionStatement) int { return strings.Compare(a.Name, b.Name) })
+	for _, act := range actions {
+		stmt := *act
+		stmt.IfNotExists = false
+		stmt.OrReplace = false
+		d.add(&stmt, "", false)
+	}
+
+	privileges := slices.Clone(def.Privileges)
+	slices.SortStableFunc(privileges, func(a, b *Privilege) int { return strings.Compare(a.Role, b.Role) })
+	d.privileges(privileges, nil, true)
+
+	if d.err != nil {
+		return "", d.err
+	}
+	return d.m.String(), nil
+}
+
+// usedExtensions returns the aliases of the namespaces that actions call
+// methods of. Only extensions can be called this way, so the aliases of
+// other namespaces in the result are ignored.
+func usedExtensions(actions []*parse.CreateActionStatement) map[string]bool {
+	used := make(map[string]bool)
+	for _, act := range actions {
+		parse.RecursivelyVisitPositions(act.Statements, func(gp parse.GetPositioner) {
+			if call, ok := gp.(*parse.ExpressionFunctionCall); ok && call.Namespace != "" {
+				used[call.Namespace] = true
+			}
+		})
+	}
+	return used
+}
+
+// withDefaultNames returns a copy of a table without the names of its
+// primary key and constraints that are the names Postgres generates for
+// them, so that they are written inline where they can be.
+func withDefaultNames(t *Table) *Table {
+	t2 := *t
+	if t2.PrimaryKeyName == t.Name+"_pkey" {
+		t2.PrimaryKeyName = ""
+	}
+
+	t2.Constraints = make([]*Constraint, len(t.Constraints))
+	for i, c := range t.Constraints {
+		t2.Constraints[i] = c
+		if c.Name != "" && c.Name == constraintName(t.Name, &Constraint{Clause: c.Clause}) {
+			t2.Constraints[i] = &Constraint{Clause: c.Clause}
+		}
+	}
+	return &t2
+}
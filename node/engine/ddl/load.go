@@ -0,0 +1,285 @@
+package ddl
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+// QueryFunc runs a read-only query against the info namespace of a node.
+type QueryFunc func(ctx context.Context, query string, params map[string]any) (*types.QueryResult, error)
+
+// Load reads the definition of a live namespace from the info namespace. If
+// the namespace does not exist, it returns an error wrapping
+// engine.ErrNamespaceNotFound.
+func Load(ctx context.Context, query QueryFunc, namespace string) (*Namespace, error) {
+	params := map[string]any{"namespace": namespace}
+	def := &Namespace{Name: namespace}
+
+	res, err := query(ctx, "SELECT name FROM info.namespaces WHERE name = $namespace", params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read namespaces: %w", err)
+	}
+	if len(res.Values) == 0 {
+		return nil, fmt.Errorf(`%w: "%s"`, engine.ErrNamespaceNotFound, namespace)
+	}
+
+	loaders := []func(context.Context, QueryFunc, *Namespace, map[string]any) error{
+		loadColumns, loadConstraints, loadForeignKeys, loadIndexes, loadActions, loadRoles, loadExtensions,
+	}
+	for _, load := range loaders {
+		if err := load(ctx, query, def, params); err != nil {
+			return nil, err
+		}
+	}
+
+	return def, nil
+}
+
+func loadColumns(ctx context.Context, query QueryFunc, def *Namespace, params map[string]any) error {
+	res, err := query(ctx, `SELECT table_name, name, data_type, is_nullable, COALESCE(default_value, ''), is_primary_key
+	FROM info.columns WHERE namespace = $namespace`, params)
+	if err != nil {
+		return fmt.Errorf("failed to read columns: %w", err)
+	}
+
+	var tblName, colName, dataType, defaultValue string
+	var nullable, pk bool
+	return res.Scan(func() error {
+		dt, err := types.ParseDataType(dataType)
+		if err != nil {
+			return fmt.Errorf(`column "%s.%s": %w`, tblName, colName, err)
+		}
+
+		tbl := def.Table(tblName)
+		if tbl == nil {
+			tbl = &Table{Name: tblName}
+			def.Tables = append(def.Tables, tbl)
+		}
+
+		col := &Column{Name: colName, Type: dt, NotNull: !nullable}
+		if defaultValue != "" {
+			col.Default, err = parseDefault(defaultValue)
+			if err != nil {
+				return fmt.Errorf(`default of column "%s.%s": %w`, tblName, colName, err)
+			}
+		}
+		tbl.Columns = append(tbl.Columns, col)
+		if pk {
+			tbl.PrimaryKey = append(tbl.PrimaryKey, colName)
+		}
+		return nil
+	}, &tblName, &colName, &dataType, &nullable, &defaultValue, &pk)
+}
+
+func loadConstraints(ctx context.Context, query QueryFunc, def *Namespace, params map[string]any) error {
+	res, err := query(ctx, `SELECT table_name, name, expression FROM info.constraints WHERE namespace = $namespace`, params)
+	if err != nil {
+		return fmt.Errorf("failed to read constraints: %w", err)
+	}
+
+	var tblName, name, expression string
+	return res.Scan(func() error {
+		tbl := def.Table(tblName)
+		if tbl == nil {
+			return fmt.Errorf(`constraint "%s" is on unknown table "%s"`, name, tblName)
+		}
+
+		clause, err := parseConstraint(expression)
+		if err != nil {
+			return fmt.Errorf(`constraint "%s" of table "%s": %w`, name, tblName, err)
+		}
+		tbl.Constraints = append(tbl.Constraints, &Constraint{Name: name, Clause: clause})
+		return nil
+	}, &tblName, &name, &expression)
+}
+
+func loadForeignKeys(ctx context.Context, query QueryFunc, def *Namespace, params map[string]any) error {
+	res, err := query(ctx, `SELECT table_name, name, columns, ref_table, ref_columns, on_update, on_delete
+	FROM info.foreign_keys WHERE namespace = $namespace`, params)
+	if err != nil {
+		return fmt.Errorf("failed to read foreign keys: %w", err)
+	}
+
+	var tblName, name, refTable, onUpdate, onDelete string
+	var columns, refColumns []string
+	return res.Scan(func() error {
+		tbl := def.Table(tblName)
+		if tbl == nil {
+			return fmt.Errorf(`foreign key "%s" is on unknown table "%s"`, name, tblName)
+		}
+
+		refs := &parse.ForeignKeyReferences{
+			RefTable:   refTable,
+			RefColumns: refColumns,
+		}
+		for _, a := range []*parse.ForeignKeyAction{
+			{On: parse.ON_UPDATE, Do: parse.ForeignKeyActionDo(onUpdate)},
+			{On: parse.ON_DELETE, Do: parse.ForeignKeyActionDo(onDelete)},
+		} {
+			if a.Do != parse.DO_NO_ACTION {
+				refs.Actions = append(refs.Actions, a)
+			}
+		}
+
+		tbl.Constraints = append(tbl.Constraints, &Constraint{
+			Name:   name,
+			Clause: &parse.ForeignKeyOutOfLineConstraint{Columns: columns, References: refs},
+		})
+		return nil
+	}, &tblName, &name, &columns, &refTable, &refColumns, &onUpdate, &onDelete)
+}
+
+func loadIndexes(ctx context.Context, query QueryFunc, def *Namespace, params map[string]any) error {
+	res, err := query(ctx, `SELECT table_name, name, is_primary_key, is_unique, columns
+	FROM info.indexes WHERE namespace = $namespace`, params)
+	if err != nil {
+		return fmt.Errorf("failed to read indexes: %w", err)
+	}
+
+	var tblName, name string
+	var pk, unique bool
+	var columns []string
+	return res.Scan(func() error {
+		tbl := def.Table(tblName)
+		if tbl == nil {
+			return fmt.Errorf(`index "%s" is on unknown table "%s"`, name, tblName)
+		}
+
+		if pk {
+			tbl.PrimaryKeyName = name
+			return nil
+		}
+		// unique constraints are backed by an index with the same name
+		for _, con := range tbl.Constraints {
+			if con.Name == name {
+				return nil
+			}
+		}
+
+		tbl.Indexes = append(tbl.Indexes, &Index{Name: name, Columns: columns, Unique: unique})
+		return nil
+	}, &tblName, &name, &pk, &unique, &columns)
+}
+
+func loadActions(ctx context.Context, query QueryFunc, def *Namespace, params map[string]any) error {
+	res, err := query(ctx, `SELECT name, raw_statement FROM info.actions
+	WHERE namespace = $namespace AND built_in = false`, params)
+	if err != nil {
+		return fmt.Errorf("failed to read actions: %w", err)
+	}
+
+	var name, raw string
+	return res.Scan(func() error {
+		stmts, err := parse.Parse(raw)
+		if err != nil {
+			return fmt.Errorf(`action "%s": %w`, name, err)
+		}
+		if len(stmts) != 1 {
+			return fmt.Errorf(`action "%s": expected 1 statement, got %d`, name, len(stmts))
+		}
+		act, ok := stmts[0].(*parse.CreateActionStatement)
+		if !ok {
+			return fmt.Errorf(`action "%s": expected CREATE ACTION, got %T`, name, stmts[0])
+		}
+
+		def.Actions = append(def.Actions, act)
+		return nil
+	}, &name, &raw)
+}
+
+func loadRoles(ctx context.Context, query QueryFunc, def *Namespace, params map[string]any) error {
+	// built-in roles exist on every network, so they are not part of the
+	// definition
+	res, err := query(ctx, "SELECT name FROM info.roles WHERE built_in = false", nil)
+	if err != nil {
+		return fmt.Errorf("failed to read roles: %w", err)
+	}
+
+	var name string
+	err = res.Scan(func() error {
+		def.Roles = append(def.Roles, name)
+		return nil
+	}, &name)
+	if err != nil {
+		return err
+	}
+
+	res, err = query(ctx, `SELECT role_name, privilege FROM info.role_privileges
+	WHERE namespace = $namespace AND granted = true`, params)
+	if err != nil {
+		return fmt.Errorf("failed to read privileges: %w", err)
+	}
+
+	var role, privilege string
+	return res.Scan(func() error {
+		def.Privileges = append(def.Privileges, &Privilege{Role: role, Privilege: privilege})
+		return nil
+	}, &role, &privilege)
+}
+
+func loadExtensions(ctx context.Context, query QueryFunc, def *Namespace, _ map[string]any) error {
+	res, err := query(ctx, "SELECT namespace, extension FROM info.extensions", nil)
+	if err != nil {
+		return fmt.Errorf("failed to read extensions: %w", err)
+	}
+
+	var alias, extension string
+	return res.Scan(func() error {
+		def.Extensions = append(def.Extensions, &parse.UseExtensionStatement{ExtName: extension, Alias: alias})
+		return nil
+	}, &alias, &extension)
+}
+
+// parseDefault parses the default value of a column, as formatted by
+// Postgres.
+func parseDefault(expr string) (parse.Expression, error) {
+	stmt, err := parseAlterTable("ALTER TABLE t ALTER COLUMN c SET DEFAULT " + expr + ";")
+	if err != nil {
+		return nil, err
+	}
+	set, ok := stmt.Actions[0].(*parse.AlterColumnSet)
+	if !ok {
+		return nil, fmt.Errorf("unexpected default %s", expr)
+	}
+	return set.Value, nil
+}
+
+// parseConstraint parses the definition of a unique or check constraint, as
+// formatted by Postgres.
+func parseConstraint(expr string) (parse.OutOfLineConstraintClause, error) {
+	stmt, err := parseAlterTable("ALTER TABLE t ADD " + expr + ";")
+	if err != nil {
+		return nil, err
+	}
+	add, ok := stmt.Actions[0].(*parse.AddTableConstraint)
+	if !ok {
+		return nil, fmt.Errorf("unexpected constraint %s", expr)
+	}
+
+	// Postgres wraps check expressions in another pair of parentheses
+	if check, ok := add.Constraint.Constraint.(*parse.CheckConstraint); ok {
+		if p, ok := check.Expression.(*parse.ExpressionParenthesized); ok && p.GetTypeCast() == nil {
+			check.Expression = p.Inner
+		}
+	}
+	return add.Constraint.Constraint, nil
+}
+
+func parseAlterTable(sql string) (*parse.AlterTableStatement, error) {
+	stmts, err := parse.Parse(sql)
+	if err != nil {
+		return nil, err
+	}
+	if len(stmts) != 1 {
+		return nil, fmt.Errorf("expected 1 statement, got %d", len(stmts))
+	}
+	stmt, ok := stmts[0].(*parse.AlterTableStatement)
+	if !ok || len(stmt.Actions) != 1 {
+		return nil, fmt.Errorf("unexpected statement %s", sql)
+	}
+	return stmt, nil
+}
@@ -0,0 +1,1343 @@
+// Package format formats Kuneiform in a canonical style. The output is
+// generated from the AST, so formatting is idempotent: formatting already
+// formatted Kuneiform does not change it.
+package format
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+/*
+	The canonical style is:
+	- SQL keywords are upper case, and action keywords (if, for, return, etc.) and modifiers are lower case.
+	- Blocks are indented with 4 spaces.
+	- Top-level statements end with a semicolon and are separated by a blank line.
+	- Each column and table constraint of a CREATE TABLE statement is on its own line.
+	- SQL statements that do not fit on a single line have one clause per line.
+	- Comments are kept, but comments within a statement are moved before it.
+*/
+
+// maxLineWidth is the width after which SQL statements are split into one
+// clause per line.
+const maxLineWidth = 100
+
+// indentation is a single level of indentation.
+const indentation = "    "
+
+// Format formats Kuneiform source. It returns an error if the source cannot be
+// parsed.
+func Format(src string) (res string, err error) {
+	stmts, err := parse.Parse(src)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	p := &printer{comments: parse.ParseComments(src)}
+	str := strings.Builder{}
+	for i, stmt := range stmts {
+		if i > 0 {
+			str.WriteString("\n")
+		}
+
+		p.writeLeading(&str, stmt.GetPosition(), -1)
+		str.WriteString(p.topLevel(stmt))
+		str.WriteString(p.trailing(stmt.GetPosition()))
+		str.WriteString("\n")
+	}
+
+	// comments at the end of the source
+	if len(p.comments) > 0 {
+		if len(stmts) > 0 {
+			str.WriteString("\n")
+		}
+		p.writeLeading(&str, nil, -1)
+	}
+
+	return str.String(), nil
+}
+
+// Statement formats a single top-level statement, including its terminating
+// semicolon.
+func Statement(stmt parse.TopLevelStatement) (res string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			if e, ok := r.(error); ok {
+				err = e
+			} else {
+				err = fmt.Errorf("%v", r)
+			}
+		}
+	}()
+
+	p := &printer{}
+	return p.topLevel(stmt), nil
+}
+
+// printer is a visitor that prints the AST as canonical Kuneiform. Each visit
+// method returns a string, which does not start with indentation but indents
+// any subsequent lines to the current indentation level.
+type printer struct {
+	// comments are the comments that have not been printed yet, in the order
+	// they appear in the source.
+	comments []*parse.Comment
+	// indent is the current indentation level.
+	indent int
+}
+
+var _ parse.Visitor = (*printer)(nil)
+
+// topLevel prints a top-level statement with its namespace prefix and
+// semicolon.
+func (p *printer) topLevel(stmt parse.TopLevelStatement) string {
+	str := strings.Builder{}
+	if ns, ok := stmt.(parse.Namespaceable); ok && ns.GetNamespacePrefix() != "" {
+		str.WriteString("{")
+		str.WriteString(ns.GetNamespacePrefix())
+		str.WriteString("} ")
+	}
+
+	if sql, ok := stmt.(*parse.SQLStatement); ok {
+		str.WriteString(p.sqlStatement(sql, str.Len(), false))
+	} else {
+		str.WriteString(stmt.Accept(p).(string))
+	}
+
+	str.WriteString(";")
+	return str.String()
+}
+
+func (p *printer) indentStr() string {
+	return strings.Repeat(indentation, p.indent)
+}
+
+// beforeStart reports whether a line and column are before the start of a
+// position. A nil position is after everything.
+func beforeStart(line, col int, pos *parse.Position) bool {
+	if pos == nil || pos.StartLine == nil || pos.StartCol == nil {
+		return true
+	}
+	return line < *pos.StartLine || (line == *pos.StartLine && col < *pos.StartCol)
+}
+
+// beforeEnd reports whether a line and column are before the start of the
+// last token of a position.
+func beforeEnd(line, col int, pos *parse.Position) bool {
+	if pos == nil || pos.EndLine == nil || pos.EndCol == nil {
+		return true
+	}
+	return line < *pos.EndLine || (line == *pos.EndLine && col < *pos.EndCol)
+}
+
+func startLine(pos *parse.Position) int {
+	if pos == nil || pos.StartLine == nil {
+		return 0
+	}
+	return *pos.StartLine
+}
+
+func endLine(pos *parse.Position) int {
+	if pos == nil || pos.EndLine == nil {
+		return 0
+	}
+	return *pos.EndLine
+}
+
+// writeLeading writes the pending comments that start before a position, each
+// on its own line. prevLine is the last source line that was written, or -1 if
+// nothing was written yet in the current block; a blank line is kept between
+// comments that were separated by one. It returns the last source line that
+// was written.
+func (p *printer) writeLeading(str *strings.Builder, pos *parse.Position, prevLine int) int {
+	for len(p.comments) > 0 {
+		c := p.comments[0]
+		if !beforeStart(*c.StartLine, *c.StartCol, pos) {
+			break
+		}
+		p.comments = p.comments[1:]
+
+		if prevLine >= 0 && *c.StartLine > prevLine+1 {
+			str.WriteString("\n")
+		}
+		str.WriteString(p.indentStr())
+		str.WriteString(c.Text)
+		str.WriteString("\n")
+		prevLine = *c.EndLine
+	}
+
+	if prevLine >= 0 && pos != nil && startLine(pos) > prevLine+1 {
+		str.WriteString("\n")
+	}
+
+	return prevLine
+}
+
+// writeInner writes the pending comments that start before the last token of
+// a position, such as the closing brace of a block.
+func (p *printer) writeInner(str *strings.Builder, pos *parse.Position, prevLine int) {
+	for len(p.comments) > 0 {
+		c := p.comments[0]
+		if !beforeEnd(*c.StartLine, *c.StartCol, pos) {
+			break
+		}
+		p.comments = p.comments[1:]
+
+		if prevLine >= 0 && *c.StartLine > prevLine+1 {
+			str.WriteString("\n")
+		}
+		str.WriteString(p.indentStr())
+		str.WriteString(c.Text)
+		str.WriteString("\n")
+		prevLine = *c.EndLine
+	}
+}
+
+// trailing returns the pending comments that start on the last line of a
+// position, after it. They are returned with a leading space, so that they can
+// be appended to the printed node.
+func (p *printer) trailing(pos *parse.Position) string {
+	if pos == nil || pos.EndLine == nil {
+		return ""
+	}
+
+	str := strings.Builder{}
+	for len(p.comments) > 0 {
+		c := p.comments[0]
+		if *c.StartLine != *pos.EndLine || beforeEnd(*c.StartLine, *c.StartCol, pos) {
+			break
+		}
+		p.comments = p.comments[1:]
+
+		str.WriteString(" ")
+		str.WriteString(c.Text)
+	}
+	return str.String()
+}
+
+// lastLine returns the last source line of a position, including any trailing
+// comments that were printed after it.
+func lastLine(pos *parse.Position, trailing string) int {
+	return endLine(pos) + strings.Count(trailing, "\n")
+}
+
+// block prints a block of action statements, including the braces. end is the
+// position of the node that the block ends with, and is used to print comments
+// at the end of the block.
+func (p *printer) block(stmts []parse.ActionStmt, end *parse.Position) string {
+	str := strings.Builder{}
+	str.WriteString("{\n")
+
+	p.indent++
+	prevLine := -1
+	for _, stmt := range stmts {
+		pos := stmt.GetPosition()
+		p.writeLeading(&str, pos, prevLine)
+
+		str.WriteString(p.indentStr())
+		str.WriteString(stmt.Accept(p).(string))
+		trailing := p.trailing(pos)
+		str.WriteString(trailing)
+		str.WriteString("\n")
+		prevLine = lastLine(pos, trailing)
+	}
+	p.writeInner(&str, end, prevLine)
+	p.indent--
+
+	if str.Len() == len("{\n") {
+		return "{}"
+	}
+
+	str.WriteString(p.indentStr())
+	str.WriteString("}")
+	return str.String()
+}
+
+// typeString prints a data type.
+func typeString(t *types.DataType) string {
+	return strings.ToUpper(t.String())
+}
+
+// typeCast prints the typecast of an expression, if any.
+func typeCast(t parse.Typecasted) string {
+	if t.GetTypeCast() == nil {
+		return ""
+	}
+	return "::" + typeString(t.GetTypeCast())
+}
+
+// list prints a list of nodes separated by commas.
+func list[T parse.Node](p *printer, nodes []T) string {
+	strs := make([]string, len(nodes))
+	for i, n := range nodes {
+		strs[i] = n.Accept(p).(string)
+	}
+	return strings.Join(strs, ", ")
+}
+
+// operand prints an operand of an operator that binds tighter than NOT. NOT
+// can be written as "!" in actions, which binds tighter than all other
+// operators, so it is wrapped in parentheses to keep its meaning.
+func (p *printer) operand(e parse.Expression) string {
+	str := e.Accept(p).(string)
+	if u, ok := e.(*parse.ExpressionUnary); ok && u.Operator == parse.UnaryOperatorNot {
+		return "(" + str + ")"
+	}
+	return str
+}
+
+// formatLiteral prints a literal value.
+func formatLiteral(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case bool:
+		if v {
+			return "TRUE"
+		}
+		return "FALSE"
+	case int64:
+		return strconv.FormatInt(v, 10)
+	case string:
+		return "'" + v + "'"
+	case *types.Decimal:
+		return v.FullString()
+	case []byte:
+		return "0x" + hex.EncodeToString(v)
+	default:
+		panic(fmt.Sprintf("unexpected literal type %T", v))
+	}
+}
+
+func (p *printer) VisitExpressionLiteral(p0 *parse.ExpressionLiteral) any {
+	return formatLiteral(p0.Value) + typeCast(p0)
+}
+
+func (p *printer) VisitExpressionFunctionCall(p0 *parse.ExpressionFunctionCall) any {
+	str := strings.Builder{}
+	if p0.Namespace != "" {
+		str.WriteString(p0.Namespace)
+		str.WriteString(".")
+	}
+	str.WriteString(p0.Name)
+	str.WriteString("(")
+	switch {
+	case p0.Star:
+		str.WriteString("*")
+	case p0.Distinct:
+		str.WriteString("DISTINCT ")
+		fallthrough
+	default:
+		str.WriteString(list(p, p0.Args))
+	}
+	str.WriteString(")")
+	str.WriteString(typeCast(p0))
+	return str.String()
+}
+
+func (p *printer) VisitExpressionWindowFunctionCall(p0 *parse.ExpressionWindowFunctionCall) any {
+	str := strings.Builder{}
+	str.WriteString(p0.FunctionCall.Accept(p).(string))
+	if p0.Filter != nil {
+		str.WriteString(" FILTER (WHERE ")
+		str.WriteString(p0.Filter.Accept(p).(string))
+		str.WriteString(")")
+	}
+	str.WriteString(" OVER ")
+	str.WriteString(p0.Window.Accept(p).(string))
+	return str.String()
+}
+
+func (p *printer) VisitWindowImpl(p0 *parse.WindowImpl) any {
+	var parts []string
+	if len(p0.PartitionBy) > 0 {
+		parts = append(parts, "PARTITION BY "+list(p, p0.PartitionBy))
+	}
+	if len(p0.OrderBy) > 0 {
+		parts = append(parts, "ORDER BY "+list(p, p0.OrderBy))
+	}
+	return "(" + strings.Join(parts, " ") + ")"
+}
+
+func (p *printer) VisitWindowReference(p0 *parse.WindowReference) any {
+	return p0.Name
+}
+
+func (p *printer) VisitExpressionVariable(p0 *parse.ExpressionVariable) any {
+	return p0.Name + typeCast(p0)
+}
+
+func (p *printer) VisitExpressionArrayAccess(p0 *parse.ExpressionArrayAccess) any {
+	str := strings.Builder{}
+	str.WriteString(p.operand(p0.Array))
+	str.WriteString("[")
+	if p0.Index != nil {
+		str.WriteString(p0.Index.Accept(p).(string))
+	} else {
+		if p0.FromTo[0] != nil {
+			str.WriteString(p0.FromTo[0].Accept(p).(string))
+		}
+		str.WriteString(":")
+		if p0.FromTo[1] != nil {
+			str.WriteString(p0.FromTo[1].Accept(p).(string))
+		}
+	}
+	str.WriteString("]")
+	str.WriteString(typeCast(p0))
+	return str.String()
+}
+
+func (p *printer) VisitExpressionMakeArray(p0 *parse.ExpressionMakeArray) any {
+	return "ARRAY[" + list(p, p0.Values) + "]" + typeCast(p0)
+}
+
+func (p *printer) VisitExpressionFieldAccess(p0 *parse.ExpressionFieldAccess) any {
+	return p.operand(p0.Record) + "." + p0.Field + typeCast(p0)
+}
+
+func (p *printer) VisitExpressionParenthesized(p0 *parse.ExpressionParenthesized) any {
+	return "(" + p0.Inner.Accept(p).(string) + ")" + typeCast(p0)
+}
+
+func (p *printer) VisitExpressionComparison(p0 *parse.ExpressionComparison) any {
+	return p.operand(p0.Left) + " " + string(p0.Operator) + " " + p.operand(p0.Right)
+}
+
+func (p *printer) VisitExpressionLogical(p0 *parse.ExpressionLogical) any {
+	return p0.Left.Accept(p).(string) + " " + string(p0.Operator) + " " + p0.Right.Accept(p).(string)
+}
+
+func (p *printer) VisitExpressionArithmetic(p0 *parse.ExpressionArithmetic) any {
+	return p.operand(p0.Left) + " " + string(p0.Operator) + " " + p.operand(p0.Right)
+}
+
+func (p *printer) VisitExpressionUnary(p0 *parse.ExpressionUnary) any {
+	if p0.Operator == parse.UnaryOperatorNot {
+		return "NOT " + p0.Expression.Accept(p).(string)
+	}
+
+	str := p.operand(p0.Expression)
+	// "--" would start a comment
+	if strings.HasPrefix(str, "-") || strings.HasPrefix(str, "+") {
+		return string(p0.Operator) + " " + str
+	}
+	return string(p0.Operator) + str
+}
+
+func (p *printer) VisitExpressionColumn(p0 *parse.ExpressionColumn) any {
+	return p0.String() + typeCast(p0)
+}
+
+func (p *printer) VisitExpressionCollate(p0 *parse.ExpressionCollate) any {
+	return p.operand(p0.Expression) + " COLLATE " + p0.Collation
+}
+
+func (p *printer) VisitExpressionStringComparison(p0 *parse.ExpressionStringComparison) any {
+	op := string(p0.Operator)
+	if p0.Not {
+		op = "NOT " + op
+	}
+	return p.operand(p0.Left) + " " + op + " " + p.operand(p0.Right)
+}
+
+func (p *printer) VisitExpressionIs(p0 *parse.ExpressionIs) any {
+	str := strings.Builder{}
+	str.WriteString(p.operand(p0.Left))
+	str.WriteString(" IS ")
+	if p0.Not {
+		str.WriteString("NOT ")
+	}
+	if p0.Distinct {
+		str.WriteString("DISTINCT FROM ")
+	}
+	str.WriteString(p.operand(p0.Right))
+	return str.String()
+}
+
+func (p *printer) VisitExpressionIn(p0 *parse.ExpressionIn) any {
+	str := strings.Builder{}
+	str.WriteString(p.operand(p0.Expression))
+	if p0.Not {
+		str.WriteString(" NOT")
+	}
+	str.WriteString(" IN (")
+	if p0.Subquery != nil {
+		str.WriteString(p0.Subquery.Accept(p).(string))
+	} else {
+		str.WriteString(list(p, p0.List))
+	}
+	str.WriteString(")")
+	return str.String()
+}
+
+func (p *printer) VisitExpressionBetween(p0 *parse.ExpressionBetween) any {
+	str := strings.Builder{}
+	str.WriteString(p.operand(p0.Expression))
+	if p0.Not {
+		str.WriteString(" NOT")
+	}
+	str.WriteString(" BETWEEN ")
+	str.WriteString(p.operand(p0.Lower))
+	str.WriteString(" AND ")
+	str.WriteString(p.operand(p0.Upper))
+	return str.String()
+}
+
+func (p *printer) VisitExpressionSubquery(p0 *parse.ExpressionSubquery) any {
+	str := strings.Builder{}
+	if p0.Not {
+		str.WriteString("NOT ")
+	}
+	if p0.Exists {
+		str.WriteString("EXISTS ")
+	}
+	str.WriteString("(")
+	str.WriteString(p0.Subquery.Accept(p).(string))
+	str.WriteString(")")
+	str.WriteString(typeCast(p0))
+	return str.String()
+}
+
+func (p *printer) VisitExpressionCase(p0 *parse.ExpressionCase) any {
+	str := strings.Builder{}
+	str.WriteString("CASE")
+	if p0.Case != nil {
+		str.WriteString(" ")
+		str.WriteString(p0.Case.Accept(p).(string))
+	}
+	for _, wt := range p0.WhenThen {
+		str.WriteString(" WHEN ")
+		str.WriteString(wt[0].Accept(p).(string))
+		str.WriteString(" THEN ")
+		str.WriteString(wt[1].Accept(p).(string))
+	}
+	if p0.Else != nil {
+		str.WriteString(" ELSE ")
+		str.WriteString(p0.Else.Accept(p).(string))
+	}
+	str.WriteString(" END")
+	return str.String()
+}
+
+func (p *printer) VisitCommonTableExpression(p0 *parse.CommonTableExpression) any {
+	str := strings.Builder{}
+	str.WriteString(p0.Name)
+	if len(p0.Columns) > 0 {
+		str.WriteString("(")
+		str.WriteString(strings.Join(p0.Columns, ", "))
+		str.WriteString(")")
+	}
+	str.WriteString(" AS (")
+	str.WriteString(p0.Query.Accept(p).(string))
+	str.WriteString(")")
+	return str.String()
+}
+
+// VisitSQLStatement prints a SQL statement on a single line.
+func (p *printer) VisitSQLStatement(p0 *parse.SQLStatement) any {
+	str := strings.Builder{}
+	if len(p0.CTEs) > 0 {
+		str.WriteString("WITH ")
+		if p0.Recursive {
+			str.WriteString("RECURSIVE ")
+		}
+		str.WriteString(list(p, p0.CTEs))
+		str.WriteString(" ")
+	}
+	str.WriteString(strings.Join(p.sqlClauses(p0), " "))
+	return str.String()
+}
+
+// sqlStatement prints a SQL statement that starts at column col of the current
+// line. If it does not fit on the line, each clause is printed on its own
+// line. If continued is true, the following lines are indented one level more
+// than the current indentation.
+func (p *printer) sqlStatement(p0 *parse.SQLStatement, col int, continued bool) string {
+	single := p0.Accept(p).(string)
+	if len(p.indentStr())+col+len(single) < maxLineWidth {
+		return single
+	}
+
+	indent := p.indentStr()
+	if continued {
+		indent += indentation
+	}
+
+	var lines []string
+	for i, cte := range p0.CTEs {
+		prefix := ""
+		if i == 0 {
+			prefix = "WITH "
+			if p0.Recursive {
+				prefix += "RECURSIVE "
+			}
+		}
+		suffix := ""
+		if i < len(p0.CTEs)-1 {
+			suffix = ","
+		}
+
+		// common table expressions that do not fit on a line have one
+		// clause of their query per line
+		line := prefix + cte.Accept(p).(string) + suffix
+		if len(indent)+len(line) < maxLineWidth {
+			lines = append(lines, line)
+			continue
+		}
+
+		cteStr := cte.Accept(p).(string)
+		lines = append(lines, prefix+cteStr[:strings.Index(cteStr, " AS (")]+" AS (")
+		for _, clause := range p.selectClauses(cte.Query) {
+			lines = append(lines, indentation+clause)
+		}
+		lines = append(lines, ")"+suffix)
+	}
+
+	lines = append(lines, p.sqlClauses(p0)...)
+	return strings.Join(lines, "\n"+indent)
+}
+
+// sqlClauses returns the top-level clauses of a SQL statement, excluding its
+// common table expressions.
+func (p *printer) sqlClauses(p0 *parse.SQLStatement) []string {
+	switch s := p0.SQL.(type) {
+	case *parse.SelectStatement:
+		return p.selectClauses(s)
+	case *parse.InsertStatement:
+		return p.insertClauses(s)
+	case *parse.UpdateStatement:
+		return p.updateClauses(s)
+	case *parse.DeleteStatement:
+		return p.deleteClauses(s)
+	default:
+		panic(fmt.Sprintf("unexpected SQL statement %T", s))
+	}
+}
+
+// VisitSelectStatement prints a SELECT statement on a single line.
+func (p *printer) VisitSelectStatement(p0 *parse.SelectStatement) any {
+	return strings.Join(p.selectClauses(p0), " ")
+}
+
+func (p *printer) selectClauses(p0 *parse.SelectStatement) []string {
+	var clauses []string
+	for i, core := range p0.SelectCores {
+		if i > 0 {
+			clauses = append(clauses, string(p0.CompoundOperators[i-1]))
+		}
+		clauses = append(clauses, p.selectCoreClauses(core)...)
+	}
+
+	if len(p0.Ordering) > 0 {
+		clauses = append(clauses, "ORDER BY "+list(p, p0.Ordering))
+	}
+	if p0.Limit != nil {
+		clauses = append(clauses, "LIMIT "+p0.Limit.Accept(p).(string))
+	}
+	if p0.Offset != nil {
+		clauses = append(clauses, "OFFSET "+p0.Offset.Accept(p).(string))
+	}
+	return clauses
+}
+
+// VisitSelectCore prints a SELECT core on a single line.
+func (p *printer) VisitSelectCore(p0 *parse.SelectCore) any {
+	return strings.Join(p.selectCoreClauses(p0), " ")
+}
+
+func (p *printer) selectCoreClauses(p0 *parse.SelectCore) []string {
+	sel := "SELECT "
+	if p0.Distinct {
+		sel += "DISTINCT "
+	}
+	clauses := []string{sel + list(p, p0.Columns)}
+
+	if p0.From != nil {
+		clauses = append(clauses, "FROM "+p0.From.Accept(p).(string))
+	}
+	for _, join := range p0.Joins {
+		clauses = append(clauses, join.Accept(p).(string))
+	}
+	if p0.Where != nil {
+		clauses = append(clauses, "WHERE "+p0.Where.Accept(p).(string))
+	}
+	if len(p0.GroupBy) > 0 {
+		clauses = append(clauses, "GROUP BY "+list(p, p0.GroupBy))
+	}
+	if p0.Having != nil {
+		clauses = append(clauses, "HAVING "+p0.Having.Accept(p).(string))
+	}
+	if len(p0.Windows) > 0 {
+		windows := make([]string, len(p0.Windows))
+		for i, w := range p0.Windows {
+			windows[i] = w.Name + " AS " + w.Window.Accept(p).(string)
+		}
+		clauses = append(clauses, "WINDOW "+strings.Join(windows, ", "))
+	}
+	return clauses
+}
+
+func (p *printer) VisitResultColumnExpression(p0 *parse.ResultColumnExpression) any {
+	str := p0.Expression.Accept(p).(string)
+	if p0.Alias != "" {
+		str += " AS " + p0.Alias
+	}
+	return str
+}
+
+func (p *printer) VisitResultColumnWildcard(p0 *parse.ResultColumnWildcard) any {
+	if p0.Table != "" {
+		return p0.Table + ".*"
+	}
+	return "*"
+}
+
+func (p *printer) VisitRelationTable(p0 *parse.RelationTable) any {
+	str := p0.Table
+	if p0.Namespace != "" {
+		str = p0.Namespace + "." + str
+	}
+	if p0.Alias != "" {
+		str += " AS " + p0.Alias
+	}
+	return str
+}
+
+func (p *printer) VisitRelationSubquery(p0 *parse.RelationSubquery) any {
+	str := "(" + p0.Subquery.Accept(p).(string) + ")"
+	if p0.Alias != "" {
+		str += " AS " + p0.Alias
+	}
+	return str
+}
+
+func (p *printer) VisitJoin(p0 *parse.Join) any {
+	str := "JOIN "
+	if p0.Type != parse.JoinTypeInner {
+		str = string(p0.Type) + " JOIN "
+	}
+	return str + p0.Relation.Accept(p).(string) + " ON " + p0.On.Accept(p).(string)
+}
+
+func (p *printer) VisitUpdateStatement(p0 *parse.UpdateStatement) any {
+	return strings.Join(p.updateClauses(p0), " ")
+}
+
+func (p *printer) updateClauses(p0 *parse.UpdateStatement) []string {
+	update := "UPDATE " + p0.Table
+	if p0.Alias != "" {
+		update += " AS " + p0.Alias
+	}
+	clauses := []string{update, "SET " + list(p, p0.SetClause)}
+
+	if p0.From != nil {
+		clauses = append(clauses, "FROM "+p0.From.Accept(p).(string))
+	}
+	for _, join := range p0.Joins {
+		clauses = append(clauses, join.Accept(p).(string))
+	}
+	if p0.Where != nil {
+		clauses = append(clauses, "WHERE "+p0.Where.Accept(p).(string))
+	}
+	return clauses
+}
+
+func (p *printer) VisitUpdateSetClause(p0 *parse.UpdateSetClause) any {
+	return p0.Column + " = " + p0.Value.Accept(p).(string)
+}
+
+func (p *printer) VisitDeleteStatement(p0 *parse.DeleteStatement) any {
+	return strings.Join(p.deleteClauses(p0), " ")
+}
+
+func (p *printer) deleteClauses(p0 *parse.DeleteStatement) []string {
+	del := "DELETE FROM " + p0.Table
+	if p0.Alias != "" {
+		del += " AS " + p0.Alias
+	}
+	clauses := []string{del}
+
+	if p0.Where != nil {
+		clauses = append(clauses, "WHERE "+p0.Where.Accept(p).(string))
+	}
+	return clauses
+}
+
+func (p *printer) VisitInsertStatement(p0 *parse.InsertStatement) any {
+	return strings.Join(p.insertClauses(p0), " ")
+}
+
+func (p *printer) insertClauses(p0 *parse.InsertStatement) []string {
+	insert := "INSERT INTO " + p0.Table
+	if p0.Alias != "" {
+		insert += " AS " + p0.Alias
+	}
+	if len(p0.Columns) > 0 {
+		insert += " (" + strings.Join(p0.Columns, ", ") + ")"
+	}
+	clauses := []string{insert}
+
+	if p0.Select != nil {
+		clauses = append(clauses, p.selectClauses(p0.Select)...)
+	} else {
+		rows := make([]string, len(p0.Values))
+		for i, row := range p0.Values {
+			rows[i] = "(" + list(p, row) + ")"
+		}
+		clauses = append(clauses, "VALUES "+strings.Join(rows, ", "))
+	}
+
+	if p0.OnConflict != nil {
+		clauses = append(clauses, p0.OnConflict.Accept(p).(string))
+	}
+	return clauses
+}
+
+func (p *printer) VisitUpsertClause(p0 *parse.OnConflict) any {
+	str := strings.Builder{}
+	str.WriteString("ON CONFLICT")
+	if len(p0.ConflictColumns) > 0 {
+		str.WriteString(" (")
+		str.WriteString(strings.Join(p0.ConflictColumns, ", "))
+		str.WriteString(")")
+		if p0.ConflictWhere != nil {
+			str.WriteString(" WHERE ")
+			str.WriteString(p0.ConflictWhere.Accept(p).(string))
+		}
+	}
+
+	if len(p0.DoUpdate) == 0 {
+		str.WriteString(" DO NOTHING")
+		return str.String()
+	}
+
+	str.WriteString(" DO UPDATE SET ")
+	str.WriteString(list(p, p0.DoUpdate))
+	if p0.UpdateWhere != nil {
+		str.WriteString(" WHERE ")
+		str.WriteString(p0.UpdateWhere.Accept(p).(string))
+	}
+	return str.String()
+}
+
+func (p *printer) VisitOrderingTerm(p0 *parse.OrderingTerm) any {
+	str := p0.Expression.Accept(p).(string)
+	// ascending order and nulls last are the defaults
+	if p0.Order == parse.OrderTypeDesc {
+		str += " DESC"
+	}
+	if p0.Nulls == parse.NullOrderFirst {
+		str += " NULLS FIRST"
+	}
+	return str
+}
+
+func (p *printer) VisitActionStmtDeclaration(p0 *parse.ActionStmtDeclaration) any {
+	return p0.Variable.Name + " " + typeString(p0.Type) + ";"
+}
+
+func (p *printer) VisitActionStmtAssignment(p0 *parse.ActionStmtAssign) any {
+	str := p0.Variable.Accept(p).(string)
+	if p0.Type != nil {
+		str += " " + typeString(p0.Type)
+	}
+	return str + " := " + p0.Value.Accept(p).(string) + ";"
+}
+
+func (p *printer) VisitActionStmtCall(p0 *parse.ActionStmtCall) any {
+	str := strings.Builder{}
+	for i, r := range p0.Receivers {
+		if i > 0 {
+			str.WriteString(", ")
+		}
+		if r == nil {
+			str.WriteString("_")
+		} else {
+			str.WriteString(r.Name)
+		}
+	}
+	if len(p0.Receivers) > 0 {
+		str.WriteString(" := ")
+	}
+	str.WriteString(p0.Call.Accept(p).(string))
+	str.WriteString(";")
+	return str.String()
+}
+
+func (p *printer) VisitActionStmtForLoop(p0 *parse.ActionStmtForLoop) any {
+	str := strings.Builder{}
+	str.WriteString("for ")
+	str.WriteString(p0.Receiver.Name)
+	str.WriteString(" in ")
+	if sql, ok := p0.LoopTerm.(*parse.LoopTermSQL); ok {
+		str.WriteString(p.sqlStatement(sql.Statement, str.Len()+2, true))
+	} else {
+		str.WriteString(p0.LoopTerm.Accept(p).(string))
+	}
+	str.WriteString(" ")
+	str.WriteString(p.block(p0.Body, p0.GetPosition()))
+	return str.String()
+}
+
+func (p *printer) VisitLoopTermRange(p0 *parse.LoopTermRange) any {
+	return p0.Start.Accept(p).(string) + ".." + p0.End.Accept(p).(string)
+}
+
+func (p *printer) VisitLoopTermSQL(p0 *parse.LoopTermSQL) any {
+	return p0.Statement.Accept(p).(string)
+}
+
+func (p *printer) VisitLoopTermExpression(p0 *parse.LoopTermExpression) any {
+	if p0.Array {
+		return "ARRAY " + p0.Expression.Accept(p).(string)
+	}
+	return p0.Expression.Accept(p).(string)
+}
+
+func (p *printer) VisitActionStmtIf(p0 *parse.ActionStmtIf) any {
+	str := strings.Builder{}
+	for i, ifThen := range p0.IfThens {
+		if i > 0 {
+			str.WriteString(" else")
+		}
+		str.WriteString(ifThen.Accept(p).(string))
+	}
+	if len(p0.Else) > 0 {
+		str.WriteString(" else ")
+		str.WriteString(p.block(p0.Else, p0.GetPosition()))
+	}
+	return str.String()
+}
+
+// VisitIfThen prints an IF or ELSEIF branch, without the "else" of ELSEIF.
+func (p *printer) VisitIfThen(p0 *parse.IfThen) any {
+	return "if " + p0.If.Accept(p).(string) + " " + p.block(p0.Then, p0.GetPosition())
+}
+
+func (p *printer) VisitActionStmtSQL(p0 *parse.ActionStmtSQL) any {
+	return p.sqlStatement(p0.SQL, 0, false) + ";"
+}
+
+func (p *printer) VisitActionStmtLoopControl(p0 *parse.ActionStmtLoopControl) any {
+	return strings.ToLower(string(p0.Type)) + ";"
+}
+
+func (p *printer) VisitActionStmtReturn(p0 *parse.ActionStmtReturn) any {
+	switch {
+	case p0.SQL != nil:
+		return "return " + p.sqlStatement(p0.SQL, len("return "), true) + ";"
+	case len(p0.Values) > 0:
+		return "return " + list(p, p0.Values) + ";"
+	default:
+		return "return;"
+	}
+}
+
+func (p *printer) VisitActionStmtReturnNext(p0 *parse.ActionStmtReturnNext) any {
+	return "return next " + list(p, p0.Values) + ";"
+}
+
+func (p *printer) VisitCreateTableStatement(p0 *parse.CreateTableStatement) any {
+	str := strings.Builder{}
+	str.WriteString("CREATE TABLE ")
+	if p0.IfNotExists {
+		str.WriteString("IF NOT EXISTS ")
+	}
+	str.WriteString(p0.Name)
+	str.WriteString(" (\n")
+
+	// columns and constraints are printed in the order they were written
+	type item struct {
+		pos *parse.Position
+		str func() string
+	}
+	var items []item
+	for _, col := range p0.Columns {
+		items = append(items, item{col.GetPosition(), func() string { return col.Accept(p).(string) }})
+	}
+	for _, con := range p0.Constraints {
+		items = append(items, item{con.GetPosition(), func() string { return p.outOfLineConstraint(con) }})
+	}
+	sortByPosition(items, func(i item) *parse.Position { return i.pos })
+
+	p.indent++
+	prevLine := -1
+	for i, it := range items {
+		p.writeLeading(&str, it.pos, prevLine)
+
+		str.WriteString(p.indentStr())
+		str.WriteString(it.str())
+		if i < len(items)-1 {
+			str.WriteString(",")
+		}
+		trailing := p.trailing(it.pos)
+		str.WriteString(trailing)
+		str.WriteString("\n")
+		prevLine = lastLine(it.pos, trailing)
+	}
+	p.writeInner(&str, p0.GetPosition(), prevLine)
+	p.indent--
+
+	str.WriteString(p.indentStr())
+	str.WriteString(")")
+	return str.String()
+}
+
+// sortByPosition sorts nodes by their start position. Nodes without a
+// position keep their relative order.
+func sortByPosition[T any](items []T, pos func(T) *parse.Position) {
+	// insertion sort, since the number of columns is small and mostly sorted
+	for i := 1; i < len(items); i++ {
+		for j := i; j > 0; j-- {
+			a, b := pos(items[j-1]), pos(items[j])
+			if b == nil || b.StartLine == nil || !beforeStart(*b.StartLine, *b.StartCol, a) {
+				break
+			}
+			items[j-1], items[j] = items[j], items[j-1]
+		}
+	}
+}
+
+func (p *printer) VisitColumn(p0 *parse.Column) any {
+	str := strings.Builder{}
+	str.WriteString(p0.Name)
+	str.WriteString(" ")
+	str.WriteString(typeString(p0.Type))
+	for _, con := range p0.Constraints {
+		str.WriteString(" ")
+		str.WriteString(con.Accept(p).(string))
+	}
+	return str.String()
+}
+
+// outOfLineConstraint prints a table constraint, including its name.
+func (p *printer) outOfLineConstraint(c *parse.OutOfLineConstraint) string {
+	str := c.Constraint.Accept(p).(string)
+	if c.Name != "" {
+		str = "CONSTRAINT " + c.Name + " " + str
+	}
+	return str
+}
+
+func (p *printer) VisitAlterTableStatement(p0 *parse.AlterTableStatement) any {
+	return "ALTER TABLE " + p0.Table + " " + list(p, p0.Actions)
+}
+
+func (p *printer) VisitDropTableStatement(p0 *parse.DropTableStatement) any {
+	str := "DROP TABLE "
+	if p0.IfExists {
+		str += "IF EXISTS "
+	}
+	str += strings.Join(p0.Tables, ", ")
+	if p0.Behavior != parse.DropBehaviorDefault {
+		str += " " + string(p0.Behavior)
+	}
+	return str
+}
+
+func (p *printer) VisitCreateIndexStatement(p0 *parse.CreateIndexStatement) any {
+	str := "CREATE "
+	if p0.Type == parse.IndexTypeUnique {
+		str += "UNIQUE "
+	}
+	str += "INDEX "
+	if p0.IfNotExists {
+		str += "IF NOT EXISTS "
+	}
+	if p0.Name != "" {
+		str += p0.Name + " "
+	}
+	return str + "ON " + p0.On + "(" + strings.Join(p0.Columns, ", ") + ")"
+}
+
+func (p *printer) VisitDropIndexStatement(p0 *parse.DropIndexStatement) any {
+	if p0.CheckExist {
+		return "DROP INDEX IF EXISTS " + p0.Name
+	}
+	return "DROP INDEX " + p0.Name
+}
+
+func (p *printer) VisitGrantOrRevokeStatement(p0 *parse.GrantOrRevokeStatement) any {
+	str := strings.Builder{}
+	if p0.IsGrant {
+		str.WriteString("GRANT ")
+		if p0.If {
+			str.WriteString("IF NOT GRANTED ")
+		}
+	} else {
+		str.WriteString("REVOKE ")
+		if p0.If {
+			str.WriteString("IF GRANTED ")
+		}
+	}
+
+	if len(p0.Privileges) > 0 {
+		str.WriteString(strings.ToUpper(strings.Join(p0.Privileges, ", ")))
+	} else {
+		str.WriteString(p0.GrantRole)
+	}
+
+	if p0.Namespace != nil {
+		str.WriteString(" ON ")
+		str.WriteString(*p0.Namespace)
+	}
+
+	if p0.IsGrant {
+		str.WriteString(" TO ")
+	} else {
+		str.WriteString(" FROM ")
+	}
+
+	switch {
+	case p0.ToRole != "":
+		str.WriteString(p0.ToRole)
+	case p0.ToUser != "":
+		str.WriteString(formatLiteral(p0.ToUser))
+	default:
+		str.WriteString(p0.ToVariable.Accept(p).(string))
+	}
+	return str.String()
+}
+
+func (p *printer) VisitTransferOwnershipStatement(p0 *parse.TransferOwnershipStatement) any {
+	if p0.ToVariable != nil {
+		return "TRANSFER OWNERSHIP TO " + p0.ToVariable.Accept(p).(string)
+	}
+	return "TRANSFER OWNERSHIP TO " + formatLiteral(p0.ToUser)
+}
+
+func (p *printer) VisitAlterColumnSet(p0 *parse.AlterColumnSet) any {
+	str := "ALTER COLUMN " + p0.Column + " SET " + string(p0.Type)
+	if p0.Type == parse.ConstraintTypeDefault {
+		str += " " + p0.Value.Accept(p).(string)
+	}
+	return str
+}
+
+func (p *printer) VisitAlterColumnDrop(p0 *parse.AlterColumnDrop) any {
+	return "ALTER COLUMN " + p0.Column + " DROP " + string(p0.Type)
+}
+
+func (p *printer) VisitAddColumn(p0 *parse.AddColumn) any {
+	str := "ADD COLUMN "
+	if p0.IfNotExists {
+		str += "IF NOT EXISTS "
+	}
+	return str + p0.Name + " " + typeString(p0.Type)
+}
+
+func (p *printer) VisitDropColumn(p0 *parse.DropColumn) any {
+	str := "DROP COLUMN "
+	if p0.IfExists {
+		str += "IF EXISTS "
+	}
+	return str + p0.Name
+}
+
+func (p *printer) VisitRenameColumn(p0 *parse.RenameColumn) any {
+	return "RENAME COLUMN " + p0.OldName + " TO " + p0.NewName
+}
+
+func (p *printer) VisitRenameTable(p0 *parse.RenameTable) any {
+	return "RENAME TO " + p0.Name
+}
+
+func (p *printer) VisitAddTableConstraint(p0 *parse.AddTableConstraint) any {
+	return "ADD " + p.outOfLineConstraint(p0.Constraint)
+}
+
+func (p *printer) VisitDropTableConstraint(p0 *parse.DropTableConstraint) any {
+	str := "DROP CONSTRAINT "
+	if p0.IfExists {
+		str += "IF EXISTS "
+	}
+	return str + p0.Name
+}
+
+func (p *printer) VisitCreateRoleStatement(p0 *parse.CreateRoleStatement) any {
+	if p0.IfNotExists {
+		return "CREATE ROLE IF NOT EXISTS " + p0.Role
+	}
+	return "CREATE ROLE " + p0.Role
+}
+
+func (p *printer) VisitDropRoleStatement(p0 *parse.DropRoleStatement) any {
+	if p0.IfExists {
+		return "DROP ROLE IF EXISTS " + p0.Role
+	}
+	return "DROP ROLE " + p0.Role
+}
+
+func (p *printer) VisitUseExtensionStatement(p0 *parse.UseExtensionStatement) any {
+	str := strings.Builder{}
+	str.WriteString("USE ")
+	if p0.IfNotExists {
+		str.WriteString("IF NOT EXISTS ")
+	}
+	str.WriteString(p0.ExtName)
+	if len(p0.Config) > 0 {
+		str.WriteString(" {")
+		for i, c := range p0.Config {
+			if i > 0 {
+				str.WriteString(", ")
+			}
+			str.WriteString(c.Key)
+			str.WriteString(": ")
+			str.WriteString(c.Value.Accept(p).(string))
+		}
+		str.WriteString("}")
+	}
+	str.WriteString(" AS ")
+	str.WriteString(p0.Alias)
+	return str.String()
+}
+
+func (p *printer) VisitUnuseExtensionStatement(p0 *parse.UnuseExtensionStatement) any {
+	if p0.IfExists {
+		return "UNUSE " + p0.Alias + " IF EXISTS"
+	}
+	return "UNUSE " + p0.Alias
+}
+
+func (p *printer) VisitCreateNamespaceStatement(p0 *parse.CreateNamespaceStatement) any {
+	if p0.IfNotExists {
+		return "CREATE NAMESPACE IF NOT EXISTS " + p0.Namespace
+	}
+	return "CREATE NAMESPACE " + p0.Namespace
+}
+
+func (p *printer) VisitDropNamespaceStatement(p0 *parse.DropNamespaceStatement) any {
+	if p0.IfExists {
+		return "DROP NAMESPACE IF EXISTS " + p0.Namespace
+	}
+	return "DROP NAMESPACE " + p0.Namespace
+}
+
+func (p *printer) VisitSetCurrentNamespaceStatement(p0 *parse.SetCurrentNamespaceStatement) any {
+	return "SET CURRENT NAMESPACE TO " + p0.Namespace
+}
+
+func (p *printer) VisitCreateActionStatement(p0 *parse.CreateActionStatement) any {
+	str := strings.Builder{}
+	str.WriteString("CREATE ")
+	if p0.OrReplace {
+		str.WriteString("OR REPLACE ")
+	}
+	str.WriteString("ACTION ")
+	if p0.IfNotExists {
+		str.WriteString("IF NOT EXISTS ")
+	}
+	str.WriteString(p0.Name)
+
+	str.WriteString("(")
+	for i, param := range p0.Parameters {
+		if i > 0 {
+			str.WriteString(", ")
+		}
+		str.WriteString(param.Name)
+		str.WriteString(" ")
+		str.WriteString(typeString(param.Type))
+	}
+	str.WriteString(")")
+
+	for _, mod := range p0.Modifiers {
+		str.WriteString(" ")
+		str.WriteString(mod)
+	}
+
+	if p0.Returns != nil {
+		str.WriteString(" returns ")
+		if p0.Returns.IsTable {
+			str.WriteString("table ")
+		}
+		str.WriteString("(")
+		for i, f := range p0.Returns.Fields {
+			if i > 0 {
+				str.WriteString(", ")
+			}
+			if f.Name != "" {
+				str.WriteString(f.Name)
+				str.WriteString(" ")
+			}
+			str.WriteString(typeString(f.Type))
+		}
+		str.WriteString(")")
+	}
+
+	str.WriteString(" ")
+	str.WriteString(p.block(p0.Statements, p0.GetPosition()))
+	return str.String()
+}
+
+func (p *printer) VisitDropActionStatement(p0 *parse.DropActionStatement) any {
+	if p0.IfExists {
+		return "DROP ACTION IF EXISTS " + p0.Name
+	}
+	return "DROP ACTION " + p0.Name
+}
+
+func (p *printer) VisitPrimaryKeyInlineConstraint(p0 *parse.PrimaryKeyInlineConstraint) any {
+	return "PRIMARY KEY"
+}
+
+func (p *printer) VisitPrimaryKeyOutOfLineConstraint(p0 *parse.PrimaryKeyOutOfLineConstraint) any {
+	return "PRIMARY KEY (" + strings.Join(p0.Columns, ", ") + ")"
+}
+
+func (p *printer) VisitUniqueInlineConstraint(p0 *parse.UniqueInlineConstraint) any {
+	return "UNIQUE"
+}
+
+func (p *printer) VisitUniqueOutOfLineConstraint(p0 *parse.UniqueOutOfLineConstraint) any {
+	return "UNIQUE (" + strings.Join(p0.Columns, ", ") + ")"
+}
+
+func (p *printer) VisitDefaultConstraint(p0 *parse.DefaultConstraint) any {
+	return "DEFAULT " + p0.Value.Accept(p).(string)
+}
+
+func (p *printer) VisitNotNullConstraint(p0 *parse.NotNullConstraint) any {
+	return "NOT NULL"
+}
+
+func (p *printer) VisitCheckConstraint(p0 *parse.CheckConstraint) any {
+	return "CHECK (" + p0.Expression.Accept(p).(string) + ")"
+}
+
+func (p *printer) VisitForeignKeyReferences(p0 *parse.ForeignKeyReferences) any {
+	str := strings.Builder{}
+	str.WriteString("REFERENCES ")
+	if p0.RefTableNamespace != "" {
+		str.WriteString(p0.RefTableNamespace)
+		str.WriteString(".")
+	}
+	str.WriteString(p0.RefTable)
+	str.WriteString("(")
+	str.WriteString(strings.Join(p0.RefColumns, ", "))
+	str.WriteString(")")
+	for _, a := range p0.Actions {
+		str.WriteString(" ON ")
+		str.WriteString(string(a.On))
+		str.WriteString(" ")
+		str.WriteString(string(a.Do))
+	}
+	return str.String()
+}
+
+func (p *printer) VisitForeignKeyOutOfLineConstraint(p0 *parse.ForeignKeyOutOfLineConstraint) any {
+	return "FOREIGN KEY (" + strings.Join(p0.Columns, ", ") + ") " + p0.References.Accept(p).(string)
+}
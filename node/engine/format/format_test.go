@@ -0,0 +1,158 @@
+package format
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+func Test_Format(t *testing.T) {
+	type testcase struct {
+		name string
+		in   string
+		want string
+	}
+
+	tests := []testcase{
+		{
+			name: "table with comments",
+			in: `-- users table
+create table if not exists users (id int primary key, -- the id
+  name text not null default 'x',
+
+  -- leading
+  amt numeric(10,2)[],
+  constraint u unique (name)
+  -- end
+)`,
+			want: `-- users table
+CREATE TABLE IF NOT EXISTS users (
+    id INT8 PRIMARY KEY, -- the id
+    name TEXT NOT NULL DEFAULT 'x',
+
+    -- leading
+    amt NUMERIC(10,2)[],
+    CONSTRAINT u UNIQUE (name)
+    -- end
+);
+`,
+		},
+		{
+			name: "action",
+			in: `CREATE ACTION act($a int, $b text) public view returns table(x int) {
+	$c int;
+	$c = $a + 1; // assign
+
+
+	if !$c = 1 { return next 1; } else if $c > 2 { break; } else { }
+	for $i in 1..$c { $d, _ := ns.fn($i::text, [1, -1]); }
+	return select x from t where y = $b;
+}`,
+			want: `CREATE ACTION act($a INT8, $b TEXT) public view returns table (x INT8) {
+    $c INT8;
+    $c := $a + 1; // assign
+
+    if (NOT $c) = 1 {
+        return next 1;
+    } elseif $c > 2 {
+        break;
+    }
+    for $i in 1..$c {
+        $d, _ := ns.fn($i::TEXT, ARRAY[1, -1]);
+    }
+    return SELECT x FROM t WHERE y = $b;
+};
+`,
+		},
+		{
+			name: "long sql is split into clauses",
+			in:   `{main}select distinct a.id, a.name, b.value, b.other_value from some_table as a join other_table as b on a.id = b.id where a.id > 10 order by a.id desc limit 10`,
+			want: `{main} SELECT DISTINCT a.id, a.name, b.value, b.other_value
+FROM some_table AS a
+JOIN other_table AS b ON a.id = b.id
+WHERE a.id > 10
+ORDER BY a.id DESC
+LIMIT 10;
+`,
+		},
+		{
+			name: "multiple statements",
+			in: `/* roles */ create role r; grant if not granted select, insert on ns to r;
+revoke r from '0xabc'; use ext {a: 1, b: 'x'} as e;
+alter table t add column c int, drop constraint if exists pk;
+insert into t (id) values (1), (2) on conflict (id) do nothing;
+-- end`,
+			want: `/* roles */
+CREATE ROLE r;
+
+GRANT IF NOT GRANTED SELECT, INSERT ON ns TO r;
+
+REVOKE r FROM '0xabc';
+
+USE ext {a: 1, b: 'x'} AS e;
+
+ALTER TABLE t ADD COLUMN c INT8, DROP CONSTRAINT IF EXISTS pk;
+
+INSERT INTO t (id) VALUES (1), (2) ON CONFLICT (id) DO NOTHING;
+
+-- end
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Format(tt.in)
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+
+			again, err := Format(got)
+			require.NoError(t, err)
+			require.Equal(t, got, again)
+		})
+	}
+}
+
+// Test_FormatIdempotent tests that formatting the Kuneiform files in the
+// repository does not change their meaning, and that formatting is idempotent.
+func Test_FormatIdempotent(t *testing.T) {
+	files := []string{
+		"../../../test/acceptance/users.sql",
+		"../../../node/_exts/ordered-sync/schema.sql",
+		"../../../node/_exts/erc20-bridge/erc20/meta_schema.sql",
+	}
+
+	for _, file := range files {
+		t.Run(file, func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			require.NoError(t, err)
+
+			formatted, err := Format(string(src))
+			require.NoError(t, err)
+
+			again, err := Format(formatted)
+			require.NoError(t, err)
+			require.Equal(t, formatted, again)
+
+			// the formatted source must parse to the same statements
+			want, err := parse.Parse(string(src))
+			require.NoError(t, err)
+			got, err := parse.Parse(formatted)
+			require.NoError(t, err)
+			require.Len(t, got, len(want))
+			for i := range want {
+				w, err := Statement(want[i])
+				require.NoError(t, err)
+				g, err := Statement(got[i])
+				require.NoError(t, err)
+				require.Equal(t, w, g)
+			}
+
+			comments := parse.ParseComments(string(src))
+			require.Len(t, parse.ParseComments(formatted), len(comments))
+		})
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"strings"
 
 	"github.com/decred/dcrd/container/lru"
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/extensions/precompiles"
@@ -146,14 +148,16 @@ func (e *executionContext) getVariableType(name string) (*types.DataType, error)
 
 // query executes a query.
 // It will parse the SQL, create a logical plan, and execute the query.
-func (e *executionContext) query(sql string, fn func(*row) error) error {
+func (e *executionContext) query(sql string, fn func(*row) error) (err error) {
 	if e.queryActive {
 		return engine.ErrQueryActive
 	}
 	e.queryActive = true
 	defer func() { e.queryActive = false }()
 
+	endPlanSpan := startSpan(e.engineCtx, "engine.plan")
 	generatedSQL, analyzed, args, err := e.prepareQuery(sql)
+	endPlanSpan(err)
 	if err != nil {
 		return err
 	}
@@ -179,6 +183,11 @@ func (e *executionContext) query(sql string, fn func(*row) error) error {
 		cols[i] = field.Name
 	}
 
+	endQuerySpan := startSpan(e.engineCtx, "engine.query",
+		attribute.String("db.system", "postgresql"),
+		attribute.String("db.statement", generatedSQL))
+	defer func() { endQuerySpan(err) }()
+
 	return query(e.engineCtx.TxContext.Ctx, e.db, generatedSQL, scanValues, func() error {
 		if len(scanValues) != len(cols) {
 			// should never happen, but just in case
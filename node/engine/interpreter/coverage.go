@@ -0,0 +1,256 @@
+package interpreter
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+// Coverage records which statements and branches of actions are executed.
+// Actions are registered when they are created, so that statements that are
+// never executed are also reported. Positions are relative to the text of the
+// statements that created the action, which are identified by the source set
+// with SetSource. It is safe for concurrent use.
+type Coverage struct {
+	mu      sync.Mutex
+	source  string
+	actions map[actionKey]*actionCoverage
+}
+
+// NewCoverage creates a new coverage recorder.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		actions: make(map[actionKey]*actionCoverage),
+	}
+}
+
+// SetSource sets the source, such as a file path, that actions created
+// afterwards are attributed to.
+func (c *Coverage) SetSource(source string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.source = source
+}
+
+// Actions returns a snapshot of the coverage of all registered actions, sorted
+// by source and line.
+func (c *Coverage) Actions() []*ActionCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := make([]*ActionCoverage, 0, len(c.actions))
+	for _, act := range c.actions {
+		cp := act.ActionCoverage
+		cp.Statements = make([]*StatementCoverage, len(act.Statements))
+		for i, s := range act.Statements {
+			s2 := *s
+			cp.Statements[i] = &s2
+		}
+		cp.Branches = make([]*BranchCoverage, len(act.Branches))
+		for i, b := range act.Branches {
+			b2 := *b
+			cp.Branches[i] = &b2
+		}
+
+		slices.SortFunc(cp.Statements, func(a, b *StatementCoverage) int {
+			return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Col, b.Col))
+		})
+		slices.SortFunc(cp.Branches, func(a, b *BranchCoverage) int {
+			return cmp.Or(cmp.Compare(a.Block, b.Block), cmp.Compare(a.Branch, b.Branch))
+		})
+
+		res = append(res, &cp)
+	}
+
+	slices.SortFunc(res, func(a, b *ActionCoverage) int {
+		return cmp.Or(cmp.Compare(a.Source, b.Source), cmp.Compare(a.Line, b.Line),
+			cmp.Compare(a.Namespace, b.Namespace), cmp.Compare(a.Name, b.Name))
+	})
+
+	return res
+}
+
+// ActionCoverage is the coverage of a single action.
+type ActionCoverage struct {
+	// Source is the source that the action was created from.
+	Source string `json:"source"`
+	// Namespace is the namespace of the action.
+	Namespace string `json:"namespace"`
+	// Name is the name of the action.
+	Name string `json:"name"`
+	// Line is the line of the CREATE ACTION statement.
+	Line int `json:"line"`
+	// Calls is the number of times the action was called.
+	Calls int64 `json:"calls"`
+	// Statements are the statements of the action, including nested ones.
+	Statements []*StatementCoverage `json:"statements"`
+	// Branches are the branches of the IF and FOR statements of the action.
+	Branches []*BranchCoverage `json:"branches"`
+}
+
+// StatementCoverage is the coverage of a single statement.
+type StatementCoverage struct {
+	Line int `json:"line"`
+	Col  int `json:"col"`
+	// Kind is the kind of statement, e.g. "sql" or "if".
+	Kind string `json:"kind"`
+	// Hits is the number of times the statement was executed.
+	Hits int64 `json:"hits"`
+}
+
+// BranchCoverage is the coverage of a single branch of an IF or FOR
+// statement.
+type BranchCoverage struct {
+	Line int `json:"line"`
+	Col  int `json:"col"`
+	// Block identifies the IF or FOR statement within the action.
+	Block int `json:"block"`
+	// Branch is the index of the branch within the block.
+	Branch int `json:"branch"`
+	// Label describes the branch, e.g. "else" or "skipped".
+	Label string `json:"label"`
+	// Hits is the number of times the branch was taken.
+	Hits int64 `json:"hits"`
+}
+
+type actionKey struct {
+	source    string
+	namespace string
+	name      string
+	line      int
+}
+
+type positionKey struct {
+	line, col int
+	kind      string
+}
+
+// actionCoverage is the coverage of an action that is being recorded.
+type actionCoverage struct {
+	ActionCoverage
+	c          *Coverage
+	statements map[positionKey]*StatementCoverage
+	blocks     map[positionKey]int
+	branches   map[[2]int]*BranchCoverage
+}
+
+// registerAction registers an action that is being created. If the action was
+// already registered from the same source position, its coverage is added to.
+// It returns nil if the coverage is nil.
+func (c *Coverage) registerAction(namespace, name string, pos *parse.Position) *actionCoverage {
+	if c == nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	line, _ := positionStart(pos)
+	key := actionKey{source: c.source, namespace: namespace, name: name, line: line}
+	if act, ok := c.actions[key]; ok {
+		return act
+	}
+
+	act := &actionCoverage{
+		ActionCoverage: ActionCoverage{
+			Source:    c.source,
+			Namespace: namespace,
+			Name:      name,
+			Line:      line,
+		},
+		c:          c,
+		statements: make(map[positionKey]*StatementCoverage),
+		blocks:     make(map[positionKey]int),
+		branches:   make(map[[2]int]*BranchCoverage),
+	}
+	c.actions[key] = act
+	return act
+}
+
+// statement registers a statement of the action.
+func (a *actionCoverage) statement(stmt parse.ActionStmt) *StatementCoverage {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	line, col := positionStart(stmt.GetPosition())
+	key := positionKey{line: line, col: col, kind: stmtKind(stmt)}
+	if s, ok := a.statements[key]; ok {
+		return s
+	}
+
+	s := &StatementCoverage{Line: line, Col: col, Kind: key.kind}
+	a.statements[key] = s
+	a.Statements = append(a.Statements, s)
+	return s
+}
+
+// branchBlock registers the branches of an IF or FOR statement, with one label
+// per branch.
+func (a *actionCoverage) branchBlock(stmt parse.ActionStmt, labels ...string) []*BranchCoverage {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+
+	line, col := positionStart(stmt.GetPosition())
+	key := positionKey{line: line, col: col, kind: stmtKind(stmt)}
+	block, ok := a.blocks[key]
+	if !ok {
+		block = len(a.blocks)
+		a.blocks[key] = block
+	}
+
+	res := make([]*BranchCoverage, len(labels))
+	for i, label := range labels {
+		b, ok := a.branches[[2]int{block, i}]
+		if !ok {
+			b = &BranchCoverage{Line: line, Col: col, Block: block, Branch: i, Label: label}
+			a.branches[[2]int{block, i}] = b
+			a.Branches = append(a.Branches, b)
+		}
+		res[i] = b
+	}
+	return res
+}
+
+// hit increments a hit counter of the action.
+func (a *actionCoverage) hit(counter *int64) {
+	a.c.mu.Lock()
+	defer a.c.mu.Unlock()
+	*counter++
+}
+
+// positionStart returns the start line and column of a position, or zeros if
+// it is not set.
+func positionStart(pos *parse.Position) (line, col int) {
+	if pos == nil || pos.StartLine == nil || pos.StartCol == nil {
+		return 0, 0
+	}
+	return *pos.StartLine, *pos.StartCol
+}
+
+// stmtKind returns the kind of an action statement.
+func stmtKind(stmt parse.ActionStmt) string {
+	switch stmt.(type) {
+	case *parse.ActionStmtDeclaration:
+		return "declaration"
+	case *parse.ActionStmtAssign:
+		return "assignment"
+	case *parse.ActionStmtCall:
+		return "call"
+	case *parse.ActionStmtForLoop:
+		return "for"
+	case *parse.ActionStmtIf:
+		return "if"
+	case *parse.ActionStmtSQL:
+		return "sql"
+	case *parse.ActionStmtLoopControl:
+		return "loop_control"
+	case *parse.ActionStmtReturn:
+		return "return"
+	case *parse.ActionStmtReturnNext:
+		return "return_next"
+	default:
+		return "unknown"
+	}
+}
@@ -0,0 +1,100 @@
+package interpreter
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/core/utils/order"
+	"github.com/kwilteam/kwil-db/node/engine/ddl"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+// NamespaceDDL reconstructs the Kuneiform DDL that defines a namespace from
+// the kwild_engine schema. It includes the tables, indexes, foreign keys,
+// actions, and privileges of the namespace, and the roles and extensions
+// they use. If the namespace does not exist, it returns an error wrapping
+// engine.ErrNamespaceNotFound.
+func (t *ThreadSafeInterpreter) NamespaceDDL(ctx context.Context, db sql.DB, namespace string) (string, error) {
+	unlock, err := t.lock(db)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	def, err := ddl.Load(ctx, func(ctx context.Context, query string, params map[string]any) (*types.QueryResult, error) {
+		res := &types.QueryResult{}
+		err := t.i.execute(newInvalidEngineCtx(ctx), db, query, params, func(row *common.Row) error {
+			if res.ColumnNames == nil {
+				res.ColumnNames = row.ColumnNames
+				res.ColumnTypes = row.ColumnTypes
+			}
+			res.Values = append(res.Values, row.Values)
+			return nil
+		}, true)
+		return res, err
+	}, namespace)
+	if err != nil {
+		return "", err
+	}
+
+	// the info namespace has the initialization parameters of extensions as
+	// text, so they are read with their types
+	exts, err := getExtensionInitializationMetadata(ctx, db)
+	if err != nil {
+		return "", err
+	}
+	def.Extensions = nil
+	for _, ext := range exts {
+		stmt := &parse.UseExtensionStatement{ExtName: ext.ExtName, Alias: ext.Alias}
+		for _, kv := range order.OrderMap(ext.Metadata) {
+			expr, err := valueExpression(kv.Value)
+			if err != nil {
+				return "", fmt.Errorf(`parameter "%s" of extension "%s": %w`, kv.Key, ext.Alias, err)
+			}
+			stmt.Config = append(stmt.Config, &struct {
+				Key   string
+				Value parse.Expression
+			}{Key: kv.Key, Value: expr})
+		}
+		def.Extensions = append(def.Extensions, stmt)
+	}
+
+	return ddl.Dump(def)
+}
+
+// valueExpression returns an expression that evaluates to a value. Values
+// whose type cannot be inferred from their literal are cast to it.
+func valueExpression(v value) (parse.Expression, error) {
+	if arr, ok := v.(arrayValue); ok {
+		expr := &parse.ExpressionMakeArray{}
+		for i := int32(1); i <= arr.Len(); i++ {
+			elem, err := arr.Get(i)
+			if err != nil {
+				return nil, err
+			}
+			e, err := valueExpression(elem)
+			if err != nil {
+				return nil, err
+			}
+			expr.Values = append(expr.Values, e)
+		}
+		expr.TypeCast = v.Type()
+		return expr, nil
+	}
+
+	lit := &parse.ExpressionLiteral{Type: v.Type(), Value: v.RawValue()}
+	switch raw := lit.Value.(type) {
+	case int64, string, bool, []byte:
+	case nil, *types.Decimal:
+		lit.TypeCast = v.Type()
+	case *types.UUID:
+		lit.Value = raw.String()
+		lit.TypeCast = v.Type()
+	default:
+		return nil, fmt.Errorf("unexpected value of type %T", raw)
+	}
+	return lit, nil
+}
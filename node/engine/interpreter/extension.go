@@ -5,6 +5,8 @@ import (
 	"fmt"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/extensions/precompiles"
@@ -71,7 +73,10 @@ func initializeExtension(ctx context.Context, svc *common.Service, db sql.DB, i
 
 				exec2 := exec.subscope(alias)
 
-				return method.Handler(exec2.engineCtx, exec2.app(), argVals, func(a []any) error {
+				endSpan := startSpan(exec2.engineCtx, "engine.precompile",
+					attribute.String("extension", alias),
+					attribute.String("method", lowerName))
+				err := method.Handler(exec2.engineCtx, exec2.app(), argVals, func(a []any) error {
 					// if no return is specified for this method, then the callback should never be called
 					if method.Returns == nil {
 						return fmt.Errorf(`%w: method "%s"."%s" returned no value, but expected one`, engine.ErrExtensionImplementation, alias, lowerName)
@@ -106,6 +111,8 @@ func initializeExtension(ctx context.Context, svc *common.Service, db sql.DB, i
 						Values:  returnVals,
 					})
 				})
+				endSpan(err)
+				return err
 			},
 			Type: executableTypePrecompile,
 		}
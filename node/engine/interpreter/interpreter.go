@@ -12,6 +12,7 @@ import (
 	"sync"
 
 	"github.com/decred/dcrd/container/lru"
+	"go.opentelemetry.io/otel/attribute"
 
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/core/types"
@@ -78,6 +79,36 @@ func (t *ThreadSafeInterpreter) ExecuteWithoutEngineCtx(ctx context.Context, db
 	return t.Execute(newInvalidEngineCtx(ctx), db, statement, params, fn)
 }
 
+// Savepoint records the in-memory state of the interpreter, such as its
+// namespaces, tables, actions, and roles. The returned function restores that
+// state. It is used when a nested DB transaction that executed statements,
+// such as one payload in a batch, is rolled back while the outer transaction
+// continues, since the interpreter only reverts its state for statements that
+// fail.
+func (t *ThreadSafeInterpreter) Savepoint() (restore func()) {
+	t.mu.RLock()
+	copied := t.i.copy()
+	t.mu.RUnlock()
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+
+		t.i.apply(copied)
+		t.i.syncNamespaceManager()
+	}
+}
+
+// SetCoverage enables recording the statement and branch coverage of actions
+// that are created after it is called. A nil coverage disables recording.
+// It is meant for testing, since it slows down execution.
+func (t *ThreadSafeInterpreter) SetCoverage(cov *Coverage) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.i.coverage = cov
+}
+
 // recursiveInterpreter is an interpreter that can call itself.
 // It is used for extensions that need to call back into the interpreter.
 type recursiveInterpreter struct {
@@ -271,7 +302,7 @@ func NewInterpreter(ctx context.Context, db sql.DB, service *common.Service, acc
 		// now, we override the built-in functions with the actions
 		namespaceFunctions := copyBuiltinExecutables()
 		for _, action := range actions {
-			exec := makeActionToExecutable(ns.Name, action)
+			exec := makeActionToExecutable(ns.Name, action, nil)
 			namespaceFunctions[exec.Name] = exec
 		}
 
@@ -531,6 +562,9 @@ type baseInterpreter struct {
 	accounts common.Accounts
 	// namespaceRegister is used to register and unregister namespaces
 	namespaceRegister engine.NamespaceRegister
+	// coverage records the execution of actions created after it is set.
+	// It is nil if coverage is not enabled.
+	coverage *Coverage
 }
 
 // copy deep copies the state of the interpreter.
@@ -549,6 +583,7 @@ func (i *baseInterpreter) copy() *baseInterpreter {
 		service:    i.service,
 		validators: i.validators,
 		accounts:   i.accounts,
+		coverage:   i.coverage,
 	}
 }
 
@@ -574,6 +609,7 @@ func (i *baseInterpreter) apply(copied *baseInterpreter) {
 	i.service = copied.service
 	i.validators = copied.validators
 	i.accounts = copied.accounts
+	i.coverage = copied.coverage
 }
 
 // adhocParseCache is an lru cache for statements that are parsed ad-hoc.
@@ -629,8 +665,13 @@ func (i *baseInterpreter) execute(ctx *common.EngineContext, db sql.DB, statemen
 		fn = func(*common.Row) error { return nil }
 	}
 
+	endSpan := startSpan(ctx, "engine.execute")
+	defer func() { endSpan(err) }()
+
 	// parse the statement
+	endParseSpan := startSpan(ctx, "engine.parse")
 	ast, err := parseAdhoc(statement)
+	endParseSpan(err)
 	if err != nil {
 		return fmt.Errorf("%w: error in top-level statement %s: %w", engine.ErrParse, statement, err)
 	}
@@ -666,10 +707,12 @@ func (i *baseInterpreter) execute(ctx *common.EngineContext, db sql.DB, statemen
 
 	interpPlanner := interpreterPlanner{}
 
-	for _, stmt := range ast {
+	for idx, stmt := range ast {
+		endStmtSpan := startSpan(ctx, "engine.statement", attribute.Int("index", idx))
 		err = stmt.Accept(&interpPlanner).(stmtFunc)(execCtx, func(row *row) error {
 			return fn(rowToCommonRow(row))
 		})
+		endStmtSpan(err)
 		if err != nil {
 			return err
 		}
@@ -738,6 +781,17 @@ func (i *baseInterpreter) call(ctx *common.EngineContext, db sql.DB, namespace,
 	namespace = strings.ToLower(namespace)
 	action = strings.ToLower(action)
 
+	endSpan := startSpan(ctx, "engine.call",
+		attribute.String("namespace", namespace),
+		attribute.String("action", action))
+	defer func() {
+		spanErr := err
+		if spanErr == nil && callRes != nil {
+			spanErr = callRes.Error
+		}
+		endSpan(spanErr)
+	}()
+
 	execCtx, err := i.newExecCtx(ctx, db, namespace, toplevel)
 	if err != nil {
 		return nil, err
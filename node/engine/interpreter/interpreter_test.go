@@ -2942,6 +2942,31 @@ func Test_ExtensionTypeChecks(t *testing.T) {
 }
 
 // This tests that SET CURRENT NAMESPACE works as expected
+func Test_Savepoint(t *testing.T) {
+	db := newTestDB(t, nil, nil)
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx) // always rollback
+
+	interp := newTestInterp(t, tx, nil, false)
+
+	// create a table in a nested transaction that is rolled back
+	nested, err := tx.BeginTx(ctx)
+	require.NoError(t, err)
+	restore := interp.Savepoint()
+	err = interp.Execute(newEngineCtx(defaultCaller), nested, `CREATE TABLE tbl (col INT PRIMARY KEY);`, nil, nil)
+	require.NoError(t, err)
+	require.NoError(t, nested.Rollback(ctx))
+	restore()
+
+	// the interpreter no longer knows the table, so it can be created again
+	err = interp.Execute(newEngineCtx(defaultCaller), tx, `CREATE TABLE tbl (col INT PRIMARY KEY);`, nil, nil)
+	require.NoError(t, err)
+	hasTable(t, interp, tx, "main", "tbl")
+}
+
 func Test_SetCurrentNamespace(t *testing.T) {
 	db := newTestDB(t, nil, nil) // dropSchemas(t, append(mainSchemas, "test_ns")...)
 
@@ -3195,3 +3220,69 @@ func Test_NamespaceDropsCache(t *testing.T) {
 	_, err = interp.CallWithoutEngineCtx(ctx, tx, "test_ns", "smthn", []any{"hello"}, nil)
 	require.NoError(t, err)
 }
+
+// Test_Coverage tests that the statements and branches of actions are recorded.
+func Test_Coverage(t *testing.T) {
+	db := newTestDB(t, nil, nil)
+
+	ctx := context.Background()
+	tx, err := db.BeginTx(ctx)
+	require.NoError(t, err)
+	defer tx.Rollback(ctx) // always rollback
+
+	interp := newTestInterp(t, tx, nil, false)
+
+	cov := interpreter.NewCoverage()
+	cov.SetSource("test.sql")
+	interp.SetCoverage(cov)
+
+	err = interp.ExecuteWithoutEngineCtx(ctx, tx, `CREATE ACTION branchy($a int) public view returns (res int) {
+	$total := 0;
+	if $a > 10 {
+		$total := 1;
+	} elseif $a > 5 {
+		$total := 2;
+	}
+	for $i in 1..$a {
+		$total := $total + $i;
+	}
+	return $total;
+}`, nil, nil)
+	require.NoError(t, err)
+
+	_, err = interp.CallWithoutEngineCtx(ctx, tx, "", "branchy", []any{20}, nil)
+	require.NoError(t, err)
+	_, err = interp.CallWithoutEngineCtx(ctx, tx, "", "branchy", []any{0}, nil)
+	require.NoError(t, err)
+
+	actions := cov.Actions()
+	require.Len(t, actions, 1)
+	act := actions[0]
+	require.Equal(t, "test.sql", act.Source)
+	require.Equal(t, "branchy", act.Name)
+	require.Equal(t, 1, act.Line)
+	require.EqualValues(t, 2, act.Calls)
+
+	hits := map[int]int64{}
+	for _, s := range act.Statements {
+		hits[s.Line] = s.Hits
+	}
+	require.Equal(t, map[int]int64{
+		2:  2,  // $total := 0
+		3:  2,  // if
+		4:  1,  // $total := 1
+		6:  0,  // $total := 2
+		8:  2,  // for
+		9:  20, // loop body
+		11: 2,  // return
+	}, hits)
+
+	var branches []int64
+	var labels []string
+	for _, b := range act.Branches {
+		branches = append(branches, b.Hits)
+		labels = append(labels, b.Label)
+	}
+	require.Equal(t, []string{"if", "elseif 1", "else", "entered", "skipped"}, labels)
+	require.Equal(t, []int64{1, 0, 1, 1, 1}, branches)
+}
@@ -15,12 +15,13 @@ import (
 	pggenerate "github.com/kwilteam/kwil-db/node/engine/pg_generate"
 )
 
-// makeActionToExecutable creates an executable from an action
-func makeActionToExecutable(namespace string, act *action) *executable {
-	planner := &interpreterPlanner{}
+// makeActionToExecutable creates an executable from an action.
+// If cov is not nil, the execution of the action's statements is recorded.
+func makeActionToExecutable(namespace string, act *action, cov *actionCoverage) *executable {
+	planner := &interpreterPlanner{coverage: cov}
 	stmtFns := make([]stmtFunc, len(act.Body))
 	for j, stmt := range act.Body {
-		stmtFns[j] = stmt.Accept(planner).(stmtFunc)
+		stmtFns[j] = planner.planStmt(stmt)
 	}
 
 	var expectedArgs []*types.DataType
@@ -58,6 +59,10 @@ func makeActionToExecutable(namespace string, act *action) *executable {
 				return err
 			}
 
+			if cov != nil {
+				cov.hit(&cov.Calls)
+			}
+
 			// validate the args
 			args, err := validateArgs(args)
 			if err != nil {
@@ -138,7 +143,27 @@ func makeActionToExecutable(namespace string, act *action) *executable {
 }
 
 // interpreterPlanner creates functions for running Kuneiform logic.
-type interpreterPlanner struct{}
+type interpreterPlanner struct {
+	// coverage records the execution of the statements of the action
+	// being planned. It is nil if coverage is not enabled.
+	coverage *actionCoverage
+}
+
+// planStmt creates the function for an action statement, recording its
+// execution if coverage is enabled.
+func (i *interpreterPlanner) planStmt(stmt parse.ActionStmt) stmtFunc {
+	fn := stmt.Accept(i).(stmtFunc)
+	if i.coverage == nil {
+		return fn
+	}
+
+	cov := i.coverage
+	counter := cov.statement(stmt)
+	return func(exec *executionContext, resFn resultFunc) error {
+		cov.hit(&counter.Hits)
+		return fn(exec, resFn)
+	}
+}
 
 var (
 
@@ -512,13 +537,34 @@ func executeBlock(exec *executionContext, fn resultFunc,
 func (i *interpreterPlanner) VisitActionStmtForLoop(p0 *parse.ActionStmtForLoop) any {
 	stmtFns := make([]stmtFunc, len(p0.Body))
 	for j, stmt := range p0.Body {
-		stmtFns[j] = stmt.Accept(i).(stmtFunc)
+		stmtFns[j] = i.planStmt(stmt)
 	}
 
 	loopFn := p0.LoopTerm.Accept(i).(loopTermFunc)
 
+	// the branches of a loop are whether its body is entered or skipped
+	var entered, skipped *int64
+	if i.coverage != nil {
+		branches := i.coverage.branchBlock(p0, "entered", "skipped")
+		entered, skipped = &branches[0].Hits, &branches[1].Hits
+	}
+	cov := i.coverage
+
 	return stmtFunc(func(exec *executionContext, fn resultFunc) error {
+		iterated := false
+		defer func() {
+			if cov == nil {
+				return
+			}
+			if iterated {
+				cov.hit(entered)
+			} else {
+				cov.hit(skipped)
+			}
+		}()
+
 		err := loopFn(exec, func(term value) error {
+			iterated = true
 			exec.scope.child()
 			defer exec.scope.popScope()
 			err := exec.allocateVariable(p0.Receiver.Name, term)
@@ -708,7 +754,7 @@ func (i *interpreterPlanner) VisitActionStmtIf(p0 *parse.ActionStmtIf) any {
 		ifFn := ifThen.If.Accept(i).(exprFunc)
 		var thenFns []stmtFunc
 		for _, stmt := range ifThen.Then {
-			thenFns = append(thenFns, stmt.Accept(i).(stmtFunc))
+			thenFns = append(thenFns, i.planStmt(stmt))
 		}
 
 		ifThenFns = append(ifThenFns, struct {
@@ -723,13 +769,32 @@ func (i *interpreterPlanner) VisitActionStmtIf(p0 *parse.ActionStmtIf) any {
 	var elseFns []stmtFunc
 	if p0.Else != nil {
 		for _, stmt := range p0.Else {
-			elseFns = append(elseFns, stmt.Accept(i).(stmtFunc))
+			elseFns = append(elseFns, i.planStmt(stmt))
+		}
+	}
+
+	// each IF and ELSEIF is a branch, followed by the ELSE branch, which is
+	// taken when no condition is true even if there is no ELSE
+	var branchHits []*int64
+	cov := i.coverage
+	if cov != nil {
+		labels := make([]string, 0, len(p0.IfThens)+1)
+		for j := range p0.IfThens {
+			if j == 0 {
+				labels = append(labels, "if")
+			} else {
+				labels = append(labels, fmt.Sprintf("elseif %d", j))
+			}
+		}
+		labels = append(labels, "else")
+		for _, b := range cov.branchBlock(p0, labels...) {
+			branchHits = append(branchHits, &b.Hits)
 		}
 	}
 
 	return stmtFunc(func(exec *executionContext, fn resultFunc) error {
 		branchRun := false // tracks if any IF branch has been run
-		for _, ifThen := range ifThenFns {
+		for j, ifThen := range ifThenFns {
 			if branchRun {
 				break
 			}
@@ -754,6 +819,9 @@ func (i *interpreterPlanner) VisitActionStmtIf(p0 *parse.ActionStmtIf) any {
 			}
 
 			branchRun = true
+			if cov != nil {
+				cov.hit(branchHits[j])
+			}
 
 			err = executeBlock(exec, fn, ifThen.Then)
 			if err != nil {
@@ -761,6 +829,10 @@ func (i *interpreterPlanner) VisitActionStmtIf(p0 *parse.ActionStmtIf) any {
 			}
 		}
 
+		if !branchRun && cov != nil {
+			cov.hit(branchHits[len(branchHits)-1])
+		}
+
 		if !branchRun && p0.Else != nil {
 			err := executeBlock(exec, fn, elseFns)
 			if err != nil {
@@ -1898,7 +1970,8 @@ func (i *interpreterPlanner) VisitCreateActionStatement(p0 *parse.CreateActionSt
 			return err
 		}
 
-		execute := makeActionToExecutable(exec.scope.namespace, &act)
+		cov := exec.interpreter.coverage.registerAction(exec.scope.namespace, p0.Name, p0.GetPosition())
+		execute := makeActionToExecutable(exec.scope.namespace, &act, cov)
 		namespace.availableFunctions[p0.Name] = execute
 
 		return nil
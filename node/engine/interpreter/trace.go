@@ -0,0 +1,32 @@
+package interpreter
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/node/metrics"
+)
+
+// startSpan starts a span that is a child of the span in the engine context's
+// transaction context, if any. Until the returned function is called, the new
+// span is the parent of any spans started from the engine context, such as
+// those of nested action calls, queries, and extension methods. The returned
+// function ends the span, recording the error if it is non-nil, and restores
+// the original context.
+func startSpan(ctx *common.EngineContext, name string, attrs ...attribute.KeyValue) func(error) {
+	txCtx := ctx.TxContext
+	parent := txCtx.Ctx
+	spanParent := parent
+	if spanParent == nil {
+		spanParent = context.Background()
+	}
+	spanCtx, span := metrics.EngineTracer.Start(spanParent, name, trace.WithAttributes(attrs...))
+	txCtx.Ctx = spanCtx
+	return func(err error) {
+		txCtx.Ctx = parent
+		metrics.EndSpan(span, err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 
 	"github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/node/engine"
+	"github.com/kwilteam/kwil-db/node/engine/format"
+	"github.com/kwilteam/kwil-db/node/engine/parse"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -904,3 +906,40 @@ func testRoundTripParse(t *testing.T, v value) {
 		t.Fatalf("values not equal: %v != %v", v.RawValue(), val2.RawValue())
 	}
 }
+
+func Test_valueExpression(t *testing.T) {
+	tests := []struct {
+		name string
+		val  any
+		want string
+	}{
+		{"int", int64(1), "1"},
+		{"text", "sepolia", "'sepolia'"},
+		{"bool", true, "TRUE"},
+		{"decimal", types.MustParseDecimalExplicit("1.50", 10, 2), "1.50::NUMERIC(10,2)"},
+		{"null", (*string)(nil), "NULL::TEXT"},
+		{"array", []string{"a", "b"}, "ARRAY['a', 'b']::TEXT[]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v, err := newValue(tt.val)
+			require.NoError(t, err)
+
+			expr, err := valueExpression(v)
+			require.NoError(t, err)
+
+			// expressions are formatted in a USE statement
+			sql, err := format.Statement(&parse.UseExtensionStatement{
+				ExtName: "ext",
+				Alias:   "a",
+				Config: []*struct {
+					Key   string
+					Value parse.Expression
+				}{{Key: "v", Value: expr}},
+			})
+			require.NoError(t, err)
+			require.Equal(t, "USE ext {v: "+tt.want+"} AS a;", sql)
+		})
+	}
+}
@@ -0,0 +1,298 @@
+// Package lint statically checks Kuneiform for likely mistakes that the parser
+// and engine accept, such as unused variables and unreachable code.
+package lint
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/node/engine/parse"
+)
+
+// Rules that can be reported.
+const (
+	// RuleUnusedVariable reports local variables that are assigned but never
+	// read.
+	RuleUnusedVariable = "unused-variable"
+	// RuleUnreachableCode reports statements that follow a return, break, or
+	// continue in the same block.
+	RuleUnreachableCode = "unreachable-code"
+	// RuleMissingAccessModifier reports actions without a public, private, or
+	// system modifier, which the engine rejects when the action is created.
+	RuleMissingAccessModifier = "missing-access-modifier"
+	// RuleUnorderedLoop reports loops over a SELECT without an ORDER BY. The
+	// engine orders the results by default, but the order should be explicit
+	// when the loop body depends on it.
+	RuleUnorderedLoop = "unordered-loop"
+	// RuleShadowedVariable reports variables that are declared in a nested
+	// block with the name of a variable or parameter of an enclosing block.
+	RuleShadowedVariable = "shadowed-variable"
+)
+
+// Rules are all the rules, in the order they are documented.
+var Rules = []string{
+	RuleUnusedVariable,
+	RuleUnreachableCode,
+	RuleMissingAccessModifier,
+	RuleUnorderedLoop,
+	RuleShadowedVariable,
+}
+
+// Diagnostic is a problem found by the linter.
+type Diagnostic struct {
+	// Line is the 1-based line of the problem, and Col the 1-based column.
+	Line int `json:"line"`
+	Col  int `json:"col"`
+	// Rule is the rule that reported the problem.
+	Rule string `json:"rule"`
+	// Message describes the problem.
+	Message string `json:"message"`
+}
+
+// String formats the diagnostic as "line:col: rule: message".
+func (d *Diagnostic) String() string {
+	return fmt.Sprintf("%d:%d: %s: %s", d.Line, d.Col, d.Rule, d.Message)
+}
+
+// Lint parses Kuneiform source and checks it. Diagnostics are sorted by
+// position, and positions are relative to the source, as opposed to the
+// trimmed source used by the parser.
+func Lint(src string) ([]*Diagnostic, error) {
+	stmts, err := parse.Parse(src)
+	if err != nil {
+		return nil, err
+	}
+
+	// the parser trims leading whitespace, so positions must be shifted
+	trimmed := strings.TrimLeft(src, " \t\r\n")
+	offset := strings.Count(src[:len(src)-len(trimmed)], "\n")
+
+	diags := Statements(stmts)
+	for _, d := range diags {
+		d.Line += offset
+	}
+	return diags, nil
+}
+
+// Statements checks parsed statements. Diagnostics are sorted by position.
+func Statements(stmts []parse.TopLevelStatement) []*Diagnostic {
+	l := &linter{}
+	for _, stmt := range stmts {
+		if act, ok := stmt.(*parse.CreateActionStatement); ok {
+			l.action(act)
+		}
+	}
+
+	slices.SortStableFunc(l.diags, func(a, b *Diagnostic) int {
+		return cmp.Or(cmp.Compare(a.Line, b.Line), cmp.Compare(a.Col, b.Col))
+	})
+	return l.diags
+}
+
+type linter struct {
+	diags []*Diagnostic
+}
+
+// report adds a diagnostic at the start of a position.
+func (l *linter) report(pos *parse.Position, rule, msg string, args ...any) {
+	d := &Diagnostic{
+		Rule:    rule,
+		Message: fmt.Sprintf(msg, args...),
+	}
+	if pos != nil && pos.StartLine != nil && pos.StartCol != nil {
+		d.Line = *pos.StartLine
+		d.Col = *pos.StartCol + 1
+	}
+	l.diags = append(l.diags, d)
+}
+
+// variable is a variable declared in an action.
+type variable struct {
+	name string
+	pos  *parse.Position
+	// kind is "parameter", "loop variable", or "variable".
+	kind string
+	used bool
+}
+
+// scope is a block of an action, which variables are declared in.
+type scope struct {
+	parent *scope
+	vars   map[string]*variable
+}
+
+func (s *scope) child() *scope {
+	return &scope{parent: s, vars: make(map[string]*variable)}
+}
+
+// lookup finds a variable in the scope or its parents.
+func (s *scope) lookup(name string) *variable {
+	for ; s != nil; s = s.parent {
+		if v, ok := s.vars[name]; ok {
+			return v
+		}
+	}
+	return nil
+}
+
+// actionLinter checks a single action.
+type actionLinter struct {
+	*linter
+	// declared are the variables declared in the action, in order.
+	declared []*variable
+}
+
+func (l *linter) action(act *parse.CreateActionStatement) {
+	hasAccess := false
+	for _, mod := range act.Modifiers {
+		switch strings.ToLower(mod) {
+		case "public", "private", "system":
+			hasAccess = true
+		}
+	}
+	if !hasAccess {
+		l.report(act.GetPosition(), RuleMissingAccessModifier,
+			`action "%s" has no access modifier; one of public, private, or system is required`, act.Name)
+	}
+
+	a := &actionLinter{linter: l}
+	top := (&scope{}).child()
+	for _, param := range act.Parameters {
+		top.vars[param.Name] = &variable{name: param.Name, kind: "parameter", used: true}
+	}
+
+	a.block(act.Statements, top)
+
+	for _, v := range a.declared {
+		if !v.used && v.kind == "variable" {
+			l.report(v.pos, RuleUnusedVariable, "variable %s is assigned but never used", v.name)
+		}
+	}
+}
+
+// declare declares a variable in a scope, reporting it if it shadows a
+// variable of an enclosing scope.
+func (a *actionLinter) declare(s *scope, name string, pos *parse.Position, kind string) {
+	if _, ok := s.vars[name]; ok {
+		// the engine rejects redeclaring a variable in the same scope
+		return
+	}
+
+	if outer := s.parent.lookup(name); outer != nil {
+		a.report(pos, RuleShadowedVariable, "%s %s shadows %s %s", kind, name, outer.kind, name)
+	}
+
+	v := &variable{name: name, pos: pos, kind: kind}
+	s.vars[name] = v
+	a.declared = append(a.declared, v)
+}
+
+// use marks the variables read by a node as used.
+func (a *actionLinter) use(s *scope, node any) {
+	parse.RecursivelyVisitPositions(node, func(gp parse.GetPositioner) {
+		if v, ok := gp.(*parse.ExpressionVariable); ok && v.Prefix == parse.VariablePrefixDollar {
+			if decl := s.lookup(v.Name); decl != nil {
+				decl.used = true
+			}
+		}
+	})
+}
+
+// block checks a block of statements in its own scope. It returns true if the
+// block always ends with a return, break, or continue.
+func (a *actionLinter) block(stmts []parse.ActionStmt, s *scope) (terminates bool) {
+	var terminator string
+	reported := false
+	for _, stmt := range stmts {
+		if terminator != "" && !reported {
+			a.report(stmt.GetPosition(), RuleUnreachableCode, "unreachable code after %s", terminator)
+			reported = true
+		}
+
+		switch stmt := stmt.(type) {
+		case *parse.ActionStmtDeclaration:
+			a.declare(s, stmt.Variable.Name, stmt.GetPosition(), "variable")
+		case *parse.ActionStmtAssign:
+			a.use(s, stmt.Value)
+			switch v := stmt.Variable.(type) {
+			case *parse.ExpressionVariable:
+				// assigning to an undeclared variable declares it
+				if s.lookup(v.Name) == nil {
+					a.declare(s, v.Name, stmt.GetPosition(), "variable")
+				}
+			default:
+				// assigning to an array element reads the array
+				a.use(s, v)
+			}
+		case *parse.ActionStmtCall:
+			a.use(s, stmt.Call)
+			for _, r := range stmt.Receivers {
+				if r != nil && s.lookup(r.Name) == nil {
+					a.declare(s, r.Name, r.GetPosition(), "variable")
+				}
+			}
+		case *parse.ActionStmtForLoop:
+			a.use(s, stmt.LoopTerm)
+			if sql, ok := stmt.LoopTerm.(*parse.LoopTermSQL); ok {
+				a.loopOrdering(sql)
+			}
+
+			body := s.child()
+			a.declare(body, stmt.Receiver.Name, stmt.Receiver.GetPosition(), "loop variable")
+			// the body of a loop can be skipped, so a loop never terminates
+			// the enclosing block
+			a.block(stmt.Body, body)
+		case *parse.ActionStmtIf:
+			allTerminate := len(stmt.Else) > 0
+			for _, ifThen := range stmt.IfThens {
+				a.use(s, ifThen.If)
+				if !a.block(ifThen.Then, s.child()) {
+					allTerminate = false
+				}
+			}
+			if len(stmt.Else) > 0 && !a.block(stmt.Else, s.child()) {
+				allTerminate = false
+			}
+			if allTerminate && terminator == "" {
+				terminator = "if statement whose branches all return, break, or continue"
+			}
+		case *parse.ActionStmtSQL:
+			a.use(s, stmt.SQL)
+		case *parse.ActionStmtLoopControl:
+			if terminator == "" {
+				terminator = strings.ToLower(string(stmt.Type))
+			}
+		case *parse.ActionStmtReturn:
+			a.use(s, stmt.Values)
+			if stmt.SQL != nil {
+				a.use(s, stmt.SQL)
+			}
+			if terminator == "" {
+				terminator = "return"
+			}
+		case *parse.ActionStmtReturnNext:
+			a.use(s, stmt.Values)
+		}
+	}
+
+	return terminator != ""
+}
+
+// loopOrdering reports a loop over a SELECT that reads from a table without
+// ordering its results.
+func (a *actionLinter) loopOrdering(term *parse.LoopTermSQL) {
+	sel, ok := term.Statement.SQL.(*parse.SelectStatement)
+	if !ok || len(sel.Ordering) > 0 {
+		return
+	}
+
+	for _, core := range sel.SelectCores {
+		if core.From != nil {
+			a.report(term.GetPosition(), RuleUnorderedLoop,
+				"loop over SELECT without ORDER BY; the order of the rows should be explicit")
+			return
+		}
+	}
+}
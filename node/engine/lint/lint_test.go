@@ -0,0 +1,125 @@
+package lint
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Lint(t *testing.T) {
+	type testcase struct {
+		name string
+		src  string
+		want []string
+	}
+
+	tests := []testcase{
+		{
+			name: "clean action",
+			src: `CREATE ACTION act($a int) public view returns (int) {
+    $b := $a + 1;
+    for $row in SELECT id FROM t ORDER BY id {
+        $b := $b + $row.id;
+    }
+    return $b;
+};`,
+		},
+		{
+			name: "missing access modifier",
+			src: `
+CREATE ACTION act() view {
+};`,
+			want: []string{`2:1: missing-access-modifier: action "act" has no access modifier; one of public, private, or system is required`},
+		},
+		{
+			name: "unused variables",
+			src: `CREATE ACTION act($a int) public {
+    $b int;
+    $c := 1;
+    $c := 2;
+    $d, _ := other($a);
+    $e := 1;
+    INSERT INTO t VALUES ($e);
+};`,
+			want: []string{
+				"2:5: unused-variable: variable $b is assigned but never used",
+				"3:5: unused-variable: variable $c is assigned but never used",
+				"5:5: unused-variable: variable $d is assigned but never used",
+			},
+		},
+		{
+			name: "unreachable code",
+			src: `CREATE ACTION act($a int) public returns (int) {
+    for $i in 1..$a {
+        if $i > 2 {
+            break;
+            $a := 1;
+        }
+        continue;
+        return 1;
+    }
+    if $a > 1 {
+        return 1;
+    } else {
+        return 2;
+    }
+    return 3;
+};`,
+			want: []string{
+				"5:13: unreachable-code: unreachable code after break",
+				"8:9: unreachable-code: unreachable code after continue",
+				"15:5: unreachable-code: unreachable code after if statement whose branches all return, break, or continue",
+			},
+		},
+		{
+			name: "unordered loops",
+			src: `CREATE ACTION act() public {
+    for $row in SELECT id FROM t {
+        INSERT INTO u VALUES ($row.id);
+    }
+    for $row in SELECT 1 AS id {
+        INSERT INTO u VALUES ($row.id);
+    }
+};`,
+			want: []string{"2:17: unordered-loop: loop over SELECT without ORDER BY; the order of the rows should be explicit"},
+		},
+		{
+			name: "shadowed variables",
+			src: `CREATE ACTION act($a int) public {
+    $b := 1;
+    if $a > 1 {
+        $b int;
+        $b := 2;
+        $a int;
+        INSERT INTO t VALUES ($a, $b);
+    }
+    for $a in 1..2 {
+        INSERT INTO t VALUES ($a, $b);
+    }
+};`,
+			want: []string{
+				"4:9: shadowed-variable: variable $b shadows variable $b",
+				"6:9: shadowed-variable: variable $a shadows parameter $a",
+				"9:9: shadowed-variable: loop variable $a shadows parameter $a",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diags, err := Lint(tt.src)
+			require.NoError(t, err)
+
+			var got []string
+			for _, d := range diags {
+				got = append(got, d.String())
+			}
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_LintParseError(t *testing.T) {
+	_, err := Lint(`CREATE ACTION act( {`)
+	require.Error(t, err)
+}
@@ -0,0 +1,48 @@
+package parse
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/kwilteam/kwil-db/node/engine/parse/gen"
+)
+
+// Comment is a comment in Kuneiform source. The parser discards comments,
+// so they are returned separately by ParseComments.
+type Comment struct {
+	Position
+	// Text is the text of the comment, including the comment markers
+	// (e.g. "-- comment" or "/* comment */").
+	Text string
+}
+
+// ParseComments returns the comments in Kuneiform source, in the order they
+// appear. Their positions can be compared to the positions of the nodes
+// returned by Parse for the same source.
+func ParseComments(sql string) []*Comment {
+	// this must trim the same way as the parser, so that positions match
+	sql = strings.TrimSpace(sql)
+
+	lexer := gen.NewKuneiformLexer(antlr.NewInputStream(sql))
+	lexer.RemoveErrorListeners()
+
+	var comments []*Comment
+	for {
+		tok := lexer.NextToken()
+		if tok.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+
+		switch tok.GetTokenType() {
+		case gen.KuneiformLexerBLOCK_COMMENT, gen.KuneiformLexerLINE_COMMENT, gen.KuneiformLexerSQL_COMMENT:
+			c := &Comment{Text: tok.GetText()}
+			c.SetToken(tok)
+			// block comments can span multiple lines
+			c.EndLine = intPtr(tok.GetLine() + strings.Count(tok.GetText(), "\n"))
+			comments = append(comments, c)
+		}
+	}
+
+	return comments
+}
@@ -0,0 +1,27 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseComments(t *testing.T) {
+	comments := ParseComments(`
+-- a
+SELECT 1; /* b
+c */ // d`)
+	require.Len(t, comments, 3)
+
+	require.Equal(t, "-- a", comments[0].Text)
+	require.Equal(t, 1, *comments[0].StartLine)
+	require.Equal(t, 0, *comments[0].StartCol)
+
+	require.Equal(t, "/* b\nc */", comments[1].Text)
+	require.Equal(t, 2, *comments[1].StartLine)
+	require.Equal(t, 10, *comments[1].StartCol)
+	require.Equal(t, 3, *comments[1].EndLine)
+
+	require.Equal(t, "// d", comments[2].Text)
+	require.Equal(t, 3, *comments[2].StartLine)
+}
@@ -19,6 +19,9 @@ type ConsensusEngine interface {
 
 	AcceptProposal(height int64, blkID, prevBlkID types.Hash, leaderSig []byte, timestamp int64) bool
 	NotifyBlockProposal(blk *ktypes.Block, done func())
+	// AcceptRelay determines if a node that does not process a proposal
+	// should still relay it, as a sentry node does for its private peers.
+	AcceptRelay(height int64, blkID types.Hash, leaderSig []byte) bool
 
 	AcceptCommit(height int64, blkID types.Hash, hdr *ktypes.BlockHeader, ci *ktypes.CommitInfo, leaderSig []byte) bool
 	NotifyBlockCommit(blk *ktypes.Block, ci *ktypes.CommitInfo, blkID types.Hash, doneFn func())
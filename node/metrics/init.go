@@ -4,11 +4,13 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
 	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
 	"go.opentelemetry.io/otel/sdk/trace"
@@ -18,8 +20,9 @@ import (
 type OTELOption func(*otelOptions)
 
 type otelOptions struct {
-	endpoint string
-	interval time.Duration
+	endpoint  string
+	interval  time.Duration
+	traceFile string
 }
 
 func WithOTELEndpoint(endpoint string) OTELOption {
@@ -34,9 +37,18 @@ func WithOTELInterval(interval time.Duration) OTELOption {
 	}
 }
 
-// StartOTEL bootstraps the OpenTelemetry pipeline. The collected metrics are
-// exported to the specified OTLP (opentelemetry protocol) collector HTTP
-// endpoint. The endpoint is in host port format, with no schema, as it uses
+// WithOTELTraceFile specifies a file to which spans are written, one JSON
+// object per span, in addition to exporting them to the OTLP collector. This is
+// useful for inspecting traces without running a collector.
+func WithOTELTraceFile(file string) OTELOption {
+	return func(o *otelOptions) {
+		o.traceFile = file
+	}
+}
+
+// StartOTEL bootstraps the OpenTelemetry pipeline. The collected metrics and
+// traces are exported to the specified OTLP (opentelemetry protocol) collector
+// HTTP endpoint, and traces may also be written to a file. The endpoint is in host port format, with no schema, as it uses
 // unencrypted HTTP currently. If it does not return an error, make sure to call
 // shutdown for proper cleanup.
 func StartOTEL(ctx context.Context, options ...OTELOption) (func(context.Context) error, error) {
@@ -76,24 +88,44 @@ func StartOTEL(ctx context.Context, options ...OTELOption) (func(context.Context
 		return errors.Join(inErr, shutdown(ctx))
 	}
 
-	// Set up propagator.
-	// prop := newPropagator()
-	// otel.SetTextMapPropagator(prop)
+	// Set up propagator so that trace context in incoming request headers
+	// (and outgoing, if we ever make any) is honored.
+	otel.SetTextMapPropagator(TracePropagator)
 
 	// Set up trace provider.
 	traceExporter, err := otlptracehttp.New(context.Background(),
 		otlptracehttp.WithEndpoint(opts.endpoint),
 		otlptracehttp.WithInsecure(),
 	)
-	// traceExporter, err := stdouttrace.New(stdouttrace.WithPrettyPrint())
 	if err != nil {
 		return nil, handleErr(err)
 	}
-	tracerProvider := trace.NewTracerProvider(
+	traceOpts := []trace.TracerProviderOption{
 		trace.WithResource(res),
 		trace.WithBatcher(traceExporter, trace.WithBatchTimeout(opts.interval)),
-	)
+	}
+
+	var traceFile *os.File
+	if opts.traceFile != "" {
+		traceFile, err = os.OpenFile(opts.traceFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+		if err != nil {
+			return nil, handleErr(fmt.Errorf("failed to open trace file: %w", err))
+		}
+		fileExporter, err := stdouttrace.New(stdouttrace.WithWriter(traceFile))
+		if err != nil {
+			traceFile.Close()
+			return nil, handleErr(err)
+		}
+		traceOpts = append(traceOpts, trace.WithBatcher(fileExporter, trace.WithBatchTimeout(opts.interval)))
+	}
+
+	tracerProvider := trace.NewTracerProvider(traceOpts...)
 	shutdownFuncs = append(shutdownFuncs, tracerProvider.Shutdown)
+	if traceFile != nil { // after the provider is shutdown and flushed
+		shutdownFuncs = append(shutdownFuncs, func(context.Context) error {
+			return traceFile.Close()
+		})
+	}
 
 	otel.SetTracerProvider(tracerProvider) // for use with otel.Tracer()
 
@@ -128,13 +160,6 @@ func StartOTEL(ctx context.Context, options ...OTELOption) (func(context.Context
 	return shutdown, nil
 }
 
-// func newPropagator() propagation.TextMapPropagator {
-// 	return propagation.NewCompositeTextMapPropagator(
-// 		propagation.TraceContext{},
-// 		propagation.Baggage{},
-// 	)
-// }
-
 // func newLoggerProvider() (*log.LoggerProvider, error) {
 // 	logExporter, err := stdoutlog.New()
 // 	if err != nil {
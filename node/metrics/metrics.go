@@ -103,6 +103,8 @@ const (
 	BlockStoreMeterName = "github.com/kwilteam/kwil-db/node/store"
 
 	AccountsMeterName = "github.com/kwilteam/kwil-db/node/accounts"
+
+	TxAppMeterName = "github.com/kwilteam/kwil-db/node/txapp"
 )
 
 // init sets up all meters and instruments. Initially, the no-op meter
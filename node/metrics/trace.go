@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// These are the tracers used to create spans in each of the instrumented
+// components. Like the meters, they are obtained from the otel global provider,
+// so they are no-op tracers until and unless StartOTEL is called, at which
+// point they delegate to the real tracer provider.
+var (
+	RPCTracer            = otel.Tracer(RPCMeterName)
+	TxAppTracer          = otel.Tracer(TxAppMeterName)
+	BlockProcessorTracer = otel.Tracer(BlockProcessorMeterName)
+	EngineTracer         = otel.Tracer(EngineMeterName)
+)
+
+// TracePropagator is the propagator used to extract trace context from the
+// headers of incoming requests (W3C traceparent/tracestate and baggage). It is
+// installed as the otel global propagator by StartOTEL, but it is also used
+// directly by the RPC server so that an upstream trace ID is honored even if it
+// is only logged.
+var TracePropagator propagation.TextMapPropagator = propagation.NewCompositeTextMapPropagator(
+	propagation.TraceContext{},
+	propagation.Baggage{},
+)
+
+// EndSpan records a non-nil error on the span, sets the span's status
+// accordingly, and ends the span. It is intended for use with a named error
+// return in a defer:
+//
+//	ctx, span := metrics.EngineTracer.Start(ctx, "name")
+//	defer func() { metrics.EndSpan(span, err) }()
+func EndSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
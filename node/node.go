@@ -70,6 +70,17 @@ type peerManager interface {
 	// Allowed() []peer.ID
 	AllowedPersistent() []peer.ID
 	// IsAllowed(p peer.ID) bool
+	IsPrivate(p peer.ID) bool
+
+	peerScorer
+	PeerScores() []peers.PeerScore
+}
+
+// peerScorer records the behavior of peers, so that the peer manager can ban
+// those that misbehave.
+type peerScorer interface {
+	ReportMisbehavior(p peer.ID, m peers.Misbehavior)
+	ReportGood(p peer.ID)
 }
 
 type WhitelistMgr struct {
@@ -143,6 +154,7 @@ type Node struct {
 	// discResp chan types.DiscoveryResponse
 
 	blkPropHandling chan struct{}
+	relayedProp     types.Hash // last relayed proposal, guarded by blkPropHandling
 
 	txQueue chan orderedTxn // enforces ordering in the tx broadcasts to the network.
 
@@ -201,6 +213,7 @@ func NewNode(cfg *Config, opts ...Option) (*Node, error) {
 	node.host.SetStreamHandler(ProtocolIDTx, node.txGetStreamHandler)
 
 	node.host.SetStreamHandler(ProtocolIDBlockPropose, node.blkPropStreamHandler)
+	node.host.SetStreamHandler(ProtocolIDBlockProposeCompact, node.blkPropStreamHandler)
 
 	return node, nil
 }
@@ -456,6 +469,30 @@ func (n *Node) Peers(context.Context) ([]*adminTypes.PeerInfo, error) {
 	return peersInfo, nil
 }
 
+// PeerScores returns the scores of peers that have misbehaved or provided
+// useful responses, lowest score first.
+func (n *Node) PeerScores(context.Context) ([]*adminTypes.PeerScore, error) {
+	now := time.Now()
+	var scores []*adminTypes.PeerScore
+	for _, ps := range n.pm.PeerScores() {
+		nodeID, err := peers.NodeIDFromPeerID(ps.ID.String())
+		if err != nil {
+			nodeID = ps.ID.String()
+		}
+		score := &adminTypes.PeerScore{
+			NodeID:    nodeID,
+			Score:     ps.Score,
+			Bans:      ps.Bans,
+			Connected: n.host.Network().Connectedness(ps.ID) == network.Connected,
+		}
+		if ps.Banned(now) {
+			score.BannedUntil = ps.BannedUntil.UnixMilli()
+		}
+		scores = append(scores, score)
+	}
+	return scores, nil
+}
+
 // Status returns the current status of the node.
 func (n *Node) Status(ctx context.Context) (*adminTypes.Status, error) {
 	ceStatus := n.ce.Status()
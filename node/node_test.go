@@ -24,6 +24,7 @@ import (
 	ktypes "github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/node/consensus"
 	"github.com/kwilteam/kwil-db/node/mempool"
+	"github.com/kwilteam/kwil-db/node/peers"
 	"github.com/kwilteam/kwil-db/node/store/memstore"
 	"github.com/kwilteam/kwil-db/node/types"
 
@@ -90,61 +91,9 @@ func makeTestHosts(t *testing.T, nNodes, nExtraHosts int, blockInterval time.Dur
 
 	for range nNodes {
 		pk, h := newTestHost(t, mn)
-		t.Logf("node host is %v", h.ID())
-
-		pkBts, _ := pk.Raw()
-		priv, err := crypto.UnmarshalSecp256k1PrivateKey(pkBts)
-		if err != nil {
-			t.Fatalf("Failed to unmarshal private key: %v", err)
-		}
-
-		// memory block store
-		bs := memstore.NewMemBS()
-		// dummy CE
-		ce := &dummyCE{}
-
-		rootDir := t.TempDir()
-		t.Logf("node root dir: %s", rootDir)
-
-		cfg := &Config{
-			ChainID: "test",
-			RootDir: rootDir,
-			PrivKey: priv,
-			Logger:  log.DiscardLogger,
-			P2P:     &defaultConfigSet.P2P,
-			// DB unused
-			DBConfig:    &defaultConfigSet.DB,
-			Statesync:   &defaultConfigSet.StateSync,
-			Mempool:     mempool.New(mempoolSz, maxTxSz),
-			BlockStore:  bs,
-			Snapshotter: newSnapshotStore(bs),
-			Consensus:   ce,
-			BlockProc:   &dummyBP{},
-		}
-
-		psCfg := &P2PServiceConfig{
-			PrivKey: priv,
-			RootDir: rootDir,
-			ChainID: "test",
-			KwilCfg: defaultConfigSet,
-			Logger:  log.DiscardLogger,
-		}
-
-		ps, err := NewP2PService(context.Background(), psCfg, h)
-		if err != nil {
-			t.Fatalf("Failed to create P2PService: %v", err)
-		}
-		cfg.P2PService = ps
+		node, ps := newTestNode(t, pk, h, defaultConfigSet)
 		p2p = append(p2p, ps)
-
-		node, err := NewNode(cfg)
-		if err != nil {
-			t.Fatalf("Failed to create Node 1: %v", err)
-		}
-
-		// privKeys = append(privKeys, priv)
 		nodes = append(nodes, node)
-
 	}
 
 	for range nExtraHosts {
@@ -158,6 +107,62 @@ func makeTestHosts(t *testing.T, nNodes, nExtraHosts int, blockInterval time.Dur
 	return nodes, hosts, p2p, mn
 }
 
+// newTestNode creates a Node with a dummy consensus engine on the given host.
+func newTestNode(t *testing.T, pk p2pcrypto.PrivKey, h host.Host, kwilCfg *config.Config) (*Node, *P2PService) {
+	t.Logf("node host is %v", h.ID())
+
+	pkBts, _ := pk.Raw()
+	priv, err := crypto.UnmarshalSecp256k1PrivateKey(pkBts)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal private key: %v", err)
+	}
+
+	// memory block store
+	bs := memstore.NewMemBS()
+	// dummy CE
+	ce := &dummyCE{}
+
+	rootDir := t.TempDir()
+	t.Logf("node root dir: %s", rootDir)
+
+	cfg := &Config{
+		ChainID: "test",
+		RootDir: rootDir,
+		PrivKey: priv,
+		Logger:  log.DiscardLogger,
+		P2P:     &kwilCfg.P2P,
+		// DB unused
+		DBConfig:    &kwilCfg.DB,
+		Statesync:   &kwilCfg.StateSync,
+		Mempool:     mempool.New(mempoolSz, maxTxSz),
+		BlockStore:  bs,
+		Snapshotter: newSnapshotStore(bs),
+		Consensus:   ce,
+		BlockProc:   &dummyBP{},
+	}
+
+	psCfg := &P2PServiceConfig{
+		PrivKey: priv,
+		RootDir: rootDir,
+		ChainID: "test",
+		KwilCfg: kwilCfg,
+		Logger:  log.DiscardLogger,
+	}
+
+	ps, err := NewP2PService(context.Background(), psCfg, h)
+	if err != nil {
+		t.Fatalf("Failed to create P2PService: %v", err)
+	}
+	cfg.P2PService = ps
+
+	node, err := NewNode(cfg)
+	if err != nil {
+		t.Fatalf("Failed to create Node: %v", err)
+	}
+
+	return node, ps
+}
+
 func linkAll(t *testing.T, mn mock.Mocknet) {
 	if err := mn.LinkAll(); err != nil {
 		t.Fatalf("Failed to link hosts: %v", err)
@@ -284,6 +289,8 @@ type dummyCE struct {
 	rejectProp   bool
 	rejectCommit bool
 	rejectACK    bool
+	relayProp    bool
+	queueTxErr   error
 
 	ackHandler         func(validatorPK []byte, ack types.AckRes)
 	blockCommitHandler func(blk *ktypes.Block, ci *ktypes.CommitInfo, blkID types.Hash)
@@ -305,6 +312,10 @@ func (ce *dummyCE) AcceptProposal(height int64, blkID, prevBlkID types.Hash, lea
 	return !ce.rejectProp
 }
 
+func (ce *dummyCE) AcceptRelay(height int64, blkID types.Hash, leaderSig []byte) bool {
+	return ce.relayProp
+}
+
 func (ce *dummyCE) AcceptCommit(height int64, blkID types.Hash, hdr *ktypes.BlockHeader, ci *ktypes.CommitInfo, leaderSig []byte) bool {
 	return !ce.rejectCommit
 }
@@ -355,7 +366,7 @@ func (ce *dummyCE) Role() types.Role {
 }
 
 func (ce *dummyCE) QueueTx(ctx context.Context, tx *types.Tx) error {
-	return nil
+	return ce.queueTxErr
 }
 
 func (ce *dummyCE) BroadcastTx(ctx context.Context, tx *types.Tx, sync uint8) (ktypes.Hash, *ktypes.TxResult, error) {
@@ -604,3 +615,149 @@ func TestStreamsBlockFetch(t *testing.T) {
 		}
 	})
 }
+
+func TestTxAnnMisbehavior(t *testing.T) {
+	nodes, extraHosts, _, mn := makeTestHosts(t, 1, 1, 5*time.Hour)
+	linkAll(t, mn)
+
+	n1 := nodes[0]
+	h1, h2 := n1.host, extraHosts[0]
+	ce := n1.ce.(*dummyCE)
+
+	ctx := context.Background()
+
+	// announce sends a tx announcement from h2 to n1, and provides rawTx when
+	// n1 requests it.
+	announce := func(t *testing.T, txHash types.Hash, rawTx []byte) {
+		s, err := h2.NewStream(ctx, h1.ID(), ProtocolIDTxAnn)
+		if err != nil {
+			t.Fatalf("Failed create new stream: %v", err)
+		}
+		defer s.Close()
+
+		if _, err = newTxHashAnn(txHash).WriteTo(s); err != nil {
+			t.Fatalf("Failed write to stream: %v", err)
+		}
+		req := make([]byte, len(getMsg))
+		if _, err = io.ReadFull(s, req); err != nil {
+			t.Fatalf("Failed to read get request: %v", err)
+		}
+		if _, err = s.Write(rawTx); err != nil {
+			t.Fatalf("Failed write to stream: %v", err)
+		}
+		s.CloseWrite()
+		io.ReadAll(s) // wait for n1 to hang up
+	}
+
+	score := func() float64 {
+		for _, ps := range n1.pm.PeerScores() {
+			if ps.ID == h2.ID() {
+				return ps.Score
+			}
+		}
+		return 0
+	}
+
+	t.Run("failed check is not penalized", func(t *testing.T) {
+		ce.queueTxErr = ktypes.ErrInsufficientBalance
+		defer func() { ce.queueTxErr = nil }()
+
+		tx := newTx(1, "bob", "data")
+		rawTx, _ := tx.MarshalBinary()
+		announce(t, tx.Hash(), rawTx)
+		if sc := score(); sc != 0 {
+			t.Errorf("expected no penalty, got score %v", sc)
+		}
+	})
+
+	t.Run("hash mismatch is penalized", func(t *testing.T) {
+		tx := newTx(2, "bob", "data")
+		rawTx, _ := tx.MarshalBinary()
+		announce(t, types.Hash{1}, rawTx)
+		if sc := score(); sc >= 0 {
+			t.Errorf("expected a penalty, got score %v", sc)
+		}
+	})
+}
+
+func TestSentryRelaysBlockProposals(t *testing.T) {
+	mn := mock.New()
+	t.Cleanup(func() {
+		mn.Close()
+	})
+
+	pkL, hL := newTestHost(t, mn)
+	pkS, hS := newTestHost(t, mn)
+	pkV, hV := newTestHost(t, mn)
+
+	kwilCfg := config.DefaultConfig()
+	kwilCfg.Consensus.ProposeTimeout = ktypes.Duration(5 * time.Hour)
+
+	leader, _ := newTestNode(t, pkL, hL, kwilCfg)
+	validator, _ := newTestNode(t, pkV, hV, kwilCfg)
+
+	// The validator is a private peer of the sentry, and only connects to it.
+	pubV, _ := pkV.GetPublic().Raw()
+	pubKeyV, err := crypto.UnmarshalSecp256k1PublicKey(pubV)
+	if err != nil {
+		t.Fatalf("Failed to unmarshal public key: %v", err)
+	}
+	sentryCfg := config.DefaultConfig()
+	sentryCfg.Consensus.ProposeTimeout = kwilCfg.Consensus.ProposeTimeout
+	sentryCfg.P2P.PrivatePeers = []string{peers.NodeIDFromPubKey(pubKeyV)}
+	sentry, _ := newTestNode(t, pkS, hS, sentryCfg)
+
+	for _, pair := range [][2]peer.ID{{hL.ID(), hS.ID()}, {hS.ID(), hV.ID()}} {
+		if _, err := mn.LinkPeers(pair[0], pair[1]); err != nil {
+			t.Fatalf("Failed to link hosts: %v", err)
+		}
+		if _, err := mn.ConnectPeers(pair[0], pair[1]); err != nil {
+			t.Fatalf("Failed to connect hosts: %v", err)
+		}
+	}
+
+	// The sentry relays proposals, but does not process them.
+	sentryCE := sentry.ce.(*dummyCE)
+	sentryCE.rejectProp = true
+	sentryCE.relayProp = true
+	sentryCE.blockPropHandler = func(blk *ktypes.Block) {
+		t.Errorf("sentry processed block proposal %v", blk.Hash())
+	}
+
+	gotProp := func(n *Node) <-chan *ktypes.Block {
+		ch := make(chan *ktypes.Block, 1)
+		n.ce.(*dummyCE).blockPropHandler = func(blk *ktypes.Block) {
+			ch <- blk
+		}
+		return ch
+	}
+	leaderProps, validatorProps := gotProp(leader), gotProp(validator)
+
+	startNodes(t, []*Node{leader, sentry, validator})
+	time.Sleep(100 * time.Millisecond)
+
+	ctx := context.Background()
+
+	checkProp := func(t *testing.T, props <-chan *ktypes.Block, want *ktypes.Block) {
+		select {
+		case blk := <-props:
+			if blk.Hash() != want.Hash() {
+				t.Errorf("got proposal %v, want %v", blk.Hash(), want.Hash())
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the relayed proposal")
+		}
+	}
+
+	t.Run("leader to sentry to validator", func(t *testing.T) {
+		blk, _ := createTestBlock(1, 2)
+		leader.ce.(*dummyCE).Fake().Propose(ctx, blk)
+		checkProp(t, validatorProps, blk)
+	})
+
+	t.Run("validator to sentry to network", func(t *testing.T) {
+		blk, _ := createTestBlock(2, 2)
+		validator.ce.(*dummyCE).Fake().Propose(ctx, blk)
+		checkProp(t, leaderProps, blk)
+	})
+}
@@ -47,9 +47,11 @@ func (n *Node) txAnnStreamHandler(s network.Stream) {
 	// t0 := time.Now(); log.Printf("retrieving new tx: %q", txid)
 
 	// First try to get from this stream.
+	from := s.Conn().RemotePeer()
 	rawTx, err := requestTx(s, []byte(getMsg))
 	if err != nil {
 		n.log.Warnf("announcer failed to provide %v due to error %v, trying other peers", txHash, err)
+		from = "" // not from the announcer, don't hold them responsible
 		// Since we are aware, ask other peers. we could also put this in a goroutine
 		s.Close() // close the announcers stream first
 		rawTx, err = n.getTxWithRetry(context.TODO(), txHash, 500*time.Millisecond, 10)
@@ -62,6 +64,7 @@ func (n *Node) txAnnStreamHandler(s network.Stream) {
 	var tx ktypes.Transaction
 	if err = tx.UnmarshalBinary(rawTx); err != nil {
 		n.log.Errorf("invalid transaction received %v: %v", txHash, err)
+		n.reportTxMisbehavior(from)
 		return
 	}
 
@@ -69,6 +72,7 @@ func (n *Node) txAnnStreamHandler(s network.Stream) {
 	ntx := types.NewTx(&tx) // the immutable tx for CE with Hash stored
 	if txHash != ntx.Hash() {
 		n.log.Errorf("tx hash mismatch: %v != %v", txHash, ntx.Hash())
+		n.reportTxMisbehavior(from)
 		return
 	}
 
@@ -79,14 +83,28 @@ func (n *Node) txAnnStreamHandler(s network.Stream) {
 
 	ctx := context.Background()
 	if err := n.ce.QueueTx(ctx, ntx); err != nil {
+		// Check failures such as a stale nonce or insufficient balance depend
+		// on our state, which may differ from the announcer's, so they are not
+		// the announcer's fault.
 		n.log.Warnf("tx %v (sz %d) failed check: %v from peer: %s", txHash, len(rawTx), err, s.Conn().RemotePeer())
 		return
 	}
+	if from != "" {
+		n.pm.ReportGood(from)
+	}
 
 	// re-announce
 	n.queueTxn(ctx, txHash, rawTx, s.Conn().RemotePeer())
 }
 
+// reportTxMisbehavior lowers the score of the peer that provided an invalid
+// transaction, if it is known.
+func (n *Node) reportTxMisbehavior(from peer.ID) {
+	if from != "" {
+		n.pm.ReportMisbehavior(from, peers.MisbehaviorInvalidTx)
+	}
+}
+
 func (n *Node) queueTxn(ctx context.Context, txID types.Hash, rawTx []byte, from peer.ID) {
 	tx := orderedTxn{txID: txID, rawtx: rawTx, from: from}
 
@@ -62,7 +62,42 @@ func NewP2PService(ctx context.Context, cfg *P2PServiceConfig, host host.Host) (
 		wcg = peers.NewWhitelistGater(peerWhitelist, peers.WithLogger(logger.New("PEERFILT")))
 		// PeerMan adds more from address book.
 	}
-	cg := peers.ChainConnectionGaters(wcg)
+	scores := peers.NewScoreBook(peers.WithLogger(logger.New("PEERSCORE")))
+
+	// In sentry mode, this node only connects to its sentries, which relay
+	// for it, and it is hidden from PEX and the DHT. The sentry gater is not
+	// given to PeerMan since it must not be modified, e.g. by the consensus
+	// engine whitelisting validators.
+	var sentries []*peer.AddrInfo
+	var sentryIDs []peer.ID
+	var scg *peers.WhitelistGater
+	if len(cfg.KwilCfg.P2P.SentryNodes) > 0 {
+		sentryAddrs, err := peers.ConvertPeersToMultiAddr(cfg.KwilCfg.P2P.SentryNodes)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sentry node: %w", err)
+		}
+		for _, addr := range sentryAddrs {
+			info, err := makePeerAddrInfo(addr)
+			if err != nil {
+				return nil, fmt.Errorf("invalid sentry node address %v: %w", addr, err)
+			}
+			sentries = append(sentries, info)
+			sentryIDs = append(sentryIDs, info.ID)
+		}
+		logger.Infof("Sentry mode enabled with %d sentry nodes", len(sentries))
+		scg = peers.NewWhitelistGater(sentryIDs, peers.WithLogger(logger.New("SENTRYFILT")))
+	}
+
+	var privatePeers []peer.ID
+	for _, nodeID := range cfg.KwilCfg.P2P.PrivatePeers {
+		peerID, err := nodeIDToPeerID(nodeID)
+		if err != nil {
+			return nil, fmt.Errorf("invalid private peer node ID: %w", err)
+		}
+		privatePeers = append(privatePeers, peerID)
+	}
+
+	cg := peers.ChainConnectionGaters(wcg, scg, scores)
 
 	if host == nil {
 		ip, portStr, err := net.SplitHostPort(cfg.KwilCfg.P2P.ListenAddress)
@@ -94,10 +129,16 @@ func NewP2PService(ctx context.Context, cfg *P2PServiceConfig, host host.Host) (
 		}
 	}
 
+	// Sentries are always dialed, even if the first connection fails.
+	for _, info := range sentries {
+		host.Peerstore().AddAddrs(info.ID, info.Addrs, peerstore.PermanentAddrTTL)
+	}
+
 	addrBookPath := filepath.Join(cfg.RootDir, "addrbook.json")
 
+	pex := cfg.KwilCfg.P2P.Pex && len(sentries) == 0
 	pmCfg := &peers.Config{
-		PEX:               cfg.KwilCfg.P2P.Pex,
+		PEX:               pex,
 		AddrBook:          addrBookPath,
 		Logger:            logger.New("PEERS"),
 		Host:              host,
@@ -105,6 +146,9 @@ func NewP2PService(ctx context.Context, cfg *P2PServiceConfig, host host.Host) (
 		TargetConnections: cfg.KwilCfg.P2P.TargetConnections,
 		ConnGater:         wcg,
 		RequiredProtocols: RequiredStreamProtocols,
+		Scores:            scores,
+		Sentries:          sentryIDs,
+		PrivatePeers:      privatePeers,
 	}
 	pm, err := peers.NewPeerMan(pmCfg)
 	if err != nil {
@@ -119,10 +163,16 @@ func NewP2PService(ctx context.Context, cfg *P2PServiceConfig, host host.Host) (
 	host.SetStreamHandler(ProtocolIDBlockHeight, dummyStreamHandler)
 	host.SetStreamHandler(ProtocolIDTx, dummyStreamHandler)
 	host.SetStreamHandler(ProtocolIDBlockPropose, dummyStreamHandler)
+	host.SetStreamHandler(ProtocolIDBlockProposeCompact, dummyStreamHandler)
 	host.SetStreamHandler(pubsub.GossipSubID_v12, dummyStreamHandler)
 
+	// A DHT client is not added to the routing tables of other peers, so a
+	// node in sentry mode is not discoverable via the DHT.
 	mode := dht.ModeServer
-	dht, err := makeDHT(ctx, host, nil, mode, pmCfg.PEX)
+	if len(sentries) > 0 {
+		mode = dht.ModeClient
+	}
+	dht, err := makeDHT(ctx, host, nil, mode, pmCfg.PEX, privatePeers...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create DHT: %w", err)
 	}
@@ -134,7 +184,7 @@ func NewP2PService(ctx context.Context, cfg *P2PServiceConfig, host host.Host) (
 		dht:       dht,
 		discovery: discoverer,
 		log:       logger,
-		pex:       cfg.KwilCfg.P2P.Pex,
+		pex:       pex,
 	}, nil
 }
 
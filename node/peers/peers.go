@@ -91,6 +91,13 @@ type PeerMan struct {
 	wlMtx               sync.RWMutex
 	persistentWhitelist map[peer.ID]bool // whitelist to persist
 
+	// scores tracks peer misbehavior, and is also a connection gater that
+	// enforces bans.
+	scores *ScoreBook
+
+	sentries     map[peer.ID]bool // only dial these if any (sentry mode)
+	privatePeers map[peer.ID]bool // never advertise these
+
 	requiredProtocols []protocol.ID
 
 	chainID           string
@@ -129,6 +136,15 @@ type Config struct {
 	Logger            log.Logger
 	ConnGater         *WhitelistGater
 	RequiredProtocols []protocol.ID
+	// Scores should also be a connection gater of the host to enforce bans.
+	// If nil, bans only prevent the PeerMan from dialing banned peers.
+	Scores *ScoreBook
+	// Sentries are the only peers dialed in sentry mode. They are never
+	// removed from the peer store or banned. The host should also have a
+	// connection gater that only permits these peers.
+	Sentries []peer.ID
+	// PrivatePeers are never advertised to other peers via PEX.
+	PrivatePeers []peer.ID
 }
 
 type idService interface {
@@ -148,6 +164,20 @@ func NewPeerMan(cfg *Config) (*PeerMan, error) {
 		return nil, errors.New("no IDService available.")
 	}
 
+	scores := cfg.Scores
+	if scores == nil {
+		scores = NewScoreBook(WithLogger(logger))
+	}
+
+	sentries := make(map[peer.ID]bool, len(cfg.Sentries))
+	for _, pid := range cfg.Sentries {
+		sentries[pid] = true
+	}
+	privatePeers := make(map[peer.ID]bool, len(cfg.PrivatePeers))
+	for _, pid := range cfg.PrivatePeers {
+		privatePeers[pid] = true
+	}
+
 	pm := &PeerMan{
 		h:                   host, // tmp: tooo much, should become minimal interface, maybe set after construction
 		c:                   host,
@@ -155,6 +185,9 @@ func NewPeerMan(cfg *Config) (*PeerMan, error) {
 		cg:                  cfg.ConnGater,
 		idService:           hi.IDService(),
 		persistentWhitelist: make(map[peer.ID]bool),
+		scores:              scores,
+		sentries:            sentries,
+		privatePeers:        privatePeers,
 		log:                 logger,
 		done:                done,
 		close: sync.OnceFunc(func() {
@@ -302,16 +335,21 @@ func (pm *PeerMan) maintainMinPeers(ctx context.Context) {
 				if pm.h.ID() == pid {
 					continue
 				}
-				if !pm.IsAllowed(pid) {
+				if !pm.IsAllowed(pid) || pm.scores.IsBanned(pid) {
 					continue // Connect would error anyway, just be silent
 				}
+				if len(pm.sentries) > 0 && !pm.sentries[pid] {
+					continue // sentry mode, only dial sentries
+				}
 				bk := lastAttempts[pid]
 				if bk == nil {
 					bk = newBackoffer(reconnectRetries, baseReconnectDelay, maxReconnectDelay, true)
 					lastAttempts[pid] = bk
 				}
 				if !bk.try() {
-					if bk.maxedOut() {
+					if bk.maxedOut() && pm.sentries[pid] {
+						delete(lastAttempts, pid) // never give up on a sentry
+					} else if bk.maxedOut() {
 						pm.log.Warnf("Failed to connect to peer %s (%v) after %d attempts", peerIDStringer(pid), pid, bk.attempts)
 						pm.removePeer(pid)
 					}
@@ -693,12 +731,89 @@ func (pm *PeerMan) savePeers() error {
 			Protos:      peerInfo.Protos,
 			Whitelisted: pm.persistentWhitelist[peerInfo.ID],
 		}
+		setPersistentScore(&persistentPeerList[i], pm.scores.Get(peerInfo.ID))
 	}
 	pm.wlMtx.RUnlock()
 
+	// Also keep the scores of peers that are not in the peer store, such as
+	// banned peers without known addresses.
+	for _, ps := range pm.scores.Scores() {
+		if slices.ContainsFunc(peerList, func(pi PeerInfo) bool { return pi.ID == ps.ID }) {
+			continue
+		}
+		pk, _ := pubKeyFromPeerID(ps.ID)
+		if pk == nil {
+			continue
+		}
+		ppi := PersistentPeerInfo{NodeID: NodeIDFromPubKey(pk)}
+		setPersistentScore(&ppi, ps)
+		persistentPeerList = append(persistentPeerList, ppi)
+	}
+
 	return persistPeers(persistentPeerList, pm.addrBook)
 }
 
+func setPersistentScore(ppi *PersistentPeerInfo, ps PeerScore) {
+	ppi.Score = ps.Score
+	ppi.Bans = ps.Bans
+	if !ps.BannedUntil.IsZero() {
+		ppi.BannedUntil = ps.BannedUntil.Unix()
+	}
+}
+
+// ReportMisbehavior lowers a peer's score for the misbehavior. If its score
+// falls too low, the peer is temporarily banned and disconnected. Sentries and
+// whitelisted peers, such as validators in private mode, are never penalized.
+func (pm *PeerMan) ReportMisbehavior(pid peer.ID, m Misbehavior) {
+	if pm.sentries[pid] {
+		// Banning a sentry would isolate this node.
+		pm.log.Warnf("Sentry peer %v misbehaved (%v)", peerIDStringer(pid), m)
+		return
+	}
+	if pm.isWhitelisted(pid) {
+		pm.log.Warnf("Whitelisted peer %v misbehaved (%v)", peerIDStringer(pid), m)
+		return
+	}
+	if !pm.scores.Misbehaved(pid, m) {
+		return
+	}
+	for _, conn := range pm.h.Network().ConnsToPeer(pid) {
+		if conn.Stat().Extra != nil {
+			conn.Stat().Extra[kicked] = struct{}{} // don't reconnect
+		}
+		conn.Close()
+	}
+}
+
+// isWhitelisted returns true if the peer is on the persistent whitelist, or on
+// the connection gater's whitelist in private mode.
+func (pm *PeerMan) isWhitelisted(pid peer.ID) bool {
+	pm.wlMtx.RLock()
+	persistent := pm.persistentWhitelist[pid]
+	pm.wlMtx.RUnlock()
+	if persistent {
+		return true
+	}
+	return pm.cg != nil && pm.cg.IsAllowed(pid)
+}
+
+// IsPrivate returns true if the peer is one that is never advertised to other
+// peers, such as a validator behind this sentry node.
+func (pm *PeerMan) IsPrivate(pid peer.ID) bool {
+	return pm.privatePeers[pid]
+}
+
+// ReportGood raises a peer's score for a useful response.
+func (pm *PeerMan) ReportGood(pid peer.ID) {
+	pm.scores.Behaved(pid)
+}
+
+// PeerScores returns the scores of peers with non-zero scores or bans, lowest
+// score first.
+func (pm *PeerMan) PeerScores() []PeerScore {
+	return pm.scores.Scores()
+}
+
 func (pm *PeerMan) removePeer(pid peer.ID) {
 	pm.ps.RemovePeer(pid)
 	pm.ps.ClearAddrs(pid)
@@ -768,6 +883,18 @@ func (pm *PeerMan) loadAddrBook() (int, error) {
 			}
 		}
 
+		if pInfo.Score != 0 || pInfo.Bans > 0 {
+			ps := PeerScore{ID: peerID, Score: pInfo.Score, Bans: pInfo.Bans}
+			if pInfo.BannedUntil > 0 {
+				ps.BannedUntil = time.Unix(pInfo.BannedUntil, 0)
+			}
+			pm.scores.Set(ps)
+		}
+
+		if len(pInfo.Addrs) == 0 {
+			continue // just a score
+		}
+
 		peerInfo := PeerInfo{
 			AddrInfo: AddrInfo{
 				ID:    peerID,
@@ -1131,7 +1258,7 @@ func (pm *PeerMan) removeOldPeers() {
 			defer pm.mtx.Unlock()
 			for peerID, disconnectTime := range pm.disconnects {
 				pm.wlMtx.RLock()
-				if pm.persistentWhitelist[peerID] {
+				if pm.persistentWhitelist[peerID] || pm.sentries[peerID] {
 					pm.wlMtx.RUnlock()
 					continue
 				}
@@ -43,6 +43,9 @@ func TestPersistAndLoadPeers(t *testing.T) {
 			Addrs:       []ma.Multiaddr{ma2},
 			Protos:      []protocol.ID{"ProtocolWhatever", "ProtocolOther"},
 			Whitelisted: false,
+			Score:       -42.5,
+			Bans:        2,
+			BannedUntil: 1700000000,
 		},
 	}
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"slices"
 	"time"
 
 	"github.com/libp2p/go-libp2p/core/network"
@@ -36,6 +37,9 @@ func (pm *PeerMan) DiscoveryStreamHandler(s network.Stream) {
 	// peers = slices.DeleteFunc(peers, func(p PeerInfo) bool {
 	// 	return p.ID == pid
 	// })
+	peers = slices.DeleteFunc(peers, func(p PeerInfo) bool {
+		return pm.privatePeers[p.ID] // e.g. a validator behind this sentry
+	})
 
 	s.SetWriteDeadline(time.Now().Add(4 * time.Second))
 	if err := writePeers(s, pm.chainID, peers); err != nil {
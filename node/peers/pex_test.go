@@ -183,3 +183,56 @@ func TestPeerDiscoverStream(t *testing.T) {
 		}
 	})
 }
+
+func TestPeerDiscoverStream_PrivatePeers(t *testing.T) {
+	hosts, mn := makeTestHosts(t, 3)
+
+	h1, h2, h3 := hosts[0], hosts[1], hosts[2]
+	pid1, pid2, pid3 := h1.ID(), h2.ID(), h3.ID()
+
+	pm1, err := NewPeerMan(&Config{
+		PEX:      true,
+		AddrBook: filepath.Join(t.TempDir(), "addrbook.json"),
+		Host:     h1,
+	})
+	require.NoError(t, err)
+
+	// h2 is a sentry for h3
+	_, err = NewPeerMan(&Config{
+		PEX:          true,
+		AddrBook:     filepath.Join(t.TempDir(), "addrbook.json"),
+		Host:         h2,
+		PrivatePeers: []peer.ID{pid3},
+	})
+	require.NoError(t, err)
+
+	linkPeers(t, mn, pid1, pid2)
+	linkPeers(t, mn, pid2, pid3)
+
+	addrs, err := pm1.RequestPeers(context.Background(), pid2)
+	require.NoError(t, err)
+	require.Len(t, addrs, 1)
+	require.Equal(t, pid1, addrs[0].ID)
+}
+
+func TestReportMisbehavior_Exempt(t *testing.T) {
+	hosts, _ := makeTestHosts(t, 4)
+	pid2, pid3, pid4 := hosts[1].ID(), hosts[2].ID(), hosts[3].ID()
+
+	// private mode, with pid2 whitelisted and pid3 a sentry
+	pm, err := NewPeerMan(&Config{
+		AddrBook:  filepath.Join(t.TempDir(), "addrbook.json"),
+		Host:      hosts[0],
+		ConnGater: NewWhitelistGater([]peer.ID{pid2}),
+		Sentries:  []peer.ID{pid3},
+	})
+	require.NoError(t, err)
+
+	for _, pid := range []peer.ID{pid2, pid3, pid4} {
+		pm.ReportMisbehavior(pid, MisbehaviorInvalidBlock)
+	}
+
+	scores := pm.PeerScores()
+	require.Len(t, scores, 1)
+	require.Equal(t, pid4, scores[0].ID)
+}
@@ -0,0 +1,282 @@
+package peers
+
+import (
+	"cmp"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/kwilteam/kwil-db/core/log"
+	"github.com/libp2p/go-libp2p/core/connmgr"
+	"github.com/libp2p/go-libp2p/core/control"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// Peers start with a score of zero. Misbehavior lowers a peer's score by a
+// penalty for the kind of misbehavior, while useful responses raise it, up to
+// maxScore. Scores recover toward zero over time. A peer whose score falls to
+// banScore is banned for a period that doubles with each ban, up to
+// maxBanDuration, and its score is reset.
+const (
+	maxScore               = 20.0
+	banScore               = -100.0
+	goodReward             = 1.0
+	scoreRecoveryPerMinute = 1.0
+
+	baseBanDuration = 10 * time.Minute
+	maxBanDuration  = 24 * time.Hour
+)
+
+// Misbehavior is a kind of peer misbehavior that lowers the peer's score.
+type Misbehavior uint8
+
+const (
+	// MisbehaviorInvalidBlock is a block or block response that is malformed
+	// or not the requested block.
+	MisbehaviorInvalidBlock Misbehavior = iota + 1
+	// MisbehaviorInvalidTx is a transaction that is malformed, not the
+	// requested transaction, or fails checks.
+	MisbehaviorInvalidTx
+	// MisbehaviorTimeout is a request that timed out.
+	MisbehaviorTimeout
+	// MisbehaviorInvalidSnapshot is a snapshot chunk with the wrong hash.
+	MisbehaviorInvalidSnapshot
+)
+
+func (m Misbehavior) String() string {
+	switch m {
+	case MisbehaviorInvalidBlock:
+		return "invalid block"
+	case MisbehaviorInvalidTx:
+		return "invalid transaction"
+	case MisbehaviorTimeout:
+		return "request timeout"
+	case MisbehaviorInvalidSnapshot:
+		return "invalid snapshot chunk"
+	default:
+		return "unknown"
+	}
+}
+
+func (m Misbehavior) penalty() float64 {
+	switch m {
+	case MisbehaviorInvalidBlock, MisbehaviorInvalidSnapshot:
+		return 40
+	case MisbehaviorInvalidTx:
+		return 5
+	case MisbehaviorTimeout:
+		return 10
+	default:
+		return 1
+	}
+}
+
+// PeerScore is the reputation of a peer.
+type PeerScore struct {
+	ID          peer.ID
+	Score       float64
+	Bans        int       // number of times the peer has been banned
+	BannedUntil time.Time // zero if never banned
+}
+
+// Banned indicates if the peer is banned at the given time.
+func (ps *PeerScore) Banned(now time.Time) bool {
+	return now.Before(ps.BannedUntil)
+}
+
+type scoreEntry struct {
+	score       float64
+	updated     time.Time
+	bans        int
+	bannedUntil time.Time
+}
+
+// current returns the score after recovery toward zero since the last update.
+func (e *scoreEntry) current(now time.Time) float64 {
+	recovered := now.Sub(e.updated).Minutes() * scoreRecoveryPerMinute
+	if e.score < 0 {
+		return min(0, e.score+recovered)
+	}
+	return max(0, e.score-recovered)
+}
+
+// ScoreBook tracks peer scores and bans. It is a libp2p
+// connmgr.ConnectionGater that rejects connections to and from banned peers.
+// The methods work with a nil *ScoreBook, which never bans, but do not give a
+// nil *ScoreBook to libp2p.New via libp2p.ConnectionGater.
+type ScoreBook struct {
+	logger log.Logger
+	now    func() time.Time
+
+	mtx    sync.Mutex
+	scores map[peer.ID]*scoreEntry
+}
+
+func NewScoreBook(opts ...GateOpt) *ScoreBook {
+	options := &gateOpts{
+		logger: log.DiscardLogger,
+	}
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return &ScoreBook{
+		logger: options.logger,
+		now:    time.Now,
+		scores: make(map[peer.ID]*scoreEntry),
+	}
+}
+
+func (sb *ScoreBook) entry(p peer.ID, now time.Time) *scoreEntry {
+	e, ok := sb.scores[p]
+	if !ok {
+		e = &scoreEntry{updated: now}
+		sb.scores[p] = e
+		return e
+	}
+	e.score, e.updated = e.current(now), now
+	return e
+}
+
+// Misbehaved lowers a peer's score for the misbehavior, and returns true if
+// the peer is newly banned.
+func (sb *ScoreBook) Misbehaved(p peer.ID, m Misbehavior) (banned bool) {
+	if sb == nil {
+		return false
+	}
+	sb.mtx.Lock()
+	defer sb.mtx.Unlock()
+
+	now := sb.now()
+	e := sb.entry(p, now)
+	if now.Before(e.bannedUntil) {
+		return false // already banned
+	}
+
+	e.score -= m.penalty()
+	sb.logger.Debugf("Peer %v misbehaved (%v), score %.1f", peerIDStringer(p), m, e.score)
+	if e.score > banScore {
+		return false
+	}
+
+	e.bans++
+	banDuration := min(maxBanDuration, baseBanDuration<<min(e.bans-1, 16))
+	e.bannedUntil = now.Add(banDuration)
+	e.score = 0
+	sb.logger.Warnf("Banned peer %v for %v after %v", peerIDStringer(p), banDuration, m)
+	return true
+}
+
+// Behaved raises a peer's score for a useful response.
+func (sb *ScoreBook) Behaved(p peer.ID) {
+	if sb == nil {
+		return
+	}
+	sb.mtx.Lock()
+	defer sb.mtx.Unlock()
+
+	e := sb.entry(p, sb.now())
+	e.score = min(maxScore, e.score+goodReward)
+}
+
+// IsBanned indicates if a peer is currently banned.
+func (sb *ScoreBook) IsBanned(p peer.ID) bool {
+	if sb == nil {
+		return false
+	}
+	sb.mtx.Lock()
+	defer sb.mtx.Unlock()
+
+	e, ok := sb.scores[p]
+	return ok && sb.now().Before(e.bannedUntil)
+}
+
+// Get returns the score of a peer.
+func (sb *ScoreBook) Get(p peer.ID) PeerScore {
+	ps := PeerScore{ID: p}
+	if sb == nil {
+		return ps
+	}
+	sb.mtx.Lock()
+	defer sb.mtx.Unlock()
+
+	if e, ok := sb.scores[p]; ok {
+		ps.Score, ps.Bans, ps.BannedUntil = e.current(sb.now()), e.bans, e.bannedUntil
+	}
+	return ps
+}
+
+// Scores returns the scores of all peers that have a non-zero score or have
+// been banned, lowest score first.
+func (sb *ScoreBook) Scores() []PeerScore {
+	if sb == nil {
+		return nil
+	}
+	sb.mtx.Lock()
+	defer sb.mtx.Unlock()
+
+	now := sb.now()
+	scores := make([]PeerScore, 0, len(sb.scores))
+	for p, e := range sb.scores {
+		score := e.current(now)
+		if score == 0 && e.bans == 0 {
+			continue
+		}
+		scores = append(scores, PeerScore{ID: p, Score: score, Bans: e.bans, BannedUntil: e.bannedUntil})
+	}
+	slices.SortFunc(scores, func(a, b PeerScore) int {
+		if c := cmp.Compare(a.Score, b.Score); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return scores
+}
+
+// Set restores the score of a peer, such as from the address book.
+func (sb *ScoreBook) Set(ps PeerScore) {
+	if sb == nil {
+		return
+	}
+	sb.mtx.Lock()
+	defer sb.mtx.Unlock()
+
+	sb.scores[ps.ID] = &scoreEntry{
+		score:       ps.Score,
+		updated:     sb.now(),
+		bans:        ps.Bans,
+		bannedUntil: ps.BannedUntil,
+	}
+}
+
+var _ connmgr.ConnectionGater = (*ScoreBook)(nil)
+
+// OUTBOUND
+
+func (sb *ScoreBook) InterceptPeerDial(p peer.ID) bool {
+	if sb.IsBanned(p) {
+		sb.logger.Infof("Blocking OUTBOUND dial to banned peer: %v", p)
+		return false
+	}
+	return true
+}
+
+func (sb *ScoreBook) InterceptAddrDial(p peer.ID, addr multiaddr.Multiaddr) bool { return true }
+
+// INBOUND
+
+func (sb *ScoreBook) InterceptAccept(connAddrs network.ConnMultiaddrs) bool { return true }
+
+func (sb *ScoreBook) InterceptSecured(dir network.Direction, p peer.ID, conn network.ConnMultiaddrs) bool {
+	if sb.IsBanned(p) {
+		sb.logger.Infof("Blocking INBOUND connection from banned peer: %v", p)
+		return false
+	}
+	return true
+}
+
+func (sb *ScoreBook) InterceptUpgraded(conn network.Conn) (bool, control.DisconnectReason) {
+	return true, 0
+}
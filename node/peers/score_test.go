@@ -0,0 +1,85 @@
+package peers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScoreBook(now *time.Time) *ScoreBook {
+	sb := NewScoreBook()
+	sb.now = func() time.Time { return *now }
+	return sb
+}
+
+func TestScoreBook_Ban(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	sb := newTestScoreBook(&now)
+	p := peer.ID("peer1")
+
+	// invalid blocks: -40, -80, then banned at -120
+	require.False(t, sb.Misbehaved(p, MisbehaviorInvalidBlock))
+	require.False(t, sb.Misbehaved(p, MisbehaviorInvalidBlock))
+	require.Equal(t, -80.0, sb.Get(p).Score)
+	require.False(t, sb.IsBanned(p))
+	require.True(t, sb.InterceptPeerDial(p))
+
+	require.True(t, sb.Misbehaved(p, MisbehaviorInvalidBlock))
+	require.True(t, sb.IsBanned(p))
+	require.False(t, sb.InterceptPeerDial(p))
+	require.False(t, sb.InterceptSecured(0, p, nil))
+	require.False(t, sb.Misbehaved(p, MisbehaviorInvalidBlock)) // already banned
+
+	ps := sb.Get(p)
+	require.Equal(t, 1, ps.Bans)
+	require.Equal(t, now.Add(baseBanDuration), ps.BannedUntil)
+
+	// The ban expires, and the next ban is twice as long.
+	now = now.Add(baseBanDuration)
+	require.False(t, sb.IsBanned(p))
+	for !sb.Misbehaved(p, MisbehaviorInvalidSnapshot) {
+	}
+	ps = sb.Get(p)
+	require.Equal(t, 2, ps.Bans)
+	require.Equal(t, now.Add(2*baseBanDuration), ps.BannedUntil)
+
+	// Other peers are unaffected.
+	require.False(t, sb.IsBanned("peer2"))
+}
+
+func TestScoreBook_Recovery(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	sb := newTestScoreBook(&now)
+	p := peer.ID("peer1")
+
+	sb.Misbehaved(p, MisbehaviorTimeout)
+	require.Equal(t, -10.0, sb.Get(p).Score)
+
+	now = now.Add(4 * time.Minute)
+	require.Equal(t, -6.0, sb.Get(p).Score)
+	now = now.Add(time.Hour)
+	require.Zero(t, sb.Get(p).Score)
+	require.Empty(t, sb.Scores())
+
+	// good responses raise the score up to the max
+	for range 2 * int(maxScore) {
+		sb.Behaved(p)
+	}
+	require.Equal(t, maxScore, sb.Get(p).Score)
+
+	sb.Misbehaved("peer2", MisbehaviorInvalidTx)
+	scores := sb.Scores()
+	require.Len(t, scores, 2)
+	require.Equal(t, peer.ID("peer2"), scores[0].ID)
+	require.Equal(t, p, scores[1].ID)
+}
+
+func TestScoreBook_Nil(t *testing.T) {
+	var sb *ScoreBook
+	require.False(t, sb.Misbehaved("peer1", MisbehaviorInvalidBlock))
+	sb.Behaved("peer1")
+	require.False(t, sb.IsBanned("peer1"))
+	require.Empty(t, sb.Scores())
+}
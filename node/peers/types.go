@@ -73,6 +73,11 @@ type PersistentPeerInfo struct {
 	Protos      []protocol.ID         `json:"protos"`
 	Whitelisted bool                  `json:"whitelisted"`
 	// We probably need a last connected time and/or ttl
+
+	// Score, Bans, and BannedUntil (unix seconds) persist the peer's score.
+	Score       float64 `json:"score,omitempty"`
+	Bans        int     `json:"bans,omitempty"`
+	BannedUntil int64   `json:"banned_until,omitempty"`
 }
 
 func (p PersistentPeerInfo) MarshalJSON() ([]byte, error) {
@@ -89,11 +94,17 @@ func (p PersistentPeerInfo) MarshalJSON() ([]byte, error) {
 		Addrs       []string `json:"addrs"`
 		Protos      []string `json:"protos"`
 		Whitelisted bool     `json:"whitelisted"`
+		Score       float64  `json:"score,omitempty"`
+		Bans        int      `json:"bans,omitempty"`
+		BannedUntil int64    `json:"banned_until,omitempty"`
 	}{
 		ID:          p.NodeID,
 		Addrs:       addrStrs,
 		Protos:      protoStrs,
 		Whitelisted: p.Whitelisted,
+		Score:       p.Score,
+		Bans:        p.Bans,
+		BannedUntil: p.BannedUntil,
 	})
 }
 
@@ -103,6 +114,9 @@ func (p *PersistentPeerInfo) UnmarshalJSON(data []byte) error {
 		Addrs       []string `json:"addrs"`
 		Protos      []string `json:"protos"`
 		Whitelisted bool     `json:"whitelisted"`
+		Score       float64  `json:"score,omitempty"`
+		Bans        int      `json:"bans,omitempty"`
+		BannedUntil int64    `json:"banned_until,omitempty"`
 	}{}
 	if err := json.Unmarshal(data, &aux); err != nil {
 		return err
@@ -110,6 +124,9 @@ func (p *PersistentPeerInfo) UnmarshalJSON(data []byte) error {
 
 	p.NodeID = aux.ID
 	p.Whitelisted = aux.Whitelisted
+	p.Score = aux.Score
+	p.Bans = aux.Bans
+	p.BannedUntil = aux.BannedUntil
 
 	for _, addrStr := range aux.Addrs {
 		addr, err := multiaddr.NewMultiaddr(addrStr)
@@ -9,6 +9,8 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
+	"os"
 	"time"
 
 	ktypes "github.com/kwilteam/kwil-db/core/types"
@@ -33,8 +35,13 @@ const (
 	// ProtocolIDBlockHeader protocol.ID = "/kwil/blkhdr/1.0.0"
 
 	ProtocolIDBlockPropose protocol.ID = "/kwil/blkprop/1.0.0"
+	// ProtocolIDBlockProposeCompact is ProtocolIDBlockPropose with the option
+	// to request a compact block with the hashes of transactions that the
+	// peer likely has in its mempool.
+	ProtocolIDBlockProposeCompact protocol.ID = "/kwil/blkprop/1.1.0"
 	// ProtocolIDACKProposal  protocol.ID = "/kwil/blkack/1.0.0"
-	getMsg = "get" // context dependent, in open stream convo
+	getMsg     = "get" // context dependent, in open stream convo
+	compactMsg = "cmp" // request for a compact block, same length as getMsg
 )
 
 func requestFrom(ctx context.Context, host host.Host, peer peer.ID, resID []byte,
@@ -90,6 +97,15 @@ func readResp(rd io.Reader, limit int64) ([]byte, error) {
 	return resp, nil
 }
 
+// isTimeout indicates if an error from a request is from a deadline or timeout.
+func isTimeout(err error) bool {
+	if errors.Is(err, os.ErrDeadlineExceeded) || errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 const (
 	// annWriteTimeout the content announcement write timeout when sending
 	// the resource identifier, which is very small.
@@ -28,6 +28,7 @@ import (
 type Node interface {
 	Status(context.Context) (*types.Status, error)
 	Peers(context.Context) ([]*types.PeerInfo, error)
+	PeerScores(context.Context) ([]*types.PeerScore, error)
 	BroadcastTx(ctx context.Context, tx *ktypes.Transaction, sync uint8) (ktypes.Hash, *ktypes.TxResult, error)
 	Role() ntypes.Role
 	AbortBlockExecution(height int64, txIDs []ktypes.Hash) error
@@ -162,6 +163,9 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 		adminjson.MethodPeers: rpcserver.MakeMethodDef(svc.Peers,
 			"get the current peers of the node",
 			"a list of the node's current peers"),
+		adminjson.MethodPeerScores: rpcserver.MakeMethodDef(svc.PeerScores,
+			"get the scores of peers that have misbehaved or provided useful responses",
+			"the peer scores and bans, lowest score first"),
 		adminjson.MethodConfig: rpcserver.MakeMethodDef(svc.GetConfig,
 			"retrieve the current effective node config",
 			"the raw bytes of the effective config TOML document"),
@@ -186,6 +190,18 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 		adminjson.MethodValRemove: rpcserver.MakeMethodDef(svc.Remove,
 			"vote to remote a validator",
 			"the hash of the broadcasted validator remove transaction"),
+		adminjson.MethodValStake: rpcserver.MakeMethodDef(svc.Stake,
+			"bond stake from the node's account to its own validator",
+			"the hash of the broadcasted stake transaction"),
+		adminjson.MethodValDelegate: rpcserver.MakeMethodDef(svc.Delegate,
+			"bond stake from the node's account to a validator",
+			"the hash of the broadcasted delegate transaction"),
+		adminjson.MethodValUndelegate: rpcserver.MakeMethodDef(svc.Undelegate,
+			"unbond the node's stake with a validator",
+			"the hash of the broadcasted undelegate transaction"),
+		adminjson.MethodValStakes: rpcserver.MakeMethodDef(svc.ListStakes,
+			"list the stake bonded to validators and the stake that is unbonding",
+			"the bonded and unbonding stake"),
 		adminjson.MethodValPromote: rpcserver.MakeMethodDef(svc.Promote,
 			"promote a validator to leader starting from the specified height", ""),
 		adminjson.MethodAddPeer: rpcserver.MakeMethodDef(svc.AddPeer,
@@ -293,6 +309,16 @@ func (svc *Service) Status(ctx context.Context, req *adminjson.StatusRequest) (*
 	}, nil
 }
 
+func (svc *Service) PeerScores(ctx context.Context, _ *adminjson.PeerScoresRequest) (*adminjson.PeerScoresResponse, *jsonrpc.Error) {
+	scores, err := svc.blockchain.PeerScores(ctx)
+	if err != nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "peer scores unavailable", nil)
+	}
+	return &adminjson.PeerScoresResponse{
+		Scores: scores,
+	}, nil
+}
+
 func (svc *Service) Peers(ctx context.Context, _ *adminjson.PeersRequest) (*adminjson.PeersResponse, *jsonrpc.Error) {
 	peers, err := svc.blockchain.Peers(ctx)
 	if err != nil {
@@ -381,6 +407,86 @@ func (svc *Service) Remove(ctx context.Context, req *adminjson.RemoveRequest) (*
 	})
 }
 
+// parseAmount parses a positive decimal amount from a request.
+func parseAmount(amount string) (*big.Int, *jsonrpc.Error) {
+	amt, ok := new(big.Int).SetString(amount, 10)
+	if !ok || amt.Sign() <= 0 {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "amount must be a positive integer", nil)
+	}
+	return amt, nil
+}
+
+func (svc *Service) Stake(ctx context.Context, req *adminjson.StakeRequest) (*userjson.BroadcastResponse, *jsonrpc.Error) {
+	amt, jsonErr := parseAmount(req.Amount)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	return svc.sendTx(ctx, &ktypes.Stake{
+		Amount: amt,
+	})
+}
+
+func (svc *Service) Delegate(ctx context.Context, req *adminjson.DelegateRequest) (*userjson.BroadcastResponse, *jsonrpc.Error) {
+	amt, jsonErr := parseAmount(req.Amount)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	return svc.sendTx(ctx, &ktypes.Delegate{
+		Validator: req.PubKey,
+		KeyType:   req.PubKeyType,
+		Amount:    amt,
+	})
+}
+
+func (svc *Service) Undelegate(ctx context.Context, req *adminjson.UndelegateRequest) (*userjson.BroadcastResponse, *jsonrpc.Error) {
+	amt, jsonErr := parseAmount(req.Amount)
+	if jsonErr != nil {
+		return nil, jsonErr
+	}
+	return svc.sendTx(ctx, &ktypes.Undelegate{
+		Validator: req.PubKey,
+		KeyType:   req.PubKeyType,
+		Amount:    amt,
+	})
+}
+
+func (svc *Service) ListStakes(ctx context.Context, req *adminjson.ListStakesRequest) (*adminjson.ListStakesResponse, *jsonrpc.Error) {
+	readTx := svc.db.BeginDelayedReadTx()
+	defer readTx.Rollback(ctx)
+
+	stakes, err := voting.GetStakes(ctx, readTx)
+	if err != nil {
+		svc.log.Error("failed to retrieve stakes", "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorDBInternal, "failed to retrieve stakes", nil)
+	}
+	unbonding, err := voting.GetUnbonding(ctx, readTx)
+	if err != nil {
+		svc.log.Error("failed to retrieve unbonding stake", "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorDBInternal, "failed to retrieve unbonding stake", nil)
+	}
+
+	res := &adminjson.ListStakesResponse{
+		Stakes:    make([]*types.Stake, len(stakes)),
+		Unbonding: make([]*types.Unbonding, len(unbonding)),
+	}
+	for i, s := range stakes {
+		res.Stakes[i] = &types.Stake{
+			Validator: s.Validator,
+			Delegator: s.Delegator,
+			Amount:    s.Amount.String(),
+		}
+	}
+	for i, u := range unbonding {
+		res.Unbonding[i] = &types.Unbonding{
+			Delegator: u.Delegator,
+			Height:    u.Height,
+			Amount:    u.Amount.String(),
+		}
+	}
+
+	return res, nil
+}
+
 func (svc *Service) Promote(ctx context.Context, req *adminjson.PromoteRequest) (*adminjson.PromoteResponse, *jsonrpc.Error) {
 	// convert this into crypto.PublicKey
 	pubKey, err := crypto.UnmarshalPublicKey(req.PubKey, req.PubKeyType)
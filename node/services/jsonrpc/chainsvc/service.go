@@ -73,17 +73,33 @@ func NewService(log log.Logger, blockchain Node, voting Validators, genesisCfg *
 		})
 	}
 	genCfg := &chainjson.GenesisResponse{
-		ChainID:          genesisCfg.ChainID,
-		InitialHeight:    genesisCfg.InitialHeight,
-		DBOwner:          genesisCfg.DBOwner,
-		Leader:           genesisCfg.Leader,
-		Validators:       genesisCfg.Validators,
-		StateHash:        genesisCfg.StateHash,
-		Allocs:           allocs,
-		MaxBlockSize:     genesisCfg.MaxBlockSize,
-		JoinExpiry:       genesisCfg.JoinExpiry,
-		DisabledGasCosts: genesisCfg.DisabledGasCosts,
-		MaxVotesPerTx:    genesisCfg.MaxVotesPerTx,
+		ChainID:                  genesisCfg.ChainID,
+		InitialHeight:            genesisCfg.InitialHeight,
+		DBOwner:                  genesisCfg.DBOwner,
+		Leader:                   genesisCfg.Leader,
+		Validators:               genesisCfg.Validators,
+		StateHash:                genesisCfg.StateHash,
+		Allocs:                   allocs,
+		MaxBlockSize:             genesisCfg.MaxBlockSize,
+		JoinExpiry:               genesisCfg.JoinExpiry,
+		DisabledGasCosts:         genesisCfg.DisabledGasCosts,
+		MaxVotesPerTx:            genesisCfg.MaxVotesPerTx,
+		NonceWindow:              genesisCfg.NonceWindow,
+		EquivocationJailBlocks:   genesisCfg.EquivocationJailBlocks,
+		EquivocationSlashPercent: genesisCfg.EquivocationSlashPercent,
+		EquivocationBurnPercent:  genesisCfg.EquivocationBurnPercent,
+		MaxEvidenceAge:           genesisCfg.MaxEvidenceAge,
+		UnbondingBlocks:          genesisCfg.UnbondingBlocks,
+		FeeLeaderPercent:         genesisCfg.FeeLeaderPercent,
+		FeeValidatorPercent:      genesisCfg.FeeValidatorPercent,
+		FeeTreasuryPercent:       genesisCfg.FeeTreasuryPercent,
+		Treasury:                 config.FormatAccountID(genesisCfg.Treasury),
+	}
+	if genesisCfg.StakePowerUnit != nil {
+		genCfg.StakePowerUnit = genesisCfg.StakePowerUnit.String()
+	}
+	if genesisCfg.StakeBlockReward != nil {
+		genCfg.StakeBlockReward = genesisCfg.StakeBlockReward.String()
 	}
 
 	return &Service{
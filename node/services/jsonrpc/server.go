@@ -24,6 +24,10 @@ import (
 	"time"
 
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/kwilteam/kwil-db/core/log"
 	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
@@ -648,7 +652,11 @@ func (s *Server) handlerJSONRPCV1(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.processJSONRPCRequest(r.Context(), w, req)
+	// Continue any trace started by the client, as indicated by the W3C
+	// traceparent header, so the spans we create are children of it.
+	ctx := metrics.TracePropagator.Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+	s.processJSONRPCRequest(ctx, w, req)
 }
 
 // processRequest handles the jsonrpc.Request with handleRequest to call the
@@ -723,9 +731,19 @@ func (s *Server) handleJSONRPCRequest(ctx context.Context, req *jsonrpc.Request)
 	s.log.Debug("handling request", "method", req.Method)
 	t0 := time.Now().UTC() // time only the handling (pertains to server utilization)
 
+	ctx, span := metrics.RPCTracer.Start(ctx, req.Method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "jsonrpc"),
+			attribute.String("rpc.method", req.Method),
+		))
+	defer span.End()
+
 	// call the method with the params
 	result, rpcErr := s.handleMethod(ctx, jsonrpc.Method(req.Method), req.Params)
 	if rpcErr != nil {
+		span.SetAttributes(attribute.Int("rpc.jsonrpc.error_code", int(rpcErr.Code)))
+		span.SetStatus(codes.Error, rpcErr.Message)
 		level := log.LevelInfo
 		switch rpcErr.Code {
 		case jsonrpc.ErrorInvalidParams, jsonrpc.ErrorInvalidRequest,
@@ -14,6 +14,7 @@ import (
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
 
 	"github.com/kwilteam/kwil-db/core/log"
 	jsonrpc "github.com/kwilteam/kwil-db/core/rpc/json"
@@ -219,3 +220,28 @@ func Test_options(t *testing.T) {
 		})
 	}
 }
+
+func Test_traceContext(t *testing.T) {
+	srv, err := NewServer("127.0.0.1:", log.DiscardLogger)
+	require.NoError(t, err)
+
+	var gotTraceID trace.TraceID
+	srv.RegisterMethodHandler(
+		"rpc.dummy",
+		MakeMethodHandler(func(ctx context.Context, _ *any) (*json.RawMessage, *jsonrpc.Error) {
+			gotTraceID = trace.SpanContextFromContext(ctx).TraceID()
+			respjson := []byte(`"hi"`)
+			return (*json.RawMessage)(&respjson), nil
+		}),
+	)
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	r := httptest.NewRequest(http.MethodPost, pathRPCV1,
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"rpc.dummy"}`))
+	r.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	w := httptest.NewRecorder()
+	srv.srv.Handler.ServeHTTP(w, r)
+
+	require.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, traceID, gotTraceID.String())
+}
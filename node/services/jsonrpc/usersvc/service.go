@@ -28,6 +28,7 @@ import (
 	"github.com/kwilteam/kwil-db/node/migrations"
 	rpcserver "github.com/kwilteam/kwil-db/node/services/jsonrpc"
 	"github.com/kwilteam/kwil-db/node/services/jsonrpc/ratelimit"
+	"github.com/kwilteam/kwil-db/node/statetree"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 	"github.com/kwilteam/kwil-db/node/voting"
 	"github.com/kwilteam/kwil-db/version"
@@ -36,6 +37,7 @@ import (
 type EngineReader interface {
 	Call(ctx *common.EngineContext, tx sql.DB, namespace, action string, args []any, resultFn func(*common.Row) error) (*common.CallResult, error)
 	Execute(ctx *common.EngineContext, tx sql.DB, query string, params map[string]any, resultFn func(*common.Row) error) error
+	NamespaceDDL(ctx context.Context, tx sql.DB, namespace string) (string, error)
 }
 
 type BlockchainTransactor interface {
@@ -51,6 +53,7 @@ type NodeApp interface {
 	NumAccounts(ctx context.Context, db sql.Executor) (count, height int64, err error)
 	Price(ctx context.Context, dbTx sql.DB, tx *types.Transaction) (*big.Int, error)
 	GetMigrationMetadata(ctx context.Context) (*types.MigrationMetadata, error)
+	BlockFees(ctx context.Context, db sql.Executor, height int64) (*types.FeeDistribution, error)
 }
 
 type Validators interface {
@@ -80,6 +83,7 @@ type Service struct {
 	chainClient BlockchainTransactor
 	validators  Validators
 	migrator    Migrator
+	stateProver StateProver // nil if the network does not have state proofs
 
 	// challenges issued to the clients
 	challengeMtx     sync.Mutex
@@ -87,6 +91,11 @@ type Service struct {
 	challengeLimiter *ratelimit.IPRateLimiter
 }
 
+// StateProver creates proofs of rows against the state root in the app hash.
+type StateProver interface {
+	Prove(height int64, key types.Hash) (*types.StateProof, error)
+}
+
 type DB interface {
 	sql.ReadTxMaker
 	sql.DelayedReadTxMaker
@@ -98,6 +107,7 @@ type serviceCfg struct {
 	challengeExpiry    time.Duration
 	challengeRateLimit float64 // challenge requests/sec, sustained
 	blockAgeThresh     time.Duration
+	stateProver        StateProver
 }
 
 // Opt is a Service option.
@@ -135,6 +145,14 @@ func WithBlockAgeHealth(ageThresh time.Duration) Opt {
 	}
 }
 
+// WithStateProver enables state proofs, for networks that maintain a state
+// tree.
+func WithStateProver(prover StateProver) Opt {
+	return func(cfg *serviceCfg) {
+		cfg.stateProver = prover
+	}
+}
+
 const (
 	defaultReadTxTimeout      = 5 * time.Second
 	defaultChallengeExpiry    = 10 * time.Second // TODO: or maybe more?
@@ -165,6 +183,7 @@ func NewService(db DB, engine EngineReader, chainClient BlockchainTransactor,
 		validators:       vals,
 		db:               db,
 		migrator:         migrator,
+		stateProver:      cfg.stateProver,
 		privateMode:      cfg.privateMode,
 		challengeExpiry:  cfg.challengeExpiry,
 		challenges:       make(map[[32]byte]time.Time),
@@ -336,11 +355,26 @@ func (svc *Service) Methods() map[jsonrpc.Method]rpcserver.MethodDef {
 			"perform an authenticated ad-hoc SQL query",
 			"the result of the query as a collection of records",
 		),
+		userjson.MethodSchema: rpcserver.MakeMethodDef(
+			svc.Schema,
+			"get the DDL of a namespace",
+			"the Kuneiform statements that define the tables, indexes, actions, roles, privileges, and extensions of the namespace",
+		),
 		userjson.MethodTxQuery: rpcserver.MakeMethodDef(
 			svc.TxQuery,
 			"query for the status of a transaction",
 			"the execution status of a transaction",
 		),
+		userjson.MethodStateProof: rpcserver.MakeMethodDef(
+			svc.StateProof,
+			"get a table row with a proof of its inclusion or absence",
+			"the row, if it exists, and the proof against the app hash of a block",
+		),
+		userjson.MethodBlockFees: rpcserver.MakeMethodDef(
+			svc.BlockFees,
+			"get the transaction fees collected in a block",
+			"the collected fees and the amounts credited to the leader, validators, and treasury, and burned",
+		),
 
 		// Migration methods
 		userjson.MethodListMigrations: rpcserver.MakeMethodDef(svc.ListPendingMigrations,
@@ -557,6 +591,28 @@ func (svc *Service) Query(ctx context.Context, req *userjson.QueryRequest) (*use
 	}, nil
 }
 
+func (svc *Service) Schema(ctx context.Context, req *userjson.SchemaRequest) (*userjson.SchemaResponse, *jsonrpc.Error) {
+	ctxExec, cancel := context.WithTimeout(ctx, svc.readTxTimeout)
+	defer cancel()
+
+	if svc.privateMode {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorNoQueryWithPrivateRPC,
+			"schema is prohibited when authenticated calls are enforced (private mode)", nil)
+	}
+	if req.Namespace == "" {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "namespace is required", nil)
+	}
+
+	readTx := svc.db.BeginDelayedReadTx()
+	defer readTx.Rollback(ctx)
+
+	ddl, err := svc.engine.NamespaceDDL(ctxExec, readTx, req.Namespace)
+	if err != nil {
+		return nil, engineError(err)
+	}
+	return &userjson.SchemaResponse{DDL: ddl}, nil
+}
+
 func (svc *Service) AuthenticatedQuery(ctx context.Context, req *userjson.AuthenticatedQueryRequest) (*userjson.QueryResponse, *jsonrpc.Error) {
 	ctxExec, cancel := context.WithTimeout(ctx, svc.readTxTimeout)
 	defer cancel()
@@ -894,6 +950,56 @@ func (svc *Service) TxQuery(ctx context.Context, req *userjson.TxQueryRequest) (
 	return txResult, nil
 }
 
+func (svc *Service) StateProof(ctx context.Context, req *userjson.StateProofRequest) (*userjson.StateProofResponse, *jsonrpc.Error) {
+	if svc.stateProver == nil {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidRequest, "state proofs are not enabled on this network", nil)
+	}
+	if req.Namespace == "" || req.Table == "" || len(req.PrimaryKey) == 0 {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "namespace, table, and primary key are required", nil)
+	}
+	if req.Height < 0 {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "height must not be negative", nil)
+	}
+
+	key := types.StateKey(req.Namespace, req.Table, req.PrimaryKey)
+	proof, err := svc.stateProver.Prove(req.Height, key)
+	if err != nil {
+		if errors.Is(err, statetree.ErrHeightNotFound) {
+			return nil, jsonrpc.NewError(jsonrpc.ErrorBlkNotFound, "no state at the requested height", nil)
+		}
+		svc.log.Warn("failed to create state proof", "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "failed to create state proof", nil)
+	}
+	proof.Namespace = req.Namespace
+	proof.Table = req.Table
+
+	return proof, nil
+}
+
+func (svc *Service) BlockFees(ctx context.Context, req *userjson.BlockFeesRequest) (*userjson.BlockFeesResponse, *jsonrpc.Error) {
+	if req.Height <= 0 {
+		return nil, jsonrpc.NewError(jsonrpc.ErrorInvalidParams, "height must be positive", nil)
+	}
+
+	readTx := svc.db.BeginDelayedReadTx()
+	defer readTx.Rollback(ctx)
+
+	fees, err := svc.nodeApp.BlockFees(ctx, readTx, req.Height)
+	if err != nil {
+		svc.log.Error("failed to get block fees", "height", req.Height, "error", err)
+		return nil, jsonrpc.NewError(jsonrpc.ErrorNodeInternal, "failed to get block fees", nil)
+	}
+
+	return &userjson.BlockFeesResponse{
+		Height:     fees.Height,
+		Collected:  fees.Collected.String(),
+		Leader:     fees.Leader.String(),
+		Validators: fees.Validators.String(),
+		Treasury:   fees.Treasury.String(),
+		Burned:     fees.Burned.String(),
+	}, nil
+}
+
 func (svc *Service) LoadChangeset(ctx context.Context, req *userjson.ChangesetRequest) (*userjson.ChangesetsResponse, *jsonrpc.Error) {
 	bts, err := svc.migrator.GetChangeset(req.Height, req.Index)
 	if err != nil {
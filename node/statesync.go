@@ -66,6 +66,7 @@ type StateSyncService struct {
 	// DHT
 	host       host.Host
 	discoverer discovery.Discovery
+	scorer     peerScorer
 
 	// Interfaces
 	db            DB
@@ -91,6 +92,7 @@ func NewStateSyncService(ctx context.Context, cfg *StatesyncConfig) (*StateSyncS
 		db:            cfg.DB,
 		host:          cfg.P2PService.host,
 		discoverer:    cfg.P2PService.discovery,
+		scorer:        cfg.P2PService.pm,
 		snapshotStore: cfg.SnapshotStore,
 		log:           cfg.Logger,
 		blockStore:    cfg.BlockStore,
@@ -163,7 +165,7 @@ func (ss *StateSyncService) DoStatesync(ctx context.Context) (bool, error) {
 	}
 
 	// request and commit the block to the blockstore
-	_, rawBlk, ci, _, err := getBlkHeight(ctx, height, ss.host, ss.log)
+	_, rawBlk, ci, _, err := getBlkHeight(ctx, height, ss.host, ss.scorer, ss.log)
 	if err != nil {
 		return false, fmt.Errorf("failed to get statesync block %d: %w", height, err)
 	}
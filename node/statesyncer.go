@@ -230,6 +230,9 @@ func (s *StateSyncService) requestSnapshotChunk(ctx context.Context, snap *snaps
 	hasher := sha256.New()
 	writer := io.MultiWriter(file, hasher)
 	if _, err := io.Copy(writer, stream); err != nil {
+		if isTimeout(err) {
+			s.scorer.ReportMisbehavior(provider.ID, peers.MisbehaviorTimeout)
+		}
 		return fmt.Errorf("failed to read snapshot chunk: %w", err)
 	}
 
@@ -239,8 +242,10 @@ func (s *StateSyncService) requestSnapshotChunk(ctx context.Context, snap *snaps
 		if err := os.Remove(chunkFile); err != nil {
 			s.log.Warn("failed to delete chunk file", "file", chunkFile, "error", err)
 		}
+		s.scorer.ReportMisbehavior(provider.ID, peers.MisbehaviorInvalidSnapshot)
 		return errors.New("chunk hash mismatch")
 	}
+	s.scorer.ReportGood(provider.ID)
 
 	return nil
 }
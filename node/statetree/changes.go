@@ -0,0 +1,332 @@
+package statetree
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/pg"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+// PrimaryKeys are the primary key columns of the user tables, in key order,
+// by schema and table name.
+type PrimaryKeys map[[2]string][]string
+
+// LoadPrimaryKeys loads the primary keys of all user tables. Tables without a
+// primary key are not in the state tree. It must be called with the consensus
+// transaction before it is precommitted, so that tables created in the block
+// are included.
+func LoadPrimaryKeys(ctx context.Context, db sql.Executor) (PrimaryKeys, error) {
+	res, err := db.Execute(ctx, `SELECT n.nspname::text, c.relname::text, a.attname::text
+		FROM pg_index i
+		JOIN pg_class c ON c.oid = i.indrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		JOIN LATERAL unnest(i.indkey::int2[]) WITH ORDINALITY AS k(attnum, ord) ON true
+		JOIN pg_attribute a ON a.attrelid = c.oid AND a.attnum = k.attnum
+		WHERE i.indisprimary AND n.nspname NOT LIKE 'kwild\_%'
+			AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+		ORDER BY n.nspname, c.relname, k.ord`)
+	if err != nil {
+		return nil, err
+	}
+
+	pks := make(PrimaryKeys)
+	for _, row := range res.Rows {
+		if len(row) != 3 {
+			return nil, fmt.Errorf("expected 3 columns, got %d", len(row))
+		}
+		schema, ok1 := row[0].(string)
+		table, ok2 := row[1].(string)
+		column, ok3 := row[2].(string)
+		if !ok1 || !ok2 || !ok3 {
+			return nil, errors.New("unexpected primary key column types")
+		}
+		key := [2]string{schema, table}
+		pks[key] = append(pks[key], column)
+	}
+	return pks, nil
+}
+
+// LoadRows loads the rows of all tables with primary keys, and passes them to
+// the add function as changes, for rebuilding the state tree. The rows are
+// encoded as they are in the changesets of the tables.
+func LoadRows(ctx context.Context, db sql.Executor, pks PrimaryKeys, add func(*Change) error) error {
+	tables := make([][2]string, 0, len(pks))
+	for tbl := range pks {
+		tables = append(tables, tbl)
+	}
+	slices.SortFunc(tables, func(a, b [2]string) int {
+		if c := strings.Compare(a[0], b[0]); c != 0 {
+			return c
+		}
+		return strings.Compare(a[1], b[1])
+	})
+
+	for _, tbl := range tables {
+		rel, err := loadRelation(ctx, db, tbl[0], tbl[1])
+		if err != nil {
+			return err
+		}
+
+		cols := make([]string, len(rel.Columns))
+		for i, col := range rel.Columns {
+			cols[i] = pgx.Identifier{col.Name}.Sanitize()
+		}
+		res, err := db.Execute(ctx, fmt.Sprintf("SELECT %s FROM %s", strings.Join(cols, ", "),
+			pgx.Identifier{rel.Schema, rel.Table}.Sanitize()))
+		if err != nil {
+			return fmt.Errorf("failed to load the rows of %s: %w", rel, err)
+		}
+
+		for _, vals := range res.Rows {
+			key, err := rowKey(rel, pks[tbl], vals)
+			if err != nil {
+				return err
+			}
+			change, err := rowChange(rel, key, vals)
+			if err != nil {
+				return err
+			}
+			if err = add(change); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// columnTypes are the Kwil types of the Postgres types that a changeset may
+// have, by OID.
+var columnTypes = map[uint32]*ktypes.DataType{
+	pgtype.Int2OID:         ktypes.IntType,
+	pgtype.Int4OID:         ktypes.IntType,
+	pgtype.Int8OID:         ktypes.IntType,
+	pgtype.TextOID:         ktypes.TextType,
+	pgtype.VarcharOID:      ktypes.TextType,
+	pgtype.BoolOID:         ktypes.BoolType,
+	pgtype.ByteaOID:        ktypes.ByteaType,
+	pgtype.UUIDOID:         ktypes.UUIDType,
+	pgtype.NumericOID:      ktypes.NumericType,
+	pgtype.Int2ArrayOID:    ktypes.IntArrayType,
+	pgtype.Int4ArrayOID:    ktypes.IntArrayType,
+	pgtype.Int8ArrayOID:    ktypes.IntArrayType,
+	pgtype.TextArrayOID:    ktypes.TextArrayType,
+	pgtype.VarcharArrayOID: ktypes.TextArrayType,
+	pgtype.BoolArrayOID:    ktypes.BoolArrayType,
+	pgtype.ByteaArrayOID:   ktypes.ByteaArrayType,
+	pgtype.UUIDArrayOID:    ktypes.UUIDArrayType,
+	pgtype.NumericArrayOID: ktypes.NumericArrayType,
+}
+
+// loadRelation loads the columns of a table, as they are in its changesets.
+func loadRelation(ctx context.Context, db sql.Executor, schema, table string) (*pg.Relation, error) {
+	res, err := db.Execute(ctx, `SELECT a.attname::text, a.atttypid::int8
+		FROM pg_attribute a
+		JOIN pg_class c ON c.oid = a.attrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = $1 AND c.relname = $2 AND a.attnum > 0 AND NOT a.attisdropped
+		ORDER BY a.attnum`, schema, table)
+	if err != nil {
+		return nil, err
+	}
+
+	rel := &pg.Relation{Schema: schema, Table: table}
+	for _, row := range res.Rows {
+		if len(row) != 2 {
+			return nil, fmt.Errorf("expected 2 columns, got %d", len(row))
+		}
+		name, ok1 := row[0].(string)
+		oid, ok2 := row[1].(int64)
+		if !ok1 || !ok2 {
+			return nil, errors.New("unexpected column info types")
+		}
+		dt, ok := columnTypes[uint32(oid)]
+		if !ok {
+			return nil, fmt.Errorf("unsupported type of column %s of %s.%s", name, schema, table)
+		}
+		rel.Columns = append(rel.Columns, &pg.Column{Name: name, Type: dt})
+	}
+	return rel, nil
+}
+
+// CollectChanges receives a block's changeset stream, and returns the changes
+// to the rows of the tables with primary keys. The stream must be drained even
+// if there is an error, so the first error is returned after the channel is
+// closed.
+func CollectChanges(changes <-chan any, pks PrimaryKeys) ([]*Change, error) {
+	c := &collector{pks: pks}
+	var err error
+	for ce := range changes {
+		if err != nil {
+			continue
+		}
+		err = c.add(ce)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c.changes, nil
+}
+
+type collector struct {
+	pks       PrimaryKeys
+	relations []*pg.Relation
+	changes   []*Change
+}
+
+func (c *collector) add(ce any) error {
+	switch ce := ce.(type) {
+	case *pg.Relation:
+		c.relations = append(c.relations, ce)
+		return nil
+	case *pg.ChangesetEntry:
+		if int(ce.RelationIdx) >= len(c.relations) {
+			return fmt.Errorf("changeset entry for unknown relation %d", ce.RelationIdx)
+		}
+		return c.addEntry(c.relations[ce.RelationIdx], ce)
+	default:
+		return nil // e.g. block spends
+	}
+}
+
+func (c *collector) addEntry(rel *pg.Relation, ce *pg.ChangesetEntry) error {
+	pkCols, ok := c.pks[[2]string{rel.Schema, rel.Table}]
+	if !ok {
+		return nil
+	}
+
+	oldVals, newVals, err := ce.DecodeTuples(rel)
+	if err != nil {
+		return fmt.Errorf("failed to decode changeset for %s: %w", rel, err)
+	}
+
+	switch ce.Kind() {
+	case pg.CSEntryKindDelete:
+		key, err := rowKey(rel, pkCols, oldVals)
+		if err != nil {
+			return err
+		}
+		c.changes = append(c.changes, &Change{Key: key})
+		return nil
+	case pg.CSEntryKindUpdate:
+		// Unchanged and TOASTed values in the new tuple are in the old tuple,
+		// since tables have a full replica identity.
+		for i, col := range ce.NewTuple {
+			if col.ValueType == pg.UnchangedUpdate || col.ValueType == pg.ToastValue {
+				newVals[i] = oldVals[i]
+			}
+		}
+		oldKey, err := rowKey(rel, pkCols, oldVals)
+		if err != nil {
+			return err
+		}
+		newKey, err := rowKey(rel, pkCols, newVals)
+		if err != nil {
+			return err
+		}
+		if oldKey != newKey {
+			c.changes = append(c.changes, &Change{Key: oldKey})
+		}
+		return c.addRow(rel, newKey, newVals)
+	default:
+		key, err := rowKey(rel, pkCols, newVals)
+		if err != nil {
+			return err
+		}
+		return c.addRow(rel, key, newVals)
+	}
+}
+
+func (c *collector) addRow(rel *pg.Relation, key ktypes.Hash, vals []any) error {
+	change, err := rowChange(rel, key, vals)
+	if err != nil {
+		return err
+	}
+	c.changes = append(c.changes, change)
+	return nil
+}
+
+// rowChange creates the change that sets the row with the given key.
+func rowChange(rel *pg.Relation, key ktypes.Hash, vals []any) (*Change, error) {
+	row := ktypes.StateRow{
+		Columns: make([]string, len(rel.Columns)),
+		Values:  make([]*ktypes.EncodedValue, len(rel.Columns)),
+	}
+	for i, col := range rel.Columns {
+		enc, err := encodeColumn(vals[i], col.Type)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode column %s of %s: %w", col.Name, rel, err)
+		}
+		row.Columns[i] = col.Name
+		row.Values[i] = enc
+	}
+
+	bts, err := row.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return &Change{Key: key, Value: bts}, nil
+}
+
+func rowKey(rel *pg.Relation, pkCols []string, vals []any) (ktypes.Hash, error) {
+	pk := make([]*ktypes.EncodedValue, len(pkCols))
+	for i, name := range pkCols {
+		idx := -1
+		for j, col := range rel.Columns {
+			if strings.EqualFold(col.Name, name) {
+				idx = j
+				break
+			}
+		}
+		if idx == -1 || idx >= len(vals) {
+			return ktypes.Hash{}, fmt.Errorf("primary key column %s not in changeset for %s", name, rel)
+		}
+		enc, err := encodeColumn(vals[idx], rel.Columns[idx].Type)
+		if err != nil {
+			return ktypes.Hash{}, fmt.Errorf("failed to encode primary key column %s of %s: %w", name, rel, err)
+		}
+		pk[i] = enc
+	}
+	return ktypes.StateKey(rel.Schema, rel.Table, pk), nil
+}
+
+// encodeColumn encodes a value of a column, with the column's type. Arrays
+// that are empty or only have nulls are encoded from the column type, since
+// their element type cannot be inferred.
+func encodeColumn(v any, dt *ktypes.DataType) (*ktypes.EncodedValue, error) {
+	if v == nil {
+		return ktypes.EncodeValue(nil)
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() != reflect.Uint8 {
+		allNull := true
+		for i := range rv.Len() {
+			if elem := rv.Index(i); !(elem.Kind() == reflect.Ptr || elem.Kind() == reflect.Interface) || !elem.IsNil() {
+				allNull = false
+				break
+			}
+		}
+		if allNull {
+			data := make([][]byte, rv.Len())
+			for i := range data {
+				data[i] = []byte{0}
+			}
+			return &ktypes.EncodedValue{Type: *dt.Copy(), Data: data}, nil
+		}
+	}
+
+	enc, err := ktypes.EncodeValue(v)
+	if err != nil {
+		return nil, err
+	}
+	enc.Type = *dt.Copy()
+	return enc, nil
+}
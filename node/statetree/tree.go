@@ -0,0 +1,619 @@
+// Package statetree maintains the state tree, a sparse Merkle tree of the rows
+// of all user tables whose root is committed to by a block's app hash. It is
+// updated from the changesets of each block, and serves inclusion and absence
+// proofs for rows at any committed height.
+//
+// The tree is stored in its own badger database. Nodes are content-addressed
+// and never pruned, so a proof may be created for any height since genesis, or
+// since the tree was last rebuilt from the user tables with Rebuild. See the
+// core/types package for the hashing scheme and proof verification.
+package statetree
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/kwilteam/kwil-db/core/log"
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+)
+
+var (
+	// ErrHeightNotFound is returned by Prove for a height that is not
+	// committed.
+	ErrHeightNotFound = errors.New("state tree height not found")
+)
+
+var (
+	nsNode   = []byte("n:") // node by hash
+	nsRoot   = []byte("r:") // state hashes by height
+	keyBest  = []byte("height")
+	keyBase  = []byte("base") // height and root of the last rebuild
+	leafByte = byte(0)
+	nodeByte = byte(1)
+)
+
+// Change is a change to a row of the state tree.
+type Change struct {
+	Key ktypes.Hash
+	// Value is the serialized row, or nil if the row was deleted.
+	Value []byte
+}
+
+// Tree is a persistent sparse Merkle tree of rows.
+type Tree struct {
+	db  *badger.DB
+	log log.Logger
+
+	mtx    sync.RWMutex
+	height int64       // last committed height
+	root   ktypes.Hash // root at the last committed height
+
+	// the update for the next height, which is staged until Commit
+	stagedHeight int64
+	stagedRoot   ktypes.Hash
+	staged       map[ktypes.Hash][]byte // new nodes
+}
+
+// Open opens or creates the state tree in the given directory.
+func Open(dir string, logger log.Logger) (*Tree, error) {
+	if logger == nil {
+		logger = log.DiscardLogger
+	}
+
+	bOpts := badger.DefaultOptions(filepath.Join(dir, "tree"))
+	bOpts.Logger = &badgerLogger{logger.NewWithLevel(log.LevelWarn, "BADGER")}
+	db, err := badger.Open(bOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Tree{
+		db:  db,
+		log: logger,
+	}
+
+	err = db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(keyBest)
+		if errors.Is(err, badger.ErrKeyNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		val, err := item.ValueCopy(nil)
+		if err != nil {
+			return err
+		}
+		t.height = int64(binary.BigEndian.Uint64(val))
+
+		sh, err := getStateHashes(txn, t.height)
+		if errors.Is(err, ErrHeightNotFound) {
+			// rebuilt at this height, so there are no state hashes
+			t.root, err = getBaseRoot(txn, t.height)
+			return err
+		}
+		if err != nil {
+			return err
+		}
+		t.root = sh.StateRoot
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load the state tree: %w", err)
+	}
+
+	return t, nil
+}
+
+// Close closes the tree's database.
+func (t *Tree) Close() error {
+	return t.db.Close()
+}
+
+// Height returns the last committed height.
+func (t *Tree) Height() int64 {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	return t.height
+}
+
+// Update applies the changes of the block at the given height, and returns the
+// new root. The update is staged until Commit or Rollback. If the height is
+// already committed, which happens when a block is re-executed after a crash,
+// the changes are not applied and the committed root is returned.
+func (t *Tree) Update(height int64, changes []*Change) (ktypes.Hash, error) {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if height <= t.height {
+		var sh *ktypes.StateHashes
+		err := t.db.View(func(txn *badger.Txn) (err error) {
+			sh, err = getStateHashes(txn, height)
+			return err
+		})
+		if err != nil {
+			return ktypes.Hash{}, err
+		}
+		return sh.StateRoot, nil
+	}
+
+	t.staged = make(map[ktypes.Hash][]byte)
+	root := t.root
+	for _, c := range changes {
+		var err error
+		if c.Value == nil {
+			root, err = t.remove(root, 0, c.Key)
+		} else {
+			root, err = t.insert(root, 0, c.Key, c.Value)
+		}
+		if err != nil {
+			t.staged = nil
+			return ktypes.Hash{}, err
+		}
+	}
+
+	t.stagedHeight = height
+	t.stagedRoot = root
+	return root, nil
+}
+
+// Commit persists the staged update with the state hashes of its block, which
+// must include the root returned by Update.
+func (t *Tree) Commit(sh *ktypes.StateHashes) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	if t.staged == nil {
+		return nil // re-executed block that was already committed
+	}
+	if sh.StateRoot != t.stagedRoot {
+		return errors.New("state hashes do not match the staged state root")
+	}
+
+	// Nodes are content-addressed, so nodes written before a crash are only
+	// garbage until the height is committed.
+	if err := t.writeStaged(); err != nil {
+		return err
+	}
+
+	shBts := marshalStateHashes(sh)
+	err := t.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(rootKey(t.stagedHeight), shBts); err != nil {
+			return err
+		}
+		return txn.Set(keyBest, binary.BigEndian.AppendUint64(nil, uint64(t.stagedHeight)))
+	})
+	if err != nil {
+		return err
+	}
+
+	t.height = t.stagedHeight
+	t.root = t.stagedRoot
+	t.staged = nil
+	return nil
+}
+
+// Rollback discards the staged update.
+func (t *Tree) Rollback() {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	t.staged = nil
+}
+
+// rebuildFlushNodes is the number of new nodes that are written by Rebuild at
+// a time, which bounds its memory use.
+const rebuildFlushNodes = 100_000
+
+// Rebuild replaces the tree with one of the rows added by the load function,
+// committed at the given height. It is used when the tree is behind the
+// database, such as after a crash or when restoring from a snapshot. There are
+// no state hashes at the rebuilt height, so proofs are only available for the
+// heights that are committed after it. Any staged update is discarded.
+func (t *Tree) Rebuild(height int64, load func(add func(*Change) error) error) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+
+	t.staged = make(map[ktypes.Hash][]byte)
+	defer func() { t.staged = nil }()
+
+	var root ktypes.Hash
+	err := load(func(c *Change) error {
+		var err error
+		if c.Value == nil {
+			root, err = t.remove(root, 0, c.Key)
+		} else {
+			root, err = t.insert(root, 0, c.Key, c.Value)
+		}
+		if err != nil {
+			return err
+		}
+		if len(t.staged) >= rebuildFlushNodes {
+			return t.writeStaged()
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load the state tree rows: %w", err)
+	}
+	if err = t.writeStaged(); err != nil {
+		return err
+	}
+
+	base := binary.BigEndian.AppendUint64(nil, uint64(height))
+	base = append(base, root[:]...)
+	err = t.db.Update(func(txn *badger.Txn) error {
+		if err := txn.Set(keyBase, base); err != nil {
+			return err
+		}
+		return txn.Set(keyBest, binary.BigEndian.AppendUint64(nil, uint64(height)))
+	})
+	if err != nil {
+		return err
+	}
+
+	t.log.Infof("Rebuilt the state tree at height %d with root %s", height, root)
+
+	t.height = height
+	t.root = root
+	return nil
+}
+
+// writeStaged writes the staged nodes, which may then be read from the
+// database.
+func (t *Tree) writeStaged() error {
+	wb := t.db.NewWriteBatch()
+	defer wb.Cancel()
+	for hash, node := range t.staged {
+		if err := wb.Set(nodeKey(hash), node); err != nil {
+			return err
+		}
+	}
+	if err := wb.Flush(); err != nil {
+		return err
+	}
+	clear(t.staged)
+	return nil
+}
+
+// Prove creates a proof for the row with the given key at a committed height.
+// The namespace and table of the proof are not set.
+func (t *Tree) Prove(height int64, key ktypes.Hash) (*ktypes.StateProof, error) {
+	t.mtx.RLock()
+	if height == 0 {
+		height = t.height
+	}
+	committed := height <= t.height
+	t.mtx.RUnlock()
+	if !committed || height <= 0 {
+		return nil, ErrHeightNotFound
+	}
+
+	proof := &ktypes.StateProof{
+		Height: height,
+		Key:    key,
+	}
+
+	err := t.db.View(func(txn *badger.Txn) error {
+		sh, err := getStateHashes(txn, height)
+		if err != nil {
+			return err
+		}
+		proof.StateHashes = *sh
+
+		hash := sh.StateRoot
+		for depth := 0; ; depth++ {
+			if hash.IsZero() {
+				return nil
+			}
+			node, err := getNode(txn, hash)
+			if err != nil {
+				return err
+			}
+
+			if node[0] == leafByte {
+				leafKey, value := splitLeaf(node)
+				if leafKey == key {
+					var row ktypes.StateRow
+					if err = row.UnmarshalBinary(value); err != nil {
+						return err
+					}
+					proof.Row = &row
+				} else {
+					proof.OtherLeaf = &ktypes.StateLeaf{
+						Key:       leafKey,
+						ValueHash: ktypes.HashBytes(value),
+					}
+				}
+				return nil
+			}
+
+			left, right := splitNode(node)
+			if ktypes.StateKeyBit(key, depth) == 0 {
+				proof.Siblings = append(proof.Siblings, right)
+				hash = left
+			} else {
+				proof.Siblings = append(proof.Siblings, left)
+				hash = right
+			}
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return proof, nil
+}
+
+// insert sets the value of the key in the subtree at the given depth, and
+// returns the new subtree hash.
+func (t *Tree) insert(hash ktypes.Hash, depth int, key ktypes.Hash, value []byte) (ktypes.Hash, error) {
+	if hash.IsZero() {
+		return t.putLeaf(key, value), nil
+	}
+
+	node, err := t.getNode(hash)
+	if err != nil {
+		return ktypes.Hash{}, err
+	}
+
+	if node[0] == leafByte {
+		leafKey, _ := splitLeaf(node)
+		if leafKey == key {
+			return t.putLeaf(key, value), nil
+		}
+		// Push the existing leaf down until the keys diverge.
+		return t.split(hash, leafKey, depth, key, value), nil
+	}
+
+	left, right := splitNode(node)
+	if ktypes.StateKeyBit(key, depth) == 0 {
+		if left, err = t.insert(left, depth+1, key, value); err != nil {
+			return ktypes.Hash{}, err
+		}
+	} else {
+		if right, err = t.insert(right, depth+1, key, value); err != nil {
+			return ktypes.Hash{}, err
+		}
+	}
+	return t.putNode(left, right), nil
+}
+
+// split creates the subtree at the given depth with an existing leaf and a
+// new leaf.
+func (t *Tree) split(leafHash, leafKey ktypes.Hash, depth int, key ktypes.Hash, value []byte) ktypes.Hash {
+	existingBit, newBit := ktypes.StateKeyBit(leafKey, depth), ktypes.StateKeyBit(key, depth)
+	if existingBit != newBit {
+		newLeaf := t.putLeaf(key, value)
+		if newBit == 0 {
+			return t.putNode(newLeaf, leafHash)
+		}
+		return t.putNode(leafHash, newLeaf)
+	}
+
+	child := t.split(leafHash, leafKey, depth+1, key, value)
+	if newBit == 0 {
+		return t.putNode(child, ktypes.Hash{})
+	}
+	return t.putNode(ktypes.Hash{}, child)
+}
+
+// remove deletes the key from the subtree at the given depth, and returns the
+// new subtree hash. A subtree left with a single leaf collapses to that leaf.
+func (t *Tree) remove(hash ktypes.Hash, depth int, key ktypes.Hash) (ktypes.Hash, error) {
+	if hash.IsZero() {
+		return hash, nil
+	}
+
+	node, err := t.getNode(hash)
+	if err != nil {
+		return ktypes.Hash{}, err
+	}
+
+	if node[0] == leafByte {
+		if leafKey, _ := splitLeaf(node); leafKey == key {
+			return ktypes.Hash{}, nil
+		}
+		return hash, nil
+	}
+
+	left, right := splitNode(node)
+	child, sibling := left, right
+	if ktypes.StateKeyBit(key, depth) == 1 {
+		child, sibling = right, left
+	}
+
+	newChild, err := t.remove(child, depth+1, key)
+	if err != nil {
+		return ktypes.Hash{}, err
+	}
+	if newChild == child {
+		return hash, nil
+	}
+
+	// Collapse the node if it is left with one leaf and an empty subtree.
+	if newChild.IsZero() {
+		isLeaf, err := t.isLeaf(sibling)
+		if err != nil {
+			return ktypes.Hash{}, err
+		}
+		if isLeaf {
+			return sibling, nil
+		}
+	}
+	if sibling.IsZero() {
+		isLeaf, err := t.isLeaf(newChild)
+		if err != nil {
+			return ktypes.Hash{}, err
+		}
+		if isLeaf {
+			return newChild, nil
+		}
+	}
+
+	if ktypes.StateKeyBit(key, depth) == 0 {
+		return t.putNode(newChild, sibling), nil
+	}
+	return t.putNode(sibling, newChild), nil
+}
+
+func (t *Tree) isLeaf(hash ktypes.Hash) (bool, error) {
+	if hash.IsZero() {
+		return false, nil
+	}
+	node, err := t.getNode(hash)
+	if err != nil {
+		return false, err
+	}
+	return node[0] == leafByte, nil
+}
+
+func (t *Tree) putLeaf(key ktypes.Hash, value []byte) ktypes.Hash {
+	node := make([]byte, 0, 1+ktypes.HashLen+len(value))
+	node = append(node, leafByte)
+	node = append(node, key[:]...)
+	node = append(node, value...)
+
+	hash := ktypes.StateLeafHash(key, ktypes.HashBytes(value))
+	t.staged[hash] = node
+	return hash
+}
+
+func (t *Tree) putNode(left, right ktypes.Hash) ktypes.Hash {
+	node := make([]byte, 0, 1+2*ktypes.HashLen)
+	node = append(node, nodeByte)
+	node = append(node, left[:]...)
+	node = append(node, right[:]...)
+
+	hash := ktypes.StateNodeHash(left, right)
+	t.staged[hash] = node
+	return hash
+}
+
+// getNode gets a staged or committed node.
+func (t *Tree) getNode(hash ktypes.Hash) ([]byte, error) {
+	if node, ok := t.staged[hash]; ok {
+		return node, nil
+	}
+	var node []byte
+	err := t.db.View(func(txn *badger.Txn) (err error) {
+		node, err = getNode(txn, hash)
+		return err
+	})
+	return node, err
+}
+
+func getNode(txn *badger.Txn, hash ktypes.Hash) ([]byte, error) {
+	item, err := txn.Get(nodeKey(hash))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state tree node %s: %w", hash, err)
+	}
+	node, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(node) == 0 || (node[0] == leafByte && len(node) < 1+ktypes.HashLen) ||
+		(node[0] == nodeByte && len(node) != 1+2*ktypes.HashLen) {
+		return nil, fmt.Errorf("invalid state tree node %s", hash)
+	}
+	return node, nil
+}
+
+func getStateHashes(txn *badger.Txn, height int64) (*ktypes.StateHashes, error) {
+	item, err := txn.Get(rootKey(height))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil, ErrHeightNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return nil, err
+	}
+	return unmarshalStateHashes(val)
+}
+
+func getBaseRoot(txn *badger.Txn, height int64) (ktypes.Hash, error) {
+	item, err := txn.Get(keyBase)
+	if err != nil {
+		return ktypes.Hash{}, fmt.Errorf("failed to get the state tree root at height %d: %w", height, err)
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return ktypes.Hash{}, err
+	}
+	if len(val) != 8+ktypes.HashLen || int64(binary.BigEndian.Uint64(val)) != height {
+		return ktypes.Hash{}, fmt.Errorf("no state tree root at height %d", height)
+	}
+	var root ktypes.Hash
+	copy(root[:], val[8:])
+	return root, nil
+}
+
+func splitLeaf(node []byte) (key ktypes.Hash, value []byte) {
+	copy(key[:], node[1:1+ktypes.HashLen])
+	return key, node[1+ktypes.HashLen:]
+}
+
+func splitNode(node []byte) (left, right ktypes.Hash) {
+	copy(left[:], node[1:1+ktypes.HashLen])
+	copy(right[:], node[1+ktypes.HashLen:])
+	return left, right
+}
+
+func nodeKey(hash ktypes.Hash) []byte {
+	return append(bytes.Clone(nsNode), hash[:]...)
+}
+
+func rootKey(height int64) []byte {
+	return binary.BigEndian.AppendUint64(bytes.Clone(nsRoot), uint64(height))
+}
+
+func marshalStateHashes(sh *ktypes.StateHashes) []byte {
+	b := make([]byte, 0, 7*ktypes.HashLen)
+	for _, h := range []ktypes.Hash{sh.PrevApp, sh.Changeset, sh.ValUpdates, sh.Accounts,
+		sh.TxResults, sh.ParamUpdates, sh.StateRoot} {
+		b = append(b, h[:]...)
+	}
+	return b
+}
+
+func unmarshalStateHashes(b []byte) (*ktypes.StateHashes, error) {
+	if len(b) != 7*ktypes.HashLen {
+		return nil, errors.New("invalid state hashes")
+	}
+	sh := &ktypes.StateHashes{}
+	for i, h := range []*ktypes.Hash{&sh.PrevApp, &sh.Changeset, &sh.ValUpdates, &sh.Accounts,
+		&sh.TxResults, &sh.ParamUpdates, &sh.StateRoot} {
+		copy(h[:], b[i*ktypes.HashLen:])
+	}
+	return sh, nil
+}
+
+// badgerLogger implements the badger.Logger interface.
+type badgerLogger struct {
+	log log.Logger
+}
+
+func (b *badgerLogger) Debugf(msg string, args ...any) {
+	b.log.Debugf(msg, args...)
+}
+
+func (b *badgerLogger) Errorf(msg string, args ...any) {
+	b.log.Errorf(msg, args...)
+}
+
+func (b *badgerLogger) Infof(msg string, args ...any) {
+	b.log.Infof(msg, args...)
+}
+
+func (b *badgerLogger) Warningf(msg string, args ...any) {
+	b.log.Warnf(msg, args...)
+}
@@ -0,0 +1,240 @@
+package statetree
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	ktypes "github.com/kwilteam/kwil-db/core/types"
+)
+
+func openTestTree(t *testing.T, dir string) *Tree {
+	tree, err := Open(dir, nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { tree.Close() })
+	return tree
+}
+
+func testRow(t *testing.T, id int64, name string) (ktypes.Hash, []byte) {
+	idVal, err := ktypes.EncodeValue(id)
+	require.NoError(t, err)
+	nameVal, err := ktypes.EncodeValue(name)
+	require.NoError(t, err)
+
+	row := ktypes.StateRow{
+		Columns: []string{"id", "name"},
+		Values:  []*ktypes.EncodedValue{idVal, nameVal},
+	}
+	bts, err := row.MarshalBinary()
+	require.NoError(t, err)
+
+	return ktypes.StateKey("main", "users", []*ktypes.EncodedValue{idVal}), bts
+}
+
+func insertChanges(t *testing.T, ids []int64) []*Change {
+	changes := make([]*Change, len(ids))
+	for i, id := range ids {
+		key, val := testRow(t, id, fmt.Sprintf("user%d", id))
+		changes[i] = &Change{Key: key, Value: val}
+	}
+	return changes
+}
+
+func deleteChanges(t *testing.T, ids []int64) []*Change {
+	changes := make([]*Change, len(ids))
+	for i, id := range ids {
+		key, _ := testRow(t, id, "")
+		changes[i] = &Change{Key: key}
+	}
+	return changes
+}
+
+func commit(t *testing.T, tree *Tree, height int64, changes []*Change) ktypes.Hash {
+	root, err := tree.Update(height, changes)
+	require.NoError(t, err)
+	require.NoError(t, tree.Commit(&ktypes.StateHashes{PrevApp: ktypes.Hash{byte(height)}, StateRoot: root}))
+	return root
+}
+
+func ids(from, to int64) []int64 {
+	var ids []int64
+	for id := from; id < to; id++ {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func requireProof(t *testing.T, tree *Tree, height int64, id int64, exists bool) {
+	key, val := testRow(t, id, fmt.Sprintf("user%d", id))
+	proof, err := tree.Prove(height, key)
+	require.NoError(t, err)
+	require.NoError(t, proof.Verify(proof.StateHashes.AppHash()))
+	if !exists {
+		require.Nil(t, proof.Row)
+		return
+	}
+	require.NotNil(t, proof.Row)
+	bts, err := proof.Row.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, val, bts)
+}
+
+func TestTree_OrderIndependence(t *testing.T) {
+	rows := ids(0, 200)
+	tree1 := openTestTree(t, t.TempDir())
+	root1 := commit(t, tree1, 1, insertChanges(t, rows))
+
+	shuffled := append([]int64(nil), rows...)
+	rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+	// Insert in a different order over two blocks, with extra rows that are
+	// later deleted.
+	tree2 := openTestTree(t, t.TempDir())
+	commit(t, tree2, 1, insertChanges(t, append(shuffled[:100:100], ids(1000, 1050)...)))
+	root2 := commit(t, tree2, 2, append(insertChanges(t, shuffled[100:]), deleteChanges(t, ids(1000, 1050))...))
+
+	require.Equal(t, root1, root2)
+}
+
+func TestTree_Proofs(t *testing.T) {
+	tree := openTestTree(t, t.TempDir())
+
+	commit(t, tree, 1, insertChanges(t, ids(0, 100)))
+	commit(t, tree, 2, append(insertChanges(t, ids(100, 150)), deleteChanges(t, ids(0, 50))...))
+
+	for id := range int64(200) {
+		requireProof(t, tree, 1, id, id < 100)
+		requireProof(t, tree, 2, id, id >= 50 && id < 150)
+	}
+
+	// the latest height is used if zero
+	proof, err := tree.Prove(0, ktypes.Hash{})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), proof.Height)
+
+	_, err = tree.Prove(3, ktypes.Hash{})
+	require.ErrorIs(t, err, ErrHeightNotFound)
+}
+
+func TestTree_Updates(t *testing.T) {
+	tree := openTestTree(t, t.TempDir())
+
+	commit(t, tree, 1, insertChanges(t, ids(0, 10)))
+
+	// update a row
+	key, val := testRow(t, 3, "renamed")
+	commit(t, tree, 2, []*Change{{Key: key, Value: val}})
+
+	proof, err := tree.Prove(2, key)
+	require.NoError(t, err)
+	bts, err := proof.Row.MarshalBinary()
+	require.NoError(t, err)
+	require.Equal(t, val, bts)
+
+	// deleting all rows leaves an empty tree
+	root := commit(t, tree, 3, deleteChanges(t, ids(0, 10)))
+	require.True(t, root.IsZero())
+	requireProof(t, tree, 3, 3, false)
+
+	// deleting a missing row is a no-op
+	root = commit(t, tree, 4, deleteChanges(t, []int64{3}))
+	require.True(t, root.IsZero())
+}
+
+func TestTree_ReplayAndRollback(t *testing.T) {
+	dir := t.TempDir()
+	tree := openTestTree(t, dir)
+
+	root1 := commit(t, tree, 1, insertChanges(t, ids(0, 10)))
+
+	// A rolled back update is discarded.
+	root2, err := tree.Update(2, insertChanges(t, ids(10, 20)))
+	require.NoError(t, err)
+	tree.Rollback()
+	_, err = tree.Prove(2, ktypes.Hash{})
+	require.ErrorIs(t, err, ErrHeightNotFound)
+	require.NoError(t, tree.Commit(&ktypes.StateHashes{StateRoot: root2})) // nothing staged
+
+	require.Equal(t, root2, commit(t, tree, 2, insertChanges(t, ids(10, 20))))
+
+	// Re-executing a committed height returns the committed root.
+	root, err := tree.Update(1, nil)
+	require.NoError(t, err)
+	require.Equal(t, root1, root)
+	require.NoError(t, tree.Commit(&ktypes.StateHashes{StateRoot: root}))
+	require.Equal(t, int64(2), tree.Height())
+
+	// The committed state is reloaded.
+	require.NoError(t, tree.Close())
+	tree, err = Open(dir, nil)
+	require.NoError(t, err)
+	defer tree.Close()
+	require.Equal(t, int64(2), tree.Height())
+	requireProof(t, tree, 2, 15, true)
+	require.Equal(t, root2, commit(t, tree, 3, nil))
+}
+
+func TestTree_Rebuild(t *testing.T) {
+	tree1 := openTestTree(t, t.TempDir())
+	commit(t, tree1, 1, insertChanges(t, ids(0, 100)))
+	root := commit(t, tree1, 2, append(insertChanges(t, ids(100, 150)), deleteChanges(t, ids(0, 50))...))
+
+	// A tree that is behind with other rows is rebuilt from the rows at height 2.
+	dir := t.TempDir()
+	tree2 := openTestTree(t, dir)
+	commit(t, tree2, 1, insertChanges(t, ids(500, 520)))
+	err := tree2.Rebuild(2, func(add func(*Change) error) error {
+		for _, c := range insertChanges(t, ids(50, 150)) {
+			if err := add(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), tree2.Height())
+
+	// There are no state hashes to prove against at the rebuilt height.
+	_, err = tree2.Prove(2, ktypes.Hash{})
+	require.ErrorIs(t, err, ErrHeightNotFound)
+	requireProof(t, tree2, 1, 505, true)
+
+	// The rebuilt root is reloaded and updated like the original tree.
+	require.NoError(t, tree2.Close())
+	tree2, err = Open(dir, nil)
+	require.NoError(t, err)
+	defer tree2.Close()
+	require.Equal(t, int64(2), tree2.Height())
+
+	require.Equal(t, root, tree2.root)
+	changes := append(insertChanges(t, ids(150, 160)), deleteChanges(t, ids(50, 60))...)
+	require.Equal(t, commit(t, tree1, 3, changes), commit(t, tree2, 3, changes))
+	requireProof(t, tree2, 3, 155, true)
+	requireProof(t, tree2, 3, 55, false)
+}
+
+func TestEncodeColumn(t *testing.T) {
+	intArr := ktypes.IntArrayType
+	enc, err := encodeColumn([]*int64{}, intArr)
+	require.NoError(t, err)
+	require.Equal(t, *intArr, enc.Type)
+	require.Empty(t, enc.Data)
+
+	enc, err = encodeColumn([]*int64{nil, nil}, intArr)
+	require.NoError(t, err)
+	require.Len(t, enc.Data, 2)
+	val, err := enc.Decode()
+	require.NoError(t, err)
+	require.Len(t, val, 2)
+
+	one := int64(1)
+	enc, err = encodeColumn([]*int64{&one, nil}, intArr)
+	require.NoError(t, err)
+	require.Len(t, enc.Data, 2)
+
+	enc, err = encodeColumn(nil, ktypes.TextType)
+	require.NoError(t, err)
+	require.Nil(t, enc.Data)
+}
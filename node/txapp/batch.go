@@ -0,0 +1,215 @@
+package txapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+func init() {
+	err := RegisterRoute(types.PayloadTypeBatch, &batchRoute{})
+	if err != nil {
+		panic(fmt.Sprintf("failed to register batch route: %s", err))
+	}
+}
+
+// batchPayloadTypes are the payload types that may be included in a batch.
+// Other payloads, such as validator and resolution payloads, have effects
+// outside of the DB transaction (e.g. on the validator set or the mempool) that
+// cannot be reverted if a later payload in the batch fails.
+var batchPayloadTypes = map[types.PayloadType]bool{
+	types.PayloadTypeRawStatement: true,
+	types.PayloadTypeExecute:      true,
+	types.PayloadTypeTransfer:     true,
+}
+
+// savepointer is implemented by account stores and engines that can discard
+// the in-memory state changes made after a savepoint. Without it, the account
+// updates of a batch payload that succeeded before a later one failed would
+// remain in the block's accounts hash, and the tables and actions it created
+// would remain in the engine, even though the DB changes are reverted.
+type savepointer interface {
+	Savepoint() (restore func())
+}
+
+// batchRoute executes the payloads of a types.Batch in order in one nested DB
+// transaction, so either all of them are applied or none are. The fee is
+// spent once for the sum of the payloads' prices. Execution stops at the first
+// payload that fails, and the results of the payloads up to and including it
+// are returned as the sub-results of the transaction.
+//
+// Unlike the other routes, batchRoute is a Route rather than a consensus.Route
+// since it runs the PreTx and InTx methods of other routes itself.
+type batchRoute struct{}
+
+var _ Route = (*batchRoute)(nil)
+
+// batchSubTxs decodes the payloads of a batch transaction and returns a copy of
+// the transaction for each of them, with the batch payload replaced.
+func batchSubTxs(tx *types.Transaction) ([]*types.Transaction, error) {
+	batch := &types.Batch{}
+	if err := batch.UnmarshalBinary(tx.Body.Payload); err != nil {
+		return nil, err
+	}
+	if len(batch.Payloads) == 0 {
+		return nil, errors.New("batch has no payloads")
+	}
+
+	subTxs := make([]*types.Transaction, len(batch.Payloads))
+	for i, p := range batch.Payloads {
+		if p == nil {
+			return nil, fmt.Errorf("batch payload %d is nil", i)
+		}
+		if !batchPayloadTypes[p.Type] {
+			return nil, fmt.Errorf("%w: %s", ErrBatchPayloadType, p.Type)
+		}
+
+		body := *tx.Body
+		body.PayloadType = p.Type
+		body.Payload = p.Payload
+		subTxs[i] = &types.Transaction{
+			Signature:     tx.Signature,
+			Body:          &body,
+			Serialization: tx.Serialization,
+			Sender:        tx.Sender,
+			Sponsor:       tx.Sponsor,
+		}
+	}
+
+	return subTxs, nil
+}
+
+// batchSubRoute returns the route that implements a batch payload.
+func batchSubRoute(pt types.PayloadType) (*baseRoute, error) {
+	route, ok := getRoute(pt.String()).(*baseRoute)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBatchPayloadType, pt)
+	}
+	return route, nil
+}
+
+func (d *batchRoute) Price(ctx context.Context, router *TxApp, db sql.DB, tx *types.Transaction) (*big.Int, error) {
+	subTxs, err := batchSubTxs(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	total := big.NewInt(0)
+	for _, sub := range subTxs {
+		route, err := batchSubRoute(sub.Body.PayloadType)
+		if err != nil {
+			return nil, err
+		}
+		price, err := route.Price(ctx, router, db, sub)
+		if err != nil {
+			return nil, err
+		}
+		total.Add(total, price)
+	}
+
+	return total, nil
+}
+
+func (d *batchRoute) Execute(ctx *common.TxContext, router *TxApp, db sql.DB, tx *types.Transaction) *TxResponse {
+	dbTx, err := db.BeginTx(ctx.Ctx)
+	if err != nil {
+		return txRes(nil, types.CodeUnknownError, "", err)
+	}
+
+	spend, code, err := router.checkAndSpend(ctx, tx, d, dbTx)
+	if err != nil {
+		switch code {
+		case types.CodeOk, types.CodeInsufficientBalance, types.CodeInsufficientFee:
+			logErr(router.service.Logger, dbTx.Commit(ctx.Ctx))
+		default:
+			logErr(router.service.Logger, dbTx.Rollback(ctx.Ctx))
+		}
+		return txRes(spend, code, "", err)
+	}
+	defer func() {
+		// As in baseRoute, always Commit the outer transaction for the spend.
+		err := dbTx.Commit(ctx.Ctx)
+		if err != nil {
+			router.service.Logger.Error("failed to commit DB tx for the spend", err)
+		}
+	}()
+
+	subTxs, err := batchSubTxs(tx)
+	if err != nil {
+		return txRes(spend, types.CodeEncodingError, "", err)
+	}
+
+	tx2, err := dbTx.BeginTx(ctx.Ctx)
+	if err != nil {
+		return txRes(spend, types.CodeUnknownError, "", err)
+	}
+	defer tx2.Rollback(ctx.Ctx) // no-op if Commit succeeded
+
+	var restores []func()
+	for _, s := range []any{router.Accounts, router.Engine} {
+		if sp, ok := s.(savepointer); ok {
+			restores = append(restores, sp.Savepoint())
+		}
+	}
+
+	results := make([]types.SubResult, 0, len(subTxs))
+	var logs []string
+	fail := func(code types.TxCode, err error) *TxResponse {
+		for _, restore := range restores {
+			restore()
+		}
+		res := txRes(spend, code, strings.Join(logs, "\n"), err)
+		res.SubResults = results
+		return res
+	}
+
+	for i, sub := range subTxs {
+		code, log, err := d.executeSub(ctx, router, tx2, sub)
+		results = append(results, types.SubResult{Code: uint32(code), Log: log})
+		if log != "" {
+			logs = append(logs, log)
+		}
+		if err != nil {
+			return fail(code, fmt.Errorf("batch payload %d (%s): %w", i, sub.Body.PayloadType, err))
+		}
+	}
+
+	err = tx2.Commit(ctx.Ctx)
+	if err != nil {
+		return fail(types.CodeUnknownError, err)
+	}
+
+	res := txRes(spend, types.CodeOk, strings.Join(logs, "\n"), nil)
+	res.SubResults = results
+	return res
+}
+
+// executeSub runs the PreTx and InTx methods of a batch payload's route in the
+// batch's nested DB transaction.
+func (d *batchRoute) executeSub(ctx *common.TxContext, router *TxApp, db sql.DB, sub *types.Transaction) (types.TxCode, string, error) {
+	route, err := batchSubRoute(sub.Body.PayloadType)
+	if err != nil {
+		return types.CodeInvalidTxType, "", err
+	}
+
+	svc := router.service.NamedLogger("route_" + route.Name())
+
+	code, err := route.PreTx(ctx, svc, sub)
+	if err != nil {
+		return code, "", err
+	}
+
+	return route.InTx(ctx, &common.App{
+		Service:    svc,
+		DB:         db,
+		Engine:     router.Engine,
+		Accounts:   router.Accounts,
+		Validators: router.Validators,
+	}, sub)
+}
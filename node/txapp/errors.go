@@ -7,4 +7,9 @@ var (
 	ErrCallerIsValidator  = errors.New("caller is already a validator")
 	ErrCallerNotProposer  = errors.New("caller is not the block proposer")
 	ErrTargetNotValidator = errors.New("target is not a validator")
+	ErrEvidenceProcessed  = errors.New("evidence already processed")
+	ErrEvidenceTooOld     = errors.New("evidence is older than the max evidence age")
+	ErrBatchPayloadType   = errors.New("payload type not allowed in a batch")
+	ErrStakingDisabled    = errors.New("staking is not enabled")
+	ErrValidatorNotStaked = errors.New("validator has no bonded stake of its own")
 )
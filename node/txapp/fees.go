@@ -0,0 +1,122 @@
+package txapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+// feeRecorder is implemented by account stores that keep the accounting of
+// the fees collected in each block.
+type feeRecorder interface {
+	RecordFees(ctx context.Context, tx sql.Executor, fees *types.FeeDistribution) error
+	BlockFees(ctx context.Context, tx sql.Executor, height int64) (*types.FeeDistribution, error)
+}
+
+// DistributeFees distributes the transaction fees collected in a block
+// according to the fee network parameters. The leader share is credited to the
+// block proposer, the validator share to the active validators or their
+// stakers, and the treasury share to the treasury account. Whatever is not
+// credited, including the remainders of integer division, is burned. It
+// returns nil if no fees were collected.
+func (r *TxApp) DistributeFees(ctx context.Context, db sql.DB, block *common.BlockContext, collected *big.Int) (*types.FeeDistribution, error) {
+	if collected == nil || collected.Sign() <= 0 {
+		return nil, nil
+	}
+
+	params := block.ChainContext.NetworkParameters
+	fees := types.NewFeeDistribution(block.Height)
+	fees.Collected.Set(collected)
+
+	share := func(percent int64) *big.Int {
+		amt := new(big.Int).Mul(collected, big.NewInt(percent))
+		return amt.Quo(amt, big.NewInt(100))
+	}
+
+	if leaderShare := share(params.FeeLeaderPercent); leaderShare.Sign() > 0 && block.Proposer != nil {
+		leader := &types.AccountID{
+			Identifier: block.Proposer.Bytes(),
+			KeyType:    block.Proposer.Type(),
+		}
+		if err := r.Accounts.Credit(ctx, db, leader, leaderShare); err != nil {
+			return nil, fmt.Errorf("error crediting leader fees: %w", err)
+		}
+		fees.Leader = leaderShare
+	}
+
+	if validatorShare := share(params.FeeValidatorPercent); validatorShare.Sign() > 0 {
+		var err error
+		if params.StakingEnabled() {
+			fees.Validators, err = r.distributeToStakers(ctx, db, validatorShare)
+		} else {
+			fees.Validators, err = r.distributeToValidators(ctx, db, validatorShare)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error distributing validator fees: %w", err)
+		}
+	}
+
+	if treasuryShare := share(params.FeeTreasuryPercent); treasuryShare.Sign() > 0 && params.Treasury != nil {
+		if err := r.Accounts.Credit(ctx, db, params.Treasury, treasuryShare); err != nil {
+			return nil, fmt.Errorf("error crediting treasury fees: %w", err)
+		}
+		fees.Treasury = treasuryShare
+	}
+
+	fees.Burned.Sub(collected, fees.Leader)
+	fees.Burned.Sub(fees.Burned, fees.Validators)
+	fees.Burned.Sub(fees.Burned, fees.Treasury)
+
+	if recorder, ok := r.Accounts.(feeRecorder); ok {
+		if err := recorder.RecordFees(ctx, db, fees); err != nil {
+			return nil, fmt.Errorf("error recording block fees: %w", err)
+		}
+	}
+
+	return fees, nil
+}
+
+// distributeToValidators credits an amount to the accounts of the active
+// validators in proportion to their power. Fractions are rounded down, and the
+// amount that was credited is returned.
+func (r *TxApp) distributeToValidators(ctx context.Context, db sql.DB, amount *big.Int) (*big.Int, error) {
+	distributed := new(big.Int)
+
+	validators := r.Validators.GetValidators()
+	totalPower := big.NewInt(validatorSetPower(validators))
+	if totalPower.Sign() <= 0 {
+		return distributed, nil
+	}
+
+	for _, v := range validators {
+		if v.Power <= 0 {
+			continue
+		}
+		share := new(big.Int).Mul(amount, big.NewInt(v.Power))
+		share.Quo(share, totalPower)
+		if share.Sign() == 0 {
+			continue
+		}
+		if err := r.Accounts.Credit(ctx, db, &v.AccountID, share); err != nil {
+			return nil, err
+		}
+		distributed.Add(distributed, share)
+	}
+
+	return distributed, nil
+}
+
+// BlockFees returns the accounting of the fees collected in the block at the
+// given height.
+func (r *TxApp) BlockFees(ctx context.Context, db sql.Executor, height int64) (*types.FeeDistribution, error) {
+	recorder, ok := r.Accounts.(feeRecorder)
+	if !ok {
+		return nil, errors.New("account store does not record block fees")
+	}
+	return recorder.BlockFees(ctx, db, height)
+}
@@ -0,0 +1,177 @@
+package txapp
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+	"github.com/kwilteam/kwil-db/node/voting"
+)
+
+// mockFeeAccount records credits and the fee accounting of blocks.
+type mockFeeAccount struct {
+	mockCreditAccount
+	recorded map[int64]*types.FeeDistribution
+}
+
+func (a *mockFeeAccount) RecordFees(_ context.Context, _ sql.Executor, fees *types.FeeDistribution) error {
+	a.recorded[fees.Height] = fees
+	return nil
+}
+
+func (a *mockFeeAccount) BlockFees(_ context.Context, _ sql.Executor, height int64) (*types.FeeDistribution, error) {
+	if fees, ok := a.recorded[height]; ok {
+		return fees, nil
+	}
+	return types.NewFeeDistribution(height), nil
+}
+
+func Test_DistributeFees(t *testing.T) {
+	valA := types.AccountID{Identifier: []byte("a"), KeyType: crypto.KeyTypeEd25519}
+	valB := types.AccountID{Identifier: []byte("b"), KeyType: crypto.KeyTypeEd25519}
+	treasury := &types.AccountID{Identifier: []byte("t"), KeyType: crypto.KeyTypeSecp256k1}
+	leader := privKey2.Public()
+
+	tests := []struct {
+		name      string
+		params    *types.NetworkParameters
+		collected int64
+		stakes    []*voting.Stake
+		want      *types.FeeDistribution // nil if nothing is distributed
+		credits   map[string]int64
+	}{
+		{
+			name:      "no fees collected",
+			params:    &types.NetworkParameters{FeeLeaderPercent: 50},
+			collected: 0,
+			credits:   map[string]int64{},
+		},
+		{
+			name:      "all fees burned by default",
+			params:    &types.NetworkParameters{},
+			collected: 1000,
+			want:      feeDistribution(1000, 0, 0, 0, 1000),
+			credits:   map[string]int64{},
+		},
+		{
+			name: "validators share by power",
+			params: &types.NetworkParameters{
+				FeeLeaderPercent:    20,
+				FeeValidatorPercent: 50,
+				FeeTreasuryPercent:  10,
+				Treasury:            treasury,
+			},
+			collected: 1001,
+			// 500 (of 500.5) split 1:3 between a and b
+			want: feeDistribution(1001, 200, 500, 100, 201),
+			credits: map[string]int64{
+				string(leader.Bytes()): 200,
+				"a":                    125,
+				"b":                    375,
+				"t":                    100,
+			},
+		},
+		{
+			name: "treasury share burned without a treasury",
+			params: &types.NetworkParameters{
+				FeeTreasuryPercent: 40,
+			},
+			collected: 100,
+			want:      feeDistribution(100, 0, 0, 0, 100),
+			credits:   map[string]int64{},
+		},
+		{
+			name: "validators share to stakers",
+			params: &types.NetworkParameters{
+				FeeValidatorPercent: 100,
+				StakePowerUnit:      big.NewInt(100),
+			},
+			collected: 100,
+			stakes: []*voting.Stake{
+				{Validator: valA, Delegator: valA, Amount: big.NewInt(300)},
+				{Validator: valA, Delegator: types.AccountID{Identifier: []byte("d")}, Amount: big.NewInt(100)},
+			},
+			want: feeDistribution(100, 0, 100, 0, 0),
+			credits: map[string]int64{
+				"a": 75,
+				"d": 25,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stakes := mockStakes()
+			*stakes = tt.stakes
+
+			acct := &mockFeeAccount{
+				mockCreditAccount: mockCreditAccount{credits: map[string]*big.Int{}},
+				recorded:          map[int64]*types.FeeDistribution{},
+			}
+			app := &TxApp{
+				Accounts: acct,
+				Validators: &mockValidator{
+					validators: []*types.Validator{
+						{AccountID: valA, Power: 1},
+						{AccountID: valB, Power: 3},
+					},
+				},
+			}
+			block := &common.BlockContext{
+				ChainContext: &common.ChainContext{
+					NetworkParameters: tt.params,
+				},
+				Height:   7,
+				Proposer: leader,
+			}
+
+			fees, err := app.DistributeFees(context.Background(), &mockDb{}, block, big.NewInt(tt.collected))
+			require.NoError(t, err)
+			if tt.want == nil {
+				require.Nil(t, fees)
+				require.Empty(t, acct.recorded)
+			} else {
+				tt.want.Height = block.Height
+				requireFees(t, tt.want, fees)
+
+				recorded, err := app.BlockFees(context.Background(), &mockDb{}, block.Height)
+				require.NoError(t, err)
+				requireFees(t, tt.want, recorded)
+			}
+
+			require.Len(t, acct.credits, len(tt.credits))
+			for id, amt := range tt.credits {
+				require.Zero(t, big.NewInt(amt).Cmp(acct.credits[id]), id)
+			}
+		})
+	}
+}
+
+func feeDistribution(collected, leader, validators, treasury, burned int64) *types.FeeDistribution {
+	return &types.FeeDistribution{
+		Collected:  big.NewInt(collected),
+		Leader:     big.NewInt(leader),
+		Validators: big.NewInt(validators),
+		Treasury:   big.NewInt(treasury),
+		Burned:     big.NewInt(burned),
+	}
+}
+
+// requireFees compares fee distributions by value, since zero amounts may have
+// different internal representations.
+func requireFees(t *testing.T, want, got *types.FeeDistribution) {
+	t.Helper()
+	require.NotNil(t, got)
+	require.Equal(t, want.Height, got.Height)
+	require.Equal(t, want.Collected.String(), got.Collected.String(), "collected")
+	require.Equal(t, want.Leader.String(), got.Leader.String(), "leader")
+	require.Equal(t, want.Validators.String(), got.Validators.String(), "validators")
+	require.Equal(t, want.Treasury.String(), got.Treasury.String(), "treasury")
+	require.Equal(t, want.Burned.String(), got.Burned.String(), "burned")
+}
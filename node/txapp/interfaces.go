@@ -11,12 +11,26 @@ import (
 )
 
 type Accounts interface {
-	Spend(ctx context.Context, tx sql.Executor, acctID *types.AccountID, amount *big.Int, nonce int64) error
+	// Spend spends an amount from an account and consumes the nonce. Nonces
+	// may skip ahead of, or fill gaps behind, the account's highest nonce by
+	// up to nonceWindow.
+	Spend(ctx context.Context, tx sql.Executor, acctID *types.AccountID, amount *big.Int, nonce, nonceWindow int64) error
 	Credit(ctx context.Context, tx sql.Executor, acctID *types.AccountID, amount *big.Int) error
 	Transfer(ctx context.Context, tx sql.TxMaker, from, to *types.AccountID, amount *big.Int) error
 	GetAccount(ctx context.Context, tx sql.Executor, acctID *types.AccountID) (*types.Account, error)
 	NumAccounts(ctx context.Context, tx sql.Executor) (int64, error)
 	ApplySpend(ctx context.Context, tx sql.Executor, acctID *types.AccountID, amount *big.Int, nonce int64) error
+	// NonceGaps returns the unused nonces that the account skipped over.
+	NonceGaps(ctx context.Context, tx sql.Executor, acctID *types.AccountID) ([]int64, error)
+	// Debit spends an amount from an account without consuming a nonce, as
+	// when a sponsor pays the fee of another account's transaction.
+	Debit(ctx context.Context, tx sql.Executor, acctID *types.AccountID, amount *big.Int) error
+	// SponsorLimit returns the remaining fees that an account will sponsor,
+	// or nil if it has no limit.
+	SponsorLimit(ctx context.Context, tx sql.Executor, acctID *types.AccountID) (*big.Int, error)
+	// SetSponsorLimit sets the remaining fees that an account will sponsor.
+	// A nil limit removes the limit.
+	SetSponsorLimit(ctx context.Context, tx sql.Executor, acctID *types.AccountID, limit *big.Int) error
 	Commit() error
 	Rollback()
 }
@@ -65,5 +79,17 @@ var (
 	approveResolution                = voting.ApproveResolution
 	resolutionExists                 = voting.ResolutionExists
 	resolutionByID                   = voting.GetResolutionInfo
+	getJailed                        = voting.GetJailed
+	evidenceProcessed                = voting.EvidenceProcessed
+	markEvidenceProcessed            = voting.MarkEvidenceProcessed
+	getPowerAtHeight                 = voting.GetPowerAtHeight
+	getStake                         = voting.GetStake
+	setStake                         = voting.SetStake
+	getValidatorStakes               = voting.GetValidatorStakes
+	getStakes                        = voting.GetStakes
+	addUnbonding                     = voting.AddUnbonding
+	setUnbonding                     = voting.SetUnbonding
+	getValidatorUnbonding            = voting.GetValidatorUnbonding
+	releaseUnbonding                 = voting.ReleaseUnbonding
 	// deleteResolution                 = voting.DeleteResolution
 )
@@ -14,6 +14,7 @@ import (
 	"github.com/kwilteam/kwil-db/core/log"
 	"github.com/kwilteam/kwil-db/core/types"
 	authExt "github.com/kwilteam/kwil-db/extensions/auth"
+	"github.com/kwilteam/kwil-db/node/accounts"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 	"github.com/kwilteam/kwil-db/node/voting"
 )
@@ -23,7 +24,13 @@ type mempool struct {
 	validatorMgr Validators
 
 	accounts map[string]*types.Account
-	acctsMtx sync.Mutex // protects accounts
+	// gaps are the unused nonces that each account has skipped over, loaded
+	// on demand when the network has a nonce window.
+	gaps map[string]map[int64]struct{}
+	// sponsorLimits are the pending remaining limits of sponsors, loaded on
+	// demand. A nil limit means the sponsor has no limit.
+	sponsorLimits map[string]*big.Int
+	acctsMtx      sync.Mutex // protects accounts, gaps, and sponsorLimits
 
 	nodeIdent auth.Signer
 	log       log.Logger
@@ -52,6 +59,53 @@ func (m *mempool) accountInfo(ctx context.Context, tx sql.Executor, acctID *type
 	return acct, nil
 }
 
+// nonceGaps retrieves the skipped nonces of an account from the mempool state
+// or the account store.
+func (m *mempool) nonceGaps(ctx context.Context, tx sql.Executor, acctID *types.AccountID) (map[int64]struct{}, error) {
+	id, err := acctID.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	if gaps, ok := m.gaps[string(id)]; ok {
+		return gaps, nil
+	}
+
+	nonces, err := m.accountMgr.NonceGaps(ctx, tx, acctID)
+	if err != nil {
+		return nil, err
+	}
+
+	gaps := make(map[int64]struct{}, len(nonces))
+	for _, n := range nonces {
+		gaps[n] = struct{}{}
+	}
+	m.gaps[string(id)] = gaps
+
+	return gaps, nil
+}
+
+// sponsorLimit retrieves the remaining limit of a sponsor from the mempool
+// state or the account store.
+func (m *mempool) sponsorLimit(ctx context.Context, tx sql.Executor, acctID *types.AccountID) (*big.Int, error) {
+	id, err := acctID.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	if limit, ok := m.sponsorLimits[string(id)]; ok {
+		return limit, nil
+	}
+
+	limit, err := m.accountMgr.SponsorLimit(ctx, tx, acctID)
+	if err != nil {
+		return nil, err
+	}
+	m.sponsorLimits[string(id)] = limit
+
+	return limit, nil
+}
+
 // accountInfoSafe is wraps accountInfo in a mutex lock.
 func (m *mempool) accountInfoSafe(ctx context.Context, tx sql.Executor, acctID *types.AccountID) (*types.Account, error) {
 	m.acctsMtx.Lock()
@@ -88,6 +142,18 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 			return fmt.Errorf("%w: raw statement", types.ErrDisallowedInMigration)
 		case types.PayloadTypeTransfer:
 			return fmt.Errorf("%w: transfer", types.ErrDisallowedInMigration)
+		case types.PayloadTypeStake, types.PayloadTypeDelegate, types.PayloadTypeUndelegate:
+			return fmt.Errorf("%w: %s", types.ErrDisallowedInMigration, tx.Body.PayloadType)
+		case types.PayloadTypeBatch:
+			batch := &types.Batch{}
+			if err := batch.UnmarshalBinary(tx.Body.Payload); err != nil {
+				return err
+			}
+			for _, p := range batch.Payloads {
+				if p.Type == types.PayloadTypeRawStatement || p.Type == types.PayloadTypeTransfer {
+					return fmt.Errorf("%w: %s in batch", types.ErrDisallowedInMigration, p.Type)
+				}
+			}
 		}
 	}
 
@@ -167,8 +233,33 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 		return err
 	}
 
+	// The fee of a sponsored transaction is paid from the sponsor's account,
+	// which must be funded instead of the sender's.
+	feePayer := acct
+	var sponsorLimit *big.Int
+	if tx.Sponsor != nil {
+		sponsorID, err := TxSponsorAcctID(tx)
+		if err != nil {
+			return err
+		}
+		if tx.Body.Fee.Cmp(tx.Sponsor.MaxFee) > 0 {
+			return fmt.Errorf("%w: fee %s exceeds the sponsor's max fee %s", types.ErrInsufficientFee,
+				tx.Body.Fee, tx.Sponsor.MaxFee)
+		}
+		if feePayer, err = m.accountInfo(ctx.Ctx, dbTx, sponsorID); err != nil {
+			return err
+		}
+		if sponsorLimit, err = m.sponsorLimit(ctx.Ctx, dbTx, sponsorID); err != nil {
+			return err
+		}
+		if sponsorLimit != nil && sponsorLimit.Cmp(tx.Body.Fee) < 0 {
+			return fmt.Errorf("%w: fee %s exceeds the sponsor's remaining limit %s", types.ErrInsufficientBalance,
+				tx.Body.Fee, sponsorLimit)
+		}
+	}
+
 	// reject the transactions from unfunded user accounts in gasEnabled mode
-	if !ctx.BlockContext.ChainContext.NetworkParameters.DisabledGasCosts && acct.Nonce == 0 && acct.Balance.Sign() == 0 {
+	if !ctx.BlockContext.ChainContext.NetworkParameters.DisabledGasCosts && feePayer.Nonce == 0 && feePayer.Balance.Sign() == 0 {
 		delete(m.accounts, string(tx.Sender))
 		return types.ErrInsufficientBalance
 	}
@@ -177,7 +268,20 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 	// a tx already in mempool (but not in a block), however without gas we
 	// would not want to allow that since there is no criteria for selecting the
 	// one to mine (normally higher fee).
-	if tx.Body.Nonce != uint64(acct.Nonce)+1 {
+	nonceWindow := ctx.BlockContext.ChainContext.NetworkParameters.NonceWindow
+	nonce := int64(tx.Body.Nonce)
+	ahead, err := accounts.CheckNonce(acct.Nonce, nonce, nonceWindow, func(n int64) (bool, error) {
+		gaps, err := m.nonceGaps(ctx.Ctx, dbTx, acctID)
+		if err != nil {
+			return false, err
+		}
+		_, ok := gaps[n]
+		return ok, nil
+	})
+	if err != nil {
+		if !errors.Is(err, accounts.ErrInvalidNonce) {
+			return err
+		}
 		// If the transaction with invalid nonce is a ValidatorVoteIDs transaction,
 		// then mark the events for rebroadcast before discarding the transaction
 		// as the votes for these events are not yet received by the network.
@@ -198,12 +302,23 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 				return err
 			}
 		}
-		return fmt.Errorf("%w for account %s: got %d, expected %d",
-			types.ErrInvalidNonce, hex.EncodeToString(tx.Sender),
-			tx.Body.Nonce, acct.Nonce+1)
+		if nonceWindow == 0 {
+			return fmt.Errorf("%w for account %s: got %d, expected %d",
+				types.ErrInvalidNonce, hex.EncodeToString(tx.Sender),
+				tx.Body.Nonce, acct.Nonce+1)
+		}
+		return fmt.Errorf("account %s: %w", hex.EncodeToString(tx.Sender), err)
 	}
 
 	spend := big.NewInt(0).Set(tx.Body.Fee) // NOTE: this could be the fee *limit*, but it depends on how the modules work
+	if tx.Sponsor != nil {
+		// the fee is taken from the sponsor's pending balance and limit
+		reducePending(feePayer.Balance, spend)
+		if sponsorLimit != nil {
+			sponsorLimit.Sub(sponsorLimit, spend)
+		}
+		spend.SetUint64(0)
+	}
 
 	switch tx.Body.PayloadType {
 	case types.PayloadTypeTransfer:
@@ -222,6 +337,58 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 			return types.ErrInsufficientBalance
 		}
 
+		spend.Add(spend, amt)
+
+	case types.PayloadTypeBatch:
+		batch := &types.Batch{}
+		err = batch.UnmarshalBinary(tx.Body.Payload)
+		if err != nil {
+			return err
+		}
+
+		// the transfers in a batch must be funded like individual transfers
+		sent := big.NewInt(0)
+		for _, p := range batch.Payloads {
+			if p.Type != types.PayloadTypeTransfer {
+				continue
+			}
+			transfer := &types.Transfer{}
+			if err = transfer.UnmarshalBinary(p.Payload); err != nil {
+				return err
+			}
+			if transfer.Amount.Sign() < 0 {
+				return errors.Join(types.ErrInvalidAmount, errors.New("negative transfer not permitted"))
+			}
+			sent.Add(sent, transfer.Amount)
+		}
+
+		if sent.Cmp(acct.Balance) > 0 {
+			return types.ErrInsufficientBalance
+		}
+
+		spend.Add(spend, sent)
+
+	case types.PayloadTypeStake, types.PayloadTypeDelegate:
+		// bonded stake is taken from the balance like a transfer
+		var amt *big.Int
+		if tx.Body.PayloadType == types.PayloadTypeStake {
+			stake := &types.Stake{}
+			if err = stake.UnmarshalBinary(tx.Body.Payload); err != nil {
+				return err
+			}
+			amt = stake.Amount
+		} else {
+			del := &types.Delegate{}
+			if err = del.UnmarshalBinary(tx.Body.Payload); err != nil {
+				return err
+			}
+			amt = del.Amount
+		}
+
+		if amt.Cmp(acct.Balance) > 0 {
+			return types.ErrInsufficientBalance
+		}
+
 		spend.Add(spend, amt)
 	}
 
@@ -236,18 +403,31 @@ func (m *mempool) applyTransaction(ctx *common.TxContext, tx *types.Transaction,
 	// Since we're not yet operating with different policy depending on whether
 	// gas is enabled for the chain, we're just going to reduce the account's
 	// pending balance, but no lower than zero. Tx execution will handle it.
-	if spend.Cmp(acct.Balance) > 0 {
-		acct.Balance.SetUint64(0)
-	} else {
-		acct.Balance.Sub(acct.Balance, spend)
-	}
+	reducePending(acct.Balance, spend)
 
 	// Account nonces and spends tracked by mempool should be incremented only for the
 	// valid transactions. This is to avoid the case where mempool rejects a transaction
 	// due to insufficient balance, but the account nonce and spend are already incremented.
 	// Due to which it accepts the next transaction with nonce+1, instead of nonce
 	// (but Tx with nonce is never pushed to the consensus pool).
-	acct.Nonce = int64(tx.Body.Nonce)
+	if ahead {
+		if nonce > acct.Nonce+1 {
+			gaps, err := m.nonceGaps(ctx.Ctx, dbTx, acctID)
+			if err != nil {
+				return err
+			}
+			for n := acct.Nonce + 1; n < nonce; n++ {
+				gaps[n] = struct{}{}
+			}
+		}
+		acct.Nonce = nonce
+	} else {
+		gaps, err := m.nonceGaps(ctx.Ctx, dbTx, acctID)
+		if err != nil {
+			return err
+		}
+		delete(gaps, nonce)
+	}
 
 	m.log.Debug("applied transaction to mempool state", "account", log.LazyHex(tx.Sender),
 		"nonce", acct.Nonce, "balance", acct.Balance)
@@ -262,4 +442,15 @@ func (m *mempool) reset() {
 	defer m.acctsMtx.Unlock()
 
 	m.accounts = make(map[string]*types.Account)
+	m.gaps = make(map[string]map[int64]struct{})
+	m.sponsorLimits = make(map[string]*big.Int)
+}
+
+// reducePending reduces a pending balance by an amount, but no lower than zero.
+func reducePending(balance, amount *big.Int) {
+	if amount.Cmp(balance) > 0 {
+		balance.SetUint64(0)
+	} else {
+		balance.Sub(balance, amount)
+	}
 }
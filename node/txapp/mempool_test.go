@@ -22,6 +22,7 @@ func Test_MempoolWithoutGas(t *testing.T) {
 	nodeIdent := auth.GetNodeSigner(privkey)
 	m := &mempool{
 		accounts:   make(map[string]*types.Account),
+		gaps:       make(map[string]map[int64]struct{}),
 		accountMgr: &accounts,
 		log:        log.DiscardLogger,
 		nodeIdent:  nodeIdent,
@@ -81,9 +82,68 @@ func Test_MempoolWithoutGas(t *testing.T) {
 	assert.EqualValues(t, m.accounts[string(id)].Nonce, 4)
 }
 
+func Test_MempoolNonceWindow(t *testing.T) {
+	m := &mempool{
+		accounts:   make(map[string]*types.Account),
+		gaps:       make(map[string]map[int64]struct{}),
+		accountMgr: &mockAccount{},
+		log:        log.DiscardLogger,
+	}
+
+	txCtx := &common.TxContext{
+		Ctx:    context.Background(),
+		Caller: "A",
+		BlockContext: &common.BlockContext{
+			ChainContext: &common.ChainContext{
+				NetworkParameters: &types.NetworkParameters{
+					DisabledGasCosts: true,
+					NonceWindow:      3,
+				},
+			},
+		},
+	}
+
+	db := &mockDb{}
+	rebroadcast := &mockRebroadcast{}
+
+	senderAcct, err := TxSenderAcctID(newTx(t, 1, "A"))
+	require.NoError(t, err)
+	id, err := senderAcct.MarshalBinary()
+	require.NoError(t, err)
+
+	// Skip ahead to nonce 3, leaving 1 and 2 unused
+	err = m.applyTransaction(txCtx, newTx(t, 3, "A"), db, rebroadcast)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, m.accounts[string(id)].Nonce)
+
+	// Beyond the window
+	err = m.applyTransaction(txCtx, newTx(t, 8, "A"), db, rebroadcast)
+	assert.ErrorIs(t, err, types.ErrInvalidNonce)
+
+	// Fill a gap, which does not change the highest nonce
+	err = m.applyTransaction(txCtx, newTx(t, 1, "A"), db, rebroadcast)
+	require.NoError(t, err)
+	assert.EqualValues(t, 3, m.accounts[string(id)].Nonce)
+
+	// The gap can only be filled once
+	err = m.applyTransaction(txCtx, newTx(t, 1, "A"), db, rebroadcast)
+	assert.ErrorIs(t, err, types.ErrInvalidNonce)
+
+	// Used nonces are rejected
+	err = m.applyTransaction(txCtx, newTx(t, 3, "A"), db, rebroadcast)
+	assert.ErrorIs(t, err, types.ErrInvalidNonce)
+
+	err = m.applyTransaction(txCtx, newTx(t, 2, "A"), db, rebroadcast)
+	require.NoError(t, err)
+	err = m.applyTransaction(txCtx, newTx(t, 4, "A"), db, rebroadcast)
+	require.NoError(t, err)
+	assert.EqualValues(t, 4, m.accounts[string(id)].Nonce)
+}
+
 func Test_MempoolWithGas(t *testing.T) {
 	m := &mempool{
 		accounts:   make(map[string]*types.Account),
+		gaps:       make(map[string]map[int64]struct{}),
 		accountMgr: &mockAccount{},
 		log:        log.DiscardLogger,
 	}
@@ -132,6 +192,81 @@ func Test_MempoolWithGas(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func Test_MempoolSponsored(t *testing.T) {
+	m := &mempool{
+		accounts:      make(map[string]*types.Account),
+		gaps:          make(map[string]map[int64]struct{}),
+		sponsorLimits: make(map[string]*big.Int),
+		accountMgr:    &mockAccount{},
+		log:           log.DiscardLogger,
+	}
+
+	txCtx := &common.TxContext{
+		Ctx: context.Background(),
+		BlockContext: &common.BlockContext{
+			ChainContext: &common.ChainContext{
+				NetworkParameters: &common.NetworkParameters{},
+			},
+		},
+	}
+
+	db := &mockDb{}
+	rebroadcast := &mockRebroadcast{}
+
+	sponsored := func(nonce uint64, fee, maxFee int64) *types.Transaction {
+		tx := newTx(t, nonce, "A")
+		tx.Body.Fee = big.NewInt(fee)
+		tx.Sponsor = &types.Sponsorship{
+			Sender: []byte("S"),
+			MaxFee: big.NewInt(maxFee),
+			Signature: &auth.Signature{
+				Data: []byte("signature"),
+				Type: auth.EthPersonalSignAuth,
+			},
+		}
+		return tx
+	}
+
+	sponsorAcct, err := TxSponsorAcctID(sponsored(1, 0, 0))
+	require.NoError(t, err)
+	sponsorID, err := sponsorAcct.MarshalBinary()
+	require.NoError(t, err)
+	m.accounts[string(sponsorID)] = &types.Account{
+		ID:      sponsorAcct,
+		Balance: big.NewInt(100),
+	}
+	m.sponsorLimits[string(sponsorID)] = big.NewInt(15)
+
+	// The unfunded sender cannot pay for its own transaction
+	err = m.applyTransaction(txCtx, newTx(t, 1, "A"), db, rebroadcast)
+	assert.ErrorIs(t, err, types.ErrInsufficientBalance)
+
+	// but the sponsor can pay for it
+	err = m.applyTransaction(txCtx, sponsored(1, 10, 10), db, rebroadcast)
+	require.NoError(t, err)
+	assert.EqualValues(t, 90, m.accounts[string(sponsorID)].Balance.Int64())
+	assert.EqualValues(t, 5, m.sponsorLimits[string(sponsorID)].Int64())
+
+	senderAcct, err := TxSenderAcctID(sponsored(1, 0, 0))
+	require.NoError(t, err)
+	senderID, err := senderAcct.MarshalBinary()
+	require.NoError(t, err)
+	assert.EqualValues(t, 1, m.accounts[string(senderID)].Nonce)
+	assert.EqualValues(t, 0, m.accounts[string(senderID)].Balance.Int64())
+
+	// The fee may not exceed the sponsor's max fee
+	err = m.applyTransaction(txCtx, sponsored(2, 5, 4), db, rebroadcast)
+	assert.ErrorIs(t, err, types.ErrInsufficientFee)
+
+	// nor the sponsor's remaining limit
+	err = m.applyTransaction(txCtx, sponsored(2, 10, 10), db, rebroadcast)
+	assert.ErrorIs(t, err, types.ErrInsufficientBalance)
+
+	err = m.applyTransaction(txCtx, sponsored(2, 5, 10), db, rebroadcast)
+	require.NoError(t, err)
+	assert.EqualValues(t, 0, m.sponsorLimits[string(sponsorID)].Int64())
+}
+
 func newTx(_ *testing.T, nonce uint64, sender string) *types.Transaction {
 	return &types.Transaction{
 		Signature: &auth.Signature{
@@ -3,6 +3,7 @@ package txapp
 import (
 	"bytes"
 	"context"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"math/big"
@@ -26,6 +27,7 @@ func init() {
 		RegisterRoute(types.PayloadTypeRawStatement, NewRoute(&rawStatementRoute{})),
 		RegisterRoute(types.PayloadTypeExecute, NewRoute(&executeActionRoute{})),
 		RegisterRoute(types.PayloadTypeTransfer, NewRoute(&transferRoute{})),
+		RegisterRoute(types.PayloadTypeSponsorLimit, NewRoute(&sponsorLimitRoute{})),
 		RegisterRoute(types.PayloadTypeValidatorJoin, NewRoute(&validatorJoinRoute{})),
 		RegisterRoute(types.PayloadTypeValidatorApprove, NewRoute(&validatorApproveRoute{})),
 		RegisterRoute(types.PayloadTypeValidatorRemove, NewRoute(&validatorRemoveRoute{})),
@@ -34,6 +36,7 @@ func init() {
 		RegisterRoute(types.PayloadTypeValidatorVoteBodies, NewRoute(&validatorVoteBodiesRoute{})),
 		RegisterRoute(types.PayloadTypeCreateResolution, NewRoute(&createResolutionRoute{})),
 		RegisterRoute(types.PayloadTypeApproveResolution, NewRoute(&approveResolutionRoute{})),
+		RegisterRoute(types.PayloadTypeSubmitEvidence, NewRoute(&submitEvidenceRoute{})),
 	)
 	if err != nil {
 		panic(fmt.Sprintf("failed to register routes: %s", err))
@@ -402,6 +405,54 @@ func (d *transferRoute) InTx(ctx *common.TxContext, app *common.App, tx *types.T
 	return 0, "", nil
 }
 
+// sponsorLimiter is implemented by account stores that track sponsor limits.
+type sponsorLimiter interface {
+	SetSponsorLimit(ctx context.Context, tx sql.Executor, acctID *types.AccountID, limit *big.Int) error
+}
+
+type sponsorLimitRoute struct {
+	limit *big.Int
+}
+
+var _ consensus.Route = (*sponsorLimitRoute)(nil)
+
+func (d *sponsorLimitRoute) Name() string {
+	return types.PayloadTypeSponsorLimit.String()
+}
+
+func (d *sponsorLimitRoute) Price(ctx context.Context, app *common.App, tx *types.Transaction) (*big.Int, error) {
+	return big.NewInt(210_000), nil
+}
+
+func (d *sponsorLimitRoute) PreTx(ctx *common.TxContext, svc *common.Service, tx *types.Transaction) (types.TxCode, error) {
+	sl := &types.SponsorLimit{}
+	err := sl.UnmarshalBinary(tx.Body.Payload)
+	if err != nil {
+		return types.CodeEncodingError, err
+	}
+
+	d.limit = sl.Limit
+	return 0, nil
+}
+
+func (d *sponsorLimitRoute) InTx(ctx *common.TxContext, app *common.App, tx *types.Transaction) (types.TxCode, string, error) {
+	sender, err := TxSenderAcctID(tx)
+	if err != nil {
+		return types.CodeInvalidSender, "", err
+	}
+
+	accts, ok := app.Accounts.(sponsorLimiter)
+	if !ok {
+		return types.CodeUnknownError, "", errors.New("account store does not support sponsor limits")
+	}
+
+	err = accts.SetSponsorLimit(ctx.Ctx, app.DB, sender, d.limit)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	return 0, "", nil
+}
+
 type validatorJoinRoute struct {
 	power uint64
 }
@@ -447,6 +498,15 @@ func (d *validatorJoinRoute) InTx(ctx *common.TxContext, app *common.App, tx *ty
 		return types.CodeInvalidSender, "", ErrCallerIsValidator
 	}
 
+	// a jailed validator is restored when its term ends, and may not rejoin before
+	_, jailedUntil, err := getJailed(ctx.Ctx, app.DB, tx.Sender, keyType)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	if jailedUntil > 0 {
+		return types.CodeInvalidSender, "", fmt.Errorf("validator is jailed until height %d", jailedUntil)
+	}
+
 	// we first need to ensure that this validator does not have a pending join request
 	// if it does, we should not allow it to join again
 	pending, err := getResolutionsByTypeAndProposer(ctx.Ctx, app.DB, voting.ValidatorJoinEventType, tx.Sender, keyType)
@@ -662,6 +722,179 @@ func (d *validatorRemoveRoute) InTx(ctx *common.TxContext, app *common.App, tx *
 	return 0, "", nil
 }
 
+// validatorJailer is implemented by validator stores that can jail validators.
+type validatorJailer interface {
+	JailValidator(ctx context.Context, db sql.Executor, pubKey []byte, keyType crypto.KeyType, power, until int64) error
+	ReleaseValidators(ctx context.Context, db sql.Executor, height int64) ([]*types.Validator, error)
+}
+
+// powerRecorder is implemented by validator stores that keep a history of the
+// validators' power, so that evidence is penalized by the power at the height
+// of the offense.
+type powerRecorder interface {
+	RecordPowers(ctx context.Context, db sql.Executor, height int64) error
+}
+
+// submitEvidenceRoute penalizes a validator that equivocated. The offender's
+// power is reduced by the equivocation slash percentage of its power at the
+// height of the offense, it is jailed for the equivocation jail blocks, and a
+// percentage of its account balance is burned. When staking is enabled, the
+// stake bonded to the offender is reduced by the slash percentage instead of
+// its power, and its power is derived from the remaining stake. The leader
+// cannot be removed from the validator set, so it is only slashed and burned,
+// keeping a power of at least one.
+type submitEvidenceRoute struct {
+	evidence *types.Evidence
+}
+
+var _ consensus.Route = (*submitEvidenceRoute)(nil)
+
+func (d *submitEvidenceRoute) Name() string {
+	return types.PayloadTypeSubmitEvidence.String()
+}
+
+func (d *submitEvidenceRoute) Price(ctx context.Context, app *common.App, tx *types.Transaction) (*big.Int, error) {
+	return big.NewInt(100_000), nil
+}
+
+func (d *submitEvidenceRoute) PreTx(ctx *common.TxContext, svc *common.Service, tx *types.Transaction) (types.TxCode, error) {
+	ev := &types.Evidence{}
+	err := ev.UnmarshalBinary(tx.Body.Payload)
+	if err != nil {
+		return types.CodeEncodingError, err
+	}
+
+	if err = ev.Verify(); err != nil {
+		return types.CodeInvalidSignature, fmt.Errorf("invalid evidence: %w", err)
+	}
+
+	if ev.Height() > ctx.BlockContext.Height {
+		return types.CodeUnknownError, errors.New("evidence is for a future block")
+	}
+
+	maxAge := ctx.BlockContext.ChainContext.NetworkParameters.MaxEvidenceAge
+	if maxAge > 0 && ctx.BlockContext.Height-ev.Height() > maxAge {
+		return types.CodeUnknownError, fmt.Errorf("%w: evidence height %d, max age %d", ErrEvidenceTooOld, ev.Height(), maxAge)
+	}
+
+	d.evidence = ev
+	return 0, nil
+}
+
+func (d *submitEvidenceRoute) InTx(ctx *common.TxContext, app *common.App, tx *types.Transaction) (types.TxCode, string, error) {
+	ev := d.evidence
+	id := ev.ID()
+
+	processed, err := evidenceProcessed(ctx.Ctx, app.DB, id)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	if processed {
+		return types.CodeUnknownError, "", ErrEvidenceProcessed
+	}
+
+	jailer, ok := app.Validators.(validatorJailer)
+	if !ok {
+		return types.CodeUnknownError, "", errors.New("validator store does not support jailing")
+	}
+
+	power, err := app.Validators.GetValidatorPower(ctx.Ctx, ev.Offender, ev.KeyType)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+
+	// The slash is a percentage of the power at the height of the offense,
+	// since the power may have changed since. If it was not recorded, the
+	// offense predates the history, and the current power is used.
+	offensePower, found, err := getPowerAtHeight(ctx.Ctx, app.DB, ev.Offender, ev.KeyType, ev.Height())
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	if found && offensePower <= 0 {
+		return types.CodeInvalidSender, "", fmt.Errorf("%w at height %d", ErrTargetNotValidator, ev.Height())
+	}
+
+	// a jailed validator may still be penalized for other offenses
+	jailedPower, jailedUntil, err := getJailed(ctx.Ctx, app.DB, ev.Offender, ev.KeyType)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	if jailedUntil > 0 {
+		power = jailedPower
+	} else if power <= 0 {
+		return types.CodeInvalidSender, "", ErrTargetNotValidator
+	}
+	if !found {
+		offensePower = power
+	}
+
+	params := ctx.BlockContext.ChainContext.NetworkParameters
+
+	// When staking is enabled, the power of a validator with bonded stake is
+	// derived from it, so the stake is slashed and the power follows from
+	// what remains. Otherwise, the power itself is slashed.
+	var newPower int64
+	var staked bool
+	stakeBurned := new(big.Int)
+	if params.StakingEnabled() {
+		newPower, stakeBurned, staked, err = slashStake(ctx, app, ev.Offender, ev.KeyType, ev.Height(), params.EquivocationSlashPercent)
+		if err != nil {
+			return types.CodeUnknownError, "", err
+		}
+	}
+	if !staked {
+		slashed := new(big.Int).Mul(big.NewInt(offensePower), big.NewInt(params.EquivocationSlashPercent))
+		newPower = max(power-slashed.Div(slashed, big.NewInt(100)).Int64(), 0)
+	}
+
+	proposer := ctx.BlockContext.Proposer
+	isLeader := proposer != nil && proposer.Type() == ev.KeyType && bytes.Equal(proposer.Bytes(), ev.Offender)
+	if params.EquivocationJailBlocks > 0 && !isLeader {
+		jailedUntil = max(jailedUntil, ctx.BlockContext.Height+params.EquivocationJailBlocks)
+	}
+
+	switch {
+	case isLeader:
+		err = app.Validators.SetValidatorPower(ctx.Ctx, app.DB, ev.Offender, ev.KeyType, max(newPower, 1))
+	case jailedUntil > 0:
+		err = jailer.JailValidator(ctx.Ctx, app.DB, ev.Offender, ev.KeyType, newPower, jailedUntil)
+	default:
+		err = app.Validators.SetValidatorPower(ctx.Ctx, app.DB, ev.Offender, ev.KeyType, newPower)
+	}
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+
+	burned := new(big.Int)
+	if params.EquivocationBurnPercent > 0 {
+		acctID := &types.AccountID{Identifier: ev.Offender, KeyType: ev.KeyType}
+		acct, err := app.Accounts.GetAccount(ctx.Ctx, app.DB, acctID)
+		if err != nil {
+			return types.CodeUnknownError, "", err
+		}
+		burned.Mul(acct.Balance, big.NewInt(params.EquivocationBurnPercent))
+		burned.Div(burned, big.NewInt(100))
+		if burned.Sign() > 0 {
+			// a negative credit is a debit
+			err = app.Accounts.Credit(ctx.Ctx, app.DB, acctID, new(big.Int).Neg(burned))
+			if err != nil {
+				return types.CodeUnknownError, "", err
+			}
+		}
+	}
+
+	err = markEvidenceProcessed(ctx.Ctx, app.DB, id, ev.Offender, ev.KeyType, ev.Height())
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+
+	app.Service.Logger.Warn("penalized equivocating validator", "offender", hex.EncodeToString(ev.Offender),
+		"kind", ev.Kind, "height", ev.Height(), "power", newPower, "jailedUntil", jailedUntil, "burned", burned,
+		"stakeBurned", stakeBurned)
+
+	return 0, "", nil
+}
+
 type validatorLeaveRoute struct{}
 
 var _ consensus.Route = (*validatorLeaveRoute)(nil)
@@ -2,7 +2,11 @@ package txapp
 
 import (
 	"encoding/hex"
+	"errors"
+	"maps"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/core/crypto"
@@ -10,6 +14,7 @@ import (
 	"github.com/kwilteam/kwil-db/core/log"
 	"github.com/kwilteam/kwil-db/core/types"
 	"github.com/kwilteam/kwil-db/extensions/resolutions"
+	"github.com/kwilteam/kwil-db/node/accounts"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 	"github.com/kwilteam/kwil-db/node/voting"
 
@@ -49,7 +54,27 @@ func Test_Routes(t *testing.T) {
 		ctx           *common.TxContext                   // optional, if nil, will automatically create a mock
 		from          auth.Signer                         // optional, if nil, will automatically use default validatorSigner1
 		getVoterPower getVoterPowerFunc
-		err           error // if not nil, expect this error
+		validators    *mockValidator       // optional, if nil, will use a mock with getVoterPower
+		block         *common.BlockContext // optional, if nil, will automatically create a mock
+		err           error                // if not nil, expect this error
+	}
+
+	evidence := newTestEvidence(privKey2)
+	evidenceBlock := &common.BlockContext{
+		ChainContext: &common.ChainContext{
+			NetworkParameters: &types.NetworkParameters{
+				EquivocationJailBlocks:   100,
+				EquivocationSlashPercent: 10,
+				EquivocationBurnPercent:  50,
+			},
+		},
+		Height:   20,
+		Proposer: privKey1.Public(),
+	}
+	evidenceVals := &mockValidator{
+		getVoterFn: func() (int64, error) {
+			return 55, nil
+		},
 	}
 
 	// due to the relative simplicity of routes and pricing, I have only tested a few complex ones.
@@ -221,14 +246,181 @@ func Test_Routes(t *testing.T) {
 			from: signer2,
 			err:  ErrCallerNotProposer,
 		},
+		{
+			// a validator that signed two proposals is slashed and jailed
+			name:       "submit_evidence, duplicate proposal",
+			fee:        100_000,
+			validators: evidenceVals,
+			block:      evidenceBlock,
+			fn: func(t *testing.T, callback func()) {
+				marked := 0
+				evidenceProcessed = func(_ context.Context, _ sql.Executor, id types.Hash) (bool, error) {
+					return false, nil
+				}
+				getPowerAtHeight = func(_ context.Context, _ sql.Executor, _ []byte, _ crypto.KeyType, _ int64) (int64, bool, error) {
+					return 0, false, nil // predates the history
+				}
+				getJailed = func(_ context.Context, _ sql.Executor, _ []byte, _ crypto.KeyType) (int64, int64, error) {
+					return 0, 0, nil
+				}
+				markEvidenceProcessed = func(_ context.Context, _ sql.Executor, id types.Hash, offender []byte, _ crypto.KeyType, height int64) error {
+					marked++
+					assert.Equal(t, evidence.ID(), id)
+					assert.Equal(t, int64(10), height)
+					return nil
+				}
+
+				callback()
+				assert.Equal(t, 1, marked)
+				assert.Equal(t, int64(50), evidenceVals.jailedPower) // 55 - 10% rounded down
+				assert.Equal(t, int64(120), evidenceVals.jailedUntil)
+			},
+			payload: evidence,
+			from:    signer1,
+		},
+		{
+			// the slash is a percentage of the power at the offense height
+			name:       "submit_evidence, power at offense height",
+			fee:        100_000,
+			validators: evidenceVals,
+			block:      evidenceBlock,
+			fn: func(t *testing.T, callback func()) {
+				evidenceProcessed = func(_ context.Context, _ sql.Executor, id types.Hash) (bool, error) {
+					return false, nil
+				}
+				getPowerAtHeight = func(_ context.Context, _ sql.Executor, _ []byte, _ crypto.KeyType, height int64) (int64, bool, error) {
+					assert.Equal(t, int64(10), height)
+					return 200, true, nil
+				}
+				getJailed = func(_ context.Context, _ sql.Executor, _ []byte, _ crypto.KeyType) (int64, int64, error) {
+					return 0, 0, nil
+				}
+				markEvidenceProcessed = func(_ context.Context, _ sql.Executor, _ types.Hash, _ []byte, _ crypto.KeyType, _ int64) error {
+					return nil
+				}
+
+				callback()
+				assert.Equal(t, int64(35), evidenceVals.jailedPower) // 55 - 10% of 200
+			},
+			payload: evidence,
+			from:    signer1,
+		},
+		{
+			name:  "submit_evidence, not a validator at offense height",
+			fee:   100_000,
+			block: evidenceBlock,
+			getVoterPower: func() (int64, error) {
+				return 1, nil
+			},
+			fn: func(t *testing.T, callback func()) {
+				evidenceProcessed = func(_ context.Context, _ sql.Executor, id types.Hash) (bool, error) {
+					return false, nil
+				}
+				getPowerAtHeight = func(_ context.Context, _ sql.Executor, _ []byte, _ crypto.KeyType, _ int64) (int64, bool, error) {
+					return 0, true, nil
+				}
+
+				callback()
+			},
+			payload: evidence,
+			from:    signer1,
+			err:     ErrTargetNotValidator,
+		},
+		{
+			name:  "submit_evidence, already processed",
+			fee:   100_000,
+			block: evidenceBlock,
+			getVoterPower: func() (int64, error) {
+				return 1, nil
+			},
+			fn: func(t *testing.T, callback func()) {
+				evidenceProcessed = func(_ context.Context, _ sql.Executor, id types.Hash) (bool, error) {
+					return true, nil
+				}
+
+				callback()
+			},
+			payload: evidence,
+			from:    signer1,
+			err:     ErrEvidenceProcessed,
+		},
+		{
+			name:  "submit_evidence, offender not a validator",
+			fee:   100_000,
+			block: evidenceBlock,
+			getVoterPower: func() (int64, error) {
+				return 0, nil
+			},
+			fn: func(t *testing.T, callback func()) {
+				evidenceProcessed = func(_ context.Context, _ sql.Executor, id types.Hash) (bool, error) {
+					return false, nil
+				}
+				getPowerAtHeight = func(_ context.Context, _ sql.Executor, _ []byte, _ crypto.KeyType, _ int64) (int64, bool, error) {
+					return 0, false, nil
+				}
+				getJailed = func(_ context.Context, _ sql.Executor, _ []byte, _ crypto.KeyType) (int64, int64, error) {
+					return 0, 0, nil
+				}
+
+				callback()
+			},
+			payload: evidence,
+			from:    signer1,
+			err:     ErrTargetNotValidator,
+		},
+		{
+			name: "submit_evidence, too old",
+			fee:  100_000,
+			block: &common.BlockContext{
+				ChainContext: &common.ChainContext{
+					NetworkParameters: &types.NetworkParameters{
+						EquivocationSlashPercent: 10,
+						MaxEvidenceAge:           5,
+					},
+				},
+				Height:   20, // the evidence is from height 10
+				Proposer: privKey1.Public(),
+			},
+			getVoterPower: func() (int64, error) {
+				return 1, nil
+			},
+			fn: func(t *testing.T, callback func()) {
+				callback()
+			},
+			payload: evidence,
+			from:    signer1,
+			err:     ErrEvidenceTooOld,
+		},
+		{
+			name: "batch, transfers",
+			fee:  2 * 210_000,
+			fn: func(t *testing.T, callback func()) {
+				callback()
+			},
+			payload: newTestBatch(t, newTestTransfer(1), newTestTransfer(2)),
+			from:    signer1,
+		},
+		{
+			name: "batch, disallowed payload",
+			fee:  210_000,
+			fn: func(t *testing.T, callback func()) {
+				callback()
+			},
+			payload: newTestBatch(t, newTestTransfer(1), &types.ValidatorLeave{}),
+			from:    signer1,
+			err:     ErrBatchPayloadType,
+		},
 	}
 
 	for _, tc := range testCases {
 		t.Run(tc.name, func(t *testing.T) {
 			// mock getAccount, which is func declared in interfaces.go
 			account := &mockAccount{}
-			Validators := &mockValidator{
-				getVoterFn: tc.getVoterPower,
+			Validators := tc.validators
+			if Validators == nil {
+				Validators = &mockValidator{
+					getVoterFn: tc.getVoterPower,
+				}
 			}
 
 			// build tx
@@ -277,6 +469,9 @@ func Test_Routes(t *testing.T) {
 					},
 					Proposer: privKey1.Public(),
 				}
+				if tc.block != nil {
+					tc.ctx.BlockContext = tc.block
+				}
 
 				res := app.Execute(tc.ctx, db, tx)
 				if tc.err != nil {
@@ -304,7 +499,23 @@ func (a *mockAccount) NumAccounts(ctx context.Context, tx sql.Executor) (int64,
 	return 1, nil
 }
 
-func (a *mockAccount) Spend(_ context.Context, _ sql.Executor, acctID *types.AccountID, amount *big.Int, nonce int64) error {
+func (a *mockAccount) Spend(_ context.Context, _ sql.Executor, acctID *types.AccountID, amount *big.Int, nonce, nonceWindow int64) error {
+	return nil
+}
+
+func (a *mockAccount) NonceGaps(_ context.Context, _ sql.Executor, acctID *types.AccountID) ([]int64, error) {
+	return nil, nil
+}
+
+func (a *mockAccount) Debit(_ context.Context, _ sql.Executor, acctID *types.AccountID, amount *big.Int) error {
+	return nil
+}
+
+func (a *mockAccount) SponsorLimit(_ context.Context, _ sql.Executor, acctID *types.AccountID) (*big.Int, error) {
+	return nil, nil
+}
+
+func (a *mockAccount) SetSponsorLimit(_ context.Context, _ sql.Executor, acctID *types.AccountID, limit *big.Int) error {
 	return nil
 }
 
@@ -325,12 +536,154 @@ func (a *mockAccount) Commit() error {
 
 func (a *mockAccount) Rollback() {}
 
+// mockBatchAccount fails the transfers after the first failAfter, and tracks
+// whether its updates were restored to a savepoint.
+type mockBatchAccount struct {
+	mockAccount
+	failAfter, transfers int
+	restored             bool
+}
+
+func (a *mockBatchAccount) Transfer(_ context.Context, _ sql.TxMaker, from, to *types.AccountID, amount *big.Int) error {
+	a.transfers++
+	if a.transfers > a.failAfter {
+		return accounts.ErrInsufficientFunds
+	}
+	return nil
+}
+
+func (a *mockBatchAccount) Savepoint() func() {
+	return func() { a.restored = true }
+}
+
+func Test_BatchRoute(t *testing.T) {
+	for _, failAfter := range []int{3, 1} {
+		acct := &mockBatchAccount{failAfter: failAfter}
+		app := &TxApp{
+			Accounts:   acct,
+			Validators: &mockValidator{},
+			service: &common.Service{
+				Logger: log.DiscardLogger,
+			},
+		}
+
+		tx, err := types.CreateTransaction(newTestBatch(t, newTestTransfer(1), newTestTransfer(2), newTestTransfer(3)), "chainid", 1)
+		require.NoError(t, err)
+		tx.Body.Fee = big.NewInt(3 * 210_000)
+		require.NoError(t, tx.Sign(signer1))
+
+		ctx := &common.TxContext{
+			Ctx: context.Background(),
+			BlockContext: &common.BlockContext{
+				ChainContext: &common.ChainContext{
+					NetworkParameters: &types.NetworkParameters{},
+				},
+			},
+		}
+
+		res := app.Execute(ctx, &mockTx{&mockDb{}}, tx)
+		if failAfter == 3 {
+			require.NoError(t, res.Error)
+			require.Equal(t, types.CodeOk, res.ResponseCode)
+			require.Len(t, res.SubResults, 3)
+			require.False(t, acct.restored)
+			continue
+		}
+
+		// the second transfer fails, so the third is not run and the first
+		// is reverted
+		require.ErrorIs(t, res.Error, accounts.ErrInsufficientFunds)
+		require.Equal(t, types.CodeInsufficientBalance, res.ResponseCode)
+		require.Equal(t, []types.SubResult{{Code: 0}, {Code: uint32(types.CodeInsufficientBalance)}}, res.SubResults)
+		require.True(t, acct.restored)
+		require.Equal(t, int64(3*210_000), res.Spend)
+	}
+}
+
+// mockBatchEngine records the tables created by raw statements in memory, as
+// the interpreter does.
+type mockBatchEngine struct {
+	common.Engine
+	tables map[string]bool
+}
+
+func (e *mockBatchEngine) Execute(_ *common.EngineContext, _ sql.DB, statement string, _ map[string]any, _ func(*common.Row) error) error {
+	tbl, ok := strings.CutPrefix(statement, "CREATE TABLE ")
+	if !ok {
+		return errors.New("unsupported statement")
+	}
+	e.tables[strings.Fields(tbl)[0]] = true
+	return nil
+}
+
+func (e *mockBatchEngine) Savepoint() func() {
+	tables := maps.Clone(e.tables)
+	return func() { e.tables = tables }
+}
+
+func Test_BatchRouteRevertsEngine(t *testing.T) {
+	eng := &mockBatchEngine{tables: map[string]bool{}}
+	app := &TxApp{
+		Accounts:   &mockBatchAccount{failAfter: 0},
+		Validators: &mockValidator{},
+		Engine:     eng,
+		service: &common.Service{
+			Logger: log.DiscardLogger,
+		},
+	}
+
+	// the table is created, then the transfer fails
+	create := &types.RawStatement{Statement: "CREATE TABLE t (id INT PRIMARY KEY)"}
+	tx, err := types.CreateTransaction(newTestBatch(t, create, newTestTransfer(1)), "chainid", 1)
+	require.NoError(t, err)
+	tx.Body.Fee = big.NewInt(0).Add(big.NewInt(10000000000000), big.NewInt(210_000))
+	require.NoError(t, tx.Sign(signer1))
+
+	ctx := &common.TxContext{
+		Ctx: context.Background(),
+		BlockContext: &common.BlockContext{
+			ChainContext: &common.ChainContext{
+				NetworkParameters: &types.NetworkParameters{},
+			},
+		},
+	}
+
+	res := app.Execute(ctx, &mockTx{&mockDb{}}, tx)
+	require.ErrorIs(t, res.Error, accounts.ErrInsufficientFunds)
+	require.Equal(t, []types.SubResult{{Code: 0}, {Code: uint32(types.CodeInsufficientBalance)}}, res.SubResults)
+	require.Empty(t, eng.tables)
+}
+
+func newTestTransfer(amt int64) *types.Transfer {
+	return &types.Transfer{
+		To: &types.AccountID{
+			Identifier: signer2.CompactID(),
+			KeyType:    crypto.KeyTypeSecp256k1,
+		},
+		Amount: big.NewInt(amt),
+	}
+}
+
+func newTestBatch(t *testing.T, payloads ...types.Payload) *types.Batch {
+	batch := &types.Batch{}
+	for _, p := range payloads {
+		bp, err := types.NewBatchPayload(p)
+		require.NoError(t, err)
+		batch.Payloads = append(batch.Payloads, bp)
+	}
+	return batch
+}
+
 type mockValidator struct {
 	getVoterFn getVoterPowerFunc
+	validators []*types.Validator
+
+	jailedPower, jailedUntil int64
+	setPower                 int64
 }
 
 func (v *mockValidator) GetValidators() []*types.Validator {
-	return nil
+	return v.validators
 }
 
 func (v *mockValidator) GetValidatorPower(_ context.Context, pubKey []byte, pubKeyType crypto.KeyType) (int64, error) {
@@ -338,15 +691,45 @@ func (v *mockValidator) GetValidatorPower(_ context.Context, pubKey []byte, pubK
 }
 
 func (v *mockValidator) SetValidatorPower(_ context.Context, _ sql.Executor, pubKey []byte, keyType crypto.KeyType, power int64) error {
+	v.setPower = power
+	return nil
+}
+
+func (v *mockValidator) JailValidator(_ context.Context, _ sql.Executor, pubKey []byte, keyType crypto.KeyType, power, until int64) error {
+	v.jailedPower, v.jailedUntil = power, until
 	return nil
 }
 
+func (v *mockValidator) ReleaseValidators(_ context.Context, _ sql.Executor, height int64) ([]*types.Validator, error) {
+	return nil, nil
+}
+
 func (v *mockValidator) Commit() error {
 	return nil
 }
 
 func (v *mockValidator) Rollback() {}
 
+// newTestEvidence creates evidence of two proposals signed by the key.
+func newTestEvidence(key crypto.PrivateKey) *types.Evidence {
+	stamp := time.UnixMilli(1700000000000).UTC()
+	blkA := types.NewBlock(10, types.Hash{1}, types.Hash{2}, types.Hash{3}, types.Hash{4}, stamp, nil)
+	blkB := types.NewBlock(10, types.Hash{5}, types.Hash{2}, types.Hash{3}, types.Hash{4}, stamp, nil)
+	if err := errors.Join(blkA.Sign(key), blkB.Sign(key)); err != nil {
+		panic(err)
+	}
+
+	return &types.Evidence{
+		Kind:       types.EvidenceDuplicateProposal,
+		Offender:   key.Public().Bytes(),
+		KeyType:    key.Type(),
+		HeaderA:    blkA.Header,
+		HeaderB:    blkB.Header,
+		SignatureA: blkA.Signature,
+		SignatureB: blkB.Signature,
+	}
+}
+
 func getSigner(hexPrivKey string) (crypto.PrivateKey, auth.Signer) {
 	bts, err := hex.DecodeString(hexPrivKey)
 	if err != nil {
@@ -0,0 +1,485 @@
+package txapp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/consensus"
+	"github.com/kwilteam/kwil-db/node/accounts"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+func init() {
+	err := errors.Join(
+		RegisterRoute(types.PayloadTypeStake, NewRoute(&stakeRoute{})),
+		RegisterRoute(types.PayloadTypeDelegate, NewRoute(&delegateRoute{})),
+		RegisterRoute(types.PayloadTypeUndelegate, NewRoute(&undelegateRoute{})),
+	)
+	if err != nil {
+		panic(fmt.Sprintf("failed to register staking routes: %s", err))
+	}
+}
+
+// checkStaking returns an error if the staking payloads may not be executed
+// with the current network parameters.
+func checkStaking(ctx *common.TxContext) (types.TxCode, error) {
+	params := ctx.BlockContext.ChainContext.NetworkParameters
+	if params.MigrationStatus == types.MigrationInProgress ||
+		params.MigrationStatus == types.MigrationCompleted {
+		return types.CodeNetworkInMigration, errors.New("cannot change stake during migration")
+	}
+	if !params.StakingEnabled() {
+		return types.CodeInvalidTxType, ErrStakingDisabled
+	}
+	return 0, nil
+}
+
+// isStakedValidator says if a validator is in the validator set or jailed, and
+// thus has its power derived from its stake.
+func isStakedValidator(ctx *common.TxContext, app *common.App, pubKey []byte, keyType crypto.KeyType) (bool, error) {
+	power, err := app.Validators.GetValidatorPower(ctx.Ctx, pubKey, keyType)
+	if err != nil {
+		return false, err
+	}
+	if power > 0 {
+		return true, nil
+	}
+	_, jailedUntil, err := getJailed(ctx.Ctx, app.DB, pubKey, keyType)
+	if err != nil {
+		return false, err
+	}
+	return jailedUntil > 0, nil
+}
+
+// stakePower returns the validator power for an amount of bonded stake.
+func stakePower(stake, unit *big.Int) (int64, error) {
+	power := new(big.Int).Quo(stake, unit)
+	if !power.IsInt64() {
+		return 0, fmt.Errorf("validator power for stake %s overflows", stake)
+	}
+	return power.Int64(), nil
+}
+
+// updateStakePower sets a validator's power from its total bonded stake. A
+// jailed validator is restored with the new power when it is released. As
+// with equivocation penalties, the leader keeps a power of at least one.
+func updateStakePower(ctx *common.TxContext, app *common.App, pubKey []byte, keyType crypto.KeyType) error {
+	stakes, err := getValidatorStakes(ctx.Ctx, app.DB, pubKey, keyType)
+	if err != nil {
+		return err
+	}
+	total := new(big.Int)
+	for _, s := range stakes {
+		total.Add(total, s.Amount)
+	}
+
+	params := ctx.BlockContext.ChainContext.NetworkParameters
+	power, err := stakePower(total, params.StakePowerUnit)
+	if err != nil {
+		return err
+	}
+
+	proposer := ctx.BlockContext.Proposer
+	if proposer != nil && proposer.Type() == keyType && bytes.Equal(proposer.Bytes(), pubKey) {
+		power = max(power, 1)
+	}
+
+	_, jailedUntil, err := getJailed(ctx.Ctx, app.DB, pubKey, keyType)
+	if err != nil {
+		return err
+	}
+	if jailedUntil > 0 {
+		jailer, ok := app.Validators.(validatorJailer)
+		if !ok {
+			return errors.New("validator store does not support jailing")
+		}
+		return jailer.JailValidator(ctx.Ctx, app.DB, pubKey, keyType, power, jailedUntil)
+	}
+
+	return app.Validators.SetValidatorPower(ctx.Ctx, app.DB, pubKey, keyType, power)
+}
+
+// slashStake burns a percentage of the stake bonded to a validator, by itself
+// and its delegators, and returns the validator's power from the remaining
+// stake and the amount burned. The stake that was undelegated from the
+// validator at or after the offense height is also slashed while it is
+// unbonding, so that it cannot escape the penalty by undelegating before the
+// evidence is submitted. If the validator has no bonded stake, staked is false
+// and the power is not derived from stake.
+func slashStake(ctx *common.TxContext, app *common.App, pubKey []byte, keyType crypto.KeyType, offenseHeight, percent int64) (power int64, burned *big.Int, staked bool, err error) {
+	slash := func(amount *big.Int) *big.Int {
+		cut := new(big.Int).Mul(amount, big.NewInt(percent))
+		return cut.Quo(cut, big.NewInt(100))
+	}
+	burned = new(big.Int)
+
+	unbonding, err := getValidatorUnbonding(ctx.Ctx, app.DB, pubKey, keyType, offenseHeight)
+	if err != nil {
+		return 0, nil, false, err
+	}
+	for _, u := range unbonding {
+		cut := slash(u.Amount)
+		if cut.Sign() == 0 {
+			continue
+		}
+		u.Amount = new(big.Int).Sub(u.Amount, cut)
+		if err = setUnbonding(ctx.Ctx, app.DB, u); err != nil {
+			return 0, nil, false, err
+		}
+		burned.Add(burned, cut)
+	}
+
+	stakes, err := getValidatorStakes(ctx.Ctx, app.DB, pubKey, keyType)
+	if err != nil {
+		return 0, nil, false, err
+	}
+	if len(stakes) == 0 {
+		return 0, burned, false, nil
+	}
+
+	total := new(big.Int)
+	for _, s := range stakes {
+		cut := slash(s.Amount)
+		remaining := new(big.Int).Sub(s.Amount, cut)
+		if cut.Sign() > 0 {
+			err = setStake(ctx.Ctx, app.DB, pubKey, keyType, &s.Delegator, remaining)
+			if err != nil {
+				return 0, nil, false, err
+			}
+		}
+		burned.Add(burned, cut)
+		total.Add(total, remaining)
+	}
+
+	power, err = stakePower(total, ctx.BlockContext.ChainContext.NetworkParameters.StakePowerUnit)
+	if err != nil {
+		return 0, nil, false, err
+	}
+	return power, burned, true, nil
+}
+
+// bondStake moves an amount from the delegator's account to its stake with a
+// validator, and updates the validator's power.
+func bondStake(ctx *common.TxContext, app *common.App, pubKey []byte, keyType crypto.KeyType, delegator *types.AccountID, amount *big.Int) (types.TxCode, error) {
+	// a negative credit is a debit
+	err := app.Accounts.Credit(ctx.Ctx, app.DB, delegator, new(big.Int).Neg(amount))
+	if err != nil {
+		if errors.Is(err, accounts.ErrNegativeBalance) {
+			return types.CodeInsufficientBalance, fmt.Errorf("insufficient balance to bond %s", amount)
+		}
+		return types.CodeUnknownError, err
+	}
+
+	stake, err := getStake(ctx.Ctx, app.DB, pubKey, keyType, delegator)
+	if err != nil {
+		return types.CodeUnknownError, err
+	}
+	err = setStake(ctx.Ctx, app.DB, pubKey, keyType, delegator, stake.Add(stake, amount))
+	if err != nil {
+		return types.CodeUnknownError, err
+	}
+
+	if err = updateStakePower(ctx, app, pubKey, keyType); err != nil {
+		return types.CodeUnknownError, err
+	}
+	return 0, nil
+}
+
+// stakeRoute bonds an amount from a validator's account to itself.
+type stakeRoute struct {
+	amount *big.Int
+}
+
+var _ consensus.Route = (*stakeRoute)(nil)
+
+func (d *stakeRoute) Name() string {
+	return types.PayloadTypeStake.String()
+}
+
+func (d *stakeRoute) Price(ctx context.Context, app *common.App, tx *types.Transaction) (*big.Int, error) {
+	return big.NewInt(210_000), nil
+}
+
+func (d *stakeRoute) PreTx(ctx *common.TxContext, svc *common.Service, tx *types.Transaction) (types.TxCode, error) {
+	if code, err := checkStaking(ctx); err != nil {
+		return code, err
+	}
+
+	stake := &types.Stake{}
+	if err := stake.UnmarshalBinary(tx.Body.Payload); err != nil {
+		return types.CodeEncodingError, err
+	}
+
+	d.amount = stake.Amount
+	return 0, nil
+}
+
+func (d *stakeRoute) InTx(ctx *common.TxContext, app *common.App, tx *types.Transaction) (types.TxCode, string, error) {
+	sender, err := TxSenderAcctID(tx)
+	if err != nil {
+		return types.CodeInvalidSender, "", err
+	}
+
+	// validators still join by vote, and may then bond stake
+	isVal, err := isStakedValidator(ctx, app, sender.Identifier, sender.KeyType)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	if !isVal {
+		return types.CodeInvalidSender, "", ErrCallerNotValidator
+	}
+
+	// A validator's power is replaced by its stake-derived power, so the first
+	// stake must be enough for a unit of power.
+	total := new(big.Int).Set(d.amount)
+	stakes, err := getValidatorStakes(ctx.Ctx, app.DB, sender.Identifier, sender.KeyType)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	for _, s := range stakes {
+		total.Add(total, s.Amount)
+	}
+	if total.Cmp(ctx.BlockContext.ChainContext.NetworkParameters.StakePowerUnit) < 0 {
+		return types.CodeInvalidAmount, "", fmt.Errorf("total stake %s is less than the stake power unit", total)
+	}
+
+	code, err := bondStake(ctx, app, sender.Identifier, sender.KeyType, sender, d.amount)
+	if err != nil {
+		return code, "", err
+	}
+	return 0, "", nil
+}
+
+// delegateRoute bonds an amount from the sender's account to a validator that
+// has bonded stake of its own.
+type delegateRoute struct {
+	validator []byte
+	keyType   crypto.KeyType
+	amount    *big.Int
+}
+
+var _ consensus.Route = (*delegateRoute)(nil)
+
+func (d *delegateRoute) Name() string {
+	return types.PayloadTypeDelegate.String()
+}
+
+func (d *delegateRoute) Price(ctx context.Context, app *common.App, tx *types.Transaction) (*big.Int, error) {
+	return big.NewInt(210_000), nil
+}
+
+func (d *delegateRoute) PreTx(ctx *common.TxContext, svc *common.Service, tx *types.Transaction) (types.TxCode, error) {
+	if code, err := checkStaking(ctx); err != nil {
+		return code, err
+	}
+
+	del := &types.Delegate{}
+	if err := del.UnmarshalBinary(tx.Body.Payload); err != nil {
+		return types.CodeEncodingError, err
+	}
+
+	d.validator = del.Validator
+	d.keyType = del.KeyType
+	d.amount = del.Amount
+	return 0, nil
+}
+
+func (d *delegateRoute) InTx(ctx *common.TxContext, app *common.App, tx *types.Transaction) (types.TxCode, string, error) {
+	sender, err := TxSenderAcctID(tx)
+	if err != nil {
+		return types.CodeInvalidSender, "", err
+	}
+
+	isVal, err := isStakedValidator(ctx, app, d.validator, d.keyType)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	if !isVal {
+		return types.CodeInvalidSender, "", ErrTargetNotValidator
+	}
+
+	selfStake, err := getStake(ctx.Ctx, app.DB, d.validator, d.keyType,
+		&types.AccountID{Identifier: d.validator, KeyType: d.keyType})
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	if selfStake.Sign() == 0 {
+		return types.CodeInvalidSender, "", ErrValidatorNotStaked
+	}
+
+	code, err := bondStake(ctx, app, d.validator, d.keyType, sender, d.amount)
+	if err != nil {
+		return code, "", err
+	}
+	return 0, "", nil
+}
+
+// undelegateRoute unbonds an amount of the sender's stake with a validator.
+// The amount is returned to the sender's account after the unbonding period.
+// The validator's power is reduced immediately, and a validator whose stake
+// no longer gives it any power is removed from the validator set.
+type undelegateRoute struct {
+	validator []byte
+	keyType   crypto.KeyType
+	amount    *big.Int
+}
+
+var _ consensus.Route = (*undelegateRoute)(nil)
+
+func (d *undelegateRoute) Name() string {
+	return types.PayloadTypeUndelegate.String()
+}
+
+func (d *undelegateRoute) Price(ctx context.Context, app *common.App, tx *types.Transaction) (*big.Int, error) {
+	return big.NewInt(210_000), nil
+}
+
+func (d *undelegateRoute) PreTx(ctx *common.TxContext, svc *common.Service, tx *types.Transaction) (types.TxCode, error) {
+	// Stake may be unbonded even if staking was disabled after it was bonded,
+	// so only migrations are checked.
+	params := ctx.BlockContext.ChainContext.NetworkParameters
+	if params.MigrationStatus == types.MigrationInProgress ||
+		params.MigrationStatus == types.MigrationCompleted {
+		return types.CodeNetworkInMigration, errors.New("cannot change stake during migration")
+	}
+
+	undel := &types.Undelegate{}
+	if err := undel.UnmarshalBinary(tx.Body.Payload); err != nil {
+		return types.CodeEncodingError, err
+	}
+
+	d.validator = undel.Validator
+	d.keyType = undel.KeyType
+	d.amount = undel.Amount
+	return 0, nil
+}
+
+func (d *undelegateRoute) InTx(ctx *common.TxContext, app *common.App, tx *types.Transaction) (types.TxCode, string, error) {
+	sender, err := TxSenderAcctID(tx)
+	if err != nil {
+		return types.CodeInvalidSender, "", err
+	}
+
+	stake, err := getStake(ctx.Ctx, app.DB, d.validator, d.keyType, sender)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	if stake.Cmp(d.amount) < 0 {
+		return types.CodeInvalidAmount, "", fmt.Errorf("amount %s exceeds the bonded stake of %s", d.amount, stake)
+	}
+
+	err = setStake(ctx.Ctx, app.DB, d.validator, d.keyType, sender, stake.Sub(stake, d.amount))
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+
+	params := ctx.BlockContext.ChainContext.NetworkParameters
+	releaseHeight := ctx.BlockContext.Height + params.UnbondingBlocks
+	err = addUnbonding(ctx.Ctx, app.DB, d.validator, d.keyType, sender, d.amount, ctx.BlockContext.Height, releaseHeight)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+
+	// A validator that left the validator set keeps no power from its stake.
+	// The power of a validator is also left alone if staking is disabled.
+	isVal, err := isStakedValidator(ctx, app, d.validator, d.keyType)
+	if err != nil {
+		return types.CodeUnknownError, "", err
+	}
+	if isVal && params.StakingEnabled() {
+		if err = updateStakePower(ctx, app, d.validator, d.keyType); err != nil {
+			return types.CodeUnknownError, "", err
+		}
+	}
+
+	return 0, "", nil
+}
+
+// releaseUnbonded returns the unbonding stake that is released at the block
+// height to the delegators' accounts.
+func (r *TxApp) releaseUnbonded(ctx context.Context, db sql.DB, height int64) error {
+	released, err := releaseUnbonding(ctx, db, height)
+	if err != nil {
+		return err
+	}
+	for _, u := range released {
+		if err = r.Accounts.Credit(ctx, db, &u.Delegator, u.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// distributeToStakers credits an amount to the accounts with stake bonded to
+// validators in the validator set, in proportion to their stake. Fractions are
+// rounded down, and the total that was credited is returned. Nothing is
+// distributed if there is no such stake.
+func (r *TxApp) distributeToStakers(ctx context.Context, db sql.DB, amount *big.Int) (*big.Int, error) {
+	distributed := new(big.Int)
+	if amount == nil || amount.Sign() <= 0 {
+		return distributed, nil
+	}
+
+	stakes, err := getStakes(ctx, db)
+	if err != nil {
+		return nil, err
+	}
+
+	// jailed validators and those that left earn no rewards
+	active := make(map[string]bool)
+	for _, v := range r.Validators.GetValidators() {
+		if v.Power > 0 {
+			active[v.KeyType.String()+string(v.Identifier)] = true
+		}
+	}
+
+	total := new(big.Int)
+	for _, s := range stakes {
+		if active[s.Validator.KeyType.String()+string(s.Validator.Identifier)] {
+			total.Add(total, s.Amount)
+		}
+	}
+	if total.Sign() == 0 {
+		return distributed, nil
+	}
+
+	for _, s := range stakes {
+		if !active[s.Validator.KeyType.String()+string(s.Validator.Identifier)] {
+			continue
+		}
+		share := new(big.Int).Mul(amount, s.Amount)
+		share.Quo(share, total)
+		if share.Sign() == 0 {
+			continue
+		}
+		if err = r.Accounts.Credit(ctx, db, &s.Delegator, share); err != nil {
+			return nil, err
+		}
+		distributed.Add(distributed, share)
+	}
+
+	return distributed, nil
+}
+
+// finalizeStaking returns matured unbonding stake and distributes the block
+// reward to stakers.
+func (r *TxApp) finalizeStaking(ctx context.Context, db sql.DB, block *common.BlockContext) error {
+	if err := r.releaseUnbonded(ctx, db, block.Height); err != nil {
+		return fmt.Errorf("error releasing unbonded stake: %w", err)
+	}
+
+	params := block.ChainContext.NetworkParameters
+	if !params.StakingEnabled() {
+		return nil
+	}
+	if _, err := r.distributeToStakers(ctx, db, params.StakeBlockReward); err != nil {
+		return fmt.Errorf("error distributing stake rewards: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,294 @@
+package txapp
+
+import (
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/crypto/auth"
+	"github.com/kwilteam/kwil-db/core/log"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+	"github.com/kwilteam/kwil-db/node/voting"
+)
+
+// mockStakes replaces the staking store functions with an in-memory store.
+func mockStakes() *[]*voting.Stake {
+	var stakes []*voting.Stake
+	find := func(pubKey []byte, keyType crypto.KeyType, delegator *types.AccountID) *voting.Stake {
+		for _, s := range stakes {
+			if string(s.Validator.Identifier) == string(pubKey) && s.Validator.KeyType == keyType &&
+				string(s.Delegator.Identifier) == string(delegator.Identifier) && s.Delegator.KeyType == delegator.KeyType {
+				return s
+			}
+		}
+		return nil
+	}
+
+	getStake = func(_ context.Context, _ sql.Executor, pubKey []byte, keyType crypto.KeyType, delegator *types.AccountID) (*big.Int, error) {
+		if s := find(pubKey, keyType, delegator); s != nil {
+			return new(big.Int).Set(s.Amount), nil
+		}
+		return new(big.Int), nil
+	}
+	setStake = func(_ context.Context, _ sql.Executor, pubKey []byte, keyType crypto.KeyType, delegator *types.AccountID, amount *big.Int) error {
+		if s := find(pubKey, keyType, delegator); s != nil {
+			s.Amount = amount
+			return nil
+		}
+		stakes = append(stakes, &voting.Stake{
+			Validator: types.AccountID{Identifier: pubKey, KeyType: keyType},
+			Delegator: *delegator,
+			Amount:    amount,
+		})
+		return nil
+	}
+	getValidatorStakes = func(_ context.Context, _ sql.Executor, pubKey []byte, keyType crypto.KeyType) ([]*voting.Stake, error) {
+		var res []*voting.Stake
+		for _, s := range stakes {
+			if string(s.Validator.Identifier) == string(pubKey) && s.Validator.KeyType == keyType {
+				res = append(res, s)
+			}
+		}
+		return res, nil
+	}
+	getStakes = func(_ context.Context, _ sql.Executor) ([]*voting.Stake, error) {
+		return stakes, nil
+	}
+	getJailed = func(_ context.Context, _ sql.Executor, _ []byte, _ crypto.KeyType) (int64, int64, error) {
+		return 0, 0, nil
+	}
+	mockUnbonding()
+
+	return &stakes
+}
+
+// mockUnbonding replaces the unbonding store functions with an in-memory
+// store. Entries are not accumulated.
+func mockUnbonding() *[]*voting.Unbonding {
+	var unbonding []*voting.Unbonding
+	addUnbonding = func(_ context.Context, _ sql.Executor, pubKey []byte, keyType crypto.KeyType, delegator *types.AccountID, amount *big.Int, created, height int64) error {
+		unbonding = append(unbonding, &voting.Unbonding{
+			Delegator: *delegator,
+			Height:    height,
+			Amount:    amount,
+			Validator: types.AccountID{Identifier: pubKey, KeyType: keyType},
+			Created:   created,
+		})
+		return nil
+	}
+	getValidatorUnbonding = func(_ context.Context, _ sql.Executor, pubKey []byte, keyType crypto.KeyType, since int64) ([]*voting.Unbonding, error) {
+		var res []*voting.Unbonding
+		for _, u := range unbonding {
+			if string(u.Validator.Identifier) == string(pubKey) && u.Validator.KeyType == keyType && u.Created >= since {
+				c := *u
+				res = append(res, &c)
+			}
+		}
+		return res, nil
+	}
+	setUnbonding = func(_ context.Context, _ sql.Executor, set *voting.Unbonding) error {
+		for _, u := range unbonding {
+			if string(u.Delegator.Identifier) == string(set.Delegator.Identifier) && u.Height == set.Height &&
+				string(u.Validator.Identifier) == string(set.Validator.Identifier) && u.Created == set.Created {
+				u.Amount = set.Amount
+			}
+		}
+		return nil
+	}
+
+	return &unbonding
+}
+
+// mockCreditAccount records the credits to accounts.
+type mockCreditAccount struct {
+	mockAccount
+	credits map[string]*big.Int
+}
+
+func (a *mockCreditAccount) Credit(_ context.Context, _ sql.Executor, acctID *types.AccountID, amount *big.Int) error {
+	bal, ok := a.credits[string(acctID.Identifier)]
+	if !ok {
+		bal = new(big.Int)
+		a.credits[string(acctID.Identifier)] = bal
+	}
+	bal.Add(bal, amount)
+	return nil
+}
+
+func Test_StakingRoutes(t *testing.T) {
+	mockStakes()
+
+	vals := &mockValidator{
+		getVoterFn: func() (int64, error) {
+			return 1, nil
+		},
+	}
+	acct := &mockCreditAccount{credits: map[string]*big.Int{}}
+	app := &TxApp{
+		Accounts:   acct,
+		Validators: vals,
+		service: &common.Service{
+			Logger: log.DiscardLogger,
+		},
+	}
+
+	params := &types.NetworkParameters{
+		StakePowerUnit:  big.NewInt(100),
+		UnbondingBlocks: 10,
+	}
+	execute := func(payload types.Payload, signer auth.Signer) *TxResponse {
+		tx, err := types.CreateTransaction(payload, "chainid", 1)
+		require.NoError(t, err)
+		tx.Body.Fee = big.NewInt(210_000)
+		require.NoError(t, tx.Sign(signer))
+
+		ctx := &common.TxContext{
+			Ctx: context.Background(),
+			BlockContext: &common.BlockContext{
+				ChainContext: &common.ChainContext{
+					NetworkParameters: params,
+				},
+				Height:   5,
+				Proposer: privKey2.Public(),
+			},
+		}
+		return app.Execute(ctx, &mockTx{&mockDb{}}, tx)
+	}
+
+	validator := signer1.CompactID()
+
+	// the first stake must be worth a unit of power
+	res := execute(&types.Stake{Amount: big.NewInt(50)}, signer1)
+	require.Equal(t, types.CodeInvalidAmount, res.ResponseCode)
+
+	// delegating requires the validator to have stake of its own
+	delegate := &types.Delegate{Validator: validator, KeyType: crypto.KeyTypeSecp256k1, Amount: big.NewInt(100)}
+	res = execute(delegate, signer2)
+	require.ErrorIs(t, res.Error, ErrValidatorNotStaked)
+
+	res = execute(&types.Stake{Amount: big.NewInt(250)}, signer1)
+	require.NoError(t, res.Error)
+	require.Equal(t, int64(2), vals.setPower)
+	require.Equal(t, big.NewInt(-250), acct.credits[string(validator)])
+
+	res = execute(delegate, signer2)
+	require.NoError(t, res.Error)
+	require.Equal(t, int64(3), vals.setPower)
+
+	// only the bonded amount may be undelegated
+	undelegate := &types.Undelegate{Validator: validator, KeyType: crypto.KeyTypeSecp256k1, Amount: big.NewInt(101)}
+	res = execute(undelegate, signer2)
+	require.Equal(t, types.CodeInvalidAmount, res.ResponseCode)
+
+	undelegate.Amount = big.NewInt(60)
+	res = execute(undelegate, signer2)
+	require.NoError(t, res.Error)
+	require.Equal(t, int64(2), vals.setPower) // 290 / 100
+
+	// staking payloads are rejected when staking is disabled
+	params.StakePowerUnit = nil
+	res = execute(&types.Stake{Amount: big.NewInt(250)}, signer1)
+	require.ErrorIs(t, res.Error, ErrStakingDisabled)
+}
+
+func Test_SlashStake(t *testing.T) {
+	stakes := mockStakes()
+	evidenceProcessed = func(_ context.Context, _ sql.Executor, _ types.Hash) (bool, error) {
+		return false, nil
+	}
+	getPowerAtHeight = func(_ context.Context, _ sql.Executor, _ []byte, _ crypto.KeyType, _ int64) (int64, bool, error) {
+		return 4, true, nil
+	}
+	markEvidenceProcessed = func(_ context.Context, _ sql.Executor, _ types.Hash, _ []byte, _ crypto.KeyType, _ int64) error {
+		return nil
+	}
+
+	offender := &types.AccountID{Identifier: privKey2.Public().Bytes(), KeyType: privKey2.Type()}
+	delegator := &types.AccountID{Identifier: signer1.CompactID(), KeyType: crypto.KeyTypeSecp256k1}
+	ctx := context.Background()
+	require.NoError(t, setStake(ctx, nil, offender.Identifier, offender.KeyType, offender, big.NewInt(300)))
+	require.NoError(t, setStake(ctx, nil, offender.Identifier, offender.KeyType, delegator, big.NewInt(100)))
+
+	// The offense is at height 10. Stake undelegated before it is not slashed,
+	// but stake undelegated after it is, while it is unbonding.
+	unbonding := mockUnbonding()
+	require.NoError(t, addUnbonding(ctx, nil, offender.Identifier, offender.KeyType, delegator, big.NewInt(50), 8, 108))
+	require.NoError(t, addUnbonding(ctx, nil, offender.Identifier, offender.KeyType, delegator, big.NewInt(50), 12, 112))
+
+	vals := &mockValidator{
+		getVoterFn: func() (int64, error) {
+			return 4, nil
+		},
+	}
+	app := &TxApp{
+		Accounts:   &mockAccount{},
+		Validators: vals,
+		service: &common.Service{
+			Logger: log.DiscardLogger,
+		},
+	}
+
+	tx, err := types.CreateTransaction(newTestEvidence(privKey2), "chainid", 1)
+	require.NoError(t, err)
+	tx.Body.Fee = big.NewInt(100_000)
+	require.NoError(t, tx.Sign(signer1))
+
+	txCtx := &common.TxContext{
+		Ctx: ctx,
+		BlockContext: &common.BlockContext{
+			ChainContext: &common.ChainContext{
+				NetworkParameters: &types.NetworkParameters{
+					EquivocationJailBlocks:   100,
+					EquivocationSlashPercent: 10,
+					StakePowerUnit:           big.NewInt(100),
+				},
+			},
+			Height:   20,
+			Proposer: privKey1.Public(),
+		},
+	}
+	res := app.Execute(txCtx, &mockTx{&mockDb{}}, tx)
+	require.NoError(t, res.Error)
+
+	// the offender and its delegator each lose 10% of their stake, and the
+	// offender's power is derived from the remaining 360
+	require.Equal(t, big.NewInt(270), (*stakes)[0].Amount)
+	require.Equal(t, big.NewInt(90), (*stakes)[1].Amount)
+	require.Equal(t, big.NewInt(50), (*unbonding)[0].Amount)
+	require.Equal(t, big.NewInt(45), (*unbonding)[1].Amount)
+	require.Equal(t, int64(3), vals.jailedPower)
+	require.Equal(t, int64(120), vals.jailedUntil)
+}
+
+func Test_DistributeToStakers(t *testing.T) {
+	stakes := mockStakes()
+
+	valA := types.AccountID{Identifier: []byte("a"), KeyType: crypto.KeyTypeEd25519}
+	valB := types.AccountID{Identifier: []byte("b"), KeyType: crypto.KeyTypeEd25519}
+	*stakes = []*voting.Stake{
+		{Validator: valA, Delegator: valA, Amount: big.NewInt(300)},
+		{Validator: valA, Delegator: types.AccountID{Identifier: []byte("d")}, Amount: big.NewInt(100)},
+		{Validator: valB, Delegator: valB, Amount: big.NewInt(600)},
+	}
+
+	acct := &mockCreditAccount{credits: map[string]*big.Int{}}
+	app := &TxApp{
+		Accounts: acct,
+		Validators: &mockValidator{
+			// b is jailed, so it is not in the validator set
+			validators: []*types.Validator{{AccountID: valA, Power: 4}},
+		},
+	}
+
+	distributed, err := app.distributeToStakers(context.Background(), &mockDb{}, big.NewInt(1001))
+	require.NoError(t, err)
+	require.Equal(t, big.NewInt(1000), distributed) // fractions are rounded down
+	require.Equal(t, big.NewInt(750), acct.credits["a"])
+	require.Equal(t, big.NewInt(250), acct.credits["d"])
+	require.Nil(t, acct.credits["b"])
+}
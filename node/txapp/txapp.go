@@ -11,6 +11,9 @@ import (
 	"slices"
 	"strings"
 
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/config"
 	"github.com/kwilteam/kwil-db/core/crypto"
@@ -23,8 +26,10 @@ import (
 	"github.com/kwilteam/kwil-db/extensions/resolutions"
 	"github.com/kwilteam/kwil-db/node/accounts"
 	"github.com/kwilteam/kwil-db/node/meta"
+	"github.com/kwilteam/kwil-db/node/metrics"
 	"github.com/kwilteam/kwil-db/node/types/sql"
 	"github.com/kwilteam/kwil-db/node/voting"
+	"github.com/kwilteam/kwil-db/node/wasm"
 )
 
 // TxApp is the transaction processor for the Kwil node.
@@ -65,11 +70,13 @@ func NewTxApp(ctx context.Context, db sql.Executor, engine common.Engine, signer
 
 		events: events,
 		mempool: &mempool{
-			accounts:     make(map[string]*types.Account),
-			accountMgr:   accounts,
-			validatorMgr: validators,
-			nodeIdent:    signer,
-			log:          service.Logger.New("mempool"),
+			accounts:      make(map[string]*types.Account),
+			gaps:          make(map[string]map[int64]struct{}),
+			sponsorLimits: make(map[string]*big.Int),
+			accountMgr:    accounts,
+			validatorMgr:  validators,
+			nodeIdent:     signer,
+			log:           service.Logger.New("mempool"),
 		},
 		signer:   signer,
 		resTypes: resTypes,
@@ -135,6 +142,13 @@ func (r *TxApp) GenesisInit(ctx context.Context, db sql.DB, genCfg *config.Genes
 		}
 	}
 
+	// the genesis validators' power applies from the first block
+	if recorder, ok := r.Validators.(powerRecorder); ok {
+		if err := recorder.RecordPowers(ctx, db, genCfg.InitialHeight); err != nil {
+			return fmt.Errorf("error recording validator power: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -161,11 +175,30 @@ func (r *TxApp) Execute(ctx *common.TxContext, db sql.DB, tx *types.Transaction)
 
 	r.service.Logger.Debug("executing transaction", "tx", tx)
 
+	// Make the tx span the parent of any spans created by the route, such as
+	// by the engine, and restore the caller's context when done.
+	parentCtx := ctx.Ctx
+	spanParent := parentCtx
+	if spanParent == nil {
+		spanParent = context.Background()
+	}
+	spanCtx, span := metrics.TxAppTracer.Start(spanParent, "txapp.execute",
+		trace.WithAttributes(
+			attribute.String("tx.id", ctx.TxID),
+			attribute.String("tx.payload_type", tx.Body.PayloadType.String()),
+			attribute.String("tx.caller", ctx.Caller),
+		))
+	ctx.Ctx = spanCtx
+	defer func() { ctx.Ctx = parentCtx }()
+
 	// no need to error out if we cannot track the validator join approval
 	r.trackValidatorJoinApprovals(tx)
 
 	// track event count
-	return route.Execute(ctx, r, db, tx)
+	res := route.Execute(ctx, r, db, tx)
+	span.SetAttributes(attribute.Int("tx.code", int(res.ResponseCode)))
+	metrics.EndSpan(span, res.Error)
+	return res
 }
 
 // trackValidatorJoinApprovals tracks validator join approvals from this node.
@@ -199,6 +232,22 @@ func (r *TxApp) Finalize(ctx context.Context, db sql.DB, block *common.BlockCont
 		return nil, nil, err
 	}
 
+	// restore validators whose jail term for equivocating has ended
+	if jailer, ok := r.Validators.(validatorJailer); ok {
+		released, err := jailer.ReleaseValidators(ctx, db, block.Height)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error releasing jailed validators: %w", err)
+		}
+		for _, val := range released {
+			r.service.Logger.Info("released jailed validator", "validator", hex.EncodeToString(val.Identifier), "power", val.Power)
+		}
+	}
+
+	// return unbonded stake and reward stakers
+	if err = r.finalizeStaking(ctx, db, block); err != nil {
+		return nil, nil, err
+	}
+
 	// end block hooks
 	for _, hook := range hooks.ListEndBlockHooks() {
 		err := hook.Hook(ctx, &common.App{
@@ -213,6 +262,13 @@ func (r *TxApp) Finalize(ctx context.Context, db sql.DB, block *common.BlockCont
 		}
 	}
 
+	// the validator updates in this block apply from the next one
+	if recorder, ok := r.Validators.(powerRecorder); ok {
+		if err = recorder.RecordPowers(ctx, db, block.Height+1); err != nil {
+			return nil, nil, fmt.Errorf("error recording validator power: %w", err)
+		}
+	}
+
 	return r.approvedJoins, expiredJoins, nil
 }
 
@@ -221,6 +277,11 @@ func (r *TxApp) Commit() error {
 	r.Accounts.Commit()
 	r.Validators.Commit()
 
+	// register the wasm precompiles added by resolutions in the block
+	if err := wasm.CommitRegistrations(); err != nil {
+		return err
+	}
+
 	r.mempool.reset()
 	r.approvedJoins = nil
 	return nil
@@ -229,6 +290,7 @@ func (r *TxApp) Commit() error {
 func (r *TxApp) Rollback() {
 	r.Accounts.Rollback()
 	r.Validators.Rollback()
+	wasm.RollbackRegistrations()
 
 	r.mempool.reset() // will issue recheck before next block
 	r.approvedJoins = nil
@@ -474,6 +536,9 @@ type TxResponse struct {
 
 	// Error is the error returned by the transaction, if any
 	Error error
+
+	// SubResults are the results of the payloads of a batch transaction
+	SubResults []types.SubResult
 }
 
 // txRes wraps a spend, tx code, and error into a tx response.
@@ -507,6 +572,74 @@ func (r *TxApp) Price(ctx context.Context, dbTx sql.DB, tx *types.Transaction, c
 	return route.Price(ctx, r, dbTx, tx)
 }
 
+// checkAndSpendSponsored is checkAndSpend for a transaction whose fee is paid
+// by a sponsor. The sender's nonce is consumed, but nothing is spent from the
+// sender's account. The sponsor pays at most the lesser of the transaction fee
+// and its signed maximum fee, and no more than its remaining sponsor limit.
+// Like an unsponsored transaction, if the sponsor cannot pay the full price,
+// as much as possible is spent and the transaction fails.
+func (r *TxApp) checkAndSpendSponsored(ctx *common.TxContext, tx *types.Transaction, sender *types.AccountID, amt *big.Int, nonceWindow int64, dbTx sql.DB) (*big.Int, types.TxCode, error) {
+	sponsor, err := TxSponsorAcctID(tx)
+	if err != nil {
+		return nil, types.CodeInvalidSender, err
+	}
+
+	if err = r.Accounts.Spend(ctx.Ctx, dbTx, sender, big.NewInt(0), int64(tx.Body.Nonce), nonceWindow); err != nil {
+		return nil, types.CodeUnknownError, err
+	}
+
+	// the sponsor consented to pay up to its max fee
+	consent := tx.Body.Fee
+	if tx.Sponsor.MaxFee.Cmp(consent) < 0 {
+		consent = tx.Sponsor.MaxFee
+	}
+
+	spend, code := amt, types.CodeOk
+	var spendErr error
+	if consent.Cmp(amt) < 0 {
+		spend, code = consent, types.CodeInsufficientFee
+		spendErr = fmt.Errorf("sponsored transaction does not consent to spending enough tokens. fee: %s, max sponsored fee: %s, required fee: %s",
+			tx.Body.Fee.String(), tx.Sponsor.MaxFee.String(), amt.String())
+	}
+
+	limit, err := r.Accounts.SponsorLimit(ctx.Ctx, dbTx, sponsor)
+	if err != nil {
+		return nil, types.CodeUnknownError, err
+	}
+	if limit != nil && limit.Cmp(spend) < 0 {
+		spend, code = limit, types.CodeInsufficientBalance
+		spendErr = fmt.Errorf("sponsored fee %s exceeds the sponsor's remaining limit %s", amt.String(), limit.String())
+	}
+
+	err = r.Accounts.Debit(ctx.Ctx, dbTx, sponsor, spend)
+	if errors.Is(err, accounts.ErrInsufficientFunds) {
+		// spend as much as possible
+		account, err := r.Accounts.GetAccount(ctx.Ctx, dbTx, sponsor)
+		if err != nil {
+			return nil, types.CodeUnknownError, err
+		}
+		if err = r.Accounts.Debit(ctx.Ctx, dbTx, sponsor, account.Balance); err != nil {
+			return nil, types.CodeUnknownError, err
+		}
+		spend, code = account.Balance, types.CodeInsufficientBalance
+		spendErr = fmt.Errorf("transaction tries to spend %s tokens, but sponsor has %s tokens", amt.String(), account.Balance.String())
+	} else if err != nil {
+		if errors.Is(err, accounts.ErrAccountNotFound) {
+			return nil, types.CodeInsufficientBalance, errors.New("sponsor account has zero balance")
+		}
+		return nil, types.CodeUnknownError, err
+	}
+
+	if limit != nil {
+		err = r.Accounts.SetSponsorLimit(ctx.Ctx, dbTx, sponsor, new(big.Int).Sub(limit, spend))
+		if err != nil {
+			return nil, types.CodeUnknownError, err
+		}
+	}
+
+	return spend, code, spendErr
+}
+
 // checkAndSpend checks the price of a transaction.
 // It requires a tx, so that spends can be made transactional with other database interactions.
 // it returns the price it will cost to execute the transaction.
@@ -535,6 +668,12 @@ func (r *TxApp) checkAndSpend(ctx *common.TxContext, tx *types.Transaction, pric
 		return nil, types.CodeInvalidSender, err
 	}
 
+	nonceWindow := ctx.BlockContext.ChainContext.NetworkParameters.NonceWindow
+
+	if tx.Sponsor != nil {
+		return r.checkAndSpendSponsored(ctx, tx, sender, amt, nonceWindow, dbTx)
+	}
+
 	// Get account info
 	account, err := r.Accounts.GetAccount(ctx.Ctx, dbTx, sender)
 	if err == nil {
@@ -545,7 +684,7 @@ func (r *TxApp) checkAndSpend(ctx *common.TxContext, tx *types.Transaction, pric
 	if tx.Body.Fee.Cmp(amt) < 0 {
 		// If the transaction does not consent to spending required tokens for the transaction execution,
 		// spend the approved tx fee and terminate the transaction
-		err = r.Accounts.Spend(ctx.Ctx, dbTx, sender, tx.Body.Fee, int64(tx.Body.Nonce))
+		err = r.Accounts.Spend(ctx.Ctx, dbTx, sender, tx.Body.Fee, int64(tx.Body.Nonce), nonceWindow)
 		if errors.Is(err, accounts.ErrInsufficientFunds) {
 			// spend as much as possible
 			account, err := r.Accounts.GetAccount(ctx.Ctx, dbTx, sender)
@@ -553,7 +692,7 @@ func (r *TxApp) checkAndSpend(ctx *common.TxContext, tx *types.Transaction, pric
 				return nil, types.CodeUnknownError, err
 			}
 
-			err2 := r.Accounts.Spend(ctx.Ctx, dbTx, sender, account.Balance, int64(tx.Body.Nonce))
+			err2 := r.Accounts.Spend(ctx.Ctx, dbTx, sender, account.Balance, int64(tx.Body.Nonce), nonceWindow)
 			if err2 != nil {
 				if errors.Is(err2, accounts.ErrAccountNotFound) {
 					return nil, types.CodeInsufficientBalance, errors.New("account has zero balance")
@@ -575,7 +714,7 @@ func (r *TxApp) checkAndSpend(ctx *common.TxContext, tx *types.Transaction, pric
 	}
 
 	// spend the tokens
-	err = r.Accounts.Spend(ctx.Ctx, dbTx, sender, amt, int64(tx.Body.Nonce))
+	err = r.Accounts.Spend(ctx.Ctx, dbTx, sender, amt, int64(tx.Body.Nonce), nonceWindow)
 	if errors.Is(err, accounts.ErrInsufficientFunds) {
 		// spend as much as possible
 		account, err := r.Accounts.GetAccount(ctx.Ctx, dbTx, sender)
@@ -583,7 +722,7 @@ func (r *TxApp) checkAndSpend(ctx *common.TxContext, tx *types.Transaction, pric
 			return nil, types.CodeUnknownError, err
 		}
 
-		err2 := r.Accounts.Spend(ctx.Ctx, dbTx, sender, account.Balance, int64(tx.Body.Nonce))
+		err2 := r.Accounts.Spend(ctx.Ctx, dbTx, sender, account.Balance, int64(tx.Body.Nonce), nonceWindow)
 		if err2 != nil {
 			return nil, types.CodeUnknownError, err2
 		}
@@ -689,3 +828,20 @@ func TxSenderAcctID(t *types.Transaction) (*types.AccountID, error) {
 		KeyType:    keyType,
 	}, nil
 }
+
+// TxSponsorAcctID returns the account ID of the sponsor that pays the fee of a
+// sponsored transaction.
+func TxSponsorAcctID(t *types.Transaction) (*types.AccountID, error) {
+	if t.Sponsor == nil || len(t.Sponsor.Sender) == 0 || t.Sponsor.Signature == nil {
+		return nil, errors.New("transaction sponsor is missing")
+	}
+	keyType, err := authExt.GetAuthenticatorKeyType(t.Sponsor.Signature.Type)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.AccountID{
+		Identifier: t.Sponsor.Sender,
+		KeyType:    keyType,
+	}, nil
+}
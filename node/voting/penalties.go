@@ -0,0 +1,176 @@
+package voting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+// JailValidator removes a validator from the validator set until the given
+// block height, when ReleaseValidators restores it with the given power. A
+// restore power of zero removes the validator permanently. If the validator is
+// already jailed, its restore power is replaced and its term is extended if the
+// new one ends later.
+func (v *VoteStore) JailValidator(ctx context.Context, db sql.Executor, pubKey []byte, pubKeyType crypto.KeyType, power, until int64) error {
+	if power < 0 {
+		return errors.New("cannot set a negative power")
+	}
+
+	pubkeyBts := encodePubKey(pubKey, pubKeyType)
+	uuid := types.NewUUIDV5(pubkeyBts)
+
+	if _, err := db.Execute(ctx, upsertJailed, uuid[:], pubkeyBts, power, until); err != nil {
+		return err
+	}
+
+	return v.SetValidatorPower(ctx, db, pubKey, pubKeyType, 0)
+}
+
+// ReleaseValidators restores the power of all jailed validators whose term
+// ends at or before the given height, and returns the restored validators.
+func (v *VoteStore) ReleaseValidators(ctx context.Context, db sql.Executor, height int64) ([]*types.Validator, error) {
+	res, err := db.Execute(ctx, jailedUntil, height)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Rows) == 0 {
+		return nil, nil
+	}
+
+	released := make([]*types.Validator, 0, len(res.Rows))
+	for _, row := range res.Rows {
+		if len(row) != 2 {
+			// this should never happen, just for safety
+			return nil, errors.New("invalid number of columns returned. this is an internal bug")
+		}
+
+		voterBts, ok := row[0].([]byte)
+		if !ok {
+			return nil, errors.New("invalid type for voter")
+		}
+		pubKey, keyType, err := DecodePubKey(voterBts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode pubKey from voter: %w", err)
+		}
+		power, ok := sql.Int64(row[1])
+		if !ok {
+			return nil, errors.New("invalid type for power")
+		}
+
+		if power == 0 { // slashed to nothing, not restored
+			continue
+		}
+		if err = v.SetValidatorPower(ctx, db, pubKey, keyType, power); err != nil {
+			return nil, err
+		}
+		released = append(released, &types.Validator{
+			AccountID: types.AccountID{
+				Identifier: pubKey,
+				KeyType:    keyType,
+			},
+			Power: power,
+		})
+	}
+
+	_, err = db.Execute(ctx, releaseJailed, height)
+	if err != nil {
+		return nil, err
+	}
+
+	return released, nil
+}
+
+// GetJailed gets the power that a jailed validator will be restored with, and
+// the height at which it is released. If the validator is not jailed, it
+// returns zeros.
+func GetJailed(ctx context.Context, db sql.Executor, pubKey []byte, pubKeyType crypto.KeyType) (power, until int64, err error) {
+	uuid := types.NewUUIDV5(encodePubKey(pubKey, pubKeyType))
+
+	res, err := db.Execute(ctx, getJailed, uuid[:])
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(res.Rows) == 0 {
+		return 0, 0, nil
+	}
+	if len(res.Rows[0]) != 2 {
+		// this should never happen, just for safety
+		return 0, 0, errors.New("invalid number of columns returned. this is an internal bug")
+	}
+
+	power, ok := sql.Int64(res.Rows[0][0])
+	if !ok {
+		return 0, 0, errors.New("invalid type for power")
+	}
+	until, ok = sql.Int64(res.Rows[0][1])
+	if !ok {
+		return 0, 0, errors.New("invalid type for until")
+	}
+
+	return power, until, nil
+}
+
+// EvidenceProcessed checks if the evidence of an offense has been processed.
+func EvidenceProcessed(ctx context.Context, db sql.Executor, id types.Hash) (bool, error) {
+	res, err := db.Execute(ctx, evidenceExists, id[:])
+	if err != nil {
+		return false, err
+	}
+
+	return len(res.Rows) != 0, nil
+}
+
+// MarkEvidenceProcessed records that the evidence of an offense by a validator
+// at the given height has been processed.
+func MarkEvidenceProcessed(ctx context.Context, db sql.Executor, id types.Hash, offender []byte, offenderKeyType crypto.KeyType, height int64) error {
+	_, err := db.Execute(ctx, insertEvidence, id[:], encodePubKey(offender, offenderKeyType), height)
+	return err
+}
+
+// RecordPowers records the power of the validators that were updated in the
+// current block as their power from the given height, which should be the
+// height of the next block. It must be called after all updates in the block,
+// and before Commit.
+func (v *VoteStore) RecordPowers(ctx context.Context, db sql.Executor, height int64) error {
+	v.mtx.Lock()
+	defer v.mtx.Unlock()
+
+	// sorted for deterministic execution
+	names := slices.Sorted(maps.Keys(v.valUpdates))
+	for _, name := range names {
+		_, err := db.Execute(ctx, upsertPowerHistory, []byte(name), height, v.valUpdates[name].Power)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetPowerAtHeight gets the power that a validator had at the given height. If
+// no power was recorded for the validator at or before the height, such as for
+// heights before the history was kept, found is false.
+func GetPowerAtHeight(ctx context.Context, db sql.Executor, pubKey []byte, pubKeyType crypto.KeyType, height int64) (power int64, found bool, err error) {
+	res, err := db.Execute(ctx, powerAtHeight, encodePubKey(pubKey, pubKeyType), height)
+	if err != nil {
+		return 0, false, err
+	}
+	if len(res.Rows) == 0 {
+		return 0, false, nil
+	}
+	if len(res.Rows[0]) != 1 {
+		// this should never happen, just for safety
+		return 0, false, errors.New("invalid number of columns returned. this is an internal bug")
+	}
+
+	power, ok := sql.Int64(res.Rows[0][0])
+	if !ok {
+		return 0, false, errors.New("invalid type for power")
+	}
+	return power, true, nil
+}
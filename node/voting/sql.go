@@ -32,11 +32,34 @@ votes:
 
 processed:
   - id: uuid
+
+jailed:
+  - id: uuid
+  - name: bytea
+  - power: int8
+  - until: int8
+
+evidence:
+  - id: bytea
+  - offender: bytea
+  - height: int8
+
+stakes:
+  - validator: bytea
+  - delegator: bytea
+  - amount: text
+
+unbonding:
+  - delegator: bytea
+  - height: int8
+  - amount: text
+  - validator: bytea
+  - created: int8
 */
 const (
 	votingSchemaName = `kwild_voting`
 
-	voteStoreVersion = 2
+	voteStoreVersion = 6
 
 	// tableResolutions is the sql table used to store resolutions that can be voted on.
 	// the vote_body_proposer is the BYTEA of the public key of the submitter, NOT the UUID
@@ -245,6 +268,140 @@ const (
 	dropExtraVoteID = `ALTER TABLE ` + votingSchemaName + `.resolutions DROP COLUMN extra_vote_id;`
 )
 
+// upgrades V2 -> V3
+const (
+	// tableJailed tracks validators that were removed from the validator set
+	// for equivocating, with the power to restore once they are released.
+	tableJailed = `CREATE TABLE IF NOT EXISTS ` + votingSchemaName + `.jailed (
+		id BYTEA PRIMARY KEY, -- id is the rfc4122 uuid of the voter
+		name BYTEA UNIQUE NOT NULL, -- name is the identifier of the voter
+		power INT8 NOT NULL CHECK(power >= 0), -- power is restored when the voter is released
+		until INT8 NOT NULL -- until is the block height at which the voter is released
+	);`
+
+	// tableEvidence records the equivocation evidence that has been
+	// processed, so that an offense is only penalized once.
+	tableEvidence = `CREATE TABLE IF NOT EXISTS ` + votingSchemaName + `.evidence (
+		id BYTEA PRIMARY KEY, -- id is the hash identifying the offense
+		offender BYTEA NOT NULL, -- offender is the identifier of the voter
+		height INT8 NOT NULL -- height is the block height of the offense
+	);`
+
+	// upsertJailed jails a voter, extending the term if it is already jailed.
+	upsertJailed = `INSERT INTO ` + votingSchemaName + `.jailed (id, name, power, until) VALUES ($1, $2, $3, $4)
+		ON CONFLICT(id) DO UPDATE SET power = $3, until = GREATEST(` + votingSchemaName + `.jailed.until, $4);`
+
+	// getJailed gets the restore power and release height of a jailed voter
+	getJailed = `SELECT power, until FROM ` + votingSchemaName + `.jailed WHERE id = $1;`
+
+	// jailedUntil gets the voters whose jail term ends at or before a height
+	jailedUntil = `SELECT name, power FROM ` + votingSchemaName + `.jailed WHERE until <= $1 ORDER BY id;`
+
+	// releaseJailed removes the voters whose jail term ends at or before a height
+	releaseJailed = `DELETE FROM ` + votingSchemaName + `.jailed WHERE until <= $1;`
+
+	// insertEvidence records processed evidence
+	insertEvidence = `INSERT INTO ` + votingSchemaName + `.evidence (id, offender, height) VALUES ($1, $2, $3);`
+
+	// evidenceExists checks if evidence has been processed
+	evidenceExists = `SELECT id FROM ` + votingSchemaName + `.evidence WHERE id = $1;`
+
+	// tableStakes tracks the stake bonded to validators. A validator's own
+	// stake has the validator's account as the delegator.
+	tableStakes = `CREATE TABLE IF NOT EXISTS ` + votingSchemaName + `.stakes (
+		validator BYTEA NOT NULL, -- validator is the identifier of the voter the stake is bonded to
+		delegator BYTEA NOT NULL, -- delegator is the serialized account ID that bonded the stake
+		amount TEXT NOT NULL, -- amount is the bonded stake as a decimal string
+		PRIMARY KEY (validator, delegator)
+	);`
+
+	// tableUnbonding tracks undelegated stake until it is returned.
+	tableUnbonding = `CREATE TABLE IF NOT EXISTS ` + votingSchemaName + `.unbonding (
+		delegator BYTEA NOT NULL, -- delegator is the serialized account ID the stake is returned to
+		height INT8 NOT NULL, -- height is the block height at which the stake is returned
+		amount TEXT NOT NULL, -- amount is the unbonding stake as a decimal string
+		PRIMARY KEY (delegator, height)
+	);`
+
+	// upsertStake sets the stake bonded by a delegator to a validator
+	upsertStake = `INSERT INTO ` + votingSchemaName + `.stakes (validator, delegator, amount) VALUES ($1, $2, $3)
+		ON CONFLICT(validator, delegator) DO UPDATE SET amount = $3;`
+
+	// deleteStake removes the stake bonded by a delegator to a validator
+	deleteStake = `DELETE FROM ` + votingSchemaName + `.stakes WHERE validator = $1 AND delegator = $2;`
+
+	// getStake gets the stake bonded by a delegator to a validator
+	getStake = `SELECT amount FROM ` + votingSchemaName + `.stakes WHERE validator = $1 AND delegator = $2;`
+
+	// validatorStakes gets all stake bonded to a validator
+	validatorStakes = `SELECT validator, delegator, amount FROM ` + votingSchemaName + `.stakes WHERE validator = $1 ORDER BY delegator;`
+
+	// allStakes gets all bonded stake
+	allStakes = `SELECT validator, delegator, amount FROM ` + votingSchemaName + `.stakes ORDER BY validator, delegator;`
+
+	// getUnbonding gets the stake returned to a delegator at a height
+	getUnbonding = `SELECT amount FROM ` + votingSchemaName + `.unbonding
+		WHERE delegator = $1 AND height = $2 AND validator = $3 AND created = $4;`
+
+	// upsertUnbonding sets the stake returned to a delegator at a height
+	upsertUnbonding = `INSERT INTO ` + votingSchemaName + `.unbonding (delegator, height, amount, validator, created) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT(delegator, height, validator, created) DO UPDATE SET amount = $3;`
+
+	// deleteUnbonding removes stake returned to a delegator at a height
+	deleteUnbonding = `DELETE FROM ` + votingSchemaName + `.unbonding
+		WHERE delegator = $1 AND height = $2 AND validator = $3 AND created = $4;`
+
+	// unbondingUntil gets the unbonding stake returned at or before a height
+	unbondingUntil = `SELECT delegator, height, amount, validator, created FROM ` + votingSchemaName + `.unbonding
+		WHERE height <= $1 ORDER BY height, delegator, validator, created;`
+
+	// allUnbonding gets all unbonding stake
+	allUnbonding = `SELECT delegator, height, amount, validator, created FROM ` + votingSchemaName + `.unbonding
+		ORDER BY height, delegator, validator, created;`
+
+	// validatorUnbonding gets the stake undelegated from a validator at or
+	// after a height
+	validatorUnbonding = `SELECT delegator, height, amount, validator, created FROM ` + votingSchemaName + `.unbonding
+		WHERE validator = $1 AND created >= $2 ORDER BY height, delegator, validator, created;`
+
+	// releaseUnbonding removes the unbonding stake returned at or before a height
+	releaseUnbonding = `DELETE FROM ` + votingSchemaName + `.unbonding WHERE height <= $1;`
+)
+
+// upgrades V4 -> V5
+const (
+	// tablePowerHistory records the power of each voter from every height at
+	// which it changed, so that an offense is penalized by the power the voter
+	// had when it was committed.
+	tablePowerHistory = `CREATE TABLE IF NOT EXISTS ` + votingSchemaName + `.power_history (
+		voter BYTEA NOT NULL, -- voter is the identifier of the voter
+		height INT8 NOT NULL, -- height is the first block height with the power
+		power INT8 NOT NULL CHECK(power >= 0), -- power is zero if the voter was removed
+		PRIMARY KEY (voter, height)
+	);`
+
+	// upsertPowerHistory records the power of a voter from a height
+	upsertPowerHistory = `INSERT INTO ` + votingSchemaName + `.power_history (voter, height, power) VALUES ($1, $2, $3)
+		ON CONFLICT(voter, height) DO UPDATE SET power = $3;`
+
+	// powerAtHeight gets the power of a voter at a height
+	powerAtHeight = `SELECT power FROM ` + votingSchemaName + `.power_history
+		WHERE voter = $1 AND height <= $2 ORDER BY height DESC LIMIT 1;`
+)
+
+// upgrades V5 -> V6
+const (
+	// alterUnbondingOrigin records the validator that unbonding stake was
+	// undelegated from and the height at which it was undelegated, so that it
+	// can be slashed for offenses committed while it was bonded. Stake that
+	// was unbonding before the upgrade has no validator.
+	alterUnbondingOrigin = `ALTER TABLE ` + votingSchemaName + `.unbonding
+		ADD COLUMN validator BYTEA NOT NULL DEFAULT '\x'::BYTEA, -- validator is the identifier of the voter the stake was bonded to
+		ADD COLUMN created INT8 NOT NULL DEFAULT 0, -- created is the block height at which the stake was undelegated
+		DROP CONSTRAINT unbonding_pkey,
+		ADD PRIMARY KEY (delegator, height, validator, created);`
+)
+
 // registered resolution types
 const (
 	// ummm.. import cycle issues, so moving them here from migrations pkg.
@@ -0,0 +1,310 @@
+package voting
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+// Stake is an amount bonded by a delegator to a validator. A validator's own
+// stake has the validator's account as the delegator.
+type Stake struct {
+	Validator types.AccountID
+	Delegator types.AccountID
+	Amount    *big.Int
+}
+
+// Unbonding is an amount of undelegated stake that is returned to the
+// delegator's account at a block height. Until then, it may be slashed for
+// offenses by the validator it was bonded to that were committed at or before
+// the height at which it was undelegated.
+type Unbonding struct {
+	Delegator types.AccountID
+	Height    int64
+	Amount    *big.Int
+	// Validator is the validator the stake was bonded to. It is empty for
+	// stake that was unbonding before this was recorded.
+	Validator types.AccountID
+	Created   int64 // the block height at which the stake was undelegated
+}
+
+// GetStake gets the amount a delegator has bonded to a validator. It returns
+// zero if there is no stake.
+func GetStake(ctx context.Context, db sql.Executor, pubKey []byte, pubKeyType crypto.KeyType, delegator *types.AccountID) (*big.Int, error) {
+	delegatorBts, err := delegator.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := db.Execute(ctx, getStake, encodePubKey(pubKey, pubKeyType), delegatorBts)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Rows) == 0 {
+		return new(big.Int), nil
+	}
+	if len(res.Rows[0]) != 1 {
+		// this should never happen, just for safety
+		return nil, errors.New("invalid number of columns returned. this is an internal bug")
+	}
+
+	return parseAmount(res.Rows[0][0])
+}
+
+// SetStake sets the amount a delegator has bonded to a validator. An amount
+// of zero removes the stake.
+func SetStake(ctx context.Context, db sql.Executor, pubKey []byte, pubKeyType crypto.KeyType, delegator *types.AccountID, amount *big.Int) error {
+	if amount.Sign() < 0 {
+		return errors.New("cannot set a negative stake")
+	}
+
+	delegatorBts, err := delegator.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	validator := encodePubKey(pubKey, pubKeyType)
+
+	if amount.Sign() == 0 {
+		_, err = db.Execute(ctx, deleteStake, validator, delegatorBts)
+		return err
+	}
+
+	_, err = db.Execute(ctx, upsertStake, validator, delegatorBts, amount.String())
+	return err
+}
+
+// GetValidatorStakes gets all stake bonded to a validator, ordered by
+// delegator.
+func GetValidatorStakes(ctx context.Context, db sql.Executor, pubKey []byte, pubKeyType crypto.KeyType) ([]*Stake, error) {
+	res, err := db.Execute(ctx, validatorStakes, encodePubKey(pubKey, pubKeyType))
+	if err != nil {
+		return nil, err
+	}
+	return scanStakes(res.Rows)
+}
+
+// GetStakes gets all bonded stake, ordered by validator and delegator.
+func GetStakes(ctx context.Context, db sql.Executor) ([]*Stake, error) {
+	res, err := db.Execute(ctx, allStakes)
+	if err != nil {
+		return nil, err
+	}
+	return scanStakes(res.Rows)
+}
+
+func scanStakes(rows [][]any) ([]*Stake, error) {
+	stakes := make([]*Stake, 0, len(rows))
+	for _, row := range rows {
+		if len(row) != 3 {
+			// this should never happen, just for safety
+			return nil, errors.New("invalid number of columns returned. this is an internal bug")
+		}
+
+		validatorBts, ok := row[0].([]byte)
+		if !ok {
+			return nil, errors.New("invalid type for validator")
+		}
+		pubKey, keyType, err := DecodePubKey(validatorBts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode pubKey from validator: %w", err)
+		}
+		delegator, err := parseAccountID(row[1])
+		if err != nil {
+			return nil, err
+		}
+		amount, err := parseAmount(row[2])
+		if err != nil {
+			return nil, err
+		}
+
+		stakes = append(stakes, &Stake{
+			Validator: types.AccountID{
+				Identifier: pubKey,
+				KeyType:    keyType,
+			},
+			Delegator: *delegator,
+			Amount:    amount,
+		})
+	}
+	return stakes, nil
+}
+
+// AddUnbonding adds an amount of stake undelegated from a validator at the
+// created height that is returned to the delegator at the given height.
+func AddUnbonding(ctx context.Context, db sql.Executor, pubKey []byte, pubKeyType crypto.KeyType, delegator *types.AccountID, amount *big.Int, created, height int64) error {
+	if amount.Sign() <= 0 {
+		return errors.New("unbonding amount must be positive")
+	}
+
+	delegatorBts, err := delegator.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	validator := encodePubKey(pubKey, pubKeyType)
+
+	total := new(big.Int).Set(amount)
+	res, err := db.Execute(ctx, getUnbonding, delegatorBts, height, validator, created)
+	if err != nil {
+		return err
+	}
+	if len(res.Rows) != 0 {
+		if len(res.Rows[0]) != 1 {
+			// this should never happen, just for safety
+			return errors.New("invalid number of columns returned. this is an internal bug")
+		}
+		pending, err := parseAmount(res.Rows[0][0])
+		if err != nil {
+			return err
+		}
+		total.Add(total, pending)
+	}
+
+	_, err = db.Execute(ctx, upsertUnbonding, delegatorBts, height, total.String(), validator, created)
+	return err
+}
+
+// SetUnbonding sets the amount of an unbonding entry, such as after it is
+// slashed. An amount of zero removes it.
+func SetUnbonding(ctx context.Context, db sql.Executor, u *Unbonding) error {
+	if u.Amount.Sign() < 0 {
+		return errors.New("cannot set a negative unbonding amount")
+	}
+
+	delegatorBts, err := u.Delegator.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	validator := []byte{} // stake that was unbonding before the validator was recorded
+	if len(u.Validator.Identifier) != 0 {
+		validator = encodePubKey(u.Validator.Identifier, u.Validator.KeyType)
+	}
+
+	if u.Amount.Sign() == 0 {
+		_, err = db.Execute(ctx, deleteUnbonding, delegatorBts, u.Height, validator, u.Created)
+		return err
+	}
+
+	_, err = db.Execute(ctx, upsertUnbonding, delegatorBts, u.Height, u.Amount.String(), validator, u.Created)
+	return err
+}
+
+// GetValidatorUnbonding gets the unbonding stake that was undelegated from a
+// validator at or after the given height.
+func GetValidatorUnbonding(ctx context.Context, db sql.Executor, pubKey []byte, pubKeyType crypto.KeyType, since int64) ([]*Unbonding, error) {
+	res, err := db.Execute(ctx, validatorUnbonding, encodePubKey(pubKey, pubKeyType), since)
+	if err != nil {
+		return nil, err
+	}
+	return scanUnbonding(res.Rows)
+}
+
+// ReleaseUnbonding removes and returns the unbonding stake that is returned
+// at or before the given height. The caller credits the delegators.
+func ReleaseUnbonding(ctx context.Context, db sql.Executor, height int64) ([]*Unbonding, error) {
+	res, err := db.Execute(ctx, unbondingUntil, height)
+	if err != nil {
+		return nil, err
+	}
+	if len(res.Rows) == 0 {
+		return nil, nil
+	}
+
+	released, err := scanUnbonding(res.Rows)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = db.Execute(ctx, releaseUnbonding, height)
+	if err != nil {
+		return nil, err
+	}
+
+	return released, nil
+}
+
+// GetUnbonding gets all unbonding stake, ordered by the height at which it is
+// returned.
+func GetUnbonding(ctx context.Context, db sql.Executor) ([]*Unbonding, error) {
+	res, err := db.Execute(ctx, allUnbonding)
+	if err != nil {
+		return nil, err
+	}
+	return scanUnbonding(res.Rows)
+}
+
+func scanUnbonding(rows [][]any) ([]*Unbonding, error) {
+	unbonding := make([]*Unbonding, 0, len(rows))
+	for _, row := range rows {
+		if len(row) != 5 {
+			// this should never happen, just for safety
+			return nil, errors.New("invalid number of columns returned. this is an internal bug")
+		}
+
+		delegator, err := parseAccountID(row[0])
+		if err != nil {
+			return nil, err
+		}
+		height, ok := sql.Int64(row[1])
+		if !ok {
+			return nil, errors.New("invalid type for height")
+		}
+		amount, err := parseAmount(row[2])
+		if err != nil {
+			return nil, err
+		}
+		validatorBts, ok := row[3].([]byte)
+		if !ok {
+			return nil, errors.New("invalid type for validator")
+		}
+		var validator types.AccountID
+		if len(validatorBts) != 0 {
+			pubKey, keyType, err := DecodePubKey(validatorBts)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decode pubKey from validator: %w", err)
+			}
+			validator = types.AccountID{Identifier: pubKey, KeyType: keyType}
+		}
+		created, ok := sql.Int64(row[4])
+		if !ok {
+			return nil, errors.New("invalid type for created height")
+		}
+
+		unbonding = append(unbonding, &Unbonding{
+			Delegator: *delegator,
+			Height:    height,
+			Amount:    amount,
+			Validator: validator,
+			Created:   created,
+		})
+	}
+	return unbonding, nil
+}
+
+func parseAccountID(v any) (*types.AccountID, error) {
+	bts, ok := v.([]byte)
+	if !ok {
+		return nil, errors.New("invalid type for delegator")
+	}
+	acctID := &types.AccountID{}
+	if err := acctID.UnmarshalBinary(bts); err != nil {
+		return nil, fmt.Errorf("failed to decode delegator: %w", err)
+	}
+	return acctID, nil
+}
+
+func parseAmount(v any) (*big.Int, error) {
+	str, ok := v.(string)
+	if !ok {
+		return nil, errors.New("invalid type for amount")
+	}
+	amount, ok := new(big.Int).SetString(str, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount %q", str)
+	}
+	return amount, nil
+}
@@ -333,6 +333,170 @@ func Test_Voting(t *testing.T) {
 
 			},
 		},
+		{
+			name: "jailing and evidence",
+			validators: map[string]validator{
+				"a": {100, crypto.KeyTypeEd25519},
+				"b": {100, crypto.KeyTypeEd25519},
+			},
+			fn: func(t *testing.T, db sql.DB, v *VoteStore) {
+				ctx := context.Background()
+				require.NoError(t, v.Commit())
+
+				err := v.JailValidator(ctx, db, []byte("a"), crypto.KeyTypeEd25519, 90, 10)
+				require.NoError(t, err)
+				require.NoError(t, v.Commit())
+
+				power, err := v.GetValidatorPower(ctx, []byte("a"), crypto.KeyTypeEd25519)
+				require.NoError(t, err)
+				require.Equal(t, int64(0), power)
+
+				power, until, err := GetJailed(ctx, db, []byte("a"), crypto.KeyTypeEd25519)
+				require.NoError(t, err)
+				require.Equal(t, int64(90), power)
+				require.Equal(t, int64(10), until)
+
+				// jailing again does not shorten the term
+				err = v.JailValidator(ctx, db, []byte("a"), crypto.KeyTypeEd25519, 80, 5)
+				require.NoError(t, err)
+				power, until, err = GetJailed(ctx, db, []byte("a"), crypto.KeyTypeEd25519)
+				require.NoError(t, err)
+				require.Equal(t, int64(80), power)
+				require.Equal(t, int64(10), until)
+
+				released, err := v.ReleaseValidators(ctx, db, 9)
+				require.NoError(t, err)
+				require.Empty(t, released)
+
+				released, err = v.ReleaseValidators(ctx, db, 10)
+				require.NoError(t, err)
+				require.Len(t, released, 1)
+				require.NoError(t, v.Commit())
+
+				power, err = v.GetValidatorPower(ctx, []byte("a"), crypto.KeyTypeEd25519)
+				require.NoError(t, err)
+				require.Equal(t, int64(80), power)
+
+				_, until, err = GetJailed(ctx, db, []byte("a"), crypto.KeyTypeEd25519)
+				require.NoError(t, err)
+				require.Zero(t, until)
+
+				id := types.Hash{1, 2, 3}
+				processed, err := EvidenceProcessed(ctx, db, id)
+				require.NoError(t, err)
+				require.False(t, processed)
+
+				err = MarkEvidenceProcessed(ctx, db, id, []byte("b"), crypto.KeyTypeEd25519, 4)
+				require.NoError(t, err)
+
+				processed, err = EvidenceProcessed(ctx, db, id)
+				require.NoError(t, err)
+				require.True(t, processed)
+			},
+		},
+		{
+			name: "power history",
+			validators: map[string]validator{
+				"a": {100, crypto.KeyTypeEd25519},
+			},
+			fn: func(t *testing.T, db sql.DB, v *VoteStore) {
+				ctx := context.Background()
+				require.NoError(t, v.RecordPowers(ctx, db, 1))
+				require.NoError(t, v.Commit())
+
+				_, found, err := GetPowerAtHeight(ctx, db, []byte("a"), crypto.KeyTypeEd25519, 0)
+				require.NoError(t, err)
+				require.False(t, found)
+
+				err = v.SetValidatorPower(ctx, db, []byte("a"), crypto.KeyTypeEd25519, 60)
+				require.NoError(t, err)
+				require.NoError(t, v.RecordPowers(ctx, db, 5))
+				require.NoError(t, v.Commit())
+
+				for height, want := range map[int64]int64{1: 100, 4: 100, 5: 60, 9: 60} {
+					power, found, err := GetPowerAtHeight(ctx, db, []byte("a"), crypto.KeyTypeEd25519, height)
+					require.NoError(t, err)
+					require.True(t, found)
+					require.Equal(t, want, power, height)
+				}
+			},
+		},
+		{
+			name: "stakes and unbonding",
+			validators: map[string]validator{
+				"a": {100, crypto.KeyTypeEd25519},
+			},
+			fn: func(t *testing.T, db sql.DB, v *VoteStore) {
+				ctx := context.Background()
+
+				self := &types.AccountID{Identifier: []byte("a"), KeyType: crypto.KeyTypeEd25519}
+				del := &types.AccountID{Identifier: []byte("d"), KeyType: crypto.KeyTypeSecp256k1}
+
+				amt, err := GetStake(ctx, db, []byte("a"), crypto.KeyTypeEd25519, del)
+				require.NoError(t, err)
+				require.Zero(t, amt.Sign())
+
+				err = SetStake(ctx, db, []byte("a"), crypto.KeyTypeEd25519, self, big.NewInt(300))
+				require.NoError(t, err)
+				err = SetStake(ctx, db, []byte("a"), crypto.KeyTypeEd25519, del, big.NewInt(100))
+				require.NoError(t, err)
+
+				stakes, err := GetValidatorStakes(ctx, db, []byte("a"), crypto.KeyTypeEd25519)
+				require.NoError(t, err)
+				require.Len(t, stakes, 2)
+
+				amt, err = GetStake(ctx, db, []byte("a"), crypto.KeyTypeEd25519, del)
+				require.NoError(t, err)
+				require.Equal(t, big.NewInt(100), amt)
+
+				// a zero stake is removed
+				err = SetStake(ctx, db, []byte("a"), crypto.KeyTypeEd25519, del, big.NewInt(0))
+				require.NoError(t, err)
+				stakes, err = GetStakes(ctx, db)
+				require.NoError(t, err)
+				require.Len(t, stakes, 1)
+				require.Equal(t, *self, stakes[0].Delegator)
+				require.Equal(t, []byte("a"), stakes[0].Validator.Identifier)
+
+				// unbonding at the same height accumulates
+				require.NoError(t, AddUnbonding(ctx, db, []byte("a"), crypto.KeyTypeEd25519, del, big.NewInt(60), 3, 10))
+				require.NoError(t, AddUnbonding(ctx, db, []byte("a"), crypto.KeyTypeEd25519, del, big.NewInt(40), 3, 10))
+				require.NoError(t, AddUnbonding(ctx, db, []byte("a"), crypto.KeyTypeEd25519, self, big.NewInt(5), 5, 12))
+
+				unbonding, err := GetUnbonding(ctx, db)
+				require.NoError(t, err)
+				require.Len(t, unbonding, 2)
+				require.Equal(t, []byte("a"), unbonding[0].Validator.Identifier)
+				require.Equal(t, int64(3), unbonding[0].Created)
+
+				// only the stake undelegated at or after a height is slashable
+				slashable, err := GetValidatorUnbonding(ctx, db, []byte("a"), crypto.KeyTypeEd25519, 4)
+				require.NoError(t, err)
+				require.Len(t, slashable, 1)
+				require.Equal(t, *self, slashable[0].Delegator)
+
+				slashable[0].Amount = big.NewInt(4)
+				require.NoError(t, SetUnbonding(ctx, db, slashable[0]))
+				slashable, err = GetValidatorUnbonding(ctx, db, []byte("a"), crypto.KeyTypeEd25519, 4)
+				require.NoError(t, err)
+				require.Equal(t, big.NewInt(4), slashable[0].Amount)
+
+				released, err := ReleaseUnbonding(ctx, db, 9)
+				require.NoError(t, err)
+				require.Empty(t, released)
+
+				released, err = ReleaseUnbonding(ctx, db, 11)
+				require.NoError(t, err)
+				require.Len(t, released, 1)
+				require.Equal(t, *del, released[0].Delegator)
+				require.Equal(t, big.NewInt(100), released[0].Amount)
+
+				unbonding, err = GetUnbonding(ctx, db)
+				require.NoError(t, err)
+				require.Len(t, unbonding, 1)
+				require.Equal(t, int64(12), unbonding[0].Height)
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -60,6 +60,10 @@ func InitializeVoteStore(ctx context.Context, db sql.TxMaker) (*VoteStore, error
 		0: initVotingTables,
 		1: dropHeight,
 		2: dropExtraVoteIDColumn,
+		3: initPenaltyTables,
+		4: initStakingTables,
+		5: initPowerHistory,
+		6: addUnbondingOrigin,
 	}
 
 	err := versioning.Upgrade(ctx, db, votingSchemaName, upgradeFns, voteStoreVersion)
@@ -167,6 +171,34 @@ func dropExtraVoteIDColumn(ctx context.Context, db sql.DB) error {
 	return err
 }
 
+func initPenaltyTables(ctx context.Context, db sql.DB) error {
+	for _, stmt := range []string{tableJailed, tableEvidence} {
+		if _, err := db.Execute(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func initStakingTables(ctx context.Context, db sql.DB) error {
+	for _, stmt := range []string{tableStakes, tableUnbonding} {
+		if _, err := db.Execute(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func initPowerHistory(ctx context.Context, db sql.DB) error {
+	_, err := db.Execute(ctx, tablePowerHistory)
+	return err
+}
+
+func addUnbondingOrigin(ctx context.Context, db sql.DB) error {
+	_, err := db.Execute(ctx, alterUnbondingOrigin)
+	return err
+}
+
 // ApproveResolution approves a resolution from a voter.
 // If the resolution does not yet exist, it will be errored,
 // Validators should only vote on existing resolutions.
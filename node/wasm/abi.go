@@ -0,0 +1,263 @@
+package wasm
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/precompiles"
+)
+
+// Manifest describes the methods of a WebAssembly precompile. It is returned,
+// JSON encoded, by the module's kwil_manifest export.
+type Manifest struct {
+	Methods []ManifestMethod `json:"methods"`
+}
+
+// ManifestMethod describes a single method of the module. The module must
+// export a function with the same name.
+type ManifestMethod struct {
+	Name string `json:"name"`
+	// Modifiers are the access modifiers of the method, e.g. "PUBLIC" and
+	// "VIEW". See precompiles.Modifier.
+	Modifiers  []string        `json:"modifiers"`
+	Parameters []ManifestValue `json:"parameters"`
+	// Returns is nil if the method does not return anything.
+	Returns *ManifestReturn `json:"returns,omitempty"`
+}
+
+// ManifestValue describes a parameter or returned column.
+type ManifestValue struct {
+	Name string `json:"name"`
+	// Type is a Kwil data type, such as "int8", "text[]" or "numeric(10,2)".
+	Type     string `json:"type"`
+	Nullable bool   `json:"nullable"`
+}
+
+// ManifestReturn describes the values returned by a method.
+type ManifestReturn struct {
+	// Table is true if the method returns any number of rows, rather than
+	// exactly one.
+	Table  bool            `json:"table"`
+	Fields []ManifestValue `json:"fields"`
+}
+
+// callResult is the JSON object returned by a method call and by the query
+// host function.
+type callResult struct {
+	Columns []string `json:"columns,omitempty"`
+	Rows    [][]any  `json:"rows,omitempty"`
+	Error   string   `json:"error,omitempty"`
+}
+
+// methodResult is the JSON object returned by a method call, with the values
+// left encoded until their types are known.
+type methodResult struct {
+	Rows  [][]json.RawMessage `json:"rows"`
+	Error string              `json:"error"`
+}
+
+// queryRequest is the JSON object passed to the query host function.
+type queryRequest struct {
+	SQL    string                     `json:"sql"`
+	Params map[string]json.RawMessage `json:"params"`
+}
+
+// toMethod converts the manifest's description of a method to a precompile
+// method. The handler is set by the caller.
+func (mm *ManifestMethod) toMethod() (*precompiles.Method, error) {
+	m := &precompiles.Method{
+		Name: strings.ToLower(mm.Name),
+	}
+
+	for _, mod := range mm.Modifiers {
+		m.AccessModifiers = append(m.AccessModifiers, precompiles.Modifier(strings.ToUpper(mod)))
+	}
+
+	var err error
+	if m.Parameters, err = toPrecompileValues(mm.Parameters); err != nil {
+		return nil, fmt.Errorf("method %s parameters: %w", mm.Name, err)
+	}
+
+	if mm.Returns != nil {
+		m.Returns = &precompiles.MethodReturn{
+			IsTable: mm.Returns.Table,
+		}
+		if m.Returns.Fields, err = toPrecompileValues(mm.Returns.Fields); err != nil {
+			return nil, fmt.Errorf("method %s returns: %w", mm.Name, err)
+		}
+	}
+
+	return m, nil
+}
+
+func toPrecompileValues(vals []ManifestValue) ([]precompiles.PrecompileValue, error) {
+	res := make([]precompiles.PrecompileValue, len(vals))
+	for i, v := range vals {
+		dt, err := types.ParseDataType(v.Type)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", v.Name, err)
+		}
+		res[i] = precompiles.NewPrecompileValue(v.Name, dt, v.Nullable)
+	}
+	return res, nil
+}
+
+// decodeValue decodes a JSON value returned by a module into the Go type
+// that the engine uses for the data type.
+func decodeValue(raw json.RawMessage, dt *types.DataType) (any, error) {
+	if isNull(raw) {
+		return nil, nil
+	}
+
+	if !dt.IsArray {
+		return decodeScalar(raw, dt)
+	}
+
+	var elems []json.RawMessage
+	if err := json.Unmarshal(raw, &elems); err != nil {
+		return nil, fmt.Errorf("expected array for %s: %w", dt, err)
+	}
+
+	scalar := dt.Copy()
+	scalar.IsArray = false
+
+	decoded := make([]any, len(elems))
+	for i, e := range elems {
+		v, err := decodeValue(e, scalar)
+		if err != nil {
+			return nil, err
+		}
+		decoded[i] = v
+	}
+
+	switch scalar.Name {
+	case types.IntType.Name:
+		return ptrSlice[int64](decoded), nil
+	case types.TextType.Name:
+		return ptrSlice[string](decoded), nil
+	case types.BoolType.Name:
+		return ptrSlice[bool](decoded), nil
+	case types.ByteaType.Name:
+		res := make([][]byte, len(decoded))
+		for i, v := range decoded {
+			if v != nil {
+				res[i] = v.([]byte)
+			}
+		}
+		return res, nil
+	case types.UUIDType.Name:
+		return castSlice[*types.UUID](decoded), nil
+	case types.NumericType.Name:
+		return castSlice[*types.Decimal](decoded), nil
+	}
+
+	return nil, fmt.Errorf("unsupported data type %s", dt)
+}
+
+func decodeScalar(raw json.RawMessage, dt *types.DataType) (any, error) {
+	switch dt.Name {
+	case types.IntType.Name:
+		var n json.Number
+		if err := json.Unmarshal(raw, &n); err != nil {
+			return nil, fmt.Errorf("expected number for %s: %w", dt, err)
+		}
+		return n.Int64()
+	case types.TextType.Name:
+		var s string
+		err := json.Unmarshal(raw, &s)
+		return s, err
+	case types.BoolType.Name:
+		var b bool
+		err := json.Unmarshal(raw, &b)
+		return b, err
+	case types.ByteaType.Name: // base64, as encoding/json encodes []byte
+		var b []byte
+		err := json.Unmarshal(raw, &b)
+		return b, err
+	case types.UUIDType.Name:
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return nil, err
+		}
+		return types.ParseUUID(s)
+	case types.NumericType.Name: // either a string or a number
+		s := string(bytes.Trim(raw, `"`))
+		return types.ParseDecimal(s)
+	}
+
+	return nil, fmt.Errorf("unsupported data type %s", dt)
+}
+
+// decodeParam decodes a query parameter from a module. Integers decode to
+// int64, other numbers to decimals, and an object of the form
+// {"bytea": "<base64>"} to a byte slice, since JSON has no native bytes type.
+func decodeParam(raw json.RawMessage) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return convertParam(v)
+}
+
+func convertParam(v any) (any, error) {
+	switch v := v.(type) {
+	case nil, string, bool:
+		return v, nil
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i, nil
+		}
+		return types.ParseDecimal(v.String())
+	case []any:
+		res := make([]any, len(v))
+		for i, e := range v {
+			var err error
+			if res[i], err = convertParam(e); err != nil {
+				return nil, err
+			}
+		}
+		return res, nil
+	case map[string]any:
+		b64, ok := v["bytea"].(string)
+		if !ok || len(v) != 1 {
+			return nil, errors.New(`objects are only supported in the form {"bytea": "<base64>"}`)
+		}
+		var b []byte
+		if err := json.Unmarshal([]byte(`"`+b64+`"`), &b); err != nil {
+			return nil, err
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unsupported parameter type %T", v)
+}
+
+func isNull(raw json.RawMessage) bool {
+	return len(raw) == 0 || string(bytes.TrimSpace(raw)) == "null"
+}
+
+func ptrSlice[T any](vals []any) []*T {
+	res := make([]*T, len(vals))
+	for i, v := range vals {
+		if v != nil {
+			t := v.(T)
+			res[i] = &t
+		}
+	}
+	return res
+}
+
+func castSlice[T any](vals []any) []T {
+	res := make([]T, len(vals))
+	for i, v := range vals {
+		if v != nil {
+			res[i] = v.(T)
+		}
+	}
+	return res
+}
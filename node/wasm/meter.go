@@ -0,0 +1,646 @@
+package wasm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"slices"
+)
+
+// fuelExport is the name of the global that instrument adds to a module to
+// hold its remaining fuel.
+const fuelExport = "kwil_fuel"
+
+// WebAssembly binary format constants used by the instrumentation.
+const (
+	sectionImport    = 2
+	sectionGlobal    = 6
+	sectionExport    = 7
+	sectionStart     = 8
+	sectionElement   = 9
+	sectionCode      = 10
+	sectionData      = 11
+	sectionDataCount = 12
+
+	externGlobal = 0x03
+
+	valTypeI32 = 0x7F
+	valTypeI64 = 0x7E
+)
+
+// The fuel consumed by instructions whose cost depends on their operand is one
+// unit per 64 bytes of memory, or per table element.
+const (
+	bytesPerFuelShift = 6  // fuel = bytes >> 6
+	pagesToFuelShift  = 10 // fuel = pages << 10, since a page is 64 KiB
+)
+
+var wasmHeader = []byte{0x00, 0x61, 0x73, 0x6D, 0x01, 0x00, 0x00, 0x00}
+
+var errUnsupportedInstruction = errors.New("unsupported instruction")
+
+// instrument rewrites a WebAssembly module so that it consumes fuel
+// deterministically as it executes. A new mutable i64 global, exported as
+// kwil_fuel, holds the remaining fuel. One unit is consumed on entry to every
+// function and at the start of every loop iteration, and the module traps with
+// unreachable if the fuel becomes negative. Since every unbounded computation
+// in WebAssembly requires either a loop or recursion, this bounds the execution
+// of any module, and every node charges exactly the same amount for the same
+// execution. The bulk memory and table instructions, and memory.grow, do work
+// proportional to their last operand without looping, so they are charged for
+// it before they execute, using a second, unexported global to hold the operand.
+//
+// Only the instruction set of the WebAssembly 2.0 core specification, without
+// SIMD, is supported. Modules with a start function are rejected, since the
+// fuel is zero until it is set by the host after instantiation. The module is
+// not validated until it is compiled after instrumentation, so references to
+// globals beyond the module's own, which would become the fuel globals, are
+// rejected here.
+func instrument(bin []byte) ([]byte, error) {
+	if !bytes.HasPrefix(bin, wasmHeader) {
+		return nil, errors.New("not a WebAssembly 1.0 binary")
+	}
+
+	type section struct {
+		id      byte
+		payload []byte
+	}
+	var sections []section
+
+	r := &reader{b: bin, pos: len(wasmHeader)}
+	for !r.done() {
+		id, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		payload, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		if id == sectionStart {
+			// A start function would run on instantiation, before the
+			// host has a chance to set the fuel.
+			return nil, errors.New("start functions are not supported")
+		}
+		sections = append(sections, section{id, payload})
+	}
+
+	// The fuel global's index follows all imported and defined globals.
+	var numGlobals uint32
+	for _, s := range sections {
+		switch s.id {
+		case sectionImport:
+			n, err := countImportedGlobals(s.payload)
+			if err != nil {
+				return nil, fmt.Errorf("import section: %w", err)
+			}
+			numGlobals += n
+		case sectionGlobal:
+			n, err := (&reader{b: s.payload}).u32()
+			if err != nil {
+				return nil, fmt.Errorf("global section: %w", err)
+			}
+			numGlobals += n
+		}
+	}
+	fuelIdx := numGlobals // the operand global is fuelIdx+1
+
+	out := bytes.NewBuffer(slices.Clone(wasmHeader))
+	writeSection := func(id byte, payload []byte) {
+		out.WriteByte(id)
+		out.Write(appendU32(nil, uint32(len(payload))))
+		out.Write(payload)
+	}
+
+	var wroteGlobal, wroteExport bool
+	emitGlobal := func(existing []byte) error {
+		payload, err := appendFuelGlobal(existing)
+		if err != nil {
+			return fmt.Errorf("global section: %w", err)
+		}
+		writeSection(sectionGlobal, payload)
+		wroteGlobal = true
+		return nil
+	}
+	emitExport := func(existing []byte) error {
+		payload, err := appendFuelExport(existing, fuelIdx)
+		if err != nil {
+			return fmt.Errorf("export section: %w", err)
+		}
+		writeSection(sectionExport, payload)
+		wroteExport = true
+		return nil
+	}
+
+	for _, s := range sections {
+		switch s.id {
+		case sectionGlobal:
+			if err := emitGlobal(s.payload); err != nil {
+				return nil, err
+			}
+			continue
+		case sectionExport:
+			if !wroteGlobal {
+				if err := emitGlobal(nil); err != nil {
+					return nil, err
+				}
+			}
+			if err := emitExport(s.payload); err != nil {
+				return nil, err
+			}
+			continue
+		case sectionStart, sectionElement, sectionDataCount, sectionCode, sectionData:
+			// These sections follow the global and export sections, so the
+			// new ones must be inserted before them if they did not exist.
+			if !wroteGlobal {
+				if err := emitGlobal(nil); err != nil {
+					return nil, err
+				}
+			}
+			if !wroteExport {
+				if err := emitExport(nil); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		if s.id == sectionCode {
+			payload, err := instrumentCode(s.payload, fuelIdx)
+			if err != nil {
+				return nil, fmt.Errorf("code section: %w", err)
+			}
+			writeSection(sectionCode, payload)
+			continue
+		}
+
+		writeSection(s.id, s.payload)
+	}
+
+	if !wroteGlobal {
+		if err := emitGlobal(nil); err != nil {
+			return nil, err
+		}
+	}
+	if !wroteExport {
+		if err := emitExport(nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return out.Bytes(), nil
+}
+
+// countImportedGlobals returns the number of globals imported by a module.
+func countImportedGlobals(payload []byte) (uint32, error) {
+	r := &reader{b: payload}
+	n, err := r.u32()
+	if err != nil {
+		return 0, err
+	}
+
+	var globals uint32
+	for range n {
+		if _, err = r.name(); err != nil { // module
+			return 0, err
+		}
+		if _, err = r.name(); err != nil { // name
+			return 0, err
+		}
+		kind, err := r.byte()
+		if err != nil {
+			return 0, err
+		}
+		switch kind {
+		case 0x00: // func: type index
+			_, err = r.u32()
+		case 0x01: // table: reftype and limits
+			if _, err = r.byte(); err == nil {
+				err = r.skipLimits()
+			}
+		case 0x02: // memory: limits
+			err = r.skipLimits()
+		case externGlobal: // global: valtype and mutability
+			_, err = r.bytes(2)
+			globals++
+		default:
+			err = fmt.Errorf("unknown import kind %d", kind)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+	return globals, nil
+}
+
+// appendFuelGlobal adds the fuel global, and the global that holds the operand
+// of charged instructions, to the existing payload of a global section, which
+// may be empty.
+func appendFuelGlobal(existing []byte) ([]byte, error) {
+	var n uint32
+	var rest []byte
+	if len(existing) > 0 {
+		r := &reader{b: existing}
+		var err error
+		if n, err = r.u32(); err != nil {
+			return nil, err
+		}
+		rest = existing[r.pos:]
+	}
+
+	payload := appendU32(nil, n+2)
+	payload = append(payload, rest...)
+	// (global (mut i64) (i64.const 0))
+	payload = append(payload, valTypeI64, 0x01, 0x42, 0x00, 0x0B)
+	// (global (mut i32) (i32.const 0))
+	return append(payload, valTypeI32, 0x01, 0x41, 0x00, 0x0B), nil
+}
+
+// appendFuelExport adds the export of the fuel global to the existing payload
+// of an export section, which may be empty. The existing exports may not
+// export the fuel globals.
+func appendFuelExport(existing []byte, fuelIdx uint32) ([]byte, error) {
+	var n uint32
+	var rest []byte
+	if len(existing) > 0 {
+		r := &reader{b: existing}
+		var err error
+		if n, err = r.u32(); err != nil {
+			return nil, err
+		}
+		rest = existing[r.pos:]
+
+		for range n {
+			if _, err = r.name(); err != nil {
+				return nil, err
+			}
+			kind, err := r.byte()
+			if err != nil {
+				return nil, err
+			}
+			idx, err := r.u32()
+			if err != nil {
+				return nil, err
+			}
+			if kind == externGlobal && idx >= fuelIdx {
+				return nil, fmt.Errorf("global index %d out of range", idx)
+			}
+		}
+	}
+
+	payload := appendU32(nil, n+1)
+	payload = append(payload, rest...)
+	payload = appendU32(payload, uint32(len(fuelExport)))
+	payload = append(payload, fuelExport...)
+	payload = append(payload, externGlobal)
+	return appendU32(payload, fuelIdx), nil
+}
+
+// chargeFuel returns the instructions that consume one unit of fuel and trap
+// if there is none left.
+func chargeFuel(fuelIdx uint32) []byte {
+	var b []byte
+	b = append(b, 0x23) // global.get
+	b = appendU32(b, fuelIdx)
+	b = append(b, 0x42, 0x01, 0x7D) // i64.const 1, i64.sub
+	b = append(b, 0x24)             // global.set
+	b = appendU32(b, fuelIdx)
+	return append(b, trapIfNoFuel(fuelIdx)...)
+}
+
+// chargeOperand returns the instructions that consume fuel for the i32 operand
+// on top of the stack, converted to fuel by shifting it left by shl and then
+// right by shr, and trap if there is not enough left. The operand is left on
+// the stack.
+func chargeOperand(fuelIdx uint32, shl, shr byte) []byte {
+	operandIdx := fuelIdx + 1
+	var b []byte
+	b = append(b, 0x24) // global.set
+	b = appendU32(b, operandIdx)
+	b = append(b, 0x23) // global.get
+	b = appendU32(b, fuelIdx)
+	b = append(b, 0x23) // global.get
+	b = appendU32(b, operandIdx)
+	b = append(b, 0xAD)            // i64.extend_i32_u
+	b = append(b, 0x42, shl, 0x86) // i64.const shl, i64.shl
+	b = append(b, 0x42, shr, 0x88) // i64.const shr, i64.shr_u
+	b = append(b, 0x7D, 0x24)      // i64.sub, global.set
+	b = appendU32(b, fuelIdx)
+	b = append(b, trapIfNoFuel(fuelIdx)...)
+	b = append(b, 0x23) // global.get
+	return appendU32(b, operandIdx)
+}
+
+// trapIfNoFuel returns the instructions that trap if the fuel is negative.
+func trapIfNoFuel(fuelIdx uint32) []byte {
+	var b []byte
+	b = append(b, 0x23) // global.get
+	b = appendU32(b, fuelIdx)
+	b = append(b, 0x42, 0x00, 0x53) // i64.const 0, i64.lt_s
+	b = append(b, 0x04, 0x40)       // if (no result)
+	b = append(b, 0x00)             // unreachable
+	return append(b, 0x0B)          // end
+}
+
+// fuelCode holds the instructions inserted into function bodies to charge fuel.
+type fuelCode struct {
+	fuelIdx  uint32 // the number of globals of the original module
+	unit     []byte // one unit
+	pages    []byte // the number of memory pages on the stack
+	bytes    []byte // the number of bytes on the stack
+	elements []byte // the number of table elements on the stack
+}
+
+func newFuelCode(fuelIdx uint32) *fuelCode {
+	return &fuelCode{
+		fuelIdx:  fuelIdx,
+		unit:     chargeFuel(fuelIdx),
+		pages:    chargeOperand(fuelIdx, pagesToFuelShift, 0),
+		bytes:    chargeOperand(fuelIdx, 0, bytesPerFuelShift),
+		elements: chargeOperand(fuelIdx, 0, 0),
+	}
+}
+
+// operandCharge returns the instructions that charge for the operand of an
+// instruction, if its cost depends on it.
+func (fc *fuelCode) operandCharge(op byte, sub uint32) []byte {
+	switch {
+	case op == 0x40: // memory.grow
+		return fc.pages
+	case op != 0xFC:
+		return nil
+	case sub == 8 || sub == 10 || sub == 11: // memory.init, memory.copy, memory.fill
+		return fc.bytes
+	case sub == 12 || sub == 14 || sub == 15 || sub == 17: // table.init, table.copy, table.grow, table.fill
+		return fc.elements
+	}
+	return nil
+}
+
+// instrumentCode instruments every function body in the code section.
+func instrumentCode(payload []byte, fuelIdx uint32) ([]byte, error) {
+	fc := newFuelCode(fuelIdx)
+
+	r := &reader{b: payload}
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+
+	out := appendU32(nil, n)
+	for i := range n {
+		size, err := r.u32()
+		if err != nil {
+			return nil, err
+		}
+		body, err := r.bytes(int(size))
+		if err != nil {
+			return nil, err
+		}
+		newBody, err := instrumentBody(body, fc)
+		if err != nil {
+			return nil, fmt.Errorf("function %d: %w", i, err)
+		}
+		out = appendU32(out, uint32(len(newBody)))
+		out = append(out, newBody...)
+	}
+	if !r.done() {
+		return nil, errors.New("trailing bytes")
+	}
+	return out, nil
+}
+
+// instrumentBody instruments a single function body, charging fuel on entry,
+// after the block type of every loop instruction, and before every instruction
+// whose cost depends on its operand.
+func instrumentBody(body []byte, fc *fuelCode) ([]byte, error) {
+	r := &reader{b: body}
+
+	// local declarations
+	n, err := r.u32()
+	if err != nil {
+		return nil, err
+	}
+	for range n {
+		if _, err = r.u32(); err != nil {
+			return nil, err
+		}
+		if _, err = r.byte(); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]byte, 0, len(body)+len(fc.unit)*4)
+	out = append(out, body[:r.pos]...)
+	out = append(out, fc.unit...)
+
+	for !r.done() {
+		start := r.pos
+		op, err := r.byte()
+		if err != nil {
+			return nil, err
+		}
+		var imm uint32 // the sub-opcode or global index
+		if op == 0xFC || op == 0x23 || op == 0x24 {
+			imm, _ = (&reader{b: body, pos: r.pos}).u32() // checked by skipImmediates
+		}
+		if err = r.skipImmediates(op); err != nil {
+			return nil, fmt.Errorf("opcode 0x%02x at offset %d: %w", op, start, err)
+		}
+		// global.get and global.set of the fuel globals would let the module
+		// refill its fuel.
+		if (op == 0x23 || op == 0x24) && imm >= fc.fuelIdx {
+			return nil, fmt.Errorf("opcode 0x%02x at offset %d: global index %d out of range", op, start, imm)
+		}
+		out = append(out, fc.operandCharge(op, imm)...)
+		out = append(out, body[start:r.pos]...)
+		if op == 0x03 { // loop
+			out = append(out, fc.unit...)
+		}
+	}
+
+	return out, nil
+}
+
+// skipImmediates advances past the immediate arguments of an instruction.
+func (r *reader) skipImmediates(op byte) error {
+	var err error
+	switch {
+	case op == 0x02 || op == 0x03 || op == 0x04: // block, loop, if
+		err = r.skipBlockType()
+	case op == 0x0C || op == 0x0D: // br, br_if
+		_, err = r.u32()
+	case op == 0x0E: // br_table
+		var n uint32
+		if n, err = r.u32(); err != nil {
+			return err
+		}
+		for range n + 1 {
+			if _, err = r.u32(); err != nil {
+				return err
+			}
+		}
+	case op == 0x10: // call
+		_, err = r.u32()
+	case op == 0x11: // call_indirect
+		if _, err = r.u32(); err == nil {
+			_, err = r.u32()
+		}
+	case op == 0x1C: // select t*
+		var n uint32
+		if n, err = r.u32(); err == nil {
+			_, err = r.bytes(int(n))
+		}
+	case op >= 0x20 && op <= 0x26: // local.*, global.*, table.get/set
+		_, err = r.u32()
+	case op >= 0x28 && op <= 0x3E: // loads and stores: memarg
+		if _, err = r.u32(); err == nil {
+			_, err = r.u32()
+		}
+	case op == 0x3F || op == 0x40: // memory.size, memory.grow
+		_, err = r.byte()
+	case op == 0x41 || op == 0x42: // i32.const, i64.const
+		err = r.skipLEB()
+	case op == 0x43: // f32.const
+		_, err = r.bytes(4)
+	case op == 0x44: // f64.const
+		_, err = r.bytes(8)
+	case op == 0xD0: // ref.null
+		_, err = r.byte()
+	case op == 0xD2: // ref.func
+		_, err = r.u32()
+	case op == 0xFC:
+		err = r.skipMiscImmediates()
+	case op == 0x00, op == 0x01, op == 0x05, op == 0x0B, op == 0x0F, // unreachable, nop, else, end, return
+		op == 0x1A, op == 0x1B, // drop, select
+		op >= 0x45 && op <= 0xC4, // numeric instructions without immediates
+		op == 0xD1:               // ref.is_null
+	default:
+		err = errUnsupportedInstruction
+	}
+	return err
+}
+
+// skipMiscImmediates advances past the sub-opcode and immediates of an 0xFC
+// prefixed instruction.
+func (r *reader) skipMiscImmediates() error {
+	sub, err := r.u32()
+	if err != nil {
+		return err
+	}
+	switch {
+	case sub <= 7: // saturating truncations
+	case sub == 8: // memory.init
+		if _, err = r.u32(); err == nil {
+			_, err = r.byte()
+		}
+	case sub == 9, sub == 13, sub == 15, sub == 16, sub == 17: // data.drop, elem.drop, table.grow/size/fill
+		_, err = r.u32()
+	case sub == 10: // memory.copy
+		_, err = r.bytes(2)
+	case sub == 11: // memory.fill
+		_, err = r.byte()
+	case sub == 12 || sub == 14: // table.init, table.copy
+		if _, err = r.u32(); err == nil {
+			_, err = r.u32()
+		}
+	default:
+		err = errUnsupportedInstruction
+	}
+	return err
+}
+
+// reader reads the WebAssembly binary format.
+type reader struct {
+	b   []byte
+	pos int
+}
+
+var errUnexpectedEOF = errors.New("unexpected end of binary")
+
+func (r *reader) done() bool { return r.pos >= len(r.b) }
+
+func (r *reader) byte() (byte, error) {
+	if r.done() {
+		return 0, errUnexpectedEOF
+	}
+	b := r.b[r.pos]
+	r.pos++
+	return b, nil
+}
+
+func (r *reader) bytes(n int) ([]byte, error) {
+	if n < 0 || r.pos+n > len(r.b) {
+		return nil, errUnexpectedEOF
+	}
+	b := r.b[r.pos : r.pos+n]
+	r.pos += n
+	return b, nil
+}
+
+func (r *reader) u32() (uint32, error) {
+	v, n := binary.Uvarint(r.b[r.pos:])
+	if n <= 0 || n > 5 || v > 1<<32-1 {
+		return 0, errors.New("invalid u32")
+	}
+	r.pos += n
+	return uint32(v), nil
+}
+
+// skipLEB advances past a signed or unsigned LEB128 integer.
+func (r *reader) skipLEB() error {
+	for {
+		b, err := r.byte()
+		if err != nil {
+			return err
+		}
+		if b&0x80 == 0 {
+			return nil
+		}
+	}
+}
+
+func (r *reader) name() (string, error) {
+	n, err := r.u32()
+	if err != nil {
+		return "", err
+	}
+	b, err := r.bytes(int(n))
+	return string(b), err
+}
+
+func (r *reader) skipLimits() error {
+	flag, err := r.byte()
+	if err != nil {
+		return err
+	}
+	if _, err = r.u32(); err != nil {
+		return err
+	}
+	if flag == 0x01 {
+		_, err = r.u32()
+	}
+	return err
+}
+
+// skipBlockType advances past a block type, which is either the empty type
+// (0x40), a value type, or a type index encoded as a positive signed LEB128.
+func (r *reader) skipBlockType() error {
+	if r.done() {
+		return errUnexpectedEOF
+	}
+	switch b := r.b[r.pos]; b {
+	case 0x40, 0x7F, 0x7E, 0x7D, 0x7C, 0x70, 0x6F:
+		r.pos++
+		return nil
+	}
+	return r.skipLEB()
+}
+
+func appendU32(b []byte, v uint32) []byte {
+	return binary.AppendUvarint(b, uint64(v))
+}
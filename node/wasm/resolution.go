@@ -0,0 +1,101 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/resolutions"
+)
+
+const (
+	PrecompileResolutionType = "wasm_precompile"
+)
+
+// PrecompileDeclaration is the body of a resolution that adds a WebAssembly
+// precompile to the network.
+type PrecompileDeclaration struct {
+	// Name is the name the precompile is registered under, as used in
+	// `USE <name> AS <alias>`.
+	Name string
+	// Module is the WebAssembly binary.
+	Module []byte
+}
+
+func init() {
+	err := resolutions.RegisterResolution(PrecompileResolutionType, resolutions.ModAdd, PrecompileResolution)
+	if err != nil {
+		panic(err)
+	}
+}
+
+var PrecompileResolution = resolutions.ResolutionConfig{
+	ConfirmationThreshold: big.NewRat(2, 3),   // > 66%
+	ExpirationPeriod:      7 * 24 * time.Hour, // 1 week
+	ResolveFunc: func(ctx context.Context, app *common.App, resolution *resolutions.Resolution, block *common.BlockContext) error {
+		var decl PrecompileDeclaration
+		err := decl.UnmarshalBinary(resolution.Body)
+		if err != nil {
+			return err
+		}
+
+		// if the module is invalid, registerOnCommit fails and the stored
+		// module is rolled back with the rest of the resolution
+		if err = StoreModule(ctx, app.DB, decl.Name, decl.Module); err != nil {
+			return err
+		}
+
+		app.Service.Logger.Info("Adding wasm precompile", "name", decl.Name, "size", len(decl.Module))
+
+		// The precompile is usable from the next block. Registering it now
+		// would make it visible to the engine before the block is committed,
+		// and leave it registered if the block is rolled back.
+		return registerOnCommit(ctx, decl.Name, decl.Module)
+	},
+}
+
+var _ encoding.BinaryMarshaler = PrecompileDeclaration{}
+var _ encoding.BinaryMarshaler = (*PrecompileDeclaration)(nil)
+
+const declVersion = 0
+
+func (d PrecompileDeclaration) MarshalBinary() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	// version uint16
+	binary.Write(buf, types.SerializationByteOrder, uint16(declVersion))
+	types.WriteString(buf, d.Name)
+	types.WriteBytes(buf, d.Module)
+	return buf.Bytes(), nil
+}
+
+var _ encoding.BinaryUnmarshaler = (*PrecompileDeclaration)(nil)
+
+func (d *PrecompileDeclaration) UnmarshalBinary(b []byte) error {
+	buf := bytes.NewBuffer(b)
+	// version uint16
+	var version uint16
+	binary.Read(buf, types.SerializationByteOrder, &version)
+	if version != declVersion {
+		return fmt.Errorf("invalid version %d", version)
+	}
+
+	name, err := types.ReadString(buf)
+	if err != nil {
+		return err
+	}
+	module, err := types.ReadBytes(buf)
+	if err != nil {
+		return err
+	}
+
+	d.Name = name
+	d.Module = module
+
+	return nil
+}
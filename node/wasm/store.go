@@ -0,0 +1,91 @@
+package wasm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	"github.com/kwilteam/kwil-db/node/types/sql"
+	"github.com/kwilteam/kwil-db/node/versioning"
+)
+
+const (
+	schemaName = `kwild_wasm`
+
+	storeVersion = 0
+
+	sqlInitTables = `CREATE TABLE IF NOT EXISTS ` + schemaName + `.modules (
+		name TEXT PRIMARY KEY,
+		code BYTEA NOT NULL
+	);`
+
+	sqlStoreModule = `INSERT INTO ` + schemaName + `.modules (name, code) VALUES ($1, $2)`
+
+	sqlGetModule = `SELECT code FROM ` + schemaName + `.modules WHERE name = $1`
+
+	sqlListModules = `SELECT name, code FROM ` + schemaName + `.modules ORDER BY name`
+)
+
+func initTables(ctx context.Context, tx sql.DB) error {
+	_, err := tx.Execute(ctx, sqlInitTables)
+	if err != nil {
+		return fmt.Errorf("failed to initialize tables: %w", err)
+	}
+
+	return nil
+}
+
+// InitializeStore creates the tables that hold the WebAssembly modules, if
+// they do not exist.
+func InitializeStore(ctx context.Context, db sql.DB) error {
+	upgradeFns := map[int64]versioning.UpgradeFunc{
+		0: initTables,
+	}
+
+	return versioning.Upgrade(ctx, db, schemaName, upgradeFns, storeVersion)
+}
+
+// StoreModule stores a module's code under the given name. It is an error to
+// store different code under a name that is already in use.
+func StoreModule(ctx context.Context, db sql.Executor, name string, code []byte) error {
+	res, err := db.Execute(ctx, sqlGetModule, name)
+	if err != nil {
+		return err
+	}
+	if len(res.Rows) > 0 {
+		existing, ok := res.Rows[0][0].([]byte)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for module code", res.Rows[0][0])
+		}
+		if !bytes.Equal(existing, code) {
+			return fmt.Errorf("a different wasm module named %s already exists", name)
+		}
+		return nil
+	}
+
+	_, err = db.Execute(ctx, sqlStoreModule, name, code)
+	return err
+}
+
+// LoadModules registers every module in the store as a precompile extension.
+// It must be called before the engine is initialized, since the engine
+// requires the precompiles used by its namespaces to be registered.
+func LoadModules(ctx context.Context, db sql.Executor) error {
+	res, err := db.Execute(ctx, sqlListModules)
+	if err != nil {
+		return err
+	}
+
+	for _, row := range res.Rows {
+		name, ok1 := row[0].(string)
+		code, ok2 := row[1].([]byte)
+		if !ok1 || !ok2 {
+			return fmt.Errorf("unexpected types %T, %T for module", row[0], row[1])
+		}
+		if err := Register(ctx, name, code); err != nil {
+			return fmt.Errorf("wasm module %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,619 @@
+// Package wasm loads precompile extensions from WebAssembly modules, so that
+// networks can add extensions without building a custom kwild binary. Modules
+// are declared in the genesis config or added by a governance resolution, and
+// are stored in the database so that every node loads the same set of modules
+// on startup.
+//
+// Modules run in a sandbox with no access to the host other than the
+// functions of the "kwil" import module described below. In particular, there
+// is no WASI, so a module cannot read the clock, the file system, or a source
+// of randomness. Execution is metered with fuel: the module's bytecode is
+// instrumented to consume fuel on every function call and loop iteration, and
+// the host functions consume fuel for their work, so every node stops an
+// execution at exactly the same point.
+//
+// A module must export:
+//
+//   - memory: the module's linear memory.
+//   - kwil_alloc(size i32) i32: allocates size bytes and returns a pointer to
+//     them. The host uses it to pass inputs to the module.
+//   - kwil_manifest() i64: returns a JSON encoded Manifest.
+//   - a function for each method in the manifest, with the signature
+//     (ptr i32, len i32) i64. The input is a JSON array of the method's
+//     arguments, and the output is a JSON object of the form
+//     {"rows": [[...], ...]} or {"error": "..."}.
+//
+// All outputs are returned as a pointer and length packed into an i64, with
+// the pointer in the upper 32 bits. An output of zero is an empty result.
+//
+// A module may import from the "kwil" module:
+//
+//   - query(ptr i32, len i32) i64: executes a SQL statement against the
+//     database through the engine. The input is a JSON object of the form
+//     {"sql": "...", "params": {"$name": value}}, and the output is a JSON
+//     object of the form {"columns": [...], "rows": [[...], ...]} or
+//     {"error": "..."}.
+//   - notice(ptr i32, len i32): logs a message at debug level.
+//
+// Values are encoded as JSON, with int8 as a number, numeric and uuid as
+// strings, and bytea as base64 strings. Since JSON has no byte type, a bytea
+// query parameter is passed as an object of the form {"bytea": "<base64>"}.
+package wasm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/extensions/precompiles"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+const (
+	hostModule     = "kwil"
+	allocExport    = "kwil_alloc"
+	manifestExport = "kwil_manifest"
+	memoryExport   = "memory"
+)
+
+var (
+	// ErrOutOfFuel is returned when a module exhausts its fuel.
+	ErrOutOfFuel = errors.New("wasm module out of fuel")
+	// ErrHostImport is returned when a module imports a function that the
+	// host does not provide.
+	ErrHostImport = errors.New("unsupported host import")
+)
+
+// Limits are the resource limits of a module.
+type Limits struct {
+	// Fuel is the fuel available to each method call.
+	Fuel int64
+	// QueryFuel is the fuel consumed by each call to the query host function.
+	QueryFuel int64
+	// RowFuel is the fuel consumed by each row returned by a query.
+	RowFuel int64
+	// MemoryPages is the maximum size of the module's memory, in 64 KiB pages.
+	MemoryPages uint32
+}
+
+// DefaultLimits are the limits used for modules loaded by kwild.
+var DefaultLimits = Limits{
+	Fuel:        10_000_000,
+	QueryFuel:   1_000,
+	RowFuel:     10,
+	MemoryPages: 256, // 16 MiB
+}
+
+// Module is a compiled WebAssembly precompile.
+type Module struct {
+	name     string
+	hash     [32]byte
+	limits   Limits
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	methods  []precompiles.Method
+}
+
+// Compile instruments and compiles a module, and reads its manifest. It
+// returns an error if the module imports anything that the host does not
+// provide, or does not have the required exports.
+func Compile(ctx context.Context, name string, code []byte, limits Limits) (*Module, error) {
+	instrumented, err := instrument(code)
+	if err != nil {
+		return nil, fmt.Errorf("invalid module: %w", err)
+	}
+
+	cfg := wazero.NewRuntimeConfigInterpreter().
+		WithCoreFeatures(api.CoreFeaturesV2 &^ api.CoreFeatureSIMD).
+		WithMemoryLimitPages(limits.MemoryPages).
+		WithCloseOnContextDone(true)
+	rt := wazero.NewRuntimeWithConfig(ctx, cfg)
+
+	m := &Module{
+		name:    strings.ToLower(name),
+		hash:    sha256.Sum256(code),
+		limits:  limits,
+		runtime: rt,
+	}
+
+	if err := m.init(ctx, instrumented); err != nil {
+		rt.Close(ctx)
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *Module) init(ctx context.Context, code []byte) error {
+	_, err := m.runtime.NewHostModuleBuilder(hostModule).
+		NewFunctionBuilder().
+		WithGoModuleFunction(api.GoModuleFunc(m.hostQuery), []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, []api.ValueType{api.ValueTypeI64}).
+		Export("query").
+		NewFunctionBuilder().
+		WithGoModuleFunction(api.GoModuleFunc(m.hostNotice), []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, nil).
+		Export("notice").
+		Instantiate(ctx)
+	if err != nil {
+		return err
+	}
+
+	m.compiled, err = m.runtime.CompileModule(ctx, code)
+	if err != nil {
+		return fmt.Errorf("invalid module: %w", err)
+	}
+
+	for _, def := range m.compiled.ImportedFunctions() {
+		mod, fn, _ := def.Import()
+		if mod != hostModule || (fn != "query" && fn != "notice") {
+			return fmt.Errorf("%w: %s.%s", ErrHostImport, mod, fn)
+		}
+	}
+	if len(m.compiled.ImportedMemories()) > 0 {
+		return fmt.Errorf("%w: modules must define their own memory", ErrHostImport)
+	}
+
+	if _, ok := m.compiled.ExportedMemories()[memoryExport]; !ok {
+		return fmt.Errorf("module does not export %q", memoryExport)
+	}
+	exports := m.compiled.ExportedFunctions()
+	if err := checkSignature(exports, allocExport, []api.ValueType{api.ValueTypeI32}, api.ValueTypeI32); err != nil {
+		return err
+	}
+	if err := checkSignature(exports, manifestExport, nil, api.ValueTypeI64); err != nil {
+		return err
+	}
+
+	manifest, err := m.readManifest(ctx)
+	if err != nil {
+		return fmt.Errorf("reading manifest: %w", err)
+	}
+
+	for _, mm := range manifest.Methods {
+		if err := checkSignature(exports, mm.Name, []api.ValueType{api.ValueTypeI32, api.ValueTypeI32}, api.ValueTypeI64); err != nil {
+			return err
+		}
+
+		method, err := mm.toMethod()
+		if err != nil {
+			return err
+		}
+		method.Handler = m.handler(mm.Name, method.Returns)
+		m.methods = append(m.methods, *method)
+	}
+
+	// verify the methods the same way the registry will, so that an invalid
+	// module is rejected before it is stored
+	return precompiles.CleanPrecompile(&precompiles.Precompile{Methods: m.methods})
+}
+
+func checkSignature(exports map[string]api.FunctionDefinition, name string, params []api.ValueType, result api.ValueType) error {
+	def, ok := exports[name]
+	if !ok {
+		return fmt.Errorf("module does not export function %q", name)
+	}
+
+	results := def.ResultTypes()
+	if !equalTypes(def.ParamTypes(), params) || len(results) != 1 || results[0] != result {
+		return fmt.Errorf("function %q has the wrong signature", name)
+	}
+	return nil
+}
+
+func equalTypes(a, b []api.ValueType) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Name returns the name the module is registered under.
+func (m *Module) Name() string {
+	return m.name
+}
+
+// Hash returns the SHA-256 hash of the module's (uninstrumented) code.
+func (m *Module) Hash() [32]byte {
+	return m.hash
+}
+
+// Methods returns the precompile methods of the module.
+func (m *Module) Methods() []precompiles.Method {
+	return m.methods
+}
+
+// Close releases the resources of the module.
+func (m *Module) Close(ctx context.Context) error {
+	return m.runtime.Close(ctx)
+}
+
+// Precompile returns the precompile extension backed by the module.
+func (m *Module) Precompile() precompiles.Precompile {
+	methods := make([]precompiles.Method, len(m.methods))
+	for i := range m.methods {
+		methods[i] = *m.methods[i].Copy()
+	}
+	return precompiles.Precompile{Methods: methods}
+}
+
+// callState is the state of a single method call, shared with the host
+// functions through the context.
+type callState struct {
+	engineCtx *common.EngineContext
+	app       *common.App
+	// err is the error that caused a host function to abort the call. It
+	// takes precedence over the error returned by the runtime.
+	err error
+}
+
+type callStateKey struct{}
+
+// instance is an instantiation of the module for a single call. Every call
+// gets a fresh instance, so no state is carried between calls.
+type instance struct {
+	mod   api.Module
+	fuel  api.MutableGlobal
+	state *callState
+}
+
+func (m *Module) instantiate(ctx context.Context, state *callState) (*instance, error) {
+	ctx = context.WithValue(ctx, callStateKey{}, state)
+	mod, err := m.runtime.InstantiateModule(ctx, m.compiled, wazero.NewModuleConfig().WithName("").WithStartFunctions())
+	if err != nil {
+		return nil, err
+	}
+
+	fuel, ok := mod.ExportedGlobal(fuelExport).(api.MutableGlobal)
+	if !ok { // unreachable: instrument always adds the export
+		mod.Close(ctx)
+		return nil, errors.New("module has no fuel global")
+	}
+	fuel.Set(uint64(m.limits.Fuel))
+
+	return &instance{mod: mod, fuel: fuel, state: state}, nil
+}
+
+// call calls an exported function, translating traps caused by running out
+// of fuel or by host function errors.
+func (i *instance) call(ctx context.Context, name string, params ...uint64) (uint64, error) {
+	ctx = context.WithValue(ctx, callStateKey{}, i.state)
+	res, err := i.mod.ExportedFunction(name).Call(ctx, params...)
+	if i.state.err != nil {
+		return 0, i.state.err
+	}
+	if int64(i.fuel.Get()) < 0 {
+		return 0, ErrOutOfFuel
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res[0], nil
+}
+
+// write passes data to the module, returning its pointer and length.
+func (i *instance) write(ctx context.Context, data []byte) (uint64, uint64, error) {
+	ptr, err := i.call(ctx, allocExport, uint64(len(data)))
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s: %w", allocExport, err)
+	}
+	if !i.mod.Memory().Write(uint32(ptr), data) {
+		return 0, 0, fmt.Errorf("%s returned an out of bounds pointer", allocExport)
+	}
+	return ptr, uint64(len(data)), nil
+}
+
+// read reads a packed pointer and length from the module's memory.
+func (i *instance) read(packed uint64) ([]byte, error) {
+	if packed == 0 {
+		return nil, nil
+	}
+	data, ok := i.mod.Memory().Read(uint32(packed>>32), uint32(packed))
+	if !ok {
+		return nil, errors.New("module returned an out of bounds pointer")
+	}
+	return data, nil
+}
+
+func (m *Module) readManifest(ctx context.Context) (*Manifest, error) {
+	inst, err := m.instantiate(ctx, &callState{})
+	if err != nil {
+		return nil, err
+	}
+	defer inst.mod.Close(ctx)
+
+	res, err := inst.call(ctx, manifestExport)
+	if err != nil {
+		return nil, err
+	}
+	data, err := inst.read(res)
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// handler returns the precompile handler for an exported method.
+func (m *Module) handler(export string, returns *precompiles.MethodReturn) precompiles.HandlerFunc {
+	return func(ctx *common.EngineContext, app *common.App, inputs []any, resultFn func([]any) error) error {
+		goCtx := context.Background()
+		if ctx.TxContext != nil && ctx.TxContext.Ctx != nil {
+			goCtx = ctx.TxContext.Ctx
+		}
+
+		args, err := json.Marshal(inputs)
+		if err != nil {
+			return err
+		}
+
+		inst, err := m.instantiate(goCtx, &callState{engineCtx: ctx, app: app})
+		if err != nil {
+			return err
+		}
+		defer inst.mod.Close(goCtx)
+
+		ptr, n, err := inst.write(goCtx, args)
+		if err != nil {
+			return err
+		}
+		packed, err := inst.call(goCtx, export, ptr, n)
+		if err != nil {
+			return fmt.Errorf("%s.%s: %w", m.name, export, err)
+		}
+		data, err := inst.read(packed)
+		if err != nil || data == nil {
+			return err
+		}
+
+		var res methodResult
+		if err := json.Unmarshal(data, &res); err != nil {
+			return fmt.Errorf("%s.%s: invalid result: %w", m.name, export, err)
+		}
+		if res.Error != "" {
+			return fmt.Errorf("%s.%s: %s", m.name, export, res.Error)
+		}
+		if returns == nil {
+			return nil
+		}
+
+		for _, row := range res.Rows {
+			if len(row) != len(returns.Fields) {
+				return fmt.Errorf("%s.%s: expected %d columns, got %d", m.name, export, len(returns.Fields), len(row))
+			}
+			vals := make([]any, len(row))
+			for j, raw := range row {
+				if vals[j], err = decodeValue(raw, returns.Fields[j].Type); err != nil {
+					return fmt.Errorf("%s.%s: column %s: %w", m.name, export, returns.Fields[j].Name, err)
+				}
+			}
+			if err := resultFn(vals); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// abort stops the execution of the module with an error.
+func abort(state *callState, err error) {
+	state.err = err
+	panic(err)
+}
+
+// consume consumes fuel from the calling module, aborting the call if it runs
+// out.
+func consume(mod api.Module, state *callState, amount int64) {
+	fuel := mod.ExportedGlobal(fuelExport).(api.MutableGlobal)
+	remaining := int64(fuel.Get()) - amount
+	fuel.Set(uint64(remaining))
+	if remaining < 0 {
+		abort(state, ErrOutOfFuel)
+	}
+}
+
+func readInput(mod api.Module, state *callState, ptr, n uint64) []byte {
+	data, ok := mod.Memory().Read(uint32(ptr), uint32(n))
+	if !ok {
+		abort(state, errors.New("host call with out of bounds pointer"))
+	}
+	return data
+}
+
+// writeOutput passes a host function result to the module.
+func writeOutput(ctx context.Context, mod api.Module, state *callState, v any) uint64 {
+	data, err := json.Marshal(v)
+	if err != nil {
+		abort(state, err)
+	}
+
+	res, err := mod.ExportedFunction(allocExport).Call(ctx, uint64(len(data)))
+	if state.err != nil {
+		panic(state.err)
+	}
+	if int64(mod.ExportedGlobal(fuelExport).Get()) < 0 {
+		abort(state, ErrOutOfFuel)
+	}
+	if err != nil {
+		abort(state, fmt.Errorf("%s: %w", allocExport, err))
+	}
+	if !mod.Memory().Write(uint32(res[0]), data) {
+		abort(state, fmt.Errorf("%s returned an out of bounds pointer", allocExport))
+	}
+	return res[0]<<32 | uint64(len(data))
+}
+
+func (m *Module) hostQuery(ctx context.Context, mod api.Module, stack []uint64) {
+	state := ctx.Value(callStateKey{}).(*callState)
+	if state.app == nil {
+		abort(state, errors.New("query is not available outside of a method call"))
+	}
+	consume(mod, state, m.limits.QueryFuel)
+
+	var req queryRequest
+	if err := json.Unmarshal(readInput(mod, state, stack[0], stack[1]), &req); err != nil {
+		stack[0] = writeOutput(ctx, mod, state, &callResult{Error: err.Error()})
+		return
+	}
+
+	params := make(map[string]any, len(req.Params))
+	for k, raw := range req.Params {
+		v, err := decodeParam(raw)
+		if err != nil {
+			stack[0] = writeOutput(ctx, mod, state, &callResult{Error: fmt.Sprintf("param %s: %v", k, err)})
+			return
+		}
+		params[k] = v
+	}
+
+	res := &callResult{}
+	err := state.app.Engine.Execute(state.engineCtx, state.app.DB, req.SQL, params, func(row *common.Row) error {
+		consume(mod, state, m.limits.RowFuel)
+		if res.Columns == nil {
+			res.Columns = row.ColumnNames
+		}
+		res.Rows = append(res.Rows, row.Values)
+		return nil
+	})
+	if err != nil {
+		res = &callResult{Error: err.Error()}
+	}
+
+	stack[0] = writeOutput(ctx, mod, state, res)
+}
+
+func (m *Module) hostNotice(ctx context.Context, mod api.Module, stack []uint64) {
+	state := ctx.Value(callStateKey{}).(*callState)
+	msg := readInput(mod, state, stack[0], stack[1])
+	if state.app != nil && state.app.Service != nil && state.app.Service.Logger != nil {
+		state.app.Service.Logger.Debug("wasm notice", "module", m.name, "message", string(msg))
+	}
+}
+
+var (
+	registryMu sync.Mutex
+	registry   = make(map[string]*Module)
+	// pending holds the modules added by resolutions in the current block.
+	// They are registered when the block is committed, so that a rolled back
+	// block does not leave them registered.
+	pending = make(map[string]*Module)
+)
+
+// Register compiles a module and registers it as a precompile extension with
+// the given name. Registering the same code under the same name more than once
+// is a no-op, so that modules can be registered from both the genesis config
+// and the database on startup.
+func Register(ctx context.Context, name string, code []byte) error {
+	name = strings.ToLower(name)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	mod, err := compileNew(ctx, name, code)
+	if err != nil || mod == nil {
+		return err
+	}
+
+	if err = register(name, mod); err != nil {
+		mod.Close(ctx)
+		return err
+	}
+	return nil
+}
+
+// registerOnCommit compiles a module and registers it as a precompile
+// extension with the given name when the current block is committed with
+// CommitRegistrations.
+func registerOnCommit(ctx context.Context, name string, code []byte) error {
+	name = strings.ToLower(name)
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	mod, err := compileNew(ctx, name, code)
+	if err != nil || mod == nil {
+		return err
+	}
+
+	pending[name] = mod
+	return nil
+}
+
+// CommitRegistrations registers the modules added in the block being
+// committed. It must be called after the block's changes are committed to the
+// database.
+func CommitRegistrations() error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	var errs []error
+	for name, mod := range pending {
+		if err := register(name, mod); err != nil {
+			mod.Close(context.Background())
+			errs = append(errs, fmt.Errorf("wasm precompile %s: %w", name, err))
+		}
+	}
+	clear(pending)
+
+	return errors.Join(errs...)
+}
+
+// RollbackRegistrations discards the modules added in the block being rolled
+// back.
+func RollbackRegistrations() {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	for _, mod := range pending {
+		mod.Close(context.Background())
+	}
+	clear(pending)
+}
+
+// compileNew compiles a module that is not yet registered or pending under the
+// given name. It returns nil if the same code already is. registryMu must be
+// held.
+func compileNew(ctx context.Context, name string, code []byte) (*Module, error) {
+	for _, mods := range []map[string]*Module{registry, pending} {
+		if existing, ok := mods[name]; ok {
+			if existing.hash == sha256.Sum256(code) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("wasm precompile %s is already registered with different code", name)
+		}
+	}
+	if _, ok := precompiles.RegisteredPrecompiles()[name]; ok {
+		return nil, fmt.Errorf("precompile %s is already registered", name)
+	}
+
+	return Compile(ctx, name, code, DefaultLimits)
+}
+
+// register registers a compiled module as a precompile extension. registryMu
+// must be held.
+func register(name string, mod *Module) error {
+	err := precompiles.RegisterInitializer(name, func(ctx context.Context, service *common.Service, db sql.DB, alias string, metadata map[string]any) (precompiles.Precompile, error) {
+		return mod.Precompile(), nil
+	})
+	if err != nil {
+		return err
+	}
+
+	registry[name] = mod
+	return nil
+}
@@ -0,0 +1,368 @@
+package wasm
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/common"
+	"github.com/kwilteam/kwil-db/core/types"
+	"github.com/kwilteam/kwil-db/extensions/precompiles"
+	"github.com/kwilteam/kwil-db/node/types/sql"
+)
+
+// The test module is assembled by hand, since there is no WebAssembly
+// toolchain available to the tests. It is equivalent to:
+//
+//	(module
+//	  (import "kwil" "query" (func $query (param i32 i32) (result i64)))
+//	  (memory (export "memory") 1)
+//	  (global $heap (mut i32) (i32.const 4096))
+//	  (func (export "kwil_alloc") (param i32) (result i32)
+//	    global.get $heap
+//	    global.get $heap
+//	    local.get 0
+//	    i32.add
+//	    global.set $heap)
+//	  (func (export "kwil_manifest") (result i64) (i64.const <manifest>))
+//	  (func (export "answer") (param i32 i32) (result i64) (i64.const <answerResult>))
+//	  (func (export "spin") (param i32 i32) (result i64) (loop (br 0)) (i64.const 0))
+//	  (func (export "lookup") (param i32 i32) (result i64)
+//	    (call $query (i32.const <query>) (i32.const <len(query)>)))
+//	  (func (export "fill") (param i32 i32) (result i64)
+//	    (memory.fill (i32.const 0) (i32.const 0) (i32.const 65536)) (i64.const 0))
+//	  (func (export "grow") (param i32 i32) (result i64)
+//	    (drop (memory.grow (i32.const 1))) (i64.const 0))
+//	  (data (i32.const 0) "<manifest>")
+//	  ...)
+const (
+	testManifest = `{"methods":[` +
+		`{"name":"answer","modifiers":["PUBLIC","VIEW"],"returns":{"fields":[{"name":"n","type":"int8"}]}},` +
+		`{"name":"spin","modifiers":["PUBLIC"]},` +
+		`{"name":"lookup","modifiers":["PUBLIC","VIEW"],"returns":{"table":true,"fields":[{"name":"name","type":"text"},{"name":"tags","type":"text[]","nullable":true}]}},` +
+		`{"name":"fill","modifiers":["PUBLIC"]},` +
+		`{"name":"grow","modifiers":["PUBLIC"]}` +
+		`]}`
+	testAnswerResult = `{"rows":[[42]]}`
+	testQuery        = `{"sql":"SELECT name, tags FROM users WHERE id = $id","params":{"$id":7,"$b":{"bytea":"AQI="}}}`
+
+	manifestOffset = 0
+	answerOffset   = 2048
+	queryOffset    = 3072
+)
+
+const (
+	i32 = 0x7F
+	i64 = 0x7E
+)
+
+func testModule(imports ...[2]string) []byte {
+	if imports == nil {
+		imports = [][2]string{{"kwil", "query"}}
+	}
+
+	var typeSec []byte
+	typeSec = vec(typeSec, 3)
+	typeSec = append(typeSec, 0x60, 2, i32, i32, 1, i64) // 0: (i32, i32) -> i64
+	typeSec = append(typeSec, 0x60, 1, i32, 1, i32)      // 1: (i32) -> i32
+	typeSec = append(typeSec, 0x60, 0, 1, i64)           // 2: () -> i64
+
+	var imps []byte
+	imps = vec(imps, len(imports))
+	for _, imp := range imports {
+		imps = name(imps, imp[0])
+		imps = name(imps, imp[1])
+		imps = append(imps, 0x00, 0) // func, type 0
+	}
+
+	funcs := []byte{7, 1, 2, 0, 0, 0, 0, 0}
+
+	memory := []byte{1, 0x00, 1} // one memory, min 1 page
+
+	var globals []byte
+	globals = vec(globals, 1)
+	globals = append(globals, i32, 0x01, 0x41)
+	globals = sleb(globals, 4096)
+	globals = append(globals, 0x0B)
+
+	// imported functions come first in the function index space
+	fn := uint32(len(imports))
+	var exports []byte
+	exports = vec(exports, 8)
+	exports = append(name(exports, "memory"), 0x02, 0)
+	for i, n := range []string{"kwil_alloc", "kwil_manifest", "answer", "spin", "lookup", "fill", "grow"} {
+		exports = append(name(exports, n), 0x00)
+		exports = binary.AppendUvarint(exports, uint64(fn+uint32(i)))
+	}
+
+	packed := func(offset, length int) int64 {
+		return int64(offset)<<32 | int64(length)
+	}
+
+	var code []byte
+	code = vec(code, 7)
+	code = body(code, []byte{0x23, 0, 0x23, 0, 0x20, 0, 0x6A, 0x24, 0})
+	code = body(code, sleb([]byte{0x42}, packed(manifestOffset, len(testManifest))))
+	code = body(code, sleb([]byte{0x42}, packed(answerOffset, len(testAnswerResult))))
+	code = body(code, []byte{0x03, 0x40, 0x0C, 0, 0x0B, 0x42, 0})
+	lookup := sleb([]byte{0x41}, queryOffset)
+	lookup = sleb(append(lookup, 0x41), int64(len(testQuery)))
+	code = body(code, append(lookup, 0x10, 0)) // call $query
+	fill := append([]byte{0x41, 0, 0x41, 0, 0x41}, sleb(nil, 65536)...)
+	code = body(code, append(fill, 0xFC, 11, 0, 0x42, 0))      // memory.fill
+	code = body(code, []byte{0x41, 1, 0x40, 0, 0x1A, 0x42, 0}) // memory.grow, drop
+
+	var data []byte
+	data = vec(data, 3)
+	data = segment(data, manifestOffset, testManifest)
+	data = segment(data, answerOffset, testAnswerResult)
+	data = segment(data, queryOffset, testQuery)
+
+	bin := append([]byte{}, wasmHeader...)
+	bin = section(bin, 1, typeSec)
+	bin = section(bin, 2, imps)
+	bin = section(bin, 3, funcs)
+	bin = section(bin, 5, memory)
+	bin = section(bin, 6, globals)
+	bin = section(bin, 7, exports)
+	bin = section(bin, 10, code)
+	return section(bin, 11, data)
+}
+
+func vec(b []byte, n int) []byte {
+	return binary.AppendUvarint(b, uint64(n))
+}
+
+func name(b []byte, s string) []byte {
+	return append(vec(b, len(s)), s...)
+}
+
+func sleb(b []byte, v int64) []byte {
+	for {
+		c := byte(v & 0x7F)
+		v >>= 7
+		if (v == 0 && c&0x40 == 0) || (v == -1 && c&0x40 != 0) {
+			return append(b, c)
+		}
+		b = append(b, c|0x80)
+	}
+}
+
+func body(b []byte, instrs []byte) []byte {
+	fn := append([]byte{0}, instrs...) // no locals
+	fn = append(fn, 0x0B)
+	return append(vec(b, len(fn)), fn...)
+}
+
+func segment(b []byte, offset int, s string) []byte {
+	b = sleb(append(b, 0x00, 0x41), int64(offset))
+	return name(append(b, 0x0B), s)
+}
+
+func section(b []byte, id byte, payload []byte) []byte {
+	return append(vec(append(b, id), len(payload)), payload...)
+}
+
+// fakeEngine records the statements executed through it and returns fixed
+// rows.
+type fakeEngine struct {
+	common.Engine
+	stmt   string
+	params map[string]any
+	rows   [][]any
+}
+
+func (f *fakeEngine) Execute(ctx *common.EngineContext, db sql.DB, statement string, params map[string]any, fn func(*common.Row) error) error {
+	f.stmt, f.params = statement, params
+	for _, r := range f.rows {
+		err := fn(&common.Row{ColumnNames: []string{"name", "tags"}, Values: r})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func method(t *testing.T, m *Module, name string) precompiles.Method {
+	for _, meth := range m.Methods() {
+		if meth.Name == name {
+			return meth
+		}
+	}
+	t.Fatalf("method %s not found", name)
+	return precompiles.Method{}
+}
+
+func call(m precompiles.Method, app *common.App) ([][]any, error) {
+	engineCtx := &common.EngineContext{TxContext: &common.TxContext{Ctx: context.Background()}}
+	var rows [][]any
+	err := m.Handler(engineCtx, app, nil, func(row []any) error {
+		rows = append(rows, row)
+		return nil
+	})
+	return rows, err
+}
+
+func Test_Module(t *testing.T) {
+	ctx := context.Background()
+	m, err := Compile(ctx, "Test", testModule(), DefaultLimits)
+	require.NoError(t, err)
+	defer m.Close(ctx)
+
+	assert.Equal(t, "test", m.Name())
+	require.Len(t, m.Methods(), 5)
+
+	answer := method(t, m, "answer")
+	assert.Equal(t, precompiles.Modifiers{precompiles.PUBLIC, precompiles.VIEW}, precompiles.Modifiers(answer.AccessModifiers))
+	require.NotNil(t, answer.Returns)
+	assert.Equal(t, types.IntType, answer.Returns.Fields[0].Type)
+
+	t.Run("result", func(t *testing.T) {
+		rows, err := call(answer, &common.App{})
+		require.NoError(t, err)
+		assert.Equal(t, [][]any{{int64(42)}}, rows)
+	})
+
+	t.Run("out of fuel", func(t *testing.T) {
+		_, err := call(method(t, m, "spin"), &common.App{})
+		require.ErrorIs(t, err, ErrOutOfFuel)
+	})
+
+	t.Run("query", func(t *testing.T) {
+		eng := &fakeEngine{rows: [][]any{
+			{"alice", []*string{strPtr("a"), nil}},
+			{"bob", nil},
+		}}
+		rows, err := call(method(t, m, "lookup"), &common.App{Engine: eng})
+		require.NoError(t, err)
+
+		assert.Equal(t, "SELECT name, tags FROM users WHERE id = $id", eng.stmt)
+		assert.Equal(t, map[string]any{"$id": int64(7), "$b": []byte{1, 2}}, eng.params)
+		assert.Equal(t, [][]any{
+			{"alice", []*string{strPtr("a"), nil}},
+			{"bob", nil},
+		}, rows)
+	})
+}
+
+func Test_QueryFuel(t *testing.T) {
+	ctx := context.Background()
+	limits := DefaultLimits
+	limits.QueryFuel = limits.Fuel // more than is left after entering the function
+	m, err := Compile(ctx, "test", testModule(), limits)
+	require.NoError(t, err)
+	defer m.Close(ctx)
+
+	_, err = call(method(t, m, "lookup"), &common.App{Engine: &fakeEngine{}})
+	require.ErrorIs(t, err, ErrOutOfFuel)
+}
+
+func Test_MemoryFuel(t *testing.T) {
+	ctx := context.Background()
+	limits := DefaultLimits
+	limits.Fuel = 1000 // less than filling or growing by a page consumes
+	m, err := Compile(ctx, "test", testModule(), limits)
+	require.NoError(t, err)
+	defer m.Close(ctx)
+
+	for _, meth := range []string{"fill", "grow"} {
+		_, err = call(method(t, m, meth), &common.App{})
+		require.ErrorIs(t, err, ErrOutOfFuel, meth)
+	}
+
+	limits.Fuel = 2000
+	m2, err := Compile(ctx, "test", testModule(), limits)
+	require.NoError(t, err)
+	defer m2.Close(ctx)
+
+	for _, meth := range []string{"fill", "grow"} {
+		_, err = call(method(t, m2, meth), &common.App{})
+		require.NoError(t, err, meth)
+	}
+}
+
+func Test_RejectHostImports(t *testing.T) {
+	ctx := context.Background()
+	_, err := Compile(ctx, "test", testModule([2]string{"wasi_snapshot_preview1", "clock_time_get"}), DefaultLimits)
+	require.ErrorIs(t, err, ErrHostImport)
+}
+
+func Test_InstrumentRejectsStart(t *testing.T) {
+	bin := section(append([]byte{}, wasmHeader...), sectionStart, []byte{0})
+	_, err := instrument(bin)
+	require.Error(t, err)
+}
+
+// Test_RejectFuelGlobals checks that a module cannot access the fuel globals,
+// which would be valid after instrumentation, with:
+//
+//	(module
+//	  (global (mut i64) (i64.const 0))
+//	  (func (loop (global.set 1 (i64.const 1000000)) (br 0))))
+func Test_RejectFuelGlobals(t *testing.T) {
+	ctx := context.Background()
+	module := func(instrs []byte, exports []byte) []byte {
+		bin := append([]byte{}, wasmHeader...)
+		bin = section(bin, 1, []byte{1, 0x60, 0, 0}) // () -> ()
+		bin = section(bin, 3, []byte{1, 0})
+		bin = section(bin, 6, []byte{1, i64, 0x01, 0x42, 0, 0x0B})
+		if exports != nil {
+			bin = section(bin, 7, exports)
+		}
+		return section(bin, 10, body(vec(nil, 1), instrs))
+	}
+
+	refill := append(sleb([]byte{0x03, 0x40, 0x42}, 1_000_000), 0x24, 1, 0x0C, 0, 0x0B)
+	_, err := Compile(ctx, "test", module(refill, nil), DefaultLimits)
+	require.ErrorContains(t, err, "global index 1 out of range")
+
+	// the module's own global may be set
+	_, err = instrument(module([]byte{0x42, 1, 0x24, 0}, nil))
+	require.NoError(t, err)
+
+	// nor can the operand global be read, or the fuel global exported
+	_, err = instrument(module([]byte{0x23, 2, 0x1A}, nil))
+	require.Error(t, err)
+	_, err = instrument(module([]byte{0x01}, append(name(vec(nil, 1), "fuel"), externGlobal, 1)))
+	require.ErrorContains(t, err, "global index 1 out of range")
+}
+
+func Test_PrecompileDeclaration(t *testing.T) {
+	decl := PrecompileDeclaration{Name: "test", Module: testModule()}
+	bts, err := decl.MarshalBinary()
+	require.NoError(t, err)
+
+	var decl2 PrecompileDeclaration
+	require.NoError(t, decl2.UnmarshalBinary(bts))
+	assert.Equal(t, decl, decl2)
+}
+
+func Test_RegisterOnCommit(t *testing.T) {
+	ctx := context.Background()
+	registered := func(name string) bool {
+		_, ok := precompiles.RegisteredPrecompiles()[name]
+		return ok
+	}
+
+	// a rolled back block does not register its modules
+	require.NoError(t, registerOnCommit(ctx, "RolledBack", testModule()))
+	assert.False(t, registered("rolledback"))
+	RollbackRegistrations()
+	require.NoError(t, CommitRegistrations())
+	assert.False(t, registered("rolledback"))
+
+	require.NoError(t, registerOnCommit(ctx, "committed", testModule()))
+	err := registerOnCommit(ctx, "committed", testModule([2]string{"kwil", "notice"}))
+	require.ErrorContains(t, err, "different code")
+	assert.False(t, registered("committed"))
+	require.NoError(t, CommitRegistrations())
+	assert.True(t, registered("committed"))
+
+	// registering the same code again is a no-op
+	require.NoError(t, Register(ctx, "committed", testModule()))
+}
+
+func strPtr(s string) *string { return &s }
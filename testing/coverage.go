@@ -0,0 +1,341 @@
+package testing
+
+import (
+	"bufio"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/kwilteam/kwil-db/node/engine/interpreter"
+)
+
+// Coverage collects the statement and branch coverage of the actions created
+// by the seed scripts and statements of schema tests. A single Coverage can be
+// shared by several tests, in which case their coverage is added up.
+type Coverage struct {
+	cov *interpreter.Coverage
+
+	mu sync.Mutex
+	// sources maps each seed script path, or seed statement identifier, to
+	// its text.
+	sources map[string]string
+	// order is the order in which sources were first seen.
+	order []string
+}
+
+// NewCoverage creates a new coverage collector.
+func NewCoverage() *Coverage {
+	return &Coverage{
+		cov:     interpreter.NewCoverage(),
+		sources: make(map[string]string),
+	}
+}
+
+// setSource sets the source that actions created afterwards are attributed
+// to.
+func (c *Coverage) setSource(name, text string) {
+	c.mu.Lock()
+	if _, ok := c.sources[name]; !ok {
+		c.order = append(c.order, name)
+	}
+	c.sources[name] = text
+	c.mu.Unlock()
+
+	c.cov.SetSource(name)
+}
+
+// lineOffset returns the number of lines that the parser skips at the start of
+// a source, since it trims leading whitespace.
+func lineOffset(text string) int {
+	trimmed := strings.TrimLeft(text, " \t\r\n")
+	return strings.Count(text[:len(text)-len(trimmed)], "\n")
+}
+
+// fileCoverage is the coverage of the actions of a single source, with lines
+// adjusted to the source text.
+type fileCoverage struct {
+	Source  string
+	Text    string
+	Actions []*interpreter.ActionCoverage
+}
+
+// files returns the coverage grouped by source, in the order the sources were
+// seen. Actions that were not created by a known source are omitted.
+func (c *Coverage) files() []*fileCoverage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	bySource := make(map[string]*fileCoverage)
+	var files []*fileCoverage
+	for _, name := range c.order {
+		f := &fileCoverage{Source: name, Text: c.sources[name]}
+		bySource[name] = f
+		files = append(files, f)
+	}
+
+	for _, act := range c.cov.Actions() {
+		f, ok := bySource[act.Source]
+		if !ok {
+			continue
+		}
+
+		offset := lineOffset(f.Text)
+		act.Line += offset
+		for _, s := range act.Statements {
+			s.Line += offset
+		}
+		for _, b := range act.Branches {
+			b.Line += offset
+		}
+		f.Actions = append(f.Actions, act)
+	}
+
+	return files
+}
+
+// CoverageSummary summarizes the coverage of the tested actions.
+type CoverageSummary struct {
+	Actions []*ActionCoverageSummary `json:"actions"`
+	// Statements is the total number of statements, and StatementsCovered
+	// the number that were executed at least once.
+	Statements        int `json:"statements"`
+	StatementsCovered int `json:"statements_covered"`
+	// Branches is the total number of branches, and BranchesCovered the
+	// number that were taken at least once.
+	Branches        int `json:"branches"`
+	BranchesCovered int `json:"branches_covered"`
+}
+
+// ActionCoverageSummary summarizes the coverage of a single action.
+type ActionCoverageSummary struct {
+	Source            string `json:"source"`
+	Namespace         string `json:"namespace"`
+	Name              string `json:"name"`
+	Line              int    `json:"line"`
+	Calls             int64  `json:"calls"`
+	Statements        int    `json:"statements"`
+	StatementsCovered int    `json:"statements_covered"`
+	Branches          int    `json:"branches"`
+	BranchesCovered   int    `json:"branches_covered"`
+}
+
+// Summary summarizes the coverage collected so far.
+func (c *Coverage) Summary() *CoverageSummary {
+	sum := &CoverageSummary{}
+	for _, f := range c.files() {
+		for _, act := range f.Actions {
+			a := &ActionCoverageSummary{
+				Source:     act.Source,
+				Namespace:  act.Namespace,
+				Name:       act.Name,
+				Line:       act.Line,
+				Calls:      act.Calls,
+				Statements: len(act.Statements),
+				Branches:   len(act.Branches),
+			}
+			for _, s := range act.Statements {
+				if s.Hits > 0 {
+					a.StatementsCovered++
+				}
+			}
+			for _, b := range act.Branches {
+				if b.Hits > 0 {
+					a.BranchesCovered++
+				}
+			}
+
+			sum.Actions = append(sum.Actions, a)
+			sum.Statements += a.Statements
+			sum.StatementsCovered += a.StatementsCovered
+			sum.Branches += a.Branches
+			sum.BranchesCovered += a.BranchesCovered
+		}
+	}
+	return sum
+}
+
+// percent formats the ratio of covered to total as a percentage.
+func percent(covered, total int) string {
+	if total == 0 {
+		return "100.0%"
+	}
+	return fmt.Sprintf("%.1f%%", float64(covered)*100/float64(total))
+}
+
+// String formats the summary as a table with one line per action.
+func (s *CoverageSummary) String() string {
+	var sb strings.Builder
+	for _, a := range s.Actions {
+		name := a.Name
+		if a.Namespace != "" {
+			name = a.Namespace + "." + a.Name
+		}
+		fmt.Fprintf(&sb, "%s:%d\t%s\tstatements %s (%d/%d)\tbranches %s (%d/%d)\n", a.Source, a.Line, name,
+			percent(a.StatementsCovered, a.Statements), a.StatementsCovered, a.Statements,
+			percent(a.BranchesCovered, a.Branches), a.BranchesCovered, a.Branches)
+	}
+	fmt.Fprintf(&sb, "total\tstatements %s (%d/%d)\tbranches %s (%d/%d)", percent(s.StatementsCovered, s.Statements),
+		s.StatementsCovered, s.Statements, percent(s.BranchesCovered, s.Branches), s.BranchesCovered, s.Branches)
+	return sb.String()
+}
+
+// lineHits returns the hits of each line of a source that has statements. If
+// a line has several statements, the most executed one is used.
+func (f *fileCoverage) lineHits() map[int]int64 {
+	hits := make(map[int]int64)
+	for _, act := range f.Actions {
+		for _, s := range act.Statements {
+			if h, ok := hits[s.Line]; !ok || s.Hits > h {
+				hits[s.Line] = s.Hits
+			}
+		}
+	}
+	return hits
+}
+
+// WriteLCOV writes the coverage in the LCOV tracefile format, which is
+// understood by most coverage tools and editors.
+func (c *Coverage) WriteLCOV(w io.Writer, testName string) error {
+	bw := bufio.NewWriter(w)
+	for _, f := range c.files() {
+		if len(f.Actions) == 0 {
+			continue
+		}
+
+		fmt.Fprintf(bw, "TN:%s\n", testName)
+		fmt.Fprintf(bw, "SF:%s\n", f.Source)
+
+		fnHit := 0
+		for _, act := range f.Actions {
+			fmt.Fprintf(bw, "FN:%d,%s\n", act.Line, lcovFuncName(act))
+		}
+		for _, act := range f.Actions {
+			fmt.Fprintf(bw, "FNDA:%d,%s\n", act.Calls, lcovFuncName(act))
+			if act.Calls > 0 {
+				fnHit++
+			}
+		}
+		fmt.Fprintf(bw, "FNF:%d\nFNH:%d\n", len(f.Actions), fnHit)
+
+		brFound, brHit := 0, 0
+		for _, act := range f.Actions {
+			// branches of blocks that were never reached are reported as "-"
+			blockHits := make(map[int]int64)
+			for _, b := range act.Branches {
+				blockHits[b.Block] += b.Hits
+			}
+			for _, b := range act.Branches {
+				taken := "-"
+				if blockHits[b.Block] > 0 {
+					taken = fmt.Sprint(b.Hits)
+				}
+				fmt.Fprintf(bw, "BRDA:%d,%d,%d,%s\n", b.Line, b.Block, b.Branch, taken)
+				brFound++
+				if b.Hits > 0 {
+					brHit++
+				}
+			}
+		}
+		fmt.Fprintf(bw, "BRF:%d\nBRH:%d\n", brFound, brHit)
+
+		hits := f.lineHits()
+		lineHit := 0
+		for line := range maxLine(hits) + 1 {
+			h, ok := hits[line]
+			if !ok {
+				continue
+			}
+			fmt.Fprintf(bw, "DA:%d,%d\n", line, h)
+			if h > 0 {
+				lineHit++
+			}
+		}
+		fmt.Fprintf(bw, "LF:%d\nLH:%d\n", len(hits), lineHit)
+		fmt.Fprintln(bw, "end_of_record")
+	}
+	return bw.Flush()
+}
+
+// lcovFuncName returns the function name of an action in LCOV reports.
+func lcovFuncName(act *interpreter.ActionCoverage) string {
+	if act.Namespace == "" {
+		return act.Name
+	}
+	return act.Namespace + "." + act.Name
+}
+
+func maxLine(hits map[int]int64) int {
+	m := 0
+	for line := range hits {
+		m = max(m, line)
+	}
+	return m
+}
+
+var coverageHTML = template.Must(template.New("coverage").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Kuneiform coverage</title>
+<style>
+body { font-family: sans-serif; }
+pre { font-family: monospace; line-height: 1.3; }
+.line { display: block; white-space: pre; }
+.num, .hits { display: inline-block; width: 4em; text-align: right; margin-right: 1em; color: #888; }
+.cov { background: #dfd; }
+.uncov { background: #fdd; }
+</style>
+</head>
+<body>
+<h1>Kuneiform coverage</h1>
+<pre>{{.Summary}}</pre>
+{{range .Files}}<h2>{{.Source}}</h2>
+<pre>{{range .Lines}}<span class="line {{.Class}}"><span class="num">{{.Num}}</span><span class="hits">{{.Hits}}</span>{{.Text}}</span>{{end}}</pre>
+{{end}}</body>
+</html>
+`))
+
+// WriteHTML writes a standalone HTML report that shows the sources of the
+// tested actions, with covered statements highlighted in green and uncovered
+// statements in red.
+func (c *Coverage) WriteHTML(w io.Writer) error {
+	type line struct {
+		Num   int
+		Hits  string
+		Class string
+		Text  string
+	}
+	type file struct {
+		Source string
+		Lines  []line
+	}
+
+	var files []file
+	for _, f := range c.files() {
+		if len(f.Actions) == 0 {
+			continue
+		}
+
+		hits := f.lineHits()
+		fl := file{Source: f.Source}
+		for i, text := range strings.Split(f.Text, "\n") {
+			l := line{Num: i + 1, Text: text}
+			if h, ok := hits[i+1]; ok {
+				l.Hits = fmt.Sprint(h)
+				l.Class = "uncov"
+				if h > 0 {
+					l.Class = "cov"
+				}
+			}
+			fl.Lines = append(fl.Lines, l)
+		}
+		files = append(files, fl)
+	}
+
+	return coverageHTML.Execute(w, map[string]any{
+		"Summary": c.Summary().String(),
+		"Files":   files,
+	})
+}
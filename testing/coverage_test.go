@@ -0,0 +1,30 @@
+package testing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LineOffset(t *testing.T) {
+	require.Equal(t, 0, lineOffset("CREATE ACTION a() public {}"))
+	require.Equal(t, 2, lineOffset("\n  \n\tCREATE ACTION a() public {}"))
+	require.Equal(t, 1, lineOffset("\r\nCREATE ACTION a() public {}\n\n"))
+	require.Equal(t, 0, lineOffset(""))
+}
+
+func Test_CoverageSummaryString(t *testing.T) {
+	sum := &CoverageSummary{
+		Actions: []*ActionCoverageSummary{
+			{Source: "a.sql", Namespace: "ns", Name: "act", Line: 3, Statements: 4, StatementsCovered: 3, Branches: 2, BranchesCovered: 1},
+		},
+		Statements:        4,
+		StatementsCovered: 3,
+		Branches:          2,
+		BranchesCovered:   1,
+	}
+	require.Equal(t, "a.sql:3\tns.act\tstatements 75.0% (3/4)\tbranches 50.0% (1/2)\n"+
+		"total\tstatements 75.0% (3/4)\tbranches 50.0% (1/2)", sum.String())
+
+	require.Equal(t, "100.0%", percent(0, 0))
+}
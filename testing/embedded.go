@@ -0,0 +1,118 @@
+package testing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/kwilteam/kwil-db/node/pg"
+)
+
+// EmbeddedPostgres configures a Postgres server that is started from locally
+// installed binaries (initdb and pg_ctl) for the duration of a test. It
+// allows tests to run without Docker or an already running database.
+type EmbeddedPostgres struct {
+	// BinDir is the directory containing the initdb and pg_ctl binaries.
+	// If empty, they are looked up in the PATH.
+	BinDir string
+	// Port is the port that the server listens on. If empty, 52854 is used.
+	Port string
+	// DataDir is the data directory of the server. If it already contains
+	// a database cluster, it is reused. If empty, a temporary directory is
+	// created and removed when the test finishes.
+	DataDir string
+}
+
+const (
+	embeddedUser = "kwild"
+	embeddedPort = "52854"
+)
+
+// runWithEmbeddedPostgres initializes and starts a Postgres server, runs fn
+// against it, and stops the server.
+func runWithEmbeddedPostgres(ctx context.Context, e *EmbeddedPostgres, logger Logger, fn func(context.Context, *pg.DB, Logger) error) (err error) {
+	port := e.Port
+	if port == "" {
+		port = embeddedPort
+	}
+
+	dataDir := e.DataDir
+	if dataDir == "" {
+		dataDir, err = os.MkdirTemp("", "kwil-test-pg-")
+		if err != nil {
+			return fmt.Errorf("error creating data directory: %w", err)
+		}
+		defer os.RemoveAll(dataDir)
+	}
+	dataDir, err = filepath.Abs(dataDir)
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(filepath.Join(dataDir, "PG_VERSION")); errors.Is(err, os.ErrNotExist) {
+		logger.Logf("initializing embedded Postgres in %s", dataDir)
+		out, err := exec.CommandContext(ctx, e.binary("initdb"), "-D", dataDir, "-U", embeddedUser,
+			"--auth=trust", "--encoding=UTF8").CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("error initializing database: %w: %s", err, out)
+		}
+	} else if err != nil {
+		return err
+	}
+
+	// the socket is placed in the data directory, since the default
+	// directory is often not writable by non-root users
+	serverOpts := strings.Join([]string{
+		"-p " + port,
+		"-k " + dataDir,
+		"-c listen_addresses=localhost",
+		"-c wal_level=logical",
+		"-c max_wal_senders=10",
+		"-c max_replication_slots=10",
+		"-c wal_sender_timeout=0",
+		"-c max_prepared_transactions=2",
+		"-c max_locks_per_transaction=4096",
+		"-c max_connections=128",
+	}, " ")
+
+	out, err := exec.CommandContext(ctx, e.binary("pg_ctl"), "-D", dataDir,
+		"-l", filepath.Join(dataDir, "postgres.log"), "-w", "-o", serverOpts, "start").CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("error starting embedded Postgres: %w: %s", err, out)
+	}
+	logger.Logf("started embedded Postgres on port %s", port)
+
+	defer func() {
+		// the context may be cancelled, but the server must be stopped anyways
+		out, err2 := exec.Command(e.binary("pg_ctl"), "-D", dataDir, "-m", "fast", "-w", "stop").CombinedOutput()
+		if err2 != nil {
+			err = errors.Join(err, fmt.Errorf("error stopping embedded Postgres: %w: %s", err2, out))
+		}
+	}()
+
+	db, err := connectWithRetry(ctx, &ConnConfig{
+		Host:   "localhost",
+		Port:   port,
+		User:   embeddedUser,
+		DBName: "postgres",
+	}, 10)
+	if err != nil {
+		return fmt.Errorf("error connecting to database: %w", err)
+	}
+
+	defer db.Close()
+
+	return fn(ctx, db, logger)
+}
+
+// binary returns the path of a Postgres binary.
+func (e *EmbeddedPostgres) binary(name string) string {
+	if e.BinDir == "" {
+		return name
+	}
+	return filepath.Join(e.BinDir, name)
+}
@@ -0,0 +1,93 @@
+package testing
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/kwilteam/kwil-db/core/crypto"
+)
+
+func Test_Steps(t *testing.T) {
+	tc := TestCase{
+		Name:      "case",
+		Action:    "act",
+		Caller:    "0xabc",
+		Height:    3,
+		Timestamp: 100,
+		ChainID:   "chain",
+		Steps: []TestStep{
+			{Action: "first"},
+			{Name: "second", Action: "second", Caller: "0xdef", Height: 4},
+		},
+	}
+
+	steps := tc.steps()
+	require.Len(t, steps, 3)
+
+	require.Equal(t, "case", steps[0].Name)
+	require.Equal(t, "act", steps[0].Action)
+
+	require.Equal(t, "step 1", steps[1].Name)
+	require.Equal(t, "0xabc", steps[1].Caller)
+	require.EqualValues(t, 3, steps[1].Height)
+	require.EqualValues(t, 100, steps[1].Timestamp)
+	require.Equal(t, "chain", steps[1].ChainID)
+
+	require.Equal(t, "second", steps[2].Name)
+	require.Equal(t, "0xdef", steps[2].Caller)
+	require.EqualValues(t, 4, steps[2].Height)
+	require.EqualValues(t, 100, steps[2].Timestamp)
+
+	// without an action, only the steps are run
+	tc.Action = ""
+	require.Len(t, tc.steps(), 2)
+}
+
+func Test_BlockContext(t *testing.T) {
+	priv, err := crypto.GeneratePrivateKey(crypto.KeyTypeEd25519)
+	require.NoError(t, err)
+	pubHex := hex.EncodeToString(priv.Public().Bytes())
+
+	step := TestStep{
+		Height:    2,
+		Timestamp: 50,
+		ChainID:   "chain",
+		Proposer:  pubHex + "#ed25519",
+	}
+	blockCtx, err := step.blockContext()
+	require.NoError(t, err)
+	require.EqualValues(t, 2, blockCtx.Height)
+	require.EqualValues(t, 50, blockCtx.Timestamp)
+	require.Equal(t, "chain", blockCtx.ChainContext.ChainID)
+	require.True(t, priv.Public().Equals(blockCtx.Proposer))
+
+	step.Proposer = pubHex // not a secp256k1 key
+	_, err = step.blockContext()
+	require.Error(t, err)
+
+	step.Proposer = "zz"
+	_, err = step.blockContext()
+	require.Error(t, err)
+}
+
+func Test_CompareRows(t *testing.T) {
+	require.NoError(t, compareRows([][]any{{1, "a"}}, [][]any{{int64(1), "a"}}))
+	require.Error(t, compareRows([][]any{{1}}, nil))
+	require.Error(t, compareRows([][]any{{1}}, [][]any{{1, 2}}))
+	require.Error(t, compareRows([][]any{{1}}, [][]any{{int64(2)}}))
+
+	require.NoError(t, compareNotices([]string{"a", "b"}, []string{"a", "b"}))
+	require.Error(t, compareNotices([]string{"a"}, []string{"a", "b"}))
+	require.Error(t, compareNotices([]string{"a"}, []string{"b"}))
+}
+
+func Test_OptionsValid(t *testing.T) {
+	require.NoError(t, (&Options{UseTestContainer: true}).valid())
+	require.NoError(t, (&Options{Conn: &ConnConfig{}}).valid())
+	require.NoError(t, (&Options{EmbeddedPostgres: &EmbeddedPostgres{}}).valid())
+	require.Error(t, (&Options{}).valid())
+	require.Error(t, (&Options{UseTestContainer: true, EmbeddedPostgres: &EmbeddedPostgres{}}).valid())
+	require.Error(t, (&Options{Conn: &ConnConfig{}, EmbeddedPostgres: &EmbeddedPostgres{}}).valid())
+}
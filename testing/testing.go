@@ -19,6 +19,7 @@ import (
 
 	"github.com/kwilteam/kwil-db/common"
 	"github.com/kwilteam/kwil-db/config"
+	"github.com/kwilteam/kwil-db/core/crypto"
 	"github.com/kwilteam/kwil-db/core/log"
 	"github.com/kwilteam/kwil-db/node/accounts"
 	"github.com/kwilteam/kwil-db/node/engine/interpreter"
@@ -112,6 +113,7 @@ func (tc SchemaTest) Run(ctx context.Context, opts *Options) error {
 
 	// we read in the scripts of seed statements
 	seedStmts := []string{}
+	seedSources := []string{} // identifies each seed statement for coverage
 	for _, schemaFile := range tc.SeedScripts {
 		bts, err := os.ReadFile(schemaFile)
 		if err != nil {
@@ -121,9 +123,13 @@ func (tc SchemaTest) Run(ctx context.Context, opts *Options) error {
 		opts.Logger.Logf(`reading seed script "%s"`, schemaFile)
 
 		seedStmts = append(seedStmts, string(bts))
+		seedSources = append(seedSources, schemaFile)
 	}
 	// once we read in the scripts, we need to add the adhoc seed statements
 	seedStmts = append(seedStmts, tc.SeedStatements...)
+	for i := range tc.SeedStatements {
+		seedSources = append(seedSources, fmt.Sprintf("%s/seed_statements[%d]", tc.Name, i))
+	}
 
 	// connect to Postgres, and run each test case in its
 	// own transaction that is rolled back.
@@ -224,8 +230,16 @@ func (tc SchemaTest) Run(ctx context.Context, opts *Options) error {
 					Logger:   opts.Logger,
 				}
 
+				if opts.Coverage != nil {
+					interp.SetCoverage(opts.Coverage.cov)
+				}
+
 				// deploy schemas
-				for _, stmt := range seedStmts {
+				for j, stmt := range seedStmts {
+					if opts.Coverage != nil {
+						opts.Coverage.setSource(seedSources[j], stmt)
+					}
+
 					err = interp.Execute(&common.EngineContext{
 						TxContext: &common.TxContext{
 							Ctx:    ctx,
@@ -246,6 +260,11 @@ func (tc SchemaTest) Run(ctx context.Context, opts *Options) error {
 					}
 				}
 
+				// actions created by the tests themselves are not reported
+				if opts.Coverage != nil {
+					opts.Coverage.cov.SetSource("")
+				}
+
 				// run test function
 				err = testFn(ctx, platform)
 				if err != nil {
@@ -273,14 +292,16 @@ type TestFunc func(ctx context.Context, platform *Platform) error
 
 // TestCase executes an action against the database engine.
 // It can be given inputs, expected outputs, expected error types,
-// and expected error messages.
+// and expected error messages. It can also assert on the notices
+// logged by the action and on the contents of tables after it runs,
+// and chain further Steps that share database state.
 type TestCase struct {
 	// Name is a name that the test will be identified by if it fails.
 	Name string `json:"name"`
 	// Namespace is the name of the database schema to execute the
 	// action against.
 	Namespace string `json:"namespace"`
-	// Action is the name of the action.
+	// Action is the name of the action. If empty, only the Steps are run.
 	Action string `json:"action"`
 	// Args are the inputs to the action.
 	// If the action takes no parameters, this should be nil.
@@ -302,89 +323,334 @@ type TestCase struct {
 	// BlockHeight sets the blockheight for the test, accessible by
 	// the @height variable. If not set, it will default to 0.
 	Height int64 `json:"height"`
+	// Timestamp sets the block timestamp in seconds, accessible by the
+	// @block_timestamp variable. If not set, it will default to 0.
+	Timestamp int64 `json:"timestamp"`
+	// Proposer sets the public key of the block proposer that is given to
+	// extensions. It is hex encoded, optionally followed by "#" and the key
+	// type, which defaults to secp256k1.
+	Proposer string `json:"proposer"`
+	// ChainID sets the chain ID that is given to extensions.
+	ChainID string `json:"chain_id"`
+	// Notices are the messages that the action is expected to log with
+	// notice(), in order. If nil, the notices are not checked.
+	Notices []string `json:"notices"`
+	// State are queries that are run after the action to assert on the
+	// contents of tables.
+	State []StateAssertion `json:"state"`
+	// Steps are executed in order after the action, if any, and see the
+	// changes made by the action and by previous steps. Steps inherit the
+	// Caller, Height, Timestamp, Proposer, and ChainID of the test case
+	// when their own are not set.
+	Steps []TestStep `json:"steps"`
+}
+
+// TestStep is one execution in a multi-step TestCase.
+type TestStep struct {
+	// Name identifies the step if it fails. If empty, the step's index is
+	// used.
+	Name string `json:"name"`
+	// Namespace is the name of the database schema to execute the
+	// action against.
+	Namespace string `json:"namespace"`
+	// Action is the name of the action. If empty, the step only checks
+	// the State assertions.
+	Action string `json:"action"`
+	// Args are the inputs to the action.
+	Args []any `json:"args"`
+	// Returns are the expected outputs of the action.
+	Returns [][]any `json:"returns"`
+	// Err is the expected error type.
+	Err error `json:"-"`
+	// ErrMsg will search the error returned by the action for
+	// the given substring.
+	ErrMsg string `json:"error"`
+	// Caller sets the @caller and @signer of the step.
+	Caller string `json:"caller"`
+	// Height sets the @height of the step.
+	Height int64 `json:"height"`
+	// Timestamp sets the @block_timestamp of the step.
+	Timestamp int64 `json:"timestamp"`
+	// Proposer sets the block proposer of the step, in the same format as
+	// TestCase.Proposer.
+	Proposer string `json:"proposer"`
+	// ChainID sets the chain ID of the step.
+	ChainID string `json:"chain_id"`
+	// Notices are the messages that the action is expected to log with
+	// notice(), in order. If nil, the notices are not checked.
+	Notices []string `json:"notices"`
+	// State are queries that are run after the action to assert on the
+	// contents of tables.
+	State []StateAssertion `json:"state"`
+	// Rollback discards the changes made by the step after its assertions
+	// are checked, so that later steps do not see them. The changes of a
+	// step whose action fails are always discarded.
+	Rollback bool `json:"rollback"`
+}
+
+// StateAssertion runs a query and compares the returned rows to the
+// expected rows. The query is executed by the deployer, and can target
+// a namespace with a prefix, e.g. "{my_namespace}SELECT * FROM users".
+type StateAssertion struct {
+	// Query is the SQL query to run.
+	Query string `json:"query"`
+	// Params are the named parameters of the query, e.g. "$id".
+	Params map[string]any `json:"params"`
+	// Returns are the expected rows returned by the query.
+	Returns [][]any `json:"returns"`
+}
+
+// steps returns the steps of the test case, with the test case's own action
+// as the first step. Unset block context fields are inherited from the test
+// case.
+func (e *TestCase) steps() []TestStep {
+	var steps []TestStep
+	if e.Action != "" || len(e.State) > 0 {
+		steps = append(steps, TestStep{
+			Name:      e.Name,
+			Namespace: e.Namespace,
+			Action:    e.Action,
+			Args:      e.Args,
+			Returns:   e.Returns,
+			Err:       e.Err,
+			ErrMsg:    e.ErrMsg,
+			Caller:    e.Caller,
+			Height:    e.Height,
+			Timestamp: e.Timestamp,
+			Proposer:  e.Proposer,
+			ChainID:   e.ChainID,
+			Notices:   e.Notices,
+			State:     e.State,
+		})
+	}
+
+	for i, step := range e.Steps {
+		if step.Name == "" {
+			step.Name = fmt.Sprintf("step %d", i+1)
+		}
+		if step.Caller == "" {
+			step.Caller = e.Caller
+		}
+		if step.Height == 0 {
+			step.Height = e.Height
+		}
+		if step.Timestamp == 0 {
+			step.Timestamp = e.Timestamp
+		}
+		if step.Proposer == "" {
+			step.Proposer = e.Proposer
+		}
+		if step.ChainID == "" {
+			step.ChainID = e.ChainID
+		}
+		steps = append(steps, step)
+	}
+
+	return steps
 }
 
 // run runs the Execution as a TestFunc
 func (e *TestCase) runExecution(ctx context.Context, platform *Platform) error {
+	for _, step := range e.steps() {
+		if err := step.run(ctx, platform); err != nil {
+			if len(e.Steps) == 0 {
+				return err
+			}
+			return fmt.Errorf(`step "%s": %w`, step.Name, err)
+		}
+	}
+	return nil
+}
+
+// run executes the step in a nested transaction, which is committed unless the
+// action fails or the step is rolled back.
+func (s *TestStep) run(ctx context.Context, platform *Platform) error {
+	blockCtx, err := s.blockContext()
+	if err != nil {
+		return err
+	}
+
 	caller := string(deployer)
-	if e.Caller != "" {
-		caller = e.Caller
+	if s.Caller != "" {
+		caller = s.Caller
 	}
 
-	// log to help users debug failed tests
-	platform.Logger.Logf(`executing action "%s" against namespace "%s"`, e.Action, e.Namespace)
+	tx, err := platform.DB.BeginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) // no-op if committed
 
-	var results [][]any
-	res, err := platform.Engine.Call(&common.EngineContext{
-		TxContext: &common.TxContext{
-			Ctx:    ctx,
-			Signer: []byte(caller),
-			Caller: caller,
-			TxID:   platform.Txid(),
-			BlockContext: &common.BlockContext{
-				Height: e.Height,
-				ChainContext: &common.ChainContext{
-					MigrationParams:   &common.MigrationContext{},
-					NetworkParameters: &common.NetworkParameters{},
-				},
+	var receivedErr error
+	if s.Action != "" {
+		// log to help users debug failed tests
+		platform.Logger.Logf(`executing action "%s" against namespace "%s"`, s.Action, s.Namespace)
+
+		var results [][]any
+		res, err := platform.Engine.Call(&common.EngineContext{
+			TxContext: &common.TxContext{
+				Ctx:          ctx,
+				Signer:       []byte(caller),
+				Caller:       caller,
+				TxID:         platform.Txid(),
+				BlockContext: blockCtx,
 			},
-		},
-		OverrideAuthz: true,
-	}, platform.DB, e.Namespace, e.Action, e.Args, func(r *common.Row) error {
-		results = append(results, r.Values)
-		return nil
-	})
+			OverrideAuthz: true,
+		}, tx, s.Namespace, s.Action, s.Args, func(r *common.Row) error {
+			results = append(results, r.Values)
+			return nil
+		})
 
-	// the received error will usually be returns as part of res,
-	// but there are times where it might be returned as a separate error
-	// (e.g. in case of an extension erroring).
-	// Therefore, we need to check both.
-	var receivedErr error
-	if err != nil {
-		receivedErr = err
-	} else if res.Error != nil {
-		receivedErr = res.Error
+		// the received error will usually be returns as part of res,
+		// but there are times where it might be returned as a separate error
+		// (e.g. in case of an extension erroring).
+		// Therefore, we need to check both.
+		if err != nil {
+			receivedErr = err
+		} else if res.Error != nil {
+			receivedErr = res.Error
+		}
+
+		if err = s.checkResult(receivedErr, results); err != nil {
+			return err
+		}
+
+		if s.Notices != nil && res != nil {
+			if err = compareNotices(s.Notices, res.Logs); err != nil {
+				return err
+			}
+		}
+	}
+
+	// a failed action leaves the transaction unusable, so the state is
+	// checked after discarding its changes
+	db := sql.DB(tx)
+	if receivedErr != nil {
+		if err = tx.Rollback(ctx); err != nil {
+			return err
+		}
+		db = platform.DB
 	}
 
+	for i, assertion := range s.State {
+		if err = assertion.check(ctx, platform, db, blockCtx); err != nil {
+			return fmt.Errorf("state assertion %d: %w", i+1, err)
+		}
+	}
+
+	if receivedErr != nil || s.Rollback {
+		return tx.Rollback(ctx)
+	}
+	return tx.Commit(ctx)
+}
+
+// checkResult checks the error or the rows returned by the action.
+func (s *TestStep) checkResult(receivedErr error, results [][]any) error {
 	// check for an execution error
 	if receivedErr != nil {
 		// if error is not nil, the test should only pass if either
 		// Err or ErrMsg or both is set
 		expectsErr := false
-		if e.Err != nil {
+		if s.Err != nil {
 			expectsErr = true
-			errTypeName := reflect.TypeOf(e.Err).Elem().Name()
-			if !errors.Is(err, e.Err) {
-				return fmt.Errorf(`expected error of type "%s", received error: %w`, errTypeName, err)
+			errTypeName := reflect.TypeOf(s.Err).Elem().Name()
+			if !errors.Is(receivedErr, s.Err) {
+				return fmt.Errorf(`expected error of type "%s", received error: %w`, errTypeName, receivedErr)
 			}
 		}
-		if e.ErrMsg != "" {
+		if s.ErrMsg != "" {
 			expectsErr = true
-			if !strings.Contains(receivedErr.Error(), e.ErrMsg) {
-				return fmt.Errorf(`expected error message to contain substring "%s", received error: %w`, e.ErrMsg, err)
+			if !strings.Contains(receivedErr.Error(), s.ErrMsg) {
+				return fmt.Errorf(`expected error message to contain substring "%s", received error: %w`, s.ErrMsg, receivedErr)
 			}
 		}
 
 		if !expectsErr {
-			return fmt.Errorf(`unexpected error: %w`, err)
+			return fmt.Errorf(`unexpected error: %w`, receivedErr)
 		}
 
 		return nil
 	}
 
-	if len(results) != len(e.Returns) {
-		return fmt.Errorf("expected %d rows to be returned, received %d", len(e.Returns), len(results))
+	if s.Err != nil || s.ErrMsg != "" {
+		return errors.New("expected an error, but the action succeeded")
+	}
+
+	return compareRows(s.Returns, results)
+}
+
+// blockContext creates the block context of the step.
+func (s *TestStep) blockContext() (*common.BlockContext, error) {
+	blockCtx := &common.BlockContext{
+		Height:    s.Height,
+		Timestamp: s.Timestamp,
+		ChainContext: &common.ChainContext{
+			ChainID:           s.ChainID,
+			MigrationParams:   &common.MigrationContext{},
+			NetworkParameters: &common.NetworkParameters{},
+		},
+	}
+
+	if s.Proposer != "" {
+		pubKeyHex, keyTypeStr, found := strings.Cut(s.Proposer, "#")
+		keyType := crypto.KeyTypeSecp256k1
+		if found {
+			var err error
+			if keyType, err = crypto.ParseKeyType(keyTypeStr); err != nil {
+				return nil, fmt.Errorf("invalid proposer key type: %w", err)
+			}
+		}
+		pubKeyBts, err := hex.DecodeString(pubKeyHex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proposer public key: %w", err)
+		}
+		blockCtx.Proposer, err = crypto.UnmarshalPublicKey(pubKeyBts, keyType)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proposer public key: %w", err)
+		}
+	}
+
+	return blockCtx, nil
+}
+
+// check runs the query and compares the rows to the expected rows.
+func (a *StateAssertion) check(ctx context.Context, platform *Platform, db sql.DB, blockCtx *common.BlockContext) error {
+	var results [][]any
+	err := platform.Engine.Execute(&common.EngineContext{
+		TxContext: &common.TxContext{
+			Ctx:          ctx,
+			Signer:       platform.Deployer,
+			Caller:       string(platform.Deployer),
+			TxID:         platform.Txid(),
+			BlockContext: blockCtx,
+		},
+		OverrideAuthz: true,
+	}, db, a.Query, a.Params, func(r *common.Row) error {
+		results = append(results, r.Values)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("error running query %q: %w", a.Query, err)
+	}
+
+	return compareRows(a.Returns, results)
+}
+
+// compareRows compares the received rows to the expected rows.
+func compareRows(expected, results [][]any) error {
+	if len(results) != len(expected) {
+		return fmt.Errorf("expected %d rows to be returned, received %d", len(expected), len(results))
 	}
 
 	for i, row := range results {
-		if len(row) != len(e.Returns[i]) {
-			return fmt.Errorf("expected %d columns to be returned, received %d", len(e.Returns[i]), len(row))
+		if len(row) != len(expected[i]) {
+			return fmt.Errorf("expected %d columns to be returned, received %d", len(expected[i]), len(row))
 		}
 
 		for j, col := range row {
-			if !assert.ObjectsAreEqualValues(e.Returns[i][j], col) {
+			if !assert.ObjectsAreEqualValues(expected[i][j], col) {
 				// add 1 to row and column index since they are 0 indexed.
-				return fmt.Errorf(`incorrect value for expected result: row %d, column %d. expected "%v", received "%v"`, i+1, j+1, e.Returns[i][j], col)
+				return fmt.Errorf(`incorrect value for expected result: row %d, column %d. expected "%v", received "%v"`, i+1, j+1, expected[i][j], col)
 			}
 		}
 	}
@@ -392,6 +658,20 @@ func (e *TestCase) runExecution(ctx context.Context, platform *Platform) error {
 	return nil
 }
 
+// compareNotices compares the notices logged by an action to the expected
+// notices.
+func compareNotices(expected, logs []string) error {
+	if len(logs) != len(expected) {
+		return fmt.Errorf("expected %d notices, received %d: %q", len(expected), len(logs), logs)
+	}
+	for i, log := range logs {
+		if log != expected[i] {
+			return fmt.Errorf(`incorrect notice %d. expected "%s", received "%s"`, i+1, expected[i], log)
+		}
+	}
+	return nil
+}
+
 // Platform provides utilities and info for usage in test functions.
 // It allows users to access the database engine, get information about the
 // schema deployers, control transactions, or even directly access PostgreSQL.
@@ -435,6 +715,10 @@ func (p *Platform) Txid() string {
 
 // runWithPostgres runs the callback function with a postgres container.
 func runWithPostgres(ctx context.Context, opts *Options, fn func(context.Context, *pg.DB, Logger) error) (err error) {
+	if opts.EmbeddedPostgres != nil {
+		return runWithEmbeddedPostgres(ctx, opts.EmbeddedPostgres, opts.Logger, fn)
+	}
+
 	if !opts.UseTestContainer {
 		db, err := pg.NewDB(ctx, &pg.DBConfig{
 			PoolConfig: pg.PoolConfig{
@@ -517,7 +801,13 @@ func runWithPostgres(ctx context.Context, opts *Options, fn func(context.Context
 
 	opts.Logger.Logf("running test container: %s", string(out))
 
-	db, err := connectWithRetry(ctx, port, 10) // might take a while to start up on slower machines
+	db, err := connectWithRetry(ctx, &ConnConfig{
+		Host:   "localhost",
+		Port:   port,
+		User:   "kwild",
+		Pass:   "kwild", // would be ignored if pg_hba.conf set with trust
+		DBName: "kwil_test_db",
+	}, 10) // might take a while to start up on slower machines
 	if err != nil {
 		return fmt.Errorf("error connecting to database: %w", err)
 	}
@@ -593,7 +883,7 @@ func dockerStartArgs(port string) (args []string) {
 
 // connectWithRetry tries to connect to Postgres, and will retry n times at
 // 1 second intervals if it fails.
-func connectWithRetry(ctx context.Context, port string, n int) (*pg.DB, error) {
+func connectWithRetry(ctx context.Context, conn *ConnConfig, n int) (*pg.DB, error) {
 	var db *pg.DB
 	var err error
 
@@ -602,11 +892,11 @@ func connectWithRetry(ctx context.Context, port string, n int) (*pg.DB, error) {
 			PoolConfig: pg.PoolConfig{
 				MaxConns: 11,
 				ConnConfig: pg.ConnConfig{
-					Host:   "localhost",
-					Port:   port,
-					User:   "kwild",
-					Pass:   "kwild", // would be ignored if pg_hba.conf set with trust
-					DBName: "kwil_test_db",
+					Host:   conn.Host,
+					Port:   conn.Port,
+					User:   conn.User,
+					Pass:   conn.Pass,
+					DBName: conn.DBName,
 				},
 			},
 		})
@@ -624,7 +914,7 @@ func connectWithRetry(ctx context.Context, port string, n int) (*pg.DB, error) {
 }
 
 // Options configures optional parameters for running the test.
-// Either UseTestContainer should be true, or a valid
+// Exactly one of UseTestContainer, EmbeddedPostgres, or a valid
 // PostgreSQL connection should be specified.
 type Options struct {
 	// UseTestContainer specifies whether the test should setup and
@@ -633,8 +923,14 @@ type Options struct {
 	// Conn specifies a manually setup Postgres connection that the
 	// test can connect to.
 	Conn *ConnConfig
+	// EmbeddedPostgres runs a Postgres server from locally installed
+	// binaries for the duration of the test, without Docker.
+	EmbeddedPostgres *EmbeddedPostgres
 	// Logger is a logger to be used in the test
 	Logger Logger
+	// Coverage, if not nil, records the statement and branch coverage of
+	// the actions created by the seed scripts and statements.
+	Coverage *Coverage
 	// ReplaceExistingContainer is a callback function that is called when
 	// a conflicting container name is already in use. If it returns
 	// true, then the container will be removed and recreated. If it
@@ -654,12 +950,23 @@ type ConnConfig struct {
 }
 
 func (d *Options) valid() error {
-	if d.UseTestContainer && d.Conn != nil {
-		return fmt.Errorf("test cannot both use a test container and specify a Postgres connection")
+	n := 0
+	if d.UseTestContainer {
+		n++
+	}
+	if d.Conn != nil {
+		n++
+	}
+	if d.EmbeddedPostgres != nil {
+		n++
+	}
+
+	if n > 1 {
+		return fmt.Errorf("test can only use one of a test container, a Postgres connection, or embedded Postgres")
 	}
 
-	if !d.UseTestContainer && d.Conn == nil {
-		return fmt.Errorf("test must either use a test container or specify a Postgres connection")
+	if n == 0 {
+		return fmt.Errorf("test must either use a test container, specify a Postgres connection, or use embedded Postgres")
 	}
 
 	return nil
@@ -3,6 +3,7 @@
 package testing
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"testing"
@@ -82,3 +83,143 @@ func Test_Testing(t *testing.T) {
 		},
 	})
 }
+
+// testing multi-step tests with notices and state assertions
+func Test_TestingSteps(t *testing.T) {
+	RunSchemaTest(t, SchemaTest{
+		Name: "testing steps",
+		SeedStatements: []string{
+			`CREATE TABLE users (id int primary key, name text not null);`,
+			`CREATE ACTION add_user($id int, $name text) public {
+				INSERT INTO users (id, name) VALUES ($id, $name);
+				notice('added ' || $name);
+			}`,
+			`CREATE ACTION block_info() public view returns (height int, ts int) {
+				return @height, @block_timestamp;
+			}`,
+		},
+		TestCases: []TestCase{
+			{
+				Name:    "action with notices and state",
+				Action:  "add_user",
+				Args:    []any{1, "alice"},
+				Notices: []string{"added alice"},
+				State: []StateAssertion{
+					{
+						Query:   "SELECT name FROM users WHERE id = $id",
+						Params:  map[string]any{"$id": 1},
+						Returns: [][]any{{"alice"}},
+					},
+				},
+			},
+			{
+				Name:      "steps share state",
+				Height:    5,
+				Timestamp: 1000,
+				Steps: []TestStep{
+					{
+						Action: "add_user",
+						Args:   []any{1, "alice"},
+					},
+					{
+						Name:   "duplicate is rolled back",
+						Action: "add_user",
+						Args:   []any{1, "bob"},
+						ErrMsg: "duplicate key",
+						State: []StateAssertion{
+							{Query: "SELECT name FROM users", Returns: [][]any{{"alice"}}},
+						},
+					},
+					{
+						Name:     "rollback discards changes",
+						Action:   "add_user",
+						Args:     []any{2, "bob"},
+						Rollback: true,
+						State: []StateAssertion{
+							{Query: "SELECT count(*) FROM users", Returns: [][]any{{2}}},
+						},
+					},
+					{
+						Name:    "block context is inherited",
+						Action:  "block_info",
+						Returns: [][]any{{5, 1000}},
+						State: []StateAssertion{
+							{Query: "SELECT count(*) FROM users", Returns: [][]any{{1}}},
+						},
+					},
+				},
+			},
+		},
+	}, &Options{
+		Conn: &ConnConfig{
+			Host:   "127.0.0.1",
+			Port:   "5432",
+			User:   "kwild",
+			Pass:   "kwild", // would be ignored if pg_hba.conf set with trust
+			DBName: "kwil_test_db",
+		},
+	})
+}
+
+// testing coverage reporting
+func Test_TestingCoverage(t *testing.T) {
+	cov := NewCoverage()
+	RunSchemaTest(t, SchemaTest{
+		Name: "coverage",
+		SeedStatements: []string{
+			`
+CREATE ACTION classify($a int) public view returns (res text) {
+	if $a > 0 {
+		return 'positive';
+	}
+	return 'not positive';
+}`,
+		},
+		TestCases: []TestCase{
+			{
+				Name:    "positive",
+				Action:  "classify",
+				Args:    []any{1},
+				Returns: [][]any{{"positive"}},
+			},
+		},
+	}, &Options{
+		Coverage: cov,
+		Conn: &ConnConfig{
+			Host:   "127.0.0.1",
+			Port:   "5432",
+			User:   "kwild",
+			Pass:   "kwild", // would be ignored if pg_hba.conf set with trust
+			DBName: "kwil_test_db",
+		},
+	})
+
+	sum := cov.Summary()
+	require.Len(t, sum.Actions, 1)
+	require.Equal(t, "coverage/seed_statements[0]", sum.Actions[0].Source)
+	require.Equal(t, 2, sum.Actions[0].Line)
+	require.Equal(t, 3, sum.Statements)
+	require.Equal(t, 2, sum.StatementsCovered)
+	require.Equal(t, 2, sum.Branches)
+	require.Equal(t, 1, sum.BranchesCovered)
+
+	var buf bytes.Buffer
+	require.NoError(t, cov.WriteLCOV(&buf, "coverage"))
+	require.Equal(t, `TN:coverage
+SF:coverage/seed_statements[0]
+FN:2,classify
+FNDA:1,classify
+FNF:1
+FNH:1
+BRDA:3,0,0,1
+BRDA:3,0,1,0
+BRF:2
+BRH:1
+DA:3,1
+DA:4,1
+DA:6,0
+LF:3
+LH:2
+end_of_record
+`, buf.String())
+}